@@ -20,6 +20,14 @@ type AgentQARequest struct {
 	WebSearchEnabled bool     `json:"web_search_enabled"`           // Whether to enable web search
 	SummaryModelID   string   `json:"summary_model_id,omitempty"`   // Optional summary model override
 	MCPServiceIDs    []string `json:"mcp_service_ids,omitempty"`    // Optional MCP service allow list
+
+	// Optional JSON schema, the final answer is additionally returned as an object conforming to it
+	ResponseSchema *ResponseSchema `json:"response_schema,omitempty"`
+
+	// Optional answer to regenerate on a new branch, the query of its question is used if Query is empty
+	RegenerateMessageID string `json:"regenerate_message_id,omitempty"`
+	// Optional question to replace by Query on a new branch
+	EditMessageID string `json:"edit_message_id,omitempty"`
 }
 
 // AgentResponseType defines the type of agent response
@@ -33,6 +41,10 @@ const (
 	AgentResponseTypeAnswer     AgentResponseType = "answer"
 	AgentResponseTypeReflection AgentResponseType = "reflection"
 	AgentResponseTypeError      AgentResponseType = "error"
+	// AgentResponseTypeQueryExpansion carries the queries generated for retrieval (HyDE, multi-query) in Data
+	AgentResponseTypeQueryExpansion AgentResponseType = "query_expansion"
+	// AgentResponseTypeSubAgent carries the progress of a sub-agent running a delegated task in Data
+	AgentResponseTypeSubAgent AgentResponseType = "sub_agent"
 )
 
 // AgentStreamResponse agent streaming response
@@ -66,7 +78,7 @@ func (c *Client) AgentQAStreamWithRequest(ctx context.Context,
 	if request == nil {
 		return fmt.Errorf("agent QA request cannot be nil")
 	}
-	if strings.TrimSpace(request.Query) == "" {
+	if strings.TrimSpace(request.Query) == "" && request.RegenerateMessageID == "" {
 		return fmt.Errorf("agent QA query cannot be empty")
 	}
 
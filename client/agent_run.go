@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AgentRunStatus is the status of an agent run
+type AgentRunStatus string
+
+const (
+	AgentRunStatusRunning   AgentRunStatus = "running"
+	AgentRunStatusCompleted AgentRunStatus = "completed"
+	AgentRunStatusStopped   AgentRunStatus = "stopped"
+	AgentRunStatusFailed    AgentRunStatus = "failed"
+)
+
+// AgentRun is an agent execution checkpointed after every round
+type AgentRun struct {
+	ID           string          `json:"id"`
+	SessionID    string          `json:"session_id"`
+	MessageID    string          `json:"message_id"`
+	Query        string          `json:"query"`
+	Status       AgentRunStatus  `json:"status"`
+	CurrentRound int             `json:"current_round"` // Number of completed rounds
+	Checkpoint   json.RawMessage `json:"checkpoint,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	Resumable    bool            `json:"resumable"` // Whether the run can be resumed now
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// AgentRunListResponse wraps the agent run list response
+type AgentRunListResponse struct {
+	Success bool        `json:"success"`
+	Data    []*AgentRun `json:"data"`
+}
+
+// AgentRunResponse wraps the agent run response
+type AgentRunResponse struct {
+	Success bool      `json:"success"`
+	Data    *AgentRun `json:"data"`
+}
+
+// ListAgentRuns lists the unfinished agent runs of the tenant
+func (c *Client) ListAgentRuns(ctx context.Context) ([]*AgentRun, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/agent-runs", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentRunListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetAgentRun gets an agent run including its last checkpoint
+func (c *Client) GetAgentRun(ctx context.Context, runID string) (*AgentRun, error) {
+	path := fmt.Sprintf("/api/v1/agent-runs/%s", runID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AgentRunResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ResumeAgentRun resumes an interrupted agent run from its last checkpoint and streams the rest of it
+func (c *Client) ResumeAgentRun(ctx context.Context, runID string, callback AgentEventCallback) error {
+	path := fmt.Sprintf("/api/v1/agent-runs/%s/resume", runID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+	}
+
+	return c.processAgentSSEStream(resp.Body, callback)
+}
@@ -43,10 +43,12 @@ type EvaluationResult struct {
 // EvaluationRequest represents an evaluation request
 // Parameters used to start a new evaluation task
 type EvaluationRequest struct {
-	DatasetID        string `json:"dataset_id"`   // Dataset ID to evaluate
-	EmbeddingModelID string `json:"embedding_id"` // Embedding model ID
-	ChatModelID      string `json:"chat_id"`      // Chat model ID
-	RerankModelID    string `json:"rerank_id"`    // Reranking model ID
+	DatasetID        string `json:"dataset_id"`         // Dataset ID to evaluate
+	EmbeddingModelID string `json:"embedding_id"`       // Embedding model ID
+	ChatModelID      string `json:"chat_id"`            // Chat model ID
+	RerankModelID    string `json:"rerank_id"`          // Reranking model ID
+	EnableHyDE       bool   `json:"enable_hyde"`        // Retrieve with a hypothetical answer (HyDE)
+	EnableMultiQuery bool   `json:"enable_multi_query"` // Retrieve with query paraphrases
 }
 
 // EvaluationTaskResponse represents an evaluation task response
@@ -263,3 +263,113 @@ func (c *Client) SearchFAQEntries(ctx context.Context,
 
 	return response.Data, nil
 }
+
+// FAQ promotion modes
+const (
+	FAQPromotionModeAuto    = "auto"
+	FAQPromotionModeNew     = "new"
+	FAQPromotionModeSimilar = "similar"
+)
+
+// FAQPromotionRequest promotes the exchange of an assistant message into a FAQ knowledge base.
+type FAQPromotionRequest struct {
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id"`
+	// Question and Answer override the question and answer of the exchange, optional
+	Question string `json:"question,omitempty"`
+	Answer   string `json:"answer,omitempty"`
+	// Mode is auto (default), new or similar
+	Mode string `json:"mode,omitempty"`
+	// EntryID is the entry receiving the question in similar mode, optional
+	EntryID   string  `json:"entry_id,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Force     bool    `json:"force,omitempty"`
+	TagID     string  `json:"tag_id,omitempty"`
+	DryRun    bool    `json:"dry_run,omitempty"`
+}
+
+// FAQPromotion is the audit record of a promotion.
+type FAQPromotion struct {
+	ID              string    `json:"id"`
+	TenantID        uint64    `json:"tenant_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	SessionID       string    `json:"session_id"`
+	MessageID       string    `json:"message_id"`
+	EntryID         string    `json:"entry_id"`
+	Action          string    `json:"action"`
+	Question        string    `json:"question"`
+	Answer          string    `json:"answer"`
+	Similarity      float64   `json:"similarity"`
+	ReviewerID      string    `json:"reviewer_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// FAQPromotionResult is the outcome of a promotion, or the plan of a dry run.
+type FAQPromotionResult struct {
+	Action     string        `json:"action"`
+	DryRun     bool          `json:"dry_run"`
+	Question   string        `json:"question"`
+	Answer     string        `json:"answer"`
+	Entry      *FAQEntry     `json:"entry,omitempty"`
+	Candidates []FAQEntry    `json:"candidates"`
+	Promotion  *FAQPromotion `json:"promotion,omitempty"`
+}
+
+// FAQPromotionsPage contains paginated promotion records.
+type FAQPromotionsPage struct {
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+	Promotions []FAQPromotion `json:"data"`
+}
+
+// PromoteFAQExchange adds a QA exchange to a FAQ knowledge base as a new entry or as a similar question.
+func (c *Client) PromoteFAQExchange(ctx context.Context,
+	knowledgeBaseID string, payload *FAQPromotionRequest,
+) (*FAQPromotionResult, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/faq/promote", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                `json:"success"`
+		Data    *FAQPromotionResult `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ListFAQPromotions returns the paginated promotions into a FAQ knowledge base, newest first.
+func (c *Client) ListFAQPromotions(ctx context.Context,
+	knowledgeBaseID string, page, pageSize int,
+) (*FAQPromotionsPage, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/faq/promotions", knowledgeBaseID)
+	query := url.Values{}
+	if page > 0 {
+		query.Add("page", strconv.Itoa(page))
+	}
+	if pageSize > 0 {
+		query.Add("page_size", strconv.Itoa(pageSize))
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool               `json:"success"`
+		Data    *FAQPromotionsPage `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	if response.Data == nil {
+		return &FAQPromotionsPage{}, nil
+	}
+	return response.Data, nil
+}
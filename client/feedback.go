@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Feedback ratings of an answer
+const (
+	FeedbackRatingUp   = "up"
+	FeedbackRatingDown = "down"
+)
+
+// FeedbackRequest rates an assistant message
+type FeedbackRequest struct {
+	// Rating is up or down
+	Rating string `json:"rating"`
+	// Correction is the corrected answer, optional
+	Correction string `json:"correction,omitempty"`
+	// UsefulChunkIDs lists the references of the message that were useful, optional
+	UsefulChunkIDs []string `json:"useful_chunk_ids,omitempty"`
+}
+
+// FeedbackChunk is the vote of a feedback on a chunk referenced by the message
+type FeedbackChunk struct {
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+	ChunkID         string `json:"chunk_id"`
+	// Vote is 1 for a useful chunk, -1 for a chunk of a bad answer and 0 otherwise
+	Vote int `json:"vote"`
+}
+
+// MessageFeedback is the feedback on an assistant message
+type MessageFeedback struct {
+	ID             string           `json:"id"`
+	TenantID       uint64           `json:"tenant_id"`
+	SessionID      string           `json:"session_id"`
+	MessageID      string           `json:"message_id"`
+	Rating         string           `json:"rating"`
+	Correction     string           `json:"correction"`
+	UsefulChunkIDs []string         `json:"useful_chunk_ids"`
+	Query          string           `json:"query"`
+	Answer         string           `json:"answer"`
+	Chunks         []*FeedbackChunk `json:"chunks"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// ChunkFeedbackStats is the feedback aggregated over a chunk, the score is between -1 and 1
+type ChunkFeedbackStats struct {
+	ChunkID     string  `json:"chunk_id"`
+	KnowledgeID string  `json:"knowledge_id"`
+	Positive    int64   `json:"positive"`
+	Negative    int64   `json:"negative"`
+	Score       float64 `json:"score"`
+}
+
+// KnowledgeFeedbackStats is the feedback aggregated over the chunks of a knowledge
+type KnowledgeFeedbackStats struct {
+	KnowledgeID    string  `json:"knowledge_id"`
+	KnowledgeTitle string  `json:"knowledge_title"`
+	Positive       int64   `json:"positive"`
+	Negative       int64   `json:"negative"`
+	Score          float64 `json:"score"`
+}
+
+// FeedbackSummary counts the feedback on answers referencing a knowledge base
+type FeedbackSummary struct {
+	Total       int64 `json:"total"`
+	Up          int64 `json:"up"`
+	Down        int64 `json:"down"`
+	Corrections int64 `json:"corrections"`
+}
+
+// FeedbackDashboard is the feedback of a knowledge base, knowledge and chunks are listed worst first
+type FeedbackDashboard struct {
+	KnowledgeBaseID string                    `json:"knowledge_base_id"`
+	Summary         FeedbackSummary           `json:"summary"`
+	Knowledge       []*KnowledgeFeedbackStats `json:"knowledge"`
+	Chunks          []*ChunkFeedbackStats     `json:"chunks"`
+}
+
+// FeedbackDatasetExport describes an evaluation dataset exported from feedback,
+// DatasetID can be passed to StartEvaluation
+type FeedbackDatasetExport struct {
+	DatasetID     string `json:"dataset_id"`
+	QuestionCount int    `json:"question_count"`
+	PassageCount  int    `json:"passage_count"`
+	Skipped       int    `json:"skipped"`
+}
+
+// MessageFeedbackResponse wraps the message feedback response
+type MessageFeedbackResponse struct {
+	Success bool             `json:"success"`
+	Data    *MessageFeedback `json:"data"`
+}
+
+// SubmitFeedback rates an assistant message, replacing earlier feedback on it
+func (c *Client) SubmitFeedback(ctx context.Context,
+	sessionID string, messageID string, request *FeedbackRequest,
+) (*MessageFeedback, error) {
+	path := fmt.Sprintf("/api/v1/messages/%s/%s/feedback", sessionID, messageID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response MessageFeedbackResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetFeedback gets the feedback on a message
+func (c *Client) GetFeedback(ctx context.Context, sessionID string, messageID string) (*MessageFeedback, error) {
+	path := fmt.Sprintf("/api/v1/messages/%s/%s/feedback", sessionID, messageID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response MessageFeedbackResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteFeedback deletes the feedback on a message
+func (c *Client) DeleteFeedback(ctx context.Context, sessionID string, messageID string) error {
+	path := fmt.Sprintf("/api/v1/messages/%s/%s/feedback", sessionID, messageID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// GetFeedbackDashboard aggregates the feedback on a knowledge base, limit <= 0 uses the server default
+func (c *Client) GetFeedbackDashboard(ctx context.Context,
+	knowledgeBaseID string, limit int,
+) (*FeedbackDashboard, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/feedback", knowledgeBaseID)
+	query := url.Values{}
+	if limit > 0 {
+		query.Add("limit", strconv.Itoa(limit))
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool               `json:"success"`
+		Data    *FeedbackDashboard `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ExportFeedbackDataset saves the corrected and upvoted answers referencing a knowledge base as an
+// evaluation dataset
+func (c *Client) ExportFeedbackDataset(ctx context.Context, knowledgeBaseID string) (*FeedbackDatasetExport, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/feedback/export", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                   `json:"success"`
+		Data    *FeedbackDatasetExport `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
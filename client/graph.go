@@ -0,0 +1,381 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Sources of a graph entity alias
+const (
+	GraphEntityAliasSourceNormalized = "normalized"
+	GraphEntityAliasSourceEmbedding  = "embedding"
+	GraphEntityAliasSourceLLM        = "llm"
+	GraphEntityAliasSourceManual     = "manual"
+)
+
+// GraphEntityAlias maps a name of the knowledge graph of a knowledge base to its canonical entity
+type GraphEntityAlias struct {
+	ID              string    `json:"id"`
+	TenantID        uint64    `json:"tenant_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	Alias           string    `json:"alias"`
+	Canonical       string    `json:"canonical"`
+	Source          string    `json:"source"`
+	Score           float64   `json:"score"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// GraphEntityAliasResponse wraps the graph entity alias response
+type GraphEntityAliasResponse struct {
+	Success bool              `json:"success"`
+	Data    *GraphEntityAlias `json:"data"`
+}
+
+// GraphData is a page of the knowledge graph of a knowledge base
+type GraphData struct {
+	Node     []*GraphNode     `json:"node"`
+	Relation []*GraphRelation `json:"relation"`
+}
+
+// GraphPage is a page of the knowledge graph, entities are ordered by degree
+type GraphPage struct {
+	Total    int64      `json:"total"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+	Data     *GraphData `json:"data"`
+}
+
+// GraphEntitiesPage is a page of the entities of the knowledge graph ordered by degree
+type GraphEntitiesPage struct {
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+	Entities []*GraphNode `json:"data"`
+}
+
+// GraphEntityChunk is a chunk a graph entity was extracted from
+type GraphEntityChunk struct {
+	ID          string `json:"id"`
+	KnowledgeID string `json:"knowledge_id"`
+	Content     string `json:"content"`
+}
+
+// GraphEntityDetail is a graph entity with its relations and source chunks
+type GraphEntityDetail struct {
+	Entity    *GraphNode          `json:"entity"`
+	Relations []*GraphRelation    `json:"relations"`
+	Chunks    []*GraphEntityChunk `json:"chunks"`
+}
+
+// GraphEntityUpdateRequest renames a graph entity or replaces its attributes, nil attributes are kept and
+// empty attributes restore the extracted ones
+type GraphEntityUpdateRequest struct {
+	Name       string   `json:"name"`
+	NewName    string   `json:"new_name,omitempty"`
+	Attributes []string `json:"attributes"`
+}
+
+// GraphCommunity is a community of the knowledge graph with the report written by the summary model
+type GraphCommunity struct {
+	ID          string    `json:"id"`
+	CommunityID string    `json:"community_id"`
+	Level       int       `json:"level"`
+	Parent      string    `json:"parent,omitempty"`
+	Title       string    `json:"title"`
+	Rating      float64   `json:"rating"`
+	Size        int       `json:"size"`
+	Entities    []string  `json:"entities"`
+	Report      string    `json:"report"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ResolveGraphEntities queues the entity resolution of the knowledge, or of the whole knowledge base if
+// knowledgeID is empty
+func (c *Client) ResolveGraphEntities(ctx context.Context, knowledgeBaseID string, knowledgeID string) error {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/resolve", knowledgeBaseID)
+	request := struct {
+		KnowledgeID string `json:"knowledge_id,omitempty"`
+	}{KnowledgeID: knowledgeID}
+	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// ListGraphEntityAliases lists the entity aliases of the knowledge graph of a knowledge base
+func (c *Client) ListGraphEntityAliases(ctx context.Context, knowledgeBaseID string) ([]*GraphEntityAlias, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/aliases", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                `json:"success"`
+		Data    []*GraphEntityAlias `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// AddGraphEntityAlias adds a manual alias and merges the nodes of the alias into the canonical entity
+func (c *Client) AddGraphEntityAlias(ctx context.Context,
+	knowledgeBaseID string, alias string, canonical string,
+) (*GraphEntityAlias, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/aliases", knowledgeBaseID)
+	request := map[string]string{"alias": alias, "canonical": canonical}
+	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GraphEntityAliasResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteGraphEntityAlias deletes an entity alias, the merged nodes are not split again
+func (c *Client) DeleteGraphEntityAlias(ctx context.Context, knowledgeBaseID string, aliasID string) error {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/aliases/%s", knowledgeBaseID, aliasID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// graphPageQuery builds the query of a page of the knowledge graph
+func graphPageQuery(page, pageSize int, keyword string) url.Values {
+	query := url.Values{}
+	if page > 0 {
+		query.Add("page", strconv.Itoa(page))
+	}
+	if pageSize > 0 {
+		query.Add("page_size", strconv.Itoa(pageSize))
+	}
+	if keyword != "" {
+		query.Add("keyword", keyword)
+	}
+	return query
+}
+
+// BrowseGraph returns a page of the entities of the knowledge graph, most connected first, with the relations
+// among them
+func (c *Client) BrowseGraph(ctx context.Context,
+	knowledgeBaseID string, page, pageSize int, keyword string,
+) (*GraphPage, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, graphPageQuery(page, pageSize, keyword))
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool       `json:"success"`
+		Data    *GraphPage `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ListGraphEntities returns a page of the entities of the knowledge graph whose name contains the keyword
+func (c *Client) ListGraphEntities(ctx context.Context,
+	knowledgeBaseID string, page, pageSize int, keyword string,
+) (*GraphEntitiesPage, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/entities", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, graphPageQuery(page, pageSize, keyword))
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool               `json:"success"`
+		Data    *GraphEntitiesPage `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetGraphEntity returns a graph entity with its relations and source chunks
+func (c *Client) GetGraphEntity(ctx context.Context, knowledgeBaseID string, name string) (*GraphEntityDetail, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/entity", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, url.Values{"name": {name}})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool               `json:"success"`
+		Data    *GraphEntityDetail `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// CreateGraphEntity creates a graph entity
+func (c *Client) CreateGraphEntity(ctx context.Context,
+	knowledgeBaseID string, name string, attributes []string,
+) (*GraphNode, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/entities", knowledgeBaseID)
+	request := &GraphNode{Name: name, Attributes: attributes}
+	return c.doGraphEntityRequest(ctx, http.MethodPost, path, request)
+}
+
+// UpdateGraphEntity renames a graph entity or replaces its attributes
+func (c *Client) UpdateGraphEntity(ctx context.Context,
+	knowledgeBaseID string, request *GraphEntityUpdateRequest,
+) (*GraphNode, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/entities", knowledgeBaseID)
+	return c.doGraphEntityRequest(ctx, http.MethodPut, path, request)
+}
+
+// MergeGraphEntities merges graph entities into the entity named into
+func (c *Client) MergeGraphEntities(ctx context.Context,
+	knowledgeBaseID string, names []string, into string,
+) (*GraphNode, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/entities/merge", knowledgeBaseID)
+	request := struct {
+		Names []string `json:"names"`
+		Into  string   `json:"into"`
+	}{Names: names, Into: into}
+	return c.doGraphEntityRequest(ctx, http.MethodPost, path, request)
+}
+
+// doGraphEntityRequest sends a request returning a graph entity
+func (c *Client) doGraphEntityRequest(ctx context.Context,
+	method, path string, request interface{},
+) (*GraphNode, error) {
+	resp, err := c.doRequest(ctx, method, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool       `json:"success"`
+		Data    *GraphNode `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteGraphEntity deletes a graph entity and its relations
+func (c *Client) DeleteGraphEntity(ctx context.Context, knowledgeBaseID string, name string) error {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/entities", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, url.Values{"name": {name}})
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// CreateGraphRelation creates a relation between two graph entities
+func (c *Client) CreateGraphRelation(ctx context.Context,
+	knowledgeBaseID string, source, target, relationType string,
+) (*GraphRelation, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/relations", knowledgeBaseID)
+	request := map[string]string{"source": source, "target": target, "type": relationType}
+	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    *GraphRelation `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteGraphRelation deletes a relation between two graph entities
+func (c *Client) DeleteGraphRelation(ctx context.Context,
+	knowledgeBaseID string, source, target, relationType string,
+) error {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/relations", knowledgeBaseID)
+	query := url.Values{"source": {source}, "target": {target}, "type": {relationType}}
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, query)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// RebuildGraphCommunities queues the detection of the communities of the knowledge graph and their reports
+func (c *Client) RebuildGraphCommunities(ctx context.Context, knowledgeBaseID string) error {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/communities", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// ListGraphCommunities lists the communities of the knowledge graph with their reports, of the level only
+// if level is not nil
+func (c *Client) ListGraphCommunities(ctx context.Context,
+	knowledgeBaseID string, level *int,
+) ([]*GraphCommunity, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/graph/communities", knowledgeBaseID)
+	query := url.Values{}
+	if level != nil {
+		query.Set("level", strconv.Itoa(*level))
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool              `json:"success"`
+		Data    []*GraphCommunity `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -13,22 +13,24 @@ import (
 
 // KnowledgeBase represents a knowledge base
 type KnowledgeBase struct {
-	ID                    string                `json:"id"`
-	Name                  string                `json:"name"` // Name must be unique within the same tenant
-	Type                  string                `json:"type"`
-	IsTemporary           bool                  `json:"is_temporary"`
-	Description           string                `json:"description"`
-	TenantID              uint64                `json:"tenant_id"`
-	ChunkingConfig        ChunkingConfig        `json:"chunking_config"`
-	ImageProcessingConfig ImageProcessingConfig `json:"image_processing_config"`
-	FAQConfig             *FAQConfig            `json:"faq_config"`
-	EmbeddingModelID      string                `json:"embedding_model_id"`
-	SummaryModelID        string                `json:"summary_model_id"`
-	VLMConfig             VLMConfig             `json:"vlm_config"`
-	StorageConfig         StorageConfig         `json:"cos_config"`
-	ExtractConfig         *ExtractConfig        `json:"extract_config"`
-	CreatedAt             time.Time             `json:"created_at"`
-	UpdatedAt             time.Time             `json:"updated_at"`
+	ID                     string                  `json:"id"`
+	Name                   string                  `json:"name"` // Name must be unique within the same tenant
+	Type                   string                  `json:"type"`
+	IsTemporary            bool                    `json:"is_temporary"`
+	Description            string                  `json:"description"`
+	TenantID               uint64                  `json:"tenant_id"`
+	ChunkingConfig         ChunkingConfig          `json:"chunking_config"`
+	ImageProcessingConfig  ImageProcessingConfig   `json:"image_processing_config"`
+	FAQConfig              *FAQConfig              `json:"faq_config"`
+	EmbeddingModelID       string                  `json:"embedding_model_id"`
+	SummaryModelID         string                  `json:"summary_model_id"`
+	VLMConfig              VLMConfig               `json:"vlm_config"`
+	StorageConfig          StorageConfig           `json:"cos_config"`
+	ExtractConfig          *ExtractConfig          `json:"extract_config"`
+	FusionConfig           *FusionConfig           `json:"fusion_config"`
+	ContextExpansionConfig *ContextExpansionConfig `json:"context_expansion_config"`
+	CreatedAt              time.Time               `json:"created_at"`
+	UpdatedAt              time.Time               `json:"updated_at"`
 	// Computed fields (not stored in database)
 	KnowledgeCount  int64 `json:"knowledge_count"`
 	ChunkCount      int64 `json:"chunk_count"`
@@ -38,16 +40,20 @@ type KnowledgeBase struct {
 
 // KnowledgeBaseConfig represents knowledge base configuration
 type KnowledgeBaseConfig struct {
-	ChunkingConfig        ChunkingConfig        `json:"chunking_config"`
-	ImageProcessingConfig ImageProcessingConfig `json:"image_processing_config"`
-	FAQConfig             *FAQConfig            `json:"faq_config"`
+	ChunkingConfig         ChunkingConfig          `json:"chunking_config"`
+	ImageProcessingConfig  ImageProcessingConfig   `json:"image_processing_config"`
+	FAQConfig              *FAQConfig              `json:"faq_config"`
+	FusionConfig           *FusionConfig           `json:"fusion_config"`
+	ContextExpansionConfig *ContextExpansionConfig `json:"context_expansion_config"`
 }
 
 // ChunkingConfig represents document chunking configuration
 type ChunkingConfig struct {
-	ChunkSize    int      `json:"chunk_size"`    // Chunk size
-	ChunkOverlap int      `json:"chunk_overlap"` // Overlap size
-	Separators   []string `json:"separators"`    // Separators
+	ChunkSize       int      `json:"chunk_size"`                  // Chunk size, the child chunk size in hierarchical mode
+	ChunkOverlap    int      `json:"chunk_overlap"`               // Overlap size
+	Separators      []string `json:"separators"`                  // Separators
+	Mode            string   `json:"mode,omitempty"`              // Chunking mode: flat or hierarchical
+	ParentChunkSize int      `json:"parent_chunk_size,omitempty"` // Parent chunk size in hierarchical mode
 }
 
 // FAQConfig represents faq-specific configuration
@@ -56,6 +62,35 @@ type FAQConfig struct {
 	QuestionIndexMode string `json:"question_index_mode"`
 }
 
+// FusionConfig represents the score fusion configuration for hybrid search
+type FusionConfig struct {
+	Method  string             `json:"method"`            // Fusion method: rrf, weighted or dbsf
+	RRFK    int                `json:"rrf_k,omitempty"`   // Rank constant for rrf, default 60
+	Weights map[string]float64 `json:"weights,omitempty"` // Per-retriever weights keyed by retriever type (vector, keywords)
+}
+
+// ContextExpansionConfig represents the expansion of retrieved chunks to their surrounding context
+type ContextExpansionConfig struct {
+	Mode      string `json:"mode"`                 // Expansion mode: none, neighbor or parent
+	Window    int    `json:"window,omitempty"`     // Neighbours added on each side, default 1, at most 5
+	MaxTokens int    `json:"max_tokens,omitempty"` // Estimated token budget of all retrieved context, default 4000
+}
+
+// MetadataFilter is a filter expression over knowledge attributes.
+// Leaf ops (eq, in, range) compare Field, which is tag_id, file_type, created_at or metadata.<key>;
+// and, or and not combine Filters (exactly one for not). created_at takes RFC3339 or YYYY-MM-DD values.
+type MetadataFilter struct {
+	Op      string            `json:"op"`                // eq, in, range, and, or, not
+	Field   string            `json:"field,omitempty"`   // Field compared by eq, in and range
+	Value   interface{}       `json:"value,omitempty"`   // Operand of eq
+	Values  []interface{}     `json:"values,omitempty"`  // Operands of in
+	Gt      interface{}       `json:"gt,omitempty"`      // Exclusive lower bound of range
+	Gte     interface{}       `json:"gte,omitempty"`     // Inclusive lower bound of range
+	Lt      interface{}       `json:"lt,omitempty"`      // Exclusive upper bound of range
+	Lte     interface{}       `json:"lte,omitempty"`     // Inclusive upper bound of range
+	Filters []*MetadataFilter `json:"filters,omitempty"` // Sub filters of and, or and not
+}
+
 // ImageProcessingConfig represents image processing configuration
 type ImageProcessingConfig struct {
 	ModelID string `json:"model_id"` // Multimodal model ID
@@ -87,9 +122,12 @@ type ExtractConfig struct {
 	Relations []*GraphRelation `json:"relations,omitempty"`
 }
 
-// GraphNode represents a node in the graph extraction configuration
+// GraphNode represents a node in the graph extraction configuration or in the knowledge graph
 type GraphNode struct {
-	Name string `json:"name"`
+	Name       string   `json:"name"`
+	Chunks     []string `json:"chunks,omitempty"`
+	Attributes []string `json:"attributes,omitempty"`
+	Degree     int      `json:"degree,omitempty"`
 }
 
 // GraphRelation represents a relation in the graph extraction configuration
@@ -236,6 +274,10 @@ type SearchParams struct {
 	MatchCount           int     `json:"match_count"`
 	DisableKeywordsMatch bool    `json:"disable_keywords_match"`
 	DisableVectorMatch   bool    `json:"disable_vector_match"`
+	// Fusion overrides the knowledge base's fusion config when set
+	Fusion *FusionConfig `json:"fusion,omitempty"`
+	// Filter restricts the search to knowledge matching the metadata filter
+	Filter *MetadataFilter `json:"filter,omitempty"`
 }
 
 // HybridSearch performs hybrid search
@@ -5,6 +5,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -40,16 +41,33 @@ type AgentStep struct {
 
 // Message message information
 type Message struct {
-	ID                  string          `json:"id"`
-	SessionID           string          `json:"session_id"`
-	RequestID           string          `json:"request_id"`
-	Content             string          `json:"content"`
-	Role                string          `json:"role"`
-	KnowledgeReferences []*SearchResult `json:"knowledge_references"`
-	AgentSteps          []AgentStep     `json:"agent_steps,omitempty"` // Agent execution steps (only for assistant messages)
-	IsCompleted         bool            `json:"is_completed"`
-	CreatedAt           time.Time       `json:"created_at"`
-	UpdatedAt           time.Time       `json:"updated_at"`
+	ID                  string              `json:"id"`
+	SessionID           string              `json:"session_id"`
+	ParentID            string              `json:"parent_id"` // Previous message of its branch
+	RequestID           string              `json:"request_id"`
+	Content             string              `json:"content"`
+	Role                string              `json:"role"`
+	KnowledgeReferences []*SearchResult     `json:"knowledge_references"`
+	AgentSteps          []AgentStep         `json:"agent_steps,omitempty"` // Agent execution steps (only for assistant messages)
+	StructuredOutput    json.RawMessage     `json:"structured_output,omitempty"`
+	CitationMap         *CitationMap        `json:"citation_map,omitempty"`
+	Groundedness        *GroundednessResult `json:"groundedness,omitempty"`
+	IsCompleted         bool                `json:"is_completed"`
+	CreatedAt           time.Time           `json:"created_at"`
+	UpdatedAt           time.Time           `json:"updated_at"`
+}
+
+// MessageTreeNode is a message with the messages following it, each child starts a branch
+type MessageTreeNode struct {
+	Message  Message            `json:"message"`
+	Active   bool               `json:"active"` // Whether the message is on the active branch
+	Children []*MessageTreeNode `json:"children"`
+}
+
+// MessageTree is the branch tree of the messages of a session
+type MessageTree struct {
+	ActiveMessageID string             `json:"active_message_id"` // Last message of the active branch
+	Roots           []*MessageTreeNode `json:"roots"`
 }
 
 // MessageListResponse message list response
@@ -117,3 +135,37 @@ func (c *Client) DeleteMessage(ctx context.Context, sessionID string, messageID
 
 	return parseResponse(resp, &response)
 }
+
+// GetMessageTree gets the branch tree of the messages of a session, regenerated answers and edited
+// questions are siblings of the original message
+func (c *Client) GetMessageTree(ctx context.Context, sessionID string) (*MessageTree, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/branches", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    *MessageTree `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// SwitchBranch activates the branch through a message and returns the messages of the branch
+func (c *Client) SwitchBranch(ctx context.Context, sessionID string, messageID string) ([]Message, error) {
+	path := fmt.Sprintf("/api/v1/sessions/%s/branches/active", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, map[string]string{"message_id": messageID}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response MessageListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -59,9 +59,10 @@ type SessionAgentConfig struct {
 
 // CreateSessionRequest session creation request
 type CreateSessionRequest struct {
-	KnowledgeBaseID string              `json:"knowledge_base_id"` // Associated knowledge base ID (optional in agent mode)
-	SessionStrategy *SessionStrategy    `json:"session_strategy"`  // Session strategy
-	AgentConfig     *SessionAgentConfig `json:"agent_config"`      // Agent configuration (optional, for agent mode)
+	KnowledgeBaseID string              `json:"knowledge_base_id"`  // Associated knowledge base ID (optional in agent mode)
+	SessionStrategy *SessionStrategy    `json:"session_strategy"`   // Session strategy
+	AgentConfig     *SessionAgentConfig `json:"agent_config"`       // Agent configuration (optional, for agent mode)
+	Pipeline        string              `json:"pipeline,omitempty"` // Chat pipeline name (optional, defaults to tenant or system pipeline)
 }
 
 // ContextConfig configures LLM context management
@@ -93,6 +94,8 @@ type Session struct {
 	SummaryParameters *SummaryConfig      `json:"summary_parameters"`
 	AgentConfig       *SessionAgentConfig `json:"agent_config"`   // Agent configuration (optional)
 	ContextConfig     *ContextConfig      `json:"context_config"` // Context management configuration (optional)
+	Pipeline          string              `json:"pipeline"`       // Chat pipeline name (optional)
+	ActiveMessageID   string              `json:"active_message_id"`
 	CreatedAt         string              `json:"created_at"`
 	UpdatedAt         string              `json:"updated_at"`
 }
@@ -227,7 +230,16 @@ func (c *Client) GenerateTitle(ctx context.Context, sessionID string, request *G
 
 // KnowledgeQARequest knowledge Q&A request
 type KnowledgeQARequest struct {
-	Query string `json:"query"`
+	Query  string          `json:"query"`
+	Filter *MetadataFilter `json:"filter,omitempty"` // Optional metadata filter restricting retrieval
+	// Optional JSON schema, the answer is additionally returned as an object conforming to it
+	ResponseSchema *ResponseSchema `json:"response_schema,omitempty"`
+}
+
+// ResponseSchema requests answers as JSON objects conforming to a JSON schema
+type ResponseSchema struct {
+	Name   string          `json:"name,omitempty"` // Optional schema name (letters, digits, "_" and "-")
+	Schema json.RawMessage `json:"schema"`         // JSON schema, its root must be an object
 }
 
 type ResponseType string
@@ -244,6 +256,91 @@ type StreamResponse struct {
 	Content             string          `json:"content"`              // Current content fragment
 	Done                bool            `json:"done"`                 // Whether completed
 	KnowledgeReferences []*SearchResult `json:"knowledge_references"` // Knowledge references
+
+	// Additional event data, the last answer fragment carries the citation map of the answer
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// CitationMap returns the citation map carried by the response, nil if there is none
+func (r *StreamResponse) CitationMap() (*CitationMap, error) {
+	if len(r.Data) == 0 {
+		return nil, nil
+	}
+	var data struct {
+		CitationMap *CitationMap `json:"citation_map"`
+	}
+	if err := json.Unmarshal(r.Data, &data); err != nil {
+		return nil, err
+	}
+	return data.CitationMap, nil
+}
+
+// Groundedness returns the groundedness check result carried by the response, nil if there is none
+func (r *StreamResponse) Groundedness() (*GroundednessResult, error) {
+	if len(r.Data) == 0 {
+		return nil, nil
+	}
+	var data struct {
+		Groundedness *GroundednessResult `json:"groundedness"`
+	}
+	if err := json.Unmarshal(r.Data, &data); err != nil {
+		return nil, err
+	}
+	return data.Groundedness, nil
+}
+
+// Citation is a knowledge chunk an answer cites
+type Citation struct {
+	ChunkID        string `json:"chunk_id"`
+	KnowledgeID    string `json:"knowledge_id"`
+	KnowledgeTitle string `json:"knowledge_title"`
+	StartAt        int    `json:"start_at"` // Character offsets of the chunk in the knowledge
+	EndAt          int    `json:"end_at"`
+}
+
+// CitationMap links the inline citation markers of an answer to the chunks they cite,
+// offsets into the answer are character offsets
+type CitationMap struct {
+	Citations        []Citation       `json:"citations"`         // Cited chunks in order of first citation
+	Markers          []CitationMarker `json:"markers"`           // Citation markers in the answer
+	Sentences        []CitedSentence  `json:"sentences"`         // Verified sentences of the answer
+	UnsupportedCount int              `json:"unsupported_count"` // Number of unsupported sentences
+}
+
+// CitationMarker is a citation marker in an answer, such as "[1]" or <kb chunk_id="..." />
+type CitationMarker struct {
+	Text    string `json:"text"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	ChunkID string `json:"chunk_id,omitempty"` // Empty if the marker cites no retrieved chunk
+}
+
+// CitedSentence is a sentence of an answer verified against the chunks cited for it
+type CitedSentence struct {
+	Text      string   `json:"text"`
+	Start     int      `json:"start"`
+	End       int      `json:"end"`
+	ChunkIDs  []string `json:"chunk_ids,omitempty"`
+	Support   float64  `json:"support"` // Share of the sentence terms found in the cited chunks
+	Supported bool     `json:"supported"`
+	Reason    string   `json:"reason,omitempty"` // uncited, unknown_source or low_overlap
+}
+
+// GroundednessResult is the result of checking an answer against the retrieved chunks
+type GroundednessResult struct {
+	Score    float64        `json:"score"` // Share of claims supported by the retrieved chunks
+	Grounded bool           `json:"grounded"`
+	Judge    string         `json:"judge"`            // llm or rerank
+	Action   string         `json:"action,omitempty"` // regenerate or fallback if the answer was replaced
+	Claims   []ClaimVerdict `json:"claims"`
+}
+
+// ClaimVerdict is the verdict on a claim of the answer
+type ClaimVerdict struct {
+	Claim     string   `json:"claim"`
+	Supported bool     `json:"supported"`
+	Score     float64  `json:"score"`
+	ChunkIDs  []string `json:"chunk_ids,omitempty"`
 }
 
 // KnowledgeQAStream knowledge Q&A streaming API
@@ -421,10 +518,42 @@ func (c *Client) StopSession(ctx context.Context, sessionID string, messageID st
 	return parseResponse(resp, &response)
 }
 
+// ToolApprovalRequest answers an agent tool call waiting for approval.
+type ToolApprovalRequest struct {
+	MessageID  string `json:"message_id"`
+	ToolCallID string `json:"tool_call_id"`
+	Approved   bool   `json:"approved"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// ApproveToolCall approves or rejects an agent tool call announced by a tool_approval stream event.
+func (c *Client) ApproveToolCall(ctx context.Context, sessionID string, request *ToolApprovalRequest) error {
+	if strings.TrimSpace(sessionID) == "" {
+		return fmt.Errorf("sessionID cannot be empty")
+	}
+	if request == nil || strings.TrimSpace(request.MessageID) == "" || strings.TrimSpace(request.ToolCallID) == "" {
+		return fmt.Errorf("messageID and toolCallID cannot be empty")
+	}
+
+	path := fmt.Sprintf("/api/v1/sessions/%s/tool-approval", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+
+	return parseResponse(resp, &response)
+}
+
 // SearchKnowledgeRequest knowledge search request
 type SearchKnowledgeRequest struct {
-	Query           string `json:"query"`             // Query content
-	KnowledgeBaseID string `json:"knowledge_base_id"` // Knowledge base ID
+	Query           string          `json:"query"`             // Query content
+	KnowledgeBaseID string          `json:"knowledge_base_id"` // Knowledge base ID
+	Filter          *MetadataFilter `json:"filter,omitempty"`  // Optional metadata filter
 }
 
 // SearchKnowledgeResponse search results response
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// UsageSummary is the model usage of a group, dimensions the query is not grouped by are empty
+type UsageSummary struct {
+	Period           *time.Time `json:"period,omitempty"`
+	SessionID        string     `json:"session_id,omitempty"`
+	KnowledgeBaseID  string     `json:"knowledge_base_id,omitempty"`
+	ModelID          string     `json:"model_id,omitempty"`
+	ModelName        string     `json:"model_name,omitempty"`
+	ModelType        string     `json:"model_type,omitempty"`
+	Stage            string     `json:"stage,omitempty"`
+	PromptTokens     int64      `json:"prompt_tokens"`
+	CompletionTokens int64      `json:"completion_tokens"`
+	TotalTokens      int64      `json:"total_tokens"`
+	CallCount        int64      `json:"call_count"`
+	Cost             float64    `json:"cost"`
+}
+
+// UsageReport is the aggregated model usage of the tenant over a time range
+type UsageReport struct {
+	StartTime time.Time       `json:"start_time"`
+	EndTime   time.Time       `json:"end_time"`
+	Currency  string          `json:"currency"`
+	Items     []*UsageSummary `json:"items"`
+	Total     UsageSummary    `json:"total"`
+}
+
+// UsageQuery selects and groups the usage, zero values are omitted
+type UsageQuery struct {
+	StartTime time.Time
+	EndTime   time.Time
+	// GroupBy dimensions: session, knowledge_base, model, model_type, stage
+	GroupBy []string
+	// Interval: hour, day or month
+	Interval        string
+	SessionID       string
+	KnowledgeBaseID string
+	ModelID         string
+	Stage           string
+}
+
+// TokenQuotaStatus is the token usage of the tenant in the current month
+type TokenQuotaStatus struct {
+	TokenQuota  int64     `json:"token_quota"`
+	TokensUsed  int64     `json:"tokens_used"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// UsageReportResponse wraps the usage report response
+type UsageReportResponse struct {
+	Success bool         `json:"success"`
+	Data    *UsageReport `json:"data"`
+}
+
+// TokenQuotaResponse wraps the token quota response
+type TokenQuotaResponse struct {
+	Success bool              `json:"success"`
+	Data    *TokenQuotaStatus `json:"data"`
+}
+
+// GetUsage queries the model usage and cost of the tenant
+func (c *Client) GetUsage(ctx context.Context, usageQuery *UsageQuery) (*UsageReport, error) {
+	query := url.Values{}
+	if usageQuery != nil {
+		if !usageQuery.StartTime.IsZero() {
+			query.Add("start_time", usageQuery.StartTime.Format(time.RFC3339))
+		}
+		if !usageQuery.EndTime.IsZero() {
+			query.Add("end_time", usageQuery.EndTime.Format(time.RFC3339))
+		}
+		if len(usageQuery.GroupBy) > 0 {
+			query.Add("group_by", strings.Join(usageQuery.GroupBy, ","))
+		}
+		for key, value := range map[string]string{
+			"interval":          usageQuery.Interval,
+			"session_id":        usageQuery.SessionID,
+			"knowledge_base_id": usageQuery.KnowledgeBaseID,
+			"model_id":          usageQuery.ModelID,
+			"stage":             usageQuery.Stage,
+		} {
+			if value != "" {
+				query.Add(key, value)
+			}
+		}
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/usage", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response UsageReportResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetTokenQuota returns the monthly token quota of the tenant and its usage in the current month
+func (c *Client) GetTokenQuota(ctx context.Context) (*TokenQuotaStatus, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/usage/quota", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response TokenQuotaResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
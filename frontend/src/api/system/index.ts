@@ -23,6 +23,13 @@ export interface PlaceholderDefinition {
   description: string
 }
 
+export interface ToolPolicy {
+  timeout_seconds?: number
+  max_calls_per_session?: number
+  max_output_chars?: number
+  require_approval?: boolean
+}
+
 export interface AgentConfig {
   max_iterations: number
   reflection_enabled: boolean
@@ -32,6 +39,9 @@ export interface AgentConfig {
   system_prompt_web_enabled?: string  // Custom system prompt when web search is enabled
   system_prompt_web_disabled?: string // Custom system prompt when web search is disabled
   use_custom_system_prompt?: boolean
+  max_parallel_tools?: number    // 单轮内同时执行的工具调用数，0 使用默认值
+  tool_timeout_seconds?: number  // 单次工具调用超时（秒），0 使用默认值
+  tool_policies?: Record<string, ToolPolicy>  // 按工具名配置的策略，以 * 结尾的键按前缀匹配
   available_tools?: ToolDefinition[]  // GET 响应中包含，POST/PUT 不需要
   available_placeholders?: PlaceholderDefinition[]  // GET 响应中包含，POST/PUT 不需要
 }
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// approvalPollInterval is the interval at which the stream is read for approval decisions
+const approvalPollInterval = 200 * time.Millisecond
+
+// streamApprover requests tool call approvals through the EventBus of an agent run and reads the decisions
+// from the stream of the assistant message, where the approval endpoint writes them. Decisions do not go
+// through any SSE connection, so they reach the agent while the client is disconnected or reconnected.
+type streamApprover struct {
+	eventBus      *event.EventBus
+	streamManager interfaces.StreamManager
+	sessionID     string
+	messageID     string
+
+	mu sync.Mutex
+	// offset is the stream position up to which decisions were read
+	offset int
+	// decisions by tool call ID, the first decision of a call wins
+	decisions map[string]*types.ToolApprovalDecision
+}
+
+// NewToolApprover creates an approver that emits EventAgentToolApproval for each tool call and waits for the
+// matching tool_approval_decision event in the stream of the assistant message
+func NewToolApprover(eventBus *event.EventBus, streamManager interfaces.StreamManager,
+	sessionID, messageID string,
+) tools.ToolApprover {
+	return &streamApprover{
+		eventBus:      eventBus,
+		streamManager: streamManager,
+		sessionID:     sessionID,
+		messageID:     messageID,
+		decisions:     make(map[string]*types.ToolApprovalDecision),
+	}
+}
+
+// RequestApproval emits the approval request and blocks until the user decides or ctx is done
+func (a *streamApprover) RequestApproval(
+	ctx context.Context,
+	toolCallID, name string,
+	args map[string]interface{},
+) (*types.ToolApprovalDecision, error) {
+	logger.Infof(ctx, "[Agent] Waiting for approval of tool %s, call ID: %s", name, toolCallID)
+	a.eventBus.Emit(ctx, event.Event{
+		ID:        toolCallID + "-tool-approval",
+		Type:      event.EventAgentToolApproval,
+		SessionID: a.sessionID,
+		Data: event.AgentToolApprovalData{
+			ToolCallID: toolCallID,
+			ToolName:   name,
+			Arguments:  args,
+		},
+	})
+
+	ticker := time.NewTicker(approvalPollInterval)
+	defer ticker.Stop()
+	for {
+		if decision := a.readDecision(ctx, toolCallID); decision != nil {
+			logger.Infof(ctx, "[Agent] Tool %s approval decided: approved=%v", name, decision.Approved)
+			return decision, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// readDecision reads the decisions appended to the stream since the last read and returns the one of the
+// tool call, if any
+func (a *streamApprover) readDecision(ctx context.Context, toolCallID string) *types.ToolApprovalDecision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	events, offset, err := a.streamManager.GetEvents(ctx, a.sessionID, a.messageID, a.offset)
+	if err != nil {
+		logger.Warnf(ctx, "[Agent] Failed to read approval decisions: %v", err)
+		return a.decisions[toolCallID]
+	}
+	a.offset = offset
+	for _, evt := range events {
+		if evt.Type != types.ResponseTypeToolApprovalDecision {
+			continue
+		}
+		id, _ := evt.Data["tool_call_id"].(string)
+		if _, ok := a.decisions[id]; ok || id == "" {
+			continue
+		}
+		approved, _ := evt.Data["approved"].(bool)
+		reason, _ := evt.Data["reason"].(string)
+		a.decisions[id] = &types.ToolApprovalDecision{ToolCallID: id, Approved: approved, Reason: reason}
+	}
+	return a.decisions[toolCallID]
+}
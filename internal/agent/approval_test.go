@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/stream"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func appendDecision(t *testing.T, manager interfaces.StreamManager, toolCallID string, approved bool) {
+	t.Helper()
+	err := manager.AppendEvent(context.Background(), "s1", "m1", interfaces.StreamEvent{
+		ID:   toolCallID + "-tool-approval-decision",
+		Type: types.ResponseTypeToolApprovalDecision,
+		Data: map[string]interface{}{"tool_call_id": toolCallID, "approved": approved, "reason": "checked"},
+	})
+	if err != nil {
+		t.Fatalf("AppendEvent failed: %v", err)
+	}
+}
+
+func TestToolApproverReadsDecisionsWithoutSSE(t *testing.T) {
+	manager := stream.NewMemoryStreamManager()
+	bus := event.NewEventBus()
+	approver := NewToolApprover(bus, manager, "s1", "m1")
+
+	// The decision is written once the approval was requested, no SSE loop is running
+	bus.On(event.EventAgentToolApproval, func(ctx context.Context, evt event.Event) error {
+		go func() {
+			appendDecision(t, manager, "other", false)
+			appendDecision(t, manager, "call-1", true)
+		}()
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	decision, err := approver.RequestApproval(ctx, "call-1", "search", nil)
+	if err != nil {
+		t.Fatalf("RequestApproval failed: %v", err)
+	}
+	if decision.ToolCallID != "call-1" || !decision.Approved || decision.Reason != "checked" {
+		t.Errorf("unexpected decision: %+v", decision)
+	}
+}
+
+func TestToolApproverAfterReconnect(t *testing.T) {
+	manager := stream.NewMemoryStreamManager()
+	if err := manager.AppendEvent(context.Background(), "s1", "m1", interfaces.StreamEvent{
+		Type: types.ResponseTypeToolApproval,
+		Data: map[string]interface{}{"tool_call_id": "call-1"},
+	}); err != nil {
+		t.Fatalf("AppendEvent failed: %v", err)
+	}
+	// The user decided while the client was disconnected and the run was not waiting, the first decision wins
+	appendDecision(t, manager, "call-1", false)
+	appendDecision(t, manager, "call-1", true)
+
+	// The resumed run asks again and finds the decision in the stream
+	approver := NewToolApprover(event.NewEventBus(), manager, "s1", "m1")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	decision, err := approver.RequestApproval(ctx, "call-1", "search", nil)
+	if err != nil {
+		t.Fatalf("RequestApproval failed: %v", err)
+	}
+	if decision.Approved {
+		t.Errorf("expected the first decision to win, got %+v", decision)
+	}
+}
+
+func TestToolApproverCancelled(t *testing.T) {
+	approver := NewToolApprover(event.NewEventBus(), stream.NewMemoryStreamManager(), "s1", "m1")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := approver.RequestApproval(ctx, "call-1", "search", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// scriptedChat streams one scripted response per call
+type scriptedChat struct {
+	responses []types.StreamResponse
+	received  [][]chat.Message
+}
+
+func (m *scriptedChat) Chat(context.Context, []chat.Message, *chat.ChatOptions) (*types.ChatResponse, error) {
+	return &types.ChatResponse{}, nil
+}
+
+func (m *scriptedChat) ChatStream(
+	_ context.Context, messages []chat.Message, _ *chat.ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	m.received = append(m.received, messages)
+	stream := make(chan types.StreamResponse, 1)
+	stream <- m.responses[len(m.received)-1]
+	close(stream)
+	return stream, nil
+}
+
+func (m *scriptedChat) GetModelName() string { return "scripted" }
+func (m *scriptedChat) GetModelID() string   { return "scripted" }
+
+type recordingCheckpointer struct {
+	rounds   []int
+	messages [][]chat.Message
+}
+
+func (c *recordingCheckpointer) SaveCheckpoint(
+	_ context.Context, state *types.AgentState, messages []chat.Message,
+) error {
+	c.rounds = append(c.rounds, state.CurrentRound)
+	c.messages = append(c.messages, messages)
+	return nil
+}
+
+func newScriptedEngine(model *scriptedChat) *AgentEngine {
+	registry := tools.NewToolRegistry(nil, nil, nil)
+	registry.RegisterTool(&sleepTool{name: "search"})
+	return NewAgentEngine(&types.AgentConfig{MaxIterations: 5}, model, registry, nil, nil, nil, "s1", "")
+}
+
+func TestCheckpointAndResume(t *testing.T) {
+	toolCall := types.StreamResponse{ToolCalls: []types.LLMToolCall{{
+		ID: "call-1", Type: "function", Function: types.FunctionCall{Name: "search", Arguments: `{"q":"found"}`},
+	}}}
+	answer := types.StreamResponse{Content: "answer", Done: true}
+
+	checkpointer := &recordingCheckpointer{}
+	engine := newScriptedEngine(&scriptedChat{responses: []types.StreamResponse{toolCall, answer}})
+	engine.SetCheckpointer(checkpointer)
+	if _, err := engine.Execute(context.Background(), "s1", "m1", "question", nil); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if len(checkpointer.rounds) != 2 || checkpointer.rounds[0] != 0 || checkpointer.rounds[1] != 1 {
+		t.Fatalf("expected checkpoints of rounds 0 and 1, got %v", checkpointer.rounds)
+	}
+
+	// Resume from the checkpoint after the tool round, the model only answers
+	model := &scriptedChat{responses: []types.StreamResponse{answer}}
+	state := &types.AgentState{
+		CurrentRound: 1,
+		RoundSteps:   []types.AgentStep{{Iteration: 0, ToolCalls: []types.ToolCall{{ID: "call-1", Name: "search"}}}},
+	}
+	state, err := newScriptedEngine(model).Resume(
+		context.Background(), "s1", "m1", "question", state, checkpointer.messages[1],
+	)
+	if err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if state.FinalAnswer != "answer" || len(state.RoundSteps) != 2 {
+		t.Errorf("unexpected resumed state: %+v", state)
+	}
+	last := model.received[0][len(model.received[0])-1]
+	if last.Role != "tool" || last.Content != "found" {
+		t.Errorf("expected resumed run to continue after the tool result, got %+v", last)
+	}
+}
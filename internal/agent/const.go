@@ -9,4 +9,11 @@ const (
 	DefaultAgentReflectionEnabled = false
 	// DefaultUseCustomSystemPrompt is the default whether to use custom system prompt for the agent
 	DefaultUseCustomSystemPrompt = false
+	// DefaultAgentMaxParallelTools is the default maximum number of tool calls of a round running at once
+	DefaultAgentMaxParallelTools = 4
+	// DefaultAgentToolTimeoutSeconds is the default timeout of a single tool call in seconds
+	DefaultAgentToolTimeoutSeconds = 60
+	// GroundednessFallbackAnswer replaces a final answer not grounded in the knowledge references
+	// when the groundedness action is fallback
+	GroundednessFallbackAnswer = "抱歉，检索到的资料不足以支持可靠的回答，请尝试换一种问法或补充相关知识。"
 )
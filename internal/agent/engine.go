@@ -10,8 +10,10 @@ import (
 	"github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/common"
 	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/groundedness"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
@@ -28,10 +30,12 @@ type AgentEngine struct {
 	toolRegistry         *tools.ToolRegistry
 	chatModel            chat.Chat
 	eventBus             *event.EventBus
-	knowledgeBasesInfo   []*KnowledgeBaseInfo      // Detailed knowledge base information for prompt
-	contextManager       interfaces.ContextManager // Context manager for writing agent conversation to LLM context
-	sessionID            string                    // Session ID for context management
-	systemPromptTemplate string                    // System prompt template (optional, uses default if empty)
+	knowledgeBasesInfo   []*KnowledgeBaseInfo         // Detailed knowledge base information for prompt
+	contextManager       interfaces.ContextManager    // Context manager for writing agent conversation to LLM context
+	sessionID            string                       // Session ID for context management
+	systemPromptTemplate string                       // System prompt template (optional, uses default if empty)
+	checkpointer         interfaces.AgentCheckpointer // Checkpointer saving the state after every round (optional)
+	groundednessJudge    groundedness.Judge           // Judge checking the final answer (optional)
 }
 
 // listToolNames returns tool.function names for logging
@@ -57,7 +61,7 @@ func NewAgentEngine(
 	if eventBus == nil {
 		eventBus = event.NewEventBus()
 	}
-	return &AgentEngine{
+	engine := &AgentEngine{
 		config:               config,
 		toolRegistry:         toolRegistry,
 		chatModel:            chatModel,
@@ -67,6 +71,8 @@ func NewAgentEngine(
 		sessionID:            sessionID,
 		systemPromptTemplate: systemPromptTemplate,
 	}
+	toolRegistry.SetDefaultTimeout(engine.toolTimeout())
+	return engine
 }
 
 // Execute executes the agent with conversation history and streaming output
@@ -109,6 +115,67 @@ func (e *AgentEngine) Execute(
 	logger.Infof(ctx, "[Agent] Total messages for LLM: %d (system: 1, history: %d, user query: 1)",
 		len(messages), len(llmContext))
 
+	// Save the initial checkpoint, so the run can be resumed even if the first round is interrupted
+	e.saveCheckpoint(ctx, state, messages)
+
+	return e.run(ctx, state, query, messages, sessionID, messageID)
+}
+
+// Resume continues an interrupted execution from its checkpointed state and LLM messages
+func (e *AgentEngine) Resume(
+	ctx context.Context,
+	sessionID, messageID, query string,
+	state *types.AgentState,
+	messages []chat.Message,
+) (*types.AgentState, error) {
+	logger.Infof(ctx, "========== Agent Execution Resumed ==========")
+	logger.Infof(ctx, "[Agent] SessionID: %s, MessageID: %s, Round: %d, Messages: %d",
+		sessionID, messageID, state.CurrentRound+1, len(messages))
+	common.PipelineInfo(ctx, "Agent", "execute_resume", map[string]interface{}{
+		"session_id": sessionID,
+		"message_id": messageID,
+		"round":      state.CurrentRound + 1,
+		"messages":   len(messages),
+	})
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("checkpoint has no messages to resume from")
+	}
+	state.IsComplete = false
+	state.FinalAnswer = ""
+	state.StructuredOutput = nil
+	state.CitationMap = nil
+	state.Groundedness = nil
+	return e.run(ctx, state, query, messages, sessionID, messageID)
+}
+
+// SetCheckpointer sets the checkpointer called after every round
+func (e *AgentEngine) SetCheckpointer(checkpointer interfaces.AgentCheckpointer) {
+	e.checkpointer = checkpointer
+}
+
+// SetGroundednessJudge sets the judge checking the final answer against the knowledge references
+func (e *AgentEngine) SetGroundednessJudge(judge groundedness.Judge) {
+	e.groundednessJudge = judge
+}
+
+// saveCheckpoint saves the state through the checkpointer, a failed checkpoint does not fail the run
+func (e *AgentEngine) saveCheckpoint(ctx context.Context, state *types.AgentState, messages []chat.Message) {
+	if e.checkpointer == nil {
+		return
+	}
+	if err := e.checkpointer.SaveCheckpoint(ctx, state, messages); err != nil {
+		logger.Warnf(ctx, "[Agent] Failed to save checkpoint of round %d: %v", state.CurrentRound, err)
+	}
+}
+
+// run executes the ReAct loop from the given state and messages and reports its outcome
+func (e *AgentEngine) run(
+	ctx context.Context,
+	state *types.AgentState,
+	query string,
+	messages []chat.Message,
+	sessionID, messageID string,
+) (*types.AgentState, error) {
 	// Get tool definitions for function calling
 	tools := e.buildToolsForLLM()
 	toolListStr := strings.Join(listToolNames(tools), ", ")
@@ -260,6 +327,7 @@ func (e *AgentEngine) executeLoop(
 				len(response.ToolCalls),
 			)
 
+			executions := make([]*toolExecution, 0, len(response.ToolCalls))
 			for i, tc := range response.ToolCalls {
 				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool: %s, ID: %s",
 					state.CurrentRound+1, i+1, len(response.ToolCalls), tc.Function.Name, tc.ID)
@@ -276,7 +344,6 @@ func (e *AgentEngine) executeLoop(
 				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Arguments:\n%s",
 					state.CurrentRound+1, i+1, len(response.ToolCalls), string(argsJSON))
 
-				toolCallStartTime := time.Now()
 				e.eventBus.Emit(ctx, event.Event{
 					ID:        tc.ID + "-tool-call",
 					Type:      event.EventAgentToolCall,
@@ -289,10 +356,6 @@ func (e *AgentEngine) executeLoop(
 					},
 				})
 				logger.Debugf(ctx, "[Agent] ToolCall -> %s args=%s", tc.Function.Name, tc.Function.Arguments)
-
-				// Execute tool
-				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Executing tool: %s...",
-					state.CurrentRound+1, i+1, len(response.ToolCalls), tc.Function.Name)
 				common.PipelineInfo(ctx, "Agent", "tool_call_start", map[string]interface{}{
 					"iteration":    state.CurrentRound,
 					"round":        state.CurrentRound + 1,
@@ -300,8 +363,20 @@ func (e *AgentEngine) executeLoop(
 					"tool_call_id": tc.ID,
 					"tool_index":   fmt.Sprintf("%d/%d", i+1, len(response.ToolCalls)),
 				})
-				result, err := e.toolRegistry.ExecuteTool(ctx, tc.Function.Name, args)
-				duration := time.Since(toolCallStartTime).Milliseconds()
+				executions = append(executions, newToolExecution(i, tc, args))
+			}
+
+			// Execute independent tool calls concurrently, results are still handled in call order
+			logger.Infof(ctx, "[Agent][Round-%d] Executing %d tool calls, max parallel: %d, timeout: %s",
+				state.CurrentRound+1, len(executions), e.maxParallelTools(), e.toolTimeout())
+			e.startToolExecutions(ctx, executions)
+
+			for _, execution := range executions {
+				i, tc, args := execution.index, execution.call, execution.args
+
+				// Wait for the tool in call order
+				<-execution.done
+				result, duration, err := execution.result, execution.duration, execution.err
 				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool execution completed in %dms",
 					state.CurrentRound+1, i+1, len(response.ToolCalls), duration)
 
@@ -427,6 +502,12 @@ func (e *AgentEngine) executeLoop(
 					}
 				}
 			}
+
+			// The session was stopped while tools were running
+			if ctx.Err() != nil {
+				logger.Warnf(ctx, "[Agent][Round-%d] Tool execution cancelled: %v", state.CurrentRound+1, ctx.Err())
+				return state, fmt.Errorf("tool execution cancelled: %w", ctx.Err())
+			}
 		}
 
 		state.RoundSteps = append(state.RoundSteps, step)
@@ -440,6 +521,7 @@ func (e *AgentEngine) executeLoop(
 		})
 		// 5. Check if we should continue
 		state.CurrentRound++
+		e.saveCheckpoint(ctx, state, messages)
 	}
 
 	// If loop finished without final answer, generate one
@@ -461,6 +543,20 @@ func (e *AgentEngine) executeLoop(
 		state.IsComplete = true
 	}
 
+	// Check the final answer against the knowledge references, structured answers are validated by their schema
+	if e.groundednessJudge != nil && e.config.ResponseSchema == nil && state.FinalAnswer != "" &&
+		len(state.KnowledgeRefs) > 0 {
+		e.checkGroundedness(ctx, state, messages, sessionID)
+	}
+
+	// Return the final answer additionally as an object conforming to the response schema
+	if e.config.ResponseSchema != nil && state.FinalAnswer != "" {
+		e.structureFinalAnswer(ctx, query, state, sessionID)
+	}
+
+	// Link the citation tags of the final answer to the collected knowledge references
+	state.CitationMap = searchutil.BuildCitationMap(state.FinalAnswer, state.KnowledgeRefs)
+
 	// Emit completion event
 	// Convert knowledge refs to interface{} slice for event data
 	knowledgeRefsInterface := make([]interface{}, 0, len(state.KnowledgeRefs))
@@ -479,6 +575,10 @@ func (e *AgentEngine) executeLoop(
 			TotalSteps:      len(state.RoundSteps),
 			TotalDurationMs: time.Since(startTime).Milliseconds(),
 			MessageID:       messageID, // Include message ID for proper message update
+
+			StructuredOutput: json.RawMessage(state.StructuredOutput),
+			CitationMap:      state.CitationMap,
+			Groundedness:     state.Groundedness,
 		},
 	})
 
@@ -858,6 +958,119 @@ func (e *AgentEngine) streamFinalAnswerToEventBus(
 	return nil
 }
 
+// structureFinalAnswer converts the final answer into an object conforming to the response schema,
+// a failure is reported as an error event and the answer is returned as free text only
+func (e *AgentEngine) structureFinalAnswer(
+	ctx context.Context,
+	query string,
+	state *types.AgentState,
+	sessionID string,
+) {
+	common.PipelineInfo(ctx, "Agent", "structured_output_start", map[string]interface{}{
+		"session_id": sessionID,
+		"native":     chat.SupportsStructuredOutput(e.chatModel),
+	})
+	messages := []chat.Message{
+		{
+			Role: "system",
+			Content: "你是一个信息抽取助手。请将给定的回答整理为结构化数据，" +
+				"只使用回答中的信息，回答中没有的字段按 Schema 允许的方式留空。",
+		},
+		{Role: "user", Content: fmt.Sprintf("用户问题: %s\n\n回答:\n%s", query, state.FinalAnswer)},
+	}
+	_, output, err := chat.GenerateStructured(ctx, e.chatModel, messages,
+		&chat.ChatOptions{Temperature: e.config.Temperature}, e.config.ResponseSchema)
+	if err != nil {
+		logger.Warnf(ctx, "[Agent] Failed to structure final answer: %v", err)
+		common.PipelineWarn(ctx, "Agent", "structured_output_failed", map[string]interface{}{
+			"session_id": sessionID,
+			"error":      err.Error(),
+		})
+		e.eventBus.Emit(ctx, event.Event{
+			ID:        generateEventID("structured-output-error"),
+			Type:      event.EventError,
+			SessionID: sessionID,
+			Data: event.ErrorData{
+				Error:     err.Error(),
+				Stage:     "structured_output",
+				SessionID: sessionID,
+			},
+		})
+		return
+	}
+	state.StructuredOutput = output
+	common.PipelineInfo(ctx, "Agent", "structured_output_done", map[string]interface{}{
+		"session_id": sessionID,
+		"output_len": len(output),
+	})
+}
+
+// checkGroundedness checks the final answer against the knowledge references. An answer that is not
+// grounded is regenerated with a stricter prompt or replaced with GroundednessFallbackAnswer, depending
+// on the configured action, and the replacement is emitted as a new final answer.
+func (e *AgentEngine) checkGroundedness(
+	ctx context.Context,
+	state *types.AgentState,
+	messages []chat.Message,
+	sessionID string,
+) {
+	config := e.config.Groundedness
+	result, err := groundedness.Check(ctx, e.groundednessJudge, state.FinalAnswer, state.KnowledgeRefs,
+		config.GetThreshold())
+	if err != nil {
+		common.PipelineWarn(ctx, "Agent", "groundedness_failed", map[string]interface{}{
+			"session_id": sessionID,
+			"error":      err.Error(),
+		})
+		return
+	}
+	common.PipelineInfo(ctx, "Agent", "groundedness_result", map[string]interface{}{
+		"session_id": sessionID,
+		"judge":      result.Judge,
+		"score":      result.Score,
+		"grounded":   result.Grounded,
+	})
+	state.Groundedness = result
+	if result.Grounded || config.GetAction() == types.GroundednessActionAnnotate {
+		return
+	}
+
+	answer := GroundednessFallbackAnswer
+	if config.GetAction() == types.GroundednessActionRegenerate {
+		resp, err := e.chatModel.Chat(ctx, groundedness.RegenerationMessages(messages, state.FinalAnswer, result),
+			&chat.ChatOptions{Temperature: e.config.Temperature})
+		if err != nil {
+			logger.Warnf(ctx, "[Agent] Failed to regenerate the final answer: %v", err)
+			return
+		}
+		answer = resp.Content
+		regenerated, err := groundedness.Check(ctx, e.groundednessJudge, answer, state.KnowledgeRefs,
+			config.GetThreshold())
+		if err != nil {
+			logger.Warnf(ctx, "[Agent] Failed to check the regenerated final answer: %v", err)
+		} else {
+			result = regenerated
+		}
+	}
+	result.Action = config.GetAction()
+	state.Groundedness = result
+	state.FinalAnswer = answer
+	if len(state.RoundSteps) > 0 {
+		state.RoundSteps[len(state.RoundSteps)-1].Thought = answer
+	}
+
+	data := event.AgentFinalAnswerData{Content: answer, Done: true, Groundedness: result}
+	if citationMap := searchutil.BuildCitationMap(answer, state.KnowledgeRefs); citationMap != nil {
+		data.CitationMap = citationMap
+	}
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        generateEventID("grounded-answer"),
+		Type:      event.EventAgentFinalAnswer,
+		SessionID: sessionID,
+		Data:      data,
+	})
+}
+
 // countTotalToolCalls counts total tool calls across all steps
 func countTotalToolCalls(steps []types.AgentStep) int {
 	total := 0
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/event"
+)
+
+// BuildSubAgentQuery builds the query a sub-agent answers for a delegated task
+func BuildSubAgentQuery(task, expectedOutput string) string {
+	var b strings.Builder
+	b.WriteString("You are a sub-agent working on a task delegated by a coordinating agent. ")
+	b.WriteString("Research the task with the available tools and answer with a concise report of your findings, ")
+	b.WriteString("naming the documents they come from. Do not ask the user questions.\n\n")
+	b.WriteString("## Task\n")
+	b.WriteString(task)
+	if expectedOutput != "" {
+		b.WriteString("\n\n## Expected Output\n")
+		b.WriteString(expectedOutput)
+	}
+	return b.String()
+}
+
+// ForwardSubAgentEvents relays the progress of a sub-agent from its own EventBus to the parent's
+// as EventAgentSubAgent events nested under the delegate_task call
+func ForwardSubAgentEvents(child, parent *event.EventBus, sessionID, parentToolCallID string) {
+	forward := func(ctx context.Context, evt event.Event, data event.AgentSubAgentData) error {
+		data.ParentToolCallID = parentToolCallID
+		parent.Emit(ctx, event.Event{
+			ID:        fmt.Sprintf("%s-sub-%s", parentToolCallID, evt.ID),
+			Type:      event.EventAgentSubAgent,
+			SessionID: sessionID,
+			Data:      data,
+		})
+		return nil
+	}
+
+	child.On(event.EventAgentThought, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentThoughtData)
+		if !ok {
+			return nil
+		}
+		return forward(ctx, evt, event.AgentSubAgentData{
+			Event:     string(event.EventAgentThought),
+			Content:   data.Content,
+			Iteration: data.Iteration,
+			Done:      data.Done,
+		})
+	})
+	child.On(event.EventAgentToolCall, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentToolCallData)
+		if !ok {
+			return nil
+		}
+		return forward(ctx, evt, event.AgentSubAgentData{
+			Event:      string(event.EventAgentToolCall),
+			ToolCallID: data.ToolCallID,
+			ToolName:   data.ToolName,
+			Iteration:  data.Iteration,
+		})
+	})
+	child.On(event.EventAgentToolResult, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentToolResultData)
+		if !ok {
+			return nil
+		}
+		content := data.Output
+		if !data.Success {
+			content = data.Error
+		}
+		return forward(ctx, evt, event.AgentSubAgentData{
+			Event:      string(event.EventAgentToolResult),
+			Content:    content,
+			ToolCallID: data.ToolCallID,
+			ToolName:   data.ToolName,
+			Success:    data.Success,
+			Iteration:  data.Iteration,
+			Done:       true,
+		})
+	})
+	child.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok {
+			return nil
+		}
+		return forward(ctx, evt, event.AgentSubAgentData{
+			Event:   string(event.EventAgentFinalAnswer),
+			Content: data.Content,
+			Done:    data.Done,
+		})
+	})
+	child.On(event.EventError, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.ErrorData)
+		if !ok {
+			return nil
+		}
+		return forward(ctx, evt, event.AgentSubAgentData{
+			Event:   string(event.EventError),
+			Content: data.Error,
+			Done:    true,
+		})
+	})
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// toolExecution is a tool call of a round scheduled for execution
+type toolExecution struct {
+	index    int
+	call     types.LLMToolCall
+	args     map[string]any
+	result   *types.ToolResult
+	err      error
+	duration int64
+	// done is closed once result, err and duration are set
+	done chan struct{}
+}
+
+// newToolExecution creates a pending execution of the tool call
+func newToolExecution(index int, call types.LLMToolCall, args map[string]any) *toolExecution {
+	return &toolExecution{
+		index: index,
+		call:  call,
+		args:  args,
+		done:  make(chan struct{}),
+	}
+}
+
+// maxParallelTools returns the number of tool calls of a round allowed to run at once
+func (e *AgentEngine) maxParallelTools() int {
+	if e.config.MaxParallelTools > 0 {
+		return e.config.MaxParallelTools
+	}
+	return DefaultAgentMaxParallelTools
+}
+
+// toolTimeout returns the timeout of a single tool call
+func (e *AgentEngine) toolTimeout() time.Duration {
+	if e.config.ToolTimeoutSeconds > 0 {
+		return time.Duration(e.config.ToolTimeoutSeconds) * time.Second
+	}
+	return DefaultAgentToolTimeoutSeconds * time.Second
+}
+
+// startToolExecutions executes the tool calls of a round concurrently, at most maxParallelTools at once.
+// Calls of stateful tools run one after another in their original order. It returns immediately,
+// callers wait on the done channel of each execution, so results can be consumed in call order
+// while later calls are still running. Cancelling ctx cancels all pending and running calls.
+func (e *AgentEngine) startToolExecutions(ctx context.Context, executions []*toolExecution) {
+	slots := make(chan struct{}, e.maxParallelTools())
+	serial := make([]*toolExecution, 0)
+	for _, execution := range executions {
+		if e.toolRegistry.IsStateful(execution.call.Function.Name) {
+			serial = append(serial, execution)
+			continue
+		}
+		go e.runToolExecution(ctx, slots, execution)
+	}
+	if len(serial) > 0 {
+		go func() {
+			for _, execution := range serial {
+				e.runToolExecution(ctx, slots, execution)
+			}
+		}()
+	}
+}
+
+// runToolExecution waits for a free slot and executes the tool call
+func (e *AgentEngine) runToolExecution(ctx context.Context, slots chan struct{}, execution *toolExecution) {
+	defer close(execution.done)
+
+	select {
+	case slots <- struct{}{}:
+		defer func() { <-slots }()
+	case <-ctx.Done():
+		execution.err = fmt.Errorf("tool %s cancelled: %w", execution.call.Function.Name, ctx.Err())
+		execution.result = &types.ToolResult{Success: false, Error: execution.err.Error()}
+		return
+	}
+
+	// The registry enforces the tool timeout and policy, the call ID identifies approval requests
+	startTime := time.Now()
+	execution.result, execution.err = e.toolRegistry.ExecuteTool(
+		tools.WithToolCallID(ctx, execution.call.ID), execution.call.Function.Name, execution.args,
+	)
+	execution.duration = time.Since(startTime).Milliseconds()
+	if execution.err != nil && execution.result == nil {
+		execution.result = &types.ToolResult{Success: false, Error: execution.err.Error()}
+	}
+}
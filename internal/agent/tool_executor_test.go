@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+type sleepTool struct {
+	name    string
+	delay   time.Duration
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (t *sleepTool) Name() string                       { return t.name }
+func (t *sleepTool) Description() string                { return t.name }
+func (t *sleepTool) Parameters() map[string]interface{} { return nil }
+
+func (t *sleepTool) Execute(ctx context.Context, args map[string]interface{}) (*types.ToolResult, error) {
+	running := t.running.Add(1)
+	defer t.running.Add(-1)
+	for {
+		peak := t.peak.Load()
+		if running <= peak || t.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+	time.Sleep(t.delay)
+	return &types.ToolResult{Success: true, Output: args["q"].(string)}, nil
+}
+
+func newTestEngine(config *types.AgentConfig, ts ...types.Tool) *AgentEngine {
+	registry := tools.NewToolRegistry(nil, nil, nil)
+	for _, tool := range ts {
+		registry.RegisterTool(tool)
+	}
+	return NewAgentEngine(config, nil, registry, nil, nil, nil, "", "")
+}
+
+func runExecutions(ctx context.Context, e *AgentEngine, name string, n int) []*toolExecution {
+	executions := make([]*toolExecution, 0, n)
+	for i := 0; i < n; i++ {
+		call := types.LLMToolCall{ID: string(rune('a' + i)), Function: types.FunctionCall{Name: name}}
+		executions = append(executions, newToolExecution(i, call, map[string]any{"q": call.ID}))
+	}
+	e.startToolExecutions(ctx, executions)
+	for _, execution := range executions {
+		<-execution.done
+	}
+	return executions
+}
+
+func TestToolExecutionsRunConcurrently(t *testing.T) {
+	tool := &sleepTool{name: "search", delay: 100 * time.Millisecond}
+	e := newTestEngine(&types.AgentConfig{MaxParallelTools: 2}, tool)
+
+	start := time.Now()
+	executions := runExecutions(context.Background(), e, "search", 4)
+	elapsed := time.Since(start)
+
+	if peak := tool.peak.Load(); peak != 2 {
+		t.Errorf("expected 2 concurrent calls, got %d", peak)
+	}
+	if elapsed >= 400*time.Millisecond {
+		t.Errorf("tool calls ran serially, took %s", elapsed)
+	}
+	for i, execution := range executions {
+		if execution.err != nil || execution.result.Output != string(rune('a'+i)) {
+			t.Errorf("unexpected result of call %d: %+v, %v", i, execution.result, execution.err)
+		}
+	}
+}
+
+func TestToolExecutionTimeoutAndCancel(t *testing.T) {
+	tool := &sleepTool{name: "slow", delay: 3 * time.Second}
+	e := newTestEngine(&types.AgentConfig{ToolTimeoutSeconds: 1}, tool)
+
+	executions := runExecutions(context.Background(), e, "slow", 1)
+	if err := executions[0].err; err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if executions[0].result == nil || executions[0].result.Success {
+		t.Errorf("expected failed result, got %+v", executions[0].result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	executions = runExecutions(ctx, e, "slow", 3)
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("stopping did not cancel tool calls, took %s", elapsed)
+	}
+	for _, execution := range executions {
+		if execution.err == nil || !strings.Contains(execution.err.Error(), "cancelled") {
+			t.Errorf("expected cancelled error, got %v", execution.err)
+		}
+	}
+}
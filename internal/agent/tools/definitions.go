@@ -19,6 +19,7 @@ func AvailableToolDefinitions() []AvailableTool {
 		{Name: "query_knowledge_graph", Label: "查询知识图谱", Description: "从知识图谱中查询关系"},
 		{Name: "get_document_info", Label: "获取文档信息", Description: "查看文档元数据"},
 		{Name: "database_query", Label: "查询数据库", Description: "查询数据库中的信息"},
+		{Name: "delegate_task", Label: "委派子任务", Description: "将独立的子任务交给子 Agent 执行并返回精简结果"},
 	}
 }
 
@@ -33,5 +34,6 @@ func DefaultAllowedTools() []string {
 		"query_knowledge_graph",
 		"get_document_info",
 		"database_query",
+		"delegate_task",
 	}
 }
@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// DelegateTaskToolName is the name of the delegate_task tool
+	DelegateTaskToolName = "delegate_task"
+	// DefaultSubAgentMaxIterations is the iteration budget of a sub-agent when the task does not set one
+	DefaultSubAgentMaxIterations = 5
+	// MaxSubAgentIterations caps the iteration budget of a sub-agent
+	MaxSubAgentIterations = 10
+	// SubAgentMaxOutputChars caps the result a sub-agent returns to the parent agent
+	SubAgentMaxOutputChars = 4000
+	// SubAgentTimeout is the timeout of a delegated task unless a tool policy sets one
+	SubAgentTimeout = 10 * time.Minute
+)
+
+// SubAgentTask is a subtask delegated to a child agent
+type SubAgentTask struct {
+	// ParentToolCallID is the delegate_task call the child agent reports its progress under
+	ParentToolCallID string
+	// Task describes what the child agent has to find out
+	Task string
+	// ExpectedOutput describes the result the parent agent expects (optional)
+	ExpectedOutput string
+	// KnowledgeBaseIDs the child agent may search, a subset of the parent's knowledge bases
+	KnowledgeBaseIDs []string
+	// Tools the child agent may use, a subset of the parent's tools
+	Tools []string
+	// MaxIterations is the iteration budget of the child agent
+	MaxIterations int
+}
+
+// SubAgentResult is the outcome of a delegated task
+type SubAgentResult struct {
+	// Answer is the final answer of the child agent
+	Answer string
+	// Rounds is the number of rounds the child agent ran
+	Rounds int
+	// ToolCalls is the number of tool calls the child agent made
+	ToolCalls int
+}
+
+// SubAgentRunner runs delegated tasks in child agents
+type SubAgentRunner interface {
+	// RunSubAgent runs the task in a child agent and blocks until it answers or ctx is done
+	RunSubAgent(ctx context.Context, task *SubAgentTask) (*SubAgentResult, error)
+}
+
+// DelegateTaskTool delegates a self-contained subtask to a child agent with its own context window
+type DelegateTaskTool struct {
+	BaseTool
+	runner         SubAgentRunner
+	knowledgeBases []string
+	tools          []string
+}
+
+// NewDelegateTaskTool creates a new delegate_task tool, the child agents may use the given
+// knowledge bases and tools
+func NewDelegateTaskTool(runner SubAgentRunner, knowledgeBases, tools []string) *DelegateTaskTool {
+	description := `Delegate a self-contained research subtask to a sub-agent with its own context window.
+
+## Core Function
+Starts a sub-agent that works on the task with a restricted set of tools and knowledge bases and a limited iteration budget. Only its condensed final answer is returned to you, its intermediate searches do not fill your context.
+
+## When to Use
+✅ **Use for**:
+- Broad questions spanning many knowledge bases: delegate one subtask per knowledge base or topic
+- Independent research threads that need several searches each (e.g., "summarize the deployment options described in KB A")
+- Subtasks whose intermediate results you do not need to see
+
+❌ **Don't use for**:
+- A single search → use knowledge_search or grep_chunks directly
+- Tasks depending on results you have not passed in the task description
+- Producing the final answer → answer the user yourself
+
+## Parameters
+- **task** (required): Complete, self-contained description of the subtask. The sub-agent does not see the conversation, include all context it needs.
+- **expected_output** (optional): What the result should contain, e.g. "a list of versions with their release dates".
+- **knowledge_base_ids** (optional): Knowledge bases the sub-agent may search, defaults to all of yours.
+- **tools** (optional): Tools the sub-agent may use, defaults to all of yours except delegate_task.
+- **max_iterations** (optional): Iteration budget of the sub-agent (1-10, default 5). Use a small budget for narrow tasks.
+
+## Notes
+- Independent delegate_task calls in the same round run in parallel
+- Sub-agents can not delegate further
+- The result is a summary, verify critical details with knowledge_search when needed`
+
+	return &DelegateTaskTool{
+		BaseTool:       NewBaseTool(DelegateTaskToolName, description),
+		runner:         runner,
+		knowledgeBases: knowledgeBases,
+		tools:          tools,
+	}
+}
+
+// Parameters returns the JSON schema for the tool's parameters
+func (t *DelegateTaskTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"task": map[string]interface{}{
+				"type":        "string",
+				"description": "Complete, self-contained description of the subtask",
+			},
+			"expected_output": map[string]interface{}{
+				"type":        "string",
+				"description": "What the result of the subtask should contain",
+			},
+			"knowledge_base_ids": map[string]interface{}{
+				"type":        "array",
+				"description": "Knowledge bases the sub-agent may search, defaults to all",
+				"items": map[string]interface{}{
+					"type": "string",
+					"enum": t.knowledgeBases,
+				},
+			},
+			"tools": map[string]interface{}{
+				"type":        "array",
+				"description": "Tools the sub-agent may use, defaults to all",
+				"items": map[string]interface{}{
+					"type": "string",
+					"enum": t.tools,
+				},
+			},
+			"max_iterations": map[string]interface{}{
+				"type":        "integer",
+				"description": "Iteration budget of the sub-agent",
+				"minimum":     1,
+				"maximum":     MaxSubAgentIterations,
+				"default":     DefaultSubAgentMaxIterations,
+			},
+		},
+		"required": []string{"task"},
+	}
+}
+
+// Timeout returns the timeout of a delegated task, a sub-agent runs far longer than a single tool
+func (t *DelegateTaskTool) Timeout() time.Duration {
+	return SubAgentTimeout
+}
+
+// Execute runs the subtask in a child agent and returns its condensed answer
+func (t *DelegateTaskTool) Execute(ctx context.Context, args map[string]interface{}) (*types.ToolResult, error) {
+	task, err := t.parseTask(args)
+	if err != nil {
+		return &types.ToolResult{Success: false, Error: err.Error()}, err
+	}
+	task.ParentToolCallID = toolCallIDFromContext(ctx)
+
+	result, err := t.runner.RunSubAgent(ctx, task)
+	if err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("sub-agent failed: %v", err),
+		}, err
+	}
+
+	answer := TruncateOutput(strings.TrimSpace(result.Answer), SubAgentMaxOutputChars)
+	output := fmt.Sprintf("=== 子任务结果 ===\n\n任务: %s\n执行轮次: %d, 工具调用: %d\n\n%s",
+		task.Task, result.Rounds, result.ToolCalls, answer)
+	return &types.ToolResult{
+		Success: true,
+		Output:  output,
+		Data: map[string]interface{}{
+			"display_type":       "sub_agent",
+			"task":               task.Task,
+			"knowledge_base_ids": task.KnowledgeBaseIDs,
+			"tools":              task.Tools,
+			"max_iterations":     task.MaxIterations,
+			"rounds":             result.Rounds,
+			"tool_calls":         result.ToolCalls,
+			"answer":             answer,
+		},
+	}, nil
+}
+
+// parseTask validates the arguments and restricts the subtask to the parent's knowledge bases and tools
+func (t *DelegateTaskTool) parseTask(args map[string]interface{}) (*SubAgentTask, error) {
+	description, _ := args["task"].(string)
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return nil, fmt.Errorf("task is required")
+	}
+	expectedOutput, _ := args["expected_output"].(string)
+
+	knowledgeBases, err := restrictTo(stringSlice(args["knowledge_base_ids"]), t.knowledgeBases, "knowledge base")
+	if err != nil {
+		return nil, err
+	}
+	tools, err := restrictTo(stringSlice(args["tools"]), t.tools, "tool")
+	if err != nil {
+		return nil, err
+	}
+
+	maxIterations := DefaultSubAgentMaxIterations
+	if value, ok := args["max_iterations"].(float64); ok && value > 0 {
+		maxIterations = min(int(value), MaxSubAgentIterations)
+	}
+
+	return &SubAgentTask{
+		Task:             description,
+		ExpectedOutput:   strings.TrimSpace(expectedOutput),
+		KnowledgeBaseIDs: knowledgeBases,
+		Tools:            tools,
+		MaxIterations:    maxIterations,
+	}, nil
+}
+
+// restrictTo returns the requested values, or all allowed values if none are requested
+func restrictTo(requested, allowed []string, kind string) ([]string, error) {
+	if len(requested) == 0 {
+		return slices.Clone(allowed), nil
+	}
+	for _, value := range requested {
+		if !slices.Contains(allowed, value) {
+			return nil, fmt.Errorf("%s %s is not available to sub-agents", kind, value)
+		}
+	}
+	return requested, nil
+}
+
+// stringSlice converts a JSON array argument to a slice of non-empty strings
+func stringSlice(value interface{}) []string {
+	items, _ := value.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" && !slices.Contains(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
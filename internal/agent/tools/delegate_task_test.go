@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+type fakeSubAgentRunner struct {
+	task   *SubAgentTask
+	answer string
+}
+
+func (r *fakeSubAgentRunner) RunSubAgent(_ context.Context, task *SubAgentTask) (*SubAgentResult, error) {
+	r.task = task
+	return &SubAgentResult{Answer: r.answer, Rounds: 2, ToolCalls: 3}, nil
+}
+
+func TestDelegateTask(t *testing.T) {
+	ctx := WithToolCallID(context.Background(), "call-1")
+	runner := &fakeSubAgentRunner{answer: strings.Repeat("答案", 3000)}
+	tool := NewDelegateTaskTool(runner, []string{"kb1", "kb2"}, []string{"knowledge_search", "grep_chunks"})
+
+	result, err := tool.Execute(ctx, map[string]interface{}{
+		"task":               "summarize kb1",
+		"knowledge_base_ids": []interface{}{"kb1"},
+		"max_iterations":     float64(50),
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("delegate_task failed: %v %+v", err, result)
+	}
+	task := runner.task
+	if task.ParentToolCallID != "call-1" || task.MaxIterations != MaxSubAgentIterations {
+		t.Errorf("unexpected task: %+v", task)
+	}
+	if len(task.KnowledgeBaseIDs) != 1 || len(task.Tools) != 2 {
+		t.Errorf("expected kb1 and all tools, got %v %v", task.KnowledgeBaseIDs, task.Tools)
+	}
+	if utf8.RuneCountInString(result.Output) > SubAgentMaxOutputChars+200 {
+		t.Errorf("expected truncated output, got %d characters", utf8.RuneCountInString(result.Output))
+	}
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"task": "x"}); err != nil {
+		t.Fatalf("delegate_task failed: %v", err)
+	}
+	if runner.task.MaxIterations != DefaultSubAgentMaxIterations {
+		t.Errorf("expected default budget, got %d", runner.task.MaxIterations)
+	}
+
+	result, err = tool.Execute(ctx, map[string]interface{}{
+		"task":               "search kb3",
+		"knowledge_base_ids": []interface{}{"kb3"},
+	})
+	if err == nil || result.Success {
+		t.Errorf("expected unavailable knowledge base to be rejected, got %+v", result)
+	}
+}
@@ -101,6 +101,9 @@ Avoid:
 - queries (required): 1–5 semantic questions or conceptual statements.
   These should reflect the meaning or topic you want embeddings to capture.
 - knowledge_base_ids (optional): limit the search scope.
+- filter (optional): metadata filter on the documents searched, e.g.
+  {"op": "and", "filters": [{"op": "eq", "field": "file_type", "value": "pdf"}, {"op": "range", "field": "created_at", "gte": "2024-01-01"}]}
+  Ops: eq, in, range (gt/gte/lt/lte), and, or, not. Fields: tag_id, file_type, created_at, metadata.<key>.
 
 ## Output
 Returns chunks ranked by semantic similarity, reranked when applicable.  
@@ -142,6 +145,10 @@ func (t *KnowledgeSearchTool) Parameters() map[string]interface{} {
 				"minItems": 0,
 				"maxItems": 10,
 			},
+			"filter": map[string]interface{}{
+				"type":        "object",
+				"description": "Optional: metadata filter expression, e.g. {\"op\": \"eq\", \"field\": \"file_type\", \"value\": \"pdf\"}",
+			},
 		},
 		"required": []string{"queries"},
 	}
@@ -200,6 +207,25 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args map[string]inter
 
 	logger.Infof(ctx, "[Tool][KnowledgeSearch] Queries: %v", queries)
 
+	// Parse optional metadata filter
+	var filter *types.MetadataFilter
+	if filterRaw, ok := args["filter"]; ok && filterRaw != nil {
+		filterJSON, err := json.Marshal(filterRaw)
+		if err == nil {
+			err = json.Unmarshal(filterJSON, &filter)
+		}
+		if err == nil {
+			err = filter.Validate()
+		}
+		if err != nil {
+			logger.Errorf(ctx, "[Tool][KnowledgeSearch] Invalid filter: %v", err)
+			return &types.ToolResult{
+				Success: false,
+				Error:   fmt.Sprintf("invalid filter: %v", err),
+			}, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+
 	// Get search parameters from tenant conversation config, fallback to global config
 	var topK int
 	var vectorThreshold, keywordThreshold, minScore float64
@@ -258,10 +284,18 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args map[string]inter
 
 	// Execute concurrent search (hybrid search handles both vector and keyword)
 	logger.Infof(ctx, "[Tool][KnowledgeSearch] Starting concurrent search across %d KBs", len(kbIDs))
-	kbTypeMap := t.getKnowledgeBaseTypes(ctx, kbIDs)
+	knowledgeBases := t.getKnowledgeBases(ctx, kbIDs)
+	kbTypeMap := make(map[string]string, len(knowledgeBases))
+	expansionConfigs := make(map[string]*types.ContextExpansionConfig)
+	for kbID, kb := range knowledgeBases {
+		kbTypeMap[kbID] = kb.Type
+		if kb.ContextExpansionConfig.Enabled() {
+			expansionConfigs[kbID] = kb.ContextExpansionConfig
+		}
+	}
 
 	allResults := t.concurrentSearch(ctx, queries, kbIDs,
-		topK, vectorThreshold, keywordThreshold, kbTypeMap)
+		topK, vectorThreshold, keywordThreshold, filter, kbTypeMap)
 	logger.Infof(ctx, "[Tool][KnowledgeSearch] Concurrent search completed: %d raw results", len(allResults))
 
 	// Note: HybridSearch now uses RRF (Reciprocal Rank Fusion) which produces normalized scores
@@ -357,6 +391,9 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args map[string]inter
 	logger.Infof(ctx, "[Tool][KnowledgeSearch] After final deduplication: %d results (from %d)",
 		len(deduplicatedResults), len(filteredResults))
 
+	// Return the parent sections of matched child chunks for knowledge bases with hierarchical chunking
+	deduplicatedResults = t.resolveParentChunks(ctx, deduplicatedResults)
+
 	// Sort results by score (descending)
 	sort.Slice(deduplicatedResults, func(i, j int) bool {
 		if deduplicatedResults[i].Score != deduplicatedResults[j].Score {
@@ -366,6 +403,9 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args map[string]inter
 		return deduplicatedResults[i].KnowledgeID < deduplicatedResults[j].KnowledgeID
 	})
 
+	// Expand results to their surrounding context for knowledge bases with small-to-big retrieval
+	deduplicatedResults = t.expandContext(ctx, deduplicatedResults, expansionConfigs)
+
 	// Log top results
 	if len(deduplicatedResults) > 0 {
 		for i := 0; i < len(deduplicatedResults) && i < 5; i++ {
@@ -386,15 +426,15 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args map[string]inter
 	return result, nil
 }
 
-// getKnowledgeBaseTypes fetches knowledge base types for the given IDs
-func (t *KnowledgeSearchTool) getKnowledgeBaseTypes(ctx context.Context, kbIDs []string) map[string]string {
-	kbTypeMap := make(map[string]string, len(kbIDs))
+// getKnowledgeBases fetches the knowledge bases of the given IDs, skipping those that fail to load
+func (t *KnowledgeSearchTool) getKnowledgeBases(ctx context.Context, kbIDs []string) map[string]*types.KnowledgeBase {
+	knowledgeBases := make(map[string]*types.KnowledgeBase, len(kbIDs))
 
 	for _, kbID := range kbIDs {
 		if kbID == "" {
 			continue
 		}
-		if _, exists := kbTypeMap[kbID]; exists {
+		if _, exists := knowledgeBases[kbID]; exists {
 			continue
 		}
 
@@ -404,10 +444,77 @@ func (t *KnowledgeSearchTool) getKnowledgeBaseTypes(ctx context.Context, kbIDs [
 			continue
 		}
 
-		kbTypeMap[kbID] = kb.Type
+		knowledgeBases[kbID] = kb
+	}
+
+	return knowledgeBases
+}
+
+// resolveParentChunks replaces matched child chunks by their parent chunks, collapsing children of the same parent
+func (t *KnowledgeSearchTool) resolveParentChunks(
+	ctx context.Context,
+	results []*searchResultWithMeta,
+) []*searchResultWithMeta {
+	searchResults := make([]*types.SearchResult, 0, len(results))
+	for _, r := range results {
+		searchResults = append(searchResults, r.SearchResult)
 	}
+	resolved, err := searchutil.ResolveParentChunks(ctx, t.chunkService.GetRepository(), t.tenantID, searchResults)
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][KnowledgeSearch] Parent chunk resolution failed, using original results: %v", err)
+		return results
+	}
+	if len(resolved) == len(results) {
+		return results
+	}
+
+	kept := make(map[*types.SearchResult]bool, len(resolved))
+	for _, r := range resolved {
+		kept[r] = true
+	}
+	resolvedResults := make([]*searchResultWithMeta, 0, len(resolved))
+	for _, r := range results {
+		if kept[r.SearchResult] {
+			resolvedResults = append(resolvedResults, r)
+		}
+	}
+	return resolvedResults
+}
 
-	return kbTypeMap
+// expandContext expands the ranked results to their neighbouring or parent chunks,
+// dropping results covered by the context of a higher ranked result
+func (t *KnowledgeSearchTool) expandContext(
+	ctx context.Context,
+	results []*searchResultWithMeta,
+	configs map[string]*types.ContextExpansionConfig,
+) []*searchResultWithMeta {
+	if len(configs) == 0 || len(results) == 0 {
+		return results
+	}
+
+	searchResults := make([]*types.SearchResult, 0, len(results))
+	for _, r := range results {
+		searchResults = append(searchResults, r.SearchResult)
+	}
+	expanded, err := searchutil.ExpandContext(ctx, t.chunkService.GetRepository(), t.tenantID, searchResults, configs)
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][KnowledgeSearch] Context expansion failed, using original results: %v", err)
+		return results
+	}
+
+	kept := make(map[*types.SearchResult]bool, len(expanded))
+	for _, r := range expanded {
+		kept[r] = true
+	}
+	expandedResults := make([]*searchResultWithMeta, 0, len(expanded))
+	for _, r := range results {
+		if kept[r.SearchResult] {
+			expandedResults = append(expandedResults, r)
+		}
+	}
+	logger.Infof(ctx, "[Tool][KnowledgeSearch] Context expansion completed: %d results (from %d)",
+		len(expandedResults), len(results))
+	return expandedResults
 }
 
 // concurrentSearch executes hybrid search across multiple KBs concurrently
@@ -417,6 +524,7 @@ func (t *KnowledgeSearchTool) concurrentSearch(
 	kbsToSearch []string,
 	topK int,
 	vectorThreshold, keywordThreshold float64,
+	filter *types.MetadataFilter,
 	kbTypeMap map[string]string,
 ) []*searchResultWithMeta {
 	var wg sync.WaitGroup
@@ -437,6 +545,7 @@ func (t *KnowledgeSearchTool) concurrentSearch(
 					MatchCount:       topK,
 					VectorThreshold:  vectorThreshold,
 					KeywordThreshold: keywordThreshold,
+					Filter:           filter,
 				}
 				kbResults, err := t.knowledgeBaseService.HybridSearch(ctx, kb, searchParams)
 				if err != nil {
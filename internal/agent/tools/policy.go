@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+)
+
+// DefaultApprovalTimeout is how long a tool call waits for the user's approval before it is rejected
+const DefaultApprovalTimeout = 10 * time.Minute
+
+// ToolApprover asks the user to approve tool calls whose policy requires approval
+type ToolApprover interface {
+	// RequestApproval announces the tool call and blocks until the user decides or ctx is done
+	RequestApproval(
+		ctx context.Context,
+		toolCallID, name string,
+		args map[string]interface{},
+	) (*types.ToolApprovalDecision, error)
+}
+
+// PolicyConfig configures the tool policies enforced by the registry for a session
+type PolicyConfig struct {
+	// Policies by tool name, keys ending with "*" match name prefixes
+	Policies map[string]*types.ToolPolicy
+	// SessionID scopes the per-session call limits
+	SessionID string
+	// Budget counts the calls against the per-session limits, an agent passes its budget to its sub-agents so
+	// they draw from the same limits. A new budget of SessionID is used if nil.
+	Budget *CallBudget
+	// Approver handles tools requiring approval, such calls are rejected without it
+	Approver ToolApprover
+}
+
+// CallBudget counts the tool calls of a session against the per-session limits of the tool policies.
+// It is safe for concurrent use by the registries sharing it.
+type CallBudget struct {
+	db        *gorm.DB
+	sessionID string
+
+	mu sync.Mutex
+	// counts by tool name, loaded from the session on the first reservation
+	counts map[string]int
+}
+
+// NewCallBudget creates the call budget of a session, the calls of its previous messages count against it
+func NewCallBudget(db *gorm.DB, sessionID string) *CallBudget {
+	return &CallBudget{db: db, sessionID: sessionID}
+}
+
+// WithToolCallID returns a context carrying the ID of the tool call being executed
+func WithToolCallID(ctx context.Context, toolCallID string) context.Context {
+	return context.WithValue(ctx, types.ToolCallIDContextKey, toolCallID)
+}
+
+// toolCallIDFromContext returns the ID of the tool call being executed, if any
+func toolCallIDFromContext(ctx context.Context) string {
+	toolCallID, _ := ctx.Value(types.ToolCallIDContextKey).(string)
+	return toolCallID
+}
+
+// SetPolicy sets the tool policies enforced by ExecuteTool
+func (r *ToolRegistry) SetPolicy(cfg PolicyConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies = cfg.Policies
+	r.approver = cfg.Approver
+	r.budget = cfg.Budget
+	if r.budget == nil {
+		r.budget = NewCallBudget(r.db, cfg.SessionID)
+	}
+}
+
+// SetDefaultTimeout sets the timeout of tools whose policy does not set one, 0 disables it
+func (r *ToolRegistry) SetDefaultTimeout(timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaultTimeout = timeout
+}
+
+// policyFor returns the policy of the tool, exact names win over the longest matching prefix
+func (r *ToolRegistry) policyFor(name string) *types.ToolPolicy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if policy, ok := r.policies[name]; ok {
+		return policy
+	}
+	var matched *types.ToolPolicy
+	matchedLen := -1
+	for pattern, policy := range r.policies {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(name, prefix) && len(prefix) > matchedLen {
+			matched, matchedLen = policy, len(prefix)
+		}
+	}
+	return matched
+}
+
+// timeoutFor returns the execution timeout of a tool under the policy
+func (r *ToolRegistry) timeoutFor(tool types.Tool, policy *types.ToolPolicy) time.Duration {
+	if policy != nil && policy.TimeoutSeconds > 0 {
+		return time.Duration(policy.TimeoutSeconds) * time.Second
+	}
+	if timed, ok := tool.(TimedTool); ok && timed.Timeout() > 0 {
+		return timed.Timeout()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.defaultTimeout
+}
+
+// reserve counts a call of the tool in the session, it fails once the limit is reached
+func (b *CallBudget) reserve(ctx context.Context, name string, limit int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.counts == nil {
+		b.counts = b.loadSessionCallCounts(ctx)
+	}
+	if b.counts[name] >= limit {
+		return false
+	}
+	b.counts[name]++
+	return true
+}
+
+// release gives back a reserved call that did not run
+func (b *CallBudget) release(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.counts[name] > 0 {
+		b.counts[name]--
+	}
+}
+
+// loadSessionCallCounts counts the tool calls stored on previous messages of the session
+func (b *CallBudget) loadSessionCallCounts(ctx context.Context) map[string]int {
+	counts := make(map[string]int)
+	if b.db == nil || b.sessionID == "" {
+		return counts
+	}
+	var steps []types.AgentSteps
+	if err := b.db.WithContext(ctx).Model(&types.Message{}).
+		Where("session_id = ? AND role = ?", b.sessionID, "assistant").
+		Pluck("agent_steps", &steps).Error; err != nil {
+		logger.Warnf(ctx, "Failed to load tool calls of session %s: %v", b.sessionID, err)
+		return counts
+	}
+	for _, messageSteps := range steps {
+		for _, step := range messageSteps {
+			for _, toolCall := range step.ToolCalls {
+				counts[toolCall.Name]++
+			}
+		}
+	}
+	return counts
+}
+
+// authorize enforces the call limit and approval of the policy,
+// it returns a failed result if the tool call must not run
+func (r *ToolRegistry) authorize(
+	ctx context.Context,
+	name string,
+	args map[string]interface{},
+	policy *types.ToolPolicy,
+) *types.ToolResult {
+	r.mu.Lock()
+	budget, approver := r.budget, r.approver
+	r.mu.Unlock()
+	if policy.MaxCallsPerSession > 0 && !budget.reserve(ctx, name, policy.MaxCallsPerSession) {
+		return &types.ToolResult{
+			Success: false,
+			Error: fmt.Sprintf("tool %s reached its limit of %d calls in this session, do not call it again",
+				name, policy.MaxCallsPerSession),
+		}
+	}
+	if !policy.RequireApproval {
+		return nil
+	}
+
+	rejected := func(reason string) *types.ToolResult {
+		if policy.MaxCallsPerSession > 0 {
+			budget.release(name)
+		}
+		return &types.ToolResult{Success: false, Error: fmt.Sprintf("tool call was not approved: %s", reason)}
+	}
+	if approver == nil {
+		return rejected("approval is not available")
+	}
+
+	approvalCtx, cancel := context.WithTimeout(ctx, DefaultApprovalTimeout)
+	defer cancel()
+	decision, err := approver.RequestApproval(approvalCtx, toolCallIDFromContext(ctx), name, args)
+	if err != nil {
+		logger.Warnf(ctx, "Approval of tool %s failed: %v", name, err)
+		return rejected("no decision was made in time")
+	}
+	if !decision.Approved {
+		reason := decision.Reason
+		if reason == "" {
+			reason = "rejected by the user"
+		}
+		return rejected(reason)
+	}
+	return nil
+}
+
+// runTool executes the tool and gives up once the timeout elapses or ctx is cancelled,
+// even if the tool itself does not observe its context
+func runTool(
+	ctx context.Context,
+	tool types.Tool,
+	args map[string]interface{},
+	timeout time.Duration,
+) (*types.ToolResult, error) {
+	toolCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		toolCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	type outcome struct {
+		result *types.ToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Errorf(ctx, "Tool %s panicked: %v", tool.Name(), r)
+				done <- outcome{err: fmt.Errorf("tool %s panicked: %v", tool.Name(), r)}
+			}
+		}()
+		result, err := tool.Execute(toolCtx, args)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-toolCtx.Done():
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("tool %s cancelled: %w", tool.Name(), ctx.Err())
+		}
+		return nil, fmt.Errorf("tool %s timed out after %s", tool.Name(), timeout)
+	}
+}
+
+// TruncateOutput keeps at most maxChars characters of the output. It keeps the head and
+// the tail, cuts at line breaks where possible and notes how much was left out.
+func TruncateOutput(output string, maxChars int) string {
+	total := utf8.RuneCountInString(output)
+	if maxChars <= 0 || total <= maxChars {
+		return output
+	}
+	runes := []rune(output)
+	headLen := maxChars * 3 / 4
+	tailLen := maxChars - headLen
+
+	head := string(runes[:headLen])
+	if i := strings.LastIndex(head, "\n"); i > len(head)/2 {
+		head = head[:i]
+	}
+	tail := string(runes[total-tailLen:])
+	if i := strings.Index(tail, "\n"); i >= 0 && i < len(tail)/2 {
+		tail = tail[i+1:]
+	}
+	omitted := total - utf8.RuneCountInString(head) - utf8.RuneCountInString(tail)
+	return fmt.Sprintf("%s\n\n... [%d characters omitted] ...\n\n%s", head, omitted, tail)
+}
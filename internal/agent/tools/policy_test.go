@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+type echoTool struct {
+	name   string
+	output string
+	calls  int
+}
+
+func (t *echoTool) Name() string                       { return t.name }
+func (t *echoTool) Description() string                { return t.name }
+func (t *echoTool) Parameters() map[string]interface{} { return nil }
+
+func (t *echoTool) Execute(context.Context, map[string]interface{}) (*types.ToolResult, error) {
+	t.calls++
+	return &types.ToolResult{Success: true, Output: t.output}, nil
+}
+
+type fixedApprover struct {
+	decision   *types.ToolApprovalDecision
+	toolCallID string
+}
+
+func (a *fixedApprover) RequestApproval(
+	_ context.Context,
+	toolCallID, _ string,
+	_ map[string]interface{},
+) (*types.ToolApprovalDecision, error) {
+	a.toolCallID = toolCallID
+	return a.decision, nil
+}
+
+func TestToolPolicy(t *testing.T) {
+	ctx := WithToolCallID(context.Background(), "call-1")
+	search := &echoTool{name: "knowledge_search", output: strings.Repeat("line\n", 100)}
+	mcp := &echoTool{name: "mcp.orders.query", output: "rows"}
+	approver := &fixedApprover{decision: &types.ToolApprovalDecision{Approved: false, Reason: "not allowed"}}
+
+	registry := NewToolRegistry(nil, nil, nil)
+	registry.RegisterTool(search)
+	registry.RegisterTool(mcp)
+	registry.SetPolicy(PolicyConfig{
+		Policies: map[string]*types.ToolPolicy{
+			"knowledge_search": {MaxCallsPerSession: 2, MaxOutputChars: 100},
+			"mcp.*":            {RequireApproval: true},
+		},
+		SessionID: "s1",
+		Approver:  approver,
+	})
+
+	for i := 0; i < 3; i++ {
+		result, err := registry.ExecuteTool(ctx, "knowledge_search", nil)
+		if err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+		if i < 2 && (!result.Success || utf8.RuneCountInString(result.Output) > 150) {
+			t.Errorf("call %d: expected truncated output, got %d characters", i, len(result.Output))
+		}
+		if i == 2 && (result.Success || !strings.Contains(result.Error, "limit of 2 calls")) {
+			t.Errorf("expected call limit, got %+v", result)
+		}
+	}
+	if search.calls != 2 {
+		t.Errorf("expected 2 executions, got %d", search.calls)
+	}
+
+	result, _ := registry.ExecuteTool(ctx, "mcp.orders.query", nil)
+	if result.Success || !strings.Contains(result.Error, "not allowed") || mcp.calls != 0 {
+		t.Errorf("expected rejected call, got %+v", result)
+	}
+	if approver.toolCallID != "call-1" {
+		t.Errorf("expected approval of call-1, got %q", approver.toolCallID)
+	}
+	approver.decision = &types.ToolApprovalDecision{Approved: true}
+	if result, _ := registry.ExecuteTool(ctx, "mcp.orders.query", nil); !result.Success || mcp.calls != 1 {
+		t.Errorf("expected approved call to run, got %+v", result)
+	}
+}
+
+func TestToolPolicySharedBudget(t *testing.T) {
+	ctx := context.Background()
+	policies := map[string]*types.ToolPolicy{"knowledge_search": {MaxCallsPerSession: 2}}
+	budget := NewCallBudget(nil, "s1")
+
+	// A parent agent and its sub-agent, each with its own registry
+	parentSearch := &echoTool{name: "knowledge_search"}
+	parent := NewToolRegistry(nil, nil, nil)
+	parent.RegisterTool(parentSearch)
+	parent.SetPolicy(PolicyConfig{Policies: policies, SessionID: "s1", Budget: budget})
+	childSearch := &echoTool{name: "knowledge_search"}
+	child := NewToolRegistry(nil, nil, nil)
+	child.RegisterTool(childSearch)
+	child.SetPolicy(PolicyConfig{Policies: policies, SessionID: "s1", Budget: budget})
+
+	for _, registry := range []*ToolRegistry{parent, child} {
+		if result, _ := registry.ExecuteTool(ctx, "knowledge_search", nil); !result.Success {
+			t.Fatalf("expected the call to run, got %+v", result)
+		}
+	}
+	for _, registry := range []*ToolRegistry{parent, child} {
+		result, _ := registry.ExecuteTool(ctx, "knowledge_search", nil)
+		if result.Success || !strings.Contains(result.Error, "limit of 2 calls") {
+			t.Errorf("expected the shared limit to be reached, got %+v", result)
+		}
+	}
+	if parentSearch.calls+childSearch.calls != 2 {
+		t.Errorf("expected 2 executions in total, got %d", parentSearch.calls+childSearch.calls)
+	}
+
+	// Setting the policy again keeps the calls counted by the shared budget
+	child.SetPolicy(PolicyConfig{Policies: policies, SessionID: "s1", Budget: budget})
+	if result, _ := child.ExecuteTool(ctx, "knowledge_search", nil); result.Success {
+		t.Errorf("expected the limit to survive a policy update, got %+v", result)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	if got := TruncateOutput("short", 10); got != "short" {
+		t.Errorf("short output changed: %q", got)
+	}
+	output := strings.Repeat("知识库", 100)
+	got := TruncateOutput(output, 60)
+	if !strings.HasPrefix(got, "知识库") || !strings.Contains(got, "characters omitted") {
+		t.Errorf("unexpected truncation: %q", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("truncation split a character")
+	}
+}
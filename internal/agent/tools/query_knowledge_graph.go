@@ -3,9 +3,13 @@ package tools
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -14,10 +18,14 @@ import (
 type QueryKnowledgeGraphTool struct {
 	BaseTool
 	knowledgeService interfaces.KnowledgeBaseService
+	graphRepo        interfaces.RetrieveGraphRepository
 }
 
 // NewQueryKnowledgeGraphTool creates a new query knowledge graph tool
-func NewQueryKnowledgeGraphTool(knowledgeService interfaces.KnowledgeBaseService) *QueryKnowledgeGraphTool {
+func NewQueryKnowledgeGraphTool(
+	knowledgeService interfaces.KnowledgeBaseService,
+	graphRepo interfaces.RetrieveGraphRepository,
+) *QueryKnowledgeGraphTool {
 	description := `Query knowledge graph to explore entity relationships and knowledge networks.
 
 ## Core Function
@@ -29,6 +37,7 @@ Explores relationships between entities in knowledge bases that have graph extra
 - Exploring knowledge networks and concept associations
 - Finding related information about specific entities
 - Understanding technical architecture and system relationships
+- Finding how two entities are connected through intermediate entities (e.g., "how is A connected to B")
 
 ❌ **Don't use for**:
 - General text search → use knowledge_search
@@ -38,6 +47,11 @@ Explores relationships between entities in knowledge bases that have graph extra
 ## Parameters
 - **knowledge_base_ids** (required): Array of knowledge base IDs (1-10). Only KBs with graph extraction configured will be effective.
 - **query** (required): Query content - can be entity name, relationship query, or concept search.
+- **entities** (optional): Entity names to expand in the graph, defaults to the query.
+- **hops** (optional): Expansion distance (1-3, default 1). Use 2 or more to reach entities connected indirectly.
+- **relation_types** (optional): Only follow these relationship types.
+- **limit** (optional): Maximum entities returned (default 30), ranked by number of relationships.
+- **path_from** / **path_to** (optional): Find the shortest relationship path between two entities.
 
 ## Graph Configuration
 Knowledge graph must be pre-configured in knowledge bases:
@@ -54,11 +68,13 @@ If KB is not configured with graph, tool will return regular search results.
 ## Notes
 - Results indicate graph configuration status
 - Cross-KB results are automatically deduplicated
-- Results are sorted by relevance`
+- Results are sorted by relevance
+- Graph entities are ranked by degree: highly connected entities are usually central concepts`
 
 	return &QueryKnowledgeGraphTool{
 		BaseTool:         NewBaseTool("query_knowledge_graph", description),
 		knowledgeService: knowledgeService,
+		graphRepo:        graphRepo,
 	}
 }
 
@@ -80,6 +96,42 @@ func (t *QueryKnowledgeGraphTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "查询内容（实体名称或查询文本）",
 			},
+			"entities": map[string]interface{}{
+				"type":        "array",
+				"description": "要在图谱中展开的实体名称，默认使用 query",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"hops": map[string]interface{}{
+				"type":        "integer",
+				"description": "展开的跳数",
+				"minimum":     1,
+				"maximum":     maxGraphToolHops,
+				"default":     1,
+			},
+			"relation_types": map[string]interface{}{
+				"type":        "array",
+				"description": "只沿这些关系类型展开，默认不限",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "返回的实体数量上限，按关系数量排序",
+				"minimum":     1,
+				"maximum":     types.MaxGraphNodeLimit,
+				"default":     defaultGraphToolLimit,
+			},
+			"path_from": map[string]interface{}{
+				"type":        "string",
+				"description": "查找最短路径的起点实体",
+			},
+			"path_to": map[string]interface{}{
+				"type":        "string",
+				"description": "查找最短路径的终点实体",
+			},
 		},
 		"required": []string{"knowledge_base_ids", "query"},
 	}
@@ -119,11 +171,15 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 		}, fmt.Errorf("invalid query")
 	}
 
+	graphQuery, pathQuery := parseGraphToolQueries(args, query)
+
 	// Concurrently query all knowledge bases
 	type graphQueryResult struct {
 		kbID    string
 		kb      *types.KnowledgeBase
 		results []*types.SearchResult
+		graph   *types.GraphData
+		path    *types.GraphData
 		err     error
 	}
 
@@ -167,8 +223,10 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 				return
 			}
 
+			graph, path := t.traverseGraph(ctx, id, graphQuery, pathQuery)
+
 			mu.Lock()
-			kbResults[id] = &graphQueryResult{kbID: id, kb: kb, results: results}
+			kbResults[id] = &graphQueryResult{kbID: id, kb: kb, results: results, graph: graph, path: path}
 			mu.Unlock()
 		}(kbID)
 	}
@@ -180,6 +238,8 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 	var errors []string
 	graphConfigs := make(map[string]map[string]interface{})
 	kbCounts := make(map[string]int)
+	entityGraph := &types.GraphData{}
+	var paths []*types.GraphData
 
 	for _, kbID := range kbIDs {
 		result := kbResults[kbID]
@@ -196,6 +256,10 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 		}
 
 		kbCounts[kbID] = len(result.results)
+		mergeGraph(entityGraph, result.graph)
+		if result.path != nil && len(result.path.Node) > 0 {
+			paths = append(paths, result.path)
+		}
 		for _, r := range result.results {
 			if _, seen := seenChunks[r.ID]; !seen {
 				seenChunks[r.ID] = r
@@ -213,7 +277,11 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 		return allResults[i].Score > allResults[j].Score
 	})
 
-	if len(allResults) == 0 {
+	types.RankGraphNodes(entityGraph, func(name string) bool {
+		return types.GraphNameMatches(name, graphQuery.Nodes)
+	}, graphQuery.Limit)
+
+	if len(allResults) == 0 && len(entityGraph.Node) == 0 && len(paths) == 0 {
 		return &types.ToolResult{
 			Success: true,
 			Output:  "未找到相关的图谱信息。",
@@ -297,6 +365,8 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 		output += "\n"
 	}
 
+	output += formatGraphEvidence(entityGraph, paths)
+
 	// Display search results
 	output += "=== 🔍 查询结果 ===\n\n"
 	if !hasGraphConfig {
@@ -348,7 +418,7 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 	output += "- ⏳ 完整的图查询语言（Cypher）支持开发中\n"
 
 	// Build structured graph data for frontend visualization
-	graphData := buildGraphVisualizationData(allResults, graphConfigs)
+	graphData := buildGraphVisualizationData(allResults, entityGraph, paths)
 
 	return &types.ToolResult{
 		Success: true,
@@ -361,6 +431,9 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 			"kb_counts":          kbCounts,
 			"graph_configs":      graphConfigs,
 			"graph_data":         graphData,
+			"entities":           entityGraph.Node,
+			"relations":          entityGraph.Relation,
+			"paths":              paths,
 			"has_graph_config":   hasGraphConfig,
 			"errors":             errors,
 			"display_type":       "graph_query_results",
@@ -371,7 +444,8 @@ func (t *QueryKnowledgeGraphTool) Execute(ctx context.Context, args map[string]i
 // buildGraphVisualizationData builds structured data for graph visualization
 func buildGraphVisualizationData(
 	results []*types.SearchResult,
-	graphConfigs map[string]map[string]interface{},
+	entityGraph *types.GraphData,
+	paths []*types.GraphData,
 ) map[string]interface{} {
 	// Build a simple graph structure for frontend visualization
 	nodes := make([]map[string]interface{}, 0)
@@ -394,6 +468,35 @@ func buildGraphVisualizationData(
 		}
 	}
 
+	// Create entity nodes and relationship edges from the graph traversal
+	seenEdges := make(map[string]bool)
+	for _, graph := range append([]*types.GraphData{entityGraph}, paths...) {
+		for _, node := range graph.Node {
+			id := "entity:" + node.Name
+			if !seenEntities[id] {
+				nodes = append(nodes, map[string]interface{}{
+					"id":     id,
+					"label":  node.Name,
+					"degree": node.Degree,
+					"chunks": node.Chunks,
+					"type":   "entity",
+				})
+				seenEntities[id] = true
+			}
+		}
+		for _, rel := range graph.Relation {
+			id := rel.Node1 + "|" + rel.Type + "|" + rel.Node2
+			if !seenEdges[id] {
+				edges = append(edges, map[string]interface{}{
+					"source": "entity:" + rel.Node1,
+					"target": "entity:" + rel.Node2,
+					"label":  rel.Type,
+				})
+				seenEdges[id] = true
+			}
+		}
+	}
+
 	return map[string]interface{}{
 		"nodes":       nodes,
 		"edges":       edges,
@@ -401,3 +504,158 @@ func buildGraphVisualizationData(
 		"total_edges": len(edges),
 	}
 }
+
+const (
+	// defaultGraphToolLimit is the default number of entities returned by the tool
+	defaultGraphToolLimit = 30
+	// maxGraphToolHops caps the expansion distance of the tool
+	maxGraphToolHops = 3
+	// maxGraphToolRelations caps the relations listed in the output
+	maxGraphToolRelations = 50
+)
+
+// parseGraphToolQueries builds the expansion query and, if both endpoints are given, the path query
+func parseGraphToolQueries(args map[string]interface{}, query string) (*types.GraphQuery, *types.GraphPathQuery) {
+	graphQuery := &types.GraphQuery{
+		Nodes:         stringSlice(args["entities"]),
+		RelationTypes: stringSlice(args["relation_types"]),
+		Limit:         defaultGraphToolLimit,
+	}
+	if len(graphQuery.Nodes) == 0 {
+		graphQuery.Nodes = []string{query}
+	}
+	if hops, ok := args["hops"].(float64); ok {
+		graphQuery.Hops = min(int(hops), maxGraphToolHops)
+	}
+	if limit, ok := args["limit"].(float64); ok && limit > 0 {
+		graphQuery.Limit = int(limit)
+	}
+	graphQuery.Normalize()
+
+	from, _ := args["path_from"].(string)
+	to, _ := args["path_to"].(string)
+	if strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+		return graphQuery, nil
+	}
+	return graphQuery, &types.GraphPathQuery{
+		Source:        strings.TrimSpace(from),
+		Target:        strings.TrimSpace(to),
+		RelationTypes: graphQuery.RelationTypes,
+	}
+}
+
+// traverseGraph expands the entities in the knowledge base graph and finds the requested path. Failures
+// are logged and leave the graph evidence empty, the chunk search results are still returned.
+func (t *QueryKnowledgeGraphTool) traverseGraph(ctx context.Context, kbID string,
+	graphQuery *types.GraphQuery, pathQuery *types.GraphPathQuery,
+) (*types.GraphData, *types.GraphData) {
+	if t.graphRepo == nil || !config.IsGraphEnabled() {
+		return nil, nil
+	}
+	namespace := types.NameSpace{KnowledgeBase: kbID}
+	query := *graphQuery
+	graph, err := t.graphRepo.ExpandNodes(ctx, namespace, &query)
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][QueryKnowledgeGraph] Failed to expand entities in KB %s: %v", kbID, err)
+	}
+	if pathQuery == nil {
+		return graph, nil
+	}
+	pq := *pathQuery
+	path, err := t.graphRepo.FindPath(ctx, namespace, &pq)
+	if err != nil {
+		logger.Warnf(ctx, "[Tool][QueryKnowledgeGraph] Failed to find path in KB %s: %v", kbID, err)
+	}
+	return graph, path
+}
+
+// mergeGraph adds the nodes and relations of src to dst, merging nodes of the same name
+func mergeGraph(dst *types.GraphData, src *types.GraphData) {
+	if src == nil {
+		return
+	}
+	nodeIndex := make(map[string]*types.GraphNode, len(dst.Node))
+	for _, node := range dst.Node {
+		nodeIndex[node.Name] = node
+	}
+	for _, node := range src.Node {
+		existing, ok := nodeIndex[node.Name]
+		if !ok {
+			merged := *node
+			nodeIndex[node.Name] = &merged
+			dst.Node = append(dst.Node, &merged)
+			continue
+		}
+		existing.Degree += node.Degree
+		for _, chunk := range node.Chunks {
+			if !slices.Contains(existing.Chunks, chunk) {
+				existing.Chunks = append(existing.Chunks, chunk)
+			}
+		}
+	}
+	seen := make(map[types.GraphRelation]bool, len(dst.Relation))
+	for _, rel := range dst.Relation {
+		seen[*rel] = true
+	}
+	for _, rel := range src.Relation {
+		if !seen[*rel] {
+			seen[*rel] = true
+			dst.Relation = append(dst.Relation, rel)
+		}
+	}
+}
+
+// formatGraphEvidence renders the ranked entities, their relationships and the found paths
+func formatGraphEvidence(entityGraph *types.GraphData, paths []*types.GraphData) string {
+	if len(entityGraph.Node) == 0 && len(paths) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	if len(paths) > 0 {
+		b.WriteString("=== 🧭 实体路径 ===\n\n")
+		for _, path := range paths {
+			b.WriteString("  " + formatGraphPath(path) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if len(entityGraph.Node) > 0 {
+		b.WriteString(fmt.Sprintf("=== 🕸️ 图谱实体（%d，按关系数排序） ===\n\n", len(entityGraph.Node)))
+		for _, node := range entityGraph.Node {
+			b.WriteString(fmt.Sprintf("  - %s（关系数: %d，关联片段: %d）\n", node.Name, node.Degree, len(node.Chunks)))
+		}
+		b.WriteString("\n")
+	}
+	if len(entityGraph.Relation) > 0 {
+		b.WriteString(fmt.Sprintf("=== 🔗 实体关系（%d） ===\n\n", len(entityGraph.Relation)))
+		for i, rel := range entityGraph.Relation {
+			if i == maxGraphToolRelations {
+				b.WriteString(fmt.Sprintf("  ... 其余 %d 条关系已省略\n", len(entityGraph.Relation)-i))
+				break
+			}
+			b.WriteString(fmt.Sprintf("  - %s -[%s]-> %s\n", rel.Node1, rel.Type, rel.Node2))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatGraphPath renders a path as a chain of entities, relations keep their stored direction
+func formatGraphPath(path *types.GraphData) string {
+	if len(path.Node) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(path.Node[0].Name)
+	for i, rel := range path.Relation {
+		if i+1 >= len(path.Node) {
+			break
+		}
+		next := path.Node[i+1].Name
+		if rel.Node1 == next {
+			b.WriteString(fmt.Sprintf(" <-[%s]- %s", rel.Type, next))
+		} else {
+			b.WriteString(fmt.Sprintf(" -[%s]-> %s", rel.Type, next))
+		}
+	}
+	return b.String()
+}
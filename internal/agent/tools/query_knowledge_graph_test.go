@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestParseGraphToolQueries(t *testing.T) {
+	graphQuery, pathQuery := parseGraphToolQueries(map[string]interface{}{
+		"hops":           float64(9),
+		"relation_types": []interface{}{"uses", ""},
+		"path_from":      " Docker ",
+		"path_to":        "Kubernetes",
+	}, "容器编排")
+	if len(graphQuery.Nodes) != 1 || graphQuery.Nodes[0] != "容器编排" {
+		t.Errorf("expected the query as entity, got %v", graphQuery.Nodes)
+	}
+	if graphQuery.Hops != maxGraphToolHops || graphQuery.Limit != defaultGraphToolLimit {
+		t.Errorf("unexpected hops %d and limit %d", graphQuery.Hops, graphQuery.Limit)
+	}
+	if pathQuery == nil || pathQuery.Source != "Docker" || len(pathQuery.RelationTypes) != 1 {
+		t.Errorf("unexpected path query: %+v", pathQuery)
+	}
+
+	_, pathQuery = parseGraphToolQueries(map[string]interface{}{"path_from": "Docker"}, "Docker")
+	if pathQuery != nil {
+		t.Errorf("expected no path query without path_to, got %+v", pathQuery)
+	}
+}
+
+func TestMergeAndRankGraph(t *testing.T) {
+	graph := &types.GraphData{}
+	mergeGraph(graph, &types.GraphData{
+		Node: []*types.GraphNode{
+			{Name: "Docker", Chunks: []string{"c1"}, Degree: 1},
+			{Name: "containerd", Chunks: []string{"c2"}, Degree: 3},
+		},
+		Relation: []*types.GraphRelation{{Node1: "Docker", Node2: "containerd", Type: "uses"}},
+	})
+	mergeGraph(graph, &types.GraphData{
+		Node: []*types.GraphNode{
+			{Name: "Docker", Chunks: []string{"c1", "c3"}, Degree: 1},
+			{Name: "runc", Chunks: []string{"c4"}, Degree: 1},
+		},
+		Relation: []*types.GraphRelation{
+			{Node1: "Docker", Node2: "containerd", Type: "uses"},
+			{Node1: "containerd", Node2: "runc", Type: "uses"},
+		},
+	})
+	if len(graph.Node) != 3 || len(graph.Relation) != 2 {
+		t.Fatalf("expected 3 nodes and 2 relations, got %d and %d", len(graph.Node), len(graph.Relation))
+	}
+	if docker := graph.Node[0]; docker.Degree != 2 || len(docker.Chunks) != 2 {
+		t.Errorf("expected merged Docker node, got %+v", docker)
+	}
+
+	types.RankGraphNodes(graph, func(name string) bool { return name == "Docker" }, 2)
+	if len(graph.Node) != 2 || graph.Node[0].Name != "Docker" || graph.Node[1].Name != "containerd" {
+		t.Errorf("expected start node then highest degree, got %v and %v", graph.Node[0].Name, graph.Node[1].Name)
+	}
+	if len(graph.Relation) != 1 {
+		t.Errorf("expected relations to dropped nodes removed, got %d", len(graph.Relation))
+	}
+}
+
+func TestFormatGraphPath(t *testing.T) {
+	path := &types.GraphData{
+		Node: []*types.GraphNode{{Name: "A"}, {Name: "B"}, {Name: "C"}},
+		Relation: []*types.GraphRelation{
+			{Node1: "A", Node2: "B", Type: "uses"},
+			{Node1: "C", Node2: "B", Type: "contains"},
+		},
+	}
+	if got, want := formatGraphPath(path), "A -[uses]-> B <-[contains]- C"; got != want {
+		t.Errorf("formatGraphPath() = %q, want %q", got, want)
+	}
+}
@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/common"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -16,6 +18,13 @@ type ToolRegistry struct {
 	knowledgeService interfaces.KnowledgeService
 	chunkService     interfaces.ChunkService
 	db               *gorm.DB
+
+	// Tool policy state, see policy.go
+	mu             sync.Mutex
+	defaultTimeout time.Duration
+	policies       map[string]*types.ToolPolicy
+	approver       ToolApprover
+	budget         *CallBudget
 }
 
 // NewToolRegistry creates a new tool registry
@@ -46,6 +55,16 @@ func (r *ToolRegistry) GetTool(name string) (types.Tool, error) {
 	return tool, nil
 }
 
+// IsStateful reports whether the named tool keeps state across calls and must not run concurrently
+func (r *ToolRegistry) IsStateful(name string) bool {
+	tool, exists := r.tools[name]
+	if !exists {
+		return false
+	}
+	stateful, ok := tool.(StatefulTool)
+	return ok && stateful.Stateful()
+}
+
 // ListTools returns all registered tool names
 func (r *ToolRegistry) ListTools() []string {
 	names := make([]string, 0, len(r.tools))
@@ -68,7 +87,8 @@ func (r *ToolRegistry) GetFunctionDefinitions() []types.FunctionDefinition {
 	return definitions
 }
 
-// ExecuteTool executes a tool by name with the given arguments
+// ExecuteTool executes a tool by name with the given arguments,
+// enforcing the timeout, call limit, approval and output size of its policy
 func (r *ToolRegistry) ExecuteTool(
 	ctx context.Context,
 	name string,
@@ -90,7 +110,21 @@ func (r *ToolRegistry) ExecuteTool(
 		}, err
 	}
 
-	result, execErr := tool.Execute(ctx, args)
+	policy := r.policyFor(name)
+	if policy != nil {
+		if blocked := r.authorize(ctx, name, args, policy); blocked != nil {
+			common.PipelineWarn(ctx, "AgentTool", "execute_blocked", map[string]interface{}{
+				"tool":  name,
+				"error": blocked.Error,
+			})
+			return blocked, nil
+		}
+	}
+
+	result, execErr := runTool(ctx, tool, args, r.timeoutFor(tool, policy))
+	if result != nil && policy != nil && policy.MaxOutputChars > 0 {
+		result.Output = TruncateOutput(result.Output, policy.MaxOutputChars)
+	}
 	fields := map[string]interface{}{
 		"tool": name,
 		"args": args,
@@ -158,6 +158,11 @@ func (t *SequentialThinkingTool) Parameters() map[string]interface{} {
 	}
 }
 
+// Stateful reports that thoughts build on the thought history of previous calls
+func (t *SequentialThinkingTool) Stateful() bool {
+	return true
+}
+
 // Execute executes the sequential thinking tool
 func (t *SequentialThinkingTool) Execute(ctx context.Context, args map[string]interface{}) (*types.ToolResult, error) {
 	logger.Infof(ctx, "[Tool][SequentialThinking] Execute started")
@@ -2,6 +2,7 @@ package tools
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
@@ -38,6 +39,24 @@ type ToolExecutor interface {
 	GetContext() map[string]interface{}
 }
 
+// StatefulTool is implemented by tools that keep state across calls.
+// Calls of stateful tools within a round are executed one after another in their original order.
+type StatefulTool interface {
+	types.Tool
+
+	// Stateful reports whether calls of the tool depend on previous calls
+	Stateful() bool
+}
+
+// TimedTool is implemented by tools that need another timeout than the registry default,
+// a timeout set by the tool policy still takes precedence
+type TimedTool interface {
+	types.Tool
+
+	// Timeout returns the execution timeout of the tool
+	Timeout() time.Duration
+}
+
 // Shared helper functions for tool output formatting
 
 // GetRelevanceLevel converts a score to a human-readable relevance level
@@ -0,0 +1,46 @@
+package answercache
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// 答案缓存类型
+const (
+	TypeMemory = "memory"
+	TypeRedis  = "redis"
+)
+
+// 默认配置
+const (
+	DefaultTTL        = 24 * time.Hour
+	DefaultMaxEntries = 200 // 每个缓存范围最多保留的答案数
+)
+
+// NewAnswerCache 创建语义答案缓存
+func NewAnswerCache() (interfaces.AnswerCache, error) {
+	ttl := DefaultTTL
+	if d, err := time.ParseDuration(os.Getenv("ANSWER_CACHE_TTL")); err == nil && d > 0 {
+		ttl = d
+	}
+	switch os.Getenv("ANSWER_CACHE_TYPE") {
+	case TypeRedis:
+		db, err := strconv.Atoi(os.Getenv("REDIS_DB"))
+		if err != nil {
+			db = 0
+		}
+		return NewRedisAnswerCache(
+			os.Getenv("REDIS_ADDR"),
+			os.Getenv("REDIS_PASSWORD"),
+			db,
+			os.Getenv("REDIS_PREFIX"),
+			ttl,
+			DefaultMaxEntries,
+		)
+	default:
+		return NewMemoryAnswerCache(ttl, DefaultMaxEntries), nil
+	}
+}
@@ -0,0 +1,122 @@
+package answercache
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// memoryScope holds the cached answers of a scope, oldest first
+type memoryScope struct {
+	knowledgeBaseIDs []string
+	answers          []*types.CachedAnswer
+}
+
+// MemoryAnswerCache implements AnswerCache using in-memory storage
+type MemoryAnswerCache struct {
+	// Map: scope key -> cached answers
+	scopes map[string]*memoryScope
+	// Map: knowledge base ID -> generation
+	generations map[string]int64
+	ttl         time.Duration
+	maxEntries  int
+	mu          sync.RWMutex
+}
+
+// NewMemoryAnswerCache creates a new in-memory answer cache
+func NewMemoryAnswerCache(ttl time.Duration, maxEntries int) *MemoryAnswerCache {
+	return &MemoryAnswerCache{
+		scopes:      make(map[string]*memoryScope),
+		generations: make(map[string]int64),
+		ttl:         ttl,
+		maxEntries:  maxEntries,
+	}
+}
+
+// resolveKey resolves the key of the scope unless it is already set
+func (m *MemoryAnswerCache) resolveKey(scope *types.AnswerCacheScope) string {
+	if scope.Key == "" {
+		scope.Key = scopeKey(scope, m.currentGenerations(scope))
+	}
+	return scope.Key
+}
+
+// Lookup returns the most similar cached answer of the scope
+func (m *MemoryAnswerCache) Lookup(
+	ctx context.Context,
+	scope *types.AnswerCacheScope,
+	embedding []float32,
+	threshold float64,
+) (*types.CachedAnswer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cached, exists := m.scopes[m.resolveKey(scope)]
+	if !exists {
+		return nil, nil
+	}
+	return bestMatch(cached.answers, embedding, threshold, m.ttl), nil
+}
+
+// Store caches an answer under the scope, evicting expired and the oldest answers beyond the limit
+func (m *MemoryAnswerCache) Store(ctx context.Context, scope *types.AnswerCacheScope, answer *types.CachedAnswer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := m.resolveKey(scope)
+	// The knowledge bases were invalidated since the scope was resolved
+	if key != scopeKey(scope, m.currentGenerations(scope)) {
+		return nil
+	}
+	if answer.CreatedAt.IsZero() {
+		answer.CreatedAt = time.Now()
+	}
+
+	cached, exists := m.scopes[key]
+	if !exists {
+		cached = &memoryScope{knowledgeBaseIDs: slices.Clone(scope.KnowledgeBaseIDs)}
+		m.scopes[key] = cached
+	}
+	cached.answers = slices.DeleteFunc(cached.answers, func(a *types.CachedAnswer) bool {
+		return time.Since(a.CreatedAt) > m.ttl
+	})
+	cached.answers = append(cached.answers, answer)
+	if len(cached.answers) > m.maxEntries {
+		cached.answers = cached.answers[len(cached.answers)-m.maxEntries:]
+	}
+	return nil
+}
+
+// currentGenerations returns the current generations of the knowledge bases of the scope
+func (m *MemoryAnswerCache) currentGenerations(scope *types.AnswerCacheScope) []int64 {
+	generations := make([]int64, len(scope.KnowledgeBaseIDs))
+	for i, kbID := range scope.KnowledgeBaseIDs {
+		generations[i] = m.generations[kbID]
+	}
+	return generations
+}
+
+// Invalidate drops the cached answers of every scope that includes one of the knowledge bases
+func (m *MemoryAnswerCache) Invalidate(ctx context.Context, knowledgeBaseIDs ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, kbID := range knowledgeBaseIDs {
+		m.generations[kbID]++
+	}
+	for key, cached := range m.scopes {
+		if slices.ContainsFunc(cached.knowledgeBaseIDs, func(id string) bool {
+			return slices.Contains(knowledgeBaseIDs, id)
+		}) {
+			delete(m.scopes, key)
+		}
+	}
+	return nil
+}
+
+// Ensure MemoryAnswerCache implements AnswerCache interface
+var _ interfaces.AnswerCache = (*MemoryAnswerCache)(nil)
@@ -0,0 +1,50 @@
+package answercache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestMemoryAnswerCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryAnswerCache(time.Hour, 10)
+	newScope := func(kbIDs ...string) *types.AnswerCacheScope {
+		return &types.AnswerCacheScope{KnowledgeBaseIDs: kbIDs, Fingerprint: "f"}
+	}
+
+	if err := cache.Store(ctx, newScope("kb1", "kb2"), &types.CachedAnswer{
+		Query: "q", Embedding: []float32{1, 0}, Answer: "cached",
+	}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	if got, _ := cache.Lookup(ctx, newScope("kb1", "kb2"), []float32{0.99, 0.1}, 0.95); got == nil || got.Answer != "cached" {
+		t.Fatalf("expected a hit for a similar query, got %v", got)
+	}
+	if got, _ := cache.Lookup(ctx, newScope("kb1", "kb2"), []float32{0, 1}, 0.95); got != nil {
+		t.Errorf("expected a miss for a different query, got %v", got)
+	}
+	if got, _ := cache.Lookup(ctx, newScope("kb1"), []float32{1, 0}, 0.95); got != nil {
+		t.Errorf("expected a miss for other knowledge bases, got %v", got)
+	}
+
+	// An answer generated before the invalidation must not be stored
+	pending := newScope("kb1", "kb2")
+	if got, _ := cache.Lookup(ctx, pending, []float32{0, 1}, 0.95); got != nil {
+		t.Fatalf("unexpected hit %v", got)
+	}
+	if err := cache.Invalidate(ctx, "kb2"); err != nil {
+		t.Fatalf("Invalidate failed: %v", err)
+	}
+	if err := cache.Store(ctx, pending, &types.CachedAnswer{Embedding: []float32{0, 1}, Answer: "stale"}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	for _, embedding := range [][]float32{{1, 0}, {0, 1}} {
+		if got, _ := cache.Lookup(ctx, newScope("kb1", "kb2"), embedding, 0.95); got != nil {
+			t.Errorf("expected the scope to be invalidated, got %v", got)
+		}
+	}
+}
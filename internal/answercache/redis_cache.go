@@ -0,0 +1,179 @@
+package answercache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisAnswerCache implements AnswerCache using a Redis List of answers per scope
+// and a generation counter per knowledge base
+type RedisAnswerCache struct {
+	client     *redis.Client
+	ttl        time.Duration // TTL for cached answers
+	prefix     string        // Redis key prefix
+	maxEntries int           // Maximum answers kept per scope
+}
+
+// NewRedisAnswerCache creates a new Redis-based answer cache
+func NewRedisAnswerCache(redisAddr, redisPassword string,
+	redisDB int, prefix string, ttl time.Duration, maxEntries int,
+) (*RedisAnswerCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: redisPassword,
+		DB:       redisDB,
+	})
+
+	// Verify connection
+	_, err := client.Ping(context.Background()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+
+	if prefix == "" {
+		prefix = "answer_cache" // Default prefix
+	} else {
+		prefix += ":answer_cache"
+	}
+
+	return &RedisAnswerCache{
+		client:     client,
+		ttl:        ttl,
+		prefix:     prefix,
+		maxEntries: maxEntries,
+	}, nil
+}
+
+// generationKey builds the Redis key for the generation of a knowledge base
+func (r *RedisAnswerCache) generationKey(kbID string) string {
+	return fmt.Sprintf("%s:generation:%s", r.prefix, kbID)
+}
+
+// entriesKey builds the Redis key for the answer list of a scope key
+func (r *RedisAnswerCache) entriesKey(scopeKey string) string {
+	sum := sha256.Sum256([]byte(scopeKey))
+	return fmt.Sprintf("%s:entries:%s", r.prefix, hex.EncodeToString(sum[:]))
+}
+
+// currentKey builds the scope key from the current generations of its knowledge bases
+func (r *RedisAnswerCache) currentKey(ctx context.Context, scope *types.AnswerCacheScope) (string, error) {
+	generations := make([]int64, len(scope.KnowledgeBaseIDs))
+	if len(scope.KnowledgeBaseIDs) > 0 {
+		keys := make([]string, len(scope.KnowledgeBaseIDs))
+		for i, kbID := range scope.KnowledgeBaseIDs {
+			keys[i] = r.generationKey(kbID)
+		}
+		values, err := r.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return "", fmt.Errorf("failed to get generations from Redis: %w", err)
+		}
+		for i, value := range values {
+			if s, ok := value.(string); ok {
+				fmt.Sscan(s, &generations[i])
+			}
+		}
+	}
+	return scopeKey(scope, generations), nil
+}
+
+// Lookup returns the most similar cached answer of the scope using Redis LRange
+func (r *RedisAnswerCache) Lookup(
+	ctx context.Context,
+	scope *types.AnswerCacheScope,
+	embedding []float32,
+	threshold float64,
+) (*types.CachedAnswer, error) {
+	if scope.Key == "" {
+		key, err := r.currentKey(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		scope.Key = key
+	}
+
+	results, err := r.client.LRange(ctx, r.entriesKey(scope.Key), 0, -1).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cached answers from Redis: %w", err)
+	}
+
+	answers := make([]*types.CachedAnswer, 0, len(results))
+	for _, result := range results {
+		var answer types.CachedAnswer
+		if err := json.Unmarshal([]byte(result), &answer); err != nil {
+			// Skip corrupted entries
+			continue
+		}
+		answers = append(answers, &answer)
+	}
+	return bestMatch(answers, embedding, threshold, r.ttl), nil
+}
+
+// Store caches an answer under the scope using Redis RPush, keeping the newest answers
+func (r *RedisAnswerCache) Store(ctx context.Context, scope *types.AnswerCacheScope, answer *types.CachedAnswer) error {
+	key, err := r.currentKey(ctx, scope)
+	if err != nil {
+		return err
+	}
+	// The knowledge bases were invalidated since the scope was resolved
+	if scope.Key != "" && scope.Key != key {
+		return nil
+	}
+	scope.Key = key
+
+	if answer.CreatedAt.IsZero() {
+		answer.CreatedAt = time.Now()
+	}
+	answerJSON, err := json.Marshal(answer)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached answer: %w", err)
+	}
+
+	entriesKey := r.entriesKey(key)
+	pipe := r.client.TxPipeline()
+	pipe.RPush(ctx, entriesKey, answerJSON)
+	pipe.LTrim(ctx, entriesKey, int64(-r.maxEntries), -1)
+	pipe.Expire(ctx, entriesKey, r.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store cached answer in Redis: %w", err)
+	}
+	return nil
+}
+
+// Invalidate bumps the generations of the knowledge bases, the answer lists
+// of their previous scope keys are no longer read and expire with their TTL
+func (r *RedisAnswerCache) Invalidate(ctx context.Context, knowledgeBaseIDs ...string) error {
+	if len(knowledgeBaseIDs) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	for _, kbID := range knowledgeBaseIDs {
+		pipe.Incr(ctx, r.generationKey(kbID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to invalidate answer cache in Redis: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis connection
+func (r *RedisAnswerCache) Close() error {
+	return r.client.Close()
+}
+
+// Ensure RedisAnswerCache implements AnswerCache interface
+var _ interfaces.AnswerCache = (*RedisAnswerCache)(nil)
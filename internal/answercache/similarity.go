@@ -0,0 +1,55 @@
+package answercache
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// scopeKey builds the key of a scope from its fingerprint and the current generations of its knowledge bases,
+// invalidating a knowledge base bumps its generation so the keys of all its scopes change
+func scopeKey(scope *types.AnswerCacheScope, generations []int64) string {
+	var b strings.Builder
+	b.WriteString(scope.Fingerprint)
+	for i, kbID := range scope.KnowledgeBaseIDs {
+		fmt.Fprintf(&b, "|%s@%d", kbID, generations[i])
+	}
+	return b.String()
+}
+
+// bestMatch returns the live answer most similar to the embedding with a similarity of at least threshold
+func bestMatch(answers []*types.CachedAnswer, embedding []float32,
+	threshold float64, ttl time.Duration,
+) *types.CachedAnswer {
+	var best *types.CachedAnswer
+	bestScore := threshold
+	for _, answer := range answers {
+		if time.Since(answer.CreatedAt) > ttl {
+			continue
+		}
+		if score := cosineSimilarity(answer.Embedding, embedding); score >= bestScore {
+			best, bestScore = answer, score
+		}
+	}
+	return best
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 if their dimensions differ
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// agentRunRepository implements the AgentRunRepository interface
+type agentRunRepository struct {
+	db *gorm.DB
+}
+
+// NewAgentRunRepository creates a new agent run repository
+func NewAgentRunRepository(db *gorm.DB) interfaces.AgentRunRepository {
+	return &agentRunRepository{db: db}
+}
+
+// CreateRun creates a run
+func (r *agentRunRepository) CreateRun(ctx context.Context, run *types.AgentRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// UpdateRun saves the status, round and checkpoint of a run
+func (r *agentRunRepository) UpdateRun(ctx context.Context, run *types.AgentRun) error {
+	return r.db.WithContext(ctx).Model(&types.AgentRun{}).
+		Where("id = ? AND tenant_id = ?", run.ID, run.TenantID).
+		Select("status", "current_round", "checkpoint", "error", "updated_at").
+		Updates(run).Error
+}
+
+// ClaimRun marks the run as running again if it is still in one of the statuses and was not updated since it
+// was read, the single conditional update lets only one of concurrent callers claim the run
+func (r *agentRunRepository) ClaimRun(ctx context.Context,
+	run *types.AgentRun, statuses []types.AgentRunStatus, now time.Time,
+) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&types.AgentRun{}).
+		Where("id = ? AND tenant_id = ? AND status IN ? AND updated_at = ?",
+			run.ID, run.TenantID, statuses, run.UpdatedAt).
+		Updates(map[string]interface{}{
+			"status":     types.AgentRunStatusRunning,
+			"error":      "",
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetRun gets a run of the tenant, it returns nil if the run does not exist
+func (r *agentRunRepository) GetRun(ctx context.Context, tenantID uint64, id string) (*types.AgentRun, error) {
+	var run types.AgentRun
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns lists the runs of the tenant in the given statuses without their checkpoints, newest first
+func (r *agentRunRepository) ListRuns(ctx context.Context,
+	tenantID uint64, statuses []types.AgentRunStatus,
+) ([]*types.AgentRun, error) {
+	var runs []*types.AgentRun
+	// The checkpoint is only needed to tell whether the run can be resumed
+	err := r.db.WithContext(ctx).
+		Select("id", "tenant_id", "session_id", "message_id", "query", "status", "current_round",
+			"error", "created_at", "updated_at",
+			"CASE WHEN checkpoint IS NULL THEN NULL ELSE '{}'::jsonb END AS checkpoint").
+		Where("tenant_id = ? AND status IN ?", tenantID, statuses).
+		Order("updated_at DESC").
+		Find(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository/dbtest"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestClaimRunIsConditional(t *testing.T) {
+	db, statements := dbtest.NewDryRunDB(t)
+	repo := NewAgentRunRepository(db)
+	read := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	now := read.Add(time.Hour)
+	run := &types.AgentRun{ID: "run-1", TenantID: 7, Status: types.AgentRunStatusStopped, UpdatedAt: read}
+	statuses := []types.AgentRunStatus{types.AgentRunStatusStopped, types.AgentRunStatusFailed}
+
+	// A dry run affects no rows, like an update losing the race
+	claimed, err := repo.ClaimRun(context.Background(), run, statuses, now)
+	if err != nil {
+		t.Fatalf("ClaimRun failed: %v", err)
+	}
+	if claimed {
+		t.Error("expected the claim to fail when no row is updated")
+	}
+
+	if len(*statements) != 1 {
+		t.Fatalf("expected a single statement, got %d", len(*statements))
+	}
+	stmt := (*statements)[0]
+	wantSQL := `UPDATE "agent_runs" SET "error"=$1,"status"=$2,"updated_at"=$3 ` +
+		`WHERE id = $4 AND tenant_id = $5 AND status IN ($6,$7) AND updated_at = $8`
+	if stmt.SQL != wantSQL {
+		t.Errorf("sql = %q, want %q", stmt.SQL, wantSQL)
+	}
+	wantVars := []interface{}{"", types.AgentRunStatusRunning, now, "run-1", uint64(7),
+		types.AgentRunStatusStopped, types.AgentRunStatusFailed, read}
+	if !reflect.DeepEqual(stmt.Vars, wantVars) {
+		t.Errorf("vars = %v, want %v", stmt.Vars, wantVars)
+	}
+}
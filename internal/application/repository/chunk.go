@@ -9,6 +9,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // chunkRepository implements the ChunkRepository interface
@@ -149,17 +150,52 @@ func (r *chunkRepository) UpdateChunks(ctx context.Context, chunks []*types.Chun
 	return r.db.WithContext(ctx).Save(chunks).Error
 }
 
-// DeleteChunk deletes a chunk by its ID
+// DeleteChunk deletes a chunk by its ID, together with its child text chunks of hierarchical chunking
 func (r *chunkRepository) DeleteChunk(ctx context.Context, tenantID uint64, id string) error {
-	return r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.Chunk{}).Error
+	return r.db.WithContext(ctx).Where(
+		"tenant_id = ? AND (id = ? OR (parent_chunk_id = ? AND chunk_type = ?))",
+		tenantID, id, id, types.ChunkTypeChildText,
+	).Delete(&types.Chunk{}).Error
 }
 
-// DeleteChunks deletes chunks by IDs in batch
+// DeleteChunks deletes chunks by IDs in batch, together with the child text chunks of hierarchical chunking
 func (r *chunkRepository) DeleteChunks(ctx context.Context, tenantID uint64, ids []string) error {
 	if len(ids) == 0 {
 		return nil
 	}
-	return r.db.WithContext(ctx).Where("tenant_id = ? AND id IN ?", tenantID, ids).Delete(&types.Chunk{}).Error
+	return r.db.WithContext(ctx).Where(
+		"tenant_id = ? AND (id IN ? OR (parent_chunk_id IN ? AND chunk_type = ?))",
+		tenantID, ids, ids, types.ChunkTypeChildText,
+	).Delete(&types.Chunk{}).Error
+}
+
+// ReplaceChunksByType replaces the chunks of a type in a knowledge base, except those of keepIDs, with the given
+// chunks in one transaction. The knowledge base row is locked, so concurrent replacements of the same knowledge
+// base run one after the other and each deletes the chunks the previous one created.
+func (r *chunkRepository) ReplaceChunksByType(ctx context.Context, tenantID uint64, kbID string,
+	chunkType types.ChunkType, chunks []*types.Chunk, keepIDs []string,
+) error {
+	for _, chunk := range chunks {
+		chunk.Content = common.CleanInvalidUTF8(chunk.Content)
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("tenant_id = ? AND id = ?", tenantID, kbID).
+			Take(&types.KnowledgeBase{}).Error; err != nil {
+			return err
+		}
+		query := tx.Where("tenant_id = ? AND knowledge_base_id = ? AND chunk_type = ?", tenantID, kbID, chunkType)
+		if len(keepIDs) > 0 {
+			query = query.Where("id NOT IN ?", keepIDs)
+		}
+		if err := query.Delete(&types.Chunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(chunks, 100).Error
+	})
 }
 
 // DeleteChunksByKnowledgeID deletes all chunks for a knowledge ID
@@ -251,6 +287,30 @@ func (r *chunkRepository) ListAllFAQChunksByKnowledgeID(
 	return allChunks, nil
 }
 
+// FindFAQChunkByQuestion returns the ID of the FAQ chunk of a knowledge base whose standard or similar
+// questions include the question, empty if there is none
+func (r *chunkRepository) FindFAQChunkByQuestion(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	question string,
+) (string, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND chunk_type = ? AND status = ?",
+			tenantID, kbID, types.ChunkTypeFAQ, types.ChunkStatusIndexed).
+		Where("metadata->>'standard_question' = ? OR metadata->'similar_questions' @> jsonb_build_array(?::text)",
+			question, question).
+		Limit(1).
+		Pluck("id", &ids).Error; err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	return ids[0], nil
+}
+
 // ListAllFAQChunksWithMetadataByKnowledgeBaseID lists all FAQ chunks for a knowledge base ID
 // Returns ID and Metadata fields for duplicate question checking
 // Uses batch query to handle large datasets
@@ -292,3 +352,17 @@ func (r *chunkRepository) ListAllFAQChunksWithMetadataByKnowledgeBaseID(
 
 	return allChunks, nil
 }
+
+// ListChunksByKnowledgeBaseIDAndType lists the chunks of a type in a knowledge base
+func (r *chunkRepository) ListChunksByKnowledgeBaseIDAndType(
+	ctx context.Context, tenantID uint64, kbID string, chunkType types.ChunkType,
+) ([]*types.Chunk, error) {
+	var chunks []*types.Chunk
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND chunk_type = ?", tenantID, kbID, chunkType).
+		Order("chunk_index ASC").
+		Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/repository/dbtest"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestFindFAQChunkByQuestionQueriesQuestions(t *testing.T) {
+	db, statements := dbtest.NewDryRunDB(t)
+	id, err := NewChunkRepository(db).FindFAQChunkByQuestion(context.Background(), 7, "kb1", "How to reset?")
+	if err != nil {
+		t.Fatalf("FindFAQChunkByQuestion failed: %v", err)
+	}
+	if id != "" {
+		t.Errorf("expected no chunk, got %q", id)
+	}
+
+	if len(*statements) != 1 {
+		t.Fatalf("expected a single statement, got %d", len(*statements))
+	}
+	stmt := (*statements)[0]
+	wantSQL := `SELECT "id" FROM "chunks" WHERE (tenant_id = $1 AND knowledge_base_id = $2 AND chunk_type = $3 ` +
+		`AND status = $4) AND (metadata->>'standard_question' = $5 ` +
+		`OR metadata->'similar_questions' @> jsonb_build_array($6::text)) AND "chunks"."deleted_at" IS NULL LIMIT $7`
+	if stmt.SQL != wantSQL {
+		t.Errorf("sql = %q, want %q", stmt.SQL, wantSQL)
+	}
+	wantVars := []interface{}{uint64(7), "kb1", types.ChunkTypeFAQ, types.ChunkStatusIndexed,
+		"How to reset?", "How to reset?", 1}
+	if !reflect.DeepEqual(stmt.Vars, wantVars) {
+		t.Errorf("vars = %v, want %v", stmt.Vars, wantVars)
+	}
+}
+
+func TestReplaceChunksByTypeLocksKnowledgeBase(t *testing.T) {
+	db, statements := dbtest.NewDryRunDB(t)
+	chunk := &types.Chunk{ID: "c3", TenantID: 7, KnowledgeBaseID: "kb1", ChunkType: types.ChunkTypeCommunityReport}
+	err := NewChunkRepository(db).ReplaceChunksByType(context.Background(),
+		7, "kb1", types.ChunkTypeCommunityReport, []*types.Chunk{chunk}, []string{"c1"})
+	if err != nil {
+		t.Fatalf("ReplaceChunksByType failed: %v", err)
+	}
+	if len(*statements) != 3 {
+		t.Fatalf("expected a lock, a delete and an insert statement, got %d", len(*statements))
+	}
+
+	// Replacements of the same knowledge base are serialized by the row lock
+	lock := (*statements)[0]
+	wantSQL := `SELECT "id" FROM "knowledge_bases" WHERE (tenant_id = $1 AND id = $2) ` +
+		`AND "knowledge_bases"."deleted_at" IS NULL LIMIT $3 FOR UPDATE`
+	if lock.SQL != wantSQL {
+		t.Errorf("lock sql = %q, want %q", lock.SQL, wantSQL)
+	}
+
+	// The chunks of the type are deleted in the same transaction, except the kept ones
+	del := (*statements)[1]
+	wantSQL = `UPDATE "chunks" SET "deleted_at"=$1 WHERE (tenant_id = $2 AND knowledge_base_id = $3 ` +
+		`AND chunk_type = $4) AND id NOT IN ($5) AND "chunks"."deleted_at" IS NULL`
+	if del.SQL != wantSQL {
+		t.Errorf("delete sql = %q, want %q", del.SQL, wantSQL)
+	}
+	if want := []interface{}{uint64(7), "kb1", types.ChunkTypeCommunityReport, "c1"}; !reflect.DeepEqual(
+		del.Vars[1:], want) {
+		t.Errorf("delete vars = %#v, want %#v", del.Vars[1:], want)
+	}
+	if insert := (*statements)[2]; !strings.HasPrefix(insert.SQL, `INSERT INTO "chunks"`) {
+		t.Errorf("expected the chunks to be inserted, got %q", insert.SQL)
+	}
+}
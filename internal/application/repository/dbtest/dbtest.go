@@ -0,0 +1,84 @@
+// Package dbtest provides a PostgreSQL database for repository tests that builds statements without a server
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Statement is a statement built by a dry run database
+type Statement struct {
+	SQL  string
+	Vars []interface{}
+}
+
+// errNoServer is returned if a statement reaches the connection despite the dry run
+var errNoServer = errors.New("dry run database has no server")
+
+// conn is a connection that only begins transactions, statements are never run in a dry run
+type conn struct{}
+
+func (conn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errNoServer
+}
+
+func (conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errNoServer
+}
+
+func (conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errNoServer
+}
+
+func (conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &tx{}, nil
+}
+
+// tx is a transaction of conn
+type tx struct {
+	conn
+}
+
+func (*tx) Commit() error   { return nil }
+func (*tx) Rollback() error { return nil }
+
+// NewDryRunDB returns a PostgreSQL database that builds statements without connecting, transactions are
+// accepted and the statements are captured in order
+func NewDryRunDB(t *testing.T) (*gorm.DB, *[]Statement) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn{}}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry run database: %v", err)
+	}
+	var statements []Statement
+	capture := func(tx *gorm.DB) {
+		statements = append(statements, Statement{SQL: tx.Statement.SQL.String(), Vars: tx.Statement.Vars})
+	}
+	for name, register := range map[string]func(string, func(*gorm.DB)) error{
+		"create": db.Callback().Create().After("gorm:create").Register,
+		"query":  db.Callback().Query().After("gorm:query").Register,
+		"update": db.Callback().Update().After("gorm:update").Register,
+		"delete": db.Callback().Delete().After("gorm:delete").Register,
+		"raw":    db.Callback().Raw().After("gorm:raw").Register,
+	} {
+		if err := register("dbtest:capture_"+name, capture); err != nil {
+			t.Fatalf("failed to register capture callback: %v", err)
+		}
+	}
+	return db, &statements
+}
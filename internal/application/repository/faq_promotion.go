@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// pgUniqueViolation is the PostgreSQL error code of a unique constraint violation
+const pgUniqueViolation = "23505"
+
+// faqPromotionRepository implements the FAQ promotion repository interface
+type faqPromotionRepository struct {
+	db *gorm.DB
+}
+
+// NewFAQPromotionRepository creates a new FAQ promotion repository
+func NewFAQPromotionRepository(db *gorm.DB) interfaces.FAQPromotionRepository {
+	return &faqPromotionRepository{db: db}
+}
+
+// CreatePromotion records a promotion, gorm.ErrDuplicatedKey is returned if the message was already promoted
+// into the knowledge base
+func (r *faqPromotionRepository) CreatePromotion(ctx context.Context, promotion *types.FAQPromotion) error {
+	err := r.db.WithContext(ctx).Create(promotion).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return gorm.ErrDuplicatedKey
+	}
+	return err
+}
+
+// SetPromotionEntry sets the entry of a recorded promotion
+func (r *faqPromotionRepository) SetPromotionEntry(ctx context.Context,
+	tenantID uint64, id string, entryID string,
+) error {
+	return r.db.WithContext(ctx).Model(&types.FAQPromotion{}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Update("entry_id", entryID).Error
+}
+
+// DeletePromotion deletes a recorded promotion
+func (r *faqPromotionRepository) DeletePromotion(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Delete(&types.FAQPromotion{}).Error
+}
+
+// GetPromotionByMessage gets the promotion of a message into a knowledge base
+func (r *faqPromotionRepository) GetPromotionByMessage(ctx context.Context,
+	tenantID uint64, kbID string, messageID string,
+) (*types.FAQPromotion, error) {
+	var promotion types.FAQPromotion
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND message_id = ?", tenantID, kbID, messageID).
+		First(&promotion).Error; err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// ListPromotions lists the promotions into a knowledge base, newest first
+func (r *faqPromotionRepository) ListPromotions(ctx context.Context,
+	tenantID uint64, kbID string, page *types.Pagination,
+) ([]*types.FAQPromotion, int64, error) {
+	db := r.db.WithContext(ctx).Model(&types.FAQPromotion{}).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var promotions []*types.FAQPromotion
+	if err := db.Order("created_at DESC").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&promotions).Error; err != nil {
+		return nil, 0, err
+	}
+	return promotions, total, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/repository/dbtest"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestCreatePromotionDuplicateMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		dbErr error
+		want  error
+	}{
+		{name: "unique violation", dbErr: &pgconn.PgError{Code: pgUniqueViolation}, want: gorm.ErrDuplicatedKey},
+		{name: "other error", dbErr: &pgconn.PgError{Code: "23503"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := dbtest.NewDryRunDB(t)
+			// The insert fails as it would on the unique index of the message
+			if err := db.Callback().Create().After("gorm:create").Register("test:fail", func(tx *gorm.DB) {
+				_ = tx.AddError(tt.dbErr)
+			}); err != nil {
+				t.Fatalf("failed to register callback: %v", err)
+			}
+			err := NewFAQPromotionRepository(db).CreatePromotion(context.Background(), &types.FAQPromotion{
+				ID: "p1", TenantID: 1, KnowledgeBaseID: "kb1", MessageID: "m1",
+			})
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+			if tt.want == nil && (err == nil || errors.Is(err, gorm.ErrDuplicatedKey)) {
+				t.Errorf("expected the original error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// feedbackVoteColumns aggregates the votes of feedback_chunks, the score is types.FeedbackScore
+var feedbackVoteColumns = []string{
+	"SUM(CASE WHEN vote > 0 THEN 1 ELSE 0 END) AS positive",
+	"SUM(CASE WHEN vote < 0 THEN 1 ELSE 0 END) AS negative",
+}
+
+// feedbackChunkColumns aggregates feedback_chunks by chunk
+var feedbackChunkColumns = strings.Join(
+	append([]string{"chunk_id", "MAX(knowledge_id) AS knowledge_id"}, feedbackVoteColumns...), ", ",
+)
+
+// feedbackScoreOrder orders aggregated votes by their score, lowest first
+var feedbackScoreOrder = fmt.Sprintf(
+	"SUM(vote)::float / (SUM(ABS(vote)) + %d), SUM(CASE WHEN vote < 0 THEN 1 ELSE 0 END) DESC",
+	types.FeedbackScorePrior,
+)
+
+// feedbackRepository implements the message feedback repository interface
+type feedbackRepository struct {
+	db *gorm.DB
+}
+
+// NewFeedbackRepository creates a new message feedback repository
+func NewFeedbackRepository(db *gorm.DB) interfaces.FeedbackRepository {
+	return &feedbackRepository{db: db}
+}
+
+// SaveFeedback creates or replaces the feedback on its message, the votes of earlier feedback are dropped
+func (r *feedbackRepository) SaveFeedback(ctx context.Context, feedback *types.MessageFeedback) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.MessageFeedback
+		err := tx.Where("tenant_id = ? AND message_id = ?", feedback.TenantID, feedback.MessageID).
+			First(&existing).Error
+		switch {
+		case err == nil:
+			feedback.ID = existing.ID
+			feedback.CreatedAt = existing.CreatedAt
+			if err := tx.Where("feedback_id = ?", existing.ID).Delete(&types.FeedbackChunk{}).Error; err != nil {
+				return err
+			}
+		case err != gorm.ErrRecordNotFound:
+			return err
+		}
+		for _, chunk := range feedback.Chunks {
+			chunk.ID = 0
+			chunk.FeedbackID = feedback.ID
+			chunk.TenantID = feedback.TenantID
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(feedback).Error
+	})
+}
+
+// GetFeedbackByMessage gets the feedback on a message with its votes
+func (r *feedbackRepository) GetFeedbackByMessage(ctx context.Context,
+	tenantID uint64, messageID string,
+) (*types.MessageFeedback, error) {
+	var feedback types.MessageFeedback
+	if err := r.db.WithContext(ctx).Preload("Chunks").
+		Where("tenant_id = ? AND message_id = ?", tenantID, messageID).
+		First(&feedback).Error; err != nil {
+		return nil, err
+	}
+	return &feedback, nil
+}
+
+// DeleteFeedbackByMessage deletes the feedback on a message with its votes
+func (r *feedbackRepository) DeleteFeedbackByMessage(ctx context.Context, tenantID uint64, messageID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var feedback types.MessageFeedback
+		if err := tx.Where("tenant_id = ? AND message_id = ?", tenantID, messageID).
+			First(&feedback).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("feedback_id = ?", feedback.ID).Delete(&types.FeedbackChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&feedback).Error
+	})
+}
+
+// ListFeedbackByKnowledgeBase lists the feedback with votes on chunks of a knowledge base, oldest first
+func (r *feedbackRepository) ListFeedbackByKnowledgeBase(ctx context.Context,
+	tenantID uint64, knowledgeBaseID string,
+) ([]*types.MessageFeedback, error) {
+	var feedback []*types.MessageFeedback
+	if err := r.db.WithContext(ctx).Preload("Chunks").
+		Where("tenant_id = ? AND id IN (?)", tenantID, r.knowledgeBaseFeedbackIDs(tenantID, knowledgeBaseID)).
+		Order("created_at").
+		Find(&feedback).Error; err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// SummarizeKnowledgeBase counts the feedback with votes on chunks of a knowledge base
+func (r *feedbackRepository) SummarizeKnowledgeBase(ctx context.Context,
+	tenantID uint64, knowledgeBaseID string,
+) (*types.FeedbackSummary, error) {
+	var summary types.FeedbackSummary
+	err := r.db.WithContext(ctx).Model(&types.MessageFeedback{}).
+		Select("COUNT(*) AS total, "+
+			"COALESCE(SUM(CASE WHEN rating = ? THEN 1 ELSE 0 END), 0) AS up, "+
+			"COALESCE(SUM(CASE WHEN rating = ? THEN 1 ELSE 0 END), 0) AS down, "+
+			"COALESCE(SUM(CASE WHEN correction <> '' THEN 1 ELSE 0 END), 0) AS corrections",
+			types.FeedbackRatingUp, types.FeedbackRatingDown).
+		Where("tenant_id = ? AND id IN (?)", tenantID, r.knowledgeBaseFeedbackIDs(tenantID, knowledgeBaseID)).
+		Scan(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// ChunkStats aggregates the votes on the given chunks, chunks without votes are left out
+func (r *feedbackRepository) ChunkStats(ctx context.Context,
+	tenantID uint64, chunkIDs []string,
+) ([]*types.ChunkFeedbackStats, error) {
+	var stats []*types.ChunkFeedbackStats
+	if len(chunkIDs) == 0 {
+		return stats, nil
+	}
+	err := r.db.WithContext(ctx).Model(&types.FeedbackChunk{}).
+		Select(feedbackChunkColumns).
+		Where("tenant_id = ? AND chunk_id IN ?", tenantID, chunkIDs).
+		Group("chunk_id").
+		Having("SUM(ABS(vote)) > 0").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stats {
+		s.Score = types.FeedbackScore(s.Positive, s.Negative)
+	}
+	return stats, nil
+}
+
+// WorstChunks aggregates the votes on chunks of a knowledge base, lowest score first
+func (r *feedbackRepository) WorstChunks(ctx context.Context,
+	tenantID uint64, knowledgeBaseID string, limit int,
+) ([]*types.ChunkFeedbackStats, error) {
+	var stats []*types.ChunkFeedbackStats
+	err := r.db.WithContext(ctx).Model(&types.FeedbackChunk{}).
+		Select(feedbackChunkColumns).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		Group("chunk_id").
+		Having("SUM(ABS(vote)) > 0").
+		Order(feedbackScoreOrder).
+		Limit(limit).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stats {
+		s.Score = types.FeedbackScore(s.Positive, s.Negative)
+	}
+	return stats, nil
+}
+
+// WorstKnowledge aggregates the votes on knowledge of a knowledge base, lowest score first
+func (r *feedbackRepository) WorstKnowledge(ctx context.Context,
+	tenantID uint64, knowledgeBaseID string, limit int,
+) ([]*types.KnowledgeFeedbackStats, error) {
+	var stats []*types.KnowledgeFeedbackStats
+	err := r.db.WithContext(ctx).Table("feedback_chunks").
+		Select(strings.Join(append([]string{
+			"feedback_chunks.knowledge_id",
+			"COALESCE(MAX(knowledges.title), '') AS knowledge_title",
+		}, feedbackVoteColumns...), ", ")).
+		Joins("LEFT JOIN knowledges ON knowledges.id = feedback_chunks.knowledge_id").
+		Where("feedback_chunks.tenant_id = ? AND feedback_chunks.knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		Group("feedback_chunks.knowledge_id").
+		Having("SUM(ABS(vote)) > 0").
+		Order(feedbackScoreOrder).
+		Limit(limit).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stats {
+		s.Score = types.FeedbackScore(s.Positive, s.Negative)
+	}
+	return stats, nil
+}
+
+// knowledgeBaseFeedbackIDs selects the IDs of feedback with votes on chunks of a knowledge base
+func (r *feedbackRepository) knowledgeBaseFeedbackIDs(tenantID uint64, knowledgeBaseID string) *gorm.DB {
+	return r.db.Model(&types.FeedbackChunk{}).
+		Select("DISTINCT feedback_id").
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID)
+}
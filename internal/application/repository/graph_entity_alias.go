@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// graphEntityAliasRepository implements the graph entity alias repository interface
+type graphEntityAliasRepository struct {
+	db *gorm.DB
+}
+
+// NewGraphEntityAliasRepository creates a new graph entity alias repository
+func NewGraphEntityAliasRepository(db *gorm.DB) interfaces.GraphEntityAliasRepository {
+	return &graphEntityAliasRepository{db: db}
+}
+
+// SaveAliases creates or updates the aliases and moves the aliases of entities that became aliases
+func (r *graphEntityAliasRepository) SaveAliases(ctx context.Context, aliases []*types.GraphEntityAlias) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, alias := range aliases {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "knowledge_base_id"}, {Name: "alias"}},
+				Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+					SQL:  "graph_entity_aliases.source <> ? OR EXCLUDED.source = ?",
+					Vars: []any{types.EntityAliasSourceManual, types.EntityAliasSourceManual},
+				}}},
+				DoUpdates: clause.AssignmentColumns([]string{"canonical", "source", "score", "updated_at"}),
+			}).Create(alias).Error; err != nil {
+				return fmt.Errorf("failed to save alias %s: %w", alias.Alias, err)
+			}
+			if err := tx.Model(&types.GraphEntityAlias{}).
+				Where("knowledge_base_id = ? AND canonical = ?", alias.KnowledgeBaseID, alias.Alias).
+				Update("canonical", alias.Canonical).Error; err != nil {
+				return fmt.Errorf("failed to move aliases of %s: %w", alias.Alias, err)
+			}
+		}
+		// Moving aliases may map a canonical entity to itself
+		return tx.Where("knowledge_base_id = ? AND alias = canonical", aliases[0].KnowledgeBaseID).
+			Delete(&types.GraphEntityAlias{}).Error
+	})
+}
+
+// ListAliases lists the aliases of a knowledge base ordered by canonical entity
+func (r *graphEntityAliasRepository) ListAliases(ctx context.Context,
+	tenantID uint64, kbID string,
+) ([]*types.GraphEntityAlias, error) {
+	var aliases []*types.GraphEntityAlias
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Order("canonical, alias").
+		Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// GetCanonicalNames returns the canonical entities of the names that are aliases
+func (r *graphEntityAliasRepository) GetCanonicalNames(ctx context.Context,
+	tenantID uint64, kbID string, names []string,
+) (map[string]string, error) {
+	canonical := make(map[string]string)
+	if len(names) == 0 {
+		return canonical, nil
+	}
+	var aliases []*types.GraphEntityAlias
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND alias IN ?", tenantID, kbID, names).
+		Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	for _, alias := range aliases {
+		canonical[alias.Alias] = alias.Canonical
+	}
+	return canonical, nil
+}
+
+// DeleteAlias deletes an alias
+func (r *graphEntityAliasRepository) DeleteAlias(ctx context.Context, tenantID uint64, kbID string, id string) error {
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND id = ?", tenantID, kbID, id).
+		Delete(&types.GraphEntityAlias{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// graphTombstoneRepository implements the graph tombstone repository interface
+type graphTombstoneRepository struct {
+	db *gorm.DB
+}
+
+// NewGraphTombstoneRepository creates a new graph tombstone repository
+func NewGraphTombstoneRepository(db *gorm.DB) interfaces.GraphTombstoneRepository {
+	return &graphTombstoneRepository{db: db}
+}
+
+// CreateTombstone records a deleted entity or relation, an existing tombstone is kept
+func (r *graphTombstoneRepository) CreateTombstone(ctx context.Context, tombstone *types.GraphTombstone) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(tombstone).Error
+}
+
+// DeleteTombstones deletes the tombstones matching the kind, name, source, target and type of the given ones
+func (r *graphTombstoneRepository) DeleteTombstones(ctx context.Context,
+	tenantID uint64, kbID string, tombstones []*types.GraphTombstone,
+) error {
+	if len(tombstones) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, tombstone := range tombstones {
+			if err := tx.Where(
+				"tenant_id = ? AND knowledge_base_id = ? AND kind = ? AND name = ? AND source = ? AND target = ? AND type = ?",
+				tenantID, kbID, tombstone.Kind, tombstone.Name, tombstone.Source, tombstone.Target, tombstone.Type,
+			).Delete(&types.GraphTombstone{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListTombstones lists the tombstones of a knowledge base
+func (r *graphTombstoneRepository) ListTombstones(ctx context.Context,
+	tenantID uint64, kbID string,
+) ([]*types.GraphTombstone, error) {
+	var tombstones []*types.GraphTombstone
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Find(&tombstones).Error; err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
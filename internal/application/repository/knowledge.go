@@ -292,3 +292,26 @@ func (r *knowledgeRepository) CountKnowledgeByStatus(
 
 	return count, nil
 }
+
+// ListKnowledgeIDsByFilter returns the IDs of knowledge in the knowledge bases that match the metadata filter
+func (r *knowledgeRepository) ListKnowledgeIDsByFilter(
+	ctx context.Context,
+	tenantID uint64,
+	kbIDs []string,
+	filter *types.MetadataFilter,
+) ([]string, error) {
+	query := r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id IN ?", tenantID, kbIDs)
+	if filter != nil {
+		cond, vars, err := BuildMetadataFilterSQL(filter)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(cond, vars...)
+	}
+	var ids []string
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
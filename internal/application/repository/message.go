@@ -107,6 +107,56 @@ func (r *messageRepository) GetMessagesBySessionBeforeTime(
 	return messages, nil
 }
 
+// GetMessageLinksBySession retrieves the ID, parent and creation time of all messages of a session,
+// enough to resolve its branches without loading the message contents
+func (r *messageRepository) GetMessageLinksBySession(
+	ctx context.Context, sessionID string,
+) ([]*types.Message, error) {
+	var messages []*types.Message
+	if err := r.db.WithContext(ctx).Select("id", "session_id", "parent_id", "role", "created_at").
+		Where("session_id = ?", sessionID).Order("created_at ASC").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetAllMessagesBySession retrieves all messages of a session across all branches
+func (r *messageRepository) GetAllMessagesBySession(
+	ctx context.Context, sessionID string,
+) ([]*types.Message, error) {
+	var messages []*types.Message
+	if err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).
+		Order("created_at ASC").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// GetMessagesByIDs retrieves the messages of a session with the given IDs
+func (r *messageRepository) GetMessagesByIDs(
+	ctx context.Context, sessionID string, messageIDs []string,
+) ([]*types.Message, error) {
+	var messages []*types.Message
+	if len(messageIDs) == 0 {
+		return messages, nil
+	}
+	if err := r.db.WithContext(ctx).Where(
+		"session_id = ? AND id IN ?", sessionID, messageIDs,
+	).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// ReparentMessages moves the messages following parentID to follow newParentID instead
+func (r *messageRepository) ReparentMessages(
+	ctx context.Context, sessionID string, parentID string, newParentID string,
+) error {
+	return r.db.WithContext(ctx).Model(&types.Message{}).Where(
+		"session_id = ? AND parent_id = ?", sessionID, parentID,
+	).Update("parent_id", newParentID).Error
+}
+
 // UpdateMessage updates an existing message
 func (r *messageRepository) UpdateMessage(ctx context.Context, message *types.Message) error {
 	return r.db.WithContext(ctx).Model(&types.Message{}).Where(
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// numericPattern matches metadata values that can be cast to numeric safely.
+// Quantifiers are written as {0,1} because gorm treats every '?' as a placeholder.
+const numericPattern = `'^-{0,1}[0-9]+(\.[0-9]+){0,1}$'`
+
+// BuildMetadataFilterSQL translates a metadata filter into a condition on the knowledges table, which engines
+// sharing the database use in a subquery.
+// Every leaf condition is coalesced to FALSE, so missing fields never match and NOT behaves as expected.
+func BuildMetadataFilterSQL(filter *types.MetadataFilter) (string, []interface{}, error) {
+	switch filter.Op {
+	case types.MetadataFilterOpAnd, types.MetadataFilterOpOr:
+		parts := make([]string, 0, len(filter.Filters))
+		var vars []interface{}
+		for _, sub := range filter.Filters {
+			sql, subVars, err := BuildMetadataFilterSQL(sub)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, sql)
+			vars = append(vars, subVars...)
+		}
+		sep := " AND "
+		if filter.Op == types.MetadataFilterOpOr {
+			sep = " OR "
+		}
+		return "(" + strings.Join(parts, sep) + ")", vars, nil
+	case types.MetadataFilterOpNot:
+		sql, vars, err := BuildMetadataFilterSQL(filter.Filters[0])
+		if err != nil {
+			return "", nil, err
+		}
+		return "(NOT " + sql + ")", vars, nil
+	}
+
+	column, columnVars := metadataFilterColumn(filter.Field)
+	var cond string
+	var vars []interface{}
+	switch filter.Op {
+	case types.MetadataFilterOpEq:
+		value, err := metadataFilterValue(filter.Field, filter.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		cond = column + " = ?"
+		vars = append(columnVars, value)
+	case types.MetadataFilterOpIn:
+		values := make([]interface{}, 0, len(filter.Values))
+		for _, v := range filter.Values {
+			value, err := metadataFilterValue(filter.Field, v)
+			if err != nil {
+				return "", nil, err
+			}
+			values = append(values, value)
+		}
+		cond = column + " IN ?"
+		vars = append(columnVars, values)
+	case types.MetadataFilterOpRange:
+		_, isMetadata := types.MetadataKey(filter.Field)
+		var parts []string
+		for _, bound := range filter.RangeBounds() {
+			if number, ok := toFloat(bound.Value); ok && isMetadata {
+				// Compare numerically, values that are not numbers never match
+				parts = append(parts, fmt.Sprintf("(CASE WHEN %s ~ %s THEN (%s)::numeric END) %s ?",
+					column, numericPattern, column, bound.Operator))
+				vars = append(append(append(vars, columnVars...), columnVars...), number)
+				continue
+			}
+			value, err := metadataFilterValue(filter.Field, bound.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, fmt.Sprintf("%s %s ?", column, bound.Operator))
+			vars = append(append(vars, columnVars...), value)
+		}
+		cond = strings.Join(parts, " AND ")
+	default:
+		return "", nil, fmt.Errorf("invalid filter op: %s", filter.Op)
+	}
+	return "COALESCE((" + cond + "), FALSE)", vars, nil
+}
+
+// metadataFilterColumn returns the SQL expression of a filter field and the variables it uses
+func metadataFilterColumn(field string) (string, []interface{}) {
+	if key, ok := types.MetadataKey(field); ok {
+		return "(metadata->>(?::text))", []interface{}{key}
+	}
+	return field, nil
+}
+
+// metadataFilterValue converts a filter value into the SQL variable compared against the field
+func metadataFilterValue(field string, value interface{}) (interface{}, error) {
+	if field == types.MetadataFilterFieldCreatedAt {
+		return types.ParseMetadataFilterTime(value)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	if number, ok := toFloat(value); ok {
+		// JSON text of metadata numbers, e.g. 3 rather than 3.0
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	}
+	return nil, fmt.Errorf("invalid value for filter field %s: %v", field, value)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestBuildMetadataFilterSQL(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	numericCase := "(CASE WHEN (metadata->>(?::text)) ~ " + numericPattern + " THEN ((metadata->>(?::text)))::numeric END)"
+	tests := []struct {
+		name   string
+		filter *types.MetadataFilter
+		sql    string
+		vars   []interface{}
+	}{
+		{
+			name:   "eq on column",
+			filter: &types.MetadataFilter{Op: types.MetadataFilterOpEq, Field: "file_type", Value: "pdf"},
+			sql:    "COALESCE((file_type = ?), FALSE)",
+			vars:   []interface{}{"pdf"},
+		},
+		{
+			name:   "eq on metadata number",
+			filter: &types.MetadataFilter{Op: types.MetadataFilterOpEq, Field: "metadata.year", Value: float64(2024)},
+			sql:    "COALESCE(((metadata->>(?::text)) = ?), FALSE)",
+			vars:   []interface{}{"year", "2024"},
+		},
+		{
+			name:   "eq on metadata bool",
+			filter: &types.MetadataFilter{Op: types.MetadataFilterOpEq, Field: "metadata.public", Value: true},
+			sql:    "COALESCE(((metadata->>(?::text)) = ?), FALSE)",
+			vars:   []interface{}{"public", "true"},
+		},
+		{
+			name: "in",
+			filter: &types.MetadataFilter{
+				Op: types.MetadataFilterOpIn, Field: "tag_id", Values: []interface{}{"t1", "t2"},
+			},
+			sql:  "COALESCE((tag_id IN ?), FALSE)",
+			vars: []interface{}{[]interface{}{"t1", "t2"}},
+		},
+		{
+			name: "range on created_at",
+			filter: &types.MetadataFilter{
+				Op: types.MetadataFilterOpRange, Field: "created_at", Gte: "2024-01-01",
+			},
+			sql:  "COALESCE((created_at >= ?), FALSE)",
+			vars: []interface{}{day},
+		},
+		{
+			name: "numeric range on metadata",
+			filter: &types.MetadataFilter{
+				Op: types.MetadataFilterOpRange, Field: "metadata.pages", Gt: 10, Lte: float64(20.5),
+			},
+			sql:  "COALESCE((" + numericCase + " > ? AND " + numericCase + " <= ?), FALSE)",
+			vars: []interface{}{"pages", "pages", float64(10), "pages", "pages", 20.5},
+		},
+		{
+			name: "string range on metadata",
+			filter: &types.MetadataFilter{
+				Op: types.MetadataFilterOpRange, Field: "metadata.version", Lt: "v2",
+			},
+			sql:  "COALESCE(((metadata->>(?::text)) < ?), FALSE)",
+			vars: []interface{}{"version", "v2"},
+		},
+		{
+			name: "and, or and not",
+			filter: &types.MetadataFilter{Op: types.MetadataFilterOpAnd, Filters: []*types.MetadataFilter{
+				{Op: types.MetadataFilterOpEq, Field: "file_type", Value: "pdf"},
+				{Op: types.MetadataFilterOpOr, Filters: []*types.MetadataFilter{
+					{Op: types.MetadataFilterOpEq, Field: "tag_id", Value: "t1"},
+					{Op: types.MetadataFilterOpNot, Filters: []*types.MetadataFilter{
+						{Op: types.MetadataFilterOpEq, Field: "metadata.lang", Value: "en"},
+					}},
+				}},
+			}},
+			sql: "(COALESCE((file_type = ?), FALSE) AND (COALESCE((tag_id = ?), FALSE) OR " +
+				"(NOT COALESCE(((metadata->>(?::text)) = ?), FALSE))))",
+			vars: []interface{}{"pdf", "t1", "lang", "en"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars, err := BuildMetadataFilterSQL(tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(vars, tt.vars) {
+				t.Errorf("vars = %#v, want %#v", vars, tt.vars)
+			}
+		})
+	}
+}
+
+func TestBuildMetadataFilterSQLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *types.MetadataFilter
+	}{
+		{
+			name:   "invalid op",
+			filter: &types.MetadataFilter{Op: "like", Field: "file_type", Value: "pdf"},
+		},
+		{
+			name:   "invalid value",
+			filter: &types.MetadataFilter{Op: types.MetadataFilterOpEq, Field: "file_type", Value: []string{"pdf"}},
+		},
+		{
+			name:   "invalid created_at",
+			filter: &types.MetadataFilter{Op: types.MetadataFilterOpRange, Field: "created_at", Lt: "yesterday"},
+		},
+		{
+			name: "invalid sub filter",
+			filter: &types.MetadataFilter{Op: types.MetadataFilterOpOr, Filters: []*types.MetadataFilter{
+				{Op: types.MetadataFilterOpEq, Field: "file_type", Value: "pdf"},
+				{Op: types.MetadataFilterOpIn, Field: "tag_id", Values: []interface{}{map[string]string{}}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := BuildMetadataFilterSQL(tt.filter); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package embedded
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// BM25 ranking parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// bm25Index is an in-memory inverted index scored with Okapi BM25
+type bm25Index struct {
+	postings map[string]map[int]int // term -> doc id -> term frequency
+	docLen   map[int]int
+	totalLen int
+}
+
+// newBM25Index creates an empty inverted index
+func newBM25Index() *bm25Index {
+	return &bm25Index{
+		postings: make(map[string]map[int]int),
+		docLen:   make(map[int]int),
+	}
+}
+
+// tokenize splits text into lower-cased search terms using jieba, dropping
+// whitespace and punctuation tokens
+func tokenize(text string) []string {
+	words := types.Jieba.CutForSearch(text, true)
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || strings.IndexFunc(w, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsNumber(r)
+		}) < 0 {
+			continue
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// Add indexes the content of a document
+func (b *bm25Index) Add(id int, content string) {
+	tokens := tokenize(content)
+	for _, t := range tokens {
+		docs, ok := b.postings[t]
+		if !ok {
+			docs = make(map[int]int)
+			b.postings[t] = docs
+		}
+		docs[id]++
+	}
+	b.docLen[id] = len(tokens)
+	b.totalLen += len(tokens)
+}
+
+// Remove drops a document that was indexed with the given content
+func (b *bm25Index) Remove(id int, content string) {
+	if _, ok := b.docLen[id]; !ok {
+		return
+	}
+	for _, t := range tokenize(content) {
+		if docs, ok := b.postings[t]; ok {
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(b.postings, t)
+			}
+		}
+	}
+	b.totalLen -= b.docLen[id]
+	delete(b.docLen, id)
+}
+
+// Search scores all documents accepted by allow against the query terms
+func (b *bm25Index) Search(query string, allow func(int) bool) map[int]float64 {
+	scores := make(map[int]float64)
+	n := float64(len(b.docLen))
+	if n == 0 {
+		return scores
+	}
+	avgLen := float64(b.totalLen) / n
+
+	seen := make(map[string]struct{})
+	for _, t := range tokenize(query) {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+
+		docs := b.postings[t]
+		if len(docs) == 0 {
+			continue
+		}
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range docs {
+			if allow != nil && !allow(id) {
+				continue
+			}
+			f := float64(tf)
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(b.docLen[id])/avgLen)
+			scores[id] += idf * f * (bm25K1 + 1) / (f + norm)
+		}
+	}
+	return scores
+}
@@ -0,0 +1,256 @@
+package embedded
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// HNSW construction parameters
+const (
+	hnswM              = 16
+	hnswMaxConn0       = hnswM * 2
+	hnswEfConstruction = 200
+	hnswEfSearch       = 64
+	hnswSeed           = 42
+)
+
+// hnswNode is a single vector in the graph, with its neighbour lists per layer
+type hnswNode struct {
+	vector  []float32
+	friends [][]int
+}
+
+// hnswIndex is an in-memory Hierarchical Navigable Small World graph over
+// normalized vectors, using cosine distance (1 - dot product).
+// Level assignment uses a seeded random source, so rebuilding the index from
+// the same insertion order always produces the same graph.
+type hnswIndex struct {
+	nodes     map[int]*hnswNode
+	entry     int
+	maxLevel  int
+	levelMult float64
+	rng       *rand.Rand
+}
+
+// newHNSWIndex creates an empty HNSW index
+func newHNSWIndex() *hnswIndex {
+	return &hnswIndex{
+		nodes:     make(map[int]*hnswNode),
+		entry:     -1,
+		levelMult: 1 / math.Log(float64(hnswM)),
+		rng:       rand.New(rand.NewSource(hnswSeed)),
+	}
+}
+
+// candidate is a node together with its distance to the query
+type candidate struct {
+	id   int
+	dist float32
+}
+
+// minHeap pops the closest candidate first
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// maxHeap pops the farthest candidate first
+type maxHeap struct{ minHeap }
+
+func (h maxHeap) Less(i, j int) bool { return h.minHeap[i].dist > h.minHeap[j].dist }
+
+// distance returns the cosine distance between two normalized vectors
+func distance(a, b []float32) float32 {
+	var dot float32
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return 1 - dot
+}
+
+// normalize returns a unit-length copy of the vector
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i, x := range v {
+		out[i] = x * scale
+	}
+	return out
+}
+
+// Len returns the number of nodes in the graph
+func (h *hnswIndex) Len() int {
+	return len(h.nodes)
+}
+
+// Add inserts a normalized vector under the given id
+func (h *hnswIndex) Add(id int, vector []float32) {
+	level := int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+	node := &hnswNode{vector: vector, friends: make([][]int, level+1)}
+	h.nodes[id] = node
+
+	if h.entry < 0 {
+		h.entry = id
+		h.maxLevel = level
+		return
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedyClosest(vector, ep, l)
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		found := h.searchLayer(vector, ep, hnswEfConstruction, l, nil)
+		neighbours := found
+		if len(neighbours) > hnswM {
+			neighbours = neighbours[:hnswM]
+		}
+		node.friends[l] = make([]int, 0, len(neighbours))
+		for _, nb := range neighbours {
+			if nb.id == id {
+				continue
+			}
+			node.friends[l] = append(node.friends[l], nb.id)
+			h.link(nb.id, id, l)
+		}
+		if len(found) > 0 {
+			ep = found[0].id
+		}
+	}
+
+	if level > h.maxLevel {
+		h.entry = id
+		h.maxLevel = level
+	}
+}
+
+// link adds target to the neighbour list of id at the given layer, pruning it
+// back to the closest connections when it grows too large
+func (h *hnswIndex) link(id, target, level int) {
+	node := h.nodes[id]
+	node.friends[level] = append(node.friends[level], target)
+
+	maxConn := hnswM
+	if level == 0 {
+		maxConn = hnswMaxConn0
+	}
+	if len(node.friends[level]) <= maxConn {
+		return
+	}
+
+	scored := make([]candidate, len(node.friends[level]))
+	for i, f := range node.friends[level] {
+		scored[i] = candidate{id: f, dist: distance(node.vector, h.nodes[f].vector)}
+	}
+	sortCandidates(scored)
+	pruned := make([]int, maxConn)
+	for i := range pruned {
+		pruned[i] = scored[i].id
+	}
+	node.friends[level] = pruned
+}
+
+// greedyClosest walks the given layer towards the query and returns the closest node found
+func (h *hnswIndex) greedyClosest(query []float32, ep, level int) int {
+	best := ep
+	bestDist := distance(query, h.nodes[ep].vector)
+	for changed := true; changed; {
+		changed = false
+		for _, f := range h.nodes[best].friends[level] {
+			if d := distance(query, h.nodes[f].vector); d < bestDist {
+				best, bestDist, changed = f, d, true
+			}
+		}
+	}
+	return best
+}
+
+// searchLayer performs a best-first search on one layer and returns up to ef
+// candidates sorted by distance. Nodes rejected by allow are still traversed
+// but never returned, so filtered searches keep exploring until ef matching
+// nodes have been found or the reachable graph is exhausted.
+func (h *hnswIndex) searchLayer(query []float32, ep, ef, level int, allow func(int) bool) []candidate {
+	visited := map[int]struct{}{ep: {}}
+	epDist := distance(query, h.nodes[ep].vector)
+
+	candidates := &minHeap{{id: ep, dist: epDist}}
+	results := &maxHeap{}
+	if allow == nil || allow(ep) {
+		heap.Push(results, candidate{id: ep, dist: epDist})
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(candidate)
+		if results.Len() >= ef && current.dist > results.minHeap[0].dist {
+			break
+		}
+		node := h.nodes[current.id]
+		if level >= len(node.friends) {
+			continue
+		}
+		for _, f := range node.friends[level] {
+			if _, ok := visited[f]; ok {
+				continue
+			}
+			visited[f] = struct{}{}
+			d := distance(query, h.nodes[f].vector)
+			if results.Len() < ef || d < results.minHeap[0].dist {
+				heap.Push(candidates, candidate{id: f, dist: d})
+				if allow == nil || allow(f) {
+					heap.Push(results, candidate{id: f, dist: d})
+					if results.Len() > ef {
+						heap.Pop(results)
+					}
+				}
+			}
+		}
+	}
+
+	out := make([]candidate, results.Len())
+	copy(out, results.minHeap)
+	sortCandidates(out)
+	return out
+}
+
+// Search returns up to k nodes accepted by allow, closest first
+func (h *hnswIndex) Search(query []float32, k int, allow func(int) bool) []candidate {
+	if h.entry < 0 || k <= 0 {
+		return nil
+	}
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedyClosest(query, ep, l)
+	}
+	found := h.searchLayer(query, ep, max(hnswEfSearch, k), 0, allow)
+	if len(found) > k {
+		found = found[:k]
+	}
+	return found
+}
+
+// sortCandidates sorts by distance, breaking ties by id for determinism
+func sortCandidates(c []candidate) {
+	sort.Slice(c, func(i, j int) bool {
+		if c[i].dist != c[j].dist {
+			return c[i].dist < c[j].dist
+		}
+		return c[i].id < c[j].id
+	})
+}
@@ -0,0 +1,445 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultBruteForceLimit is the vector count up to which search scans every vector
+	defaultBruteForceLimit = 2048
+	// compactMinTombstones avoids rebuilding the indexes for a handful of deletions
+	compactMinTombstones = 1024
+)
+
+// NewEmbeddedRetrieveEngineRepository creates a repository that stores its data in dataDir
+// An empty dataDir keeps everything in memory only
+func NewEmbeddedRetrieveEngineRepository(dataDir string) (interfaces.RetrieveEngineRepository, error) {
+	log := logger.GetLogger(context.Background())
+	log.Infof("[Embedded] Initializing embedded retriever engine repository, data dir: %q", dataDir)
+
+	res := &embeddedRepository{
+		dataDir:         dataDir,
+		byID:            make(map[string]int),
+		vectors:         make(map[int]*hnswIndex),
+		keywords:        newBM25Index(),
+		bruteForceLimit: defaultBruteForceLimit,
+		walCompactBytes: defaultWALCompactBytes,
+	}
+	if err := res.load(); err != nil {
+		log.Errorf("[Embedded] Failed to load data: %v", err)
+		return nil, err
+	}
+
+	log.Infof("[Embedded] Successfully initialized repository, %d documents loaded", res.live)
+	return res, nil
+}
+
+// EngineType returns the retriever engine type (embedded)
+func (r *embeddedRepository) EngineType() types.RetrieverEngineType {
+	return types.EmbeddedRetrieverEngineType
+}
+
+// Support returns supported retriever types (keywords and vector)
+func (r *embeddedRepository) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
+}
+
+// calculateStorageSize estimates the storage size for a single document
+func (r *embeddedRepository) calculateStorageSize(doc *document) int64 {
+	// 1. Content text plus posting list entries (~1x)
+	contentSizeBytes := int64(len(doc.Content)) * 2
+
+	// 2. Vector storage (4 bytes per dimension) plus HNSW neighbour lists on layer 0
+	var vectorSizeBytes int64 = 0
+	var hnswIndexBytes int64 = 0
+	if len(doc.Embedding) > 0 {
+		vectorSizeBytes = int64(len(doc.Embedding) * 4)
+		hnswIndexBytes = hnswMaxConn0 * 8
+	}
+
+	// 3. Metadata (IDs and fixed overhead)
+	metadataSizeBytes := int64(len(doc.SourceID) + len(doc.ChunkID) +
+		len(doc.KnowledgeID) + len(doc.KnowledgeBaseID) + 64)
+
+	return contentSizeBytes + vectorSizeBytes + hnswIndexBytes + metadataSizeBytes
+}
+
+// EstimateStorageSize estimates total storage size for multiple indices
+func (r *embeddedRepository) EstimateStorageSize(ctx context.Context,
+	indexInfoList []*types.IndexInfo, params map[string]any,
+) int64 {
+	var totalStorageSize int64
+	for _, indexInfo := range indexInfoList {
+		totalStorageSize += r.calculateStorageSize(toDocument(indexInfo, params))
+	}
+	logger.GetLogger(ctx).Infof(
+		"[Embedded] Storage size for %d indices: %d bytes", len(indexInfoList), totalStorageSize,
+	)
+	return totalStorageSize
+}
+
+// Save stores a single index entry
+func (r *embeddedRepository) Save(ctx context.Context,
+	indexInfo *types.IndexInfo, additionalParams map[string]any,
+) error {
+	logger.GetLogger(ctx).Debugf("[Embedded] Saving index for chunk ID: %s", indexInfo.ChunkID)
+	return r.BatchSave(ctx, []*types.IndexInfo{indexInfo}, additionalParams)
+}
+
+// BatchSave stores multiple index entries
+func (r *embeddedRepository) BatchSave(ctx context.Context,
+	indexInfoList []*types.IndexInfo, additionalParams map[string]any,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(indexInfoList) == 0 {
+		log.Warn("[Embedded] Empty list provided to BatchSave, skipping")
+		return nil
+	}
+	log.Infof("[Embedded] Batch saving %d indices", len(indexInfoList))
+
+	docs := make([]*document, 0, len(indexInfoList))
+	for _, indexInfo := range indexInfoList {
+		doc := toDocument(indexInfo, additionalParams)
+		doc.ID = uuid.New().String()
+		docs = append(docs, doc)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.commit(&walRecord{Op: walOpAdd, Documents: docs}); err != nil {
+		log.Errorf("[Embedded] Failed to persist batch save: %v", err)
+		return fmt.Errorf("failed to batch save: %w", err)
+	}
+
+	log.Infof("[Embedded] Successfully batch saved %d indices", len(indexInfoList))
+	return nil
+}
+
+// deleteWhere deletes every live document matching the predicate and returns the count
+func (r *embeddedRepository) deleteWhere(match func(*document) bool) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []string
+	for _, doc := range r.docs {
+		if doc != nil && match(doc) {
+			ids = append(ids, doc.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := r.commit(&walRecord{Op: walOpRemove, IDs: ids}); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// DeleteByChunkIDList deletes indices by chunk IDs
+func (r *embeddedRepository) DeleteByChunkIDList(ctx context.Context, chunkIDList []string, dimension int) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkIDList) == 0 {
+		log.Warn("[Embedded] Empty chunk ID list provided for deletion, skipping")
+		return nil
+	}
+	ids := toSet(chunkIDList)
+	deleted, err := r.deleteWhere(func(d *document) bool { _, ok := ids[d.ChunkID]; return ok })
+	if err != nil {
+		log.Errorf("[Embedded] Failed to delete by chunk IDs: %v", err)
+		return fmt.Errorf("failed to delete by chunk IDs: %w", err)
+	}
+	log.Infof("[Embedded] Deleted %d documents by chunk IDs", deleted)
+	return nil
+}
+
+// DeleteBySourceIDList deletes indices by source IDs
+func (r *embeddedRepository) DeleteBySourceIDList(ctx context.Context, sourceIDList []string, dimension int) error {
+	log := logger.GetLogger(ctx)
+	if len(sourceIDList) == 0 {
+		log.Warn("[Embedded] Empty source ID list provided for deletion, skipping")
+		return nil
+	}
+	ids := toSet(sourceIDList)
+	deleted, err := r.deleteWhere(func(d *document) bool { _, ok := ids[d.SourceID]; return ok })
+	if err != nil {
+		log.Errorf("[Embedded] Failed to delete by source IDs: %v", err)
+		return fmt.Errorf("failed to delete by source IDs: %w", err)
+	}
+	log.Infof("[Embedded] Deleted %d documents by source IDs", deleted)
+	return nil
+}
+
+// DeleteByKnowledgeIDList deletes indices by knowledge IDs
+func (r *embeddedRepository) DeleteByKnowledgeIDList(ctx context.Context,
+	knowledgeIDList []string, dimension int,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeIDList) == 0 {
+		log.Warn("[Embedded] Empty knowledge ID list provided for deletion, skipping")
+		return nil
+	}
+	ids := toSet(knowledgeIDList)
+	deleted, err := r.deleteWhere(func(d *document) bool { _, ok := ids[d.KnowledgeID]; return ok })
+	if err != nil {
+		log.Errorf("[Embedded] Failed to delete by knowledge IDs: %v", err)
+		return fmt.Errorf("failed to delete by knowledge IDs: %w", err)
+	}
+	log.Infof("[Embedded] Deleted %d documents by knowledge IDs", deleted)
+	return nil
+}
+
+// BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
+func (r *embeddedRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkStatusMap) == 0 {
+		log.Warn("[Embedded] Empty chunk status map provided, skipping")
+		return nil
+	}
+	log.Infof("[Embedded] Batch updating chunk enabled status, count: %d", len(chunkStatusMap))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record := &walRecord{Op: walOpEnable}
+	for _, doc := range r.docs {
+		if doc == nil {
+			continue
+		}
+		if enabled, ok := chunkStatusMap[doc.ChunkID]; ok && doc.IsEnabled != enabled {
+			record.IDs = append(record.IDs, doc.ID)
+			record.Enabled = append(record.Enabled, enabled)
+		}
+	}
+	updated := len(record.IDs)
+	if updated > 0 {
+		if err := r.commit(record); err != nil {
+			log.Errorf("[Embedded] Failed to persist chunk status update: %v", err)
+			return fmt.Errorf("failed to update chunk enabled status: %w", err)
+		}
+	}
+
+	log.Infof("[Embedded] Successfully updated %d documents", updated)
+	return nil
+}
+
+// allowFunc builds the filter shared by all retrieval queries
+// Callers must hold the read lock while the returned function is used
+func (r *embeddedRepository) allowFunc(params types.RetrieveParams) func(int) bool {
+	knowledgeBaseIDs := toSet(params.KnowledgeBaseIDs)
+	knowledgeIDs := toSet(params.KnowledgeIDs)
+	excludeKnowledgeIDs := toSet(params.ExcludeKnowledgeIDs)
+	excludeChunkIDs := toSet(params.ExcludeChunkIDs)
+	return func(id int) bool {
+		doc := r.docs[id]
+		if doc == nil || !doc.IsEnabled {
+			return false
+		}
+		if len(knowledgeBaseIDs) > 0 {
+			if _, ok := knowledgeBaseIDs[doc.KnowledgeBaseID]; !ok {
+				return false
+			}
+		}
+		if len(knowledgeIDs) > 0 {
+			if _, ok := knowledgeIDs[doc.KnowledgeID]; !ok {
+				return false
+			}
+		}
+		if _, ok := excludeKnowledgeIDs[doc.KnowledgeID]; ok {
+			return false
+		}
+		if _, ok := excludeChunkIDs[doc.ChunkID]; ok {
+			return false
+		}
+		return true
+	}
+}
+
+// Retrieve dispatches the retrieval operation to the appropriate method based on retriever type
+func (r *embeddedRepository) Retrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("[Embedded] Processing retrieval request of type: %s", params.RetrieverType)
+
+	switch params.RetrieverType {
+	case types.VectorRetrieverType:
+		return r.VectorRetrieve(ctx, params)
+	case types.KeywordsRetrieverType:
+		return r.KeywordsRetrieve(ctx, params)
+	}
+
+	err := fmt.Errorf("invalid retriever type: %v", params.RetrieverType)
+	log.Errorf("[Embedded] %v", err)
+	return nil, err
+}
+
+// VectorRetrieve performs cosine similarity search, exact for small indexes and HNSW otherwise
+func (r *embeddedRepository) VectorRetrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Infof("[Embedded] Vector retrieval: dim=%d, topK=%d, threshold=%.4f",
+		len(params.Embedding), params.TopK, params.Threshold)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index := r.vectors[len(params.Embedding)]
+	if index == nil || params.TopK <= 0 {
+		log.Warnf("[Embedded] No vectors of dimension %d indexed", len(params.Embedding))
+		return buildRetrieveResult(nil, types.VectorRetrieverType), nil
+	}
+
+	query := normalize(params.Embedding)
+	allow := r.allowFunc(params)
+	var found []candidate
+	if index.Len() <= r.bruteForceLimit {
+		for id, node := range index.nodes {
+			if allow(id) {
+				found = append(found, candidate{id: id, dist: distance(query, node.vector)})
+			}
+		}
+		sortCandidates(found)
+		if len(found) > params.TopK {
+			found = found[:params.TopK]
+		}
+	} else {
+		found = index.Search(query, params.TopK, allow)
+	}
+
+	var results []*types.IndexWithScore
+	for _, c := range found {
+		score := float64(1 - c.dist)
+		if score < params.Threshold {
+			continue
+		}
+		results = append(results, r.docs[c.id].toIndexWithScore(score, types.MatchTypeEmbedding))
+	}
+
+	if len(results) == 0 {
+		log.Warnf("[Embedded] No vector matches found that meet threshold %.4f", params.Threshold)
+	} else {
+		log.Infof("[Embedded] Vector retrieval found %d results", len(results))
+		log.Debugf("[Embedded] Top result score: %.4f", results[0].Score)
+	}
+	return buildRetrieveResult(results, types.VectorRetrieverType), nil
+}
+
+// KeywordsRetrieve performs BM25 search over jieba tokens
+func (r *embeddedRepository) KeywordsRetrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Infof("[Embedded] Performing keywords retrieval with query: %s, topK: %d", params.Query, params.TopK)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scores := r.keywords.Search(params.Query, r.allowFunc(params))
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if params.TopK >= 0 && len(ids) > params.TopK {
+		ids = ids[:params.TopK]
+	}
+
+	results := make([]*types.IndexWithScore, 0, len(ids))
+	for _, id := range ids {
+		results = append(results, r.docs[id].toIndexWithScore(scores[id], types.MatchTypeKeywords))
+	}
+
+	if len(results) == 0 {
+		log.Warnf("[Embedded] No keyword matches found for query: %s", params.Query)
+	} else {
+		log.Infof("[Embedded] Keywords retrieval found %d results", len(results))
+	}
+	return buildRetrieveResult(results, types.KeywordsRetrieverType), nil
+}
+
+// CopyIndices copies index data from source knowledge base to target knowledge base
+func (r *embeddedRepository) CopyIndices(ctx context.Context,
+	sourceKnowledgeBaseID string,
+	sourceToTargetKBIDMap map[string]string,
+	sourceToTargetChunkIDMap map[string]string,
+	targetKnowledgeBaseID string,
+	dimension int,
+) error {
+	log := logger.GetLogger(ctx)
+	log.Infof(
+		"[Embedded] Copying indices from source knowledge base %s to target knowledge base %s, count: %d",
+		sourceKnowledgeBaseID, targetKnowledgeBaseID, len(sourceToTargetChunkIDMap),
+	)
+	if len(sourceToTargetChunkIDMap) == 0 {
+		log.Warn("[Embedded] Empty mapping, skipping copy")
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var copies []*document
+	for _, doc := range r.docs {
+		if doc == nil || doc.KnowledgeBaseID != sourceKnowledgeBaseID {
+			continue
+		}
+		targetChunkID, ok := sourceToTargetChunkIDMap[doc.ChunkID]
+		if !ok {
+			log.Warnf("[Embedded] Source chunk %s not found in target mapping, skipping", doc.ChunkID)
+			continue
+		}
+		targetKnowledgeID, ok := sourceToTargetKBIDMap[doc.KnowledgeID]
+		if !ok {
+			log.Warnf("[Embedded] Source knowledge %s not found in target mapping, skipping", doc.KnowledgeID)
+			continue
+		}
+		target := *doc
+		target.ID = uuid.New().String()
+		target.SourceID = targetChunkID
+		target.ChunkID = targetChunkID
+		target.KnowledgeID = targetKnowledgeID
+		target.KnowledgeBaseID = targetKnowledgeBaseID
+		target.Embedding = slices.Clone(doc.Embedding)
+		copies = append(copies, &target)
+	}
+	if len(copies) > 0 {
+		if err := r.commit(&walRecord{Op: walOpAdd, Documents: copies}); err != nil {
+			log.Errorf("[Embedded] Failed to persist copied indices: %v", err)
+			return fmt.Errorf("failed to copy indices: %w", err)
+		}
+	}
+
+	log.Infof("[Embedded] Index copy completed, total copied: %d", len(copies))
+	return nil
+}
+
+func buildRetrieveResult(results []*types.IndexWithScore, retrieverType types.RetrieverType) []*types.RetrieveResult {
+	return []*types.RetrieveResult{
+		{
+			Results:             results,
+			RetrieverEngineType: types.EmbeddedRetrieverEngineType,
+			RetrieverType:       retrieverType,
+			Error:               nil,
+		},
+	}
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,291 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func newTestRepository(t *testing.T, dataDir string) *embeddedRepository {
+	repo, err := NewEmbeddedRetrieveEngineRepository(dataDir)
+	if err != nil {
+		t.Fatalf("NewEmbeddedRetrieveEngineRepository failed: %v", err)
+	}
+	return repo.(*embeddedRepository)
+}
+
+func seedTestIndices(t *testing.T, repo *embeddedRepository) {
+	indices := []*types.IndexInfo{
+		{SourceID: "c1", ChunkID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: "Go 语言的向量检索"},
+		{SourceID: "c2", ChunkID: "c2", KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: "Python web framework"},
+		{SourceID: "c3", ChunkID: "c3", KnowledgeID: "k2", KnowledgeBaseID: "kb2", Content: "向量数据库与全文检索"},
+	}
+	params := map[string]any{
+		"embedding": map[string][]float32{
+			"c1": {1, 0, 0},
+			"c2": {0, 1, 0},
+			"c3": {0.9, 0.1, 0},
+		},
+	}
+	if err := repo.BatchSave(context.Background(), indices, params); err != nil {
+		t.Fatalf("BatchSave failed: %v", err)
+	}
+}
+
+func resultChunkIDs(results []*types.RetrieveResult) []string {
+	var ids []string
+	for _, r := range results {
+		for _, item := range r.Results {
+			ids = append(ids, item.ChunkID)
+		}
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEmbeddedRetrieve(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t, "")
+	seedTestIndices(t, repo)
+
+	tests := []struct {
+		name     string
+		params   types.RetrieveParams
+		expected []string
+	}{
+		{
+			name: "vector",
+			params: types.RetrieveParams{
+				RetrieverType: types.VectorRetrieverType, Embedding: []float32{1, 0, 0}, TopK: 2,
+			},
+			expected: []string{"c1", "c3"},
+		},
+		{
+			name: "vector with threshold and knowledge base filter",
+			params: types.RetrieveParams{
+				RetrieverType: types.VectorRetrieverType, Embedding: []float32{1, 0, 0}, TopK: 5,
+				Threshold: 0.5, KnowledgeBaseIDs: []string{"kb2"},
+			},
+			expected: []string{"c3"},
+		},
+		{
+			name: "vector with unknown dimension",
+			params: types.RetrieveParams{
+				RetrieverType: types.VectorRetrieverType, Embedding: []float32{1, 0}, TopK: 5,
+			},
+			expected: nil,
+		},
+		{
+			name: "keywords",
+			params: types.RetrieveParams{
+				RetrieverType: types.KeywordsRetrieverType, Query: "向量检索", TopK: 5,
+			},
+			expected: []string{"c1", "c3"},
+		},
+		{
+			name: "keywords with exclusions",
+			params: types.RetrieveParams{
+				RetrieverType: types.KeywordsRetrieverType, Query: "向量检索", TopK: 5,
+				ExcludeKnowledgeIDs: []string{"k1"},
+			},
+			expected: []string{"c3"},
+		},
+		{
+			name: "vector restricted to filtered knowledge",
+			params: types.RetrieveParams{
+				RetrieverType: types.VectorRetrieverType, Embedding: []float32{1, 0, 0}, TopK: 5,
+				KnowledgeIDs: []string{"k2"},
+			},
+			expected: []string{"c3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.Retrieve(ctx, tt.params)
+			if err != nil {
+				t.Fatalf("Retrieve failed: %v", err)
+			}
+			if got := resultChunkIDs(results); !equalIDs(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEmbeddedPersistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := newTestRepository(t, dir)
+	seedTestIndices(t, repo)
+
+	if err := repo.BatchUpdateChunkEnabledStatus(ctx, map[string]bool{"c1": false}); err != nil {
+		t.Fatalf("BatchUpdateChunkEnabledStatus failed: %v", err)
+	}
+	if err := repo.DeleteBySourceIDList(ctx, []string{"c2"}, 3); err != nil {
+		t.Fatalf("DeleteBySourceIDList failed: %v", err)
+	}
+	if err := repo.CopyIndices(ctx, "kb2",
+		map[string]string{"k2": "k2-copy"}, map[string]string{"c3": "c3-copy"}, "kb3", 3,
+	); err != nil {
+		t.Fatalf("CopyIndices failed: %v", err)
+	}
+
+	reopened := newTestRepository(t, dir)
+	results, err := reopened.Retrieve(ctx, types.RetrieveParams{
+		RetrieverType: types.VectorRetrieverType, Embedding: []float32{0, 1, 0}, TopK: 5,
+	})
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if got := resultChunkIDs(results); !equalIDs(got, []string{"c3", "c3-copy"}) {
+		t.Fatalf("expected [c3 c3-copy], got %v", got)
+	}
+	if item := results[0].Results[1]; item.KnowledgeID != "k2-copy" || item.KnowledgeBaseID != "kb3" {
+		t.Errorf("copied document not remapped: %+v", item)
+	}
+}
+
+func TestHNSWMatchesBruteForce(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t, "")
+	rng := rand.New(rand.NewSource(1))
+
+	const n, dim = 600, 16
+	embeddings := make(map[string][]float32, n)
+	indices := make([]*types.IndexInfo, 0, n)
+	for i := 0; i < n; i++ {
+		id := string(rune('a'+i%26)) + string(rune('a'+i/26))
+		vector := make([]float32, dim)
+		for j := range vector {
+			vector[j] = rng.Float32()*2 - 1
+		}
+		embeddings[id] = vector
+		kb := "kb1"
+		if i%3 == 0 {
+			kb = "kb2"
+		}
+		indices = append(indices, &types.IndexInfo{SourceID: id, ChunkID: id, KnowledgeBaseID: kb})
+	}
+	if err := repo.BatchSave(ctx, indices, map[string]any{"embedding": embeddings}); err != nil {
+		t.Fatalf("BatchSave failed: %v", err)
+	}
+
+	hits := 0
+	const queries = 50
+	for q := 0; q < queries; q++ {
+		query := make([]float32, dim)
+		for j := range query {
+			query[j] = rng.Float32()*2 - 1
+		}
+		params := types.RetrieveParams{
+			RetrieverType: types.VectorRetrieverType, Embedding: query, TopK: 1, KnowledgeBaseIDs: []string{"kb2"},
+		}
+
+		repo.bruteForceLimit = n
+		exact, err := repo.Retrieve(ctx, params)
+		if err != nil {
+			t.Fatalf("Retrieve failed: %v", err)
+		}
+		repo.bruteForceLimit = 0
+		approx, err := repo.Retrieve(ctx, params)
+		if err != nil {
+			t.Fatalf("Retrieve failed: %v", err)
+		}
+		if got := resultChunkIDs(approx); len(got) == 1 && equalIDs(got, resultChunkIDs(exact)) {
+			hits++
+		}
+	}
+	if hits < queries*9/10 {
+		t.Errorf("HNSW recall too low: %d/%d", hits, queries)
+	}
+}
+
+func TestEmbeddedWriteAheadLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := newTestRepository(t, dir)
+	seedTestIndices(t, repo)
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("w%d", i)
+		if err := repo.Save(ctx, &types.IndexInfo{
+			SourceID: id, ChunkID: id, KnowledgeID: "k3", KnowledgeBaseID: "kb3", Content: "增量写入",
+		}, nil); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); !os.IsNotExist(err) {
+		t.Fatalf("small changes should only be logged, snapshot: %v", err)
+	}
+	if err := repo.DeleteByKnowledgeIDList(ctx, []string{"k1"}, 3); err != nil {
+		t.Fatalf("DeleteByKnowledgeIDList failed: %v", err)
+	}
+
+	// A torn record left by a crash is dropped on replay
+	f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open log failed: %v", err)
+	}
+	if _, err := f.Write([]byte{42, 0, 0, 0, 1}); err != nil {
+		t.Fatalf("write log failed: %v", err)
+	}
+	f.Close()
+
+	reopened := newTestRepository(t, dir)
+	if reopened.live != 21 {
+		t.Fatalf("expected 21 documents after replay, got %d", reopened.live)
+	}
+
+	// Folding the log into the snapshot keeps the documents and empties the log
+	reopened.walCompactBytes = 1
+	if err := reopened.Save(ctx, &types.IndexInfo{SourceID: "s1", ChunkID: "s1", Content: "快照"}, nil); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if reopened.walBytes != 0 {
+		t.Fatalf("expected the log to be folded into the snapshot, %d bytes left", reopened.walBytes)
+	}
+	if again := newTestRepository(t, dir); again.live != 22 {
+		t.Fatalf("expected 22 documents after reload, got %d", again.live)
+	}
+}
+
+func TestEmbeddedFailedWriteKeepsMemory(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t, t.TempDir())
+	seedTestIndices(t, repo)
+	repo.wal.Close()
+
+	if err := repo.Save(ctx, &types.IndexInfo{SourceID: "c4", ChunkID: "c4", Content: "失败"}, nil); err == nil {
+		t.Fatal("expected Save to fail")
+	}
+	if err := repo.DeleteByChunkIDList(ctx, []string{"c1"}, 3); err == nil {
+		t.Fatal("expected DeleteByChunkIDList to fail")
+	}
+	if err := repo.BatchUpdateChunkEnabledStatus(ctx, map[string]bool{"c2": false}); err == nil {
+		t.Fatal("expected BatchUpdateChunkEnabledStatus to fail")
+	}
+	results, err := repo.Retrieve(ctx, types.RetrieveParams{
+		RetrieverType: types.VectorRetrieverType, Embedding: []float32{1, 1, 0}, TopK: 5,
+	})
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if got := resultChunkIDs(results); len(got) != 3 {
+		t.Errorf("failed writes must not change memory, got %v", got)
+	}
+}
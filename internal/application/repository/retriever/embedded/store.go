@@ -0,0 +1,333 @@
+package embedded
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+const (
+	snapshotVersion  = 2
+	snapshotFileName = "index.gob"
+	walFileName      = "wal.log"
+	// defaultWALCompactBytes is the log size from which the log is folded into the snapshot, the log is also
+	// folded once it outgrows the snapshot so rewriting the snapshot stays amortized linear
+	defaultWALCompactBytes = 4 << 20
+	// walHeaderSize is the length and CRC32 prefix of a log record
+	walHeaderSize = 8
+)
+
+// Operations of the write-ahead log
+const (
+	walOpAdd = iota + 1
+	walOpRemove
+	walOpEnable
+)
+
+// walRecord is a change of the repository in the write-ahead log. Documents are referred to by their ID so
+// replaying a record does not depend on the internal ids, which change on compaction.
+type walRecord struct {
+	Seq uint64
+	Op  int
+	// Documents added by walOpAdd
+	Documents []*document
+	// IDs of the documents removed by walOpRemove or updated by walOpEnable
+	IDs []string
+	// Enabled is the status set by walOpEnable
+	Enabled []bool
+}
+
+// snapshotPath returns the location of the snapshot file
+func (r *embeddedRepository) snapshotPath() string {
+	return filepath.Join(r.dataDir, snapshotFileName)
+}
+
+// walPath returns the location of the write-ahead log
+func (r *embeddedRepository) walPath() string {
+	return filepath.Join(r.dataDir, walFileName)
+}
+
+// load reads the snapshot from the data directory, if any, replays the write-ahead log over it and opens
+// the log for the next changes
+func (r *embeddedRepository) load() error {
+	if r.dataDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(r.dataDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	if err := r.loadSnapshot(); err != nil {
+		return err
+	}
+	return r.replayWAL()
+}
+
+// loadSnapshot reads the snapshot and rebuilds the indexes
+func (r *embeddedRepository) loadSnapshot() error {
+	f, err := os.Open(r.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	// Version 1 snapshots have no log
+	if snap.Version < 1 || snap.Version > snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", snap.Version)
+	}
+	r.rebuild(snap.Documents)
+	r.seq = snap.LastSeq
+	if info, err := f.Stat(); err == nil {
+		r.snapshotBytes = info.Size()
+	}
+	return nil
+}
+
+// replayWAL applies the log records newer than the snapshot. A torn record at the end of the log, left by a
+// crash during a write, is discarded.
+func (r *embeddedRepository) replayWAL() error {
+	f, err := os.OpenFile(r.walPath(), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open write-ahead log: %w", err)
+	}
+	reader := bufio.NewReader(f)
+	var valid int64
+	for {
+		record, size, err := readWALRecord(reader)
+		if err != nil {
+			// io.EOF at the end of the log, anything else is a torn or corrupted tail
+			break
+		}
+		valid += size
+		if record.Seq <= r.seq {
+			continue
+		}
+		r.apply(record)
+		r.seq = record.Seq
+	}
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to truncate write-ahead log: %w", err)
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to seek write-ahead log: %w", err)
+	}
+	r.wal = f
+	r.walBytes = valid
+	return nil
+}
+
+// readWALRecord reads a record and returns it with its size in the log
+func readWALRecord(reader io.Reader) (*walRecord, int64, error) {
+	header := make([]byte, walHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, 0, fmt.Errorf("torn record header: %w", err)
+		}
+		return nil, 0, err
+	}
+	length := binary.LittleEndian.Uint32(header[:4])
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, 0, fmt.Errorf("torn record: %w", err)
+	}
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, 0, errors.New("corrupted record")
+	}
+	var record walRecord
+	if err := gob.NewDecoder(bytes.NewReader(body)).Decode(&record); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return &record, int64(walHeaderSize + len(body)), nil
+}
+
+// commit durably appends the record to the log and only then applies it to memory, so a failed write leaves
+// the repository unchanged. Callers must hold the write lock.
+func (r *embeddedRepository) commit(record *walRecord) error {
+	if r.wal != nil {
+		record.Seq = r.seq + 1
+		var body bytes.Buffer
+		if err := gob.NewEncoder(&body).Encode(record); err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+		frame := make([]byte, walHeaderSize, walHeaderSize+body.Len())
+		binary.LittleEndian.PutUint32(frame[:4], uint32(body.Len()))
+		binary.LittleEndian.PutUint32(frame[4:], crc32.ChecksumIEEE(body.Bytes()))
+		frame = append(frame, body.Bytes()...)
+		if _, err := r.wal.Write(frame); err != nil {
+			// Drop a partial write so the next record does not follow garbage
+			_ = r.wal.Truncate(r.walBytes)
+			_, _ = r.wal.Seek(r.walBytes, io.SeekStart)
+			return fmt.Errorf("failed to write log: %w", err)
+		}
+		if err := r.wal.Sync(); err != nil {
+			_ = r.wal.Truncate(r.walBytes)
+			_, _ = r.wal.Seek(r.walBytes, io.SeekStart)
+			return fmt.Errorf("failed to sync log: %w", err)
+		}
+		r.walBytes += int64(len(frame))
+		r.seq = record.Seq
+	}
+	r.apply(record)
+	r.compact()
+	r.maybeSnapshot()
+	return nil
+}
+
+// apply applies a record to memory
+func (r *embeddedRepository) apply(record *walRecord) {
+	switch record.Op {
+	case walOpAdd:
+		for _, doc := range record.Documents {
+			r.add(doc)
+		}
+	case walOpRemove:
+		for _, id := range record.IDs {
+			if internal, ok := r.byID[id]; ok {
+				r.remove(internal)
+			}
+		}
+	case walOpEnable:
+		for i, id := range record.IDs {
+			if internal, ok := r.byID[id]; ok && i < len(record.Enabled) {
+				r.docs[internal].IsEnabled = record.Enabled[i]
+			}
+		}
+	}
+}
+
+// maybeSnapshot folds the log into the snapshot once the log outgrows it. The changes are already durable in
+// the log, so a failed snapshot is only logged and retried after the next change.
+func (r *embeddedRepository) maybeSnapshot() {
+	if r.wal == nil || r.walBytes < max(r.walCompactBytes, r.snapshotBytes) {
+		return
+	}
+	if err := r.writeSnapshot(); err != nil {
+		logger.GetLogger(context.Background()).Warnf(
+			"[Embedded] Failed to snapshot, changes stay in the write-ahead log: %v", err)
+	}
+}
+
+// writeSnapshot writes all live documents to the snapshot file atomically and empties the log. Records the
+// snapshot already holds are skipped by sequence number if the log could not be emptied.
+// Callers must hold the write lock.
+func (r *embeddedRepository) writeSnapshot() error {
+	snap := snapshot{Version: snapshotVersion, LastSeq: r.seq, Documents: r.liveDocuments()}
+	tmp, err := os.CreateTemp(r.dataDir, snapshotFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	if err := gob.NewEncoder(writer).Encode(&snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.snapshotPath()); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	r.snapshotBytes = info.Size()
+
+	if err := r.wal.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate write-ahead log: %w", err)
+	}
+	if _, err := r.wal.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek write-ahead log: %w", err)
+	}
+	r.walBytes = 0
+	return nil
+}
+
+// liveDocuments returns the non-deleted documents in insertion order
+func (r *embeddedRepository) liveDocuments() []*document {
+	docs := make([]*document, 0, r.live)
+	for _, doc := range r.docs {
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// rebuild resets the repository to exactly the given documents
+func (r *embeddedRepository) rebuild(docs []*document) {
+	r.docs = nil
+	r.live = 0
+	r.byID = make(map[string]int, len(docs))
+	r.vectors = make(map[int]*hnswIndex)
+	r.keywords = newBM25Index()
+	for _, doc := range docs {
+		r.add(doc)
+	}
+}
+
+// add appends a document and indexes it
+func (r *embeddedRepository) add(doc *document) {
+	id := len(r.docs)
+	r.docs = append(r.docs, doc)
+	r.live++
+	r.byID[doc.ID] = id
+	r.keywords.Add(id, doc.Content)
+	if dim := len(doc.Embedding); dim > 0 {
+		index, ok := r.vectors[dim]
+		if !ok {
+			index = newHNSWIndex()
+			r.vectors[dim] = index
+		}
+		index.Add(id, doc.Embedding)
+	}
+}
+
+// remove marks a document as deleted. The vector stays in the graph as a
+// tombstone to keep it navigable until the next compaction.
+func (r *embeddedRepository) remove(id int) {
+	doc := r.docs[id]
+	if doc == nil {
+		return
+	}
+	r.keywords.Remove(id, doc.Content)
+	r.docs[id] = nil
+	delete(r.byID, doc.ID)
+	r.live--
+}
+
+// compact rebuilds the indexes once tombstones outnumber live documents
+func (r *embeddedRepository) compact() {
+	if tombstones := len(r.docs) - r.live; tombstones > compactMinTombstones && tombstones > r.live {
+		r.rebuild(r.liveDocuments())
+	}
+}
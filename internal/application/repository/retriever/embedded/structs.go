@@ -0,0 +1,102 @@
+package embedded
+
+import (
+	"os"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// embeddedRepository implements the RetrieveEngineRepository interface with
+// an in-process HNSW vector index and a BM25 inverted index. All documents are
+// kept in memory; every change is appended to a write-ahead log in the data
+// directory before it is applied, and the log is folded into a snapshot once it
+// outgrows it. Both indexes are rebuilt from the snapshot and the log on startup.
+type embeddedRepository struct {
+	mu      sync.RWMutex
+	dataDir string
+
+	// docs is indexed by internal document id; deleted entries are nil
+	docs []*document
+	live int
+	// byID maps document IDs to internal document ids
+	byID map[string]int
+
+	// wal is the open write-ahead log, nil when nothing is persisted
+	wal           *os.File
+	walBytes      int64
+	snapshotBytes int64
+	// seq is the sequence number of the last change
+	seq uint64
+	// walCompactBytes is the minimum log size folded into the snapshot
+	walCompactBytes int64
+
+	vectors  map[int]*hnswIndex // keyed by embedding dimension
+	keywords *bm25Index
+
+	// bruteForceLimit is the index size up to which vector search is exact
+	bruteForceLimit int
+}
+
+// document is a single indexed chunk
+type document struct {
+	ID              string
+	Content         string
+	SourceID        string
+	SourceType      int
+	ChunkID         string
+	KnowledgeID     string
+	KnowledgeBaseID string
+	Embedding       []float32 // normalized
+	IsEnabled       bool
+}
+
+// snapshot is the on-disk representation of the repository
+type snapshot struct {
+	Version int
+	// LastSeq is the sequence number of the last log record the snapshot holds
+	LastSeq   uint64
+	Documents []*document
+}
+
+// toDocument converts IndexInfo to the embedded document format
+func toDocument(indexInfo *types.IndexInfo, additionalParams map[string]any) *document {
+	doc := &document{
+		Content:         common.CleanInvalidUTF8(indexInfo.Content),
+		SourceID:        indexInfo.SourceID,
+		SourceType:      int(indexInfo.SourceType),
+		ChunkID:         indexInfo.ChunkID,
+		KnowledgeID:     indexInfo.KnowledgeID,
+		KnowledgeBaseID: indexInfo.KnowledgeBaseID,
+		IsEnabled:       true, // Default to enabled
+	}
+	if additionalParams != nil {
+		if embeddingMap, ok := additionalParams["embedding"].(map[string][]float32); ok {
+			if embedding := embeddingMap[indexInfo.SourceID]; len(embedding) > 0 {
+				doc.Embedding = normalize(embedding)
+			}
+		}
+		if chunkEnabledMap, ok := additionalParams["chunk_enabled"].(map[string]bool); ok {
+			if enabled, exists := chunkEnabledMap[indexInfo.ChunkID]; exists {
+				doc.IsEnabled = enabled
+			}
+		}
+	}
+	return doc
+}
+
+// toIndexWithScore converts a document to the IndexWithScore domain model
+func (d *document) toIndexWithScore(score float64, matchType types.MatchType) *types.IndexWithScore {
+	return &types.IndexWithScore{
+		ID:              d.ID,
+		SourceID:        d.SourceID,
+		SourceType:      types.SourceType(d.SourceType),
+		ChunkID:         d.ChunkID,
+		KnowledgeID:     d.KnowledgeID,
+		KnowledgeBaseID: d.KnowledgeBaseID,
+		Content:         d.Content,
+		Score:           score,
+		MatchType:       matchType,
+	}
+}
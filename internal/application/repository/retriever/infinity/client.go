@@ -0,0 +1,215 @@
+package infinity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Error codes returned by the Infinity HTTP API that are treated as benign
+const (
+	errCodeOK            = 0
+	errCodeTableNotExist = 3022
+)
+
+// Client is a minimal client for the Infinity HTTP API
+// Only the endpoints required by the retriever repository are implemented
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Infinity HTTP client
+// If httpClient is nil, a client with a 30 second timeout is used
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// apiError is the error returned when Infinity responds with a non-zero error code
+type apiError struct {
+	Code    int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("infinity error %d: %s", e.Code, e.Message)
+}
+
+// isTableNotExist reports whether the error means the target table is missing
+func isTableNotExist(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.Code == errCodeTableNotExist
+}
+
+// baseResponse holds the fields shared by all Infinity responses
+type baseResponse struct {
+	ErrorCode int    `json:"error_code"`
+	ErrorMsg  string `json:"error_msg"`
+}
+
+// fieldDef describes a column in a create table request
+type fieldDef struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default any    `json:"default,omitempty"`
+}
+
+// indexDef describes an index in a create index request
+type indexDef struct {
+	Fields       []string          `json:"fields"`
+	Index        map[string]string `json:"index"`
+	CreateOption string            `json:"create_option"`
+}
+
+// selectRequest is the body of a select (search) request
+type selectRequest struct {
+	Output []string         `json:"output"`
+	Filter string           `json:"filter,omitempty"`
+	Search []map[string]any `json:"search,omitempty"`
+	Limit  int              `json:"limit,omitempty"`
+	Offset int              `json:"offset,omitempty"`
+}
+
+// selectResponse is the response of a select request
+type selectResponse struct {
+	baseResponse
+	Output []map[string]any `json:"output"`
+}
+
+// listTablesResponse is the response of a list tables request
+type listTablesResponse struct {
+	baseResponse
+	Tables []any `json:"tables"`
+}
+
+// CreateDatabase creates the database if it does not exist
+func (c *Client) CreateDatabase(ctx context.Context, database string) error {
+	return c.do(ctx, http.MethodPost, c.databasePath(database),
+		map[string]any{"create_option": "ignore_if_exists"}, nil)
+}
+
+// CreateTable creates a table with the given columns if it does not exist
+func (c *Client) CreateTable(ctx context.Context, database, table string, fields []fieldDef) error {
+	return c.do(ctx, http.MethodPost, c.tablePath(database, table),
+		map[string]any{"create_option": "ignore_if_exists", "fields": fields}, nil)
+}
+
+// CreateIndex creates an index on a table if it does not exist
+func (c *Client) CreateIndex(ctx context.Context, database, table, index string, def indexDef) error {
+	return c.do(ctx, http.MethodPost, c.tablePath(database, table)+"/indexes/"+url.PathEscape(index), def, nil)
+}
+
+// ListTables returns the names of all tables in the database
+func (c *Client) ListTables(ctx context.Context, database string) ([]string, error) {
+	var resp listTablesResponse
+	if err := c.do(ctx, http.MethodGet, c.databasePath(database)+"/tables", nil, &resp); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(resp.Tables))
+	for _, t := range resp.Tables {
+		switch v := t.(type) {
+		case string:
+			names = append(names, v)
+		case map[string]any:
+			for _, key := range []string{"table_name", "name"} {
+				if name, ok := v[key].(string); ok && name != "" {
+					names = append(names, name)
+					break
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// Insert inserts rows into a table
+func (c *Client) Insert(ctx context.Context, database, table string, rows []map[string]any) error {
+	return c.do(ctx, http.MethodPost, c.tablePath(database, table)+"/docs", rows, nil)
+}
+
+// Delete deletes the rows matching the filter
+func (c *Client) Delete(ctx context.Context, database, table, filter string) error {
+	return c.do(ctx, http.MethodDelete, c.tablePath(database, table)+"/docs",
+		map[string]any{"filter": filter}, nil)
+}
+
+// Update updates the rows matching the filter
+func (c *Client) Update(ctx context.Context, database, table, filter string, values map[string]any) error {
+	return c.do(ctx, http.MethodPut, c.tablePath(database, table)+"/docs",
+		map[string]any{"filter": filter, "update": values}, nil)
+}
+
+// Select runs a select request and returns the output rows
+func (c *Client) Select(ctx context.Context, database, table string, req *selectRequest) ([]map[string]any, error) {
+	var resp selectResponse
+	if err := c.do(ctx, http.MethodGet, c.tablePath(database, table)+"/docs", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Output, nil
+}
+
+func (c *Client) databasePath(database string) string {
+	return "/databases/" + url.PathEscape(database)
+}
+
+func (c *Client) tablePath(database, table string) string {
+	return c.databasePath(database) + "/tables/" + url.PathEscape(table)
+}
+
+// do sends a request to Infinity and decodes the response into out
+func (c *Client) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var base baseResponse
+	if err := json.Unmarshal(data, &base); err != nil {
+		return fmt.Errorf("unexpected response (status %d): %s", resp.StatusCode, string(data))
+	}
+	if base.ErrorCode != errCodeOK {
+		return &apiError{Code: base.ErrorCode, Message: base.ErrorMsg}
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
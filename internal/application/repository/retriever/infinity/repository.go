@@ -0,0 +1,641 @@
+package infinity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+const (
+	envInfinityDatabase    = "INFINITY_DATABASE"
+	envInfinityTablePrefix = "INFINITY_TABLE_PREFIX"
+	envInfinityAnalyzer    = "INFINITY_ANALYZER"
+	defaultDatabase        = "default_db"
+	defaultTablePrefix     = "weknora_embeddings"
+	defaultAnalyzer        = "standard"
+
+	fieldID              = "id"
+	fieldContent         = "content"
+	fieldSourceID        = "source_id"
+	fieldSourceType      = "source_type"
+	fieldChunkID         = "chunk_id"
+	fieldKnowledgeID     = "knowledge_id"
+	fieldKnowledgeBaseID = "knowledge_base_id"
+	fieldEmbedding       = "embedding"
+	fieldIsEnabled       = "is_enabled"
+	fieldSimilarity      = "_similarity"
+	fieldScore           = "_score"
+
+	copyBatchSize = 500
+)
+
+// outputFields are the columns returned by retrieval queries
+var outputFields = []string{
+	fieldID, fieldContent, fieldSourceID, fieldSourceType,
+	fieldChunkID, fieldKnowledgeID, fieldKnowledgeBaseID,
+}
+
+// NewInfinityRetrieveEngineRepository creates and initializes a new Infinity repository
+func NewInfinityRetrieveEngineRepository(client *Client) interfaces.RetrieveEngineRepository {
+	log := logger.GetLogger(context.Background())
+	log.Info("[Infinity] Initializing Infinity retriever engine repository")
+
+	database := os.Getenv(envInfinityDatabase)
+	if database == "" {
+		database = defaultDatabase
+	}
+	tablePrefix := os.Getenv(envInfinityTablePrefix)
+	if tablePrefix == "" {
+		log.Warn("[Infinity] INFINITY_TABLE_PREFIX environment variable not set, using default table prefix")
+		tablePrefix = defaultTablePrefix
+	}
+	analyzer := os.Getenv(envInfinityAnalyzer)
+	if analyzer == "" {
+		analyzer = defaultAnalyzer
+	}
+
+	res := &infinityRepository{
+		client:      client,
+		database:    database,
+		tablePrefix: tablePrefix,
+		analyzer:    analyzer,
+		readyTables: make(map[string]struct{}),
+	}
+	if err := client.CreateDatabase(context.Background(), database); err != nil {
+		log.Errorf("[Infinity] Failed to create database %s: %v", database, err)
+	} else {
+		log.Info("[Infinity] Successfully initialized repository")
+	}
+	return res
+}
+
+// EngineType returns the retriever engine type (Infinity)
+func (r *infinityRepository) EngineType() types.RetrieverEngineType {
+	return types.InfinityRetrieverEngineType
+}
+
+// Support returns supported retriever types (keywords and vector)
+func (r *infinityRepository) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
+}
+
+// tableName returns the table that stores embeddings of the given dimension
+// Dimension 0 holds rows indexed for keyword retrieval only
+func (r *infinityRepository) tableName(dimension int) string {
+	return fmt.Sprintf("%s_%d", r.tablePrefix, dimension)
+}
+
+// ensureTable creates the table and its indexes for the given dimension if needed
+func (r *infinityRepository) ensureTable(ctx context.Context, dimension int) (string, error) {
+	table := r.tableName(dimension)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.readyTables[table]; ok {
+		return table, nil
+	}
+
+	fields := []fieldDef{
+		{Name: fieldID, Type: "varchar"},
+		{Name: fieldContent, Type: "varchar"},
+		{Name: fieldSourceID, Type: "varchar"},
+		{Name: fieldSourceType, Type: "integer"},
+		{Name: fieldChunkID, Type: "varchar"},
+		{Name: fieldKnowledgeID, Type: "varchar"},
+		{Name: fieldKnowledgeBaseID, Type: "varchar"},
+		{Name: fieldIsEnabled, Type: "tinyint", Default: 1},
+	}
+	if dimension > 0 {
+		fields = append(fields, fieldDef{Name: fieldEmbedding, Type: fmt.Sprintf("vector,%d,float", dimension)})
+	}
+	if err := r.client.CreateTable(ctx, r.database, table, fields); err != nil {
+		return "", fmt.Errorf("failed to create table %s: %w", table, err)
+	}
+
+	if err := r.client.CreateIndex(ctx, r.database, table, table+"_content_idx", indexDef{
+		Fields:       []string{fieldContent},
+		Index:        map[string]string{"type": "FullText", "analyzer": r.analyzer},
+		CreateOption: "ignore_if_exists",
+	}); err != nil {
+		return "", fmt.Errorf("failed to create fulltext index on %s: %w", table, err)
+	}
+	if dimension > 0 {
+		if err := r.client.CreateIndex(ctx, r.database, table, table+"_embedding_idx", indexDef{
+			Fields: []string{fieldEmbedding},
+			Index: map[string]string{
+				"type": "Hnsw", "metric": "cosine", "encode": "plain", "M": "16", "ef_construction": "200",
+			},
+			CreateOption: "ignore_if_exists",
+		}); err != nil {
+			return "", fmt.Errorf("failed to create vector index on %s: %w", table, err)
+		}
+	}
+
+	r.readyTables[table] = struct{}{}
+	logger.GetLogger(ctx).Infof("[Infinity] Table ready: %s", table)
+	return table, nil
+}
+
+// listTables returns all tables managed by this repository
+func (r *infinityRepository) listTables(ctx context.Context) ([]string, error) {
+	names, err := r.client.ListTables(ctx, r.database)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.HasPrefix(name, r.tablePrefix+"_") {
+			tables = append(tables, name)
+		}
+	}
+	slices.Sort(tables)
+	return tables, nil
+}
+
+// calculateStorageSize estimates the storage size for a single row
+func (r *infinityRepository) calculateStorageSize(embedding *InfinityVectorEmbedding) int64 {
+	// 1. Content text size, plus inverted index overhead (~1x)
+	contentSizeBytes := int64(len(embedding.Content)) * 2
+
+	// 2. Vector storage size (4 bytes per dimension) plus HNSW graph (M=16, 2 layers of links)
+	var vectorSizeBytes int64 = 0
+	var hnswIndexBytes int64 = 0
+	if len(embedding.Embedding) > 0 {
+		vectorSizeBytes = int64(len(embedding.Embedding) * 4)
+		hnswIndexBytes = 16 * 2 * 4
+	}
+
+	// 3. Metadata size (IDs and fixed overhead)
+	metadataSizeBytes := int64(len(embedding.SourceID) + len(embedding.ChunkID) +
+		len(embedding.KnowledgeID) + len(embedding.KnowledgeBaseID) + 64)
+
+	return contentSizeBytes + vectorSizeBytes + hnswIndexBytes + metadataSizeBytes
+}
+
+// EstimateStorageSize estimates total storage size for multiple indices
+func (r *infinityRepository) EstimateStorageSize(ctx context.Context,
+	indexInfoList []*types.IndexInfo, params map[string]any,
+) int64 {
+	var totalStorageSize int64
+	for _, indexInfo := range indexInfoList {
+		totalStorageSize += r.calculateStorageSize(toInfinityVectorEmbedding(indexInfo, params))
+	}
+	logger.GetLogger(ctx).Infof(
+		"[Infinity] Storage size for %d indices: %d bytes", len(indexInfoList), totalStorageSize,
+	)
+	return totalStorageSize
+}
+
+// Save stores a single index entry
+func (r *infinityRepository) Save(ctx context.Context,
+	indexInfo *types.IndexInfo, additionalParams map[string]any,
+) error {
+	logger.GetLogger(ctx).Debugf("[Infinity] Saving index for chunk ID: %s", indexInfo.ChunkID)
+	return r.BatchSave(ctx, []*types.IndexInfo{indexInfo}, additionalParams)
+}
+
+// BatchSave stores multiple index entries, grouped into tables by embedding dimension
+func (r *infinityRepository) BatchSave(ctx context.Context,
+	indexInfoList []*types.IndexInfo, additionalParams map[string]any,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(indexInfoList) == 0 {
+		log.Warn("[Infinity] Empty list provided to BatchSave, skipping")
+		return nil
+	}
+	log.Infof("[Infinity] Batch saving %d indices", len(indexInfoList))
+
+	rowsByDimension := make(map[int][]map[string]any)
+	for _, indexInfo := range indexInfoList {
+		embedding := toInfinityVectorEmbedding(indexInfo, additionalParams)
+		embedding.ID = uuid.New().String()
+		dimension := len(embedding.Embedding)
+		rowsByDimension[dimension] = append(rowsByDimension[dimension], embedding.toRow())
+	}
+
+	for dimension, rows := range rowsByDimension {
+		table, err := r.ensureTable(ctx, dimension)
+		if err != nil {
+			log.Errorf("[Infinity] %v", err)
+			return err
+		}
+		if err := r.client.Insert(ctx, r.database, table, rows); err != nil {
+			log.Errorf("[Infinity] Failed to insert into %s: %v", table, err)
+			return fmt.Errorf("failed to batch save: %w", err)
+		}
+	}
+
+	log.Infof("[Infinity] Successfully batch saved %d indices", len(indexInfoList))
+	return nil
+}
+
+// deleteWhere deletes the rows matching the filter from every managed table
+func (r *infinityRepository) deleteWhere(ctx context.Context, filter string) error {
+	tables, err := r.listTables(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	for _, table := range tables {
+		if err := r.client.Delete(ctx, r.database, table, filter); err != nil && !isTableNotExist(err) {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// DeleteByChunkIDList deletes indices by chunk IDs
+func (r *infinityRepository) DeleteByChunkIDList(ctx context.Context, chunkIDList []string, dimension int) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkIDList) == 0 {
+		log.Warn("[Infinity] Empty chunk ID list provided for deletion, skipping")
+		return nil
+	}
+	log.Infof("[Infinity] Deleting indices by chunk IDs, count: %d", len(chunkIDList))
+	if err := r.deleteWhere(ctx, inClause(fieldChunkID, chunkIDList)); err != nil {
+		log.Errorf("[Infinity] Failed to delete by chunk IDs: %v", err)
+		return err
+	}
+	log.Infof("[Infinity] Successfully deleted documents by chunk IDs")
+	return nil
+}
+
+// DeleteBySourceIDList deletes indices by source IDs
+func (r *infinityRepository) DeleteBySourceIDList(ctx context.Context, sourceIDList []string, dimension int) error {
+	log := logger.GetLogger(ctx)
+	if len(sourceIDList) == 0 {
+		log.Warn("[Infinity] Empty source ID list provided for deletion, skipping")
+		return nil
+	}
+	log.Infof("[Infinity] Deleting indices by source IDs, count: %d", len(sourceIDList))
+	if err := r.deleteWhere(ctx, inClause(fieldSourceID, sourceIDList)); err != nil {
+		log.Errorf("[Infinity] Failed to delete by source IDs: %v", err)
+		return err
+	}
+	log.Infof("[Infinity] Successfully deleted documents by source IDs")
+	return nil
+}
+
+// DeleteByKnowledgeIDList deletes indices by knowledge IDs
+func (r *infinityRepository) DeleteByKnowledgeIDList(ctx context.Context,
+	knowledgeIDList []string, dimension int,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeIDList) == 0 {
+		log.Warn("[Infinity] Empty knowledge ID list provided for deletion, skipping")
+		return nil
+	}
+	log.Infof("[Infinity] Deleting indices by knowledge IDs, count: %d", len(knowledgeIDList))
+	if err := r.deleteWhere(ctx, inClause(fieldKnowledgeID, knowledgeIDList)); err != nil {
+		log.Errorf("[Infinity] Failed to delete by knowledge IDs: %v", err)
+		return err
+	}
+	log.Infof("[Infinity] Successfully deleted documents by knowledge IDs")
+	return nil
+}
+
+// BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
+func (r *infinityRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkStatusMap) == 0 {
+		log.Warn("[Infinity] Empty chunk status map provided, skipping")
+		return nil
+	}
+	log.Infof("[Infinity] Batch updating chunk enabled status, count: %d", len(chunkStatusMap))
+
+	// Group chunks by enabled status for batch updates
+	enabledChunkIDs := make([]string, 0)
+	disabledChunkIDs := make([]string, 0)
+	for chunkID, enabled := range chunkStatusMap {
+		if enabled {
+			enabledChunkIDs = append(enabledChunkIDs, chunkID)
+		} else {
+			disabledChunkIDs = append(disabledChunkIDs, chunkID)
+		}
+	}
+
+	tables, err := r.listTables(ctx)
+	if err != nil {
+		log.Errorf("[Infinity] Failed to list tables: %v", err)
+		return err
+	}
+	for _, table := range tables {
+		if len(enabledChunkIDs) > 0 {
+			if err := r.client.Update(ctx, r.database, table,
+				inClause(fieldChunkID, enabledChunkIDs), map[string]any{fieldIsEnabled: 1},
+			); err != nil {
+				log.Errorf("[Infinity] Failed to update enabled chunks in %s: %v", table, err)
+				return fmt.Errorf("failed to update enabled chunks: %w", err)
+			}
+		}
+		if len(disabledChunkIDs) > 0 {
+			if err := r.client.Update(ctx, r.database, table,
+				inClause(fieldChunkID, disabledChunkIDs), map[string]any{fieldIsEnabled: 0},
+			); err != nil {
+				log.Errorf("[Infinity] Failed to update disabled chunks in %s: %v", table, err)
+				return fmt.Errorf("failed to update disabled chunks: %w", err)
+			}
+		}
+	}
+
+	log.Infof("[Infinity] Successfully enabled %d chunks and disabled %d chunks",
+		len(enabledChunkIDs), len(disabledChunkIDs))
+	return nil
+}
+
+// getBaseFilter builds the filter expression shared by all retrieval queries
+func (r *infinityRepository) getBaseFilter(params types.RetrieveParams) string {
+	conds := []string{fieldIsEnabled + " = 1"}
+	if len(params.KnowledgeBaseIDs) > 0 {
+		conds = append(conds, inClause(fieldKnowledgeBaseID, params.KnowledgeBaseIDs))
+	}
+	if len(params.KnowledgeIDs) > 0 {
+		conds = append(conds, inClause(fieldKnowledgeID, params.KnowledgeIDs))
+	}
+	if len(params.ExcludeKnowledgeIDs) > 0 {
+		conds = append(conds, notInClause(fieldKnowledgeID, params.ExcludeKnowledgeIDs))
+	}
+	if len(params.ExcludeChunkIDs) > 0 {
+		conds = append(conds, notInClause(fieldChunkID, params.ExcludeChunkIDs))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// Retrieve dispatches the retrieval operation to the appropriate method based on retriever type
+func (r *infinityRepository) Retrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("[Infinity] Processing retrieval request of type: %s", params.RetrieverType)
+
+	switch params.RetrieverType {
+	case types.VectorRetrieverType:
+		return r.VectorRetrieve(ctx, params)
+	case types.KeywordsRetrieverType:
+		return r.KeywordsRetrieve(ctx, params)
+	}
+
+	err := fmt.Errorf("invalid retriever type: %v", params.RetrieverType)
+	log.Errorf("[Infinity] %v", err)
+	return nil, err
+}
+
+// VectorRetrieve performs dense vector search with cosine similarity
+func (r *infinityRepository) VectorRetrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Infof("[Infinity] Vector retrieval: dim=%d, topK=%d, threshold=%.4f",
+		len(params.Embedding), params.TopK, params.Threshold)
+
+	if len(params.Embedding) == 0 || params.TopK <= 0 {
+		return buildRetrieveResult(nil, types.VectorRetrieverType), nil
+	}
+
+	table := r.tableName(len(params.Embedding))
+	rows, err := r.client.Select(ctx, r.database, table, &selectRequest{
+		Output: append(slices.Clone(outputFields), fieldSimilarity),
+		Filter: r.getBaseFilter(params),
+		Search: []map[string]any{{
+			"match_method": "dense",
+			"fields":       fieldEmbedding,
+			"query_vector": params.Embedding,
+			"element_type": "float",
+			"metric_type":  "cosine",
+			"topn":         params.TopK,
+		}},
+	})
+	if isTableNotExist(err) {
+		log.Warnf("[Infinity] Table %s does not exist, no vectors of dimension %d indexed", table, len(params.Embedding))
+		return buildRetrieveResult(nil, types.VectorRetrieverType), nil
+	}
+	if err != nil {
+		log.Errorf("[Infinity] Vector search failed: %v", err)
+		return nil, err
+	}
+
+	var results []*types.IndexWithScore
+	for _, row := range rows {
+		score := floatValue(row[fieldSimilarity])
+		if score < params.Threshold {
+			continue
+		}
+		embedding := &InfinityVectorEmbeddingWithScore{InfinityVectorEmbedding: *fromRow(row), Score: score}
+		results = append(results, fromInfinityVectorEmbedding(embedding, types.MatchTypeEmbedding))
+	}
+	sortByScore(results)
+
+	if len(results) == 0 {
+		log.Warnf("[Infinity] No vector matches found that meet threshold %.4f", params.Threshold)
+	} else {
+		log.Infof("[Infinity] Vector retrieval found %d results", len(results))
+		log.Debugf("[Infinity] Top result score: %.4f", results[0].Score)
+	}
+	return buildRetrieveResult(results, types.VectorRetrieverType), nil
+}
+
+// KeywordsRetrieve performs BM25 full-text search across all managed tables
+func (r *infinityRepository) KeywordsRetrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Infof("[Infinity] Performing keywords retrieval with query: %s, topK: %d", params.Query, params.TopK)
+
+	matchingText := sanitizeMatchingText(params.Query)
+	if matchingText == "" || params.TopK <= 0 {
+		return buildRetrieveResult(nil, types.KeywordsRetrieverType), nil
+	}
+
+	tables, err := r.listTables(ctx)
+	if err != nil {
+		log.Errorf("[Infinity] Failed to list tables: %v", err)
+		return nil, err
+	}
+
+	filter := r.getBaseFilter(params)
+	var results []*types.IndexWithScore
+	for _, table := range tables {
+		rows, err := r.client.Select(ctx, r.database, table, &selectRequest{
+			Output: append(slices.Clone(outputFields), fieldScore),
+			Filter: filter,
+			Search: []map[string]any{{
+				"match_method":  "text",
+				"fields":        fieldContent,
+				"matching_text": matchingText,
+				"topn":          params.TopK,
+			}},
+		})
+		if isTableNotExist(err) {
+			continue
+		}
+		if err != nil {
+			log.Errorf("[Infinity] Keywords search on %s failed: %v", table, err)
+			return nil, err
+		}
+		for _, row := range rows {
+			embedding := &InfinityVectorEmbeddingWithScore{
+				InfinityVectorEmbedding: *fromRow(row),
+				Score:                   floatValue(row[fieldScore]),
+			}
+			results = append(results, fromInfinityVectorEmbedding(embedding, types.MatchTypeKeywords))
+		}
+	}
+	sortByScore(results)
+	if len(results) > params.TopK {
+		results = results[:params.TopK]
+	}
+
+	if len(results) == 0 {
+		log.Warnf("[Infinity] No keyword matches found for query: %s", params.Query)
+	} else {
+		log.Infof("[Infinity] Keywords retrieval found %d results", len(results))
+	}
+	return buildRetrieveResult(results, types.KeywordsRetrieverType), nil
+}
+
+// CopyIndices copies index data from source knowledge base to target knowledge base
+func (r *infinityRepository) CopyIndices(ctx context.Context,
+	sourceKnowledgeBaseID string,
+	sourceToTargetKBIDMap map[string]string,
+	sourceToTargetChunkIDMap map[string]string,
+	targetKnowledgeBaseID string,
+	dimension int,
+) error {
+	log := logger.GetLogger(ctx)
+	log.Infof(
+		"[Infinity] Copying indices from source knowledge base %s to target knowledge base %s, count: %d",
+		sourceKnowledgeBaseID, targetKnowledgeBaseID, len(sourceToTargetChunkIDMap),
+	)
+	if len(sourceToTargetChunkIDMap) == 0 {
+		log.Warn("[Infinity] Empty mapping, skipping copy")
+		return nil
+	}
+
+	tables, err := r.listTables(ctx)
+	if err != nil {
+		log.Errorf("[Infinity] Failed to list tables: %v", err)
+		return err
+	}
+
+	totalCopied := 0
+	for _, table := range tables {
+		output := append(slices.Clone(outputFields), fieldIsEnabled)
+		if table != r.tableName(0) {
+			output = append(output, fieldEmbedding)
+		}
+		for offset := 0; ; offset += copyBatchSize {
+			rows, err := r.client.Select(ctx, r.database, table, &selectRequest{
+				Output: output,
+				Filter: inClause(fieldKnowledgeBaseID, []string{sourceKnowledgeBaseID}),
+				Limit:  copyBatchSize,
+				Offset: offset,
+			})
+			if err != nil {
+				log.Errorf("[Infinity] Failed to query source rows from %s: %v", table, err)
+				return err
+			}
+
+			targetRows := make([]map[string]any, 0, len(rows))
+			for _, row := range rows {
+				source := fromRow(row)
+				targetChunkID, ok := sourceToTargetChunkIDMap[source.ChunkID]
+				if !ok {
+					log.Warnf("[Infinity] Source chunk %s not found in target mapping, skipping", source.ChunkID)
+					continue
+				}
+				targetKnowledgeID, ok := sourceToTargetKBIDMap[source.KnowledgeID]
+				if !ok {
+					log.Warnf("[Infinity] Source knowledge %s not found in target mapping, skipping",
+						source.KnowledgeID)
+					continue
+				}
+				target := *source
+				target.ID = uuid.New().String()
+				target.SourceID = targetChunkID
+				target.ChunkID = targetChunkID
+				target.KnowledgeID = targetKnowledgeID
+				target.KnowledgeBaseID = targetKnowledgeBaseID
+				targetRows = append(targetRows, target.toRow())
+			}
+
+			if len(targetRows) > 0 {
+				if err := r.client.Insert(ctx, r.database, table, targetRows); err != nil {
+					log.Errorf("[Infinity] Failed to insert copied rows into %s: %v", table, err)
+					return err
+				}
+				totalCopied += len(targetRows)
+				log.Infof("[Infinity] Successfully copied batch, batch size: %d, total copied: %d",
+					len(targetRows), totalCopied)
+			}
+
+			if len(rows) < copyBatchSize {
+				break
+			}
+		}
+	}
+
+	log.Infof("[Infinity] Index copy completed, total copied: %d", totalCopied)
+	return nil
+}
+
+func buildRetrieveResult(results []*types.IndexWithScore, retrieverType types.RetrieverType) []*types.RetrieveResult {
+	return []*types.RetrieveResult{
+		{
+			Results:             results,
+			RetrieverEngineType: types.InfinityRetrieverEngineType,
+			RetrieverType:       retrieverType,
+			Error:               nil,
+		},
+	}
+}
+
+// sortByScore sorts results by score, highest first
+func sortByScore(results []*types.IndexWithScore) {
+	slices.SortStableFunc(results, func(a, b *types.IndexWithScore) int {
+		if a.Score > b.Score {
+			return -1
+		} else if a.Score < b.Score {
+			return 1
+		}
+		return 0
+	})
+}
+
+// quote quotes a string literal for an Infinity filter expression
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// inClause builds "field IN ('a', 'b')"
+func inClause(field string, values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quote(v)
+	}
+	return fmt.Sprintf("%s IN (%s)", field, strings.Join(quoted, ", "))
+}
+
+// notInClause builds "field NOT IN ('a', 'b')"
+func notInClause(field string, values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quote(v)
+	}
+	return fmt.Sprintf("%s NOT IN (%s)", field, strings.Join(quoted, ", "))
+}
+
+// sanitizeMatchingText removes characters that have a special meaning in the
+// Infinity full-text query syntax so the user query is matched as plain terms
+func sanitizeMatchingText(query string) string {
+	replacer := strings.NewReplacer(
+		`\`, " ", `"`, " ", `'`, " ", "(", " ", ")", " ", "^", " ", "~", " ", "*", " ", "?", " ",
+		":", " ", "[", " ", "]", " ", "{", " ", "}", " ", "!", " ", "+", " ", "-", " ", "&", " ", "|", " ",
+	)
+	return strings.Join(strings.Fields(replacer.Replace(query)), " ")
+}
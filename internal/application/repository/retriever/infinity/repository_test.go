@@ -0,0 +1,393 @@
+package infinity
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// fakeInfinity is an in-process stand-in for the Infinity HTTP API.
+// It understands the subset of filter and search syntax emitted by the repository.
+type fakeInfinity struct {
+	mu     sync.Mutex
+	tables map[string][]map[string]any
+}
+
+var (
+	fakeTablePath = regexp.MustCompile(`^/databases/[^/]+/tables/([^/]+)(/docs|/indexes/[^/]+)?$`)
+	fakeClause    = regexp.MustCompile(`^(\w+) (NOT IN|IN|=) (.+)$`)
+	fakeLiteral   = regexp.MustCompile(`'((?:[^']|'')*)'`)
+)
+
+func newFakeInfinity(t *testing.T) *httptest.Server {
+	fake := &fakeInfinity{tables: make(map[string][]map[string]any)}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (f *fakeInfinity) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var body any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	reply := func(resp map[string]any) {
+		if _, ok := resp["error_code"]; !ok {
+			resp["error_code"] = errCodeOK
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/tables") && r.Method == http.MethodGet {
+		names := make([]any, 0, len(f.tables))
+		for name := range f.tables {
+			names = append(names, map[string]any{"table_name": name})
+		}
+		reply(map[string]any{"tables": names})
+		return
+	}
+	m := fakeTablePath.FindStringSubmatch(r.URL.Path)
+	if m == nil {
+		reply(map[string]any{})
+		return
+	}
+	table, suffix := m[1], m[2]
+	if suffix == "" {
+		if _, ok := f.tables[table]; !ok {
+			f.tables[table] = nil
+		}
+		reply(map[string]any{})
+		return
+	}
+	if strings.HasPrefix(suffix, "/indexes/") {
+		reply(map[string]any{})
+		return
+	}
+	rows, ok := f.tables[table]
+	if !ok {
+		reply(map[string]any{"error_code": errCodeTableNotExist, "error_msg": "table not exist"})
+		return
+	}
+
+	req, _ := body.(map[string]any)
+	switch r.Method {
+	case http.MethodPost:
+		for _, row := range body.([]any) {
+			f.tables[table] = append(f.tables[table], row.(map[string]any))
+		}
+		reply(map[string]any{})
+	case http.MethodDelete:
+		kept := rows[:0]
+		for _, row := range rows {
+			if !fakeMatch(row, req["filter"].(string)) {
+				kept = append(kept, row)
+			}
+		}
+		f.tables[table] = kept
+		reply(map[string]any{})
+	case http.MethodPut:
+		for _, row := range rows {
+			if fakeMatch(row, req["filter"].(string)) {
+				for k, v := range req["update"].(map[string]any) {
+					row[k] = v
+				}
+			}
+		}
+		reply(map[string]any{})
+	case http.MethodGet:
+		reply(map[string]any{"output": fakeSelect(rows, req)})
+	}
+}
+
+func fakeMatch(row map[string]any, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	for _, clause := range strings.Split(filter, " AND ") {
+		m := fakeClause.FindStringSubmatch(clause)
+		if m == nil {
+			return false
+		}
+		value := stringValue(row[m[1]])
+		switch m[2] {
+		case "=":
+			if floatValue(row[m[1]]) != 1 {
+				return false
+			}
+		case "IN", "NOT IN":
+			found := false
+			for _, lit := range fakeLiteral.FindAllStringSubmatch(m[3], -1) {
+				if strings.ReplaceAll(lit[1], "''", "'") == value {
+					found = true
+				}
+			}
+			if found != (m[2] == "IN") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func fakeSelect(rows []map[string]any, req map[string]any) []map[string]any {
+	type scored struct {
+		row   map[string]any
+		score float64
+	}
+	var matched []scored
+	filter, _ := req["filter"].(string)
+	for _, row := range rows {
+		if fakeMatch(row, filter) {
+			matched = append(matched, scored{row: row})
+		}
+	}
+
+	scoreField := ""
+	if search, ok := req["search"].([]any); ok && len(search) > 0 {
+		expr := search[0].(map[string]any)
+		topn := int(floatValue(expr["topn"]))
+		var hits []scored
+		for _, s := range matched {
+			switch expr["match_method"] {
+			case "dense":
+				scoreField = fieldSimilarity
+				s.score = cosine(vectorValue(s.row[fieldEmbedding]), vectorValue(expr["query_vector"]))
+			case "text":
+				scoreField = fieldScore
+				for _, term := range strings.Fields(expr["matching_text"].(string)) {
+					if strings.Contains(stringValue(s.row[fieldContent]), term) {
+						s.score++
+					}
+				}
+				if s.score == 0 {
+					continue
+				}
+			}
+			hits = append(hits, s)
+		}
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+		if len(hits) > topn {
+			hits = hits[:topn]
+		}
+		matched = hits
+	}
+
+	offset, limit := int(floatValue(req["offset"])), int(floatValue(req["limit"]))
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	matched = matched[offset:]
+	if limit > 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+
+	output := make([]map[string]any, 0, len(matched))
+	for _, s := range matched {
+		out := make(map[string]any)
+		for _, field := range req["output"].([]any) {
+			name := field.(string)
+			if name == scoreField {
+				out[name] = s.score
+			} else if v, ok := s.row[name]; ok {
+				out[name] = v
+			}
+		}
+		output = append(output, out)
+	}
+	return output
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i] * b[i])
+		na += float64(a[i] * a[i])
+		nb += float64(b[i] * b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func newTestRepository(t *testing.T) *infinityRepository {
+	server := newFakeInfinity(t)
+	return NewInfinityRetrieveEngineRepository(NewClient(server.URL, server.Client())).(*infinityRepository)
+}
+
+func seedTestIndices(t *testing.T, repo *infinityRepository) {
+	ctx := context.Background()
+	indices := []*types.IndexInfo{
+		{SourceID: "c1", ChunkID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: "golang vector search"},
+		{SourceID: "c2", ChunkID: "c2", KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: "python web framework"},
+		{SourceID: "c3", ChunkID: "c3", KnowledgeID: "k2", KnowledgeBaseID: "kb2", Content: "golang concurrency"},
+	}
+	params := map[string]any{
+		"embedding": map[string][]float32{
+			"c1": {1, 0, 0},
+			"c2": {0, 1, 0},
+			"c3": {0.9, 0.1, 0},
+		},
+	}
+	if err := repo.BatchSave(ctx, indices, params); err != nil {
+		t.Fatalf("BatchSave failed: %v", err)
+	}
+}
+
+func resultChunkIDs(results []*types.RetrieveResult) []string {
+	var ids []string
+	for _, r := range results {
+		for _, item := range r.Results {
+			ids = append(ids, item.ChunkID)
+		}
+	}
+	return ids
+}
+
+func TestInfinityRetrieve(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	seedTestIndices(t, repo)
+
+	t.Run("VectorWithKnowledgeBaseFilter", func(t *testing.T) {
+		results, err := repo.Retrieve(ctx, types.RetrieveParams{
+			RetrieverType:    types.VectorRetrieverType,
+			Embedding:        []float32{1, 0, 0},
+			KnowledgeBaseIDs: []string{"kb1"},
+			TopK:             5,
+			Threshold:        0.5,
+		})
+		if err != nil {
+			t.Fatalf("Retrieve failed: %v", err)
+		}
+		if got := resultChunkIDs(results); len(got) != 1 || got[0] != "c1" {
+			t.Errorf("expected [c1], got %v", got)
+		}
+		if results[0].RetrieverEngineType != types.InfinityRetrieverEngineType {
+			t.Errorf("unexpected engine type %s", results[0].RetrieverEngineType)
+		}
+	})
+
+	t.Run("VectorUnknownDimension", func(t *testing.T) {
+		results, err := repo.Retrieve(ctx, types.RetrieveParams{
+			RetrieverType: types.VectorRetrieverType,
+			Embedding:     []float32{1, 0},
+			TopK:          5,
+		})
+		if err != nil {
+			t.Fatalf("Retrieve failed: %v", err)
+		}
+		if got := resultChunkIDs(results); len(got) != 0 {
+			t.Errorf("expected no results, got %v", got)
+		}
+	})
+
+	t.Run("KeywordsWithExclusion", func(t *testing.T) {
+		results, err := repo.Retrieve(ctx, types.RetrieveParams{
+			RetrieverType:   types.KeywordsRetrieverType,
+			Query:           "golang (search)",
+			ExcludeChunkIDs: []string{"c3"},
+			TopK:            5,
+		})
+		if err != nil {
+			t.Fatalf("Retrieve failed: %v", err)
+		}
+		if got := resultChunkIDs(results); len(got) != 1 || got[0] != "c1" {
+			t.Errorf("expected [c1], got %v", got)
+		}
+	})
+
+	t.Run("InvalidRetrieverType", func(t *testing.T) {
+		if _, err := repo.Retrieve(ctx, types.RetrieveParams{RetrieverType: "unknown"}); err == nil {
+			t.Error("expected error for invalid retriever type")
+		}
+	})
+}
+
+func TestInfinityUpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	seedTestIndices(t, repo)
+
+	keywords := types.RetrieveParams{RetrieverType: types.KeywordsRetrieverType, Query: "golang", TopK: 5}
+
+	if err := repo.BatchUpdateChunkEnabledStatus(ctx, map[string]bool{"c1": false}); err != nil {
+		t.Fatalf("BatchUpdateChunkEnabledStatus failed: %v", err)
+	}
+	results, err := repo.Retrieve(ctx, keywords)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if got := resultChunkIDs(results); len(got) != 1 || got[0] != "c3" {
+		t.Errorf("expected disabled chunk to be skipped, got %v", got)
+	}
+
+	if err := repo.DeleteByKnowledgeIDList(ctx, []string{"k2"}, 3); err != nil {
+		t.Fatalf("DeleteByKnowledgeIDList failed: %v", err)
+	}
+	results, err = repo.Retrieve(ctx, keywords)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if got := resultChunkIDs(results); len(got) != 0 {
+		t.Errorf("expected no results after delete, got %v", got)
+	}
+}
+
+func TestInfinityCopyIndices(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	seedTestIndices(t, repo)
+
+	err := repo.CopyIndices(ctx, "kb1",
+		map[string]string{"k1": "k1-copy"},
+		map[string]string{"c1": "c1-copy", "c2": "c2-copy"},
+		"kb3", 3,
+	)
+	if err != nil {
+		t.Fatalf("CopyIndices failed: %v", err)
+	}
+
+	results, err := repo.Retrieve(ctx, types.RetrieveParams{
+		RetrieverType:    types.VectorRetrieverType,
+		Embedding:        []float32{0, 1, 0},
+		KnowledgeBaseIDs: []string{"kb3"},
+		TopK:             1,
+	})
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if got := resultChunkIDs(results); len(got) != 1 || got[0] != "c2-copy" {
+		t.Fatalf("expected [c2-copy], got %v", got)
+	}
+	if item := results[0].Results[0]; item.KnowledgeID != "k1-copy" || item.KnowledgeBaseID != "kb3" {
+		t.Errorf("copied row not remapped: %+v", item)
+	}
+}
+
+func TestSanitizeMatchingText(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"hello world", "hello world"},
+		{`"quoted" (group)^2`, "quoted group 2"},
+		{"  a && b || !c  ", "a b c"},
+		{"***", ""},
+	}
+	for _, tt := range tests {
+		if got := sanitizeMatchingText(tt.query); got != tt.expected {
+			t.Errorf("sanitizeMatchingText(%q) = %q, want %q", tt.query, got, tt.expected)
+		}
+	}
+}
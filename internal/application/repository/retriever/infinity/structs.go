@@ -0,0 +1,167 @@
+package infinity
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// infinityRepository implements the RetrieveEngineRepository interface for Infinity
+// Each embedding dimension is stored in its own table, because vector columns have a fixed dimension
+type infinityRepository struct {
+	client      *Client
+	database    string
+	tablePrefix string
+	analyzer    string
+
+	mu          sync.Mutex
+	readyTables map[string]struct{}
+}
+
+// InfinityVectorEmbedding is a row stored in an Infinity table
+type InfinityVectorEmbedding struct {
+	ID              string    `json:"id"`
+	Content         string    `json:"content"`
+	SourceID        string    `json:"source_id"`
+	SourceType      int       `json:"source_type"`
+	ChunkID         string    `json:"chunk_id"`
+	KnowledgeID     string    `json:"knowledge_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	Embedding       []float32 `json:"embedding"`
+	IsEnabled       bool      `json:"is_enabled"`
+}
+
+// InfinityVectorEmbeddingWithScore extends InfinityVectorEmbedding with a search score
+type InfinityVectorEmbeddingWithScore struct {
+	InfinityVectorEmbedding
+	Score float64
+}
+
+// toInfinityVectorEmbedding converts IndexInfo to the Infinity row format
+func toInfinityVectorEmbedding(indexInfo *types.IndexInfo, additionalParams map[string]any) *InfinityVectorEmbedding {
+	vector := &InfinityVectorEmbedding{
+		Content:         common.CleanInvalidUTF8(indexInfo.Content),
+		SourceID:        indexInfo.SourceID,
+		SourceType:      int(indexInfo.SourceType),
+		ChunkID:         indexInfo.ChunkID,
+		KnowledgeID:     indexInfo.KnowledgeID,
+		KnowledgeBaseID: indexInfo.KnowledgeBaseID,
+		IsEnabled:       true, // Default to enabled
+	}
+	if additionalParams != nil && slices.Contains(slices.Collect(maps.Keys(additionalParams)), fieldEmbedding) {
+		if embeddingMap, ok := additionalParams[fieldEmbedding].(map[string][]float32); ok {
+			vector.Embedding = embeddingMap[indexInfo.SourceID]
+		}
+	}
+	if additionalParams != nil {
+		if chunkEnabledMap, ok := additionalParams["chunk_enabled"].(map[string]bool); ok {
+			if enabled, exists := chunkEnabledMap[indexInfo.ChunkID]; exists {
+				vector.IsEnabled = enabled
+			}
+		}
+	}
+	return vector
+}
+
+// toRow converts the embedding into the column map sent to Infinity
+func (v *InfinityVectorEmbedding) toRow() map[string]any {
+	row := map[string]any{
+		fieldID:              v.ID,
+		fieldContent:         v.Content,
+		fieldSourceID:        v.SourceID,
+		fieldSourceType:      v.SourceType,
+		fieldChunkID:         v.ChunkID,
+		fieldKnowledgeID:     v.KnowledgeID,
+		fieldKnowledgeBaseID: v.KnowledgeBaseID,
+		fieldIsEnabled:       boolToInt(v.IsEnabled),
+	}
+	if len(v.Embedding) > 0 {
+		row[fieldEmbedding] = v.Embedding
+	}
+	return row
+}
+
+// fromRow converts an Infinity output row into an embedding
+func fromRow(row map[string]any) *InfinityVectorEmbedding {
+	return &InfinityVectorEmbedding{
+		ID:              stringValue(row[fieldID]),
+		Content:         stringValue(row[fieldContent]),
+		SourceID:        stringValue(row[fieldSourceID]),
+		SourceType:      int(floatValue(row[fieldSourceType])),
+		ChunkID:         stringValue(row[fieldChunkID]),
+		KnowledgeID:     stringValue(row[fieldKnowledgeID]),
+		KnowledgeBaseID: stringValue(row[fieldKnowledgeBaseID]),
+		Embedding:       vectorValue(row[fieldEmbedding]),
+		IsEnabled:       floatValue(row[fieldIsEnabled]) != 0,
+	}
+}
+
+// fromInfinityVectorEmbedding converts a scored row to the IndexWithScore domain model
+func fromInfinityVectorEmbedding(embedding *InfinityVectorEmbeddingWithScore,
+	matchType types.MatchType,
+) *types.IndexWithScore {
+	return &types.IndexWithScore{
+		ID:              embedding.ID,
+		SourceID:        embedding.SourceID,
+		SourceType:      types.SourceType(embedding.SourceType),
+		ChunkID:         embedding.ChunkID,
+		KnowledgeID:     embedding.KnowledgeID,
+		KnowledgeBaseID: embedding.KnowledgeBaseID,
+		Content:         embedding.Content,
+		Score:           embedding.Score,
+		MatchType:       matchType,
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func stringValue(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+func floatValue(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case bool:
+		if n {
+			return 1
+		}
+		return 0
+	}
+	return 0
+}
+
+func vectorValue(v any) []float32 {
+	values, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	vector := make([]float32, len(values))
+	for i, value := range values {
+		vector[i] = float32(floatValue(value))
+	}
+	return vector
+}
@@ -3,6 +3,7 @@ package neo4j
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -227,6 +228,171 @@ func (n *Neo4jRepository) SearchNode(
 	return result.(*types.GraphData), nil
 }
 
+// ExpandNodes returns the neighbourhood of the query nodes up to the query hops, with node degrees
+func (n *Neo4jRepository) ExpandNodes(
+	ctx context.Context,
+	namespace types.NameSpace,
+	query *types.GraphQuery,
+) (*types.GraphData, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	query.Normalize()
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		cypher := `
+			MATCH (s:` + labelExpr + `)
+			WHERE ANY(nodeText IN $nodes WHERE s.name CONTAINS nodeText)
+			WITH collect(s) AS starts
+			WHERE size(starts) > 0
+			CALL apoc.path.subgraphAll(starts, {maxLevel: $hops, relationshipFilter: $filter})
+			YIELD nodes, relationships
+			RETURN [n IN nodes | {name: n.name, chunks: n.chunks, attributes: n.attributes,
+					degree: size([(n)--() | 1])}] AS nodes,
+				[r IN relationships | {source: startNode(r).name, target: endNode(r).name, type: type(r)}] AS relations
+		`
+		params := map[string]interface{}{
+			"nodes":  query.Nodes,
+			"hops":   query.Hops,
+			"filter": strings.Join(query.RelationTypes, "|"),
+		}
+		res, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query: %v", err)
+		}
+		graphData := &types.GraphData{}
+		for res.Next(ctx) {
+			mergeGraphRecord(graphData, res.Record())
+		}
+		return graphData, res.Err()
+	})
+	if err != nil {
+		logger.Errorf(ctx, "expand nodes failed: %v", err)
+		return nil, err
+	}
+	graphData := result.(*types.GraphData)
+	types.RankGraphNodes(graphData, func(name string) bool {
+		return types.GraphNameMatches(name, query.Nodes)
+	}, query.Limit)
+	return graphData, nil
+}
+
+// FindPath returns the shortest path between the query entities in path order
+func (n *Neo4jRepository) FindPath(
+	ctx context.Context,
+	namespace types.NameSpace,
+	query *types.GraphPathQuery,
+) (*types.GraphData, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	query.Normalize()
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		// The length bound of a variable length pattern cannot be a parameter, MaxHops is a capped integer
+		cypher := fmt.Sprintf(`
+			MATCH (a:%[1]s) WHERE a.name CONTAINS $source
+			MATCH (b:%[1]s) WHERE b.name CONTAINS $target AND a <> b
+			MATCH p = shortestPath((a)-[*..%[2]d]-(b))
+			WHERE size($types) = 0 OR ALL(r IN relationships(p) WHERE type(r) IN $types)
+			RETURN [n IN nodes(p) | {name: n.name, chunks: n.chunks, attributes: n.attributes}] AS nodes,
+				[r IN relationships(p) | {source: startNode(r).name, target: endNode(r).name, type: type(r)}] AS relations
+			ORDER BY length(p)
+			LIMIT 1
+		`, labelExpr, query.MaxHops)
+		relationTypes := query.RelationTypes
+		if relationTypes == nil {
+			relationTypes = []string{}
+		}
+		params := map[string]interface{}{"source": query.Source, "target": query.Target, "types": relationTypes}
+		res, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query: %v", err)
+		}
+		graphData := &types.GraphData{}
+		if res.Next(ctx) {
+			mergeGraphRecord(graphData, res.Record())
+		}
+		return graphData, res.Err()
+	})
+	if err != nil {
+		logger.Errorf(ctx, "find path failed: %v", err)
+		return nil, err
+	}
+	return result.(*types.GraphData), nil
+}
+
+// mergeGraphRecord adds the nodes and relations lists of a record to the graph, merging nodes of the same
+// name from different knowledge
+func mergeGraphRecord(graph *types.GraphData, record *neo4j.Record) {
+	nodeIndex := make(map[string]*types.GraphNode, len(graph.Node))
+	for _, node := range graph.Node {
+		nodeIndex[node.Name] = node
+	}
+	nodes, _ := record.Get("nodes")
+	for _, item := range toList(nodes) {
+		props, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("%v", props["name"])
+		chunks := listI2listS(toList(props["chunks"]))
+		node, seen := nodeIndex[name]
+		if !seen {
+			node = &types.GraphNode{Name: name, Attributes: listI2listS(toList(props["attributes"]))}
+			nodeIndex[name] = node
+			graph.Node = append(graph.Node, node)
+		} else if attributes := listI2listS(toList(props["attributes"])); len(attributes) > 0 &&
+			(len(node.Attributes) == 0 || props["kg"] == types.ManualGraphKnowledgeID) {
+			// Attributes edited through the API take precedence over the extracted ones
+			node.Attributes = attributes
+		}
+		for _, chunk := range chunks {
+			if !slices.Contains(node.Chunks, chunk) {
+				node.Chunks = append(node.Chunks, chunk)
+			}
+		}
+		if degree, ok := props["degree"].(int64); ok {
+			node.Degree += int(degree)
+		}
+	}
+	relations, _ := record.Get("relations")
+	relationSeen := make(map[[3]string]bool)
+	for _, rel := range graph.Relation {
+		relationSeen[[3]string{rel.Node1, rel.Node2, rel.Type}] = true
+	}
+	for _, item := range toList(relations) {
+		props, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		rel := &types.GraphRelation{
+			Node1: fmt.Sprintf("%v", props["source"]),
+			Node2: fmt.Sprintf("%v", props["target"]),
+			Type:  fmt.Sprintf("%v", props["type"]),
+		}
+		key := [3]string{rel.Node1, rel.Node2, rel.Type}
+		if !relationSeen[key] {
+			relationSeen[key] = true
+			graph.Relation = append(graph.Relation, rel)
+		}
+	}
+}
+
+// toList converts a record value to a list, nil for missing properties
+func toList(value any) []any {
+	list, _ := value.([]any)
+	return list
+}
+
 func listI2listS(list []any) []string {
 	result := make([]string, len(list))
 	for i, v := range list {
@@ -234,3 +400,383 @@ func listI2listS(list []any) []string {
 	}
 	return result
 }
+
+// ListNodes returns the nodes of the namespace, nodes of the same name in different knowledge are merged
+func (n *Neo4jRepository) ListNodes(ctx context.Context, namespace types.NameSpace) ([]*types.GraphNode, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	cypher := `
+		MATCH (n:` + n.Label(namespace) + `)
+		RETURN collect({name: n.name, kg: n.kg, chunks: n.chunks, attributes: n.attributes}) AS nodes
+	`
+	graphData, err := n.readGraph(ctx, cypher, nil)
+	if err != nil {
+		logger.Errorf(ctx, "list nodes failed: %v", err)
+		return nil, err
+	}
+	return graphData.Node, nil
+}
+
+// RankNodes returns a page of the nodes of the namespace matching the keyword, most connected first. The
+// degree of a node counts the relations from and to it, relations of different knowledge counted once.
+func (n *Neo4jRepository) RankNodes(ctx context.Context,
+	namespace types.NameSpace, keyword string, page *types.Pagination,
+) ([]*types.GraphNode, int64, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, 0, nil
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	type rankResult struct {
+		nodes []*types.GraphNode
+		total int64
+	}
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		params := map[string]interface{}{"keyword": keyword, "offset": page.Offset(), "limit": page.Limit()}
+		countQuery := `
+			MATCH (n:` + labelExpr + `)
+			WHERE toLower(n.name) CONTAINS toLower($keyword)
+			RETURN count(DISTINCT n.name) AS total
+		`
+		res, err := tx.Run(ctx, countQuery, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query: %v", err)
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count nodes: %v", err)
+		}
+		total, _ := record.Get("total")
+
+		rankQuery := `
+			MATCH (n:` + labelExpr + `)
+			WHERE toLower(n.name) CONTAINS toLower($keyword)
+			WITH DISTINCT n.name AS name
+			OPTIONAL MATCH (:` + labelExpr + ` {name: name})-[r]-(:` + labelExpr + `)
+			WITH name, collect(DISTINCT [startNode(r).name, endNode(r).name, type(r)]) AS relations
+			WITH name, size([rel IN relations WHERE rel[2] IS NOT NULL]) AS degree
+			ORDER BY degree DESC, name
+			SKIP $offset LIMIT $limit
+			MATCH (m:` + labelExpr + ` {name: name})
+			RETURN name, degree,
+				collect({name: m.name, kg: m.kg, chunks: m.chunks, attributes: m.attributes}) AS nodes
+			ORDER BY degree DESC, name
+		`
+		res, err = tx.Run(ctx, rankQuery, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query: %v", err)
+		}
+		graphData := &types.GraphData{}
+		degrees := make(map[string]int)
+		for res.Next(ctx) {
+			record := res.Record()
+			name, _ := record.Get("name")
+			degree, _ := record.Get("degree")
+			degrees[fmt.Sprintf("%v", name)] = int(degree.(int64))
+			mergeGraphRecord(graphData, record)
+		}
+		for _, node := range graphData.Node {
+			node.Degree = degrees[node.Name]
+		}
+		return &rankResult{nodes: graphData.Node, total: total.(int64)}, res.Err()
+	})
+	if err != nil {
+		logger.Errorf(ctx, "rank nodes failed: %v", err)
+		return nil, 0, err
+	}
+	ranked := result.(*rankResult)
+	if ranked.nodes == nil {
+		ranked.nodes = []*types.GraphNode{}
+	}
+	return ranked.nodes, ranked.total, nil
+}
+
+// GetNode returns the named node of the namespace, nil if there is none
+func (n *Neo4jRepository) GetNode(ctx context.Context,
+	namespace types.NameSpace, name string,
+) (*types.GraphNode, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	cypher := `
+		MATCH (n:` + n.Label(namespace) + ` {name: $name})
+		RETURN collect({name: n.name, kg: n.kg, chunks: n.chunks, attributes: n.attributes}) AS nodes
+	`
+	graphData, err := n.readGraph(ctx, cypher, map[string]interface{}{"name": name})
+	if err != nil {
+		logger.Errorf(ctx, "get node failed: %v", err)
+		return nil, err
+	}
+	if len(graphData.Node) == 0 {
+		return nil, nil
+	}
+	return graphData.Node[0], nil
+}
+
+// readGraph runs a read query returning lists of nodes and relations and merges its records into a graph
+func (n *Neo4jRepository) readGraph(ctx context.Context,
+	cypher string, params map[string]interface{},
+) (*types.GraphData, error) {
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query: %v", err)
+		}
+		graphData := &types.GraphData{}
+		for res.Next(ctx) {
+			mergeGraphRecord(graphData, res.Record())
+		}
+		return graphData, res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*types.GraphData), nil
+}
+
+// RenameNodes renames the nodes of the namespace, merging them into the nodes of the new name of the same
+// knowledge. The new names must not be renamed themselves.
+func (n *Neo4jRepository) RenameNodes(ctx context.Context,
+	namespace types.NameSpace, renames map[string]string,
+) error {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil
+	}
+	names := make([]string, 0, len(renames))
+	for from, to := range renames {
+		if from != "" && to != "" && from != to {
+			names = append(names, from)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	slices.Sort(names)
+
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		// Nodes of the old name are merged into the node of the new name of the same knowledge, mergeNodes
+		// moves their relations and merges the relations that become duplicates
+		mergeQuery := `
+			MATCH (a:` + labelExpr + ` {name: $from}), (b:` + labelExpr + ` {name: $to})
+			WHERE a.kg = b.kg
+			SET b.chunks = apoc.coll.union(coalesce(b.chunks, []), coalesce(a.chunks, [])),
+				b.attributes = apoc.coll.union(coalesce(b.attributes, []), coalesce(a.attributes, []))
+			WITH a, b
+			CALL apoc.refactor.mergeNodes([b, a], {properties: 'discard', mergeRels: true}) YIELD node
+			RETURN count(node)
+		`
+		renameQuery := `
+			MATCH (a:` + labelExpr + ` {name: $from})
+			SET a.name = $to
+		`
+		dropLoopsQuery := `
+			MATCH (a:` + labelExpr + ` {name: $to})-[r]->(a)
+			DELETE r
+		`
+		for _, from := range names {
+			params := map[string]interface{}{"from": from, "to": renames[from]}
+			for _, query := range []string{mergeQuery, renameQuery, dropLoopsQuery} {
+				if _, err := tx.Run(ctx, query, params); err != nil {
+					return nil, fmt.Errorf("failed to rename node %s: %v", from, err)
+				}
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "rename nodes failed: %v", err)
+		return err
+	}
+	logger.Infof(ctx, "renamed %d nodes of knowledge base %s", len(names), namespace.KnowledgeBase)
+	return nil
+}
+
+// ListRelations returns the relations of the namespace, relations of different knowledge are merged
+func (n *Neo4jRepository) ListRelations(ctx context.Context,
+	namespace types.NameSpace,
+) ([]*types.GraphRelation, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	labelExpr := n.Label(namespace)
+	cypher := `
+		MATCH (n:` + labelExpr + `)-[r]->(m:` + labelExpr + `)
+		RETURN collect({source: n.name, target: m.name, type: type(r)}) AS relations
+	`
+	graphData, err := n.readGraph(ctx, cypher, nil)
+	if err != nil {
+		logger.Errorf(ctx, "list relations failed: %v", err)
+		return nil, err
+	}
+	return graphData.Relation, nil
+}
+
+// ListNodeRelations returns the relations of the namespace from or to one of the named nodes
+func (n *Neo4jRepository) ListNodeRelations(ctx context.Context,
+	namespace types.NameSpace, names []string,
+) ([]*types.GraphRelation, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	labelExpr := n.Label(namespace)
+	cypher := `
+		UNWIND $names AS name
+		MATCH (:` + labelExpr + ` {name: name})-[r]-(:` + labelExpr + `)
+		RETURN collect(DISTINCT {source: startNode(r).name, target: endNode(r).name, type: type(r)}) AS relations
+	`
+	graphData, err := n.readGraph(ctx, cypher, map[string]interface{}{"names": names})
+	if err != nil {
+		logger.Errorf(ctx, "list relations failed: %v", err)
+		return nil, err
+	}
+	return graphData.Relation, nil
+}
+
+// ListRelationsAmong returns the relations of the namespace between the named nodes
+func (n *Neo4jRepository) ListRelationsAmong(ctx context.Context,
+	namespace types.NameSpace, names []string,
+) ([]*types.GraphRelation, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	labelExpr := n.Label(namespace)
+	cypher := `
+		UNWIND $names AS name
+		MATCH (a:` + labelExpr + ` {name: name})-[r]->(b:` + labelExpr + `)
+		WHERE b.name IN $names
+		RETURN collect({source: a.name, target: b.name, type: type(r)}) AS relations
+	`
+	graphData, err := n.readGraph(ctx, cypher, map[string]interface{}{"names": names})
+	if err != nil {
+		logger.Errorf(ctx, "list relations failed: %v", err)
+		return nil, err
+	}
+	return graphData.Relation, nil
+}
+
+// DeleteNodes deletes the named nodes of the namespace and their relations
+func (n *Neo4jRepository) DeleteNodes(ctx context.Context, namespace types.NameSpace, names []string) error {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			MATCH (n:` + n.Label(namespace) + `)
+			WHERE n.name IN $names
+			DETACH DELETE n
+		`
+		if _, err := tx.Run(ctx, query, map[string]interface{}{"names": names}); err != nil {
+			return nil, fmt.Errorf("failed to delete nodes: %v", err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "delete nodes failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteRelations deletes the relations of the namespace
+func (n *Neo4jRepository) DeleteRelations(ctx context.Context,
+	namespace types.NameSpace, relations []*types.GraphRelation,
+) error {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil
+	}
+	if len(relations) == 0 {
+		return nil
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		query := `
+			UNWIND $data AS row
+			MATCH (a:` + labelExpr + ` {name: row.source})-[r]->(b:` + labelExpr + ` {name: row.target})
+			WHERE type(r) = row.type
+			DELETE r
+		`
+		relData := make([]map[string]interface{}, 0, len(relations))
+		for _, rel := range relations {
+			relData = append(relData, map[string]interface{}{
+				"source": rel.Node1,
+				"target": rel.Node2,
+				"type":   rel.Type,
+			})
+		}
+		if _, err := tx.Run(ctx, query, map[string]interface{}{"data": relData}); err != nil {
+			return nil, fmt.Errorf("failed to delete relationships: %v", err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "delete relations failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SetNodeAttributes creates the node of the namespace or replaces its attributes
+func (n *Neo4jRepository) SetNodeAttributes(ctx context.Context,
+	namespace types.NameSpace, name string, attributes []string,
+) error {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil
+	}
+	if attributes == nil {
+		attributes = []string{}
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		query := `
+			CALL apoc.merge.node($labels, {name: $name, kg: $knowledge_id},
+				{attributes: $attributes, chunks: []}, {attributes: $attributes}) YIELD node
+			RETURN count(node)
+		`
+		params := map[string]interface{}{
+			"labels":       n.Labels(namespace),
+			"name":         name,
+			"knowledge_id": namespace.Knowledge,
+			"attributes":   attributes,
+		}
+		if _, err := tx.Run(ctx, query, params); err != nil {
+			return nil, fmt.Errorf("failed to set node attributes: %v", err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "set node attributes failed: %v", err)
+		return err
+	}
+	return nil
+}
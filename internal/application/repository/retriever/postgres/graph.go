@@ -0,0 +1,871 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// graphNode defines the database model for knowledge graph entities
+type graphNode struct {
+	ID              uint              `gorm:"primarykey"`
+	KnowledgeBaseID string            `gorm:"column:knowledge_base_id;not null"`
+	KnowledgeID     string            `gorm:"column:knowledge_id;not null"`
+	Name            string            `gorm:"column:name;not null"`
+	Chunks          types.StringArray `gorm:"column:chunks;type:jsonb"`
+	Attributes      types.StringArray `gorm:"column:attributes;type:jsonb"`
+	CreatedAt       time.Time         `gorm:"column:created_at"`
+	UpdatedAt       time.Time         `gorm:"column:updated_at"`
+}
+
+// TableName specifies the database table name for graphNode
+func (graphNode) TableName() string {
+	return "graph_nodes"
+}
+
+// graphRelation defines the database model for relationships between knowledge graph entities
+type graphRelation struct {
+	ID              uint      `gorm:"primarykey"`
+	KnowledgeBaseID string    `gorm:"column:knowledge_base_id;not null"`
+	KnowledgeID     string    `gorm:"column:knowledge_id;not null"`
+	Source          string    `gorm:"column:source;not null"`
+	Target          string    `gorm:"column:target;not null"`
+	Type            string    `gorm:"column:type;not null"`
+	CreatedAt       time.Time `gorm:"column:created_at"`
+}
+
+// TableName specifies the database table name for graphRelation
+func (graphRelation) TableName() string {
+	return "graph_relations"
+}
+
+// graphNodeKey is the unique key of a node, nodes of different knowledge are distinct like in Neo4j
+var graphNodeKey = []clause.Column{{Name: "knowledge_base_id"}, {Name: "knowledge_id"}, {Name: "name"}}
+
+// graphRelationKey is the unique key of a relation
+var graphRelationKey = []clause.Column{
+	{Name: "knowledge_base_id"}, {Name: "knowledge_id"}, {Name: "source"}, {Name: "target"}, {Name: "type"},
+}
+
+// mergeArrayExpr unions the JSON array column of an existing node with the one of the inserted node
+func mergeArrayExpr(column string) string {
+	return fmt.Sprintf(`(SELECT COALESCE(jsonb_agg(DISTINCT c), '[]'::jsonb)
+	FROM jsonb_array_elements(
+		COALESCE(graph_nodes.%[1]s, '[]'::jsonb) || COALESCE(EXCLUDED.%[1]s, '[]'::jsonb)
+	) AS c)`, column)
+}
+
+// pgGraphRepository stores the knowledge graph in PostgreSQL tables
+type pgGraphRepository struct {
+	db *gorm.DB
+}
+
+// NewPostgresGraphRepository creates a new PostgreSQL knowledge graph repository
+func NewPostgresGraphRepository(db *gorm.DB) interfaces.RetrieveGraphRepository {
+	logger.GetLogger(context.Background()).Info("[Postgres] Initializing PostgreSQL graph repository")
+	return &pgGraphRepository{db: db}
+}
+
+// graphScope restricts a query to the knowledge base and knowledge of the namespace
+func graphScope(db *gorm.DB, namespace types.NameSpace) *gorm.DB {
+	if namespace.KnowledgeBase != "" {
+		db = db.Where("knowledge_base_id = ?", namespace.KnowledgeBase)
+	}
+	if namespace.Knowledge != "" {
+		db = db.Where("knowledge_id = ?", namespace.Knowledge)
+	}
+	return db
+}
+
+// AddGraph merges the nodes and relations of the graphs into the namespace. Like the Neo4j repository,
+// chunks of existing nodes are unioned, attributes are only set on creation and relation endpoints that
+// are not nodes yet are created without chunks.
+func (r *pgGraphRepository) AddGraph(ctx context.Context,
+	namespace types.NameSpace, graphs []*types.GraphData,
+) error {
+	nodes, relations := r.collectGraph(namespace, graphs)
+	if len(nodes) == 0 && len(relations) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(nodes) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: graphNodeKey,
+				DoUpdates: clause.Assignments(map[string]any{
+					"chunks":     gorm.Expr(mergeArrayExpr("chunks")),
+					"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+				}),
+			}).CreateInBatches(nodes, 500).Error; err != nil {
+				return fmt.Errorf("failed to create nodes: %w", err)
+			}
+		}
+		if len(relations) > 0 {
+			if err := tx.Clauses(clause.OnConflict{Columns: graphRelationKey, DoNothing: true}).
+				CreateInBatches(relations, 500).Error; err != nil {
+				return fmt.Errorf("failed to create relationships: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to add graph: %v", err)
+		return err
+	}
+	return nil
+}
+
+// collectGraph converts the graphs to rows, merging duplicate nodes and relations since a row cannot be
+// upserted twice by the same statement
+func (r *pgGraphRepository) collectGraph(namespace types.NameSpace,
+	graphs []*types.GraphData,
+) ([]*graphNode, []*graphRelation) {
+	now := time.Now()
+	var nodes []*graphNode
+	nodeIndex := make(map[string]*graphNode)
+	addNode := func(name string, chunks []string, attributes []string) {
+		if node, ok := nodeIndex[name]; ok {
+			for _, chunk := range chunks {
+				if !slices.Contains(node.Chunks, chunk) {
+					node.Chunks = append(node.Chunks, chunk)
+				}
+			}
+			return
+		}
+		node := &graphNode{
+			KnowledgeBaseID: namespace.KnowledgeBase,
+			KnowledgeID:     namespace.Knowledge,
+			Name:            name,
+			Chunks:          append(types.StringArray{}, chunks...),
+			Attributes:      append(types.StringArray{}, attributes...),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		nodeIndex[name] = node
+		nodes = append(nodes, node)
+	}
+
+	var relations []*graphRelation
+	relationSeen := make(map[[3]string]bool)
+	for _, graph := range graphs {
+		for _, node := range graph.Node {
+			if node.Name != "" {
+				addNode(node.Name, node.Chunks, node.Attributes)
+			}
+		}
+		for _, rel := range graph.Relation {
+			key := [3]string{rel.Node1, rel.Node2, rel.Type}
+			if rel.Node1 == "" || rel.Node2 == "" || relationSeen[key] {
+				continue
+			}
+			relationSeen[key] = true
+			addNode(rel.Node1, nil, nil)
+			addNode(rel.Node2, nil, nil)
+			relations = append(relations, &graphRelation{
+				KnowledgeBaseID: namespace.KnowledgeBase,
+				KnowledgeID:     namespace.Knowledge,
+				Source:          rel.Node1,
+				Target:          rel.Node2,
+				Type:            rel.Type,
+				CreatedAt:       now,
+			})
+		}
+	}
+	return nodes, relations
+}
+
+// DelGraph deletes the nodes and relations of the namespaces
+func (r *pgGraphRepository) DelGraph(ctx context.Context, namespaces []types.NameSpace) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, namespace := range namespaces {
+			// An empty namespace would match the whole graph
+			if namespace.KnowledgeBase == "" && namespace.Knowledge == "" {
+				continue
+			}
+			if err := graphScope(tx, namespace).Delete(&graphRelation{}).Error; err != nil {
+				return fmt.Errorf("failed to delete relationships: %w", err)
+			}
+			if err := graphScope(tx, namespace).Delete(&graphNode{}).Error; err != nil {
+				return fmt.Errorf("failed to delete nodes: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to delete graph: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SearchNode returns the relations of the namespace with an endpoint whose name contains one of the
+// nodes, together with their endpoints
+func (r *pgGraphRepository) SearchNode(ctx context.Context,
+	namespace types.NameSpace, nodes []string,
+) (*types.GraphData, error) {
+	graphData := &types.GraphData{}
+	terms := nonEmptyTerms(nodes)
+	if len(terms) == 0 {
+		return graphData, nil
+	}
+
+	var relations []*graphRelation
+	if err := graphScope(r.db.WithContext(ctx), namespace).
+		Where(`EXISTS (SELECT 1 FROM jsonb_array_elements_text(?::jsonb) AS t(term)
+			WHERE strpos(source, t.term) > 0 OR strpos(target, t.term) > 0)`, terms).
+		Order("id").
+		Find(&relations).Error; err != nil {
+		logger.Errorf(ctx, "search node failed: %v", err)
+		return nil, err
+	}
+	if len(relations) == 0 {
+		return graphData, nil
+	}
+
+	var names []string
+	nameSeen := make(map[string]bool)
+	relationSeen := make(map[[3]string]bool)
+	for _, rel := range relations {
+		key := [3]string{rel.Source, rel.Target, rel.Type}
+		if relationSeen[key] {
+			continue
+		}
+		relationSeen[key] = true
+		graphData.Relation = append(graphData.Relation, &types.GraphRelation{
+			Node1: rel.Source,
+			Node2: rel.Target,
+			Type:  rel.Type,
+		})
+		for _, name := range []string{rel.Source, rel.Target} {
+			if !nameSeen[name] {
+				nameSeen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	graphNodes, err := r.loadNodes(ctx, namespace, names)
+	if err != nil {
+		logger.Errorf(ctx, "search node failed: %v", err)
+		return nil, err
+	}
+	graphData.Node = graphNodes
+	return graphData, nil
+}
+
+// loadNodes loads the named nodes of the namespace in the given order. Nodes of the same name in
+// different knowledge are merged.
+func (r *pgGraphRepository) loadNodes(ctx context.Context,
+	namespace types.NameSpace, names []string,
+) ([]*types.GraphNode, error) {
+	var rows []*graphNode
+	if err := graphScope(r.db.WithContext(ctx), namespace).
+		Where("name IN ?", names).
+		Order("id").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*types.GraphNode, len(names))
+	for _, node := range mergeNodeRows(rows) {
+		byName[node.Name] = node
+	}
+	nodes := make([]*types.GraphNode, 0, len(names))
+	for _, name := range names {
+		if node, ok := byName[name]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// mergeNodeRows converts node rows to graph nodes in the order of their first row, merging the chunks of
+// rows of the same name
+func mergeNodeRows(rows []*graphNode) []*types.GraphNode {
+	var nodes []*types.GraphNode
+	byName := make(map[string]*types.GraphNode, len(rows))
+	edited := make(map[string]bool)
+	for _, row := range rows {
+		node, ok := byName[row.Name]
+		if !ok {
+			node = &types.GraphNode{Name: row.Name}
+			byName[row.Name] = node
+			nodes = append(nodes, node)
+		}
+		for _, chunk := range row.Chunks {
+			if !slices.Contains(node.Chunks, chunk) {
+				node.Chunks = append(node.Chunks, chunk)
+			}
+		}
+		// Attributes edited through the API take precedence over the extracted ones
+		if row.KnowledgeID == types.ManualGraphKnowledgeID && len(row.Attributes) > 0 {
+			node.Attributes, edited[row.Name] = row.Attributes, true
+		} else if len(node.Attributes) == 0 && !edited[row.Name] {
+			node.Attributes = row.Attributes
+		}
+	}
+	return nodes
+}
+
+// graphScopeSQL returns the namespace condition on the table alias with its arguments
+func graphScopeSQL(alias string, namespace types.NameSpace) (string, []any) {
+	conditions := []string{"TRUE"}
+	var args []any
+	if namespace.KnowledgeBase != "" {
+		conditions = append(conditions, alias+".knowledge_base_id = ?")
+		args = append(args, namespace.KnowledgeBase)
+	}
+	if namespace.Knowledge != "" {
+		conditions = append(conditions, alias+".knowledge_id = ?")
+		args = append(args, namespace.Knowledge)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// graphTypesSQL returns the relation type condition on the table alias with its arguments
+func graphTypesSQL(alias string, relationTypes []string) (string, []any) {
+	if len(relationTypes) == 0 {
+		return "TRUE", nil
+	}
+	return alias + ".type IN ?", []any{relationTypes}
+}
+
+// graphExpandSQL walks the relations from the matched start nodes up to the given depth with a recursive
+// CTE and ranks the reached nodes, start nodes first and then by degree
+const graphExpandSQL = `
+WITH RECURSIVE walk(name, depth) AS (
+	SELECT n.name, 0 FROM graph_nodes n
+	WHERE %[1]s AND EXISTS (
+		SELECT 1 FROM jsonb_array_elements_text(?::jsonb) AS t(term) WHERE strpos(n.name, t.term) > 0
+	)
+	UNION
+	SELECT CASE WHEN r.source = w.name THEN r.target ELSE r.source END, w.depth + 1
+	FROM walk w JOIN graph_relations r ON r.source = w.name OR r.target = w.name
+	WHERE w.depth < ? AND %[2]s AND %[3]s
+),
+reached AS (
+	SELECT name, MIN(depth) AS depth FROM walk GROUP BY name
+),
+degrees AS (
+	SELECT e.name, COUNT(*) AS degree FROM (
+		SELECT r.source AS name FROM graph_relations r WHERE %[2]s
+		UNION ALL
+		SELECT r.target AS name FROM graph_relations r WHERE %[2]s
+	) e
+	WHERE e.name IN (SELECT name FROM reached)
+	GROUP BY e.name
+)
+SELECT reached.name, reached.depth, COALESCE(degrees.degree, 0) AS degree
+FROM reached LEFT JOIN degrees ON degrees.name = reached.name
+ORDER BY reached.depth = 0 DESC, degree DESC, reached.depth, reached.name
+LIMIT ?`
+
+// reachedNode is a node reached by the expansion walk
+type reachedNode struct {
+	Name   string
+	Depth  int
+	Degree int
+}
+
+// ExpandNodes returns the neighbourhood of the query nodes up to the query hops, with node degrees
+func (r *pgGraphRepository) ExpandNodes(ctx context.Context,
+	namespace types.NameSpace, query *types.GraphQuery,
+) (*types.GraphData, error) {
+	query.Normalize()
+	graphData := &types.GraphData{}
+	terms := nonEmptyTerms(query.Nodes)
+	if len(terms) == 0 {
+		return graphData, nil
+	}
+
+	nodeScope, nodeScopeArgs := graphScopeSQL("n", namespace)
+	relScope, relScopeArgs := graphScopeSQL("r", namespace)
+	relTypes, relTypesArgs := graphTypesSQL("r", query.RelationTypes)
+	var args []any
+	args = append(args, nodeScopeArgs...)
+	args = append(args, terms, query.Hops)
+	args = append(args, relScopeArgs...)
+	args = append(args, relTypesArgs...)
+	args = append(args, relScopeArgs...)
+	args = append(args, relScopeArgs...)
+	args = append(args, query.Limit)
+
+	var reached []*reachedNode
+	if err := r.db.WithContext(ctx).
+		Raw(fmt.Sprintf(graphExpandSQL, nodeScope, relScope, relTypes), args...).
+		Scan(&reached).Error; err != nil {
+		logger.Errorf(ctx, "expand nodes failed: %v", err)
+		return nil, err
+	}
+	if len(reached) == 0 {
+		return graphData, nil
+	}
+
+	names := make([]string, 0, len(reached))
+	degrees := make(map[string]int, len(reached))
+	for _, node := range reached {
+		names = append(names, node.Name)
+		degrees[node.Name] = node.Degree
+	}
+	var relations []*graphRelation
+	db := graphScope(r.db.WithContext(ctx), namespace).
+		Where("source IN ? AND target IN ?", names, names)
+	if len(query.RelationTypes) > 0 {
+		db = db.Where("type IN ?", query.RelationTypes)
+	}
+	if err := db.Order("id").Find(&relations).Error; err != nil {
+		logger.Errorf(ctx, "expand nodes failed: %v", err)
+		return nil, err
+	}
+	graphData.Relation = toGraphRelations(relations)
+
+	nodes, err := r.loadNodes(ctx, namespace, names)
+	if err != nil {
+		logger.Errorf(ctx, "expand nodes failed: %v", err)
+		return nil, err
+	}
+	for _, node := range nodes {
+		node.Degree = degrees[node.Name]
+	}
+	graphData.Node = nodes
+	return graphData, nil
+}
+
+// pathStep is the relation through which a node was first reached by the path search
+type pathStep struct {
+	prev     string
+	relation *graphRelation
+}
+
+// FindPath returns the shortest path between the query entities in path order. The search is a
+// breadth-first search with one query per hop, which stops at the first hop reaching a target.
+func (r *pgGraphRepository) FindPath(ctx context.Context,
+	namespace types.NameSpace, query *types.GraphPathQuery,
+) (*types.GraphData, error) {
+	query.Normalize()
+	graphData := &types.GraphData{}
+	if query.Source == "" || query.Target == "" {
+		return graphData, nil
+	}
+	sources, err := r.matchNodeNames(ctx, namespace, query.Source)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := r.matchNodeNames(ctx, namespace, query.Target)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 || len(targets) == 0 {
+		return graphData, nil
+	}
+	isTarget := make(map[string]bool, len(targets))
+	for _, name := range targets {
+		isTarget[name] = true
+	}
+
+	steps := make(map[string]*pathStep)
+	for _, name := range sources {
+		steps[name] = &pathStep{}
+	}
+	frontier := sources
+	found := ""
+	for hop := 0; hop < query.MaxHops && found == "" && len(frontier) > 0; hop++ {
+		var relations []*graphRelation
+		db := graphScope(r.db.WithContext(ctx), namespace).
+			Where("source IN ? OR target IN ?", frontier, frontier)
+		if len(query.RelationTypes) > 0 {
+			db = db.Where("type IN ?", query.RelationTypes)
+		}
+		if err := db.Order("id").Find(&relations).Error; err != nil {
+			logger.Errorf(ctx, "find path failed: %v", err)
+			return nil, err
+		}
+		inFrontier := make(map[string]bool, len(frontier))
+		for _, name := range frontier {
+			inFrontier[name] = true
+		}
+		var next []string
+		for _, rel := range relations {
+			for _, edge := range [][2]string{{rel.Source, rel.Target}, {rel.Target, rel.Source}} {
+				from, to := edge[0], edge[1]
+				if !inFrontier[from] || steps[to] != nil {
+					continue
+				}
+				steps[to] = &pathStep{prev: from, relation: rel}
+				next = append(next, to)
+				if isTarget[to] && found == "" {
+					found = to
+				}
+			}
+		}
+		frontier = next
+	}
+	if found == "" {
+		return graphData, nil
+	}
+
+	var names []string
+	var relations []*graphRelation
+	for name := found; ; name = steps[name].prev {
+		names = append([]string{name}, names...)
+		if steps[name].relation == nil {
+			break
+		}
+		relations = append([]*graphRelation{steps[name].relation}, relations...)
+	}
+	graphData.Relation = toGraphRelations(relations)
+	if graphData.Node, err = r.loadNodes(ctx, namespace, names); err != nil {
+		logger.Errorf(ctx, "find path failed: %v", err)
+		return nil, err
+	}
+	return graphData, nil
+}
+
+// matchNodeNames returns the names of the nodes of the namespace containing the term
+func (r *pgGraphRepository) matchNodeNames(ctx context.Context,
+	namespace types.NameSpace, term string,
+) ([]string, error) {
+	var names []string
+	if err := graphScope(r.db.WithContext(ctx).Model(&graphNode{}), namespace).
+		Where("strpos(name, ?) > 0", term).
+		Distinct("name").
+		Order("name").
+		Pluck("name", &names).Error; err != nil {
+		logger.Errorf(ctx, "find path failed: %v", err)
+		return nil, err
+	}
+	return names, nil
+}
+
+// toGraphRelations converts relation rows to graph relations
+func toGraphRelations(relations []*graphRelation) []*types.GraphRelation {
+	result := make([]*types.GraphRelation, 0, len(relations))
+	seen := make(map[[3]string]bool, len(relations))
+	for _, rel := range relations {
+		key := [3]string{rel.Source, rel.Target, rel.Type}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, &types.GraphRelation{Node1: rel.Source, Node2: rel.Target, Type: rel.Type})
+	}
+	return result
+}
+
+// nonEmptyTerms drops empty search terms, which would match every name. The terms are bound as a JSON
+// array since gorm expands a bound slice into a row.
+func nonEmptyTerms(nodes []string) types.StringArray {
+	terms := make(types.StringArray, 0, len(nodes))
+	for _, node := range nodes {
+		if node != "" {
+			terms = append(terms, node)
+		}
+	}
+	return terms
+}
+
+// ListNodes returns the nodes of the namespace, nodes of the same name in different knowledge are merged
+func (r *pgGraphRepository) ListNodes(ctx context.Context, namespace types.NameSpace) ([]*types.GraphNode, error) {
+	var rows []*graphNode
+	if err := graphScope(r.db.WithContext(ctx), namespace).Order("id").Find(&rows).Error; err != nil {
+		logger.Errorf(ctx, "list nodes failed: %v", err)
+		return nil, err
+	}
+	return mergeNodeRows(rows), nil
+}
+
+// rankNodesSQL ranks the nodes of the namespace matching the keyword by the number of relations from and to
+// them, relations of different knowledge counted once
+const rankNodesSQL = `
+WITH matched AS (
+	SELECT DISTINCT n.name FROM graph_nodes n
+	WHERE %[1]s AND strpos(lower(n.name), lower(?)) > 0
+),
+relations AS (
+	SELECT DISTINCT r.source, r.target, r.type FROM graph_relations r
+	WHERE %[2]s
+		AND (r.source IN (SELECT name FROM matched) OR r.target IN (SELECT name FROM matched))
+),
+degrees AS (
+	SELECT e.name, COUNT(*) AS degree FROM (
+		SELECT source AS name FROM relations
+		UNION ALL
+		SELECT target AS name FROM relations
+	) e
+	GROUP BY e.name
+)
+SELECT matched.name, COALESCE(degrees.degree, 0) AS degree
+FROM matched LEFT JOIN degrees ON degrees.name = matched.name
+ORDER BY degree DESC, matched.name COLLATE "C"
+LIMIT ? OFFSET ?`
+
+// rankedNode is a node ranked by its degree
+type rankedNode struct {
+	Name   string
+	Degree int
+}
+
+// RankNodes returns a page of the nodes of the namespace matching the keyword, most connected first
+func (r *pgGraphRepository) RankNodes(ctx context.Context,
+	namespace types.NameSpace, keyword string, page *types.Pagination,
+) ([]*types.GraphNode, int64, error) {
+	var total int64
+	if err := graphScope(r.db.WithContext(ctx).Model(&graphNode{}), namespace).
+		Where("strpos(lower(name), lower(?)) > 0", keyword).
+		Distinct("name").
+		Count(&total).Error; err != nil {
+		logger.Errorf(ctx, "rank nodes failed: %v", err)
+		return nil, 0, err
+	}
+
+	nodeScope, nodeScopeArgs := graphScopeSQL("n", namespace)
+	relScope, relScopeArgs := graphScopeSQL("r", namespace)
+	var args []any
+	args = append(args, nodeScopeArgs...)
+	args = append(args, keyword)
+	args = append(args, relScopeArgs...)
+	args = append(args, page.Limit(), page.Offset())
+	var ranked []*rankedNode
+	if err := r.db.WithContext(ctx).
+		Raw(fmt.Sprintf(rankNodesSQL, nodeScope, relScope), args...).
+		Find(&ranked).Error; err != nil {
+		logger.Errorf(ctx, "rank nodes failed: %v", err)
+		return nil, 0, err
+	}
+	if len(ranked) == 0 {
+		return []*types.GraphNode{}, total, nil
+	}
+
+	names := make([]string, 0, len(ranked))
+	degrees := make(map[string]int, len(ranked))
+	for _, node := range ranked {
+		names = append(names, node.Name)
+		degrees[node.Name] = node.Degree
+	}
+	nodes, err := r.loadNodes(ctx, namespace, names)
+	if err != nil {
+		logger.Errorf(ctx, "rank nodes failed: %v", err)
+		return nil, 0, err
+	}
+	for _, node := range nodes {
+		node.Degree = degrees[node.Name]
+	}
+	return nodes, total, nil
+}
+
+// GetNode returns the named node of the namespace, nil if there is none
+func (r *pgGraphRepository) GetNode(ctx context.Context,
+	namespace types.NameSpace, name string,
+) (*types.GraphNode, error) {
+	nodes, err := r.loadNodes(ctx, namespace, []string{name})
+	if err != nil {
+		logger.Errorf(ctx, "get node failed: %v", err)
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	return nodes[0], nil
+}
+
+// renameNodesSQL copies the nodes of the old name under the new name, merging them into the existing nodes
+// of the new name of the same knowledge
+const renameNodesSQL = `
+INSERT INTO graph_nodes (knowledge_base_id, knowledge_id, name, chunks, attributes, created_at, updated_at)
+SELECT n.knowledge_base_id, n.knowledge_id, ?, n.chunks, n.attributes, n.created_at, ?
+FROM graph_nodes n
+WHERE %[1]s AND n.name = ?
+ON CONFLICT (knowledge_base_id, knowledge_id, name) DO UPDATE SET
+	chunks = %[2]s,
+	attributes = %[3]s,
+	updated_at = EXCLUDED.updated_at`
+
+// renameRelationsSQL copies the relations of the old name with the endpoint renamed, relations that become
+// loops are dropped and relations that already exist are kept
+const renameRelationsSQL = `
+INSERT INTO graph_relations (knowledge_base_id, knowledge_id, source, target, type, created_at)
+SELECT knowledge_base_id, knowledge_id, source, target, type, created_at FROM (
+	SELECT r.knowledge_base_id, r.knowledge_id, r.type, r.created_at,
+		CASE WHEN r.source = ? THEN ? ELSE r.source END AS source,
+		CASE WHEN r.target = ? THEN ? ELSE r.target END AS target
+	FROM graph_relations r
+	WHERE %[1]s AND (r.source = ? OR r.target = ?)
+) moved
+WHERE moved.source <> moved.target
+ON CONFLICT (knowledge_base_id, knowledge_id, source, target, type) DO NOTHING`
+
+// RenameNodes renames the nodes of the namespace, merging them into the nodes of the new name of the same
+// knowledge. The new names must not be renamed themselves.
+func (r *pgGraphRepository) RenameNodes(ctx context.Context,
+	namespace types.NameSpace, renames map[string]string,
+) error {
+	// An empty namespace would rename the nodes of every knowledge base
+	if namespace.KnowledgeBase == "" && namespace.Knowledge == "" {
+		return nil
+	}
+	names := make([]string, 0, len(renames))
+	for from, to := range renames {
+		if from != "" && to != "" && from != to {
+			names = append(names, from)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	slices.Sort(names)
+
+	nodeScope, nodeScopeArgs := graphScopeSQL("n", namespace)
+	relScope, relScopeArgs := graphScopeSQL("r", namespace)
+	nodesSQL := fmt.Sprintf(renameNodesSQL, nodeScope, mergeArrayExpr("chunks"), mergeArrayExpr("attributes"))
+	relationsSQL := fmt.Sprintf(renameRelationsSQL, relScope)
+	now := time.Now()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, from := range names {
+			to := renames[from]
+			args := append([]any{to, now}, nodeScopeArgs...)
+			if err := tx.Exec(nodesSQL, append(args, from)...).Error; err != nil {
+				return fmt.Errorf("failed to rename nodes: %w", err)
+			}
+			if err := graphScope(tx, namespace).Where("name = ?", from).Delete(&graphNode{}).Error; err != nil {
+				return fmt.Errorf("failed to delete renamed nodes: %w", err)
+			}
+			args = append([]any{from, to, from, to}, relScopeArgs...)
+			if err := tx.Exec(relationsSQL, append(args, from, from)...).Error; err != nil {
+				return fmt.Errorf("failed to move relationships: %w", err)
+			}
+			if err := graphScope(tx, namespace).
+				Where("source = ? OR target = ?", from, from).
+				Delete(&graphRelation{}).Error; err != nil {
+				return fmt.Errorf("failed to delete moved relationships: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to rename nodes: %v", err)
+		return err
+	}
+	logger.Infof(ctx, "renamed %d nodes of knowledge base %s", len(names), namespace.KnowledgeBase)
+	return nil
+}
+
+// ListRelations returns the relations of the namespace, relations of different knowledge are merged
+func (r *pgGraphRepository) ListRelations(ctx context.Context,
+	namespace types.NameSpace,
+) ([]*types.GraphRelation, error) {
+	var relations []*graphRelation
+	if err := graphScope(r.db.WithContext(ctx), namespace).Order("id").Find(&relations).Error; err != nil {
+		logger.Errorf(ctx, "list relations failed: %v", err)
+		return nil, err
+	}
+	return toGraphRelations(relations), nil
+}
+
+// ListNodeRelations returns the relations of the namespace from or to one of the named nodes
+func (r *pgGraphRepository) ListNodeRelations(ctx context.Context,
+	namespace types.NameSpace, names []string,
+) ([]*types.GraphRelation, error) {
+	return r.listRelationsWhere(ctx, namespace, "source IN ? OR target IN ?", names)
+}
+
+// ListRelationsAmong returns the relations of the namespace between the named nodes
+func (r *pgGraphRepository) ListRelationsAmong(ctx context.Context,
+	namespace types.NameSpace, names []string,
+) ([]*types.GraphRelation, error) {
+	return r.listRelationsWhere(ctx, namespace, "source IN ? AND target IN ?", names)
+}
+
+// listRelationsWhere returns the relations of the namespace whose endpoints meet the condition on the names
+func (r *pgGraphRepository) listRelationsWhere(ctx context.Context,
+	namespace types.NameSpace, condition string, names []string,
+) ([]*types.GraphRelation, error) {
+	if len(names) == 0 {
+		return []*types.GraphRelation{}, nil
+	}
+	var relations []*graphRelation
+	if err := graphScope(r.db.WithContext(ctx), namespace).
+		Where(condition, names, names).
+		Order("id").
+		Find(&relations).Error; err != nil {
+		logger.Errorf(ctx, "list relations failed: %v", err)
+		return nil, err
+	}
+	return toGraphRelations(relations), nil
+}
+
+// DeleteNodes deletes the named nodes of the namespace and their relations
+func (r *pgGraphRepository) DeleteNodes(ctx context.Context, namespace types.NameSpace, names []string) error {
+	if len(names) == 0 || (namespace.KnowledgeBase == "" && namespace.Knowledge == "") {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := graphScope(tx, namespace).
+			Where("source IN ? OR target IN ?", names, names).
+			Delete(&graphRelation{}).Error; err != nil {
+			return fmt.Errorf("failed to delete relationships: %w", err)
+		}
+		if err := graphScope(tx, namespace).Where("name IN ?", names).Delete(&graphNode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete nodes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to delete nodes: %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteRelations deletes the relations of the namespace
+func (r *pgGraphRepository) DeleteRelations(ctx context.Context,
+	namespace types.NameSpace, relations []*types.GraphRelation,
+) error {
+	if len(relations) == 0 || (namespace.KnowledgeBase == "" && namespace.Knowledge == "") {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rel := range relations {
+			if err := graphScope(tx, namespace).
+				Where("source = ? AND target = ? AND type = ?", rel.Node1, rel.Node2, rel.Type).
+				Delete(&graphRelation{}).Error; err != nil {
+				return fmt.Errorf("failed to delete relationships: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to delete relations: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SetNodeAttributes creates the node of the namespace or replaces its attributes
+func (r *pgGraphRepository) SetNodeAttributes(ctx context.Context,
+	namespace types.NameSpace, name string, attributes []string,
+) error {
+	now := time.Now()
+	node := &graphNode{
+		KnowledgeBaseID: namespace.KnowledgeBase,
+		KnowledgeID:     namespace.Knowledge,
+		Name:            name,
+		Chunks:          types.StringArray{},
+		Attributes:      append(types.StringArray{}, attributes...),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   graphNodeKey,
+		DoUpdates: clause.AssignmentColumns([]string{"attributes", "updated_at"}),
+	}).Create(node).Error; err != nil {
+		logger.Errorf(ctx, "failed to set node attributes: %v", err)
+		return err
+	}
+	return nil
+}
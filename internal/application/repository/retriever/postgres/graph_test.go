@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/repository/dbtest"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestAddGraphUpsertsNodes(t *testing.T) {
+	db, statements := dbtest.NewDryRunDB(t)
+	namespace := types.NameSpace{KnowledgeBase: "kb1", Knowledge: "k1"}
+	err := NewPostgresGraphRepository(db).AddGraph(context.Background(), namespace, []*types.GraphData{
+		{
+			Node:     []*types.GraphNode{{Name: "A", Chunks: []string{"c1"}, Attributes: []string{"x"}}},
+			Relation: []*types.GraphRelation{{Node1: "A", Node2: "B", Type: "r"}, {Node1: "A", Node2: "", Type: "r"}},
+		},
+		// The same node and relation extracted from another chunk
+		{
+			Node:     []*types.GraphNode{{Name: "A", Chunks: []string{"c1", "c2"}, Attributes: []string{"y"}}},
+			Relation: []*types.GraphRelation{{Node1: "A", Node2: "B", Type: "r"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("AddGraph failed: %v", err)
+	}
+	if len(*statements) != 2 {
+		t.Fatalf("expected a node and a relation statement, got %d", len(*statements))
+	}
+
+	nodes := (*statements)[0]
+	wantSQL := `INSERT INTO "graph_nodes" ("knowledge_base_id","knowledge_id","name","chunks","attributes",` +
+		`"created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7),($8,$9,$10,$11,$12,$13,$14) ` +
+		`ON CONFLICT ("knowledge_base_id","knowledge_id","name") DO UPDATE SET ` +
+		`"chunks"=` + mergeArrayExpr("chunks") + `,"updated_at"=EXCLUDED.updated_at RETURNING "id"`
+	if nodes.SQL != wantSQL {
+		t.Errorf("node sql = %q, want %q", nodes.SQL, wantSQL)
+	}
+	// Duplicate nodes are merged into one row with the union of their chunks and the first attributes, the
+	// missing endpoint is created without chunks
+	wantNodes := [][]interface{}{
+		{"kb1", "k1", "A", types.StringArray{"c1", "c2"}, types.StringArray{"x"}},
+		{"kb1", "k1", "B", types.StringArray{}, types.StringArray{}},
+	}
+	for i, want := range wantNodes {
+		if got := nodes.Vars[i*7 : i*7+5]; !reflect.DeepEqual(got, want) {
+			t.Errorf("node %d vars = %#v, want %#v", i, got, want)
+		}
+	}
+
+	relations := (*statements)[1]
+	wantSQL = `INSERT INTO "graph_relations" ("knowledge_base_id","knowledge_id","source","target","type",` +
+		`"created_at") VALUES ($1,$2,$3,$4,$5,$6) ` +
+		`ON CONFLICT ("knowledge_base_id","knowledge_id","source","target","type") DO NOTHING RETURNING "id"`
+	if relations.SQL != wantSQL {
+		t.Errorf("relation sql = %q, want %q", relations.SQL, wantSQL)
+	}
+	if want := []interface{}{"kb1", "k1", "A", "B", "r"}; !reflect.DeepEqual(relations.Vars[:5], want) {
+		t.Errorf("relation vars = %#v, want %#v", relations.Vars[:5], want)
+	}
+}
+
+func TestAddGraphEmpty(t *testing.T) {
+	db, statements := dbtest.NewDryRunDB(t)
+	err := NewPostgresGraphRepository(db).AddGraph(context.Background(),
+		types.NameSpace{KnowledgeBase: "kb1"}, []*types.GraphData{{Node: []*types.GraphNode{{Name: ""}}}})
+	if err != nil {
+		t.Fatalf("AddGraph failed: %v", err)
+	}
+	if len(*statements) != 0 {
+		t.Errorf("expected no statement, got %v", *statements)
+	}
+}
+
+func TestDelGraphScopesNamespaces(t *testing.T) {
+	db, statements := dbtest.NewDryRunDB(t)
+	err := NewPostgresGraphRepository(db).DelGraph(context.Background(), []types.NameSpace{
+		{KnowledgeBase: "kb1", Knowledge: "k1"},
+		// An empty namespace must not delete the whole graph
+		{},
+		{KnowledgeBase: "kb2"},
+	})
+	if err != nil {
+		t.Fatalf("DelGraph failed: %v", err)
+	}
+	want := []dbtest.Statement{
+		{SQL: `DELETE FROM "graph_relations" WHERE knowledge_base_id = $1 AND knowledge_id = $2`,
+			Vars: []interface{}{"kb1", "k1"}},
+		{SQL: `DELETE FROM "graph_nodes" WHERE knowledge_base_id = $1 AND knowledge_id = $2`,
+			Vars: []interface{}{"kb1", "k1"}},
+		{SQL: `DELETE FROM "graph_relations" WHERE knowledge_base_id = $1`, Vars: []interface{}{"kb2"}},
+		{SQL: `DELETE FROM "graph_nodes" WHERE knowledge_base_id = $1`, Vars: []interface{}{"kb2"}},
+	}
+	if !reflect.DeepEqual(*statements, want) {
+		t.Errorf("statements = %#v, want %#v", *statements, want)
+	}
+}
+
+func TestSearchNode(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []string
+		want  []dbtest.Statement
+	}{
+		{
+			name:  "terms are bound as one array",
+			nodes: []string{"A", "", "B"},
+			want: []dbtest.Statement{{
+				SQL: `SELECT * FROM "graph_relations" WHERE knowledge_base_id = $1 AND knowledge_id = $2 AND ` +
+					`(EXISTS (SELECT 1 FROM jsonb_array_elements_text($3::jsonb) AS t(term)
+			WHERE strpos(source, t.term) > 0 OR strpos(target, t.term) > 0)) ORDER BY id`,
+				Vars: []interface{}{"kb1", "k1", types.StringArray{"A", "B"}},
+			}},
+		},
+		{
+			name:  "empty terms match nothing",
+			nodes: []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := dbtest.NewDryRunDB(t)
+			graph, err := NewPostgresGraphRepository(db).SearchNode(context.Background(),
+				types.NameSpace{KnowledgeBase: "kb1", Knowledge: "k1"}, tt.nodes)
+			if err != nil {
+				t.Fatalf("SearchNode failed: %v", err)
+			}
+			if len(graph.Node) != 0 || len(graph.Relation) != 0 {
+				t.Errorf("expected an empty graph, got %+v", graph)
+			}
+			if !reflect.DeepEqual(*statements, tt.want) {
+				t.Errorf("statements = %#v, want %#v", *statements, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeNodeRows(t *testing.T) {
+	rows := []*graphNode{
+		{Name: "A", KnowledgeID: "k1", Chunks: types.StringArray{"c1"}, Attributes: types.StringArray{"x"}},
+		{Name: "B", KnowledgeID: "k1", Chunks: types.StringArray{"c2"}},
+		{Name: "A", KnowledgeID: "k2", Chunks: types.StringArray{"c1", "c3"}, Attributes: types.StringArray{"y"}},
+		{Name: "A", KnowledgeID: types.ManualGraphKnowledgeID, Attributes: types.StringArray{"edited"}},
+	}
+	want := []*types.GraphNode{
+		{Name: "A", Chunks: []string{"c1", "c3"}, Attributes: []string{"edited"}},
+		{Name: "B", Chunks: []string{"c2"}},
+	}
+	if got := mergeNodeRows(rows); !reflect.DeepEqual(got, want) {
+		t.Errorf("nodes = %+v, want %+v", got, want)
+	}
+}
+
+func TestRankNodesPagesInDatabase(t *testing.T) {
+	db, statements := dbtest.NewDryRunDB(t)
+	nodes, total, err := NewPostgresGraphRepository(db).RankNodes(context.Background(),
+		types.NameSpace{KnowledgeBase: "kb1"}, "Al", &types.Pagination{Page: 3, PageSize: 10})
+	if err != nil {
+		t.Fatalf("RankNodes failed: %v", err)
+	}
+	if len(nodes) != 0 || total != 0 {
+		t.Errorf("expected no nodes, got %d of %d", len(nodes), total)
+	}
+	if len(*statements) != 2 {
+		t.Fatalf("expected a count and a rank statement, got %d", len(*statements))
+	}
+
+	count := (*statements)[0]
+	wantSQL := `SELECT COUNT(DISTINCT("name")) FROM "graph_nodes" ` +
+		`WHERE knowledge_base_id = $1 AND strpos(lower(name), lower($2)) > 0`
+	if count.SQL != wantSQL {
+		t.Errorf("count sql = %q, want %q", count.SQL, wantSQL)
+	}
+	if want := []interface{}{"kb1", "Al"}; !reflect.DeepEqual(count.Vars, want) {
+		t.Errorf("count vars = %#v, want %#v", count.Vars, want)
+	}
+
+	// Filtering, ranking and paging happen in the query, only the page of nodes is loaded
+	rank := (*statements)[1]
+	for _, part := range []string{
+		"n.knowledge_base_id = $1 AND strpos(lower(n.name), lower($2)) > 0",
+		"r.knowledge_base_id = $3",
+		`ORDER BY degree DESC, matched.name COLLATE "C"`,
+		"LIMIT $4 OFFSET $5",
+	} {
+		if !strings.Contains(rank.SQL, part) {
+			t.Errorf("rank sql %q does not contain %q", rank.SQL, part)
+		}
+	}
+	if want := []interface{}{"kb1", "Al", "kb1", 10, 20}; !reflect.DeepEqual(rank.Vars, want) {
+		t.Errorf("rank vars = %#v, want %#v", rank.Vars, want)
+	}
+}
+
+func TestNodeLookups(t *testing.T) {
+	namespace := types.NameSpace{KnowledgeBase: "kb1"}
+	tests := []struct {
+		name   string
+		lookup func(*pgGraphRepository) error
+		want   dbtest.Statement
+	}{
+		{
+			name: "get node",
+			lookup: func(r *pgGraphRepository) error {
+				node, err := r.GetNode(context.Background(), namespace, "A")
+				if node != nil {
+					t.Errorf("expected no node, got %+v", node)
+				}
+				return err
+			},
+			want: dbtest.Statement{
+				SQL:  `SELECT * FROM "graph_nodes" WHERE knowledge_base_id = $1 AND name IN ($2) ORDER BY id`,
+				Vars: []interface{}{"kb1", "A"},
+			},
+		},
+		{
+			name: "relations of nodes",
+			lookup: func(r *pgGraphRepository) error {
+				_, err := r.ListNodeRelations(context.Background(), namespace, []string{"A"})
+				return err
+			},
+			want: dbtest.Statement{
+				SQL: `SELECT * FROM "graph_relations" WHERE knowledge_base_id = $1 ` +
+					`AND (source IN ($2) OR target IN ($3)) ORDER BY id`,
+				Vars: []interface{}{"kb1", "A", "A"},
+			},
+		},
+		{
+			name: "relations among nodes",
+			lookup: func(r *pgGraphRepository) error {
+				_, err := r.ListRelationsAmong(context.Background(), namespace, []string{"A", "B"})
+				return err
+			},
+			want: dbtest.Statement{
+				SQL: `SELECT * FROM "graph_relations" WHERE knowledge_base_id = $1 ` +
+					`AND (source IN ($2,$3) AND target IN ($4,$5)) ORDER BY id`,
+				Vars: []interface{}{"kb1", "A", "B", "A", "B"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := dbtest.NewDryRunDB(t)
+			if err := tt.lookup(&pgGraphRepository{db: db}); err != nil {
+				t.Fatalf("lookup failed: %v", err)
+			}
+			if want := []dbtest.Statement{tt.want}; !reflect.DeepEqual(*statements, want) {
+				t.Errorf("statements = %#v, want %#v", *statements, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// knowledgeFilterSQL returns the condition restricting embeddings to the knowledge of the searched knowledge
+// bases that matches the metadata filter, so the filter is applied by the retrieval query itself
+func knowledgeFilterSQL(params types.RetrieveParams) (string, []interface{}, error) {
+	cond, vars, err := repository.BuildMetadataFilterSQL(params.Filter)
+	if err != nil {
+		return "", nil, err
+	}
+	scope := "deleted_at IS NULL"
+	var scopeVars []interface{}
+	if len(params.KnowledgeBaseIDs) > 0 {
+		scope += " AND knowledge_base_id IN ?"
+		scopeVars = append(scopeVars, common.ToInterfaceSlice(params.KnowledgeBaseIDs))
+	}
+	return "knowledge_id IN (SELECT id FROM knowledges WHERE " + scope + " AND " + cond + ")",
+		append(scopeVars, vars...), nil
+}
+
+// numberPlaceholders rewrites the ? placeholders of a condition into $n placeholders numbered from start,
+// expanding list variables as gorm does, for queries written with numbered placeholders
+func numberPlaceholders(sql string, vars []interface{}, start int) (string, []interface{}) {
+	var b strings.Builder
+	numbered := make([]interface{}, 0, len(vars))
+	bind := func(value interface{}) {
+		numbered = append(numbered, value)
+		fmt.Fprintf(&b, "$%d", start+len(numbered)-1)
+	}
+	next := 0
+	for i := 0; i < len(sql); i++ {
+		if sql[i] != '?' || next >= len(vars) {
+			b.WriteByte(sql[i])
+			continue
+		}
+		value := vars[next]
+		next++
+		list, ok := value.([]interface{})
+		if !ok {
+			bind(value)
+			continue
+		}
+		b.WriteByte('(')
+		for j, item := range list {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			bind(item)
+		}
+		b.WriteByte(')')
+	}
+	return b.String(), numbered
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/repository/dbtest"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestKeywordsRetrieveEvaluatesMetadataFilter(t *testing.T) {
+	db, statements := dbtest.NewDryRunDB(t)
+	_, err := (&pgRepository{db: db}).KeywordsRetrieve(context.Background(), types.RetrieveParams{
+		Query:            "q",
+		KnowledgeBaseIDs: []string{"kb1"},
+		TopK:             5,
+		RetrieverType:    types.KeywordsRetrieverType,
+		Filter: &types.MetadataFilter{Op: types.MetadataFilterOpIn, Field: "metadata.department",
+			Values: []interface{}{"legal", "hr"}},
+	})
+	if err != nil {
+		t.Fatalf("KeywordsRetrieve failed: %v", err)
+	}
+	if len(*statements) != 1 {
+		t.Fatalf("expected one statement, got %d", len(*statements))
+	}
+	// The filter is a subquery of the retrieval, not a list of knowledge resolved beforehand
+	statement := (*statements)[0]
+	want := "knowledge_id IN (SELECT id FROM knowledges WHERE deleted_at IS NULL AND knowledge_base_id IN ($2) " +
+		"AND COALESCE(((metadata->>($3::text)) IN ($4,$5)), FALSE))"
+	if !strings.Contains(statement.SQL, want) {
+		t.Errorf("sql %q does not contain %q", statement.SQL, want)
+	}
+	if want := []interface{}{"kb1", "kb1", "department", "legal", "hr"}; !reflect.DeepEqual(statement.Vars[:5], want) {
+		t.Errorf("vars = %#v, want %#v", statement.Vars[:5], want)
+	}
+}
+
+func TestNumberPlaceholders(t *testing.T) {
+	sql, vars, err := knowledgeFilterSQL(types.RetrieveParams{
+		KnowledgeBaseIDs: []string{"kb1", "kb2"},
+		Filter: &types.MetadataFilter{Op: types.MetadataFilterOpAnd, Filters: []*types.MetadataFilter{
+			{Op: types.MetadataFilterOpEq, Field: "file_type", Value: "pdf"},
+			{Op: types.MetadataFilterOpRange, Field: "metadata.pages", Gte: 10},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("knowledgeFilterSQL failed: %v", err)
+	}
+	sql, vars = numberPlaceholders(sql, vars, 3)
+	want := "knowledge_id IN (SELECT id FROM knowledges WHERE deleted_at IS NULL AND knowledge_base_id IN ($3,$4) " +
+		"AND (COALESCE((file_type = $5), FALSE) AND COALESCE(((CASE WHEN (metadata->>($6::text)) ~ " +
+		`'^-{0,1}[0-9]+(\.[0-9]+){0,1}$' THEN ((metadata->>($7::text)))::numeric END) >= $8), FALSE)))`
+	if sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if want := []interface{}{"kb1", "kb2", "pdf", "pages", "pages", float64(10)}; !reflect.DeepEqual(vars, want) {
+		t.Errorf("vars = %#v, want %#v", vars, want)
+	}
+}
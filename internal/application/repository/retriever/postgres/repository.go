@@ -36,6 +36,11 @@ func (r *pgRepository) Support() []types.RetrieverType {
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// SupportMetadataFilter reports that metadata filters are evaluated against the knowledges table
+func (r *pgRepository) SupportMetadataFilter() bool {
+	return true
+}
+
 // calculateIndexStorageSize calculates storage size for a single index entry
 func (g *pgRepository) calculateIndexStorageSize(embeddingDB *pgVector) int64 {
 	// 1. Text content size
@@ -173,6 +178,20 @@ func (g *pgRepository) KeywordsRetrieve(ctx context.Context,
 			Values: common.ToInterfaceSlice(params.KnowledgeBaseIDs),
 		})
 	}
+	if len(params.KnowledgeIDs) > 0 {
+		conds = append(conds, clause.IN{
+			Column: "knowledge_id",
+			Values: common.ToInterfaceSlice(params.KnowledgeIDs),
+		})
+	}
+	if params.Filter != nil {
+		sql, vars, err := knowledgeFilterSQL(params)
+		if err != nil {
+			logger.GetLogger(ctx).Errorf("[Postgres] Invalid metadata filter: %v", err)
+			return nil, err
+		}
+		conds = append(conds, clause.Expr{SQL: sql, Vars: vars})
+	}
 	conds = append(conds, clause.Expr{
 		SQL:  "id @@@ paradedb.match(field => 'content', value => ?, distance => 1)",
 		Vars: []interface{}{params.Query},
@@ -266,6 +285,30 @@ func (g *pgRepository) VectorRetrieve(ctx context.Context,
 			strings.Join(placeholders, ", ")))
 	}
 
+	// Knowledge filter resolved from the metadata filter
+	if len(params.KnowledgeIDs) > 0 {
+		placeholders := make([]string, len(params.KnowledgeIDs))
+		paramStart := len(allVars) + 1
+		for i := range params.KnowledgeIDs {
+			placeholders[i] = fmt.Sprintf("$%d", paramStart+i)
+			allVars = append(allVars, params.KnowledgeIDs[i])
+		}
+		whereParts = append(whereParts, fmt.Sprintf("knowledge_id IN (%s)",
+			strings.Join(placeholders, ", ")))
+	}
+
+	// Metadata filter, evaluated against the knowledges table in the same query
+	if params.Filter != nil {
+		sql, vars, err := knowledgeFilterSQL(params)
+		if err != nil {
+			logger.GetLogger(ctx).Errorf("[Postgres] Invalid metadata filter: %v", err)
+			return nil, err
+		}
+		sql, vars = numberPlaceholders(sql, vars, len(allVars)+1)
+		whereParts = append(whereParts, sql)
+		allVars = append(allVars, vars...)
+	}
+
 	// is_enabled filter
 	whereParts = append(whereParts, fmt.Sprintf("(is_enabled IS NULL OR is_enabled = $%d)", len(allVars)+1))
 	allVars = append(allVars, true)
@@ -80,7 +80,17 @@ func (r *sessionRepository) GetPagedByTenantID(
 // Update updates a session
 func (r *sessionRepository) Update(ctx context.Context, session *types.Session) error {
 	session.UpdatedAt = time.Now()
-	return r.db.WithContext(ctx).Where("tenant_id = ?", session.TenantID).Save(session).Error
+	// The active branch moves with every message, a session loaded before must not reset it
+	return r.db.WithContext(ctx).Where("tenant_id = ?", session.TenantID).Omit("active_message_id").
+		Save(session).Error
+}
+
+// UpdateActiveMessage sets the last message of the active branch of a session
+func (r *sessionRepository) UpdateActiveMessage(ctx context.Context,
+	tenantID uint64, id string, messageID string,
+) error {
+	return r.db.WithContext(ctx).Model(&types.Session{}).Where("tenant_id = ? AND id = ?", tenantID, id).
+		Update("active_message_id", messageID).Error
 }
 
 // Delete deletes a session
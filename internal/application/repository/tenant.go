@@ -112,7 +112,7 @@ func (r *tenantRepository) AdjustStorageUsed(ctx context.Context, tenantID uint6
 		tenant.StorageUsed += delta
 		// 保存更新并验证业务规则
 		if tenant.StorageUsed < 0 {
-			logger.Error(ctx, "tenant storage used is negative %s: %d", tenant.ID, tenant.StorageUsed)
+			logger.Errorf(ctx, "tenant storage used is negative %d: %d", tenant.ID, tenant.StorageUsed)
 			tenant.StorageUsed = 0
 		}
 
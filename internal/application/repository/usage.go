@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// usageDimensionColumns maps the usage dimensions to the ledger columns they group by
+var usageDimensionColumns = map[types.UsageDimension][]string{
+	types.UsageDimensionSession:       {"session_id"},
+	types.UsageDimensionKnowledgeBase: {"knowledge_base_id"},
+	types.UsageDimensionModel:         {"model_id", "model_name"},
+	types.UsageDimensionModelType:     {"model_type"},
+	types.UsageDimensionStage:         {"stage"},
+}
+
+// usageRepository implements the usage ledger repository interface
+type usageRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageRepository creates a new usage ledger repository
+func NewUsageRepository(db *gorm.DB) interfaces.UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// CreateRecord adds a record to the usage ledger
+func (r *usageRepository) CreateRecord(ctx context.Context, record *types.UsageRecord) error {
+	return r.db.WithContext(ctx).Create(record).Error
+}
+
+// Summarize aggregates the usage ledger of the tenant, the query must be validated
+func (r *usageRepository) Summarize(ctx context.Context,
+	tenantID uint64, query *types.UsageQuery,
+) ([]*types.UsageSummary, error) {
+	var selects, groups []string
+	if query.Interval != "" {
+		selects = append(selects, fmt.Sprintf("date_trunc('%s', created_at) AS period", query.Interval))
+		groups = append(groups, "period")
+	}
+	for _, dimension := range query.GroupBy {
+		columns, ok := usageDimensionColumns[dimension]
+		if !ok {
+			return nil, fmt.Errorf("unsupported group_by dimension: %s", dimension)
+		}
+		selects = append(selects, columns...)
+		groups = append(groups, columns...)
+	}
+	selects = append(selects,
+		"SUM(prompt_tokens) AS prompt_tokens",
+		"SUM(completion_tokens) AS completion_tokens",
+		"SUM(total_tokens) AS total_tokens",
+		"SUM(call_count) AS call_count",
+		"SUM(cost) AS cost",
+	)
+
+	db := r.filterUsage(r.db.WithContext(ctx).Model(&types.UsageRecord{}), tenantID, query).
+		Select(strings.Join(selects, ", "))
+	if len(groups) > 0 {
+		db = db.Group(strings.Join(groups, ", "))
+	}
+	if query.Interval != "" {
+		db = db.Order("period")
+	} else {
+		db = db.Order("total_tokens DESC")
+	}
+
+	var summaries []*types.UsageSummary
+	if err := db.Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// SumTokens returns the total tokens used by the tenant in [start, end)
+func (r *usageRepository) SumTokens(ctx context.Context, tenantID uint64, start, end time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&types.UsageRecord{}).
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, start, end).
+		Select("COALESCE(SUM(total_tokens), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// filterUsage applies the time range and filters of the query
+func (r *usageRepository) filterUsage(db *gorm.DB, tenantID uint64, query *types.UsageQuery) *gorm.DB {
+	db = db.Where("tenant_id = ?", tenantID)
+	if !query.StartTime.IsZero() {
+		db = db.Where("created_at >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		db = db.Where("created_at < ?", query.EndTime)
+	}
+	if query.SessionID != "" {
+		db = db.Where("session_id = ?", query.SessionID)
+	}
+	if query.KnowledgeBaseID != "" {
+		db = db.Where("knowledge_base_id = ?", query.KnowledgeBaseID)
+	}
+	if query.ModelID != "" {
+		db = db.Where("model_id = ?", query.ModelID)
+	}
+	if query.Stage != "" {
+		db = db.Where("stage = ?", query.Stage)
+	}
+	return db
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Tencent/WeKnora/internal/agent"
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// subAgentToolNames returns the tools sub-agents may use, sub-agents can not delegate further
+func subAgentToolNames(toolNames []string) []string {
+	return slices.DeleteFunc(slices.Clone(toolNames), func(name string) bool {
+		return name == tools.DelegateTaskToolName
+	})
+}
+
+// subAgentRunner runs tasks delegated by an agent in child agents sharing its models and session
+type subAgentRunner struct {
+	service        *agentService
+	config         *types.AgentConfig // Configuration of the parent agent
+	chatModel      chat.Chat
+	rerankModel    rerank.Reranker
+	eventBus       *event.EventBus    // EventBus of the parent agent
+	approver       tools.ToolApprover // Approver of the parent agent
+	budget         *tools.CallBudget  // Call budget of the parent agent
+	sessionID      string
+	sessionService interfaces.SessionService
+}
+
+// RunSubAgent runs the task in a child agent with its own context window and iteration budget
+func (r *subAgentRunner) RunSubAgent(ctx context.Context, task *tools.SubAgentTask) (*tools.SubAgentResult, error) {
+	logger.Infof(ctx, "[Agent] Delegating task to sub-agent, knowledge bases: %v, tools: %v, max iterations: %d",
+		task.KnowledgeBaseIDs, task.Tools, task.MaxIterations)
+
+	config := &types.AgentConfig{
+		MaxIterations:       task.MaxIterations,
+		AllowedTools:        task.Tools,
+		Temperature:         r.config.Temperature,
+		KnowledgeBases:      task.KnowledgeBaseIDs,
+		WebSearchEnabled:    r.config.WebSearchEnabled && slices.Contains(task.Tools, "web_search"),
+		WebSearchMaxResults: r.config.WebSearchMaxResults,
+		MaxParallelTools:    r.config.MaxParallelTools,
+		ToolTimeoutSeconds:  r.config.ToolTimeoutSeconds,
+		ToolPolicies:        r.config.ToolPolicies,
+	}
+
+	registry := tools.NewToolRegistry(r.service.knowledgeService, r.service.chunkService, r.service.db)
+	if err := r.service.registerTools(
+		ctx, registry, config, subAgentToolNames(task.Tools),
+		r.rerankModel, r.chatModel, r.sessionID, r.sessionService,
+	); err != nil {
+		return nil, fmt.Errorf("failed to register sub-agent tools: %w", err)
+	}
+	// Approvals are requested through the approver of the parent agent and calls count against its budget
+	if len(config.ToolPolicies) > 0 {
+		registry.SetPolicy(tools.PolicyConfig{
+			Policies:  config.ToolPolicies,
+			SessionID: r.sessionID,
+			Budget:    r.budget,
+			Approver:  r.approver,
+		})
+	}
+
+	// The sub-agent streams into its own EventBus, its progress is relayed as nested events
+	childBus := event.NewEventBus()
+	agent.ForwardSubAgentEvents(childBus, r.eventBus, r.sessionID, task.ParentToolCallID)
+
+	// Without a context manager the sub-agent's messages stay out of the session context
+	engine := agent.NewAgentEngine(
+		config,
+		r.chatModel,
+		registry,
+		childBus,
+		r.service.loadKnowledgeBaseInfos(ctx, config.KnowledgeBases),
+		nil,
+		r.sessionID,
+		"",
+	)
+	state, err := engine.Execute(
+		types.WithUsageStage(ctx, types.UsageStageSubAgent),
+		r.sessionID, "", agent.BuildSubAgentQuery(task.Task, task.ExpectedOutput), nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	toolCalls := 0
+	for _, step := range state.RoundSteps {
+		toolCalls += len(step.ToolCalls)
+	}
+	logger.Infof(ctx, "[Agent] Sub-agent finished in %d rounds with %d tool calls", state.CurrentRound, toolCalls)
+	return &tools.SubAgentResult{
+		Answer:    state.FinalAnswer,
+		Rounds:    len(state.RoundSteps),
+		ToolCalls: toolCalls,
+	}, nil
+}
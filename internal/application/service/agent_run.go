@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// resumableRunStatuses are the statuses of unfinished runs, a running run is only resumable once it is stale
+var resumableRunStatuses = []types.AgentRunStatus{
+	types.AgentRunStatusRunning, types.AgentRunStatusStopped, types.AgentRunStatusFailed,
+}
+
+// agentRunService implements the AgentRunService interface
+type agentRunService struct {
+	repo interfaces.AgentRunRepository
+}
+
+// NewAgentRunService creates a new agent run service
+func NewAgentRunService(repo interfaces.AgentRunRepository) interfaces.AgentRunService {
+	return &agentRunService{repo: repo}
+}
+
+// StartRun records a new running agent run of the assistant message
+func (s *agentRunService) StartRun(ctx context.Context,
+	sessionID, messageID, query string,
+) (*types.AgentRun, error) {
+	now := time.Now()
+	run := &types.AgentRun{
+		ID:        uuid.New().String(),
+		TenantID:  ctx.Value(types.TenantIDContextKey).(uint64),
+		SessionID: sessionID,
+		MessageID: messageID,
+		Query:     query,
+		Status:    types.AgentRunStatusRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		logger.Errorf(ctx, "Failed to create agent run of message %s: %v", messageID, err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Agent run %s started for message %s", run.ID, messageID)
+	return run, nil
+}
+
+// ResumeRun claims a resumable run and marks it as running again. The claim only succeeds if the run is
+// unchanged since it was read, so of concurrent resumes of the same run only one proceeds.
+func (s *agentRunService) ResumeRun(ctx context.Context, run *types.AgentRun) error {
+	now := time.Now()
+	claimed, err := s.repo.ClaimRun(ctx, run, resumableRunStatuses, now)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to resume agent run %s: %v", run.ID, err)
+		return err
+	}
+	if !claimed {
+		logger.Warnf(ctx, "Agent run %s changed since it was read, it is resumed or checkpointed elsewhere", run.ID)
+		return werrors.NewConflictError("Agent run is already being resumed")
+	}
+	run.Status = types.AgentRunStatusRunning
+	run.Error = ""
+	run.UpdatedAt = now
+	logger.Infof(ctx, "Agent run %s resumed at round %d", run.ID, run.CurrentRound+1)
+	return nil
+}
+
+// FinishRun records the outcome of the run, a cancelled context means it was stopped
+func (s *agentRunService) FinishRun(ctx context.Context, run *types.AgentRun, runErr error) {
+	switch {
+	case runErr == nil:
+		run.Status = types.AgentRunStatusCompleted
+		// A completed run can not be resumed, its checkpoint is no longer needed
+		run.Checkpoint = nil
+	case errors.Is(ctx.Err(), context.Canceled):
+		run.Status = types.AgentRunStatusStopped
+	default:
+		run.Status = types.AgentRunStatusFailed
+		run.Error = runErr.Error()
+	}
+	run.UpdatedAt = time.Now()
+	if err := s.repo.UpdateRun(context.WithoutCancel(ctx), run); err != nil {
+		logger.Errorf(ctx, "Failed to finish agent run %s: %v", run.ID, err)
+		return
+	}
+	logger.Infof(ctx, "Agent run %s finished with status %s after %d rounds", run.ID, run.Status, run.CurrentRound)
+}
+
+// Checkpointer returns the checkpointer saving the rounds of the run
+func (s *agentRunService) Checkpointer(
+	run *types.AgentRun, config *types.AgentConfig,
+) interfaces.AgentCheckpointer {
+	return &agentRunCheckpointer{repo: s.repo, run: run, config: config}
+}
+
+// GetRun gets a run of the tenant including its checkpoint
+func (s *agentRunService) GetRun(ctx context.Context, id string) (*types.AgentRun, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	run, err := s.repo.GetRun(ctx, tenantID, id)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get agent run %s: %v", id, err)
+		return nil, err
+	}
+	if run != nil {
+		run.Resumable = run.IsResumable(time.Now())
+	}
+	return run, nil
+}
+
+// ListInFlightRuns lists the unfinished runs of the tenant
+func (s *agentRunService) ListInFlightRuns(ctx context.Context) ([]*types.AgentRun, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	runs, err := s.repo.ListRuns(ctx, tenantID, resumableRunStatuses)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list agent runs: %v", err)
+		return nil, err
+	}
+	now := time.Now()
+	for _, run := range runs {
+		run.Resumable = run.IsResumable(now)
+		// The listing only tells whether a checkpoint exists, it does not load it
+		run.Checkpoint = nil
+	}
+	return runs, nil
+}
+
+// agentRunCheckpointer saves the agent state of a run after every round
+type agentRunCheckpointer struct {
+	repo   interfaces.AgentRunRepository
+	run    *types.AgentRun
+	config *types.AgentConfig
+}
+
+// SaveCheckpoint saves the state and the LLM messages for the next round
+func (c *agentRunCheckpointer) SaveCheckpoint(ctx context.Context,
+	state *types.AgentState, messages []chat.Message,
+) error {
+	messagesJSON, err := json.Marshal(messages)
+	if err != nil {
+		return err
+	}
+	c.run.CurrentRound = state.CurrentRound
+	c.run.Checkpoint = &types.AgentCheckpoint{
+		Config:   c.config,
+		State:    state,
+		Messages: messagesJSON,
+	}
+	c.run.UpdatedAt = time.Now()
+	// The round is complete even if the run is being stopped
+	return c.repo.UpdateRun(context.WithoutCancel(ctx), c.run)
+}
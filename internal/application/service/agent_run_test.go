@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// fakeAgentRunRepository keeps a single run and claims it with the semantics of the conditional update
+type fakeAgentRunRepository struct {
+	interfaces.AgentRunRepository
+	mu  sync.Mutex
+	run types.AgentRun
+}
+
+func (r *fakeAgentRunRepository) ClaimRun(ctx context.Context,
+	run *types.AgentRun, statuses []types.AgentRunStatus, now time.Time,
+) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.run.ID != run.ID || !slices.Contains(statuses, r.run.Status) || !r.run.UpdatedAt.Equal(run.UpdatedAt) {
+		return false, nil
+	}
+	r.run.Status = types.AgentRunStatusRunning
+	r.run.UpdatedAt = now
+	return true, nil
+}
+
+func TestResumeRunClaimsOnce(t *testing.T) {
+	repo := &fakeAgentRunRepository{run: types.AgentRun{
+		ID: "run-1", Status: types.AgentRunStatusStopped, UpdatedAt: time.Now().Add(-time.Hour),
+	}}
+	service := NewAgentRunService(repo)
+
+	const resumes = 8
+	var wg sync.WaitGroup
+	errs := make([]error, resumes)
+	// Every resume read the run before any of them claimed it
+	read := repo.run
+	for i := range resumes {
+		run := read
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = service.ResumeRun(context.Background(), &run)
+		}()
+	}
+	wg.Wait()
+
+	claimed := 0
+	for _, err := range errs {
+		if err == nil {
+			claimed++
+			continue
+		}
+		var appErr *werrors.AppError
+		if !errors.As(err, &appErr) || appErr.HTTPCode != http.StatusConflict {
+			t.Errorf("expected a conflict error, got %v", err)
+		}
+	}
+	if claimed != 1 {
+		t.Errorf("expected exactly one resume to claim the run, got %d", claimed)
+	}
+	if repo.run.Status != types.AgentRunStatusRunning {
+		t.Errorf("expected the run to be running, got %s", repo.run.Status)
+	}
+}
+
+func TestResumeRunRejectsChangedRun(t *testing.T) {
+	read := time.Now().Add(-time.Hour)
+	repo := &fakeAgentRunRepository{run: types.AgentRun{
+		ID: "run-1", Status: types.AgentRunStatusFailed, UpdatedAt: read,
+	}}
+	// The run was checkpointed after it was read
+	stale := repo.run
+	repo.run.UpdatedAt = read.Add(time.Minute)
+
+	err := NewAgentRunService(repo).ResumeRun(context.Background(), &stale)
+	var appErr *werrors.AppError
+	if !errors.As(err, &appErr) || appErr.HTTPCode != http.StatusConflict {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	if stale.Status != types.AgentRunStatusFailed {
+		t.Errorf("a failed claim must leave the run unchanged, got %s", stale.Status)
+	}
+}
@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/Tencent/WeKnora/internal/agent"
 	"github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/groundedness"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/mcp"
 	"github.com/Tencent/WeKnora/internal/models/chat"
@@ -32,6 +34,8 @@ type agentService struct {
 	knowledgeBaseService interfaces.KnowledgeBaseService
 	knowledgeService     interfaces.KnowledgeService
 	chunkService         interfaces.ChunkService
+	graphRepo            interfaces.RetrieveGraphRepository
+	streamManager        interfaces.StreamManager
 }
 
 // NewAgentService creates a new agent service
@@ -46,6 +50,8 @@ func NewAgentService(
 	eventBus *event.EventBus,
 	db *gorm.DB,
 	webSearchService interfaces.WebSearchService,
+	graphRepo interfaces.RetrieveGraphRepository,
+	streamManager interfaces.StreamManager,
 ) interfaces.AgentService {
 	return &agentService{
 		cfg:                  cfg,
@@ -58,6 +64,8 @@ func NewAgentService(
 		eventBus:             eventBus,
 		db:                   db,
 		webSearchService:     webSearchService,
+		graphRepo:            graphRepo,
+		streamManager:        streamManager,
 	}
 }
 
@@ -70,6 +78,7 @@ func (s *agentService) CreateAgentEngine(
 	eventBus *event.EventBus,
 	contextManager interfaces.ContextManager,
 	sessionID string,
+	messageID string,
 	sessionService interfaces.SessionService,
 ) (interfaces.AgentEngine, error) {
 	logger.Infof(ctx, "Creating agent engine with custom EventBus")
@@ -91,10 +100,34 @@ func (s *agentService) CreateAgentEngine(
 	toolRegistry := tools.NewToolRegistry(s.knowledgeService, s.chunkService, s.db)
 
 	// Register tools
-	if err := s.registerTools(ctx, toolRegistry, config, rerankModel, chatModel, sessionID, sessionService); err != nil {
+	toolNames := agentToolNames(config)
+	if err := s.registerTools(
+		ctx, toolRegistry, config, toolNames, rerankModel, chatModel, sessionID, sessionService,
+	); err != nil {
 		return nil, fmt.Errorf("failed to register tools: %w", err)
 	}
 
+	// Tool calls requiring approval are announced through the EventBus and decided through the stream of
+	// the assistant message. Sub-agents share the approver and the per-session call budget of the run.
+	approver := agent.NewToolApprover(eventBus, s.streamManager, sessionID, messageID)
+	budget := tools.NewCallBudget(s.db, sessionID)
+
+	// Delegated tasks run in sub-agents restricted to the knowledge bases and tools of this agent
+	if slices.Contains(toolNames, tools.DelegateTaskToolName) {
+		runner := &subAgentRunner{
+			service:        s,
+			config:         config,
+			chatModel:      chatModel,
+			rerankModel:    rerankModel,
+			eventBus:       eventBus,
+			approver:       approver,
+			budget:         budget,
+			sessionID:      sessionID,
+			sessionService: sessionService,
+		}
+		toolRegistry.RegisterTool(tools.NewDelegateTaskTool(runner, config.KnowledgeBases, subAgentToolNames(toolNames)))
+	}
+
 	// Register MCP tools from enabled services for this tenant
 	tenantID := uint64(0)
 	if tid, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
@@ -125,22 +158,19 @@ func (s *agentService) CreateAgentEngine(
 		}
 	}
 
-	// Get knowledge base detailed information for prompt
-	kbInfos, err := s.getKnowledgeBaseInfos(ctx, config.KnowledgeBases)
-	if err != nil {
-		logger.Warnf(ctx, "Failed to get knowledge base details, using IDs only: %v", err)
-		// Create fallback info with IDs only
-		kbInfos = make([]*agent.KnowledgeBaseInfo, 0, len(config.KnowledgeBases))
-		for _, kbID := range config.KnowledgeBases {
-			kbInfos = append(kbInfos, &agent.KnowledgeBaseInfo{
-				ID:          kbID,
-				Name:        kbID, // Use ID as name when details unavailable
-				Description: "",
-				DocCount:    0,
-			})
-		}
+	// Enforce tenant tool policies
+	if len(config.ToolPolicies) > 0 {
+		toolRegistry.SetPolicy(tools.PolicyConfig{
+			Policies:  config.ToolPolicies,
+			SessionID: sessionID,
+			Budget:    budget,
+			Approver:  approver,
+		})
 	}
 
+	// Get knowledge base detailed information for prompt
+	kbInfos := s.loadKnowledgeBaseInfos(ctx, config.KnowledgeBases)
+
 	systemPromptTemplate := ""
 	if config.UseCustomSystemPrompt {
 		systemPromptTemplate = config.ResolveSystemPrompt(config.WebSearchEnabled)
@@ -158,27 +188,61 @@ func (s *agentService) CreateAgentEngine(
 		systemPromptTemplate,
 	)
 
+	// Check the final answer against the knowledge references with the configured judge
+	if config.Groundedness.IsEnabled() {
+		judge, err := groundedness.NewJudge(config.Groundedness.GetJudge(), chatModel, rerankModel)
+		if err != nil {
+			logger.Warnf(ctx, "Groundedness check disabled: %v", err)
+		} else {
+			engine.SetGroundednessJudge(judge)
+		}
+	}
+
 	return engine, nil
 }
 
-// registerTools registers tools based on the agent configuration
+// loadKnowledgeBaseInfos retrieves the knowledge base details for the prompt, falling back to their IDs
+func (s *agentService) loadKnowledgeBaseInfos(ctx context.Context, kbIDs []string) []*agent.KnowledgeBaseInfo {
+	kbInfos, err := s.getKnowledgeBaseInfos(ctx, kbIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get knowledge base details, using IDs only: %v", err)
+		// Create fallback info with IDs only
+		kbInfos = make([]*agent.KnowledgeBaseInfo, 0, len(kbIDs))
+		for _, kbID := range kbIDs {
+			kbInfos = append(kbInfos, &agent.KnowledgeBaseInfo{
+				ID:          kbID,
+				Name:        kbID, // Use ID as name when details unavailable
+				Description: "",
+				DocCount:    0,
+			})
+		}
+	}
+	return kbInfos
+}
+
+// agentToolNames returns the tools enabled for the agent configuration
+func agentToolNames(config *types.AgentConfig) []string {
+	// If no specific tools allowed, register default tools
+	toolNames := tools.DefaultAllowedTools()
+	// If web search is enabled, add web_search to allowedTools
+	if config.WebSearchEnabled {
+		toolNames = append(toolNames, "web_search")
+		toolNames = append(toolNames, "web_fetch")
+	}
+	return toolNames
+}
+
+// registerTools registers the named tools based on the agent configuration
 func (s *agentService) registerTools(
 	ctx context.Context,
 	registry *tools.ToolRegistry,
 	config *types.AgentConfig,
+	allowedTools []string,
 	rerankModel rerank.Reranker,
 	chatModel chat.Chat,
 	sessionID string,
 	sessionService interfaces.SessionService,
 ) error {
-	// If no specific tools allowed, register default tools
-	allowedTools := tools.DefaultAllowedTools()
-	// If web search is enabled, add web_search to allowedTools
-	if config.WebSearchEnabled {
-		allowedTools = append(allowedTools, "web_search")
-		allowedTools = append(allowedTools, "web_fetch")
-	}
-
 	// Get tenant ID from context
 	tenantID := uint64(0)
 	if tid, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
@@ -216,7 +280,7 @@ func (s *agentService) registerTools(
 		case "list_knowledge_chunks":
 			registry.RegisterTool(tools.NewListKnowledgeChunksTool(tenantID, s.knowledgeService, s.chunkService))
 		case "query_knowledge_graph":
-			registry.RegisterTool(tools.NewQueryKnowledgeGraphTool(s.knowledgeBaseService))
+			registry.RegisterTool(tools.NewQueryKnowledgeGraphTool(s.knowledgeBaseService, s.graphRepo))
 		case "get_document_info":
 			registry.RegisterTool(tools.NewGetDocumentInfoTool(tenantID, s.knowledgeService, s.chunkService))
 		case "database_query":
@@ -241,6 +305,9 @@ func (s *agentService) registerTools(
 			registry.RegisterTool(tools.NewWebFetchTool(chatModel))
 			logger.Infof(ctx, "Registered web_fetch tool for session: %s", sessionID)
 
+		case tools.DelegateTaskToolName:
+			// Registered by CreateAgentEngine, sub-agents need the EventBus of the run
+
 		default:
 			logger.Warnf(ctx, "Unknown tool: %s", toolName)
 		}
@@ -0,0 +1,166 @@
+package chatpipline
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// PluginAnswerCache replays cached answers of semantically similar questions over the same knowledge bases.
+// On a hit it ends the pipeline with the cached answer and references, on a miss it caches the streamed answer.
+type PluginAnswerCache struct {
+	answerCache          interfaces.AnswerCache
+	modelService         interfaces.ModelService
+	knowledgeBaseService interfaces.KnowledgeBaseService
+}
+
+// NewPluginAnswerCache creates and registers a new PluginAnswerCache instance
+func NewPluginAnswerCache(eventManager *EventManager,
+	answerCache interfaces.AnswerCache,
+	modelService interfaces.ModelService,
+	knowledgeBaseService interfaces.KnowledgeBaseService,
+) *PluginAnswerCache {
+	res := &PluginAnswerCache{
+		answerCache:          answerCache,
+		modelService:         modelService,
+		knowledgeBaseService: knowledgeBaseService,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the event types this plugin handles
+func (p *PluginAnswerCache) ActivationEvents() []types.EventType {
+	return []types.EventType{types.ANSWER_CACHE}
+}
+
+// OnEvent looks up a cached answer for the rewritten query, failures are logged and the question is answered as usual
+func (p *PluginAnswerCache) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	chatManage.AnswerCached = false
+	// Cached answers are free text, structured answers are always generated
+	if !chatManage.EnableAnswerCache || chatManage.WebSearchEnabled || chatManage.ResponseSchema != nil {
+		pipelineInfo(ctx, "AnswerCache", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "answer_cache_disabled",
+		})
+		return next()
+	}
+
+	query := retrievalQuery(chatManage)
+	scope, embedding, err := p.embedQuery(ctx, chatManage, query)
+	if err != nil {
+		pipelineWarn(ctx, "AnswerCache", "embed_query", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	threshold := chatManage.AnswerCacheThreshold
+	if threshold <= 0 {
+		threshold = types.DefaultAnswerCacheThreshold
+	}
+	cached, err := p.answerCache.Lookup(ctx, scope, embedding, threshold)
+	if err != nil {
+		pipelineWarn(ctx, "AnswerCache", "lookup", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	if cached != nil {
+		pipelineInfo(ctx, "AnswerCache", "hit", map[string]interface{}{
+			"session_id":   chatManage.SessionID,
+			"query":        query,
+			"cached_query": cached.Query,
+			"ref_cnt":      len(cached.References),
+		})
+		chatManage.MergeResult = cloneSearchResults(cached.References)
+		chatManage.ChatResponse = &types.ChatResponse{Content: cached.Answer}
+		chatManage.AnswerCached = true
+		return ErrAnswerCached
+	}
+
+	pipelineInfo(ctx, "AnswerCache", "miss", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"query":      query,
+	})
+	p.storeAnswerOnCompletion(ctx, chatManage, scope, query, embedding)
+	return next()
+}
+
+// embedQuery builds the cache scope of the chat and embeds the query with the embedding model of its knowledge bases
+func (p *PluginAnswerCache) embedQuery(ctx context.Context,
+	chatManage *types.ChatManage, query string,
+) (*types.AnswerCacheScope, []float32, error) {
+	kb, err := p.knowledgeBaseService.GetKnowledgeBaseByID(ctx, chatManage.KnowledgeBaseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	embedder, err := p.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	embedding, err := embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	return types.NewAnswerCacheScope(chatManage, kb.EmbeddingModelID), embedding, nil
+}
+
+// storeAnswerOnCompletion caches the streamed answer once it is done, answers without references
+// such as fallback responses and answers that are not grounded are not cached
+func (p *PluginAnswerCache) storeAnswerOnCompletion(ctx context.Context,
+	chatManage *types.ChatManage, scope *types.AnswerCacheScope, query string, embedding []float32,
+) {
+	if chatManage.EventBus == nil {
+		return
+	}
+	// The request context is cancelled once the answer is done
+	storeCtx := context.WithoutCancel(ctx)
+	var answer strings.Builder
+	chatManage.EventBus.On(types.EventType(event.EventAgentFinalAnswer), func(ctx context.Context, evt types.Event) error {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok {
+			return nil
+		}
+		answer.WriteString(data.Content)
+		if !data.Done || len(chatManage.MergeResult) == 0 || strings.TrimSpace(answer.String()) == "" {
+			return nil
+		}
+		// Answers failing the groundedness check are not replayed
+		if result, ok := data.Groundedness.(*types.GroundednessResult); ok && !result.Grounded {
+			return nil
+		}
+		if err := p.answerCache.Store(storeCtx, scope, &types.CachedAnswer{
+			Query:      query,
+			Embedding:  embedding,
+			Answer:     answer.String(),
+			References: cloneSearchResults(chatManage.MergeResult),
+			CreatedAt:  time.Now(),
+		}); err != nil {
+			pipelineWarn(storeCtx, "AnswerCache", "store", map[string]interface{}{
+				"session_id": chatManage.SessionID,
+				"error":      err.Error(),
+			})
+		}
+		return nil
+	})
+}
+
+// cloneSearchResults copies the search results so cached references are not shared with a chat
+func cloneSearchResults(results []*types.SearchResult) []*types.SearchResult {
+	cloned := make([]*types.SearchResult, 0, len(results))
+	for _, result := range results {
+		r := *result
+		cloned = append(cloned, &r)
+	}
+	return cloned
+}
@@ -3,6 +3,8 @@ package chatpipline
 import (
 	"context"
 
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -56,7 +58,14 @@ func (p *PluginChatCompletion) OnEvent(
 	pipelineInfo(ctx, "Completion", "model_call", map[string]interface{}{
 		"chat_model": chatManage.ChatModelID,
 	})
-	chatResponse, err := chatModel.Chat(ctx, chatMessages, opt)
+	var chatResponse *types.ChatResponse
+	if chatManage.ResponseSchema != nil {
+		chatResponse, chatManage.StructuredOutput, err = chat.GenerateStructured(
+			ctx, chatModel, chatMessages, opt, chatManage.ResponseSchema,
+		)
+	} else {
+		chatResponse, err = chatModel.Chat(ctx, chatMessages, opt)
+	}
 	if err != nil {
 		pipelineError(ctx, "Completion", "model_call", map[string]interface{}{
 			"chat_model": chatManage.ChatModelID,
@@ -72,5 +81,8 @@ func (p *PluginChatCompletion) OnEvent(
 		"prompt_tokens":     chatResponse.Usage.PromptTokens,
 	})
 	chatManage.ChatResponse = chatResponse
+	if chatManage.ResponseSchema == nil {
+		chatManage.CitationMap = searchutil.BuildCitationMap(chatResponse.Content, chatManage.MergeResult)
+	}
 	return next()
 }
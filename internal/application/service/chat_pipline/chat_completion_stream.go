@@ -2,11 +2,14 @@ package chatpipline
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
@@ -74,6 +77,16 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 		"session_id": chatManage.SessionID,
 	})
 
+	// Structured answers are validated as a whole, they are generated first and emitted at once
+	if chatManage.ResponseSchema != nil {
+		pipelineInfo(ctx, "Stream", "structured_output", map[string]interface{}{
+			"chat_model":        chatManage.ChatModelID,
+			"native_structured": chat.SupportsStructuredOutput(chatModel),
+		})
+		go p.generateStructured(ctx, chatModel, chatMessages, opt, chatManage, eventBus)
+		return next()
+	}
+
 	// Initiate streaming chat model call with independent context
 	pipelineInfo(ctx, "Stream", "model_call", map[string]interface{}{
 		"chat_model": chatManage.ChatModelID,
@@ -107,14 +120,18 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 			// Emit event for each answer chunk
 			if response.ResponseType == types.ResponseTypeAnswer {
 				finalContent += response.Content
+				answer := event.AgentFinalAnswerData{Content: response.Content, Done: response.Done}
+				// The last chunk links the citation markers of the answer to the references
+				if response.Done {
+					if citationMap := searchutil.BuildCitationMap(finalContent, chatManage.MergeResult); citationMap != nil {
+						answer.CitationMap = citationMap
+					}
+				}
 				if err := eventBus.Emit(ctx, types.Event{
 					ID:        answerID,
 					Type:      types.EventType(event.EventAgentFinalAnswer),
 					SessionID: chatManage.SessionID,
-					Data: event.AgentFinalAnswerData{
-						Content: response.Content,
-						Done:    response.Done,
-					},
+					Data:      answer,
 				}); err != nil {
 					logger.Errorf(ctx, "Failed to emit answer event: %v", err)
 				}
@@ -128,3 +145,46 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 
 	return next()
 }
+
+// generateStructured answers with a JSON object conforming to the response schema and emits it as the last
+// answer chunk together with its citations, a failure is reported as an error followed by an empty answer
+func (p *PluginChatCompletionStream) generateStructured(ctx context.Context,
+	chatModel chat.Chat, chatMessages []chat.Message, opt *chat.ChatOptions,
+	chatManage *types.ChatManage, eventBus types.EventBusInterface,
+) {
+	answer := event.AgentFinalAnswerData{Done: true}
+	resp, output, err := chat.GenerateStructured(ctx, chatModel, chatMessages, opt, chatManage.ResponseSchema)
+	if err != nil {
+		pipelineError(ctx, "Stream", "structured_output", map[string]interface{}{
+			"chat_model": chatManage.ChatModelID,
+			"error":      err.Error(),
+		})
+		if err := eventBus.Emit(ctx, types.Event{
+			Type:      types.EventType(event.EventError),
+			SessionID: chatManage.SessionID,
+			Data: event.ErrorData{
+				Error:     err.Error(),
+				Stage:     "structured_output",
+				SessionID: chatManage.SessionID,
+			},
+		}); err != nil {
+			logger.Errorf(ctx, "Failed to emit structured output error event: %v", err)
+		}
+	} else {
+		resp.Content = string(output)
+		chatManage.ChatResponse = resp
+		chatManage.StructuredOutput = output
+		answer.Content = resp.Content
+		answer.StructuredOutput = json.RawMessage(output)
+		answer.Citations = types.CitationsFromReferences(chatManage.MergeResult)
+	}
+
+	if err := eventBus.Emit(ctx, types.Event{
+		ID:        fmt.Sprintf("%s-answer", uuid.New().String()[:8]),
+		Type:      types.EventType(event.EventAgentFinalAnswer),
+		SessionID: chatManage.SessionID,
+		Data:      answer,
+	}); err != nil {
+		logger.Errorf(ctx, "Failed to emit structured answer event: %v", err)
+	}
+}
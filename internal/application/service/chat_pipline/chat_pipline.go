@@ -72,11 +72,17 @@ func (e *EventManager) Trigger(ctx context.Context,
 	eventType types.EventType, chatManage *types.ChatManage,
 ) *PluginError {
 	if handler, ok := e.handlers[eventType]; ok {
-		return handler(ctx, eventType, chatManage)
+		// Model calls of the stage are accounted to the event type
+		return handler(types.WithUsageStage(ctx, types.UsageStage(eventType)), eventType, chatManage)
 	}
 	return nil
 }
 
+// HasPlugins reports whether any plugin is registered for the event type
+func (e *EventManager) HasPlugins(eventType types.EventType) bool {
+	return len(e.listeners[eventType]) > 0
+}
+
 // PluginError represents an error in plugin execution
 type PluginError struct {
 	Err         error  // Original error
@@ -90,6 +96,10 @@ var (
 		Description: "No relevant content found",
 		ErrorType:   "search_nothing",
 	}
+	ErrAnswerCached = &PluginError{
+		Description: "Answer replayed from the answer cache",
+		ErrorType:   "answer_cached",
+	}
 	ErrSearch = &PluginError{
 		Description: "Failed to search knowledge base",
 		ErrorType:   "search_failed",
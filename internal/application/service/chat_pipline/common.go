@@ -1,9 +1,14 @@
 package chatpipline
 
 import (
+	"bytes"
 	"context"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -71,3 +76,46 @@ func prepareMessagesWithHistory(chatManage *types.ChatManage) []chat.Message {
 
 	return chatMessages
 }
+
+// retrievalQuery returns the query used for retrieval, the rewritten query when available
+func retrievalQuery(chatManage *types.ChatManage) string {
+	if query := strings.TrimSpace(chatManage.RewriteQuery); query != "" {
+		return query
+	}
+	return strings.TrimSpace(chatManage.Query)
+}
+
+// renderPrompt renders a prompt template with the given data
+func renderPrompt(name, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// emitQueryExpansion reports the queries generated by a query expansion stage on the event bus
+func emitQueryExpansion(ctx context.Context, chatManage *types.ChatManage,
+	strategy, query string, queries []string, start time.Time,
+) {
+	if chatManage.EventBus == nil {
+		return
+	}
+	if err := chatManage.EventBus.Emit(ctx, types.Event{
+		Type:      types.EventType(event.EventQueryExpanded),
+		SessionID: chatManage.SessionID,
+		Data: event.QueryExpansionData{
+			Strategy:      strategy,
+			OriginalQuery: query,
+			Queries:       queries,
+			SessionID:     chatManage.SessionID,
+			Duration:      time.Since(start).Milliseconds(),
+		},
+	}); err != nil {
+		logger.Warnf(ctx, "Failed to emit query expansion event: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package chatpipline
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/searchutil"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// PluginExpand expands merged chunks to their neighbouring or parent chunks (small-to-big retrieval)
+// according to the context expansion configuration of their knowledge base
+type PluginExpand struct {
+	chunkRepo            interfaces.ChunkRepository
+	knowledgeBaseService interfaces.KnowledgeBaseService
+}
+
+// NewPluginExpand creates and registers a new PluginExpand instance
+func NewPluginExpand(eventManager *EventManager,
+	chunkRepo interfaces.ChunkRepository,
+	knowledgeBaseService interfaces.KnowledgeBaseService,
+) *PluginExpand {
+	res := &PluginExpand{
+		chunkRepo:            chunkRepo,
+		knowledgeBaseService: knowledgeBaseService,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the event types this plugin handles
+func (p *PluginExpand) ActivationEvents() []types.EventType {
+	return []types.EventType{types.CHUNK_EXPAND}
+}
+
+// OnEvent expands the merged chunks within the token budget of their knowledge base,
+// failures are logged and the chunks are kept as retrieved
+func (p *PluginExpand) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	if len(chatManage.MergeResult) == 0 {
+		pipelineInfo(ctx, "Expand", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "no_results",
+		})
+		return next()
+	}
+
+	configs := p.expansionConfigs(ctx, chatManage)
+	if len(configs) == 0 {
+		pipelineInfo(ctx, "Expand", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "expansion_disabled",
+		})
+		return next()
+	}
+
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	if tenantID == 0 {
+		tenantID = chatManage.TenantID
+	}
+	if tenantID == 0 {
+		pipelineWarn(ctx, "Expand", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "missing_tenant",
+		})
+		return next()
+	}
+
+	before := len(chatManage.MergeResult)
+	expanded, err := searchutil.ExpandContext(ctx, p.chunkRepo, tenantID, chatManage.MergeResult, configs)
+	if err != nil {
+		pipelineError(ctx, "Expand", "expand", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+	chatManage.MergeResult = expanded
+
+	tokens := 0
+	for _, result := range expanded {
+		tokens += searchutil.EstimateTokens(result.Content)
+	}
+	pipelineInfo(ctx, "Expand", "output", map[string]interface{}{
+		"session_id":  chatManage.SessionID,
+		"before_cnt":  before,
+		"after_cnt":   len(expanded),
+		"context_tok": tokens,
+	})
+	return next()
+}
+
+// expansionConfigs returns the context expansion configs of the searched knowledge bases that enable it
+func (p *PluginExpand) expansionConfigs(ctx context.Context,
+	chatManage *types.ChatManage,
+) map[string]*types.ContextExpansionConfig {
+	knowledgeBaseIDs := chatManage.KnowledgeBaseIDs
+	if len(knowledgeBaseIDs) == 0 && chatManage.KnowledgeBaseID != "" {
+		knowledgeBaseIDs = []string{chatManage.KnowledgeBaseID}
+	}
+
+	configs := make(map[string]*types.ContextExpansionConfig)
+	for _, kbID := range knowledgeBaseIDs {
+		kb, err := p.knowledgeBaseService.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			pipelineWarn(ctx, "Expand", "get_knowledge_base", map[string]interface{}{
+				"kb_id": kbID,
+				"error": err.Error(),
+			})
+			continue
+		}
+		if kb.ContextExpansionConfig.Enabled() {
+			configs[kbID] = kb.ContextExpansionConfig
+		}
+	}
+	return configs
+}
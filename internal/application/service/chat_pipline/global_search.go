@@ -0,0 +1,198 @@
+package chatpipline
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Tencent/WeKnora/internal/community"
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// maxGlobalSearchReports caps the community reports of a knowledge base mapped by the global search, the best
+// rated are kept
+const maxGlobalSearchReports = 100
+
+// PluginGlobalSearch answers corpus wide questions from the community reports of the knowledge graph.
+// It maps the reports of the configured level to the key points answering the question, the best points
+// become the merged results the chat completion reduces into the answer.
+type PluginGlobalSearch struct {
+	chunkRepo    interfaces.ChunkRepository
+	modelService interfaces.ModelService
+	config       *config.Config
+}
+
+// NewPluginGlobalSearch creates a new global search plugin and registers it with the event manager
+func NewPluginGlobalSearch(eventManager *EventManager,
+	chunkRepo interfaces.ChunkRepository,
+	modelService interfaces.ModelService,
+	config *config.Config,
+) *PluginGlobalSearch {
+	res := &PluginGlobalSearch{
+		chunkRepo:    chunkRepo,
+		modelService: modelService,
+		config:       config,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the event types this plugin handles
+func (p *PluginGlobalSearch) ActivationEvents() []types.EventType {
+	return []types.EventType{types.GLOBAL_SEARCH}
+}
+
+// communityConfig returns the community configuration with its defaults
+func (p *PluginGlobalSearch) communityConfig() types.GraphCommunityConfig {
+	if p.config == nil || p.config.ExtractManager == nil {
+		return (*types.GraphCommunityConfig)(nil).WithDefaults()
+	}
+	return p.config.ExtractManager.Communities.WithDefaults()
+}
+
+// OnEvent maps the community reports of the knowledge bases to the key points answering the query
+func (p *PluginGlobalSearch) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	cfg := p.communityConfig()
+	query := retrievalQuery(chatManage)
+	knowledgeBaseIDs := chatManage.KnowledgeBaseIDs
+	if len(knowledgeBaseIDs) == 0 && chatManage.KnowledgeBaseID != "" {
+		knowledgeBaseIDs = []string{chatManage.KnowledgeBaseID}
+	}
+	pipelineInfo(ctx, "GlobalSearch", "input", map[string]interface{}{
+		"session_id":   chatManage.SessionID,
+		"query":        query,
+		"kb_ids":       knowledgeBaseIDs,
+		"search_level": cfg.SearchLevel,
+	})
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	var reports []*types.Chunk
+	for _, kbID := range knowledgeBaseIDs {
+		chunks, err := p.chunkRepo.ListChunksByKnowledgeBaseIDAndType(ctx,
+			tenantID, kbID, types.ChunkTypeCommunityReport)
+		if err != nil {
+			pipelineWarn(ctx, "GlobalSearch", "list_reports", map[string]interface{}{
+				"session_id": chatManage.SessionID,
+				"kb_id":      kbID,
+				"error":      err.Error(),
+			})
+			continue
+		}
+		reports = append(reports, selectReports(chunks, cfg.SearchLevel)...)
+	}
+	if len(reports) == 0 {
+		pipelineWarn(ctx, "GlobalSearch", "no_reports", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+		})
+		return ErrSearchNothing
+	}
+
+	chatModel, err := p.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	if err != nil {
+		pipelineError(ctx, "GlobalSearch", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": chatManage.ChatModelID,
+			"error":         err.Error(),
+		})
+		return ErrGetChatModel.WithError(err)
+	}
+	contents := make([]string, len(reports))
+	for i, report := range reports {
+		contents[i] = report.Content
+	}
+	mapper := community.NewMapper(chatModel, cfg.BatchSize, cfg.Concurrency)
+	points, err := mapper.Map(ctx, query, contents, func(err error) {
+		pipelineWarn(ctx, "GlobalSearch", "map_batch", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+	})
+	if err != nil {
+		pipelineError(ctx, "GlobalSearch", "map", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return ErrSearchNothing
+	}
+	if len(points) > cfg.MaxPoints {
+		points = points[:cfg.MaxPoints]
+	}
+
+	results := make([]*types.SearchResult, 0, len(points))
+	for i, point := range points {
+		// A point cites the report it came from, the first one if it came from several
+		report := reports[0]
+		if len(point.Reports) > 0 {
+			report = reports[point.Reports[0]]
+		}
+		meta, _ := report.CommunityReportMetadata()
+		title := ""
+		if meta != nil {
+			title = meta.Title
+		}
+		results = append(results, &types.SearchResult{
+			ID:             report.ID,
+			Content:        point.Description,
+			KnowledgeTitle: title,
+			ChunkIndex:     report.ChunkIndex,
+			Seq:            i,
+			Score:          float64(point.Score) / 100,
+			MatchType:      types.MatchTypeGraph,
+			ChunkType:      string(types.ChunkTypeCommunityReport),
+			ChunkMetadata:  report.Metadata,
+		})
+	}
+	if len(results) == 0 {
+		pipelineInfo(ctx, "GlobalSearch", "no_points", map[string]interface{}{
+			"session_id":  chatManage.SessionID,
+			"reports_cnt": len(reports),
+		})
+		return ErrSearchNothing
+	}
+	chatManage.SearchResult = results
+	chatManage.MergeResult = results
+	pipelineInfo(ctx, "GlobalSearch", "output", map[string]interface{}{
+		"session_id":  chatManage.SessionID,
+		"reports_cnt": len(reports),
+		"points_cnt":  len(results),
+	})
+	return next()
+}
+
+// selectReports returns the reports of the level, or of the finest level if the hierarchy is shallower,
+// best rated first
+func selectReports(chunks []*types.Chunk, level int) []*types.Chunk {
+	type rated struct {
+		chunk *types.Chunk
+		meta  *types.CommunityReportMetadata
+	}
+	var all []rated
+	deepest := -1
+	for _, chunk := range chunks {
+		meta, err := chunk.CommunityReportMetadata()
+		if err != nil || meta == nil {
+			continue
+		}
+		all = append(all, rated{chunk: chunk, meta: meta})
+		deepest = max(deepest, meta.Level)
+	}
+	level = min(level, deepest)
+	var selected []rated
+	for _, report := range all {
+		if report.meta.Level == level {
+			selected = append(selected, report)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].meta.Rating > selected[j].meta.Rating })
+	if len(selected) > maxGlobalSearchReports {
+		selected = selected[:maxGlobalSearchReports]
+	}
+	result := make([]*types.Chunk, len(selected))
+	for i, report := range selected {
+		result[i] = report.chunk
+	}
+	return result
+}
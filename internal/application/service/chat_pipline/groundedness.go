@@ -0,0 +1,213 @@
+package chatpipline
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/groundedness"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/searchutil"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// PluginGroundedness checks whether the answer is supported by the merged chunks and, if it is not,
+// annotates it, regenerates it with a stricter prompt or replaces it with the fallback response.
+// After CHAT_COMPLETION it checks the generated answer, in streaming pipelines it must run before
+// CHAT_COMPLETION_STREAM to intercept the streamed answer.
+type PluginGroundedness struct {
+	modelService interfaces.ModelService
+}
+
+// NewPluginGroundedness creates and registers a new PluginGroundedness instance
+func NewPluginGroundedness(eventManager *EventManager, modelService interfaces.ModelService) *PluginGroundedness {
+	res := &PluginGroundedness{modelService: modelService}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the event types this plugin handles
+func (p *PluginGroundedness) ActivationEvents() []types.EventType {
+	return []types.EventType{types.GROUNDEDNESS_CHECK}
+}
+
+// OnEvent checks the answer, failures of the check are logged and the answer is kept as is
+func (p *PluginGroundedness) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	// Structured answers are validated against their schema instead
+	if !chatManage.Groundedness.IsEnabled() || len(chatManage.MergeResult) == 0 || chatManage.ResponseSchema != nil {
+		return next()
+	}
+	chatModel, opt, err := prepareChatModel(ctx, p.modelService, chatManage)
+	if err != nil {
+		return ErrGetChatModel.WithError(err)
+	}
+	judge, err := p.newJudge(ctx, chatManage, chatModel)
+	if err != nil {
+		pipelineWarn(ctx, "Groundedness", "judge", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+	checker := &groundednessChecker{chatManage: chatManage, judge: judge, chatModel: chatModel, opt: opt}
+
+	if chatManage.ChatResponse != nil {
+		resp, result := checker.check(ctx, chatManage.ChatResponse.Content)
+		if resp != nil {
+			chatManage.ChatResponse = resp
+			chatManage.CitationMap = searchutil.BuildCitationMap(resp.Content, chatManage.MergeResult)
+		}
+		chatManage.GroundednessResult = result
+		return next()
+	}
+	if chatManage.EventBus != nil {
+		p.interceptAnswer(chatManage, checker)
+	}
+	return next()
+}
+
+// newJudge creates the configured judge, the rerank judge uses the rerank model of the chat
+func (p *PluginGroundedness) newJudge(ctx context.Context,
+	chatManage *types.ChatManage, chatModel chat.Chat,
+) (groundedness.Judge, error) {
+	var rerankModel rerank.Reranker
+	if chatManage.Groundedness.GetJudge() == types.GroundednessJudgeRerank {
+		var err error
+		if rerankModel, err = p.modelService.GetRerankModel(ctx, chatManage.RerankModelID); err != nil {
+			return nil, err
+		}
+	}
+	return groundedness.NewJudge(chatManage.Groundedness.GetJudge(), chatModel, rerankModel)
+}
+
+// interceptAnswer routes the streamed answer through a temporary event bus. Annotated answers are
+// forwarded as they stream and the result is attached to the last chunk, answers that may be replaced
+// are buffered until they are checked.
+func (p *PluginGroundedness) interceptAnswer(chatManage *types.ChatManage, checker *groundednessChecker) {
+	originalEventBus := chatManage.EventBus
+	tempEventBus := event.NewEventBus()
+	chatManage.EventBus = tempEventBus.AsEventBusInterface()
+	buffered := chatManage.Groundedness.GetAction() != types.GroundednessActionAnnotate
+
+	var answer strings.Builder
+	tempEventBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok {
+			return nil
+		}
+		answer.WriteString(data.Content)
+		if !data.Done && buffered {
+			return nil
+		}
+		if data.Done {
+			if buffered {
+				data.Content = answer.String()
+			}
+			resp, result := checker.check(ctx, answer.String())
+			if resp != nil {
+				data.Content = resp.Content
+				data.CitationMap = nil
+				if citationMap := searchutil.BuildCitationMap(resp.Content, chatManage.MergeResult); citationMap != nil {
+					data.CitationMap = citationMap
+				}
+			}
+			if result != nil {
+				data.Groundedness = result
+			}
+		}
+		return originalEventBus.Emit(ctx, types.Event{
+			ID:        evt.ID,
+			Type:      types.EventType(event.EventAgentFinalAnswer),
+			SessionID: chatManage.SessionID,
+			Data:      data,
+		})
+	})
+}
+
+// groundednessChecker checks an answer and takes the configured action if it is not grounded
+type groundednessChecker struct {
+	chatManage *types.ChatManage
+	judge      groundedness.Judge
+	chatModel  chat.Chat
+	opt        *chat.ChatOptions
+}
+
+// check returns the groundedness of the answer and the response replacing it, nil if the answer is kept
+func (c *groundednessChecker) check(ctx context.Context,
+	answer string,
+) (*types.ChatResponse, *types.GroundednessResult) {
+	chatManage := c.chatManage
+	config := chatManage.Groundedness
+	result, err := groundedness.Check(ctx, c.judge, answer, chatManage.MergeResult, config.GetThreshold())
+	if err != nil {
+		pipelineWarn(ctx, "Groundedness", "check", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return nil, nil
+	}
+	pipelineInfo(ctx, "Groundedness", "result", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"judge":      result.Judge,
+		"score":      result.Score,
+		"claims":     len(result.Claims),
+		"grounded":   result.Grounded,
+	})
+	if result.Grounded {
+		return nil, result
+	}
+
+	switch config.GetAction() {
+	case types.GroundednessActionRegenerate:
+		messages := groundedness.RegenerationMessages(prepareMessagesWithHistory(chatManage), answer, result)
+		resp, err := c.chatModel.Chat(ctx, messages, c.opt)
+		if err != nil {
+			pipelineWarn(ctx, "Groundedness", "regenerate", map[string]interface{}{
+				"session_id": chatManage.SessionID,
+				"error":      err.Error(),
+			})
+			return nil, result
+		}
+		// The regenerated answer is kept even if it is still not grounded, its result tells
+		regenerated, err := groundedness.Check(ctx, c.judge, resp.Content, chatManage.MergeResult,
+			config.GetThreshold())
+		if err != nil {
+			logger.Warnf(ctx, "Failed to check the regenerated answer: %v", err)
+			regenerated = result
+		}
+		regenerated.Action = types.GroundednessActionRegenerate
+		return resp, regenerated
+	case types.GroundednessActionFallback:
+		result.Action = types.GroundednessActionFallback
+		return &types.ChatResponse{Content: c.fallbackAnswer(ctx)}, result
+	default:
+		return nil, result
+	}
+}
+
+// fallbackAnswer generates the answer of the fallback strategy, the fixed fallback response
+// unless the model strategy is configured with a prompt
+func (c *groundednessChecker) fallbackAnswer(ctx context.Context) string {
+	chatManage := c.chatManage
+	if chatManage.FallbackStrategy != types.FallbackStrategyModel || chatManage.FallbackPrompt == "" {
+		return chatManage.FallbackResponse
+	}
+	prompt, err := renderPrompt("fallbackPrompt", chatManage.FallbackPrompt, map[string]interface{}{
+		"Query": chatManage.Query,
+	})
+	if err != nil {
+		logger.Warnf(ctx, "Failed to render fallback prompt: %v", err)
+		return chatManage.FallbackResponse
+	}
+	resp, err := c.chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, c.opt)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to generate fallback answer: %v", err)
+		return chatManage.FallbackResponse
+	}
+	return resp.Content
+}
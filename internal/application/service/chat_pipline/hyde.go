@@ -0,0 +1,121 @@
+package chatpipline
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// defaultHyDEPrompt is used when no HyDE prompt is configured
+const defaultHyDEPrompt = `请针对下面的问题写一段简洁、信息密集的回答，就像它摘自一篇相关文档。
+即使不确定也请直接给出回答，不要解释、不要提问，不超过 200 字。
+
+问题：{{.Query}}`
+
+// PluginHyDE implements Hypothetical Document Embeddings (HyDE)
+// It asks the chat model for a hypothetical answer to the query, which is embedded
+// in place of the query for vector search since it is closer to the answering documents
+type PluginHyDE struct {
+	modelService interfaces.ModelService
+	config       *config.Config
+}
+
+// NewPluginHyDE creates a new HyDE plugin instance and registers it with the event manager
+func NewPluginHyDE(eventManager *EventManager,
+	modelService interfaces.ModelService,
+	config *config.Config,
+) *PluginHyDE {
+	res := &PluginHyDE{
+		modelService: modelService,
+		config:       config,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the event types this plugin handles
+func (p *PluginHyDE) ActivationEvents() []types.EventType {
+	return []types.EventType{types.HYDE_QUERY}
+}
+
+// OnEvent generates the hypothetical answer used by vector search,
+// failures are logged and the search falls back to embedding the query
+func (p *PluginHyDE) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	chatManage.HyDEDocument = ""
+	if !chatManage.EnableHyDE {
+		pipelineInfo(ctx, "HyDE", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "hyde_disabled",
+		})
+		return next()
+	}
+
+	start := time.Now()
+	query := retrievalQuery(chatManage)
+	pipelineInfo(ctx, "HyDE", "input", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"query":      query,
+	})
+
+	promptTemplate := p.config.Conversation.HyDEPrompt
+	if promptTemplate == "" {
+		promptTemplate = defaultHyDEPrompt
+	}
+	prompt, err := renderPrompt("hyde", promptTemplate, map[string]interface{}{"Query": query})
+	if err != nil {
+		pipelineError(ctx, "HyDE", "render_template", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	chatModel, err := p.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	if err != nil {
+		pipelineError(ctx, "HyDE", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": chatManage.ChatModelID,
+			"error":         err.Error(),
+		})
+		return next()
+	}
+
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "user", Content: prompt},
+	}, &chat.ChatOptions{
+		Temperature:         0.7,
+		MaxCompletionTokens: 300,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineError(ctx, "HyDE", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	document := strings.TrimSpace(reg.ReplaceAllString(response.Content, ""))
+	if document == "" {
+		pipelineWarn(ctx, "HyDE", "empty_output", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+		})
+		return next()
+	}
+	chatManage.HyDEDocument = document
+	emitQueryExpansion(ctx, chatManage, "hyde", query, []string{document}, start)
+
+	pipelineInfo(ctx, "HyDE", "output", map[string]interface{}{
+		"session_id":      chatManage.SessionID,
+		"document_length": len([]rune(document)),
+	})
+	return next()
+}
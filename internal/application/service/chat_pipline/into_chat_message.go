@@ -14,6 +14,11 @@ import (
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 )
 
+// citationInstruction asks the chat model to cite the numbered passages, it is added to the user content
+// unless the context template already contains it
+const citationInstruction = "每条检索到的信息前都有编号，使用了检索信息的句子必须在句末标注来源编号，" +
+	"如 [1] 或 [1][3]，不得标注不存在的编号"
+
 // PluginIntoChatMessage handles the transformation of search results into chat messages
 type PluginIntoChatMessage struct{}
 
@@ -39,11 +44,11 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 		"template_len":     len(chatManage.SummaryConfig.ContextTemplate),
 	})
 
-	// Extract content from merge results
+	// Extract content from merge results, numbered so the answer can cite them as [n]
 	passages := make([]string, len(chatManage.MergeResult))
 	for i, result := range chatManage.MergeResult {
 		// 合并内容和图片信息
-		passages[i] = getEnrichedPassageForChat(ctx, result)
+		passages[i] = fmt.Sprintf("[%d] %s", i+1, getEnrichedPassageForChat(ctx, result))
 	}
 
 	// Parse the context template
@@ -84,6 +89,11 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 		return ErrTemplateExecute.WithError(err)
 	}
 
+	// The passages are numbered here, so the instruction to cite them does not depend on the template
+	if len(passages) > 0 && !strings.Contains(chatManage.SummaryConfig.ContextTemplate, citationInstruction) {
+		userContent.WriteString("\n\n## 引用要求\n- " + citationInstruction + "\n")
+	}
+
 	// Set formatted content back to chat management
 	chatManage.UserContent = userContent.String()
 	pipelineInfo(ctx, "IntoChatMessage", "output", map[string]interface{}{
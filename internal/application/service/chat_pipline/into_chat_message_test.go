@@ -0,0 +1,57 @@
+package chatpipline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestIntoChatMessageCitationInstruction(t *testing.T) {
+	plugin := &PluginIntoChatMessage{}
+	results := []*types.SearchResult{{Content: "彗星由冰和尘埃组成"}, {Content: "彗尾背向太阳"}}
+	tests := []struct {
+		name     string
+		template string
+		results  []*types.SearchResult
+		want     int
+	}{
+		{
+			name:     "custom template",
+			template: "{{range .Contexts}}{{.}}\n{{end}}{{.Query}}",
+			results:  results,
+			want:     1,
+		},
+		{
+			name:     "template with the instruction",
+			template: "- " + citationInstruction + "\n{{range .Contexts}}{{.}}\n{{end}}{{.Query}}",
+			results:  results,
+			want:     1,
+		},
+		{
+			name:     "no passages",
+			template: "{{.Query}}",
+			want:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatManage := &types.ChatManage{
+				Query:         "彗星由什么组成",
+				MergeResult:   tt.results,
+				SummaryConfig: types.SummaryConfig{ContextTemplate: tt.template},
+			}
+			if err := plugin.OnEvent(context.Background(), types.INTO_CHAT_MESSAGE, chatManage,
+				func() *PluginError { return nil }); err != nil {
+				t.Fatalf("OnEvent failed: %v", err)
+			}
+			if got := strings.Count(chatManage.UserContent, citationInstruction); got != tt.want {
+				t.Errorf("instruction appears %d times, want %d: %q", got, tt.want, chatManage.UserContent)
+			}
+			if tt.results != nil && !strings.Contains(chatManage.UserContent, "[2] 彗尾背向太阳") {
+				t.Errorf("passages are not numbered: %q", chatManage.UserContent)
+			}
+		})
+	}
+}
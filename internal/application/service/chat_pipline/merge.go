@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -60,6 +61,9 @@ func (p *PluginMerge) OnEvent(ctx context.Context,
 		return next()
 	}
 
+	// Replace child chunks of hierarchical chunking by their parent sections
+	searchResult = p.resolveParentChunks(ctx, chatManage, searchResult)
+
 	// Group chunks by their knowledge source ID
 	knowledgeGroup := make(map[string][]*types.SearchResult)
 	for _, chunk := range searchResult {
@@ -148,6 +152,36 @@ func (p *PluginMerge) OnEvent(ctx context.Context,
 	return next()
 }
 
+// resolveParentChunks replaces child text chunks by their parent chunks, keeping the results on failure
+func (p *PluginMerge) resolveParentChunks(
+	ctx context.Context,
+	chatManage *types.ChatManage,
+	results []*types.SearchResult,
+) []*types.SearchResult {
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	if tenantID == 0 && chatManage != nil {
+		tenantID = chatManage.TenantID
+	}
+	if tenantID == 0 || p.chunkRepo == nil {
+		return results
+	}
+
+	resolved, err := searchutil.ResolveParentChunks(ctx, p.chunkRepo, tenantID, results)
+	if err != nil {
+		pipelineWarn(ctx, "Merge", "parent_resolve", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return results
+	}
+	if len(resolved) != len(results) {
+		pipelineInfo(ctx, "Merge", "parent_resolved", map[string]interface{}{
+			"before_cnt": len(results),
+			"after_cnt":  len(resolved),
+		})
+	}
+	return resolved
+}
+
 // mergeImageInfo 合并两个chunk的ImageInfo
 func mergeImageInfo(ctx context.Context, target *types.SearchResult, source *types.SearchResult) error {
 	// 如果source没有ImageInfo，不需要合并
@@ -607,29 +641,7 @@ func mergeOrderedContent(prev, base, next string, maxLen int) string {
 
 // concatNoOverlap concatenates two strings, removing potential overlapping prefix/suffix
 func concatNoOverlap(a, b string) string {
-	if a == "" {
-		return b
-	}
-	if b == "" {
-		return a
-	}
-
-	ar := []rune(a)
-	br := []rune(b)
-	maxOverlap := minInt(len(ar), len(br))
-	for k := maxOverlap; k > 0; k-- {
-		if string(ar[len(ar)-k:]) == string(br[:k]) {
-			return string(ar) + string(br[k:])
-		}
-	}
-	return string(ar) + string(br)
-}
-
-func minInt(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+	return searchutil.ConcatNoOverlap(a, b)
 }
 
 func containsID(ids []string, target string) bool {
@@ -0,0 +1,167 @@
+package chatpipline
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// defaultMultiQueryPrompt is used when no multi-query prompt is configured
+const defaultMultiQueryPrompt = `请为下面的问题生成 {{.Count}} 个语义相同但表述不同的检索问题，用于从知识库中召回更多相关内容。
+要求：保留原问题中的关键实体和术语；每行输出一个问题，不要编号，不要输出其他内容。
+
+问题：{{.Query}}`
+
+// defaultMultiQueryCount is the number of paraphrases generated when none is configured
+const defaultMultiQueryCount = 3
+
+// multiQueryListMarker matches list markers models tend to prepend despite the prompt
+var multiQueryListMarker = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.、)）]|[(（]\d+[)）])\s*`)
+
+// PluginMultiQuery generates paraphrases of the query with the chat model.
+// The search stage retrieves for the query and each paraphrase in parallel and fuses the result lists.
+type PluginMultiQuery struct {
+	modelService interfaces.ModelService
+	config       *config.Config
+}
+
+// NewPluginMultiQuery creates a new multi-query plugin instance and registers it with the event manager
+func NewPluginMultiQuery(eventManager *EventManager,
+	modelService interfaces.ModelService,
+	config *config.Config,
+) *PluginMultiQuery {
+	res := &PluginMultiQuery{
+		modelService: modelService,
+		config:       config,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the event types this plugin handles
+func (p *PluginMultiQuery) ActivationEvents() []types.EventType {
+	return []types.EventType{types.MULTI_QUERY}
+}
+
+// OnEvent generates the paraphrases searched alongside the query,
+// failures are logged and the search falls back to the query alone
+func (p *PluginMultiQuery) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	chatManage.MultiQueries = nil
+	if !chatManage.EnableMultiQuery {
+		pipelineInfo(ctx, "MultiQuery", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "multi_query_disabled",
+		})
+		return next()
+	}
+
+	start := time.Now()
+	query := retrievalQuery(chatManage)
+	count := p.queryCount(chatManage)
+	pipelineInfo(ctx, "MultiQuery", "input", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"query":      query,
+		"count":      count,
+	})
+
+	promptTemplate := p.config.Conversation.MultiQueryPrompt
+	if promptTemplate == "" {
+		promptTemplate = defaultMultiQueryPrompt
+	}
+	prompt, err := renderPrompt("multi_query", promptTemplate, map[string]interface{}{
+		"Query": query,
+		"Count": count,
+	})
+	if err != nil {
+		pipelineError(ctx, "MultiQuery", "render_template", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	chatModel, err := p.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	if err != nil {
+		pipelineError(ctx, "MultiQuery", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": chatManage.ChatModelID,
+			"error":         err.Error(),
+		})
+		return next()
+	}
+
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "user", Content: prompt},
+	}, &chat.ChatOptions{
+		Temperature:         0.7,
+		MaxCompletionTokens: 100 * count,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineError(ctx, "MultiQuery", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	queries := parseMultiQueries(reg.ReplaceAllString(response.Content, ""), query, count)
+	if len(queries) == 0 {
+		pipelineWarn(ctx, "MultiQuery", "empty_output", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+		})
+		return next()
+	}
+	chatManage.MultiQueries = queries
+	emitQueryExpansion(ctx, chatManage, "multi_query", query, queries, start)
+
+	pipelineInfo(ctx, "MultiQuery", "output", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"queries":    strings.Join(queries, " | "),
+	})
+	return next()
+}
+
+// queryCount returns the number of paraphrases to generate for the request
+func (p *PluginMultiQuery) queryCount(chatManage *types.ChatManage) int {
+	count := chatManage.MultiQueryCount
+	if count <= 0 {
+		count = p.config.Conversation.MultiQueryCount
+	}
+	if count <= 0 {
+		count = defaultMultiQueryCount
+	}
+	return min(count, types.MaxMultiQueryCount)
+}
+
+// parseMultiQueries extracts one paraphrase per line, dropping list markers,
+// duplicates and the original query, and keeps at most count paraphrases
+func parseMultiQueries(content, query string, count int) []string {
+	seen := map[string]struct{}{strings.ToLower(strings.TrimSpace(query)): {}}
+	var queries []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(multiQueryListMarker.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		key := strings.ToLower(line)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		queries = append(queries, line)
+		if len(queries) == count {
+			break
+		}
+	}
+	return queries
+}
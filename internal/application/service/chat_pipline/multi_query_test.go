@@ -0,0 +1,37 @@
+package chatpipline
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestParseMultiQueries(t *testing.T) {
+	content := "1. 彗星的彗尾是什么形状\n\n- 彗尾形状\n2) 彗尾的形状\n彗尾形状\n（3）彗尾为什么背向太阳\n多余的一行"
+	got := parseMultiQueries(content, "彗尾的形状", 3)
+	want := []string{"彗星的彗尾是什么形状", "彗尾形状", "彗尾为什么背向太阳"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseMultiQueries() = %v, want %v", got, want)
+	}
+}
+
+func TestFuseQueryResults(t *testing.T) {
+	lists := [][]*types.SearchResult{
+		{{ID: "a", Score: 0.9}, {ID: "b", Score: 0.8}, {ID: "c", Score: 0.1}},
+		{{ID: "c", Score: 0.7}, {ID: "b", Score: 0.95}},
+		{{ID: "b", Score: 0.6}, {ID: "d", Score: 0.5}},
+	}
+	fused := fuseQueryResults(lists, types.DefaultRRFK)
+
+	var ids []string
+	for _, r := range fused {
+		ids = append(ids, r.ID)
+	}
+	if want := []string{"b", "c", "a", "d"}; !slices.Equal(ids, want) {
+		t.Fatalf("fused order = %v, want %v", ids, want)
+	}
+	if fused[0].Score != 0.95 {
+		t.Errorf("expected best retrieval score to be kept, got %v", fused[0].Score)
+	}
+}
@@ -0,0 +1,91 @@
+package chatpipline
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// PipelineRegistry resolves chat pipeline definitions by name.
+// Tenant definitions take precedence over those in the application config,
+// which take precedence over the built-in pipelines of types.Pipline.
+type PipelineRegistry struct {
+	eventManager *EventManager
+	pipelines    map[string]*types.PipelineDefinition
+	defaultName  string
+}
+
+// NewPipelineRegistry creates a pipeline registry from the application config
+func NewPipelineRegistry(cfg *config.Config, eventManager *EventManager) *PipelineRegistry {
+	r := &PipelineRegistry{
+		eventManager: eventManager,
+		pipelines:    make(map[string]*types.PipelineDefinition),
+		defaultName:  types.DefaultPipelineName,
+	}
+	if cfg.Conversation != nil {
+		for _, pipeline := range cfg.Conversation.Pipelines {
+			r.pipelines[pipeline.Name] = pipeline
+		}
+		if cfg.Conversation.DefaultPipeline != "" {
+			r.defaultName = cfg.Conversation.DefaultPipeline
+		}
+	}
+	return r
+}
+
+// ValidatePipelines checks the configured pipelines against the registered plugins,
+// it must run after all plugins are registered
+func ValidatePipelines(r *PipelineRegistry) error {
+	for _, pipeline := range r.pipelines {
+		if err := r.Validate(pipeline); err != nil {
+			return err
+		}
+	}
+	if _, err := r.Resolve(r.defaultName, nil); err != nil {
+		return fmt.Errorf("invalid default pipeline: %w", err)
+	}
+	return nil
+}
+
+// Validate checks that the pipeline is well formed and every stage is handled by a registered plugin
+func (r *PipelineRegistry) Validate(pipeline *types.PipelineDefinition) error {
+	if err := pipeline.Validate(); err != nil {
+		return err
+	}
+	for _, stage := range pipeline.Stages {
+		if !r.eventManager.HasPlugins(stage.Event) {
+			return fmt.Errorf("no plugin registered for stage %s in pipeline %s", stage.Event, pipeline.Name)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the pipeline of the given name, visible to the tenant's conversation config.
+// An empty name selects the tenant's default pipeline, then the configured default.
+func (r *PipelineRegistry) Resolve(name string, tenantConv *types.ConversationConfig) (*types.PipelineDefinition, error) {
+	if name == "" && tenantConv != nil {
+		name = tenantConv.DefaultPipeline
+	}
+	if name == "" {
+		name = r.defaultName
+	}
+	if tenantConv != nil {
+		for _, pipeline := range tenantConv.Pipelines {
+			if pipeline.Name == name {
+				// Tenant pipelines may refer to plugins that are no longer registered
+				if err := r.Validate(pipeline); err != nil {
+					return nil, err
+				}
+				return pipeline, nil
+			}
+		}
+	}
+	if pipeline, ok := r.pipelines[name]; ok {
+		return pipeline, nil
+	}
+	if pipeline, ok := types.BuiltinPipeline(name); ok {
+		return pipeline, nil
+	}
+	return nil, fmt.Errorf("pipeline not found: %s", name)
+}
@@ -0,0 +1,123 @@
+package chatpipline
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func newTestPipelineRegistry(pipelines ...*types.PipelineDefinition) *PipelineRegistry {
+	manager := NewEventManager()
+	manager.Register(&testPlugin{name: "search", events: []types.EventType{types.CHUNK_SEARCH, types.CHUNK_RERANK}})
+	cfg := &config.Config{Conversation: &config.ConversationConfig{Pipelines: pipelines}}
+	return NewPipelineRegistry(cfg, manager)
+}
+
+func TestPipelineRegistryResolve(t *testing.T) {
+	configured := types.NewPipelineDefinition("search", []types.EventType{types.CHUNK_SEARCH})
+	registry := newTestPipelineRegistry(configured)
+
+	tenantPipeline := types.NewPipelineDefinition("search", []types.EventType{types.CHUNK_SEARCH, types.CHUNK_RERANK})
+	tenantConv := &types.ConversationConfig{Pipelines: []*types.PipelineDefinition{tenantPipeline}, DefaultPipeline: "search"}
+
+	if got, err := registry.Resolve("search", nil); err != nil || got != configured {
+		t.Errorf("expected configured pipeline, got %v, %v", got, err)
+	}
+	if got, err := registry.Resolve("", tenantConv); err != nil || got != tenantPipeline {
+		t.Errorf("expected tenant default pipeline, got %v, %v", got, err)
+	}
+	if got, err := registry.Resolve("rag", tenantConv); err != nil || len(got.Stages) != len(types.Pipline["rag"]) {
+		t.Errorf("expected built-in rag pipeline, got %v, %v", got, err)
+	}
+	if _, err := registry.Resolve("missing", nil); err == nil {
+		t.Error("expected error for unknown pipeline")
+	}
+
+	// Tenant pipelines are validated against the registered plugins
+	tenantConv.Pipelines = []*types.PipelineDefinition{
+		types.NewPipelineDefinition("search", []types.EventType{types.CHAT_COMPLETION}),
+	}
+	if _, err := registry.Resolve("search", tenantConv); err == nil {
+		t.Error("expected error for stage without plugin")
+	}
+}
+
+func TestValidatePipelines(t *testing.T) {
+	if err := ValidatePipelines(newTestPipelineRegistry(
+		types.NewPipelineDefinition(types.DefaultPipelineName, []types.EventType{types.CHUNK_SEARCH}),
+	)); err != nil {
+		t.Errorf("expected valid pipelines, got %v", err)
+	}
+
+	invalid := &types.PipelineDefinition{Name: types.DefaultPipelineName, Stages: []types.PipelineStage{
+		{Event: types.CHUNK_SEARCH, Params: map[string]interface{}{"unknown_setting": 1}},
+	}}
+	if err := ValidatePipelines(newTestPipelineRegistry(invalid)); err == nil {
+		t.Error("expected error for unknown stage param")
+	}
+}
+
+func TestPipelineStageParams(t *testing.T) {
+	chatManage := &types.ChatManage{RerankTopK: 5, EnableHyDE: true}
+	restore, err := chatManage.ApplyParams(map[string]interface{}{
+		"rerank_top_k":   8,
+		"enable_hyde":    false,
+		"summary_config": map[string]interface{}{"temperature": 0.1},
+	})
+	if err != nil {
+		t.Fatalf("ApplyParams failed: %v", err)
+	}
+	if chatManage.RerankTopK != 8 || chatManage.EnableHyDE || chatManage.SummaryConfig.Temperature != 0.1 {
+		t.Errorf("params not applied: %+v", chatManage)
+	}
+	restore()
+	if chatManage.RerankTopK != 5 || !chatManage.EnableHyDE || chatManage.SummaryConfig.Temperature != 0 {
+		t.Errorf("params not restored: %+v", chatManage)
+	}
+
+	if _, err := chatManage.ApplyParams(map[string]interface{}{"rerank_top_k": "many"}); err == nil {
+		t.Error("expected error for mistyped param")
+	}
+	if chatManage.RerankTopK != 5 {
+		t.Errorf("failed ApplyParams must leave settings unchanged, got %d", chatManage.RerankTopK)
+	}
+}
+
+func TestPipelineStageParamsRefuseRequestScope(t *testing.T) {
+	registry := newTestPipelineRegistry()
+	for _, key := range []string{"knowledge_base_ids", "knowledge_base_id", "session_id", "query", "rewrite_query",
+		"history", "chat_model_id", "filter"} {
+		pipeline := &types.PipelineDefinition{Name: "scoped", Stages: []types.PipelineStage{
+			{Event: types.CHUNK_SEARCH, Params: map[string]interface{}{key: nil}},
+		}}
+		if err := registry.Validate(pipeline); err == nil {
+			t.Errorf("expected param %s to be refused", key)
+		}
+	}
+
+	chatManage := &types.ChatManage{KnowledgeBaseIDs: []string{"kb1"}}
+	if _, err := chatManage.ApplyParams(map[string]interface{}{"knowledge_base_ids": []string{"kb2"}}); err == nil {
+		t.Error("expected knowledge_base_ids to be refused")
+	}
+	if chatManage.KnowledgeBaseIDs[0] != "kb1" {
+		t.Errorf("refused param must leave settings unchanged, got %v", chatManage.KnowledgeBaseIDs)
+	}
+}
+
+func TestPipelineConditionMatches(t *testing.T) {
+	skipFAQ := &types.PipelineCondition{SkipKnowledgeBaseTypes: []string{types.KnowledgeBaseTypeFAQ}}
+	tests := []struct {
+		kbTypes []string
+		want    bool
+	}{
+		{[]string{types.KnowledgeBaseTypeFAQ}, false},
+		{[]string{types.KnowledgeBaseTypeFAQ, types.KnowledgeBaseTypeDocument}, true},
+		{nil, true},
+	}
+	for _, tt := range tests {
+		if got := skipFAQ.Matches(&types.ChatManage{KnowledgeBaseTypes: tt.kbTypes}); got != tt.want {
+			t.Errorf("Matches(%v) = %v, want %v", tt.kbTypes, got, tt.want)
+		}
+	}
+}
@@ -15,13 +15,17 @@ import (
 
 // PluginRerank implements reranking functionality for chat pipeline
 type PluginRerank struct {
-	modelService interfaces.ModelService // Service to access rerank models
+	modelService    interfaces.ModelService    // Service to access rerank models
+	feedbackService interfaces.FeedbackService // Service to access the feedback scores of chunks
 }
 
 // NewPluginRerank creates a new rerank plugin instance
-func NewPluginRerank(eventManager *EventManager, modelService interfaces.ModelService) *PluginRerank {
+func NewPluginRerank(eventManager *EventManager,
+	modelService interfaces.ModelService, feedbackService interfaces.FeedbackService,
+) *PluginRerank {
 	res := &PluginRerank{
-		modelService: modelService,
+		modelService:    modelService,
+		feedbackService: feedbackService,
 	}
 	eventManager.Register(res)
 	return res
@@ -130,6 +134,9 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 		})
 		reranked = append(reranked, sr)
 	}
+	if chatManage.FeedbackRerank.IsEnabled() {
+		p.applyFeedback(ctx, chatManage, reranked)
+	}
 	final := applyMMR(ctx, reranked, chatManage, min(len(reranked), max(1, chatManage.RerankTopK)), 0.7)
 	chatManage.RerankResult = final
 
@@ -157,6 +164,46 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 	return next()
 }
 
+// applyFeedback adjusts the scores of the reranked chunks by their feedback, the scores are kept on failure
+func (p *PluginRerank) applyFeedback(ctx context.Context,
+	chatManage *types.ChatManage, results []*types.SearchResult,
+) {
+	chunkIDs := make([]string, 0, len(results))
+	for _, result := range results {
+		chunkIDs = append(chunkIDs, result.ID)
+	}
+	scores, err := p.feedbackService.GetChunkScores(ctx, chunkIDs)
+	if err != nil {
+		pipelineWarn(ctx, "Rerank", "feedback", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return
+	}
+	weight := chatManage.FeedbackRerank.GetWeight()
+	pipelineInfo(ctx, "Rerank", "feedback", map[string]interface{}{
+		"adjusted_cnt": applyFeedbackScores(results, scores, weight),
+		"weight":       weight,
+	})
+}
+
+// applyFeedbackScores scales the score of each result with feedback by 1 + weight * its feedback score
+// and returns the number of adjusted results
+func applyFeedbackScores(results []*types.SearchResult, scores map[string]float64, weight float64) int {
+	adjusted := 0
+	for _, result := range results {
+		score, ok := scores[result.ID]
+		if !ok {
+			continue
+		}
+		result.Metadata = ensureMetadata(result.Metadata)
+		result.Metadata["feedback_score"] = fmt.Sprintf("%.4f", score)
+		result.Score *= 1 + weight*score
+		adjusted++
+	}
+	return adjusted
+}
+
 // rerank performs the actual reranking operation with given query and passages
 func (p *PluginRerank) rerank(ctx context.Context,
 	chatManage *types.ChatManage, rerankModel rerank.Reranker, query string, passages []string,
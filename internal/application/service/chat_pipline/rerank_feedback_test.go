@@ -0,0 +1,57 @@
+package chatpipline
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestApplyFeedbackScores(t *testing.T) {
+	// A good answer with one reference marked useful, a bad answer without marked references
+	votes := map[string][]int{
+		"useful": {
+			types.FeedbackVote(types.FeedbackRatingUp, true, true),
+			types.FeedbackVote(types.FeedbackRatingDown, true, true),
+		},
+		"unmarked": {types.FeedbackVote(types.FeedbackRatingUp, true, false)},
+		"bad": {
+			types.FeedbackVote(types.FeedbackRatingDown, false, false),
+			types.FeedbackVote(types.FeedbackRatingDown, false, false),
+		},
+	}
+	scores := make(map[string]float64)
+	for chunkID, chunkVotes := range votes {
+		var positive, negative int64
+		for _, vote := range chunkVotes {
+			switch {
+			case vote > 0:
+				positive++
+			case vote < 0:
+				negative++
+			}
+		}
+		scores[chunkID] = types.FeedbackScore(positive, negative)
+	}
+	if scores["useful"] != 0.5 || scores["unmarked"] != 0 || scores["bad"] != -0.5 {
+		t.Fatalf("unexpected feedback scores %v", scores)
+	}
+
+	results := []*types.SearchResult{
+		{ID: "useful", Score: 0.8},
+		{ID: "bad", Score: 0.8},
+		{ID: "new", Score: 0.8},
+	}
+	if adjusted := applyFeedbackScores(results, scores, 0.2); adjusted != 2 {
+		t.Errorf("adjusted = %d, want 2", adjusted)
+	}
+	want := []float64{0.88, 0.72, 0.8}
+	for i, result := range results {
+		if math.Abs(result.Score-want[i]) > 1e-9 {
+			t.Errorf("score of %s = %v, want %v", result.ID, result.Score, want[i])
+		}
+	}
+	if results[1].Metadata["feedback_score"] != "-0.5000" || results[2].Metadata != nil {
+		t.Errorf("unexpected metadata %v, %v", results[1].Metadata, results[2].Metadata)
+	}
+}
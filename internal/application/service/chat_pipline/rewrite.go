@@ -84,9 +84,20 @@ func (p *PluginRewrite) OnEvent(ctx context.Context,
 	// Convert historical messages to conversation history structure
 	historyMap := make(map[string]*types.History)
 
-	// Process historical messages, grouped by requestID
+	// Process historical messages, grouped by requestID. A regenerated answer has its own request,
+	// it is grouped with the question it follows.
+	questions := make(map[string]*types.Message)
 	for _, message := range history {
-		history, ok := historyMap[message.RequestID]
+		if message.Role == "user" {
+			questions[message.ID] = message
+		}
+	}
+	for _, message := range history {
+		requestID := message.RequestID
+		if question, ok := questions[message.ParentID]; ok && message.Role != "user" {
+			requestID = question.RequestID
+		}
+		history, ok := historyMap[requestID]
 		if !ok {
 			history = &types.History{}
 		}
@@ -99,7 +110,7 @@ func (p *PluginRewrite) OnEvent(ctx context.Context,
 			history.Answer = reg.ReplaceAllString(message.Content, "")
 			history.KnowledgeReferences = message.KnowledgeReferences
 		}
-		historyMap[message.RequestID] = history
+		historyMap[requestID] = history
 	}
 
 	// Convert to list and filter incomplete conversations
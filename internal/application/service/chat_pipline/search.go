@@ -1,9 +1,11 @@
 package chatpipline
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"unicode"
@@ -172,6 +174,8 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 							MatchCount:           expTopK,
 							DisableVectorMatch:   true,
 							DisableKeywordsMatch: false,
+							Fusion:               chatManage.FusionConfig,
+							Filter:               chatManage.Filter,
 						}
 						res, err := p.knowledgeBaseService.HybridSearch(ctx, kbID, paramsExp)
 						if err != nil {
@@ -308,7 +312,8 @@ func buildContentSignature(content string) string {
 	return searchutil.BuildContentSignature(content)
 }
 
-// searchKnowledgeBases performs KB searches across KB IDs using RewriteQuery only
+// searchKnowledgeBases performs KB searches across KB IDs using RewriteQuery and,
+// when the multi-query stage ran, each paraphrase; the per-query result lists are fused
 func (p *PluginSearch) searchKnowledgeBases(
 	ctx context.Context,
 	knowledgeBaseIDs []string,
@@ -320,44 +325,100 @@ func (p *PluginSearch) searchKnowledgeBases(
 		VectorThreshold:  chatManage.VectorThreshold,
 		KeywordThreshold: chatManage.KeywordThreshold,
 		MatchCount:       chatManage.EmbeddingTopK,
+		Fusion:           chatManage.FusionConfig,
+		Filter:           chatManage.Filter,
+		// The hypothetical answer generated by the HyDE stage replaces the query for vector search
+		VectorQueryText: chatManage.HyDEDocument,
+	}
+	queryParams := []types.SearchParams{baseParams}
+	for _, query := range chatManage.MultiQueries {
+		params := baseParams
+		params.QueryText = query
+		params.VectorQueryText = ""
+		queryParams = append(queryParams, params)
 	}
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	var results []*types.SearchResult
-
-	// Search with rewrite query only (removed duplicate ProcessedQuery search)
-	for _, kbID := range knowledgeBaseIDs {
-		wg.Add(1)
-		go func(knowledgeBaseID string) {
-			defer wg.Done()
-			res, err := p.knowledgeBaseService.HybridSearch(ctx, knowledgeBaseID, baseParams)
-			if err != nil {
-				pipelineWarn(ctx, "Search", "kb_search_error", map[string]interface{}{
-					"kb_id": knowledgeBaseID,
-					"query": baseParams.QueryText,
-					"error": err.Error(),
+	queryResults := make([][]*types.SearchResult, len(queryParams))
+
+	for i, params := range queryParams {
+		for _, kbID := range knowledgeBaseIDs {
+			wg.Add(1)
+			go func(i int, params types.SearchParams, knowledgeBaseID string) {
+				defer wg.Done()
+				res, err := p.knowledgeBaseService.HybridSearch(ctx, knowledgeBaseID, params)
+				if err != nil {
+					pipelineWarn(ctx, "Search", "kb_search_error", map[string]interface{}{
+						"kb_id": knowledgeBaseID,
+						"query": params.QueryText,
+						"error": err.Error(),
+					})
+					return
+				}
+				pipelineInfo(ctx, "Search", "kb_result", map[string]interface{}{
+					"kb_id":     knowledgeBaseID,
+					"query":     params.QueryText,
+					"hit_count": len(res),
 				})
-				return
-			}
-			pipelineInfo(ctx, "Search", "kb_result", map[string]interface{}{
-				"kb_id":     knowledgeBaseID,
-				"hit_count": len(res),
-			})
-			mu.Lock()
-			results = append(results, res...)
-			mu.Unlock()
-		}(kbID)
+				mu.Lock()
+				queryResults[i] = append(queryResults[i], res...)
+				mu.Unlock()
+			}(i, params, kbID)
+		}
 	}
 
 	wg.Wait()
 
+	results := queryResults[0]
+	if len(queryResults) > 1 {
+		results = fuseQueryResults(queryResults, chatManage.FusionConfig.GetRRFK())
+	}
 	pipelineInfo(ctx, "Search", "kb_result_summary", map[string]interface{}{
+		"queries":    len(queryParams),
 		"total_hits": len(results),
 	})
 	return results
 }
 
+// fuseQueryResults merges the result lists of several queries by reciprocal rank fusion.
+// Results are ordered by fused rank, while each keeps its best retrieval score so that
+// thresholds and rerank score blending downstream see comparable values.
+func fuseQueryResults(lists [][]*types.SearchResult, rrfK int) []*types.SearchResult {
+	fusedScores := make(map[string]float64)
+	best := make(map[string]*types.SearchResult)
+	var order []string
+	for _, list := range lists {
+		ranked := slices.Clone(list)
+		slices.SortStableFunc(ranked, func(a, b *types.SearchResult) int {
+			return cmp.Compare(b.Score, a.Score)
+		})
+		seen := make(map[string]struct{}, len(ranked))
+		for rank, result := range ranked {
+			if _, ok := seen[result.ID]; ok {
+				continue
+			}
+			seen[result.ID] = struct{}{}
+			fusedScores[result.ID] += 1 / float64(rrfK+rank+1)
+			if current, ok := best[result.ID]; !ok {
+				best[result.ID] = result
+				order = append(order, result.ID)
+			} else if result.Score > current.Score {
+				best[result.ID] = result
+			}
+		}
+	}
+
+	fused := make([]*types.SearchResult, 0, len(order))
+	for _, id := range order {
+		fused = append(fused, best[id])
+	}
+	slices.SortStableFunc(fused, func(a, b *types.SearchResult) int {
+		return cmp.Compare(fusedScores[b.ID], fusedScores[a.ID])
+	})
+	return fused
+}
+
 // searchWebIfEnabled executes web search when enabled and returns converted results
 func (p *PluginSearch) searchWebIfEnabled(ctx context.Context, chatManage *types.ChatManage) []*types.SearchResult {
 	if !chatManage.WebSearchEnabled || p.webSearchService == nil || p.tenantService == nil || chatManage.TenantID <= 0 {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -14,6 +15,7 @@ type PluginSearchEntity struct {
 	graphRepo     interfaces.RetrieveGraphRepository
 	chunkRepo     interfaces.ChunkRepository
 	knowledgeRepo interfaces.KnowledgeRepository
+	config        *config.Config
 }
 
 // NewPluginSearchEntity creates a new plugin search entity
@@ -22,11 +24,13 @@ func NewPluginSearchEntity(
 	graphRepository interfaces.RetrieveGraphRepository,
 	chunkRepository interfaces.ChunkRepository,
 	knowledgeRepository interfaces.KnowledgeRepository,
+	config *config.Config,
 ) *PluginSearchEntity {
 	res := &PluginSearchEntity{
 		graphRepo:     graphRepository,
 		chunkRepo:     chunkRepository,
 		knowledgeRepo: knowledgeRepository,
+		config:        config,
 	}
 	eventManager.Register(res)
 	return res
@@ -72,7 +76,7 @@ func (p *PluginSearchEntity) OnEvent(ctx context.Context,
 		go func(knowledgeBaseID string) {
 			defer wg.Done()
 
-			graph, err := p.graphRepo.SearchNode(ctx, types.NameSpace{KnowledgeBase: knowledgeBaseID}, entity)
+			graph, err := p.searchGraph(ctx, types.NameSpace{KnowledgeBase: knowledgeBaseID}, entity)
 			if err != nil {
 				logger.Errorf(ctx, "Failed to search entity in KB %s: %v", knowledgeBaseID, err)
 				return
@@ -149,6 +153,56 @@ func (p *PluginSearchEntity) OnEvent(ctx context.Context,
 	return next()
 }
 
+// maxPathEntities caps the entities whose pairwise paths are searched
+const maxPathEntities = 4
+
+// searchGraph expands the neighbourhood of the entities and, if enabled, adds the shortest paths between
+// them. Path nodes come first since they bridge the documents of different entities.
+func (p *PluginSearchEntity) searchGraph(ctx context.Context,
+	namespace types.NameSpace, entity []string,
+) (*types.GraphData, error) {
+	var graphConfig *types.GraphSearchConfig
+	if p.config != nil && p.config.Conversation != nil {
+		graphConfig = p.config.Conversation.GraphSearch
+	}
+	graph, err := p.graphRepo.ExpandNodes(ctx, namespace, graphConfig.GraphQuery(entity))
+	if err != nil {
+		return nil, err
+	}
+	if graph == nil {
+		graph = &types.GraphData{}
+	}
+	if !graphConfig.PathsEnabled() {
+		return graph, nil
+	}
+
+	endpoints := entity
+	if len(endpoints) > maxPathEntities {
+		endpoints = endpoints[:maxPathEntities]
+	}
+	paths := &types.GraphData{}
+	for i := 0; i < len(endpoints); i++ {
+		for j := i + 1; j < len(endpoints); j++ {
+			path, err := p.graphRepo.FindPath(ctx, namespace, &types.GraphPathQuery{
+				Source: endpoints[i],
+				Target: endpoints[j],
+			})
+			if err != nil {
+				logger.Warnf(ctx, "Failed to find path between %s and %s: %v", endpoints[i], endpoints[j], err)
+				continue
+			}
+			if path != nil && len(path.Relation) > 0 {
+				paths.Node = append(paths.Node, path.Node...)
+				paths.Relation = append(paths.Relation, path.Relation...)
+			}
+		}
+	}
+	logger.Infof(ctx, "Found %d path relations between %d entities", len(paths.Relation), len(endpoints))
+	graph.Node = append(paths.Node, graph.Node...)
+	graph.Relation = append(paths.Relation, graph.Relation...)
+	return graph, nil
+}
+
 // filterSeenChunk filters seen chunks from the graph
 func filterSeenChunk(ctx context.Context, graph *types.GraphData, searchResult []*types.SearchResult) []string {
 	seen := map[string]bool{}
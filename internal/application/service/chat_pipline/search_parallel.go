@@ -57,6 +57,7 @@ func NewPluginSearchParallel(
 		graphRepo:     graphRepository,
 		chunkRepo:     chunkRepository,
 		knowledgeRepo: knowledgeRepository,
+		config:        config,
 	}
 
 	res := &PluginSearchParallel{
@@ -102,8 +102,12 @@ func (p *PluginStreamFilter) filterEventsWithPrefix(
 				Type:      types.EventType(event.EventAgentFinalAnswer),
 				SessionID: chatManage.SessionID,
 				Data: event.AgentFinalAnswerData{
-					Content: responseBuilder.String(),
-					Done:    data.Done,
+					Content:          responseBuilder.String(),
+					Done:             data.Done,
+					StructuredOutput: data.StructuredOutput,
+					Citations:        data.Citations,
+					CitationMap:      data.CitationMap,
+					Groundedness:     data.Groundedness,
 				},
 			})
 			matchFound = true
@@ -6,6 +6,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -21,6 +22,7 @@ type chunkService struct {
 	kbRepository    interfaces.KnowledgeBaseRepository
 	modelService    interfaces.ModelService
 	retrieveEngine  interfaces.RetrieveEngineRegistry
+	answerCache     interfaces.AnswerCache
 }
 
 // NewChunkService creates a new chunk service
@@ -35,12 +37,30 @@ func NewChunkService(
 	kbRepository interfaces.KnowledgeBaseRepository,
 	modelService interfaces.ModelService,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
+	answerCache interfaces.AnswerCache,
 ) interfaces.ChunkService {
 	return &chunkService{
 		chunkRepository: chunkRepository,
 		kbRepository:    kbRepository,
 		modelService:    modelService,
 		retrieveEngine:  retrieveEngine,
+		answerCache:     answerCache,
+	}
+}
+
+// invalidateAnswerCache drops the cached answers of the knowledge bases of the chunks after they changed
+func (s *chunkService) invalidateAnswerCache(ctx context.Context, chunks ...*types.Chunk) {
+	var kbIDs []string
+	for _, chunk := range chunks {
+		if chunk.KnowledgeBaseID != "" && !slices.Contains(kbIDs, chunk.KnowledgeBaseID) {
+			kbIDs = append(kbIDs, chunk.KnowledgeBaseID)
+		}
+	}
+	if len(kbIDs) == 0 {
+		return
+	}
+	if err := s.answerCache.Invalidate(ctx, kbIDs...); err != nil {
+		logger.Warnf(ctx, "Failed to invalidate answer cache of knowledge bases %v: %v", kbIDs, err)
 	}
 }
 
@@ -186,6 +206,7 @@ func (s *chunkService) UpdateChunk(ctx context.Context, chunk *types.Chunk) erro
 		})
 		return err
 	}
+	s.invalidateAnswerCache(ctx, chunk)
 
 	logger.Info(ctx, "Chunk updated successfully")
 	return nil
@@ -206,6 +227,7 @@ func (s *chunkService) UpdateChunks(ctx context.Context, chunks []*types.Chunk)
 		})
 		return err
 	}
+	s.invalidateAnswerCache(ctx, chunks...)
 
 	logger.Infof(ctx, "Successfully updated %d chunks", len(chunks))
 	return nil
@@ -221,13 +243,23 @@ func (s *chunkService) UpdateChunks(ctx context.Context, chunks []*types.Chunk)
 //   - error: Any error encountered during deletion
 func (s *chunkService) DeleteChunk(ctx context.Context, id string) error {
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
-	err := s.chunkRepository.DeleteChunk(ctx, tenantID, id)
+	// Load the chunk first to know whose cached answers it may be cited by
+	chunk, err := s.chunkRepository.GetChunkByID(ctx, tenantID, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"chunk_id":  id,
+			"tenant_id": tenantID,
+		})
+		return err
+	}
+	err = s.chunkRepository.DeleteChunk(ctx, tenantID, id)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"tenant_id": tenantID,
 		})
 		return err
 	}
+	s.invalidateAnswerCache(ctx, chunk)
 	logger.Info(ctx, "Chunk deleted successfully")
 	return nil
 }
@@ -250,7 +282,15 @@ func (s *chunkService) DeleteChunks(ctx context.Context, ids []string) error {
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Tenant ID: %d", tenantID)
 
-	err := s.chunkRepository.DeleteChunks(ctx, tenantID, ids)
+	chunks, err := s.chunkRepository.ListChunksByID(ctx, tenantID, ids)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"chunk_ids": ids,
+			"tenant_id": tenantID,
+		})
+		return err
+	}
+	err = s.chunkRepository.DeleteChunks(ctx, tenantID, ids)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"chunk_ids": ids,
@@ -258,6 +298,7 @@ func (s *chunkService) DeleteChunks(ctx context.Context, ids []string) error {
 		})
 		return err
 	}
+	s.invalidateAnswerCache(ctx, chunks...)
 
 	logger.Infof(ctx, "Successfully deleted %d chunks", len(ids))
 	return nil
@@ -431,6 +472,7 @@ func (s *chunkService) DeleteGeneratedQuestion(ctx context.Context, chunkID stri
 		})
 		return fmt.Errorf("failed to update chunk: %w", err)
 	}
+	s.invalidateAnswerCache(ctx, chunk)
 
 	logger.Infof(ctx, "Successfully deleted generated question %s from chunk %s", questionID, chunkID)
 	return nil
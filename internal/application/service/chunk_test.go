@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// fakeChunkRepository keeps chunks in memory, only the methods used by the tests are implemented
+type fakeChunkRepository struct {
+	interfaces.ChunkRepository
+	chunks map[string]*types.Chunk
+}
+
+func (r *fakeChunkRepository) GetChunkByID(ctx context.Context, tenantID uint64, id string) (*types.Chunk, error) {
+	chunk, ok := r.chunks[id]
+	if !ok {
+		return nil, errors.New("chunk not found")
+	}
+	return chunk, nil
+}
+
+func (r *fakeChunkRepository) ListChunksByID(ctx context.Context,
+	tenantID uint64, ids []string,
+) ([]*types.Chunk, error) {
+	var chunks []*types.Chunk
+	for _, id := range ids {
+		if chunk, ok := r.chunks[id]; ok {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+func (r *fakeChunkRepository) UpdateChunk(ctx context.Context, chunk *types.Chunk) error {
+	r.chunks[chunk.ID] = chunk
+	return nil
+}
+
+func (r *fakeChunkRepository) UpdateChunks(ctx context.Context, chunks []*types.Chunk) error {
+	for _, chunk := range chunks {
+		r.chunks[chunk.ID] = chunk
+	}
+	return nil
+}
+
+func (r *fakeChunkRepository) DeleteChunk(ctx context.Context, tenantID uint64, id string) error {
+	delete(r.chunks, id)
+	return nil
+}
+
+func (r *fakeChunkRepository) DeleteChunks(ctx context.Context, tenantID uint64, ids []string) error {
+	for _, id := range ids {
+		delete(r.chunks, id)
+	}
+	return nil
+}
+
+// fakeAnswerCache records the invalidated knowledge bases
+type fakeAnswerCache struct {
+	interfaces.AnswerCache
+	invalidated []string
+}
+
+func (c *fakeAnswerCache) Invalidate(ctx context.Context, knowledgeBaseIDs ...string) error {
+	c.invalidated = append(c.invalidated, knowledgeBaseIDs...)
+	return nil
+}
+
+func TestChunkChangesInvalidateAnswerCache(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	newService := func() (interfaces.ChunkService, *fakeAnswerCache) {
+		repo := &fakeChunkRepository{chunks: map[string]*types.Chunk{
+			"c1": {ID: "c1", KnowledgeBaseID: "kb1"},
+			"c2": {ID: "c2", KnowledgeBaseID: "kb2"},
+			"c3": {ID: "c3", KnowledgeBaseID: "kb2"},
+		}}
+		cache := &fakeAnswerCache{}
+		return NewChunkService(repo, nil, nil, nil, cache), cache
+	}
+
+	tests := []struct {
+		name   string
+		change func(interfaces.ChunkService) error
+		want   []string
+	}{
+		{
+			name: "update",
+			change: func(s interfaces.ChunkService) error {
+				return s.UpdateChunk(ctx, &types.Chunk{ID: "c1", KnowledgeBaseID: "kb1", Content: "edited"})
+			},
+			want: []string{"kb1"},
+		},
+		{
+			name: "batch update",
+			change: func(s interfaces.ChunkService) error {
+				return s.UpdateChunks(ctx, []*types.Chunk{
+					{ID: "c2", KnowledgeBaseID: "kb2"}, {ID: "c3", KnowledgeBaseID: "kb2"},
+				})
+			},
+			want: []string{"kb2"},
+		},
+		{
+			name:   "delete",
+			change: func(s interfaces.ChunkService) error { return s.DeleteChunk(ctx, "c2") },
+			want:   []string{"kb2"},
+		},
+		{
+			name:   "batch delete",
+			change: func(s interfaces.ChunkService) error { return s.DeleteChunks(ctx, []string{"c1", "c2", "c3"}) },
+			want:   []string{"kb1", "kb2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, cache := newService()
+			if err := tt.change(service); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			slices.Sort(cache.invalidated)
+			if !slices.Equal(cache.invalidated, tt.want) {
+				t.Errorf("invalidated %v, want %v", cache.invalidated, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkDeleteFailureKeepsAnswerCache(t *testing.T) {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	cache := &fakeAnswerCache{}
+	service := NewChunkService(&fakeChunkRepository{chunks: map[string]*types.Chunk{}}, nil, nil, nil, cache)
+	if err := service.DeleteChunk(ctx, "missing"); err == nil {
+		t.Fatal("expected an error for a missing chunk")
+	}
+	if len(cache.invalidated) != 0 {
+		t.Errorf("invalidated %v after a failed delete", cache.invalidated)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -37,12 +39,24 @@ type QaInfo struct {
 	AID int64 `parquet:"aid"` // Answer ID
 }
 
-// GetDatasetByID retrieves QA pairs from dataset by ID
+// datasetRoot is the directory holding a subdirectory of parquet files per dataset
+const datasetRoot = "./dataset"
+
+// GetDatasetByID retrieves QA pairs from dataset by ID, unknown IDs get the default dataset
 func (d *DatasetService) GetDatasetByID(ctx context.Context, datasetID string) ([]*types.QAPair, error) {
 	logger.Info(ctx, "Start getting dataset by ID")
 	logger.Infof(ctx, "Getting dataset with ID: %s", datasetID)
 
-	dataset := DefaultDataset()
+	var dataset dataset
+	if dir, ok := datasetDir(datasetID); ok && fileExists(filepath.Join(dir, "queries.parquet")) {
+		var err error
+		if dataset, err = loadDataset(dir); err != nil {
+			logger.Errorf(ctx, "Failed to load dataset %s: %v", datasetID, err)
+			return nil, err
+		}
+	} else {
+		dataset = DefaultDataset()
+	}
 	dataset.PrintStats(ctx)
 	qaPairs := dataset.Iterate()
 
@@ -50,28 +64,94 @@ func (d *DatasetService) GetDatasetByID(ctx context.Context, datasetID string) (
 	return qaPairs, nil
 }
 
+// SaveDataset writes the QA pairs as parquet files in the layout of the default dataset,
+// passages are stored once per passage ID
+func (d *DatasetService) SaveDataset(ctx context.Context, datasetID string, pairs []*types.QAPair) error {
+	dir, ok := datasetDir(datasetID)
+	if !ok {
+		return fmt.Errorf("invalid dataset ID: %s", datasetID)
+	}
+	logger.Infof(ctx, "Saving dataset %s with %d QA pairs", datasetID, len(pairs))
+
+	var queries, corpus, answers []TextInfo
+	var qrels []RelsInfo
+	var qas []QaInfo
+	savedPassages := make(map[int]bool)
+	for _, pair := range pairs {
+		queries = append(queries, TextInfo{ID: int64(pair.QID), Text: pair.Question})
+		answers = append(answers, TextInfo{ID: int64(pair.AID), Text: pair.Answer})
+		qas = append(qas, QaInfo{QID: int64(pair.QID), AID: int64(pair.AID)})
+		for i, pid := range pair.PIDs {
+			qrels = append(qrels, RelsInfo{QID: int64(pair.QID), PID: int64(pid)})
+			if !savedPassages[pid] && i < len(pair.Passages) {
+				savedPassages[pid] = true
+				corpus = append(corpus, TextInfo{ID: int64(pid), Text: pair.Passages[i]})
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := parquet.WriteFile(filepath.Join(dir, "queries.parquet"), queries); err != nil {
+		return err
+	}
+	if err := parquet.WriteFile(filepath.Join(dir, "corpus.parquet"), corpus); err != nil {
+		return err
+	}
+	if err := parquet.WriteFile(filepath.Join(dir, "answers.parquet"), answers); err != nil {
+		return err
+	}
+	if err := parquet.WriteFile(filepath.Join(dir, "qrels.parquet"), qrels); err != nil {
+		return err
+	}
+	return parquet.WriteFile(filepath.Join(dir, "qas.parquet"), qas)
+}
+
+// datasetDir returns the directory of a dataset, IDs must be plain directory names
+func datasetDir(datasetID string) (string, bool) {
+	if datasetID == "" || datasetID == "." || datasetID == ".." || filepath.Base(datasetID) != datasetID {
+		return "", false
+	}
+	return filepath.Join(datasetRoot, datasetID), true
+}
+
+// fileExists reports whether the path is an existing file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 // DefaultDataset loads and initializes the default dataset from parquet files
 func DefaultDataset() dataset {
-	datasetDir := "./dataset/samples"
-	queries, err := loadParquet[TextInfo](fmt.Sprintf("%s/queries.parquet", datasetDir))
+	res, err := loadDataset(filepath.Join(datasetRoot, "samples"))
 	if err != nil {
 		panic(err)
 	}
+	return res
+}
+
+// loadDataset loads a dataset from the parquet files in the directory
+func loadDataset(datasetDir string) (dataset, error) {
+	queries, err := loadParquet[TextInfo](fmt.Sprintf("%s/queries.parquet", datasetDir))
+	if err != nil {
+		return dataset{}, err
+	}
 	corpus, err := loadParquet[TextInfo](fmt.Sprintf("%s/corpus.parquet", datasetDir))
 	if err != nil {
-		panic(err)
+		return dataset{}, err
 	}
 	answers, err := loadParquet[TextInfo](fmt.Sprintf("%s/answers.parquet", datasetDir))
 	if err != nil {
-		panic(err)
+		return dataset{}, err
 	}
 	qrels, err := loadParquet[RelsInfo](fmt.Sprintf("%s/qrels.parquet", datasetDir))
 	if err != nil {
-		panic(err)
+		return dataset{}, err
 	}
 	qas, err := loadParquet[QaInfo](fmt.Sprintf("%s/qas.parquet", datasetDir))
 	if err != nil {
-		panic(err)
+		return dataset{}, err
 	}
 
 	res := dataset{
@@ -96,7 +176,7 @@ func DefaultDataset() dataset {
 	for _, qi := range qas {
 		res.qas[qi.QID] = qi.AID
 	}
-	return res
+	return res, nil
 }
 
 // dataset represents the in-memory dataset structure
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/entityresolve"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// entityResolutionDelay postpones the resolution of a knowledge until its chunks are likely extracted, the
+// extraction of every chunk queues it again and the task ID keeps a single one queued
+const entityResolutionDelay = 2 * time.Minute
+
+// NewEntityResolutionTask queues the resolution of the entities of the knowledge against the knowledge base,
+// or of the whole knowledge base if knowledgeID is empty. A resolution already queued is not queued twice.
+func NewEntityResolutionTask(ctx context.Context,
+	client *asynq.Client, tenantID uint64, kbID string, knowledgeID string,
+) error {
+	payload, err := json.Marshal(types.EntityResolutionPayload{
+		TenantID:        tenantID,
+		KnowledgeBaseID: kbID,
+		KnowledgeID:     knowledgeID,
+	})
+	if err != nil {
+		return err
+	}
+	taskID := types.TypeEntityResolution + ":" + kbID
+	options := []asynq.Option{asynq.Queue("low"), asynq.MaxRetry(3)}
+	if knowledgeID != "" {
+		taskID += ":" + knowledgeID
+		options = append(options, asynq.ProcessIn(entityResolutionDelay))
+	}
+	options = append(options, asynq.TaskID(taskID))
+	info, err := client.Enqueue(asynq.NewTask(types.TypeEntityResolution, payload, options...))
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+	if err != nil {
+		logger.Errorf(ctx, "failed to enqueue entity resolution task: %v", err)
+		return fmt.Errorf("failed to enqueue task: %v", err)
+	}
+	logger.Infof(ctx, "enqueued entity resolution task: id=%s knowledge_base=%s knowledge=%s",
+		info.ID, kbID, knowledgeID)
+	return nil
+}
+
+// entityResolutionService implements the entity resolution service interface
+type entityResolutionService struct {
+	config       *config.Config
+	aliasRepo    interfaces.GraphEntityAliasRepository
+	graphRepo    interfaces.RetrieveGraphRepository
+	kbService    interfaces.KnowledgeBaseService
+	modelService interfaces.ModelService
+	task         *asynq.Client
+}
+
+// NewEntityResolutionService creates a new entity resolution service
+func NewEntityResolutionService(config *config.Config,
+	aliasRepo interfaces.GraphEntityAliasRepository,
+	graphRepo interfaces.RetrieveGraphRepository,
+	kbService interfaces.KnowledgeBaseService,
+	modelService interfaces.ModelService,
+	task *asynq.Client,
+) interfaces.EntityResolutionService {
+	return &entityResolutionService{
+		config:       config,
+		aliasRepo:    aliasRepo,
+		graphRepo:    graphRepo,
+		kbService:    kbService,
+		modelService: modelService,
+		task:         task,
+	}
+}
+
+// resolutionConfig returns the entity resolution configuration, nil if not configured
+func (s *entityResolutionService) resolutionConfig() *types.EntityResolutionConfig {
+	if s.config.ExtractManager == nil {
+		return nil
+	}
+	return s.config.ExtractManager.EntityResolution
+}
+
+// ResolveEntities handles the entity resolution task
+func (s *entityResolutionService) ResolveEntities(ctx context.Context, t *asynq.Task) error {
+	var p types.EntityResolutionPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		logger.Errorf(ctx, "failed to unmarshal task payload: %v", err)
+		return err
+	}
+	ctx = logger.WithRequestID(ctx, uuid.New().String())
+	ctx = logger.WithField(ctx, "entity_resolution", p.KnowledgeBaseID)
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, p.TenantID)
+
+	if !s.resolutionConfig().IsEnabled() || !config.IsGraphEnabled() {
+		logger.Warn(ctx, "Entity resolution is not enabled, skip entity resolution task")
+		return nil
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, p.KnowledgeBaseID)
+	if err != nil {
+		logger.Warnf(ctx, "entity resolution ignores knowledge base %s: %v", p.KnowledgeBaseID, err)
+		return nil
+	}
+	return s.resolve(ctx, kb, p.KnowledgeID)
+}
+
+// resolve merges the nodes of the knowledge base naming the same entity. Known aliases are applied first,
+// then the names of the knowledge, or all names if knowledgeID is empty, are resolved against the names of
+// the knowledge base.
+func (s *entityResolutionService) resolve(ctx context.Context, kb *types.KnowledgeBase, knowledgeID string) error {
+	namespace := types.NameSpace{KnowledgeBase: kb.ID}
+	nodes, err := s.graphRepo.ListNodes(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	pending := make(map[string]bool)
+	if knowledgeID != "" {
+		knowledgeNodes, err := s.graphRepo.ListNodes(ctx, types.NameSpace{KnowledgeBase: kb.ID, Knowledge: knowledgeID})
+		if err != nil {
+			return err
+		}
+		for _, node := range knowledgeNodes {
+			pending[node.Name] = true
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+	}
+
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	renames, err := s.aliasRepo.GetCanonicalNames(ctx, kb.TenantID, kb.ID, names)
+	if err != nil {
+		return fmt.Errorf("failed to get canonical names: %w", err)
+	}
+	entities := make(map[string]*entityresolve.Entity, len(nodes))
+	var order []string
+	for _, node := range nodes {
+		name := node.Name
+		if canonical, ok := renames[name]; ok {
+			name = canonical
+		}
+		entity, ok := entities[name]
+		if !ok {
+			entity = &entityresolve.Entity{Name: name, Pending: true}
+			entities[name] = entity
+			order = append(order, name)
+		}
+		entity.Weight += len(node.Chunks)
+		if knowledgeID != "" && !pending[node.Name] {
+			entity.Pending = false
+		}
+	}
+	candidates := make([]entityresolve.Entity, 0, len(order))
+	for _, name := range order {
+		candidates = append(candidates, *entities[name])
+	}
+
+	resolver, err := s.newResolver(ctx, kb)
+	if err != nil {
+		return err
+	}
+	merges, err := resolver.Resolve(ctx, candidates)
+	if err != nil {
+		return err
+	}
+
+	merged := make(map[string]string, len(merges))
+	aliases := make([]*types.GraphEntityAlias, 0, len(merges))
+	for _, merge := range merges {
+		merged[merge.Alias] = merge.Canonical
+		aliases = append(aliases, &types.GraphEntityAlias{
+			ID:              uuid.New().String(),
+			TenantID:        kb.TenantID,
+			KnowledgeBaseID: kb.ID,
+			Alias:           merge.Alias,
+			Canonical:       merge.Canonical,
+			Source:          merge.Source,
+			Score:           merge.Score,
+		})
+	}
+	// A canonical entity of a known alias may have been merged in turn
+	for alias, canonical := range renames {
+		if target, ok := merged[canonical]; ok {
+			renames[alias] = target
+		}
+	}
+	for alias, canonical := range merged {
+		renames[alias] = canonical
+	}
+	if len(renames) == 0 {
+		logger.Infof(ctx, "No entity of knowledge base %s to merge among %d", kb.ID, len(nodes))
+		return nil
+	}
+
+	if err := s.graphRepo.RenameNodes(ctx, namespace, renames); err != nil {
+		return err
+	}
+	if err := s.aliasRepo.SaveAliases(ctx, aliases); err != nil {
+		return fmt.Errorf("failed to save aliases: %w", err)
+	}
+	logger.Infof(ctx, "Merged %d entities of knowledge base %s among %d, %d new aliases",
+		len(renames), kb.ID, len(nodes), len(aliases))
+	return nil
+}
+
+// newResolver creates the resolver of the knowledge base, embedding names with its embedding model and
+// asking its summary model when adjudication is enabled
+func (s *entityResolutionService) newResolver(ctx context.Context,
+	kb *types.KnowledgeBase,
+) (*entityresolve.Resolver, error) {
+	cfg := s.resolutionConfig()
+	var embed entityresolve.Embedder
+	if kb.EmbeddingModelID != "" {
+		embedder, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get embedding model: %w", err)
+		}
+		embed = func(ctx context.Context, names []string) ([][]float32, error) {
+			return embedder.BatchEmbedWithPool(ctx, embedder, names)
+		}
+	}
+	var adjudicator entityresolve.Adjudicator
+	if cfg.LLMAdjudication && kb.SummaryModelID != "" {
+		chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chat model: %w", err)
+		}
+		adjudicator = entityresolve.NewLLMAdjudicator(chatModel)
+	}
+	return entityresolve.New(cfg, embed, adjudicator), nil
+}
+
+// EnqueueResolution queues the resolution of the knowledge, or the rebuild of the whole knowledge base
+func (s *entityResolutionService) EnqueueResolution(ctx context.Context, kbID string, knowledgeID string) error {
+	if !config.IsGraphEnabled() {
+		return werrors.NewBadRequestError("Knowledge graph is not enabled")
+	}
+	if !s.resolutionConfig().IsEnabled() {
+		return werrors.NewBadRequestError("Entity resolution is not enabled")
+	}
+	kb, err := getTenantKnowledgeBase(ctx, s.kbService, kbID)
+	if err != nil {
+		return err
+	}
+	return NewEntityResolutionTask(ctx, s.task, kb.TenantID, kb.ID, knowledgeID)
+}
+
+// ListAliases lists the aliases of the knowledge graph of a knowledge base
+func (s *entityResolutionService) ListAliases(ctx context.Context, kbID string) ([]*types.GraphEntityAlias, error) {
+	kb, err := getTenantKnowledgeBase(ctx, s.kbService, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return s.aliasRepo.ListAliases(ctx, kb.TenantID, kb.ID)
+}
+
+// AddAlias adds a manual alias and merges the nodes of the alias into its canonical entity
+func (s *entityResolutionService) AddAlias(ctx context.Context,
+	kbID string, req *types.GraphEntityAliasRequest,
+) (*types.GraphEntityAlias, error) {
+	kb, err := getTenantKnowledgeBase(ctx, s.kbService, kbID)
+	if err != nil {
+		return nil, err
+	}
+	aliasName, canonical := strings.TrimSpace(req.Alias), strings.TrimSpace(req.Canonical)
+	if aliasName == "" || canonical == "" {
+		return nil, werrors.NewBadRequestError("Alias and canonical entity are required")
+	}
+	// The canonical entity may itself be an alias
+	known, err := s.aliasRepo.GetCanonicalNames(ctx, kb.TenantID, kb.ID, []string{canonical})
+	if err != nil {
+		return nil, err
+	}
+	if target, ok := known[canonical]; ok {
+		canonical = target
+	}
+	if aliasName == canonical {
+		return nil, werrors.NewBadRequestError("An entity cannot be an alias of itself")
+	}
+
+	alias := &types.GraphEntityAlias{
+		ID:              uuid.New().String(),
+		TenantID:        kb.TenantID,
+		KnowledgeBaseID: kb.ID,
+		Alias:           aliasName,
+		Canonical:       canonical,
+		Source:          types.EntityAliasSourceManual,
+		Score:           1,
+	}
+	if err := s.aliasRepo.SaveAliases(ctx, []*types.GraphEntityAlias{alias}); err != nil {
+		return nil, err
+	}
+	if config.IsGraphEnabled() {
+		if err := s.graphRepo.RenameNodes(ctx, types.NameSpace{KnowledgeBase: kb.ID},
+			map[string]string{aliasName: canonical}); err != nil {
+			return nil, err
+		}
+	}
+	logger.Infof(ctx, "Entity %s of knowledge base %s merged into %s", aliasName, kb.ID, canonical)
+	return alias, nil
+}
+
+// DeleteAlias deletes an alias, the merged nodes are not split again
+func (s *entityResolutionService) DeleteAlias(ctx context.Context, kbID string, aliasID string) error {
+	kb, err := getTenantKnowledgeBase(ctx, s.kbService, kbID)
+	if err != nil {
+		return err
+	}
+	if err := s.aliasRepo.DeleteAlias(ctx, kb.TenantID, kb.ID, aliasID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return werrors.NewNotFoundError("Alias not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// getTenantKnowledgeBase gets a knowledge base of the tenant of the request
+func getTenantKnowledgeBase(ctx context.Context,
+	kbService interfaces.KnowledgeBaseService, kbID string,
+) (*types.KnowledgeBase, error) {
+	kb, err := kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb.TenantID != ctx.Value(types.TenantIDContextKey).(uint64) {
+		return nil, werrors.NewNotFoundError("Knowledge base not found")
+	}
+	return kb, nil
+}
+
+// canonicalizeGraph replaces the names of the graph that are aliases by their canonical entity and drops the
+// relations that become loops
+func canonicalizeGraph(graph *types.GraphData, canonical map[string]string) {
+	rename := func(name string) string {
+		if target, ok := canonical[name]; ok {
+			return target
+		}
+		return name
+	}
+	for _, node := range graph.Node {
+		node.Name = rename(node.Name)
+	}
+	relations := graph.Relation[:0]
+	for _, rel := range graph.Relation {
+		rel.Node1, rel.Node2 = rename(rel.Node1), rename(rel.Node2)
+		if rel.Node1 != rel.Node2 {
+			relations = append(relations, rel)
+		}
+	}
+	graph.Relation = relations
+}
@@ -130,12 +130,14 @@ func (e *EvaluationService) EvaluationResult(ctx context.Context, taskID string)
 // knowledgeBaseID: ID of the knowledge base to use (empty to create new)
 // chatModelID: ID of the chat model to evaluate
 // rerankModelID: ID of the rerank model to evaluate
+// enableHyDE, enableMultiQuery: whether retrieval uses the HyDE and multi-query stages
 func (e *EvaluationService) Evaluation(ctx context.Context,
 	datasetID string, knowledgeBaseID string, chatModelID string, rerankModelID string,
+	enableHyDE bool, enableMultiQuery bool,
 ) (*types.EvaluationDetail, error) {
 	logger.Info(ctx, "Start evaluation")
-	logger.Infof(ctx, "Dataset ID: %s, Knowledge Base ID: %s, Chat Model ID: %s, Rerank Model ID: %s",
-		datasetID, knowledgeBaseID, chatModelID, rerankModelID)
+	logger.Infof(ctx, "Dataset ID: %s, Knowledge Base ID: %s, Chat Model ID: %s, Rerank Model ID: %s, HyDE: %v, Multi-query: %v",
+		datasetID, knowledgeBaseID, chatModelID, rerankModelID, enableHyDE, enableMultiQuery)
 
 	// Get tenant ID from context for multi-tenancy support
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
@@ -292,6 +294,10 @@ func (e *EvaluationService) Evaluation(ctx context.Context,
 			FallbackResponse:    e.config.Conversation.FallbackResponse,
 			RewritePromptSystem: e.config.Conversation.RewritePromptSystem,
 			RewritePromptUser:   e.config.Conversation.RewritePromptUser,
+			EnableHyDE:          enableHyDE,
+			EnableMultiQuery:    enableMultiQuery,
+			MultiQueryCount:     e.config.Conversation.MultiQueryCount,
+			Groundedness:        evaluationGroundedness(e.config.Conversation.Groundedness),
 		},
 	}
 
@@ -396,6 +402,7 @@ func (e *EvaluationService) EvalDataset(ctx context.Context, detail *types.Evalu
 			// Prepare chat management parameters for this QA pair
 			chatManage := detail.Params.Clone()
 			chatManage.Query = qaPair.Question
+			chatManage.RewriteQuery = qaPair.Question
 
 			// Execute knowledge QA pipeline
 			logger.Infof(ctx, "Running knowledge QA for question: %s", qaPair.Question)
@@ -412,6 +419,7 @@ func (e *EvaluationService) EvalDataset(ctx context.Context, detail *types.Evalu
 			metricHook.recordSearchResult(i, chatManage.SearchResult)
 			metricHook.recordRerankResult(i, chatManage.RerankResult)
 			metricHook.recordChatResponse(i, chatManage.ChatResponse)
+			metricHook.recordGroundedness(i, chatManage.GroundednessResult)
 			metricHook.recordFinish(i)
 
 			// Update progress metrics
@@ -445,6 +453,15 @@ func (e *EvaluationService) EvalDataset(ctx context.Context, detail *types.Evalu
 	return nil
 }
 
+// evaluationGroundedness returns the groundedness check used by evaluations, answers are always
+// checked so the groundedness metric is recorded, by default without changing them
+func evaluationGroundedness(config *types.GroundednessConfig) *types.GroundednessConfig {
+	if config.IsEnabled() {
+		return config
+	}
+	return &types.GroundednessConfig{Enabled: true, Action: types.GroundednessActionAnnotate}
+}
+
 // getPassageList extracts and organizes passages from QA pairs
 // Returns a slice of passages indexed by their passage IDs
 func getPassageList(dataset []*types.QAPair) []string {
@@ -4,11 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"strings"
 
 	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
 	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/graphedit"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -24,8 +23,8 @@ func NewChunkExtractTask(
 	chunkID string,
 	modelID string,
 ) error {
-	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) != "true" {
-		logger.Warn(ctx, "NEO4J is not enabled, skip chunk extract task")
+	if !config.IsGraphEnabled() {
+		logger.Warn(ctx, "Knowledge graph is not enabled, skip chunk extract task")
 		return nil
 	}
 	payload, err := json.Marshal(types.ExtractChunkPayload{
@@ -53,6 +52,11 @@ type ChunkExtractService struct {
 	knowledgeBaseRepo interfaces.KnowledgeBaseRepository
 	chunkRepo         interfaces.ChunkRepository
 	graphEngine       interfaces.RetrieveGraphRepository
+	aliasRepo         interfaces.GraphEntityAliasRepository
+	tombstoneRepo     interfaces.GraphTombstoneRepository
+	resolution        *types.EntityResolutionConfig
+	communities       *types.GraphCommunityConfig
+	task              *asynq.Client
 }
 
 // NewChunkExtractService creates a new chunk extract service
@@ -62,6 +66,9 @@ func NewChunkExtractService(
 	knowledgeBaseRepo interfaces.KnowledgeBaseRepository,
 	chunkRepo interfaces.ChunkRepository,
 	graphEngine interfaces.RetrieveGraphRepository,
+	aliasRepo interfaces.GraphEntityAliasRepository,
+	tombstoneRepo interfaces.GraphTombstoneRepository,
+	task *asynq.Client,
 ) interfaces.Extracter {
 	// generator := chatpipline.NewQAPromptGenerator(chatpipline.NewFormater(), config.ExtractManager.ExtractGraph)
 	// ctx := context.Background()
@@ -73,6 +80,11 @@ func NewChunkExtractService(
 		knowledgeBaseRepo: knowledgeBaseRepo,
 		chunkRepo:         chunkRepo,
 		graphEngine:       graphEngine,
+		aliasRepo:         aliasRepo,
+		tombstoneRepo:     tombstoneRepo,
+		resolution:        config.ExtractManager.EntityResolution,
+		communities:       config.ExtractManager.Communities,
+		task:              task,
 	}
 }
 
@@ -134,6 +146,9 @@ func (s *ChunkExtractService) Extract(ctx context.Context, t *asynq.Task) error
 	for _, node := range graph.Node {
 		node.Chunks = []string{chunk.ID}
 	}
+	// Names renamed, merged or deleted through the API or by the resolution stay so
+	s.applyAliases(ctx, p.TenantID, chunk.KnowledgeBaseID, graph)
+	s.applyTombstones(ctx, p.TenantID, chunk.KnowledgeBaseID, graph)
 	if err = s.graphEngine.AddGraph(ctx,
 		types.NameSpace{KnowledgeBase: chunk.KnowledgeBaseID, Knowledge: chunk.KnowledgeID},
 		[]*types.GraphData{graph},
@@ -141,5 +156,52 @@ func (s *ChunkExtractService) Extract(ctx context.Context, t *asynq.Task) error
 		logger.Errorf(ctx, "failed to add graph: %v", err)
 		return err
 	}
+	if s.resolution.IsEnabled() {
+		if err := NewEntityResolutionTask(ctx, s.task,
+			p.TenantID, chunk.KnowledgeBaseID, chunk.KnowledgeID); err != nil {
+			logger.Warnf(ctx, "failed to enqueue entity resolution of knowledge %s: %v", chunk.KnowledgeID, err)
+		}
+	}
+	if s.communities.IsEnabled() {
+		if err := NewCommunityDetectionTask(ctx, s.task,
+			p.TenantID, chunk.KnowledgeBaseID, communityDetectionDelay); err != nil {
+			logger.Warnf(ctx, "failed to enqueue community detection of knowledge base %s: %v",
+				chunk.KnowledgeBaseID, err)
+		}
+	}
 	return nil
 }
+
+// applyAliases replaces the extracted names that are known aliases by their canonical entity, so entities
+// merged before are not extracted again under another name
+func (s *ChunkExtractService) applyAliases(ctx context.Context,
+	tenantID uint64, kbID string, graph *types.GraphData,
+) {
+	names := make([]string, 0, len(graph.Node)+2*len(graph.Relation))
+	for _, node := range graph.Node {
+		names = append(names, node.Name)
+	}
+	for _, rel := range graph.Relation {
+		names = append(names, rel.Node1, rel.Node2)
+	}
+	canonical, err := s.aliasRepo.GetCanonicalNames(ctx, tenantID, kbID, names)
+	if err != nil {
+		logger.Warnf(ctx, "failed to get canonical names, aliases are not applied: %v", err)
+		return
+	}
+	canonicalizeGraph(graph, canonical)
+}
+
+// applyTombstones drops the extracted entities and relations deleted through the API
+func (s *ChunkExtractService) applyTombstones(ctx context.Context,
+	tenantID uint64, kbID string, graph *types.GraphData,
+) {
+	tombstones, err := s.tombstoneRepo.ListTombstones(ctx, tenantID, kbID)
+	if err != nil {
+		logger.Warnf(ctx, "failed to list graph tombstones, deleted entities may come back: %v", err)
+		return
+	}
+	if dropped := graphedit.Filter(graph, tombstones); dropped > 0 {
+		logger.Infof(ctx, "dropped %d deleted entities and relations of the extracted graph", dropped)
+	}
+}
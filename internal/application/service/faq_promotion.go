@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// faqPromotionCandidateCount is the number of similar entries returned with a promotion
+	faqPromotionCandidateCount = 5
+	// faqPromotionCandidateThreshold is the minimum similarity of a returned similar entry
+	faqPromotionCandidateThreshold = 0.5
+)
+
+// faqPromotionService implements the FAQ promotion service interface
+type faqPromotionService struct {
+	repo             interfaces.FAQPromotionRepository
+	knowledgeService interfaces.KnowledgeService
+	messageService   interfaces.MessageService
+	feedbackService  interfaces.FeedbackService
+	chunkRepo        interfaces.ChunkRepository
+	userService      interfaces.UserService
+}
+
+// NewFAQPromotionService creates a new FAQ promotion service
+func NewFAQPromotionService(repo interfaces.FAQPromotionRepository,
+	knowledgeService interfaces.KnowledgeService,
+	messageService interfaces.MessageService,
+	feedbackService interfaces.FeedbackService,
+	chunkRepo interfaces.ChunkRepository,
+	userService interfaces.UserService,
+) interfaces.FAQPromotionService {
+	return &faqPromotionService{
+		repo:             repo,
+		knowledgeService: knowledgeService,
+		messageService:   messageService,
+		feedbackService:  feedbackService,
+		chunkRepo:        chunkRepo,
+		userService:      userService,
+	}
+}
+
+// Promote adds the exchange of an assistant message to a FAQ knowledge base. The question is checked
+// against the questions of the existing entries, an exact match is always a conflict and an entry at
+// least as similar as the threshold either receives the question as a similar question (auto and similar
+// mode) or is a conflict (new mode, unless forced).
+func (s *faqPromotionService) Promote(ctx context.Context,
+	kbID string, req *types.FAQPromotionRequest,
+) (*types.FAQPromotionResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := req.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+
+	message, err := s.messageService.GetMessage(ctx, req.SessionID, req.MessageID)
+	if err != nil {
+		return nil, werrors.NewNotFoundError("Message not found")
+	}
+	if message.Role != "assistant" {
+		return nil, werrors.NewBadRequestError("Only answers can be promoted")
+	}
+	question, answer, edited := s.resolveExchange(ctx, message, req)
+	if question == "" {
+		return nil, werrors.NewBadRequestError("The answer has no question, a question is required")
+	}
+	if answer == "" {
+		return nil, werrors.NewBadRequestError("The answer is empty, an answer is required")
+	}
+
+	previous, err := s.repo.GetPromotionByMessage(ctx, tenantID, kbID, message.ID)
+	if err == nil {
+		return nil, werrors.NewConflictError(
+			fmt.Sprintf("Message already promoted to FAQ entry %s", previous.EntryID))
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	// Searching also checks that the knowledge base is a FAQ knowledge base
+	candidates, err := s.knowledgeService.SearchFAQEntries(ctx, kbID, &types.FAQSearchRequest{
+		QueryText:       question,
+		VectorThreshold: faqPromotionCandidateThreshold,
+		MatchCount:      faqPromotionCandidateCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	if entryID, err := s.chunkRepo.FindFAQChunkByQuestion(ctx, tenantID, kbID, question); err != nil {
+		return nil, fmt.Errorf("failed to find existing FAQ question: %w", err)
+	} else if entryID != "" {
+		return nil, werrors.NewConflictError(fmt.Sprintf("Question already exists in FAQ entry %s", entryID))
+	}
+
+	var chosen *types.FAQEntry
+	if req.Mode == types.FAQPromotionModeSimilar && req.EntryID != "" {
+		if chosen, err = s.knowledgeService.GetFAQEntry(ctx, kbID, req.EntryID); err != nil {
+			return nil, err
+		}
+	}
+	action, entry, err := decidePromotion(req, candidates, chosen)
+	if err != nil {
+		return nil, err
+	}
+	var similarity float64
+	if len(candidates) > 0 {
+		similarity = candidates[0].Score
+	}
+	result := &types.FAQPromotionResult{
+		Action:     action,
+		DryRun:     req.DryRun,
+		Question:   question,
+		Answer:     answer,
+		Entry:      entry,
+		Candidates: candidates,
+	}
+	if req.DryRun {
+		return result, nil
+	}
+
+	promotion := &types.FAQPromotion{
+		ID:              uuid.New().String(),
+		TenantID:        tenantID,
+		KnowledgeBaseID: kbID,
+		SessionID:       req.SessionID,
+		MessageID:       message.ID,
+		Action:          result.Action,
+		Question:        question,
+		Answer:          answer,
+		Similarity:      similarity,
+	}
+	if user, err := s.userService.GetCurrentUser(ctx); err == nil && user != nil {
+		promotion.ReviewerID = user.ID
+	}
+	// Only an edited answer is worth adding, the original answer is already covered by the entry
+	if result.Action == types.FAQPromotionActionMerged && (!edited || slices.Contains(result.Entry.Answers, answer)) {
+		promotion.Answer = ""
+	}
+
+	// Recording the promotion first claims the message, a concurrent promotion of the same message conflicts
+	// on the unique index before it changes the knowledge base
+	if err := s.repo.CreatePromotion(ctx, promotion); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, werrors.NewConflictError("Message already promoted to this FAQ knowledge base")
+		}
+		logger.Errorf(ctx, "Failed to record promotion of message %s: %v", message.ID, err)
+		return nil, err
+	}
+	if result.Action == types.FAQPromotionActionCreated {
+		result.Entry, err = s.knowledgeService.CreateFAQEntry(ctx, kbID, &types.FAQEntryPayload{
+			StandardQuestion: question,
+			Answers:          []string{answer},
+			TagID:            req.TagID,
+		})
+	} else {
+		result.Entry, err = s.mergeIntoEntry(ctx, kbID, result.Entry, question, promotion.Answer)
+	}
+	if err != nil {
+		// Release the message so that the promotion can be retried
+		if err := s.repo.DeletePromotion(ctx, tenantID, promotion.ID); err != nil {
+			logger.Errorf(ctx, "Failed to delete promotion %s: %v", promotion.ID, err)
+		}
+		return nil, err
+	}
+	promotion.EntryID = result.Entry.ID
+	if err := s.repo.SetPromotionEntry(ctx, tenantID, promotion.ID, promotion.EntryID); err != nil {
+		logger.Errorf(ctx, "Failed to record entry of promotion %s: %v", promotion.ID, err)
+		return nil, err
+	}
+	result.Promotion = promotion
+	logger.Infof(ctx, "Message %s promoted to FAQ entry %s of knowledge base %s (%s)",
+		message.ID, promotion.EntryID, kbID, promotion.Action)
+	return result, nil
+}
+
+// ListPromotions lists the promotions into a FAQ knowledge base, newest first
+func (s *faqPromotionService) ListPromotions(ctx context.Context,
+	kbID string, page *types.Pagination,
+) (*types.PageResult, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	promotions, total, err := s.repo.ListPromotions(ctx, tenantID, kbID, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, promotions), nil
+}
+
+// resolveExchange returns the question and answer to promote and whether the answer differs from the
+// message. Edits of the request take precedence, then the correction of the message feedback.
+func (s *faqPromotionService) resolveExchange(ctx context.Context,
+	message *types.Message, req *types.FAQPromotionRequest,
+) (string, string, bool) {
+	question := strings.TrimSpace(req.Question)
+	if question == "" && message.ParentID != "" {
+		if parent, err := s.messageService.GetMessage(ctx, req.SessionID, message.ParentID); err == nil &&
+			parent.Role == "user" {
+			question = strings.TrimSpace(parent.Content)
+		}
+	}
+	answer := strings.TrimSpace(req.Answer)
+	if answer == "" {
+		if feedback, err := s.feedbackService.GetFeedback(ctx, req.SessionID, message.ID); err == nil {
+			answer = strings.TrimSpace(feedback.Correction)
+		}
+	}
+	if answer == "" {
+		return question, strings.TrimSpace(message.Content), false
+	}
+	return question, answer, answer != strings.TrimSpace(message.Content)
+}
+
+// decidePromotion returns the action of a promotion and the entry receiving the question, nil for a new
+// entry. candidates are sorted by descending similarity, chosen is the entry requested in similar mode.
+func decidePromotion(req *types.FAQPromotionRequest,
+	candidates []*types.FAQEntry, chosen *types.FAQEntry,
+) (types.FAQPromotionAction, *types.FAQEntry, error) {
+	var similarity float64
+	if len(candidates) > 0 {
+		similarity = candidates[0].Score
+	}
+	switch req.Mode {
+	case types.FAQPromotionModeSimilar:
+		if chosen != nil {
+			return types.FAQPromotionActionMerged, chosen, nil
+		}
+		if len(candidates) == 0 {
+			return "", nil, werrors.NewBadRequestError("No similar FAQ entry found, an entry ID is required")
+		}
+		return types.FAQPromotionActionMerged, candidates[0], nil
+	case types.FAQPromotionModeNew:
+		if len(candidates) > 0 && similarity >= req.Threshold && !req.Force {
+			return "", nil, werrors.NewConflictError(fmt.Sprintf(
+				"FAQ entry %s is a likely duplicate with similarity %.2f", candidates[0].ID, similarity))
+		}
+		return types.FAQPromotionActionCreated, nil, nil
+	default:
+		if len(candidates) > 0 && similarity >= req.Threshold {
+			return types.FAQPromotionActionMerged, candidates[0], nil
+		}
+		return types.FAQPromotionActionCreated, nil, nil
+	}
+}
+
+// mergeIntoEntry adds the question as a similar question of the entry and the answer, if not empty, as
+// one of its answers
+func (s *faqPromotionService) mergeIntoEntry(ctx context.Context,
+	kbID string, entry *types.FAQEntry, question string, answer string,
+) (*types.FAQEntry, error) {
+	isEnabled := entry.IsEnabled
+	payload := &types.FAQEntryPayload{
+		StandardQuestion:  entry.StandardQuestion,
+		SimilarQuestions:  append(slices.Clone(entry.SimilarQuestions), question),
+		NegativeQuestions: entry.NegativeQuestions,
+		Answers:           entry.Answers,
+		TagID:             entry.TagID,
+		IsEnabled:         &isEnabled,
+	}
+	if answer != "" {
+		payload.Answers = append(slices.Clone(entry.Answers), answer)
+	}
+	if err := s.knowledgeService.UpdateFAQEntry(ctx, kbID, entry.ID, payload); err != nil {
+		return nil, err
+	}
+	return s.knowledgeService.GetFAQEntry(ctx, kbID, entry.ID)
+}
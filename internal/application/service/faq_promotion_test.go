@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestDecidePromotion(t *testing.T) {
+	nearest := &types.FAQEntry{ID: "nearest", Score: 0.92}
+	far := &types.FAQEntry{ID: "far", Score: 0.6}
+	chosen := &types.FAQEntry{ID: "chosen"}
+	tests := []struct {
+		name       string
+		req        types.FAQPromotionRequest
+		candidates []*types.FAQEntry
+		chosen     *types.FAQEntry
+		action     types.FAQPromotionAction
+		entry      *types.FAQEntry
+		code       int
+	}{
+		{
+			name:       "auto merges above the threshold",
+			req:        types.FAQPromotionRequest{Mode: types.FAQPromotionModeAuto, Threshold: 0.9},
+			candidates: []*types.FAQEntry{nearest, far},
+			action:     types.FAQPromotionActionMerged,
+			entry:      nearest,
+		},
+		{
+			name:       "auto merges at the threshold",
+			req:        types.FAQPromotionRequest{Mode: types.FAQPromotionModeAuto, Threshold: 0.92},
+			candidates: []*types.FAQEntry{nearest},
+			action:     types.FAQPromotionActionMerged,
+			entry:      nearest,
+		},
+		{
+			name:       "auto creates below the threshold",
+			req:        types.FAQPromotionRequest{Mode: types.FAQPromotionModeAuto, Threshold: 0.95},
+			candidates: []*types.FAQEntry{nearest},
+			action:     types.FAQPromotionActionCreated,
+		},
+		{
+			name:   "auto creates without candidates",
+			req:    types.FAQPromotionRequest{Mode: types.FAQPromotionModeAuto, Threshold: 0.9},
+			action: types.FAQPromotionActionCreated,
+		},
+		{
+			name:       "new conflicts above the threshold",
+			req:        types.FAQPromotionRequest{Mode: types.FAQPromotionModeNew, Threshold: 0.9},
+			candidates: []*types.FAQEntry{nearest},
+			code:       http.StatusConflict,
+		},
+		{
+			name:       "new is forced above the threshold",
+			req:        types.FAQPromotionRequest{Mode: types.FAQPromotionModeNew, Threshold: 0.9, Force: true},
+			candidates: []*types.FAQEntry{nearest},
+			action:     types.FAQPromotionActionCreated,
+		},
+		{
+			name:       "new creates below the threshold",
+			req:        types.FAQPromotionRequest{Mode: types.FAQPromotionModeNew, Threshold: 0.9},
+			candidates: []*types.FAQEntry{far},
+			action:     types.FAQPromotionActionCreated,
+		},
+		{
+			name:       "similar merges into the chosen entry",
+			req:        types.FAQPromotionRequest{Mode: types.FAQPromotionModeSimilar, Threshold: 0.9},
+			candidates: []*types.FAQEntry{nearest},
+			chosen:     chosen,
+			action:     types.FAQPromotionActionMerged,
+			entry:      chosen,
+		},
+		{
+			name:       "similar merges into the closest entry below the threshold",
+			req:        types.FAQPromotionRequest{Mode: types.FAQPromotionModeSimilar, Threshold: 0.9},
+			candidates: []*types.FAQEntry{far},
+			action:     types.FAQPromotionActionMerged,
+			entry:      far,
+		},
+		{
+			name: "similar requires an entry",
+			req:  types.FAQPromotionRequest{Mode: types.FAQPromotionModeSimilar, Threshold: 0.9},
+			code: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, entry, err := decidePromotion(&tt.req, tt.candidates, tt.chosen)
+			if tt.code != 0 {
+				var appErr *werrors.AppError
+				if !errors.As(err, &appErr) || appErr.HTTPCode != tt.code {
+					t.Fatalf("expected HTTP %d, got %v", tt.code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if action != tt.action || entry != tt.entry {
+				t.Errorf("got %s into %v, want %s into %v", action, entry, tt.action, tt.entry)
+			}
+		})
+	}
+}
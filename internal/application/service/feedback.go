@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultFeedbackDashboardLimit is the number of knowledge and chunks listed by the dashboard by default
+	defaultFeedbackDashboardLimit = 20
+	// maxFeedbackDashboardLimit caps the number of knowledge and chunks listed by the dashboard
+	maxFeedbackDashboardLimit = 100
+)
+
+// feedbackService implements the message feedback service interface
+type feedbackService struct {
+	repo           interfaces.FeedbackRepository
+	messageService interfaces.MessageService
+	chunkRepo      interfaces.ChunkRepository
+	datasetService interfaces.DatasetService
+}
+
+// NewFeedbackService creates a new message feedback service
+func NewFeedbackService(repo interfaces.FeedbackRepository,
+	messageService interfaces.MessageService,
+	chunkRepo interfaces.ChunkRepository,
+	datasetService interfaces.DatasetService,
+) interfaces.FeedbackService {
+	return &feedbackService{
+		repo:           repo,
+		messageService: messageService,
+		chunkRepo:      chunkRepo,
+		datasetService: datasetService,
+	}
+}
+
+// SubmitFeedback records the feedback on an assistant message. Every knowledge chunk referenced by the
+// message gets a vote according to the rating and the references marked useful, references that are no
+// longer stored chunks, e.g. web search results, get no vote.
+func (s *feedbackService) SubmitFeedback(ctx context.Context,
+	feedback *types.MessageFeedback,
+) (*types.MessageFeedback, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if err := feedback.Rating.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+	message, err := s.messageService.GetMessage(ctx, feedback.SessionID, feedback.MessageID)
+	if err != nil {
+		return nil, werrors.NewNotFoundError("Message not found")
+	}
+	if message.Role != "assistant" {
+		return nil, werrors.NewBadRequestError("Only answers can be rated")
+	}
+
+	referenced := make(map[string]bool, len(message.KnowledgeReferences))
+	var chunkIDs []string
+	for _, reference := range message.KnowledgeReferences {
+		if !referenced[reference.ID] {
+			referenced[reference.ID] = true
+			chunkIDs = append(chunkIDs, reference.ID)
+		}
+	}
+	useful := make(map[string]bool, len(feedback.UsefulChunkIDs))
+	usefulChunkIDs := make(types.StringArray, 0, len(feedback.UsefulChunkIDs))
+	for _, chunkID := range feedback.UsefulChunkIDs {
+		if !referenced[chunkID] {
+			return nil, werrors.NewBadRequestError(fmt.Sprintf("chunk %s is not referenced by the message", chunkID))
+		}
+		if !useful[chunkID] {
+			useful[chunkID] = true
+			usefulChunkIDs = append(usefulChunkIDs, chunkID)
+		}
+	}
+
+	feedback.ID = uuid.New().String()
+	feedback.TenantID = tenantID
+	feedback.UsefulChunkIDs = usefulChunkIDs
+	feedback.Answer = message.Content
+	feedback.Query = ""
+	if message.ParentID != "" {
+		if question, err := s.messageService.GetMessage(ctx, feedback.SessionID, message.ParentID); err == nil &&
+			question.Role == "user" {
+			feedback.Query = question.Content
+		}
+	}
+
+	feedback.Chunks = nil
+	if len(chunkIDs) > 0 {
+		chunks, err := s.chunkRepo.ListChunksByID(ctx, tenantID, chunkIDs)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to get chunks referenced by message %s: %v", message.ID, err)
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			feedback.Chunks = append(feedback.Chunks, &types.FeedbackChunk{
+				KnowledgeBaseID: chunk.KnowledgeBaseID,
+				KnowledgeID:     chunk.KnowledgeID,
+				ChunkID:         chunk.ID,
+				Vote:            types.FeedbackVote(feedback.Rating, len(useful) > 0, useful[chunk.ID]),
+			})
+		}
+	}
+
+	if err := s.repo.SaveFeedback(ctx, feedback); err != nil {
+		logger.Errorf(ctx, "Failed to save feedback on message %s: %v", message.ID, err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Feedback %s saved on message %s with %d chunk votes",
+		feedback.Rating, message.ID, len(feedback.Chunks))
+	return feedback, nil
+}
+
+// GetFeedback gets the feedback on a message
+func (s *feedbackService) GetFeedback(ctx context.Context,
+	sessionID string, messageID string,
+) (*types.MessageFeedback, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	feedback, err := s.repo.GetFeedbackByMessage(ctx, tenantID, messageID)
+	if err == gorm.ErrRecordNotFound || (err == nil && feedback.SessionID != sessionID) {
+		return nil, werrors.NewNotFoundError("Feedback not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// DeleteFeedback deletes the feedback on a message
+func (s *feedbackService) DeleteFeedback(ctx context.Context, sessionID string, messageID string) error {
+	if _, err := s.GetFeedback(ctx, sessionID, messageID); err != nil {
+		return err
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	return s.repo.DeleteFeedbackByMessage(ctx, tenantID, messageID)
+}
+
+// GetChunkScores returns the feedback score of the chunks with feedback
+func (s *feedbackService) GetChunkScores(ctx context.Context, chunkIDs []string) (map[string]float64, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	stats, err := s.repo.ChunkStats(ctx, tenantID, chunkIDs)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(stats))
+	for _, stat := range stats {
+		scores[stat.ChunkID] = stat.Score
+	}
+	return scores, nil
+}
+
+// GetDashboard aggregates the feedback on a knowledge base, the knowledge and chunks with the lowest
+// feedback scores are the first candidates for fixing
+func (s *feedbackService) GetDashboard(ctx context.Context,
+	knowledgeBaseID string, limit int,
+) (*types.FeedbackDashboard, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if limit <= 0 {
+		limit = defaultFeedbackDashboardLimit
+	}
+	limit = min(limit, maxFeedbackDashboardLimit)
+
+	summary, err := s.repo.SummarizeKnowledgeBase(ctx, tenantID, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	knowledge, err := s.repo.WorstKnowledge(ctx, tenantID, knowledgeBaseID, limit)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := s.repo.WorstChunks(ctx, tenantID, knowledgeBaseID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &types.FeedbackDashboard{
+		KnowledgeBaseID: knowledgeBaseID,
+		Summary:         *summary,
+		Knowledge:       knowledge,
+		Chunks:          chunks,
+	}, nil
+}
+
+// ExportDataset saves the feedback on a knowledge base as an evaluation dataset. Each corrected or upvoted
+// answer becomes a QA pair of its question, the correction or else the upvoted answer, and the current
+// content of its chunks in the knowledge base with a positive vote. Feedback without a reference answer
+// or such a chunk is skipped.
+func (s *feedbackService) ExportDataset(ctx context.Context,
+	knowledgeBaseID string,
+) (*types.FeedbackDatasetExport, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	feedbacks, err := s.repo.ListFeedbackByKnowledgeBase(ctx, tenantID, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunkIDs []string
+	for _, feedback := range feedbacks {
+		for _, chunk := range feedback.Chunks {
+			if chunk.Vote > 0 && chunk.KnowledgeBaseID == knowledgeBaseID {
+				chunkIDs = append(chunkIDs, chunk.ChunkID)
+			}
+		}
+	}
+	contents := make(map[string]string)
+	if len(chunkIDs) > 0 {
+		chunks, err := s.chunkRepo.ListChunksByID(ctx, tenantID, chunkIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			contents[chunk.ID] = chunk.Content
+		}
+	}
+
+	export := &types.FeedbackDatasetExport{}
+	var pairs []*types.QAPair
+	passageIDs := make(map[string]int)
+	for _, feedback := range feedbacks {
+		answer := feedback.Correction
+		if answer == "" && feedback.Rating == types.FeedbackRatingUp {
+			answer = feedback.Answer
+		}
+		if feedback.Query == "" || answer == "" {
+			export.Skipped++
+			continue
+		}
+		pair := &types.QAPair{Question: feedback.Query, Answer: answer}
+		for _, chunk := range feedback.Chunks {
+			content, ok := contents[chunk.ChunkID]
+			if chunk.Vote <= 0 || chunk.KnowledgeBaseID != knowledgeBaseID || !ok {
+				continue
+			}
+			pid, ok := passageIDs[chunk.ChunkID]
+			if !ok {
+				pid = len(passageIDs) + 1
+				passageIDs[chunk.ChunkID] = pid
+			}
+			pair.PIDs = append(pair.PIDs, pid)
+			pair.Passages = append(pair.Passages, content)
+		}
+		if len(pair.PIDs) == 0 {
+			export.Skipped++
+			continue
+		}
+		pair.QID = len(pairs) + 1
+		pair.AID = pair.QID
+		pairs = append(pairs, pair)
+	}
+	if len(pairs) == 0 {
+		return nil, werrors.NewBadRequestError("No feedback with a reference answer and a useful chunk to export")
+	}
+
+	export.DatasetID = "feedback-" + uuid.New().String()
+	if err := s.datasetService.SaveDataset(ctx, export.DatasetID, pairs); err != nil {
+		logger.Errorf(ctx, "Failed to save feedback dataset of knowledge base %s: %v", knowledgeBaseID, err)
+		return nil, err
+	}
+	export.QuestionCount = len(pairs)
+	export.PassageCount = len(passageIDs)
+	logger.Infof(ctx, "Exported %d QA pairs of knowledge base %s as dataset %s, skipped %d",
+		len(pairs), knowledgeBaseID, export.DatasetID, export.Skipped)
+	return export, nil
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/community"
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/graphedit"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// communityDetectionDelay postpones the detection after an extraction until the documents being imported
+	// are likely extracted, the task ID keeps a single detection queued
+	communityDetectionDelay = 10 * time.Minute
+	// maxCommunityRelations caps the relations of a community given to the chat model
+	maxCommunityRelations = 100
+	// maxCommunitySubReports caps the reports of nested communities given to the chat model
+	maxCommunitySubReports = 10
+)
+
+// NewCommunityDetectionTask queues the detection of the communities of the knowledge base after the delay.
+// A detection already queued is not queued twice.
+func NewCommunityDetectionTask(ctx context.Context,
+	client *asynq.Client, tenantID uint64, kbID string, delay time.Duration,
+) error {
+	payload, err := json.Marshal(types.CommunityDetectionPayload{
+		TenantID:        tenantID,
+		KnowledgeBaseID: kbID,
+	})
+	if err != nil {
+		return err
+	}
+	info, err := client.Enqueue(asynq.NewTask(types.TypeCommunityDetection, payload,
+		asynq.Queue("low"), asynq.MaxRetry(3), asynq.ProcessIn(delay),
+		asynq.TaskID(types.TypeCommunityDetection+":"+kbID),
+	))
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+	if err != nil {
+		logger.Errorf(ctx, "failed to enqueue community detection task: %v", err)
+		return fmt.Errorf("failed to enqueue task: %v", err)
+	}
+	logger.Infof(ctx, "enqueued community detection task: id=%s knowledge_base=%s", info.ID, kbID)
+	return nil
+}
+
+// graphCommunityService implements the graph community service interface. The reports of a knowledge base are
+// chunks of type community_report without knowledge, replaced as a whole by each detection.
+type graphCommunityService struct {
+	config       *config.Config
+	graphRepo    interfaces.RetrieveGraphRepository
+	chunkRepo    interfaces.ChunkRepository
+	kbService    interfaces.KnowledgeBaseService
+	modelService interfaces.ModelService
+	task         *asynq.Client
+}
+
+// NewGraphCommunityService creates a new graph community service
+func NewGraphCommunityService(config *config.Config,
+	graphRepo interfaces.RetrieveGraphRepository,
+	chunkRepo interfaces.ChunkRepository,
+	kbService interfaces.KnowledgeBaseService,
+	modelService interfaces.ModelService,
+	task *asynq.Client,
+) interfaces.GraphCommunityService {
+	return &graphCommunityService{
+		config:       config,
+		graphRepo:    graphRepo,
+		chunkRepo:    chunkRepo,
+		kbService:    kbService,
+		modelService: modelService,
+		task:         task,
+	}
+}
+
+// communityConfig returns the community configuration, nil if not configured
+func (s *graphCommunityService) communityConfig() *types.GraphCommunityConfig {
+	if s.config.ExtractManager == nil {
+		return nil
+	}
+	return s.config.ExtractManager.Communities
+}
+
+// DetectCommunities handles the community detection task
+func (s *graphCommunityService) DetectCommunities(ctx context.Context, t *asynq.Task) error {
+	var p types.CommunityDetectionPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		logger.Errorf(ctx, "failed to unmarshal task payload: %v", err)
+		return err
+	}
+	ctx = logger.WithRequestID(ctx, uuid.New().String())
+	ctx = logger.WithField(ctx, "community_detection", p.KnowledgeBaseID)
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, p.TenantID)
+
+	if !s.communityConfig().IsEnabled() || !config.IsGraphEnabled() {
+		logger.Warn(ctx, "Community detection is not enabled, skip community detection task")
+		return nil
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, p.KnowledgeBaseID)
+	if err != nil {
+		logger.Warnf(ctx, "community detection ignores knowledge base %s: %v", p.KnowledgeBaseID, err)
+		return nil
+	}
+	return s.detect(ctx, kb)
+}
+
+// detect clusters the graph of the knowledge base, summarizes the communities from the finest level up so the
+// report of a community builds on the reports of its nested communities, and replaces the reports. Communities
+// of the same entities as a community of the previous detection keep its report, so only new or changed
+// communities are summarized.
+func (s *graphCommunityService) detect(ctx context.Context, kb *types.KnowledgeBase) error {
+	cfg := s.communityConfig().WithDefaults()
+	if kb.SummaryModelID == "" {
+		logger.Warnf(ctx, "knowledge base %s has no summary model, skip community detection", kb.ID)
+		return nil
+	}
+	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	if err != nil {
+		return fmt.Errorf("failed to get chat model: %w", err)
+	}
+
+	namespace := types.NameSpace{KnowledgeBase: kb.ID}
+	nodes, err := s.graphRepo.ListNodes(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	relations, err := s.graphRepo.ListRelations(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	ranked := graphedit.Rank(nodes, relations, "")
+	names := make([]string, 0, len(ranked))
+	for _, node := range ranked {
+		names = append(names, node.Name)
+	}
+	edges := make([]community.Edge, 0, len(relations))
+	for _, rel := range relations {
+		edges = append(edges, community.Edge{Source: rel.Node1, Target: rel.Node2, Weight: 1})
+	}
+
+	var communities []*community.Community
+	byID := make(map[string]*community.Community)
+	deepest := -1
+	for _, c := range community.Detect(names, edges, cfg.Resolution, cfg.MaxLevels) {
+		if len(c.Members) >= cfg.MinSize {
+			communities = append(communities, c)
+			byID[c.ID] = c
+			deepest = max(deepest, c.Level)
+		}
+	}
+	logger.Infof(ctx, "detected %d communities of %d entities in knowledge base %s",
+		len(communities), len(names), kb.ID)
+
+	previous, err := s.chunkRepo.ListChunksByKnowledgeBaseIDAndType(ctx,
+		kb.TenantID, kb.ID, types.ChunkTypeCommunityReport)
+	if err != nil {
+		return err
+	}
+	reusable := reusableReports(previous)
+
+	summarizer := community.NewSummarizer(chatModel)
+	reports := make(map[string]*community.Report, len(communities))
+	var failed []*community.Community
+	reused := 0
+	for level := deepest; level >= 0; level-- {
+		var pending []*community.Community
+		var inputs []*community.ReportInput
+		for _, c := range communities {
+			if c.Level != level {
+				continue
+			}
+			if report, ok := reusable[membersHash(c.Members)]; ok {
+				reports[c.ID] = report
+				reused++
+				continue
+			}
+			// A community that merged nothing at this level has the report of its single nested community
+			if len(c.Children) == 1 {
+				child, ok := byID[c.Children[0]]
+				if report := reports[c.Children[0]]; ok && report != nil && len(child.Members) == len(c.Members) {
+					reports[c.ID] = report
+					continue
+				}
+			}
+			pending = append(pending, c)
+			inputs = append(inputs, s.reportInput(c, ranked, relations, reports, cfg.ReportEntities))
+		}
+		results := make([]*community.Report, len(pending))
+		group, groupCtx := errgroup.WithContext(ctx)
+		group.SetLimit(cfg.Concurrency)
+		for i, c := range pending {
+			group.Go(func() error {
+				report, err := summarizer.Summarize(groupCtx, inputs[i])
+				if err != nil {
+					logger.Warnf(groupCtx, "failed to summarize community %s: %v", c.ID, err)
+					return nil
+				}
+				results[i] = report
+				return nil
+			})
+		}
+		_ = group.Wait()
+		for i, c := range pending {
+			if results[i] == nil {
+				failed = append(failed, c)
+				continue
+			}
+			reports[c.ID] = results[i]
+		}
+	}
+	if len(failed) > 0 && len(reports) == 0 {
+		return fmt.Errorf("failed to summarize all %d communities", len(failed))
+	}
+	logger.Infof(ctx, "reused %d unchanged community reports of knowledge base %s", reused, kb.ID)
+	return s.replaceReports(ctx, kb, communities, reports, keptReports(previous, failed), ranked, cfg.ReportEntities)
+}
+
+// keptReports returns the IDs of the previous reports that share an entity with a community of the same level
+// that failed to summarize, so they stay searchable until a later detection summarizes the community
+func keptReports(previous []*types.Chunk, failed []*community.Community) []string {
+	if len(failed) == 0 {
+		return nil
+	}
+	var kept []string
+	for _, chunk := range previous {
+		meta, err := chunk.CommunityReportMetadata()
+		if err != nil || meta == nil {
+			continue
+		}
+		for _, c := range failed {
+			if c.Level == meta.Level && slices.ContainsFunc(meta.Entities, func(entity string) bool {
+				return slices.Contains(c.Members, entity)
+			}) {
+				kept = append(kept, chunk.ID)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// reusableReports returns the reports of the previous detection by the hash of the entities of their community
+func reusableReports(chunks []*types.Chunk) map[string]*community.Report {
+	reports := make(map[string]*community.Report, len(chunks))
+	for _, chunk := range chunks {
+		meta, err := chunk.CommunityReportMetadata()
+		// Reports stored before the hash was kept cannot be matched
+		if err != nil || meta == nil || meta.MembersHash == "" || meta.Summary == "" {
+			continue
+		}
+		reports[meta.MembersHash] = &community.Report{
+			Title:    meta.Title,
+			Summary:  meta.Summary,
+			Findings: meta.Findings,
+			Rating:   meta.Rating,
+		}
+	}
+	return reports
+}
+
+// membersHash identifies the sorted entities of a community
+func membersHash(members []string) string {
+	hash := sha256.Sum256([]byte(strings.Join(members, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// reportInput returns the most connected entities of the community, the relations among them and the reports
+// of the nested communities, best rated first
+func (s *graphCommunityService) reportInput(c *community.Community,
+	ranked []*types.GraphNode, relations []*types.GraphRelation,
+	reports map[string]*community.Report, maxEntities int,
+) *community.ReportInput {
+	input := &community.ReportInput{Entities: topMembers(c, ranked, maxEntities)}
+	input.Relations = graphedit.Among(relations, input.Entities)
+	if len(input.Relations) > maxCommunityRelations {
+		input.Relations = input.Relations[:maxCommunityRelations]
+	}
+	for _, child := range c.Children {
+		if report, ok := reports[child]; ok {
+			input.SubReports = append(input.SubReports, report)
+		}
+	}
+	sort.SliceStable(input.SubReports, func(i, j int) bool {
+		return input.SubReports[i].Rating > input.SubReports[j].Rating
+	})
+	if len(input.SubReports) > maxCommunitySubReports {
+		input.SubReports = input.SubReports[:maxCommunitySubReports]
+	}
+	return input
+}
+
+// replaceReports stores the reports as chunks of the knowledge base in place of the reports of the previous
+// detection, except the kept ones
+func (s *graphCommunityService) replaceReports(ctx context.Context, kb *types.KnowledgeBase,
+	communities []*community.Community, reports map[string]*community.Report,
+	kept []string, ranked []*types.GraphNode, maxEntities int,
+) error {
+	chunks := make([]*types.Chunk, 0, len(reports))
+	for _, c := range communities {
+		report, ok := reports[c.ID]
+		if !ok {
+			continue
+		}
+		entities := topMembers(c, ranked, maxEntities)
+		meta := &types.CommunityReportMetadata{
+			CommunityID: c.ID,
+			Level:       c.Level,
+			Parent:      c.Parent,
+			Title:       report.Title,
+			Rating:      report.Rating,
+			Size:        len(c.Members),
+			Entities:    make([]string, 0, len(entities)),
+			Summary:     report.Summary,
+			Findings:    report.Findings,
+			MembersHash: membersHash(c.Members),
+		}
+		for _, entity := range entities {
+			meta.Entities = append(meta.Entities, entity.Name)
+		}
+		content := report.Content()
+		chunk := &types.Chunk{
+			ID:              uuid.New().String(),
+			TenantID:        kb.TenantID,
+			KnowledgeBaseID: kb.ID,
+			Content:         content,
+			ChunkIndex:      len(chunks),
+			IsEnabled:       true,
+			Status:          int(types.ChunkStatusStored),
+			EndAt:           len([]rune(content)),
+			ChunkType:       types.ChunkTypeCommunityReport,
+		}
+		if err := chunk.SetCommunityReportMetadata(meta); err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := s.chunkRepo.ReplaceChunksByType(ctx,
+		kb.TenantID, kb.ID, types.ChunkTypeCommunityReport, chunks, kept); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "stored %d community reports of knowledge base %s, kept %d previous reports",
+		len(chunks), kb.ID, len(kept))
+	return nil
+}
+
+// EnqueueDetection queues the detection of the communities of a knowledge base
+func (s *graphCommunityService) EnqueueDetection(ctx context.Context, kbID string) error {
+	if !config.IsGraphEnabled() {
+		return werrors.NewBadRequestError("Knowledge graph is not enabled")
+	}
+	if !s.communityConfig().IsEnabled() {
+		return werrors.NewBadRequestError("Community detection is not enabled")
+	}
+	kb, err := getTenantKnowledgeBase(ctx, s.kbService, kbID)
+	if err != nil {
+		return err
+	}
+	return NewCommunityDetectionTask(ctx, s.task, kb.TenantID, kb.ID, 0)
+}
+
+// ListCommunities lists the communities of a knowledge base, by level and then best rated first
+func (s *graphCommunityService) ListCommunities(ctx context.Context,
+	kbID string, req *types.GraphCommunityListRequest,
+) ([]*types.GraphCommunity, error) {
+	kb, err := getTenantKnowledgeBase(ctx, s.kbService, kbID)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := s.chunkRepo.ListChunksByKnowledgeBaseIDAndType(ctx,
+		kb.TenantID, kb.ID, types.ChunkTypeCommunityReport)
+	if err != nil {
+		return nil, err
+	}
+	communities := make([]*types.GraphCommunity, 0, len(chunks))
+	for _, chunk := range chunks {
+		meta, err := chunk.CommunityReportMetadata()
+		if err != nil || meta == nil {
+			logger.Warnf(ctx, "community report %s without metadata: %v", chunk.ID, err)
+			continue
+		}
+		if req.Level != nil && meta.Level != *req.Level {
+			continue
+		}
+		communities = append(communities, &types.GraphCommunity{
+			ID:                      chunk.ID,
+			CommunityReportMetadata: *meta,
+			Report:                  chunk.Content,
+			CreatedAt:               chunk.CreatedAt,
+		})
+	}
+	sort.SliceStable(communities, func(i, j int) bool {
+		if communities[i].Level != communities[j].Level {
+			return communities[i].Level < communities[j].Level
+		}
+		return communities[i].Rating > communities[j].Rating
+	})
+	return communities, nil
+}
+
+// topMembers returns the most connected entities of the community
+func topMembers(c *community.Community, ranked []*types.GraphNode, limit int) []*types.GraphNode {
+	members := make(map[string]bool, len(c.Members))
+	for _, name := range c.Members {
+		members[name] = true
+	}
+	result := make([]*types.GraphNode, 0, min(len(c.Members), limit))
+	for _, node := range ranked {
+		if len(result) == limit {
+			break
+		}
+		if members[node.Name] {
+			result = append(result, node)
+		}
+	}
+	return result
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func (r *fakeChunkRepository) ListChunksByKnowledgeBaseIDAndType(ctx context.Context,
+	tenantID uint64, kbID string, chunkType types.ChunkType,
+) ([]*types.Chunk, error) {
+	var chunks []*types.Chunk
+	for _, chunk := range r.chunks {
+		if chunk.KnowledgeBaseID == kbID && chunk.ChunkType == chunkType {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+func (r *fakeChunkRepository) ReplaceChunksByType(ctx context.Context, tenantID uint64, kbID string,
+	chunkType types.ChunkType, chunks []*types.Chunk, keepIDs []string,
+) error {
+	for id, chunk := range r.chunks {
+		if chunk.KnowledgeBaseID == kbID && chunk.ChunkType == chunkType && !slices.Contains(keepIDs, id) {
+			delete(r.chunks, id)
+		}
+	}
+	for _, chunk := range chunks {
+		r.chunks[chunk.ID] = chunk
+	}
+	return nil
+}
+
+// fakeGraphRepository serves a fixed graph
+type fakeGraphRepository struct {
+	interfaces.RetrieveGraphRepository
+	graph *types.GraphData
+}
+
+func (r *fakeGraphRepository) ListNodes(ctx context.Context, namespace types.NameSpace) ([]*types.GraphNode, error) {
+	nodes := make([]*types.GraphNode, 0, len(r.graph.Node))
+	for _, node := range r.graph.Node {
+		nodes = append(nodes, &types.GraphNode{Name: node.Name})
+	}
+	return nodes, nil
+}
+
+func (r *fakeGraphRepository) ListRelations(ctx context.Context,
+	namespace types.NameSpace,
+) ([]*types.GraphRelation, error) {
+	return r.graph.Relation, nil
+}
+
+// fakeModelService returns the chat model of every model ID
+type fakeModelService struct {
+	interfaces.ModelService
+	model chat.Chat
+}
+
+func (s *fakeModelService) GetChatModel(ctx context.Context, modelID string) (chat.Chat, error) {
+	return s.model, nil
+}
+
+// countingChat writes a report of a new title for each request, or fails every request if fail is set
+type countingChat struct {
+	mu    sync.Mutex
+	calls int
+	fail  bool
+}
+
+func (c *countingChat) Chat(ctx context.Context,
+	messages []chat.Message, opts *chat.ChatOptions,
+) (*types.ChatResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.fail {
+		return nil, errors.New("timeout")
+	}
+	return &types.ChatResponse{Content: fmt.Sprintf(
+		`{"title": "report %d", "summary": "summary %d", "findings": ["finding"], "rating": 5}`, c.calls, c.calls,
+	)}, nil
+}
+
+func (c *countingChat) ChatStream(ctx context.Context,
+	messages []chat.Message, opts *chat.ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *countingChat) GetModelName() string { return "counting" }
+
+func (c *countingChat) GetModelID() string { return "counting" }
+
+// triangle returns the nodes and relations of entities related to each other
+func triangle(graph *types.GraphData, names ...string) {
+	for i, name := range names {
+		graph.Node = append(graph.Node, &types.GraphNode{Name: name})
+		for _, other := range names[i+1:] {
+			graph.Relation = append(graph.Relation, &types.GraphRelation{Node1: name, Node2: other, Type: "related"})
+		}
+	}
+}
+
+// reportTitles returns the report titles of the stored communities by their most connected entities
+func reportTitles(t *testing.T, repo *fakeChunkRepository) map[string]string {
+	t.Helper()
+	titles := make(map[string]string)
+	for _, chunk := range repo.chunks {
+		meta, err := chunk.CommunityReportMetadata()
+		if err != nil || meta == nil {
+			t.Fatalf("report %s without metadata: %v", chunk.ID, err)
+		}
+		entities := slices.Clone(meta.Entities)
+		slices.Sort(entities)
+		titles[fmt.Sprint(entities)] = meta.Title
+	}
+	return titles
+}
+
+// newCommunityTestService returns a community service over the graph, summarizing with the model
+func newCommunityTestService(graph *types.GraphData, model chat.Chat) (*graphCommunityService, *fakeChunkRepository) {
+	chunks := &fakeChunkRepository{chunks: map[string]*types.Chunk{}}
+	return &graphCommunityService{
+		config: &config.Config{ExtractManager: &config.ExtractManagerConfig{
+			Communities: &types.GraphCommunityConfig{Enabled: true},
+		}},
+		graphRepo:    &fakeGraphRepository{graph: graph},
+		chunkRepo:    chunks,
+		modelService: &fakeModelService{model: model},
+	}, chunks
+}
+
+// joinB adds the entity b4 to the b community
+func joinB(graph *types.GraphData) {
+	graph.Node = append(graph.Node, &types.GraphNode{Name: "b4"})
+	graph.Relation = append(graph.Relation,
+		&types.GraphRelation{Node1: "b4", Node2: "b1", Type: "related"},
+		&types.GraphRelation{Node1: "b4", Node2: "b2", Type: "related"})
+}
+
+func TestDetectReusesUnchangedCommunities(t *testing.T) {
+	graph := &types.GraphData{}
+	triangle(graph, "a1", "a2", "a3")
+	triangle(graph, "b1", "b2", "b3")
+	model := &countingChat{}
+	service, chunks := newCommunityTestService(graph, model)
+	kb := &types.KnowledgeBase{ID: "kb1", TenantID: 1, SummaryModelID: "m1"}
+	ctx := context.Background()
+
+	if err := service.detect(ctx, kb); err != nil {
+		t.Fatalf("first detection failed: %v", err)
+	}
+	first := reportTitles(t, chunks)
+	if model.calls == 0 || len(first) == 0 {
+		t.Fatalf("expected the communities to be summarized, got %d calls and reports %v", model.calls, first)
+	}
+
+	// Nothing changed, every report is reused
+	calls := model.calls
+	if err := service.detect(ctx, kb); err != nil {
+		t.Fatalf("second detection failed: %v", err)
+	}
+	if model.calls != calls {
+		t.Errorf("expected no summary of unchanged communities, got %d calls", model.calls-calls)
+	}
+	if second := reportTitles(t, chunks); fmt.Sprint(second) != fmt.Sprint(first) {
+		t.Errorf("reports changed from %v to %v", first, second)
+	}
+
+	// A new entity joins the b community, the a community keeps its report
+	joinB(graph)
+	calls = model.calls
+	if err := service.detect(ctx, kb); err != nil {
+		t.Fatalf("third detection failed: %v", err)
+	}
+	if model.calls == calls {
+		t.Error("expected the changed community to be summarized")
+	}
+	third := reportTitles(t, chunks)
+	key := fmt.Sprint([]string{"a1", "a2", "a3"})
+	if third[key] == "" || third[key] != first[key] {
+		t.Errorf("report of the unchanged community changed from %q to %q", first[key], third[key])
+	}
+	for entities, title := range third {
+		if entities != key && title == first[fmt.Sprint([]string{"b1", "b2", "b3"})] {
+			t.Errorf("report %q of the changed community was reused", title)
+		}
+	}
+}
+
+func TestDetectKeepsReportsOfFailedCommunities(t *testing.T) {
+	graph := &types.GraphData{}
+	triangle(graph, "a1", "a2", "a3")
+	triangle(graph, "b1", "b2", "b3")
+	model := &countingChat{}
+	service, chunks := newCommunityTestService(graph, model)
+	kb := &types.KnowledgeBase{ID: "kb1", TenantID: 1, SummaryModelID: "m1"}
+	ctx := context.Background()
+
+	if err := service.detect(ctx, kb); err != nil {
+		t.Fatalf("first detection failed: %v", err)
+	}
+	first := reportTitles(t, chunks)
+	oldB := fmt.Sprint([]string{"b1", "b2", "b3"})
+
+	// The changed b community fails to summarize, its previous report stays
+	joinB(graph)
+	model.fail = true
+	if err := service.detect(ctx, kb); err != nil {
+		t.Fatalf("second detection failed: %v", err)
+	}
+	second := reportTitles(t, chunks)
+	if second[oldB] == "" || second[oldB] != first[oldB] {
+		t.Errorf("report of the failed community changed from %q to %q", first[oldB], second[oldB])
+	}
+	if len(second) != 2 {
+		t.Errorf("expected the reused and the kept report, got %v", second)
+	}
+
+	// A later detection summarizes it and drops the previous report
+	model.fail = false
+	if err := service.detect(ctx, kb); err != nil {
+		t.Fatalf("third detection failed: %v", err)
+	}
+	third := reportTitles(t, chunks)
+	if _, ok := third[oldB]; ok {
+		t.Errorf("previous report of the b community was not replaced: %v", third)
+	}
+	if len(third) != 2 || len(chunks.chunks) != 2 {
+		t.Errorf("expected one report per community, got %v", third)
+	}
+}
@@ -66,6 +66,7 @@ type knowledgeService struct {
 	modelService    interfaces.ModelService
 	task            *asynq.Client
 	graphEngine     interfaces.RetrieveGraphRepository
+	answerCache     interfaces.AnswerCache
 }
 
 const (
@@ -89,6 +90,7 @@ func NewKnowledgeService(
 	task *asynq.Client,
 	graphEngine interfaces.RetrieveGraphRepository,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
+	answerCache interfaces.AnswerCache,
 ) (interfaces.KnowledgeService, error) {
 	return &knowledgeService{
 		config:          config,
@@ -104,6 +106,7 @@ func NewKnowledgeService(
 		task:            task,
 		graphEngine:     graphEngine,
 		retrieveEngine:  retrieveEngine,
+		answerCache:     answerCache,
 	}, nil
 }
 
@@ -117,6 +120,13 @@ func (s *knowledgeService) GetRepository() interfaces.KnowledgeRepository {
 	return s.repo
 }
 
+// invalidateAnswerCache drops the cached answers of the knowledge bases after their knowledge changed
+func (s *knowledgeService) invalidateAnswerCache(ctx context.Context, kbIDs ...string) {
+	if err := s.answerCache.Invalidate(ctx, kbIDs...); err != nil {
+		logger.Warnf(ctx, "Failed to invalidate answer cache of knowledge bases %v: %v", kbIDs, err)
+	}
+}
+
 // isKnowledgeDeleting checks if a knowledge entry is being deleted.
 // This is used to prevent async tasks from conflicting with deletion operations.
 func (s *knowledgeService) isKnowledgeDeleting(ctx context.Context, tenantID uint64, knowledgeID string) bool {
@@ -792,6 +802,7 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 	if err = wg.Wait(); err != nil {
 		return err
 	}
+	s.invalidateAnswerCache(ctx, knowledge.KnowledgeBaseID)
 	// Delete the knowledge entry itself from the database
 	return s.repo.DeleteKnowledge(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
 }
@@ -898,6 +909,11 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 	if err = wg.Wait(); err != nil {
 		return err
 	}
+	kbIDs := make([]string, 0, len(knowledgeList))
+	for _, knowledge := range knowledgeList {
+		kbIDs = append(kbIDs, knowledge.KnowledgeBaseID)
+	}
+	s.invalidateAnswerCache(ctx, kbIDs...)
 	// 5. Delete the knowledge entry itself from the database
 	return s.repo.DeleteKnowledgeList(ctx, tenantInfo.ID, ids)
 }
@@ -1013,6 +1029,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 
 	ctx, span := tracing.ContextWithSpan(ctx, "knowledgeService.processChunks")
 	defer span.End()
+	ctx = types.WithUsageStage(types.WithUsageScope(ctx, "", knowledge.KnowledgeBaseID), types.UsageStageIndexing)
 	span.SetAttributes(
 		attribute.Int("tenant_id", int(knowledge.TenantID)),
 		attribute.String("knowledge_base_id", knowledge.KnowledgeBaseID),
@@ -1107,6 +1124,11 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		var chunkImages []types.ImageInfo
 		insertChunks = append(insertChunks, textChunk)
 
+		// 层级切分：文本Chunk作为父Chunk，切分出的子Chunk用于检索
+		if kb.ChunkingConfig.IsHierarchical() {
+			insertChunks = append(insertChunks, buildChildChunks(textChunk, kb.ChunkingConfig)...)
+		}
+
 		// 处理图片信息
 		if len(chunkData.Images) > 0 {
 			logger.GetLogger(ctx).Infof("Processing %d images in chunk #%d", len(chunkData.Images), chunkData.Seq)
@@ -1210,6 +1232,10 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	// Create index information for each chunk (without generated questions for now)
 	indexInfoList := make([]*types.IndexInfo, 0, len(insertChunks))
 	for _, chunk := range insertChunks {
+		// Parent chunks of hierarchical chunking are retrieved through their child chunks
+		if kb.ChunkingConfig.IsHierarchical() && chunk.ChunkType == types.ChunkTypeText {
+			continue
+		}
 		// Add original chunk content to index
 		indexInfoList = append(indexInfoList, &types.IndexInfo{
 			Content:         chunk.Content,
@@ -1344,6 +1370,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks update knowledge failed")
 	}
+	s.invalidateAnswerCache(ctx, knowledge.KnowledgeBaseID)
 
 	// Enqueue question generation task if enabled (async, non-blocking)
 	if options.EnableQuestionGeneration && len(textChunks) > 0 {
@@ -1529,6 +1556,7 @@ func (s *knowledgeService) ProcessSummaryGeneration(ctx context.Context, t *asyn
 	}
 
 	logger.Infof(ctx, "Processing summary generation for knowledge: %s", payload.KnowledgeID)
+	ctx = types.WithUsageStage(types.WithUsageScope(ctx, "", payload.KnowledgeBaseID), types.UsageStageSummaryGeneration)
 
 	// Set tenant context
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
@@ -1708,6 +1736,8 @@ func (s *knowledgeService) ProcessQuestionGeneration(ctx context.Context, t *asy
 	}
 
 	logger.Infof(ctx, "Processing question generation for knowledge: %s", payload.KnowledgeID)
+	ctx = types.WithUsageStage(types.WithUsageScope(ctx, "", payload.KnowledgeBaseID),
+		types.UsageStageQuestionGeneration)
 
 	// Set tenant context
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
@@ -1997,6 +2027,7 @@ func (s *knowledgeService) UpdateKnowledge(ctx context.Context, knowledge *types
 		logger.Errorf(ctx, "Failed to update knowledge: %v", err)
 		return err
 	}
+	s.invalidateAnswerCache(ctx, record.KnowledgeBaseID)
 	logger.Infof(ctx, "Knowledge updated successfully, ID: %s", knowledge.ID)
 	return nil
 }
@@ -2199,7 +2230,7 @@ func (s *knowledgeService) CloneKnowledgeBase(ctx context.Context, srcID, dstID
 		g.Go(func() error {
 			err := s.DeleteKnowledgeList(gctx, ids)
 			if err != nil {
-				logger.Errorf(gctx, "delete partial knowledge %v: %w", ids, err)
+				logger.Errorf(gctx, "delete partial knowledge %v: %v", ids, err)
 				return err
 			}
 			return nil
@@ -2218,12 +2249,12 @@ func (s *knowledgeService) CloneKnowledgeBase(ctx context.Context, srcID, dstID
 		g.Go(func() error {
 			srcKn, err := s.repo.GetKnowledgeByID(gctx, srcKB.TenantID, knowledge)
 			if err != nil {
-				logger.Errorf(gctx, "get knowledge %s: %w", knowledge, err)
+				logger.Errorf(gctx, "get knowledge %s: %v", knowledge, err)
 				return err
 			}
 			err = s.cloneKnowledge(gctx, srcKn, dstKB)
 			if err != nil {
-				logger.Errorf(gctx, "clone knowledge %s: %w", knowledge, err)
+				logger.Errorf(gctx, "clone knowledge %s: %v", knowledge, err)
 				return err
 			}
 			return nil
@@ -2445,6 +2476,7 @@ func (s *knowledgeService) UpdateImageInfo(
 	if err != nil {
 		logger.Warnf(ctx, "Failed to update knowledge file hash: %v", err)
 	}
+	s.invalidateAnswerCache(ctx, chunk.KnowledgeBaseID)
 
 	logger.Infof(ctx, "Updated chunk successfully, chunk ID: %s, knowledge ID: %s", chunk.ID, chunk.KnowledgeID)
 	return nil
@@ -2642,8 +2674,7 @@ func (s *knowledgeService) UpsertFAQEntries(ctx context.Context,
 		return "", fmt.Errorf("failed to initialize task: %w", err)
 	}
 
-	logger.Infof(ctx, "Allocated ChunkIndex range [%d, %d] for FAQ import task %s, next ChunkIndex will be %d",
-		taskID)
+	logger.Infof(ctx, "Allocated FAQ import task %s", taskID)
 
 	// Enqueue FAQ import task to Asynq
 	logger.Info(ctx, "Enqueuing FAQ import task to Asynq")
@@ -3086,6 +3117,7 @@ func (s *knowledgeService) executeFAQImport(ctx context.Context, taskID string,
 		)
 	}
 
+	s.invalidateAnswerCache(ctx, kbID)
 	totalDuration := time.Since(totalStartTime)
 	logger.Infof(
 		ctx,
@@ -3184,6 +3216,7 @@ func (s *knowledgeService) CreateFAQEntry(ctx context.Context,
 	if err := s.chunkService.UpdateChunk(ctx, chunk); err != nil {
 		return nil, fmt.Errorf("failed to update chunk status: %w", err)
 	}
+	s.invalidateAnswerCache(ctx, kb.ID)
 
 	// 转换为FAQEntry返回
 	entry, err := s.chunkToFAQEntry(chunk, kb)
@@ -3194,6 +3227,23 @@ func (s *knowledgeService) CreateFAQEntry(ctx context.Context,
 	return entry, nil
 }
 
+// GetFAQEntry gets a single FAQ entry.
+func (s *knowledgeService) GetFAQEntry(ctx context.Context, kbID string, entryID string) (*types.FAQEntry, error) {
+	kb, err := s.validateFAQKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	chunk, err := s.chunkRepo.GetChunkByID(ctx, tenantID, entryID)
+	if err != nil {
+		return nil, werrors.NewNotFoundError("FAQ 条目不存在")
+	}
+	if chunk.KnowledgeBaseID != kb.ID || chunk.ChunkType != types.ChunkTypeFAQ {
+		return nil, werrors.NewNotFoundError("FAQ 条目不存在")
+	}
+	return s.chunkToFAQEntry(chunk, kb)
+}
+
 // UpdateFAQEntry updates a single FAQ entry.
 func (s *knowledgeService) UpdateFAQEntry(ctx context.Context,
 	kbID string, entryID string, payload *types.FAQEntryPayload,
@@ -3275,7 +3325,11 @@ func (s *knowledgeService) UpdateFAQEntry(ctx context.Context,
 	if err != nil {
 		return err
 	}
-	return s.indexFAQChunks(ctx, kb, faqKnowledge, []*types.Chunk{chunk}, embeddingModel, false, true)
+	if err := s.indexFAQChunks(ctx, kb, faqKnowledge, []*types.Chunk{chunk}, embeddingModel, false, true); err != nil {
+		return err
+	}
+	s.invalidateAnswerCache(ctx, kb.ID)
+	return nil
 }
 
 // UpdateFAQEntryStatus updates enable status for a FAQ entry.
@@ -3313,6 +3367,7 @@ func (s *knowledgeService) UpdateFAQEntryStatus(ctx context.Context,
 	if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, chunkStatusMap); err != nil {
 		return err
 	}
+	s.invalidateAnswerCache(ctx, kb.ID)
 
 	return nil
 }
@@ -3374,6 +3429,7 @@ func (s *knowledgeService) UpdateFAQEntryStatusBatch(ctx context.Context,
 		if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, chunkStatusMap); err != nil {
 			return err
 		}
+		s.invalidateAnswerCache(ctx, kb.ID)
 	}
 
 	return nil
@@ -3723,6 +3779,7 @@ func (s *knowledgeService) DeleteFAQEntries(ctx context.Context,
 		if err := s.deleteFAQChunkVectors(ctx, kb, faqKnowledge, chunksToRemove); err != nil {
 			return err
 		}
+		s.invalidateAnswerCache(ctx, kb.ID)
 	}
 	return nil
 }
@@ -4101,6 +4158,7 @@ func (s *knowledgeService) indexFAQChunks(ctx context.Context,
 	if len(chunks) == 0 {
 		return nil
 	}
+	ctx = types.WithUsageStage(types.WithUsageScope(ctx, "", kb.ID), types.UsageStageIndexing)
 	indexStartTime := time.Now()
 	logger.Debugf(ctx, "indexFAQChunks: starting to index %d chunks", len(chunks))
 
@@ -4306,8 +4364,8 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 		FileName:    fileName,
 		FileType:    fileType,
 		ReadConfig: &proto.ReadConfig{
-			ChunkSize:        int32(kb.ChunkingConfig.ChunkSize),
-			ChunkOverlap:     int32(kb.ChunkingConfig.ChunkOverlap),
+			ChunkSize:        int32(kb.ChunkingConfig.ReadChunkSize()),
+			ChunkOverlap:     int32(kb.ChunkingConfig.ReadChunkOverlap()),
 			Separators:       kb.ChunkingConfig.Separators,
 			EnableMultimodal: enableMultimodel,
 			StorageConfig: &proto.StorageConfig{
@@ -4562,8 +4620,8 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 			Url:   payload.URL,
 			Title: knowledge.Title,
 			ReadConfig: &proto.ReadConfig{
-				ChunkSize:        int32(kb.ChunkingConfig.ChunkSize),
-				ChunkOverlap:     int32(kb.ChunkingConfig.ChunkOverlap),
+				ChunkSize:        int32(kb.ChunkingConfig.ReadChunkSize()),
+				ChunkOverlap:     int32(kb.ChunkingConfig.ReadChunkOverlap()),
 				Separators:       kb.ChunkingConfig.Separators,
 				EnableMultimodal: payload.EnableMultimodel,
 				StorageConfig: &proto.StorageConfig{
@@ -4640,8 +4698,8 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 			FileName:    payload.FileName,
 			FileType:    payload.FileType,
 			ReadConfig: &proto.ReadConfig{
-				ChunkSize:        int32(kb.ChunkingConfig.ChunkSize),
-				ChunkOverlap:     int32(kb.ChunkingConfig.ChunkOverlap),
+				ChunkSize:        int32(kb.ChunkingConfig.ReadChunkSize()),
+				ChunkOverlap:     int32(kb.ChunkingConfig.ReadChunkOverlap()),
 				Separators:       kb.ChunkingConfig.Separators,
 				EnableMultimodal: payload.EnableMultimodel,
 				StorageConfig: &proto.StorageConfig{
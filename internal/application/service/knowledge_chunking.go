@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/docreader/proto"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// buildChildChunks splits a parent text chunk into the child chunks used for retrieval in hierarchical chunking.
+// Children inherit the chunk index of their parent and are not linked to each other.
+func buildChildChunks(parent *types.Chunk, config types.ChunkingConfig) []*types.Chunk {
+	spans := utils.SplitText(parent.Content, config.GetChildChunkSize(), config.ChunkOverlap, config.Separators)
+	children := make([]*types.Chunk, 0, len(spans))
+	for _, span := range spans {
+		if strings.TrimSpace(span.Content) == "" {
+			continue
+		}
+		children = append(children, &types.Chunk{
+			ID:              uuid.New().String(),
+			TenantID:        parent.TenantID,
+			KnowledgeID:     parent.KnowledgeID,
+			KnowledgeBaseID: parent.KnowledgeBaseID,
+			Content:         span.Content,
+			ChunkIndex:      parent.ChunkIndex,
+			IsEnabled:       true,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+			StartAt:         parent.StartAt + span.Start,
+			EndAt:           parent.StartAt + span.End,
+			ChunkType:       types.ChunkTypeChildText,
+			ParentChunkID:   parent.ID,
+		})
+	}
+	return children
+}
+
+// rebuildDocumentChunks restores the document text from its stored text chunks and splits it again
+// according to the chunking config, so existing knowledge can be rechunked without parsing the file again.
+// Images of a stored chunk are attached to the new chunk containing the start of the stored chunk.
+func rebuildDocumentChunks(stored []*types.Chunk, config types.ChunkingConfig) []*proto.Chunk {
+	textChunks := make([]*types.Chunk, 0, len(stored))
+	for _, chunk := range stored {
+		if chunk.ChunkType == types.ChunkTypeText {
+			textChunks = append(textChunks, chunk)
+		}
+	}
+	sort.Slice(textChunks, func(i, j int) bool {
+		return textChunks[i].StartAt < textChunks[j].StartAt
+	})
+
+	// Overlapping chunks are joined at their start offset, the same way summaries restore the content
+	var document []rune
+	for _, chunk := range textChunks {
+		start := min(max(chunk.StartAt, 0), len(document))
+		document = append(document[:start], []rune(chunk.Content)...)
+	}
+
+	spans := utils.SplitText(string(document), config.ReadChunkSize(), config.ReadChunkOverlap(), config.Separators)
+	chunks := make([]*proto.Chunk, 0, len(spans))
+	for i, span := range spans {
+		chunks = append(chunks, &proto.Chunk{
+			Content: span.Content,
+			Seq:     int32(i),
+			Start:   int32(span.Start),
+			End:     int32(span.End),
+		})
+	}
+	if len(chunks) == 0 {
+		return chunks
+	}
+
+	for _, chunk := range textChunks {
+		if chunk.ImageInfo == "" {
+			continue
+		}
+		var images []types.ImageInfo
+		if err := json.Unmarshal([]byte(chunk.ImageInfo), &images); err != nil {
+			continue
+		}
+		// The last new chunk starting at or before the stored chunk holds its start
+		target := sort.Search(len(chunks), func(i int) bool {
+			return int(chunks[i].Start) > chunk.StartAt
+		})
+		target = max(target-1, 0)
+		for _, image := range images {
+			chunks[target].Images = append(chunks[target].Images, &proto.Image{
+				Url:         image.URL,
+				OriginalUrl: image.OriginalURL,
+				Start:       int32(image.StartPos),
+				End:         int32(image.EndPos),
+				OcrText:     image.OCRText,
+				Caption:     image.Caption,
+			})
+		}
+	}
+	return chunks
+}
+
+// RechunkKnowledgeBase enqueues a rechunk task for every processed knowledge of the knowledge base,
+// used when the chunking mode of a knowledge base changes
+func (s *knowledgeService) RechunkKnowledgeBase(ctx context.Context, kbID string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledges, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kbID)
+	if err != nil {
+		return err
+	}
+
+	enqueued := 0
+	for _, knowledge := range knowledges {
+		if knowledge.ParseStatus != types.ParseStatusCompleted {
+			continue
+		}
+		payloadBytes, err := json.Marshal(types.KnowledgeRechunkPayload{
+			TenantID:        tenantID,
+			KnowledgeBaseID: kbID,
+			KnowledgeID:     knowledge.ID,
+		})
+		if err != nil {
+			return err
+		}
+		task := asynq.NewTask(types.TypeKnowledgeRechunk, payloadBytes, asynq.Queue("low"), asynq.MaxRetry(3))
+		if _, err := s.task.Enqueue(task); err != nil {
+			return err
+		}
+		enqueued++
+	}
+	logger.Infof(ctx, "Enqueued %d rechunk tasks for knowledge base: %s", enqueued, kbID)
+	return nil
+}
+
+// ProcessKnowledgeRechunk handles async knowledge rechunk task
+func (s *knowledgeService) ProcessKnowledgeRechunk(ctx context.Context, t *asynq.Task) error {
+	var payload types.KnowledgeRechunkPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal knowledge rechunk payload: %v", err)
+		return nil // Don't retry on unmarshal error
+	}
+
+	ctx = logger.WithField(ctx, "knowledge_rechunk", payload.KnowledgeID)
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.KnowledgeBaseID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base: %v", err)
+		return nil
+	}
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, payload.TenantID, payload.KnowledgeID)
+	if err != nil || knowledge == nil {
+		logger.Errorf(ctx, "Failed to get knowledge: %v", err)
+		return nil
+	}
+	if knowledge.ParseStatus != types.ParseStatusCompleted {
+		logger.Infof(ctx, "Knowledge is not completed, skipping rechunk: %s", knowledge.ID)
+		return nil
+	}
+
+	stored, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, payload.TenantID, knowledge.ID)
+	if err != nil {
+		return err
+	}
+	chunks := rebuildDocumentChunks(stored, kb.ChunkingConfig)
+	if len(chunks) == 0 {
+		logger.Infof(ctx, "Knowledge has no text chunks, skipping rechunk: %s", knowledge.ID)
+		return nil
+	}
+	logger.Infof(ctx, "Rechunking knowledge %s: %d stored chunks into %d chunks", knowledge.ID, len(stored), len(chunks))
+
+	// processChunks replaces the old chunks and indexes and accounts the new storage size
+	if knowledge.StorageSize > 0 {
+		if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, -knowledge.StorageSize); err != nil {
+			logger.Warnf(ctx, "Failed to release storage usage before rechunk: %v", err)
+		}
+		tenantInfo.StorageUsed = max(tenantInfo.StorageUsed-knowledge.StorageSize, 0)
+		knowledge.StorageSize = 0
+	}
+
+	options := ProcessChunksOptions{}
+	if kb.QuestionGenerationConfig != nil && kb.QuestionGenerationConfig.Enabled {
+		options.EnableQuestionGeneration = true
+		options.QuestionCount = kb.QuestionGenerationConfig.QuestionCount
+	}
+	s.processChunks(ctx, kb, knowledge, chunks, options)
+	return nil
+}
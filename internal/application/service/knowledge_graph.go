@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/graphedit"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// maxEntityChunks caps the source chunks returned with an entity
+const maxEntityChunks = 50
+
+// knowledgeGraphService implements the knowledge graph service interface. Created entities, relations and
+// edited attributes are stored in the manual namespace of the knowledge base, renames and merges are manual
+// aliases and deletions are tombstones, all of them applied again when documents are extracted.
+type knowledgeGraphService struct {
+	graphRepo         interfaces.RetrieveGraphRepository
+	resolutionService interfaces.EntityResolutionService
+	tombstoneRepo     interfaces.GraphTombstoneRepository
+	kbService         interfaces.KnowledgeBaseService
+	chunkRepo         interfaces.ChunkRepository
+}
+
+// NewKnowledgeGraphService creates a new knowledge graph service
+func NewKnowledgeGraphService(graphRepo interfaces.RetrieveGraphRepository,
+	resolutionService interfaces.EntityResolutionService,
+	tombstoneRepo interfaces.GraphTombstoneRepository,
+	kbService interfaces.KnowledgeBaseService,
+	chunkRepo interfaces.ChunkRepository,
+) interfaces.KnowledgeGraphService {
+	return &knowledgeGraphService{
+		graphRepo:         graphRepo,
+		resolutionService: resolutionService,
+		tombstoneRepo:     tombstoneRepo,
+		kbService:         kbService,
+		chunkRepo:         chunkRepo,
+	}
+}
+
+// BrowseGraph returns a page of entities, most connected first, with the relations among them
+func (s *knowledgeGraphService) BrowseGraph(ctx context.Context,
+	kbID string, req *types.GraphBrowseRequest,
+) (*types.PageResult, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	namespace := types.NameSpace{KnowledgeBase: kb.ID}
+	nodes, total, err := s.graphRepo.RankNodes(ctx, namespace, strings.TrimSpace(req.Keyword), &req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	relations, err := s.graphRepo.ListRelationsAmong(ctx, namespace, names)
+	if err != nil {
+		return nil, err
+	}
+	if relations == nil {
+		relations = []*types.GraphRelation{}
+	}
+	return types.NewPageResult(total, &req.Pagination, &types.GraphData{Node: nodes, Relation: relations}), nil
+}
+
+// ListEntities returns a page of entities, most connected first
+func (s *knowledgeGraphService) ListEntities(ctx context.Context,
+	kbID string, req *types.GraphBrowseRequest,
+) (*types.PageResult, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, total, err := s.graphRepo.RankNodes(ctx,
+		types.NameSpace{KnowledgeBase: kb.ID}, strings.TrimSpace(req.Keyword), &req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, &req.Pagination, nodes), nil
+}
+
+// GetEntity returns an entity with its relations and the chunks it was extracted from
+func (s *knowledgeGraphService) GetEntity(ctx context.Context,
+	kbID string, name string,
+) (*types.GraphEntityDetail, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	namespace := types.NameSpace{KnowledgeBase: kb.ID}
+	entity, err := s.graphRepo.GetNode(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return nil, werrors.NewNotFoundError("Entity not found")
+	}
+	relations, err := s.graphRepo.ListNodeRelations(ctx, namespace, []string{entity.Name})
+	if err != nil {
+		return nil, err
+	}
+	if relations == nil {
+		relations = []*types.GraphRelation{}
+	}
+	detail := &types.GraphEntityDetail{
+		Entity:    entity,
+		Relations: relations,
+		Chunks:    make([]*types.GraphEntityChunk, 0),
+	}
+	entity.Degree = len(detail.Relations)
+
+	chunkIDs := entity.Chunks[:min(len(entity.Chunks), maxEntityChunks)]
+	if len(chunkIDs) == 0 {
+		return detail, nil
+	}
+	chunks, err := s.chunkRepo.ListChunksByID(ctx, kb.TenantID, chunkIDs)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list chunks of entity %s: %v", entity.Name, err)
+		return nil, err
+	}
+	for _, chunk := range chunks {
+		detail.Chunks = append(detail.Chunks, &types.GraphEntityChunk{
+			ID:          chunk.ID,
+			KnowledgeID: chunk.KnowledgeID,
+			Content:     chunk.Content,
+		})
+	}
+	return detail, nil
+}
+
+// CreateEntity creates an entity in the manual namespace of the knowledge base
+func (s *knowledgeGraphService) CreateEntity(ctx context.Context,
+	kbID string, req *types.GraphEntityRequest,
+) (*types.GraphNode, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, werrors.NewBadRequestError("Entity name is required")
+	}
+	existing, err := s.graphRepo.GetNode(ctx, types.NameSpace{KnowledgeBase: kb.ID}, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, werrors.NewConflictError("Entity already exists")
+	}
+	if err := s.graphRepo.SetNodeAttributes(ctx, manualNameSpace(kb.ID), name, req.Attributes); err != nil {
+		return nil, err
+	}
+	s.clearTombstones(ctx, kb, graphedit.EntityTombstone(name))
+	logger.Infof(ctx, "Entity %s of knowledge base %s created", name, kb.ID)
+	return &types.GraphNode{Name: name, Attributes: req.Attributes}, nil
+}
+
+// UpdateEntity replaces the attributes of an entity and renames it. Renaming adds a manual alias, so the old
+// name extracted again is renamed too, and merges the entity into the entity of the new name if there is one.
+func (s *knowledgeGraphService) UpdateEntity(ctx context.Context,
+	kbID string, req *types.GraphEntityUpdateRequest,
+) (*types.GraphNode, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	entity, err := s.graphRepo.GetNode(ctx, types.NameSpace{KnowledgeBase: kb.ID}, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return nil, werrors.NewNotFoundError("Entity not found")
+	}
+	if req.Attributes != nil {
+		if err := s.graphRepo.SetNodeAttributes(ctx,
+			manualNameSpace(kb.ID), entity.Name, req.Attributes); err != nil {
+			return nil, err
+		}
+	}
+	name := entity.Name
+	if newName := strings.TrimSpace(req.NewName); newName != "" && newName != name {
+		if _, err := s.resolutionService.AddAlias(ctx, kb.ID,
+			&types.GraphEntityAliasRequest{Alias: name, Canonical: newName}); err != nil {
+			return nil, err
+		}
+		s.clearTombstones(ctx, kb, graphedit.EntityTombstone(newName))
+		name = newName
+	}
+	return s.reloadEntity(ctx, kb, name)
+}
+
+// MergeEntities merges entities into one, the merged names become manual aliases of the entity
+func (s *knowledgeGraphService) MergeEntities(ctx context.Context,
+	kbID string, req *types.GraphEntityMergeRequest,
+) (*types.GraphNode, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	into := strings.TrimSpace(req.Into)
+	if into == "" {
+		return nil, werrors.NewBadRequestError("Target entity is required")
+	}
+	var names []string
+	for _, name := range req.Names {
+		if slices.Contains(names, name) {
+			continue
+		}
+		entity, err := s.graphRepo.GetNode(ctx, types.NameSpace{KnowledgeBase: kb.ID}, name)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return nil, werrors.NewNotFoundError("Entity not found").WithDetails(name)
+		}
+		if name != into {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		if _, err := s.resolutionService.AddAlias(ctx, kb.ID,
+			&types.GraphEntityAliasRequest{Alias: name, Canonical: into}); err != nil {
+			return nil, err
+		}
+	}
+	s.clearTombstones(ctx, kb, graphedit.EntityTombstone(into))
+	logger.Infof(ctx, "Merged %d entities of knowledge base %s into %s", len(names), kb.ID, into)
+	return s.reloadEntity(ctx, kb, into)
+}
+
+// DeleteEntity deletes an entity and its relations from every knowledge of the knowledge base
+func (s *knowledgeGraphService) DeleteEntity(ctx context.Context, kbID string, name string) error {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	entity, err := s.graphRepo.GetNode(ctx, types.NameSpace{KnowledgeBase: kb.ID}, name)
+	if err != nil {
+		return err
+	}
+	if entity == nil {
+		return werrors.NewNotFoundError("Entity not found")
+	}
+	if err := s.createTombstone(ctx, kb, graphedit.EntityTombstone(name)); err != nil {
+		return err
+	}
+	if err := s.graphRepo.DeleteNodes(ctx, types.NameSpace{KnowledgeBase: kb.ID}, []string{name}); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Entity %s of knowledge base %s deleted", name, kb.ID)
+	return nil
+}
+
+// CreateRelation creates a relation between two entities in the manual namespace of the knowledge base
+func (s *knowledgeGraphService) CreateRelation(ctx context.Context,
+	kbID string, req *types.GraphRelationRequest,
+) (*types.GraphRelation, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	rel := &types.GraphRelation{
+		Node1: strings.TrimSpace(req.Source),
+		Node2: strings.TrimSpace(req.Target),
+		Type:  strings.TrimSpace(req.Type),
+	}
+	if rel.Node1 == "" || rel.Node2 == "" || rel.Type == "" {
+		return nil, werrors.NewBadRequestError("Source, target and type are required")
+	}
+	if rel.Node1 == rel.Node2 {
+		return nil, werrors.NewBadRequestError("An entity cannot be related to itself")
+	}
+	namespace := types.NameSpace{KnowledgeBase: kb.ID}
+	for _, name := range []string{rel.Node1, rel.Node2} {
+		entity, err := s.graphRepo.GetNode(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return nil, werrors.NewNotFoundError("Entity not found")
+		}
+	}
+	relations, err := s.graphRepo.ListNodeRelations(ctx, namespace, []string{rel.Node1})
+	if err != nil {
+		return nil, err
+	}
+	if findRelation(relations, rel) != nil {
+		return nil, werrors.NewConflictError("Relation already exists")
+	}
+	if err := s.graphRepo.AddGraph(ctx, manualNameSpace(kb.ID),
+		[]*types.GraphData{{Relation: []*types.GraphRelation{rel}}}); err != nil {
+		return nil, err
+	}
+	s.clearTombstones(ctx, kb, graphedit.RelationTombstone(rel))
+	logger.Infof(ctx, "Relation %s -[%s]-> %s of knowledge base %s created", rel.Node1, rel.Type, rel.Node2, kb.ID)
+	return rel, nil
+}
+
+// DeleteRelation deletes a relation from every knowledge of the knowledge base
+func (s *knowledgeGraphService) DeleteRelation(ctx context.Context,
+	kbID string, req *types.GraphRelationRequest,
+) error {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	relations, err := s.graphRepo.ListNodeRelations(ctx, types.NameSpace{KnowledgeBase: kb.ID}, []string{req.Source})
+	if err != nil {
+		return err
+	}
+	rel := findRelation(relations, &types.GraphRelation{Node1: req.Source, Node2: req.Target, Type: req.Type})
+	if rel == nil {
+		return werrors.NewNotFoundError("Relation not found")
+	}
+	if err := s.createTombstone(ctx, kb, graphedit.RelationTombstone(rel)); err != nil {
+		return err
+	}
+	if err := s.graphRepo.DeleteRelations(ctx,
+		types.NameSpace{KnowledgeBase: kb.ID}, []*types.GraphRelation{rel}); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Relation %s -[%s]-> %s of knowledge base %s deleted", rel.Node1, rel.Type, rel.Node2, kb.ID)
+	return nil
+}
+
+// getKnowledgeBase gets a knowledge base of the tenant of the request with the knowledge graph enabled
+func (s *knowledgeGraphService) getKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	if !config.IsGraphEnabled() {
+		return nil, werrors.NewBadRequestError("Knowledge graph is not enabled")
+	}
+	return getTenantKnowledgeBase(ctx, s.kbService, kbID)
+}
+
+// reloadEntity returns the named entity of the knowledge base after an edit
+func (s *knowledgeGraphService) reloadEntity(ctx context.Context,
+	kb *types.KnowledgeBase, name string,
+) (*types.GraphNode, error) {
+	entity, err := s.graphRepo.GetNode(ctx, types.NameSpace{KnowledgeBase: kb.ID}, name)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return &types.GraphNode{Name: name}, nil
+	}
+	return entity, nil
+}
+
+// createTombstone records a deleted entity or relation of the knowledge base
+func (s *knowledgeGraphService) createTombstone(ctx context.Context,
+	kb *types.KnowledgeBase, tombstone *types.GraphTombstone,
+) error {
+	tombstone.ID = uuid.New().String()
+	tombstone.TenantID = kb.TenantID
+	tombstone.KnowledgeBaseID = kb.ID
+	if err := s.tombstoneRepo.CreateTombstone(ctx, tombstone); err != nil {
+		logger.Errorf(ctx, "Failed to create graph tombstone: %v", err)
+		return err
+	}
+	return nil
+}
+
+// clearTombstones deletes the tombstones of entities or relations created again, failures are only logged
+// since the element is created anyway
+func (s *knowledgeGraphService) clearTombstones(ctx context.Context,
+	kb *types.KnowledgeBase, tombstones ...*types.GraphTombstone,
+) {
+	if err := s.tombstoneRepo.DeleteTombstones(ctx, kb.TenantID, kb.ID, tombstones); err != nil {
+		logger.Warnf(ctx, "Failed to delete graph tombstones of knowledge base %s: %v", kb.ID, err)
+	}
+}
+
+// manualNameSpace returns the namespace of the edits of the knowledge graph of a knowledge base
+func manualNameSpace(kbID string) types.NameSpace {
+	return types.NameSpace{KnowledgeBase: kbID, Knowledge: types.ManualGraphKnowledgeID}
+}
+
+// findRelation returns the relation of the same source, target and type, nil if there is none
+func findRelation(relations []*types.GraphRelation, rel *types.GraphRelation) *types.GraphRelation {
+	for _, candidate := range relations {
+		if *candidate == *rel {
+			return candidate
+		}
+	}
+	return nil
+}
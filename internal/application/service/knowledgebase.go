@@ -19,6 +19,10 @@ import (
 // ErrInvalidTenantID represents an error for invalid tenant ID
 var ErrInvalidTenantID = errors.New("invalid tenant ID")
 
+// filterKnowledgeBatchSize is the number of knowledge IDs sent in one retrieval to engines that cannot
+// evaluate the metadata filter of a search themselves
+const filterKnowledgeBatchSize = 1000
+
 // knowledgeBaseService implements the knowledge base service interface
 type knowledgeBaseService struct {
 	repo           interfaces.KnowledgeBaseRepository
@@ -197,6 +201,14 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 	if config.FAQConfig != nil {
 		kb.FAQConfig = config.FAQConfig
 	}
+	// Update fusion config if provided
+	if config.FusionConfig != nil {
+		kb.FusionConfig = config.FusionConfig
+	}
+	// Update context expansion config if provided
+	if config.ContextExpansionConfig != nil {
+		kb.ContextExpansionConfig = config.ContextExpansionConfig
+	}
 	kb.UpdatedAt = time.Now()
 	kb.EnsureDefaults()
 
@@ -299,14 +311,16 @@ func (s *knowledgeBaseService) DeleteKnowledgeBase(ctx context.Context, id strin
 
 		// Delete knowledge graph data
 		logger.Infof(ctx, "Deleting knowledge graph data")
-		namespaces := make([]types.NameSpace, 0, len(knowledgeList))
+		namespaces := make([]types.NameSpace, 0, len(knowledgeList)+1)
 		for _, knowledge := range knowledgeList {
 			namespaces = append(namespaces, types.NameSpace{
 				KnowledgeBase: knowledge.KnowledgeBaseID,
 				Knowledge:     knowledge.ID,
 			})
 		}
-		if s.graphEngine != nil && len(namespaces) > 0 {
+		// Entities and relations edited through the graph API
+		namespaces = append(namespaces, types.NameSpace{KnowledgeBase: id, Knowledge: types.ManualGraphKnowledgeID})
+		if s.graphEngine != nil {
 			if err := s.graphEngine.DelGraph(ctx, namespaces); err != nil {
 				logger.Warnf(ctx, "Failed to delete knowledge graph: %v", err)
 			}
@@ -322,6 +336,20 @@ func (s *knowledgeBaseService) DeleteKnowledgeBase(ctx context.Context, id strin
 		}
 	}
 
+	// Community reports belong to the knowledge base rather than to a knowledge entry
+	reports, err := s.chunkRepo.ListChunksByKnowledgeBaseIDAndType(ctx, tenantID, id, types.ChunkTypeCommunityReport)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list community reports: %v", err)
+	} else if len(reports) > 0 {
+		reportIDs := make([]string, 0, len(reports))
+		for _, report := range reports {
+			reportIDs = append(reportIDs, report.ID)
+		}
+		if err := s.chunkRepo.DeleteChunks(ctx, tenantID, reportIDs); err != nil {
+			logger.Warnf(ctx, "Failed to delete community reports: %v", err)
+		}
+	}
+
 	// Step 3: Delete the knowledge base itself
 	logger.Infof(ctx, "Deleting knowledge base from database")
 	err = s.repo.DeleteKnowledgeBase(ctx, id)
@@ -472,9 +500,13 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 
 		// Generate embedding vector for the query text
 		logger.Info(ctx, "Starting to generate query embedding")
-		queryEmbedding, err := embeddingModel.Embed(ctx, params.QueryText)
+		vectorQueryText := params.QueryText
+		if params.VectorQueryText != "" {
+			vectorQueryText = params.VectorQueryText
+		}
+		queryEmbedding, err := embeddingModel.Embed(ctx, vectorQueryText)
 		if err != nil {
-			logger.Errorf(ctx, "Failed to embed query text, query text: %s, error: %v", params.QueryText, err)
+			logger.Errorf(ctx, "Failed to embed query text, query text: %s, error: %v", vectorQueryText, err)
 			return nil, err
 		}
 		logger.Infof(ctx, "Query embedding generated successfully, embedding vector length: %d", len(queryEmbedding))
@@ -509,6 +541,18 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		return nil, errors.New("no retrieve params")
 	}
 
+	if params.Filter != nil {
+		retrieveParams, err = s.applyMetadataFilter(ctx, retrieveEngine, kb, params.Filter, retrieveParams)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to apply metadata filter, knowledge base ID: %s, error: %v", id, err)
+			return nil, err
+		}
+		if len(retrieveParams) == 0 {
+			logger.Info(ctx, "No knowledge matches the metadata filter")
+			return nil, nil
+		}
+	}
+
 	// Execute retrieval using the configured engines
 	logger.Infof(ctx, "Starting retrieval, parameter count: %d", len(retrieveParams))
 	retrieveResults, err := retrieveEngine.Retrieve(ctx, retrieveParams)
@@ -519,13 +563,16 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		})
 		return nil, err
 	}
+	if params.Filter != nil {
+		// Results of the knowledge batches of an engine are one ranking
+		retrieveResults = retriever.MergeResults(retrieveResults, matchCount)
+	}
 
 	// Collect all results from different retrievers and deduplicate by chunk ID
 	logger.Infof(ctx, "Processing retrieval results")
 
-	// Separate results by retriever type for RRF fusion
-	var vectorResults []*types.IndexWithScore
-	var keywordResults []*types.IndexWithScore
+	// Count results per retriever type
+	var vectorCount, keywordCount int
 	for _, retrieveResult := range retrieveResults {
 		logger.Infof(ctx, "Retrieval results, engine: %v, retriever: %v, count: %v",
 			retrieveResult.RetrieverEngineType,
@@ -533,92 +580,36 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 			len(retrieveResult.Results),
 		)
 		if retrieveResult.RetrieverType == types.VectorRetrieverType {
-			vectorResults = append(vectorResults, retrieveResult.Results...)
+			vectorCount += len(retrieveResult.Results)
 		} else {
-			keywordResults = append(keywordResults, retrieveResult.Results...)
+			keywordCount += len(retrieveResult.Results)
 		}
 	}
 
 	// Early return if no results
-	if len(vectorResults) == 0 && len(keywordResults) == 0 {
+	if vectorCount == 0 && keywordCount == 0 {
 		logger.Info(ctx, "No search results found")
 		return nil, nil
 	}
-	logger.Infof(ctx, "Result count before RRF fusion: vector=%d, keyword=%d", len(vectorResults), len(keywordResults))
-
-	// Use RRF (Reciprocal Rank Fusion) to merge results
-	// RRF score = sum(1 / (k + rank)) for each retriever where the chunk appears
-	// k=60 is a common choice that works well in practice
-	const rrfK = 60
-
-	// Build rank maps for each retriever (already sorted by score from retriever)
-	vectorRanks := make(map[string]int)
-	for i, r := range vectorResults {
-		if _, exists := vectorRanks[r.ChunkID]; !exists {
-			vectorRanks[r.ChunkID] = i + 1 // 1-indexed rank
-		}
-	}
-	keywordRanks := make(map[string]int)
-	for i, r := range keywordResults {
-		if _, exists := keywordRanks[r.ChunkID]; !exists {
-			keywordRanks[r.ChunkID] = i + 1 // 1-indexed rank
-		}
-	}
-
-	// Collect all unique chunks and compute RRF scores
-	chunkInfoMap := make(map[string]*types.IndexWithScore)
-	rrfScores := make(map[string]float64)
 
-	// Process vector results
-	for _, r := range vectorResults {
-		if _, exists := chunkInfoMap[r.ChunkID]; !exists {
-			chunkInfoMap[r.ChunkID] = r
-		}
-	}
-	// Process keyword results
-	for _, r := range keywordResults {
-		if _, exists := chunkInfoMap[r.ChunkID]; !exists {
-			chunkInfoMap[r.ChunkID] = r
-		}
+	// Fuse results of all retrievers into comparable scores
+	// The request's fusion config takes precedence over the knowledge base's, RRF (k=60) is the default
+	fusionConfig := params.Fusion
+	if fusionConfig == nil {
+		fusionConfig = kb.FusionConfig
 	}
+	logger.Infof(ctx, "Result count before %s fusion: vector=%d, keyword=%d",
+		fusionConfig.GetMethod(), vectorCount, keywordCount)
 
-	// Compute RRF scores
-	for chunkID := range chunkInfoMap {
-		rrfScore := 0.0
-		if rank, ok := vectorRanks[chunkID]; ok {
-			rrfScore += 1.0 / float64(rrfK+rank)
-		}
-		if rank, ok := keywordRanks[chunkID]; ok {
-			rrfScore += 1.0 / float64(rrfK+rank)
-		}
-		rrfScores[chunkID] = rrfScore
-	}
-
-	// Convert to slice and sort by RRF score
-	deduplicatedChunks := make([]*types.IndexWithScore, 0, len(chunkInfoMap))
-	for chunkID, info := range chunkInfoMap {
-		// Store RRF score in the Score field for downstream processing
-		info.Score = rrfScores[chunkID]
-		deduplicatedChunks = append(deduplicatedChunks, info)
-	}
-	slices.SortFunc(deduplicatedChunks, func(a, b *types.IndexWithScore) int {
-		if a.Score > b.Score {
-			return -1
-		} else if a.Score < b.Score {
-			return 1
-		}
-		return 0
-	})
+	deduplicatedChunks := retriever.FuseResults(retrieveResults, fusionConfig)
 
-	logger.Infof(ctx, "Result count after RRF fusion: %d", len(deduplicatedChunks))
+	logger.Infof(ctx, "Result count after %s fusion: %d", fusionConfig.GetMethod(), len(deduplicatedChunks))
 
-	// Log top results after RRF fusion for debugging
+	// Log top results after fusion for debugging
 	for i, chunk := range deduplicatedChunks {
 		if i < 15 {
-			vRank, vOk := vectorRanks[chunk.ChunkID]
-			kRank, kOk := keywordRanks[chunk.ChunkID]
-			logger.Debugf(ctx, "RRF rank %d: chunk_id=%s, rrf_score=%.6f, vector_rank=%v(%v), keyword_rank=%v(%v)",
-				i, chunk.ChunkID, chunk.Score, vRank, vOk, kRank, kOk)
+			logger.Debugf(ctx, "Fusion rank %d: chunk_id=%s, fused_score=%.6f, match_type=%v",
+				i, chunk.ChunkID, chunk.Score, chunk.MatchType)
 		}
 	}
 
@@ -626,7 +617,7 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 
 	// Check if we need iterative retrieval for FAQ with separate indexing
 	// Only use iterative retrieval if we don't have enough unique chunks after first deduplication
-	totalRetrieved := len(vectorResults) + len(keywordResults)
+	totalRetrieved := vectorCount + keywordCount
 	needsIterativeRetrieval := len(deduplicatedChunks) < params.MatchCount &&
 		kb.Type == types.KnowledgeBaseTypeFAQ && totalRetrieved == matchCount*2
 	if needsIterativeRetrieval {
@@ -653,6 +644,40 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	return s.processSearchResults(ctx, deduplicatedChunks)
 }
 
+// applyMetadataFilter restricts retrieval to the knowledge matching the metadata filter. Engines that support
+// metadata filters evaluate it in their own query, the others retrieve once per batch of matching knowledge IDs.
+// No params are returned when the filter matches no knowledge.
+func (s *knowledgeBaseService) applyMetadataFilter(ctx context.Context,
+	retrieveEngine *retriever.CompositeRetrieveEngine,
+	kb *types.KnowledgeBase,
+	filter *types.MetadataFilter,
+	retrieveParams []types.RetrieveParams,
+) ([]types.RetrieveParams, error) {
+	var knowledgeIDs []string
+	resolved := false
+	filtered := make([]types.RetrieveParams, 0, len(retrieveParams))
+	for _, param := range retrieveParams {
+		if retrieveEngine.SupportMetadataFilter(param.RetrieverType) {
+			param.Filter = filter
+			filtered = append(filtered, param)
+			continue
+		}
+		if !resolved {
+			ids, err := s.kgRepo.ListKnowledgeIDsByFilter(ctx, kb.TenantID, []string{kb.ID}, filter)
+			if err != nil {
+				return nil, err
+			}
+			knowledgeIDs, resolved = ids, true
+			logger.Infof(ctx, "Metadata filter matched %d knowledge", len(knowledgeIDs))
+		}
+		for batch := range slices.Chunk(knowledgeIDs, filterKnowledgeBatchSize) {
+			param.KnowledgeIDs = batch
+			filtered = append(filtered, param)
+		}
+	}
+	return filtered, nil
+}
+
 // iterativeRetrieveWithDeduplication performs iterative retrieval until enough unique chunks are found
 // This is used for FAQ knowledge bases with separate indexing mode
 // Negative question filtering is applied after each iteration to ensure we have enough valid chunks
@@ -921,8 +946,9 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 		chunkMap[chunk.ID] = chunk
 		processedChunkIDs[chunk.ID] = true
 
-		// Collect parent chunks
-		if chunk.ParentChunkID != "" && !processedChunkIDs[chunk.ParentChunkID] {
+		// Collect parent chunks, child text chunks are resolved to their parents after rerank
+		if chunk.ParentChunkID != "" && chunk.ChunkType != types.ChunkTypeChildText &&
+			!processedChunkIDs[chunk.ParentChunkID] {
 			additionalChunkIDs = append(additionalChunkIDs, chunk.ParentChunkID)
 			processedChunkIDs[chunk.ParentChunkID] = true
 
@@ -1071,8 +1097,8 @@ func (s *knowledgeBaseService) buildSearchResult(chunk *types.Chunk,
 // isValidTextChunk checks if a chunk is a valid text chunk
 func (s *knowledgeBaseService) isValidTextChunk(chunk *types.Chunk) bool {
 	return slices.Contains([]types.ChunkType{
-		types.ChunkTypeText, types.ChunkTypeSummary,
-		types.ChunkTypeFAQ,
+		types.ChunkTypeText, types.ChunkTypeChildText,
+		types.ChunkTypeSummary, types.ChunkTypeFAQ,
 	}, chunk.ChunkType)
 }
 
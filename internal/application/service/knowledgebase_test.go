@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// fakeKnowledgeRepository resolves every metadata filter to the same knowledge
+type fakeKnowledgeRepository struct {
+	interfaces.KnowledgeRepository
+	ids      []string
+	resolved int
+}
+
+func (r *fakeKnowledgeRepository) ListKnowledgeIDsByFilter(ctx context.Context,
+	tenantID uint64, kbIDs []string, filter *types.MetadataFilter,
+) ([]string, error) {
+	r.resolved++
+	return r.ids, nil
+}
+
+// fakeRetrieveEngine is a retrieve engine of one retriever type
+type fakeRetrieveEngine struct {
+	interfaces.RetrieveEngineService
+	engineType     types.RetrieverEngineType
+	retrieverType  types.RetrieverType
+	metadataFilter bool
+}
+
+func (e *fakeRetrieveEngine) EngineType() types.RetrieverEngineType { return e.engineType }
+
+func (e *fakeRetrieveEngine) Support() []types.RetrieverType {
+	return []types.RetrieverType{e.retrieverType}
+}
+
+func (e *fakeRetrieveEngine) SupportMetadataFilter() bool { return e.metadataFilter }
+
+// fakeRetrieveEngineRegistry serves the engines by type
+type fakeRetrieveEngineRegistry struct {
+	interfaces.RetrieveEngineRegistry
+	engines map[types.RetrieverEngineType]interfaces.RetrieveEngineService
+}
+
+func (r *fakeRetrieveEngineRegistry) GetRetrieveEngineService(
+	engineType types.RetrieverEngineType,
+) (interfaces.RetrieveEngineService, error) {
+	return r.engines[engineType], nil
+}
+
+func TestApplyMetadataFilter(t *testing.T) {
+	registry := &fakeRetrieveEngineRegistry{engines: map[types.RetrieverEngineType]interfaces.RetrieveEngineService{
+		types.PostgresRetrieverEngineType: &fakeRetrieveEngine{engineType: types.PostgresRetrieverEngineType,
+			retrieverType: types.VectorRetrieverType, metadataFilter: true},
+		types.ElasticsearchRetrieverEngineType: &fakeRetrieveEngine{engineType: types.ElasticsearchRetrieverEngineType,
+			retrieverType: types.KeywordsRetrieverType},
+	}}
+	engine, err := retriever.NewCompositeRetrieveEngine(registry, []types.RetrieverEngineParams{
+		{RetrieverEngineType: types.PostgresRetrieverEngineType, RetrieverType: types.VectorRetrieverType},
+		{RetrieverEngineType: types.ElasticsearchRetrieverEngineType, RetrieverType: types.KeywordsRetrieverType},
+	})
+	if err != nil {
+		t.Fatalf("failed to create retrieve engine: %v", err)
+	}
+	params := []types.RetrieveParams{
+		{RetrieverType: types.VectorRetrieverType, KnowledgeBaseIDs: []string{"kb1"}},
+		{RetrieverType: types.KeywordsRetrieverType, KnowledgeBaseIDs: []string{"kb1"}},
+	}
+	filter := &types.MetadataFilter{Op: types.MetadataFilterOpEq, Field: "metadata.department", Value: "legal"}
+	kb := &types.KnowledgeBase{ID: "kb1", TenantID: 1}
+
+	t.Run("broad filter is split into batches", func(t *testing.T) {
+		repo := &fakeKnowledgeRepository{}
+		for i := range 2*filterKnowledgeBatchSize + 1 {
+			repo.ids = append(repo.ids, fmt.Sprintf("k%d", i))
+		}
+		service := &knowledgeBaseService{kgRepo: repo}
+		filtered, err := service.applyMetadataFilter(context.Background(), engine, kb, filter, params)
+		if err != nil {
+			t.Fatalf("applyMetadataFilter failed: %v", err)
+		}
+		if len(filtered) != 4 {
+			t.Fatalf("expected the vector params and 3 keyword batches, got %d params", len(filtered))
+		}
+		// The engine supporting metadata filters evaluates the filter itself
+		if filtered[0].Filter != filter || filtered[0].KnowledgeIDs != nil {
+			t.Errorf("vector params = %+v, want the filter without knowledge IDs", filtered[0])
+		}
+		total := 0
+		for _, param := range filtered[1:] {
+			if param.RetrieverType != types.KeywordsRetrieverType || param.Filter != nil {
+				t.Errorf("unexpected keyword params %+v", param)
+			}
+			if len(param.KnowledgeIDs) > filterKnowledgeBatchSize {
+				t.Errorf("batch of %d knowledge exceeds %d", len(param.KnowledgeIDs), filterKnowledgeBatchSize)
+			}
+			total += len(param.KnowledgeIDs)
+		}
+		if total != len(repo.ids) {
+			t.Errorf("batches cover %d knowledge, want %d", total, len(repo.ids))
+		}
+		if repo.resolved != 1 {
+			t.Errorf("expected the filter to be resolved once, got %d", repo.resolved)
+		}
+	})
+
+	t.Run("filter matching no knowledge", func(t *testing.T) {
+		service := &knowledgeBaseService{kgRepo: &fakeKnowledgeRepository{}}
+		filtered, err := service.applyMetadataFilter(context.Background(), engine, kb, filter, params[1:])
+		if err != nil {
+			t.Fatalf("applyMetadataFilter failed: %v", err)
+		}
+		if len(filtered) != 0 {
+			t.Errorf("expected no params, got %+v", filtered)
+		}
+	})
+}
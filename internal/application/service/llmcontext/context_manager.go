@@ -143,6 +143,34 @@ func (cm *contextManager) ClearContext(ctx context.Context, sessionID string) er
 	return nil
 }
 
+// RebuildContext replaces the context of a session with the given messages, compressing them if needed
+func (cm *contextManager) RebuildContext(ctx context.Context, sessionID string, messages []chat.Message) error {
+	logger.Infof(ctx, "[ContextManager][Session-%s] Rebuilding context from %d messages", sessionID, len(messages))
+
+	rebuilt := make([]chat.Message, len(messages))
+	copy(rebuilt, messages)
+
+	tokenCount := cm.compressionStrategy.EstimateTokens(rebuilt)
+	if tokenCount > cm.maxTokens {
+		logger.Infof(ctx, "[ContextManager][Session-%s] Rebuilt context exceeds max tokens (%d > %d), applying compression",
+			sessionID, tokenCount, cm.maxTokens)
+		compressed, err := cm.compressionStrategy.Compress(ctx, rebuilt, cm.maxTokens)
+		if err != nil {
+			logger.Errorf(ctx, "[ContextManager][Session-%s] Failed to compress context: %v", sessionID, err)
+			return fmt.Errorf("failed to compress context: %w", err)
+		}
+		rebuilt = compressed
+	}
+
+	if err := cm.storage.Save(ctx, sessionID, rebuilt); err != nil {
+		logger.Errorf(ctx, "[ContextManager][Session-%s] Failed to save context: %v", sessionID, err)
+		return fmt.Errorf("failed to save context: %w", err)
+	}
+
+	logger.Infof(ctx, "[ContextManager][Session-%s] Context rebuilt (total: %d messages)", sessionID, len(rebuilt))
+	return nil
+}
+
 // GetContextStats returns statistics about the context
 func (cm *contextManager) GetContextStats(ctx context.Context, sessionID string) (*interfaces.ContextStats, error) {
 	// Load messages from storage
@@ -0,0 +1,57 @@
+package llmcontext
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildContextForBranch(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now()
+	message := func(id, parentID, role, content string, minute int) *types.Message {
+		return &types.Message{
+			ID: id, ParentID: parentID, Role: role, Content: content,
+			CreatedAt: start.Add(time.Duration(minute) * time.Minute),
+		}
+	}
+	// q1 was answered twice, a2 is the regenerated answer, q2 follows the first answer
+	messages := []*types.Message{
+		message("q1", "", "user", "What is a comet?", 0),
+		message("a1", "q1", "assistant", "A ball of ice.", 1),
+		message("q2", "a1", "user", "How big is it?", 2),
+		message("a3", "q2", "assistant", "A few kilometers.", 3),
+		message("a2", "q1", "assistant", "A small body of ice and dust.", 4),
+	}
+
+	assert.Equal(t, "a2", types.BranchLeaf(messages, "q1"), "the latest answer is selected")
+	assert.Equal(t, "a3", types.BranchLeaf(messages, "a1"))
+	path := types.MessagePath(messages, "a3")
+	require.Len(t, path, 4)
+	assert.Equal(t, []string{"q1", "a1", "q2", "a3"}, []string{path[0].ID, path[1].ID, path[2].ID, path[3].ID})
+
+	tree := types.BuildMessageTree(messages, "a2")
+	require.Len(t, tree.Roots, 1)
+	require.Len(t, tree.Roots[0].Children, 2)
+	assert.False(t, tree.Roots[0].Children[0].Active)
+	assert.True(t, tree.Roots[0].Children[1].Active)
+
+	manager := NewContextManager(NewMemoryStorage(), NewSlidingWindowStrategy(2), 1)
+	require.NoError(t, manager.AddMessage(ctx, "s1", chat.Message{Role: "assistant", Content: "stale"}))
+	history := make([]chat.Message, 0, len(path))
+	for _, m := range path {
+		history = append(history, chat.Message{Role: m.Role, Content: m.Content})
+	}
+	require.NoError(t, manager.RebuildContext(ctx, "s1", history))
+	rebuilt, err := manager.GetContext(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, []chat.Message{
+		{Role: "user", Content: "How big is it?"},
+		{Role: "assistant", Content: "A few kilometers."},
+	}, rebuilt, "the branch replaces the context and is compressed to the window")
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -45,12 +46,17 @@ func (s *messageService) CreateMessage(ctx context.Context, message *types.Messa
 	// Check if the session exists to validate the message belongs to a valid session
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d, session ID: %s", tenantID, message.SessionID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, message.SessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, message.SessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return nil, err
 	}
 
+	// The message continues the active branch unless its parent is given
+	if message.ParentID == "" {
+		message.ParentID = session.ActiveMessageID
+	}
+
 	// Create the message in the repository
 	logger.Info(ctx, "Session exists, creating message")
 	createdMessage, err := s.messageRepo.CreateMessage(ctx, message)
@@ -61,6 +67,15 @@ func (s *messageService) CreateMessage(ctx context.Context, message *types.Messa
 		return nil, err
 	}
 
+	// The new message becomes the end of the active branch
+	if err := s.sessionRepo.UpdateActiveMessage(ctx, tenantID, message.SessionID, createdMessage.ID); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": message.SessionID,
+			"message_id": createdMessage.ID,
+		})
+		return nil, err
+	}
+
 	logger.Infof(ctx, "Message created successfully, ID: %s", createdMessage.ID)
 	return createdMessage, nil
 }
@@ -156,15 +171,20 @@ func (s *messageService) GetRecentMessagesBySession(ctx context.Context,
 	// Verify the session exists before retrieving messages
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return nil, err
 	}
 
-	// Retrieve the most recent messages
+	// Retrieve the most recent messages of the active branch
 	logger.Info(ctx, "Session exists, getting recent messages")
-	messages, err := s.messageRepo.GetRecentMessagesBySession(ctx, sessionID, limit)
+	var messages []*types.Message
+	if session.ActiveMessageID != "" {
+		messages, err = s.getBranchMessages(ctx, sessionID, session.ActiveMessageID, limit, nil)
+	} else {
+		messages, err = s.messageRepo.GetRecentMessagesBySession(ctx, sessionID, limit)
+	}
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id": sessionID,
@@ -195,15 +215,23 @@ func (s *messageService) GetMessagesBySessionBeforeTime(ctx context.Context,
 	// Verify the session exists before retrieving messages
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return nil, err
 	}
 
-	// Retrieve messages before the specified time
+	// Retrieve messages of the active branch before the specified time
 	logger.Info(ctx, "Session exists, getting messages before time")
-	messages, err := s.messageRepo.GetMessagesBySessionBeforeTime(ctx, sessionID, beforeTime, limit)
+	var messages []*types.Message
+	if session.ActiveMessageID != "" {
+		messages, err = s.getBranchMessages(ctx, sessionID, session.ActiveMessageID, limit,
+			func(message *types.Message) bool {
+				return message.CreatedAt.Before(beforeTime)
+			})
+	} else {
+		messages, err = s.messageRepo.GetMessagesBySessionBeforeTime(ctx, sessionID, beforeTime, limit)
+	}
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id":  sessionID,
@@ -265,11 +293,19 @@ func (s *messageService) DeleteMessage(ctx context.Context, sessionID string, me
 	// Verify the session exists before deleting the message
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return err
 	}
+	message, err := s.messageRepo.GetMessage(ctx, sessionID, messageID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": messageID,
+		})
+		return err
+	}
 
 	// Delete the message from the repository
 	logger.Info(ctx, "Session exists, deleting message")
@@ -282,6 +318,160 @@ func (s *messageService) DeleteMessage(ctx context.Context, sessionID string, me
 		return err
 	}
 
+	// Keep the branches through the message connected by linking its followers to its parent
+	if err := s.messageRepo.ReparentMessages(ctx, sessionID, messageID, message.ParentID); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": messageID,
+		})
+		return err
+	}
+	if session.ActiveMessageID == messageID {
+		if err := s.sessionRepo.UpdateActiveMessage(ctx, tenantID, sessionID, message.ParentID); err != nil {
+			logger.Errorf(ctx, "Failed to update active branch of session %s: %v", sessionID, err)
+			return err
+		}
+	}
+
 	logger.Info(ctx, "Message deleted successfully")
 	return nil
 }
+
+// GetMessageTree retrieves all messages of a session arranged by their branches
+// Parameters:
+//   - ctx: Context containing tenant information
+//   - sessionID: The ID of the session
+//
+// Returns the branch tree of the session or an error if retrieval fails
+func (s *messageService) GetMessageTree(ctx context.Context, sessionID string) (*types.MessageTree, error) {
+	logger.Infof(ctx, "Getting message tree for session ID: %s", sessionID)
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get session: %v", err)
+		return nil, err
+	}
+
+	messages, err := s.messageRepo.GetAllMessagesBySession(ctx, sessionID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+		})
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Retrieved message tree of %d messages successfully", len(messages))
+	return types.BuildMessageTree(messages, session.ActiveMessageID), nil
+}
+
+// BranchFrom makes a message the end of the active branch, so that the next message starts a new
+// branch after it. This is how an answer is regenerated (branching from its question) and a question
+// is edited (branching from the message before it).
+// Parameters:
+//   - ctx: Context containing tenant information
+//   - sessionID: The ID of the session
+//   - messageID: The message to branch from, empty to branch from the start of the session
+//
+// Returns the messages of the branch up to the message or an error if it fails
+func (s *messageService) BranchFrom(ctx context.Context,
+	sessionID string, messageID string,
+) ([]*types.Message, error) {
+	logger.Infof(ctx, "Branching session %s from message: %s", sessionID, messageID)
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.sessionRepo.Get(ctx, tenantID, sessionID); err != nil {
+		logger.Errorf(ctx, "Failed to get session: %v", err)
+		return nil, err
+	}
+	if messageID != "" {
+		if _, err := s.messageRepo.GetMessage(ctx, sessionID, messageID); err != nil {
+			logger.Errorf(ctx, "Failed to get message to branch from: %v", err)
+			return nil, err
+		}
+	}
+
+	if err := s.sessionRepo.UpdateActiveMessage(ctx, tenantID, sessionID, messageID); err != nil {
+		logger.Errorf(ctx, "Failed to update active branch of session %s: %v", sessionID, err)
+		return nil, err
+	}
+	if messageID == "" {
+		return []*types.Message{}, nil
+	}
+	return s.getBranchMessages(ctx, sessionID, messageID, 0, nil)
+}
+
+// SwitchBranch activates the branch through a message. The branch continues after the message with
+// its most recent follower at each fork, so selecting a question selects its latest answer.
+// Parameters:
+//   - ctx: Context containing tenant information
+//   - sessionID: The ID of the session
+//   - messageID: Any message of the branch to activate
+//
+// Returns the messages of the activated branch or an error if it fails
+func (s *messageService) SwitchBranch(ctx context.Context,
+	sessionID string, messageID string,
+) ([]*types.Message, error) {
+	logger.Infof(ctx, "Switching session %s to the branch of message: %s", sessionID, messageID)
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.sessionRepo.Get(ctx, tenantID, sessionID); err != nil {
+		logger.Errorf(ctx, "Failed to get session: %v", err)
+		return nil, err
+	}
+	if _, err := s.messageRepo.GetMessage(ctx, sessionID, messageID); err != nil {
+		logger.Errorf(ctx, "Failed to get message of the branch: %v", err)
+		return nil, err
+	}
+
+	links, err := s.messageRepo.GetMessageLinksBySession(ctx, sessionID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+		})
+		return nil, err
+	}
+	leafID := types.BranchLeaf(links, messageID)
+	if err := s.sessionRepo.UpdateActiveMessage(ctx, tenantID, sessionID, leafID); err != nil {
+		logger.Errorf(ctx, "Failed to update active branch of session %s: %v", sessionID, err)
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Session %s switched to branch ending at message %s", sessionID, leafID)
+	return s.getBranchMessages(ctx, sessionID, leafID, 0, nil)
+}
+
+// getBranchMessages loads the last limit messages (all if limit is 0) of the branch ending at leafID
+// that are accepted by keep, in conversation order
+func (s *messageService) getBranchMessages(ctx context.Context,
+	sessionID string, leafID string, limit int, keep func(*types.Message) bool,
+) ([]*types.Message, error) {
+	links, err := s.messageRepo.GetMessageLinksBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	path := types.MessagePath(links, leafID)
+	ids := make([]string, 0, len(path))
+	for i := len(path) - 1; i >= 0 && (limit <= 0 || len(ids) < limit); i-- {
+		if keep == nil || keep(path[i]) {
+			ids = append(ids, path[i].ID)
+		}
+	}
+	slices.Reverse(ids)
+
+	messages, err := s.messageRepo.GetMessagesByIDs(ctx, sessionID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*types.Message, len(messages))
+	for _, message := range messages {
+		byID[message.ID] = message
+	}
+	ordered := make([]*types.Message, 0, len(ids))
+	for _, id := range ids {
+		if message, ok := byID[id]; ok {
+			ordered = append(ordered, message)
+		}
+	}
+	return ordered, nil
+}
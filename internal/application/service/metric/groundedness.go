@@ -0,0 +1,21 @@
+package metric
+
+import (
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// GroundednessMetric reports how well the generated text is supported by the retrieved chunks
+type GroundednessMetric struct{}
+
+// NewGroundednessMetric creates a new GroundednessMetric instance
+func NewGroundednessMetric() *GroundednessMetric {
+	return &GroundednessMetric{}
+}
+
+// Compute returns the groundedness score of the generated text, 0 if it could not be checked
+func (m *GroundednessMetric) Compute(metricInput *types.MetricInput) float64 {
+	if metricInput.Groundedness == nil {
+		return 0.0
+	}
+	return metricInput.Groundedness.Score
+}
@@ -47,6 +47,9 @@ var metricCalculators = []struct {
 	{metric.NewRougeMetric(true, "rouge-l", "f"), func(r *types.MetricResult) *float64 {
 		return &r.GenerationMetrics.ROUGEL
 	}},
+	{metric.NewGroundednessMetric(), func(r *types.MetricResult) *float64 {
+		return &r.GenerationMetrics.Groundedness
+	}},
 }
 
 // Append calculates and stores metrics for given input
@@ -94,6 +97,7 @@ type qaPairMetric struct {
 	searchResult []*types.SearchResult
 	rerankResult []*types.SearchResult
 	chatResponse *types.ChatResponse
+	groundedness *types.GroundednessResult
 }
 
 // NewHookMetric creates a new HookMetric with given capacity
@@ -130,6 +134,11 @@ func (h *HookMetric) recordChatResponse(index int, chatResponse *types.ChatRespo
 	h.qaPairMetricList[index].chatResponse = chatResponse
 }
 
+// recordGroundedness records the groundedness of the generated response
+func (h *HookMetric) recordGroundedness(index int, result *types.GroundednessResult) {
+	h.qaPairMetricList[index].groundedness = result
+}
+
 // recordFinish finalizes metrics for a QA pair
 func (h *HookMetric) recordFinish(index int) {
 	// Prepare retrieval IDs from rerank results
@@ -150,6 +159,7 @@ func (h *HookMetric) recordFinish(index int) {
 		RetrievalIDs:   retrievalIDs,
 		GeneratedTexts: generatedTexts,
 		GeneratedGT:    h.qaPairMetricList[index].qaPair.Answer,
+		Groundedness:   h.qaPairMetricList[index].groundedness,
 	}
 
 	// Thread-safe append of metrics
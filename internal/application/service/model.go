@@ -11,6 +11,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/usage"
 )
 
 // ErrModelNotFound is returned when a model cannot be found in the repository
@@ -20,13 +21,18 @@ var ErrModelNotFound = errors.New("model not found")
 type modelService struct {
 	repo          interfaces.ModelRepository
 	ollamaService *ollama.OllamaService
+	usageService  interfaces.UsageService
 }
 
-// NewModelService creates a new model service instance
-func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.OllamaService) interfaces.ModelService {
+// NewModelService creates a new model service instance, the models it returns record their usage
+func NewModelService(repo interfaces.ModelRepository,
+	ollamaService *ollama.OllamaService,
+	usageService interfaces.UsageService,
+) interfaces.ModelService {
 	return &modelService{
 		repo:          repo,
 		ollamaService: ollamaService,
+		usageService:  usageService,
 	}
 }
 
@@ -262,7 +268,7 @@ func (s *modelService) GetEmbeddingModel(ctx context.Context, modelId string) (e
 	}
 
 	logger.Info(ctx, "Embedding model initialized successfully")
-	return embedder, nil
+	return usage.WrapEmbedder(embedder, s.usageService), nil
 }
 
 // GetRerankModel retrieves and initializes a reranking model instance
@@ -296,7 +302,7 @@ func (s *modelService) GetRerankModel(ctx context.Context, modelId string) (rera
 	}
 
 	logger.Info(ctx, "Rerank model initialized successfully")
-	return reranker, nil
+	return usage.WrapReranker(reranker, s.usageService), nil
 }
 
 // GetChatModel retrieves and initializes a chat model instance
@@ -329,11 +335,13 @@ func (s *modelService) GetChatModel(ctx context.Context, modelId string) (chat.C
 
 	// Initialize the chat model with model configuration
 	chatModel, err := chat.NewChat(&chat.ChatConfig{
-		ModelID:   model.ID,
-		APIKey:    model.Parameters.APIKey,
-		BaseURL:   model.Parameters.BaseURL,
-		ModelName: model.Name,
-		Source:    model.Source,
+		ModelID:          model.ID,
+		APIKey:           model.Parameters.APIKey,
+		BaseURL:          model.Parameters.BaseURL,
+		ModelName:        model.Name,
+		Source:           model.Source,
+		StructuredOutput: model.Parameters.SupportsStructuredOutput,
+		StreamUsage:      model.Parameters.SupportsStreamUsage,
 	})
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
@@ -343,7 +351,7 @@ func (s *modelService) GetChatModel(ctx context.Context, modelId string) (chat.C
 		return nil, err
 	}
 
-	return chatModel, nil
+	return usage.WrapChat(chatModel, model.Type, s.usageService), nil
 }
 
 // Note: default model selection logic has been removed; models no longer
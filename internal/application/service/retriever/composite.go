@@ -102,6 +102,18 @@ func (c *CompositeRetrieveEngine) SupportRetriever(r types.RetrieverType) bool {
 	return false
 }
 
+// SupportMetadataFilter checks if the engine of a retriever type evaluates metadata filters itself
+func (c *CompositeRetrieveEngine) SupportMetadataFilter(r types.RetrieverType) bool {
+	for _, engineInfo := range c.engineInfos {
+		if engineInfo == nil || !slices.Contains(engineInfo.retrieverType, r) {
+			continue
+		}
+		engine, ok := engineInfo.retrieveEngine.(interfaces.MetadataFilterRetrieveEngine)
+		return ok && engine.SupportMetadataFilter()
+	}
+	return false
+}
+
 // BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 func (c *CompositeRetrieveEngine) BatchUpdateChunkEnabledStatus(
 	ctx context.Context,
@@ -0,0 +1,161 @@
+package retriever
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// retrieverOrder fixes the order in which result lists are visited, so the
+// chunk information kept for duplicates does not depend on goroutine timing
+var retrieverOrder = map[types.RetrieverType]int{
+	types.VectorRetrieverType:   0,
+	types.KeywordsRetrieverType: 1,
+}
+
+// FuseResults merges the result lists of several retrievers into one list,
+// deduplicated by chunk ID and ordered by fused score (highest first).
+// The fused score replaces the Score of each returned item.
+func FuseResults(results []*types.RetrieveResult, config *types.FusionConfig) []*types.IndexWithScore {
+	lists := make([]*types.RetrieveResult, 0, len(results))
+	for _, result := range results {
+		if result != nil && len(result.Results) > 0 {
+			lists = append(lists, result)
+		}
+	}
+	slices.SortStableFunc(lists, func(a, b *types.RetrieveResult) int {
+		return orderOf(a.RetrieverType) - orderOf(b.RetrieverType)
+	})
+
+	chunkInfo := make(map[string]*types.IndexWithScore)
+	var chunkOrder []string
+	fusedScores := make(map[string]float64)
+	for _, list := range lists {
+		weight := config.GetWeight(list.RetrieverType)
+		for chunkID, score := range normalizedScores(list.Results, config) {
+			fusedScores[chunkID] += weight * score
+		}
+		for _, item := range list.Results {
+			if _, exists := chunkInfo[item.ChunkID]; !exists {
+				chunkInfo[item.ChunkID] = item
+				chunkOrder = append(chunkOrder, item.ChunkID)
+			}
+		}
+	}
+
+	fused := make([]*types.IndexWithScore, 0, len(chunkOrder))
+	for _, chunkID := range chunkOrder {
+		info := chunkInfo[chunkID]
+		info.Score = fusedScores[chunkID]
+		fused = append(fused, info)
+	}
+	slices.SortStableFunc(fused, func(a, b *types.IndexWithScore) int {
+		if a.Score > b.Score {
+			return -1
+		} else if a.Score < b.Score {
+			return 1
+		}
+		return 0
+	})
+	return fused
+}
+
+// MergeResults merges the result lists of the same engine and retriever type, e.g. of retrievals split into
+// batches of knowledge, into one list ordered by score and cut to topK
+func MergeResults(results []*types.RetrieveResult, topK int) []*types.RetrieveResult {
+	type listKey struct {
+		engineType    types.RetrieverEngineType
+		retrieverType types.RetrieverType
+	}
+	var merged []*types.RetrieveResult
+	lists := make(map[listKey]*types.RetrieveResult)
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		key := listKey{engineType: result.RetrieverEngineType, retrieverType: result.RetrieverType}
+		list, ok := lists[key]
+		if !ok {
+			list = &types.RetrieveResult{RetrieverEngineType: key.engineType, RetrieverType: key.retrieverType}
+			lists[key] = list
+			merged = append(merged, list)
+		}
+		list.Results = append(list.Results, result.Results...)
+		list.Error = errors.Join(list.Error, result.Error)
+	}
+	for _, list := range merged {
+		slices.SortStableFunc(list.Results, func(a, b *types.IndexWithScore) int {
+			if c := cmp.Compare(b.Score, a.Score); c != 0 {
+				return c
+			}
+			return strings.Compare(a.ChunkID, b.ChunkID)
+		})
+		if topK > 0 && len(list.Results) > topK {
+			list.Results = list.Results[:topK]
+		}
+	}
+	return merged
+}
+
+func orderOf(retrieverType types.RetrieverType) int {
+	if order, ok := retrieverOrder[retrieverType]; ok {
+		return order
+	}
+	return len(retrieverOrder)
+}
+
+// normalizedScores returns the per-chunk contribution of a single result list
+// before weighting. Only the first (best) occurrence of each chunk counts.
+func normalizedScores(items []*types.IndexWithScore, config *types.FusionConfig) map[string]float64 {
+	scores := make(map[string]float64, len(items))
+	switch config.GetMethod() {
+	case types.FusionMethodWeighted:
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, item := range items {
+			lo = math.Min(lo, item.Score)
+			hi = math.Max(hi, item.Score)
+		}
+		for _, item := range items {
+			if _, exists := scores[item.ChunkID]; !exists {
+				scores[item.ChunkID] = scaleScore(item.Score, lo, hi)
+			}
+		}
+	case types.FusionMethodDBSF:
+		var mean, variance float64
+		for _, item := range items {
+			mean += item.Score
+		}
+		mean /= float64(len(items))
+		for _, item := range items {
+			variance += (item.Score - mean) * (item.Score - mean)
+		}
+		std := math.Sqrt(variance / float64(len(items)))
+		for _, item := range items {
+			if _, exists := scores[item.ChunkID]; !exists {
+				scores[item.ChunkID] = scaleScore(item.Score, mean-3*std, mean+3*std)
+			}
+		}
+	default:
+		// Results are already sorted by score, so the position is the rank
+		k := float64(config.GetRRFK())
+		for i, item := range items {
+			if _, exists := scores[item.ChunkID]; !exists {
+				scores[item.ChunkID] = 1.0 / (k + float64(i+1))
+			}
+		}
+	}
+	return scores
+}
+
+// scaleScore maps score from [lo, hi] onto [0, 1], clamping values outside the range
+// A degenerate range (all scores equal) maps every score to 1
+func scaleScore(score, lo, hi float64) float64 {
+	if hi <= lo {
+		return 1
+	}
+	return math.Max(0, math.Min(1, (score-lo)/(hi-lo)))
+}
@@ -0,0 +1,155 @@
+package retriever
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func buildResults() []*types.RetrieveResult {
+	return []*types.RetrieveResult{
+		{
+			RetrieverType: types.KeywordsRetrieverType,
+			Results: []*types.IndexWithScore{
+				{ChunkID: "b", Score: 12},
+				{ChunkID: "c", Score: 6},
+				{ChunkID: "d", Score: 3},
+			},
+		},
+		{
+			RetrieverType: types.VectorRetrieverType,
+			Results: []*types.IndexWithScore{
+				{ChunkID: "a", Score: 0.9},
+				{ChunkID: "b", Score: 0.8},
+				{ChunkID: "a", Score: 0.7}, // duplicate chunk, e.g. a second FAQ question
+				{ChunkID: "c", Score: 0.5},
+			},
+		},
+	}
+}
+
+func chunkIDs(items []*types.IndexWithScore) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ChunkID
+	}
+	return ids
+}
+
+func scoreOf(items []*types.IndexWithScore, chunkID string) float64 {
+	for _, item := range items {
+		if item.ChunkID == chunkID {
+			return item.Score
+		}
+	}
+	return math.NaN()
+}
+
+func TestFuseResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *types.FusionConfig
+		expected []string
+		scores   map[string]float64
+	}{
+		{
+			name:     "default rrf",
+			config:   nil,
+			expected: []string{"b", "c", "a", "d"},
+			scores: map[string]float64{
+				"a": 1.0 / 61,
+				"b": 1.0/62 + 1.0/61,
+				"c": 1.0/64 + 1.0/62,
+				"d": 1.0 / 63,
+			},
+		},
+		{
+			name:     "weighted rrf",
+			config:   &types.FusionConfig{Method: types.FusionMethodRRF, RRFK: 1, Weights: map[types.RetrieverType]float64{types.KeywordsRetrieverType: 0}},
+			expected: []string{"a", "b", "c", "d"},
+			scores:   map[string]float64{"a": 1.0 / 2, "b": 1.0 / 3, "c": 1.0 / 5, "d": 0},
+		},
+		{
+			name:     "min-max weighted",
+			config:   &types.FusionConfig{Method: types.FusionMethodWeighted, Weights: map[types.RetrieverType]float64{types.VectorRetrieverType: 2}},
+			expected: []string{"b", "a", "c", "d"},
+			scores:   map[string]float64{"a": 2, "b": 1 + 2*0.75, "c": 1.0 / 3, "d": 0},
+		},
+		{
+			name:     "distribution based",
+			config:   &types.FusionConfig{Method: types.FusionMethodDBSF},
+			expected: []string{"b", "c", "a", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fused := FuseResults(buildResults(), tt.config)
+			got := chunkIDs(fused)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("expected %v, got %v", tt.expected, got)
+				}
+			}
+			for chunkID, want := range tt.scores {
+				if got := scoreOf(fused, chunkID); math.Abs(got-want) > 1e-9 {
+					t.Errorf("score of %s = %v, want %v", chunkID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	// Two knowledge batches of the vector retriever and one keyword list
+	results := []*types.RetrieveResult{
+		{
+			RetrieverEngineType: types.ElasticsearchRetrieverEngineType,
+			RetrieverType:       types.VectorRetrieverType,
+			Results:             []*types.IndexWithScore{{ChunkID: "a", Score: 0.7}, {ChunkID: "b", Score: 0.4}},
+		},
+		{
+			RetrieverEngineType: types.ElasticsearchRetrieverEngineType,
+			RetrieverType:       types.KeywordsRetrieverType,
+			Results:             []*types.IndexWithScore{{ChunkID: "k", Score: 3}},
+		},
+		{
+			RetrieverEngineType: types.ElasticsearchRetrieverEngineType,
+			RetrieverType:       types.VectorRetrieverType,
+			Results:             []*types.IndexWithScore{{ChunkID: "c", Score: 0.9}, {ChunkID: "d", Score: 0.5}},
+		},
+	}
+	merged := MergeResults(results, 3)
+	if len(merged) != 2 {
+		t.Fatalf("expected a vector and a keyword list, got %d lists", len(merged))
+	}
+	if got, want := chunkIDs(merged[0].Results), []string{"c", "a", "d"}; !slices.Equal(got, want) {
+		t.Errorf("vector results = %v, want %v", got, want)
+	}
+	if got, want := chunkIDs(merged[1].Results), []string{"k"}; !slices.Equal(got, want) {
+		t.Errorf("keyword results = %v, want %v", got, want)
+	}
+}
+
+func TestFusionConfigValidate(t *testing.T) {
+	tests := []struct {
+		config  *types.FusionConfig
+		wantErr bool
+	}{
+		{nil, false},
+		{&types.FusionConfig{}, false},
+		{&types.FusionConfig{Method: types.FusionMethodDBSF}, false},
+		{&types.FusionConfig{Method: "max"}, true},
+		{&types.FusionConfig{RRFK: -1}, true},
+		{&types.FusionConfig{Weights: map[types.RetrieverType]float64{types.VectorRetrieverType: -0.5}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
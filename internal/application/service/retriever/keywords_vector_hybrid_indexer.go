@@ -135,6 +135,12 @@ func (v *KeywordsVectorHybridRetrieveEngineService) Support() []types.RetrieverT
 	return v.indexRepository.Support()
 }
 
+// SupportMetadataFilter reports whether the repository evaluates metadata filters itself
+func (v *KeywordsVectorHybridRetrieveEngineService) SupportMetadataFilter() bool {
+	engine, ok := v.indexRepository.(interfaces.MetadataFilterRetrieveEngine)
+	return ok && engine.SupportMetadataFilter()
+}
+
 // EstimateStorageSize estimates the storage space needed for the provided index information
 func (v *KeywordsVectorHybridRetrieveEngineService) EstimateStorageSize(
 	ctx context.Context,
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"text/template"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -40,7 +42,9 @@ type sessionService struct {
 	modelService         interfaces.ModelService         // Service for model operations
 	tenantService        interfaces.TenantService        // Service for tenant operations
 	eventManager         *chatpipline.EventManager       // Event manager for chat pipeline
+	pipelineRegistry     *chatpipline.PipelineRegistry   // Registry resolving chat pipeline definitions
 	agentService         interfaces.AgentService         // Service for agent operations
+	agentRunService      interfaces.AgentRunService      // Service checkpointing agent runs
 	sessionStorage       llmcontext.ContextStorage       // Session storage
 	knowledgeService     interfaces.KnowledgeService     // Service for knowledge operations
 	redisClient          *redis.Client                   // Redis client for temp KB state
@@ -55,7 +59,9 @@ func NewSessionService(cfg *config.Config,
 	modelService interfaces.ModelService,
 	tenantService interfaces.TenantService,
 	eventManager *chatpipline.EventManager,
+	pipelineRegistry *chatpipline.PipelineRegistry,
 	agentService interfaces.AgentService,
+	agentRunService interfaces.AgentRunService,
 	sessionStorage llmcontext.ContextStorage,
 	redisClient *redis.Client,
 ) interfaces.SessionService {
@@ -68,7 +74,9 @@ func NewSessionService(cfg *config.Config,
 		modelService:         modelService,
 		tenantService:        tenantService,
 		eventManager:         eventManager,
+		pipelineRegistry:     pipelineRegistry,
 		agentService:         agentService,
+		agentRunService:      agentRunService,
 		sessionStorage:       sessionStorage,
 		redisClient:          redisClient,
 	}
@@ -230,6 +238,8 @@ func (s *sessionService) GenerateTitle(ctx context.Context,
 	if session.Title != "" {
 		return session.Title, nil
 	}
+	ctx = types.WithUsageStage(types.WithUsageScope(ctx, session.ID, session.KnowledgeBaseID),
+		types.UsageStageTitleGeneration)
 	var err error
 	// Get the first user message, either from provided messages or repository
 	var message *types.Message
@@ -397,6 +407,8 @@ func (s *sessionService) KnowledgeQA(
 	assistantMessageID string,
 	summaryModelID string,
 	webSearchEnabled bool,
+	filter *types.MetadataFilter,
+	responseSchema *types.ResponseSchema,
 	eventBus *event.EventBus,
 ) error {
 	logger.Infof(
@@ -419,6 +431,8 @@ func (s *sessionService) KnowledgeQA(
 	}
 
 	logger.Infof(ctx, "Using knowledge bases: %v", knowledgeBaseIDs)
+	// Usage of multi knowledge base questions is attributed to the first knowledge base
+	ctx = types.WithUsageScope(ctx, session.ID, knowledgeBaseIDs[0])
 
 	// Determine chat model ID: prioritize request's summaryModelID, then Remote models
 	chatModelID, err := s.selectChatModelIDWithOverride(ctx, session, knowledgeBaseIDs, summaryModelID)
@@ -447,6 +461,14 @@ func (s *sessionService) KnowledgeQA(
 	fallbackPrompt := ""
 	enableRewrite := session.EnableRewrite
 	enableQueryExpansion := true
+	enableHyDE := s.cfg.Conversation.EnableHyDE
+	enableMultiQuery := s.cfg.Conversation.EnableMultiQuery
+	multiQueryCount := s.cfg.Conversation.MultiQueryCount
+	enableAnswerCache := s.cfg.Conversation.EnableAnswerCache
+	answerCacheThreshold := s.cfg.Conversation.AnswerCacheThreshold
+	var fusionConfig *types.FusionConfig
+	groundednessConfig := s.cfg.Conversation.Groundedness
+	feedbackRerank := s.cfg.Conversation.FeedbackRerank
 
 	summaryParams := session.SummaryParameters
 	if summaryParams == nil {
@@ -484,6 +506,22 @@ func (s *sessionService) KnowledgeQA(
 		}
 		enableRewrite = tenantConv.EnableRewrite
 		enableQueryExpansion = tenantConv.EnableQueryExpansion
+		enableHyDE = tenantConv.EnableHyDE
+		enableMultiQuery = tenantConv.EnableMultiQuery
+		if tenantConv.MultiQueryCount > 0 {
+			multiQueryCount = tenantConv.MultiQueryCount
+		}
+		enableAnswerCache = tenantConv.EnableAnswerCache
+		if tenantConv.AnswerCacheThreshold > 0 {
+			answerCacheThreshold = tenantConv.AnswerCacheThreshold
+		}
+		fusionConfig = tenantConv.FusionConfig
+		if tenantConv.Groundedness != nil {
+			groundednessConfig = tenantConv.Groundedness
+		}
+		if tenantConv.FeedbackRerank != nil {
+			feedbackRerank = tenantConv.FeedbackRerank
+		}
 
 		if tenantConv.MaxCompletionTokens != 0 {
 			summaryConfig.MaxCompletionTokens = tenantConv.MaxCompletionTokens
@@ -531,6 +569,8 @@ func (s *sessionService) KnowledgeQA(
 		RerankModelID:        rerankModelID,
 		RerankTopK:           rerankTopK,
 		RerankThreshold:      rerankThreshold,
+		FusionConfig:         fusionConfig,
+		Filter:               filter,
 		MaxRounds:            maxRounds,
 		ChatModelID:          chatModelID,
 		SummaryConfig:        summaryConfig,
@@ -544,11 +584,29 @@ func (s *sessionService) KnowledgeQA(
 		RewritePromptUser:    rewritePromptUser,
 		EnableRewrite:        enableRewrite,
 		EnableQueryExpansion: enableQueryExpansion,
+		EnableHyDE:           enableHyDE,
+		EnableMultiQuery:     enableMultiQuery,
+		MultiQueryCount:      multiQueryCount,
+		EnableAnswerCache:    enableAnswerCache,
+		AnswerCacheThreshold: answerCacheThreshold,
+		Groundedness:         groundednessConfig,
+		FeedbackRerank:       feedbackRerank,
+		ResponseSchema:       responseSchema,
+	}
+
+	// Resolve the pipeline selected by the session, falling back to the tenant and system defaults
+	pipeline, err := s.pipelineRegistry.Resolve(session.Pipeline, tenantConv)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to resolve pipeline %q: %v", session.Pipeline, err)
+		return err
+	}
+	if pipeline.NeedsKnowledgeBaseTypes() {
+		chatManage.KnowledgeBaseTypes = s.getKnowledgeBaseTypes(ctx, knowledgeBaseIDs)
 	}
 
 	// Start knowledge QA event processing
-	logger.Info(ctx, "Triggering knowledge base question answering event")
-	err = s.KnowledgeQAByEvent(ctx, chatManage, types.Pipline["rag_stream"])
+	logger.Infof(ctx, "Triggering knowledge base question answering pipeline: %s", pipeline.Name)
+	err = s.runPipeline(ctx, chatManage, pipeline)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id":        session.ID,
@@ -572,6 +630,11 @@ func (s *sessionService) KnowledgeQA(
 		}
 	}
 
+	// Replay the cached answer after its references
+	if chatManage.AnswerCached && chatManage.ChatResponse != nil {
+		s.emitCachedAnswer(ctx, chatManage)
+	}
+
 	// Note: Answer events are now emitted directly by chat_completion_stream plugin
 	// Completion event will be emitted when the last answer event has Done=true
 	// We can optionally add a completion watcher here if needed, but for now
@@ -692,6 +755,13 @@ func (s *sessionService) selectChatModelID(
 // KnowledgeQAByEvent processes knowledge QA through a series of events in the pipeline
 func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 	chatManage *types.ChatManage, eventList []types.EventType,
+) error {
+	return s.runPipeline(ctx, chatManage, types.NewPipelineDefinition("", eventList))
+}
+
+// runPipeline processes knowledge QA through the stages of the pipeline
+func (s *sessionService) runPipeline(ctx context.Context,
+	chatManage *types.ChatManage, pipeline *types.PipelineDefinition,
 ) error {
 	ctx, span := tracing.ContextWithSpan(ctx, "SessionService.KnowledgeQAByEvent")
 	defer span.End()
@@ -702,8 +772,8 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 
 	// Prepare method list for logging and tracing
 	methods := []string{}
-	for _, event := range eventList {
-		methods = append(methods, string(event))
+	for _, stage := range pipeline.Stages {
+		methods = append(methods, string(stage.Event))
 	}
 
 	// Set up tracing attributes
@@ -714,10 +784,21 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 		attribute.String("method", strings.Join(methods, ",")),
 	)
 
-	// Process each event in sequence
-	for _, eventType := range eventList {
+	// Process each stage in sequence
+	for _, stage := range pipeline.Stages {
+		eventType := stage.Event
+		if !stage.When.Matches(chatManage) {
+			logger.Infof(ctx, "Skipping event %v, stage condition not met", eventType)
+			continue
+		}
+		restoreParams, paramErr := chatManage.ApplyParams(stage.Params)
+		if paramErr != nil {
+			logger.Errorf(ctx, "Invalid params of event %v: %v", eventType, paramErr)
+			return paramErr
+		}
 		logger.Infof(ctx, "Starting to trigger event: %v", eventType)
 		err := s.eventManager.Trigger(ctx, eventType, chatManage)
+		restoreParams()
 
 		// Handle case where search returns no results
 		if err == chatpipline.ErrSearchNothing {
@@ -731,6 +812,19 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 			return nil
 		}
 
+		// A cached answer was found, the remaining stages are skipped
+		if err == chatpipline.ErrAnswerCached {
+			logger.Infof(ctx, "Event %v triggered, replaying cached answer", eventType)
+			return nil
+		}
+
+		// Optional stages do not fail the pipeline
+		if err != nil && stage.Optional {
+			logger.Warnf(ctx, "Optional event %v failed, continuing, error type: %s, error: %v",
+				eventType, err.ErrorType, err.Err)
+			continue
+		}
+
 		// Handle other errors
 		if err != nil {
 			logger.Errorf(ctx, "Event triggering failed, event: %v, error type: %s, description: %s, error: %v",
@@ -758,12 +852,29 @@ func getTenantConversationConfig(ctx context.Context) (*types.ConversationConfig
 	return tenant.ConversationConfig, nil
 }
 
+// getKnowledgeBaseTypes returns the distinct types of the knowledge bases, skipping those that cannot be loaded
+func (s *sessionService) getKnowledgeBaseTypes(ctx context.Context, knowledgeBaseIDs []string) []string {
+	var kbTypes []string
+	for _, kbID := range knowledgeBaseIDs {
+		kb, err := s.knowledgeBaseService.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to get knowledge base %s for pipeline conditions: %v", kbID, err)
+			continue
+		}
+		if !slices.Contains(kbTypes, kb.Type) {
+			kbTypes = append(kbTypes, kb.Type)
+		}
+	}
+	return kbTypes
+}
+
 // SearchKnowledge performs knowledge base search without LLM summarization
 func (s *sessionService) SearchKnowledge(ctx context.Context,
-	knowledgeBaseID, query string,
+	knowledgeBaseID, query string, filter *types.MetadataFilter,
 ) ([]*types.SearchResult, error) {
 	logger.Info(ctx, "Start knowledge base search without LLM summary")
 	logger.Infof(ctx, "Knowledge base search parameters, knowledge base ID: %s, query: %s", knowledgeBaseID, query)
+	ctx = types.WithUsageStage(types.WithUsageScope(ctx, "", knowledgeBaseID), types.UsageStageSearch)
 
 	// Create default retrieval parameters
 	chatManage := &types.ChatManage{
@@ -778,6 +889,7 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 		MaxRounds:           s.cfg.Conversation.MaxRounds,
 		RewritePromptSystem: s.cfg.Conversation.RewritePromptSystem,
 		RewritePromptUser:   s.cfg.Conversation.RewritePromptUser,
+		Filter:              filter,
 	}
 
 	// Get default models
@@ -856,6 +968,7 @@ func (s *sessionService) AgentQA(
 	session *types.Session,
 	query string,
 	assistantMessageID string,
+	responseSchema *types.ResponseSchema,
 	eventBus *event.EventBus,
 ) error {
 	sessionID := session.ID
@@ -897,12 +1010,20 @@ func (s *sessionService) AgentQA(
 	// Tenant config provides the runtime parameters (MaxIterations, Temperature, Tools, Models)
 	// Session config provides KnowledgeBases
 	agentConfig := &types.AgentConfig{
-		MaxIterations:     tenantInfo.AgentConfig.MaxIterations,
-		ReflectionEnabled: tenantInfo.AgentConfig.ReflectionEnabled,
-		AllowedTools:      tools.DefaultAllowedTools(),
-		Temperature:       tenantInfo.AgentConfig.Temperature,
-		KnowledgeBases:    session.AgentConfig.KnowledgeBases,   // Use session's knowledge bases
-		WebSearchEnabled:  session.AgentConfig.WebSearchEnabled, // Web search enabled from session config
+		MaxIterations:      tenantInfo.AgentConfig.MaxIterations,
+		ReflectionEnabled:  tenantInfo.AgentConfig.ReflectionEnabled,
+		AllowedTools:       tools.DefaultAllowedTools(),
+		Temperature:        tenantInfo.AgentConfig.Temperature,
+		KnowledgeBases:     session.AgentConfig.KnowledgeBases,   // Use session's knowledge bases
+		WebSearchEnabled:   session.AgentConfig.WebSearchEnabled, // Web search enabled from session config
+		MaxParallelTools:   tenantInfo.AgentConfig.MaxParallelTools,
+		ToolTimeoutSeconds: tenantInfo.AgentConfig.ToolTimeoutSeconds,
+		ToolPolicies:       tenantInfo.AgentConfig.ToolPolicies,
+		ResponseSchema:     responseSchema,
+		Groundedness:       s.cfg.Conversation.Groundedness,
+	}
+	if tenantInfo.ConversationConfig != nil && tenantInfo.ConversationConfig.Groundedness != nil {
+		agentConfig.Groundedness = tenantInfo.ConversationConfig.Groundedness
 	}
 
 	agentConfig.UseCustomSystemPrompt = tenantInfo.AgentConfig.UseCustomSystemPrompt
@@ -955,6 +1076,109 @@ func (s *sessionService) AgentQA(
 		logger.Infof(ctx, "Agent configured with %d knowledge base(s): %v",
 			len(agentConfig.KnowledgeBases), agentConfig.KnowledgeBases)
 	}
+	ctx = types.WithUsageStage(types.WithUsageScope(ctx, sessionID, agentConfig.KnowledgeBases[0]), types.UsageStageAgent)
+
+	engine, contextManager, err := s.createAgentEngine(ctx, session, agentConfig, assistantMessageID, eventBus)
+	if err != nil {
+		return err
+	}
+	// Get LLM context from context manager
+	llmContext, err := s.getContextForSession(ctx, contextManager, sessionID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get LLM context: %v, continuing without history", err)
+		llmContext = []chat.Message{}
+	}
+	logger.Infof(ctx, "Loaded %d messages from LLM context manager", len(llmContext))
+
+	// Checkpoint the run after every round, so it can be resumed if it is interrupted
+	run, err := s.agentRunService.StartRun(ctx, sessionID, assistantMessageID, query)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to start agent run, continuing without checkpoints: %v", err)
+	} else {
+		engine.SetCheckpointer(s.agentRunService.Checkpointer(run, agentConfig))
+	}
+
+	// Execute agent with streaming (asynchronously)
+	// Events will be emitted to EventBus and handled by the Handler layer
+	logger.Info(ctx, "Executing agent with streaming")
+	_, err = engine.Execute(ctx, sessionID, assistantMessageID, query, llmContext)
+	if run != nil {
+		s.agentRunService.FinishRun(ctx, run, err)
+	}
+	if err != nil {
+		s.emitAgentError(ctx, eventBus, sessionID, err)
+	}
+	// Return empty - events will be handled by Handler via EventBus subscription
+	return nil
+}
+
+// ResumeAgentRun continues an interrupted agent run of the session from its last checkpoint,
+// the run must have been claimed with AgentRunService.ResumeRun
+func (s *sessionService) ResumeAgentRun(
+	ctx context.Context,
+	session *types.Session,
+	run *types.AgentRun,
+	eventBus *event.EventBus,
+) error {
+	if run.Checkpoint == nil || run.Checkpoint.Config == nil || run.Checkpoint.State == nil {
+		return errors.New("agent run has no checkpoint to resume from")
+	}
+	var messages []chat.Message
+	if err := json.Unmarshal(run.Checkpoint.Messages, &messages); err != nil {
+		logger.Errorf(ctx, "Failed to decode checkpoint messages of agent run %s: %v", run.ID, err)
+		err = fmt.Errorf("failed to decode checkpoint: %w", err)
+		s.agentRunService.FinishRun(ctx, run, err)
+		return err
+	}
+	logger.Infof(ctx, "Resume agent run %s of session %s at round %d", run.ID, session.ID, run.CurrentRound+1)
+
+	// The run continues with the configuration it was started with
+	agentConfig := run.Checkpoint.Config
+	if len(agentConfig.KnowledgeBases) > 0 {
+		ctx = types.WithUsageScope(ctx, session.ID, agentConfig.KnowledgeBases[0])
+	}
+	ctx = types.WithUsageStage(ctx, types.UsageStageAgent)
+
+	engine, _, err := s.createAgentEngine(ctx, session, agentConfig, run.MessageID, eventBus)
+	if err != nil {
+		// Release the claim so the run can be resumed again
+		s.agentRunService.FinishRun(ctx, run, err)
+		return err
+	}
+	engine.SetCheckpointer(s.agentRunService.Checkpointer(run, agentConfig))
+
+	_, err = engine.Resume(ctx, session.ID, run.MessageID, run.Query, run.Checkpoint.State, messages)
+	s.agentRunService.FinishRun(ctx, run, err)
+	if err != nil {
+		s.emitAgentError(ctx, eventBus, session.ID, err)
+	}
+	return nil
+}
+
+// emitAgentError emits the error of an agent execution to the EventBus used by the agent
+func (s *sessionService) emitAgentError(ctx context.Context, eventBus *event.EventBus, sessionID string, err error) {
+	logger.Errorf(ctx, "Agent execution failed: %v", err)
+	eventBus.Emit(ctx, event.Event{
+		Type:      event.EventError,
+		SessionID: sessionID,
+		Data: event.ErrorData{
+			Error:     err.Error(),
+			Stage:     "agent_execution",
+			SessionID: sessionID,
+		},
+	})
+}
+
+// createAgentEngine resolves the models of the session and creates an agent engine for the configuration
+// answering into the assistant message
+func (s *sessionService) createAgentEngine(
+	ctx context.Context,
+	session *types.Session,
+	agentConfig *types.AgentConfig,
+	messageID string,
+	eventBus *event.EventBus,
+) (interfaces.AgentEngine, interfaces.ContextManager, error) {
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 
 	summaryModelID := session.SummaryModelID
 	if summaryModelID == "" && tenantInfo.ConversationConfig != nil {
@@ -962,13 +1186,13 @@ func (s *sessionService) AgentQA(
 	}
 	if summaryModelID == "" {
 		logger.Warnf(ctx, "No summary model configured for tenant %d or session %s", tenantInfo.ID, session.ID)
-		return errors.New("summary model is not configured in conversation settings")
+		return nil, nil, errors.New("summary model is not configured in conversation settings")
 	}
 
 	summaryModel, err := s.modelService.GetChatModel(ctx, summaryModelID)
 	if err != nil {
 		logger.Warnf(ctx, "Failed to get chat model: %v", err)
-		return fmt.Errorf("failed to get chat model: %w", err)
+		return nil, nil, fmt.Errorf("failed to get chat model: %w", err)
 	}
 
 	rerankModelID := session.RerankModelID
@@ -977,24 +1201,17 @@ func (s *sessionService) AgentQA(
 	}
 	if rerankModelID == "" {
 		logger.Warnf(ctx, "No rerank model configured for tenant %d or session %s", tenantInfo.ID, session.ID)
-		return errors.New("rerank model is not configured in conversation settings")
+		return nil, nil, errors.New("rerank model is not configured in conversation settings")
 	}
 
 	rerankModel, err := s.modelService.GetRerankModel(ctx, rerankModelID)
 	if err != nil {
 		logger.Warnf(ctx, "Failed to get rerank model: %v", err)
-		return fmt.Errorf("failed to get rerank model: %w", err)
+		return nil, nil, fmt.Errorf("failed to get rerank model: %w", err)
 	}
 
 	// Get or create contextManager for this session
 	contextManager := s.getContextManagerForSession(ctx, session, summaryModel)
-	// Get LLM context from context manager
-	llmContext, err := s.getContextForSession(ctx, contextManager, sessionID)
-	if err != nil {
-		logger.Warnf(ctx, "Failed to get LLM context: %v, continuing without history", err)
-		llmContext = []chat.Message{}
-	}
-	logger.Infof(ctx, "Loaded %d messages from LLM context manager", len(llmContext))
 
 	// Create agent engine with EventBus and ContextManager
 	logger.Info(ctx, "Creating agent engine")
@@ -1006,31 +1223,14 @@ func (s *sessionService) AgentQA(
 		eventBus,
 		contextManager,
 		session.ID,
+		messageID,
 		s,
 	)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to create agent engine: %v", err)
-		return err
+		return nil, nil, err
 	}
-
-	// Execute agent with streaming (asynchronously)
-	// Events will be emitted to EventBus and handled by the Handler layer
-	logger.Info(ctx, "Executing agent with streaming")
-	if _, err := engine.Execute(ctx, sessionID, assistantMessageID, query, llmContext); err != nil {
-		logger.Errorf(ctx, "Agent execution failed: %v", err)
-		// Emit error event to the EventBus used by this agent
-		eventBus.Emit(ctx, event.Event{
-			Type:      event.EventError,
-			SessionID: sessionID,
-			Data: event.ErrorData{
-				Error:     err.Error(),
-				Stage:     "agent_execution",
-				SessionID: sessionID,
-			},
-		})
-	}
-	// Return empty - events will be handled by Handler via EventBus subscription
-	return nil
+	return engine, contextManager, nil
 }
 
 // getContextManagerForSession creates a context manager for the session based on configuration
@@ -1093,6 +1293,37 @@ func (s *sessionService) ClearContext(ctx context.Context, sessionID string) err
 	return s.sessionStorage.Delete(ctx, sessionID)
 }
 
+// RebuildContext replaces the LLM context of a session with the conversation of a branch
+// This keeps the agent history in line with the branch a message is regenerated or edited on
+func (s *sessionService) RebuildContext(ctx context.Context, session *types.Session, messages []*types.Message) error {
+	logger.Infof(ctx, "Rebuilding context for session %s from %d messages", session.ID, len(messages))
+
+	// The summary model is only needed by the smart compression strategy
+	var summaryModel chat.Chat
+	summaryModelID := session.SummaryModelID
+	if tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant); ok && summaryModelID == "" &&
+		tenant.ConversationConfig != nil {
+		summaryModelID = tenant.ConversationConfig.SummaryModelID
+	}
+	if summaryModelID != "" {
+		model, err := s.modelService.GetChatModel(ctx, summaryModelID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to get chat model for context compression: %v", err)
+		} else {
+			summaryModel = model
+		}
+	}
+
+	history := make([]chat.Message, 0, len(messages))
+	for _, message := range messages {
+		if (message.Role != "user" && message.Role != "assistant") || message.Content == "" {
+			continue
+		}
+		history = append(history, chat.Message{Role: message.Role, Content: message.Content})
+	}
+	return s.getContextManagerForSession(ctx, session, summaryModel).RebuildContext(ctx, session.ID, history)
+}
+
 // GetWebSearchTempKBState retrieves the temporary KB state for web search from Redis
 func (s *sessionService) GetWebSearchTempKBState(
 	ctx context.Context,
@@ -1335,6 +1566,23 @@ func (s *sessionService) consumeFallbackStream(
 	}
 }
 
+// emitCachedAnswer emits the answer replayed from the answer cache, its citation markers
+// refer to the cached references
+func (s *sessionService) emitCachedAnswer(ctx context.Context, chatManage *types.ChatManage) {
+	answer := event.AgentFinalAnswerData{Content: chatManage.ChatResponse.Content, Done: true}
+	if citationMap := searchutil.BuildCitationMap(answer.Content, chatManage.MergeResult); citationMap != nil {
+		answer.CitationMap = citationMap
+	}
+	if err := chatManage.EventBus.Emit(ctx, types.Event{
+		ID:        generateEventID("cached-answer"),
+		Type:      types.EventType(event.EventAgentFinalAnswer),
+		SessionID: chatManage.SessionID,
+		Data:      answer,
+	}); err != nil {
+		logger.Errorf(ctx, "Failed to emit cached answer event: %v", err)
+	}
+}
+
 // emitFallbackAnswer emits fallback answer event
 func (s *sessionService) emitFallbackAnswer(ctx context.Context, chatManage *types.ChatManage, content string) {
 	if chatManage.EventBus == nil {
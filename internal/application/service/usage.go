@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/usage"
+)
+
+// usageService implements the usage ledger service interface
+type usageService struct {
+	repo    interfaces.UsageRepository
+	pricing *usage.Pricing
+}
+
+// NewUsageService creates a new usage ledger service
+func NewUsageService(repo interfaces.UsageRepository, cfg *config.Config) interfaces.UsageService {
+	return &usageService{
+		repo:    repo,
+		pricing: usage.NewPricing(cfg.Usage),
+	}
+}
+
+// Record adds a model call to the usage ledger
+func (s *usageService) Record(ctx context.Context, record *types.UsageRecord) {
+	tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64)
+	if !ok || tenantID == 0 {
+		logger.Debugf(ctx, "Skip usage record of model %s without tenant", record.ModelName)
+		return
+	}
+	scope := types.UsageScopeFromContext(ctx)
+	record.TenantID = tenantID
+	record.SessionID = scope.SessionID
+	record.KnowledgeBaseID = scope.KnowledgeBaseID
+	record.Stage = scope.Stage
+	record.Cost = s.pricing.Cost(record)
+	// The call may finish right before its request context is cancelled
+	if err := s.repo.CreateRecord(context.WithoutCancel(ctx), record); err != nil {
+		logger.Warnf(ctx, "Failed to record usage of model %s: %v", record.ModelName, err)
+	}
+}
+
+// GetUsage aggregates the usage ledger of the tenant, the range defaults to the current month
+func (s *usageService) GetUsage(ctx context.Context, query *types.UsageQuery) (*types.UsageReport, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if query.StartTime.IsZero() {
+		query.StartTime, _ = types.UsagePeriod(time.Now())
+	}
+	if query.EndTime.IsZero() {
+		query.EndTime = time.Now()
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.Summarize(ctx, tenantID, query)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"tenant_id": tenantID,
+		})
+		return nil, err
+	}
+	report := &types.UsageReport{
+		StartTime: query.StartTime,
+		EndTime:   query.EndTime,
+		Currency:  s.pricing.Currency(),
+		Items:     items,
+	}
+	for _, item := range items {
+		report.Total.PromptTokens += item.PromptTokens
+		report.Total.CompletionTokens += item.CompletionTokens
+		report.Total.TotalTokens += item.TotalTokens
+		report.Total.CallCount += item.CallCount
+		report.Total.Cost += item.Cost
+	}
+	return report, nil
+}
+
+// GetTokenQuotaStatus returns the token usage of the tenant in the current month
+func (s *usageService) GetTokenQuotaStatus(ctx context.Context) (*types.TokenQuotaStatus, error) {
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	start, end := types.UsagePeriod(time.Now())
+	used, err := s.repo.SumTokens(ctx, tenantInfo.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &types.TokenQuotaStatus{
+		TokenQuota:  tenantInfo.TokenQuota,
+		TokensUsed:  used,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}, nil
+}
+
+// CheckTokenQuota returns a TokenQuotaExceededError if the tenant used up its monthly token quota
+func (s *usageService) CheckTokenQuota(ctx context.Context) error {
+	tenantInfo, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if !ok || tenantInfo.TokenQuota <= 0 {
+		return nil
+	}
+	status, err := s.GetTokenQuotaStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Exceeded() {
+		logger.Warnf(ctx, "Tenant %d exceeded its monthly token quota: %d/%d",
+			tenantInfo.ID, status.TokensUsed, status.TokenQuota)
+		return types.NewTokenQuotaExceededError()
+	}
+	return nil
+}
@@ -91,6 +91,26 @@ var engine = map[string][]types.RetrieverEngineParams{
 			RetrieverEngineType: types.ElasticsearchRetrieverEngineType,
 		},
 	},
+	"infinity": {
+		{
+			RetrieverType:       types.KeywordsRetrieverType,
+			RetrieverEngineType: types.InfinityRetrieverEngineType,
+		},
+		{
+			RetrieverType:       types.VectorRetrieverType,
+			RetrieverEngineType: types.InfinityRetrieverEngineType,
+		},
+	},
+	"embedded": {
+		{
+			RetrieverType:       types.KeywordsRetrieverType,
+			RetrieverEngineType: types.EmbeddedRetrieverEngineType,
+		},
+		{
+			RetrieverType:       types.VectorRetrieverType,
+			RetrieverEngineType: types.EmbeddedRetrieverEngineType,
+		},
+	},
 }
 
 // Register creates a new user account
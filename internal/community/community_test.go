@@ -0,0 +1,126 @@
+package community
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// clique returns the edges between every pair of the names
+func clique(names ...string) []Edge {
+	var edges []Edge
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			edges = append(edges, Edge{Source: names[i], Target: names[j], Weight: 1})
+		}
+	}
+	return edges
+}
+
+func TestDetect(t *testing.T) {
+	edges := append(clique("a1", "a2", "a3", "a4"), clique("b1", "b2", "b3", "b4")...)
+	edges = append(edges, Edge{Source: "a1", Target: "b1", Weight: 1})
+	communities := Detect([]string{"lonely"}, edges, 0, 0)
+
+	finest := communities[len(communities)-1].Level
+	byMember := make(map[string]*Community)
+	for _, community := range communities {
+		if community.Level != finest {
+			continue
+		}
+		for _, member := range community.Members {
+			byMember[member] = community
+		}
+	}
+	if byMember["a1"] == nil || byMember["a1"] != byMember["a4"] || byMember["b1"] != byMember["b4"] {
+		t.Fatalf("cliques were split: %+v", byMember)
+	}
+	if byMember["a1"] == byMember["b1"] {
+		t.Errorf("cliques were merged at the finest level")
+	}
+	if len(byMember["lonely"].Members) != 1 {
+		t.Errorf("isolated entity shares a community: %+v", byMember["lonely"])
+	}
+
+	byID := make(map[string]*Community, len(communities))
+	for _, community := range communities {
+		byID[community.ID] = community
+	}
+	for _, community := range communities {
+		if community.Level == 0 {
+			if community.Parent != "" {
+				t.Errorf("community %s of level 0 has parent %s", community.ID, community.Parent)
+			}
+			continue
+		}
+		parent := byID[community.Parent]
+		if parent == nil || parent.Level != community.Level-1 {
+			t.Fatalf("community %s has no parent of the previous level", community.ID)
+		}
+		for _, member := range community.Members {
+			if !strings.Contains(strings.Join(parent.Members, ","), member) {
+				t.Errorf("member %s of %s is not in its parent %s", member, community.ID, parent.ID)
+			}
+		}
+	}
+}
+
+func TestDetectEmpty(t *testing.T) {
+	if communities := Detect(nil, nil, 0, 0); len(communities) != 0 {
+		t.Errorf("communities of an empty graph: %+v", communities)
+	}
+}
+
+// fakeChat answers the requests whose prompt contains a marker with the answer of the marker
+type fakeChat struct {
+	answers map[string]string
+}
+
+func (f *fakeChat) Chat(_ context.Context, messages []chat.Message, _ *chat.ChatOptions) (*types.ChatResponse, error) {
+	user := messages[len(messages)-1].Content
+	for marker, answer := range f.answers {
+		if strings.Contains(user, marker) {
+			return &types.ChatResponse{Content: answer}, nil
+		}
+	}
+	return nil, errors.New("unexpected request")
+}
+
+func (f *fakeChat) ChatStream(context.Context, []chat.Message, *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakeChat) GetModelName() string { return "fake" }
+
+func (f *fakeChat) GetModelID() string { return "fake" }
+
+func TestMap(t *testing.T) {
+	model := &fakeChat{answers: map[string]string{
+		"alpha": `{"points": [{"description": "Alpha leads", "score": 40, "reports": [2, 9]}, ` +
+			`{"description": "noise", "score": 0}]}`,
+		"gamma": `{"points": [{"description": "Gamma matters most", "score": 90, "reports": [1]}]}`,
+	}}
+	var failures int
+	points, err := NewMapper(model, 2, 2).Map(context.Background(), "themes?",
+		[]string{"report beta", "report alpha", "report gamma", "report unknown"},
+		func(error) { failures++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures != 0 {
+		t.Errorf("%d batches failed", failures)
+	}
+	if len(points) != 2 || points[0].Description != "Gamma matters most" || points[1].Description != "Alpha leads" {
+		t.Fatalf("unexpected points %+v", points)
+	}
+	if len(points[0].Reports) != 1 || points[0].Reports[0] != 2 {
+		t.Errorf("gamma point refers to reports %v, want [2]", points[0].Reports)
+	}
+	if len(points[1].Reports) != 1 || points[1].Reports[0] != 1 {
+		t.Errorf("alpha point refers to reports %v, want [1]", points[1].Reports)
+	}
+}
@@ -0,0 +1,269 @@
+// Package community clusters the entity graph of a knowledge base into hierarchical communities, summarizes
+// them with the chat model and maps community reports to the key points answering a corpus wide question
+package community
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+const (
+	// DefaultResolution is the modularity resolution, higher values find smaller communities
+	DefaultResolution = 1.0
+	// DefaultMaxLevels caps the levels of the hierarchy
+	DefaultMaxLevels = 3
+	// maxMovePasses caps the passes of the local moving phase of a level
+	maxMovePasses = 20
+)
+
+// Edge is an undirected weighted edge between two entities, relations in both directions add up
+type Edge struct {
+	Source string
+	Target string
+	Weight float64
+}
+
+// Community is a cluster of entities at a level of the hierarchy. Level 0 holds the coarsest communities,
+// each community of level n+1 is nested in its parent community of level n.
+type Community struct {
+	// ID is unique in the hierarchy, e.g. "1-4" for the fifth community of level 1
+	ID string
+	// Level in the hierarchy, 0 is the coarsest
+	Level int
+	// Parent is the ID of the enclosing community of the previous level, empty at level 0
+	Parent string
+	// Children are the IDs of the nested communities of the next level
+	Children []string
+	// Members are the entities of the community, sorted
+	Members []string
+}
+
+// Detect clusters the entities with the Louvain method. Each pass of the method merges the communities of
+// the previous pass, so the passes form the levels of the hierarchy from the finest to the coarsest, and
+// detection stops when a pass merges nothing or after maxLevels passes. Isolated entities form their own
+// community. The result is ordered by level and then by decreasing size.
+func Detect(nodes []string, edges []Edge, resolution float64, maxLevels int) []*Community {
+	if resolution <= 0 {
+		resolution = DefaultResolution
+	}
+	if maxLevels <= 0 {
+		maxLevels = DefaultMaxLevels
+	}
+	names := slices.Clone(nodes)
+	for _, edge := range edges {
+		names = append(names, edge.Source, edge.Target)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	if len(names) > 0 && names[0] == "" {
+		names = names[1:]
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+
+	g := newGraph(len(names))
+	for _, edge := range edges {
+		if edge.Source == "" || edge.Target == "" || edge.Source == edge.Target {
+			continue
+		}
+		weight := edge.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		g.add(index[edge.Source], index[edge.Target], weight)
+		g.add(index[edge.Target], index[edge.Source], weight)
+	}
+
+	// membership[l][i] is the community of entity i after pass l
+	var membership [][]int
+	current := make([]int, len(names))
+	for i := range current {
+		current[i] = i
+	}
+	for len(membership) < maxLevels {
+		partition, count := g.moveNodes(resolution)
+		if count == g.size() {
+			break
+		}
+		next := make([]int, len(names))
+		for i, node := range current {
+			next[i] = partition[node]
+		}
+		membership = append(membership, next)
+		current = next
+		g = g.aggregate(partition, count)
+		if count == 1 {
+			break
+		}
+	}
+	if len(membership) == 0 {
+		// No pass merged anything, every entity is its own community
+		membership = append(membership, current)
+	}
+	return buildHierarchy(names, membership)
+}
+
+// graph is a weighted undirected graph as a symmetric adjacency, the self loop of a node holds twice the
+// weight of the edges inside it so the degree of a node is the sum of its row
+type graph struct {
+	adjacency []map[int]float64
+}
+
+func newGraph(size int) *graph {
+	g := &graph{adjacency: make([]map[int]float64, size)}
+	for i := range g.adjacency {
+		g.adjacency[i] = make(map[int]float64)
+	}
+	return g
+}
+
+func (g *graph) size() int {
+	return len(g.adjacency)
+}
+
+func (g *graph) add(from, to int, weight float64) {
+	g.adjacency[from][to] += weight
+}
+
+// neighbours returns the neighbours of a node in increasing order so moves are deterministic
+func (g *graph) neighbours(node int) []int {
+	result := make([]int, 0, len(g.adjacency[node]))
+	for neighbour := range g.adjacency[node] {
+		result = append(result, neighbour)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// moveNodes runs the local moving phase: every node moves to the neighbouring community with the highest
+// modularity gain until no node moves. It returns the community of each node, numbered from 0, and the
+// number of communities.
+func (g *graph) moveNodes(resolution float64) ([]int, int) {
+	n := g.size()
+	degree := make([]float64, n)
+	total := 0.0
+	for i, row := range g.adjacency {
+		for _, weight := range row {
+			degree[i] += weight
+		}
+		total += degree[i]
+	}
+	community := make([]int, n)
+	for i := range community {
+		community[i] = i
+	}
+	if total == 0 {
+		return community, n
+	}
+	// communityDegree is the sum of the degrees of the nodes of each community
+	communityDegree := slices.Clone(degree)
+
+	for pass := 0; pass < maxMovePasses; pass++ {
+		moved := false
+		for node := 0; node < n; node++ {
+			own := community[node]
+			links := make(map[int]float64)
+			for _, neighbour := range g.neighbours(node) {
+				if neighbour != node {
+					links[community[neighbour]] += g.adjacency[node][neighbour]
+				}
+			}
+			communityDegree[own] -= degree[node]
+
+			gain := func(c int) float64 {
+				return links[c] - resolution*communityDegree[c]*degree[node]/total
+			}
+			best, bestGain := own, gain(own)
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+			for _, c := range candidates {
+				if value := gain(c); value > bestGain+1e-12 {
+					best, bestGain = c, value
+				}
+			}
+			communityDegree[best] += degree[node]
+			if best != own {
+				community[node] = best
+				moved = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+
+	// Renumber the communities in order of their first node
+	renumber := make(map[int]int)
+	for i, c := range community {
+		if _, ok := renumber[c]; !ok {
+			renumber[c] = len(renumber)
+		}
+		community[i] = renumber[c]
+	}
+	return community, len(renumber)
+}
+
+// aggregate returns the graph whose nodes are the communities of the partition
+func (g *graph) aggregate(partition []int, count int) *graph {
+	result := newGraph(count)
+	for node, row := range g.adjacency {
+		for neighbour, weight := range row {
+			result.add(partition[node], partition[neighbour], weight)
+		}
+	}
+	return result
+}
+
+// buildHierarchy converts the memberships of the passes, finest first, to communities with level 0 the
+// coarsest
+func buildHierarchy(names []string, membership [][]int) []*Community {
+	levels := len(membership)
+	var result []*Community
+	byKey := make(map[[2]int]*Community)
+	for pass := levels - 1; pass >= 0; pass-- {
+		level := levels - 1 - pass
+		var communities []*Community
+		for node, c := range membership[pass] {
+			key := [2]int{level, c}
+			community, ok := byKey[key]
+			if !ok {
+				community = &Community{Level: level}
+				byKey[key] = community
+				communities = append(communities, community)
+			}
+			community.Members = append(community.Members, names[node])
+			if level > 0 {
+				community.Parent = byKey[[2]int{level - 1, membership[pass+1][node]}].ID
+			}
+		}
+		sort.SliceStable(communities, func(i, j int) bool {
+			if len(communities[i].Members) != len(communities[j].Members) {
+				return len(communities[i].Members) > len(communities[j].Members)
+			}
+			return communities[i].Members[0] < communities[j].Members[0]
+		})
+		for i, community := range communities {
+			community.ID = fmt.Sprintf("%d-%d", level, i)
+		}
+		result = append(result, communities...)
+	}
+	byID := make(map[string]*Community, len(result))
+	for _, community := range result {
+		byID[community.ID] = community
+	}
+	for _, community := range result {
+		if parent, ok := byID[community.Parent]; ok && community.Parent != "" {
+			parent.Children = append(parent.Children, community.ID)
+		}
+	}
+	return result
+}
@@ -0,0 +1,152 @@
+package community
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"golang.org/x/sync/errgroup"
+)
+
+// mapPrompt asks the chat model for the key points of a batch of community reports answering a question
+const mapPrompt = `你是知识库分析助手。下面给出编号的知识库社区报告，请从中提取回答用户问题所需的关键要点。
+每个要点包含：
+- description：要点的完整陈述，只使用报告中的信息；
+- score：0 到 100 的分数，表示该要点对回答问题的重要程度；
+- reports：支撑该要点的报告编号。
+报告与问题无关时返回空列表，不要编造信息。`
+
+// pointsSchema is the JSON schema of the map output
+var pointsSchema = &types.ResponseSchema{Name: "key_points", Schema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"points": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"description": {"type": "string"},
+					"score": {"type": "integer"},
+					"reports": {"type": "array", "items": {"type": "integer"}}
+				},
+				"required": ["description", "score"]
+			}
+		}
+	},
+	"required": ["points"]
+}`)}
+
+// Point is a key point of the community reports answering a question
+type Point struct {
+	// Description states the point
+	Description string
+	// Score is the importance of the point for the question, from 1 to 100
+	Score int
+	// Reports are the indexes of the reports supporting the point
+	Reports []int
+}
+
+// Mapper runs the map step of the global search: it asks the chat model for the key points of batches of
+// community reports, the reduce step answers from the best points
+type Mapper struct {
+	model       chat.Chat
+	batchSize   int
+	concurrency int
+}
+
+// NewMapper creates a mapper sending batchSize reports per request with at most concurrency requests at once
+func NewMapper(model chat.Chat, batchSize int, concurrency int) *Mapper {
+	return &Mapper{model: model, batchSize: max(batchSize, 1), concurrency: max(concurrency, 1)}
+}
+
+// Map returns the points of the reports answering the question with a positive score, best first. A failed
+// batch is reported through onError and skipped, Map only fails if every batch fails.
+func (m *Mapper) Map(ctx context.Context,
+	question string, reports []string, onError func(error),
+) ([]*Point, error) {
+	var batches [][]int
+	for start := 0; start < len(reports); start += m.batchSize {
+		batch := make([]int, 0, m.batchSize)
+		for i := start; i < min(start+m.batchSize, len(reports)); i++ {
+			batch = append(batch, i)
+		}
+		batches = append(batches, batch)
+	}
+
+	results := make([][]*Point, len(batches))
+	errs := make([]error, len(batches))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(m.concurrency)
+	for i, batch := range batches {
+		group.Go(func() error {
+			results[i], errs[i] = m.mapBatch(groupCtx, question, reports, batch)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	var points []*Point
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		}
+		points = append(points, results[i]...)
+	}
+	if failed > 0 && failed == len(batches) {
+		return nil, fmt.Errorf("all %d map requests failed: %w", failed, errs[0])
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Score > points[j].Score })
+	return points, nil
+}
+
+// mapBatch asks the chat model for the points of a batch of reports, numbered from 1 in the prompt
+func (m *Mapper) mapBatch(ctx context.Context, question string, reports []string, batch []int) ([]*Point, error) {
+	var content strings.Builder
+	for i, report := range batch {
+		fmt.Fprintf(&content, "报告 %d：\n%s\n\n", i+1, reports[report])
+	}
+	content.WriteString("问题：" + question)
+	messages := []chat.Message{
+		{Role: "system", Content: mapPrompt},
+		{Role: "user", Content: content.String()},
+	}
+	_, output, err := chat.GenerateStructured(ctx, m.model, messages, &chat.ChatOptions{Temperature: 0},
+		pointsSchema)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Points []struct {
+			Description string `json:"description"`
+			Score       int    `json:"score"`
+			Reports     []int  `json:"reports"`
+		} `json:"points"`
+	}
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, err
+	}
+	points := make([]*Point, 0, len(parsed.Points))
+	for _, item := range parsed.Points {
+		description := strings.TrimSpace(item.Description)
+		if description == "" || item.Score <= 0 {
+			continue
+		}
+		point := &Point{Description: description, Score: min(item.Score, 100)}
+		for _, number := range item.Reports {
+			if number >= 1 && number <= len(batch) {
+				point.Reports = append(point.Reports, batch[number-1])
+			}
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
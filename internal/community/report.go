@@ -0,0 +1,122 @@
+package community
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// reportPrompt asks the chat model for the report of a community
+const reportPrompt = `你是知识图谱分析专家。下面给出知识库中一个实体社区的实体、实体属性、实体之间的关系，` +
+	`以及其中子社区的报告。请撰写该社区的报告：
+- title：简短而具体的标题，包含最有代表性的实体名称；
+- summary：一段话概括社区的整体结构、核心实体及它们之间的关系；
+- findings：3 到 8 条关于该社区的关键发现，每条一句话，只使用给出的信息，不要编造；
+- rating：0 到 10 的重要性评分，反映该社区对理解整个知识库的重要程度。
+使用与实体名称相同的语言撰写。`
+
+// reportSchema is the JSON schema of a community report
+var reportSchema = &types.ResponseSchema{Name: "community_report", Schema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"title": {"type": "string"},
+		"summary": {"type": "string"},
+		"findings": {"type": "array", "items": {"type": "string"}},
+		"rating": {"type": "number"}
+	},
+	"required": ["title", "summary", "findings", "rating"]
+}`)}
+
+// Report is the summary of a community written by the chat model
+type Report struct {
+	Title    string   `json:"title"`
+	Summary  string   `json:"summary"`
+	Findings []string `json:"findings"`
+	Rating   float64  `json:"rating"`
+}
+
+// Content returns the report as markdown, the text stored and searched for the community
+func (r *Report) Content() string {
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n%s\n", r.Title, r.Summary)
+	if len(r.Findings) > 0 {
+		content.WriteString("\n")
+		for _, finding := range r.Findings {
+			fmt.Fprintf(&content, "- %s\n", finding)
+		}
+	}
+	return content.String()
+}
+
+// ReportInput is the part of the graph a community report is written from
+type ReportInput struct {
+	// Entities of the community, most connected first
+	Entities []*types.GraphNode
+	// Relations among the entities
+	Relations []*types.GraphRelation
+	// SubReports are the reports of the nested communities
+	SubReports []*Report
+}
+
+// prompt returns the user message describing the community
+func (in *ReportInput) prompt() string {
+	var content strings.Builder
+	content.WriteString("实体：\n")
+	for _, entity := range in.Entities {
+		content.WriteString("- " + entity.Name)
+		if len(entity.Attributes) > 0 {
+			content.WriteString("：" + strings.Join(entity.Attributes, "；"))
+		}
+		content.WriteString("\n")
+	}
+	if len(in.Relations) > 0 {
+		content.WriteString("\n关系：\n")
+		for _, rel := range in.Relations {
+			fmt.Fprintf(&content, "- %s -[%s]-> %s\n", rel.Node1, rel.Type, rel.Node2)
+		}
+	}
+	if len(in.SubReports) > 0 {
+		content.WriteString("\n子社区报告：\n")
+		for _, report := range in.SubReports {
+			fmt.Fprintf(&content, "- %s：%s\n", report.Title, report.Summary)
+		}
+	}
+	return content.String()
+}
+
+// Summarizer writes community reports with the chat model
+type Summarizer struct {
+	model chat.Chat
+}
+
+// NewSummarizer creates a summarizer asking the chat model
+func NewSummarizer(model chat.Chat) *Summarizer {
+	return &Summarizer{model: model}
+}
+
+// Summarize writes the report of a community
+func (s *Summarizer) Summarize(ctx context.Context, input *ReportInput) (*Report, error) {
+	messages := []chat.Message{
+		{Role: "system", Content: reportPrompt},
+		{Role: "user", Content: input.prompt()},
+	}
+	_, output, err := chat.GenerateStructured(ctx, s.model, messages, &chat.ChatOptions{Temperature: 0.1},
+		reportSchema)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, err
+	}
+	report.Title = strings.TrimSpace(report.Title)
+	if report.Title == "" {
+		return nil, fmt.Errorf("community report without title")
+	}
+	report.Rating = min(max(report.Rating, 0), 10)
+	return &report, nil
+}
@@ -24,6 +24,7 @@ type Config struct {
 	StreamManager  *StreamManagerConfig  `yaml:"stream_manager"  json:"stream_manager"`
 	ExtractManager *ExtractManagerConfig `yaml:"extract"         json:"extract"`
 	WebSearch      *WebSearchConfig      `yaml:"web_search"      json:"web_search"`
+	Usage          *UsageConfig          `yaml:"usage"           json:"usage"`
 }
 
 type DocReaderConfig struct {
@@ -59,6 +60,26 @@ type ConversationConfig struct {
 	ExtractRelationshipsPrompt string         `yaml:"extract_relationships_prompt"  json:"extract_relationships_prompt"`
 	// GenerateQuestionsPrompt is used to generate questions for document chunks to improve recall
 	GenerateQuestionsPrompt string `yaml:"generate_questions_prompt" json:"generate_questions_prompt"`
+	// EnableHyDE searches with a hypothetical answer generated by HyDEPrompt
+	EnableHyDE bool   `yaml:"enable_hyde" json:"enable_hyde"`
+	HyDEPrompt string `yaml:"hyde_prompt" json:"hyde_prompt"`
+	// EnableMultiQuery searches with MultiQueryCount paraphrases generated by MultiQueryPrompt
+	EnableMultiQuery bool   `yaml:"enable_multi_query" json:"enable_multi_query"`
+	MultiQueryCount  int    `yaml:"multi_query_count"  json:"multi_query_count"`
+	MultiQueryPrompt string `yaml:"multi_query_prompt" json:"multi_query_prompt"`
+	// EnableAnswerCache replays cached answers of similar questions over the same knowledge bases
+	EnableAnswerCache    bool    `yaml:"enable_answer_cache"    json:"enable_answer_cache"`
+	AnswerCacheThreshold float64 `yaml:"answer_cache_threshold" json:"answer_cache_threshold"`
+	// Groundedness checks answers against the retrieved chunks
+	Groundedness *types.GroundednessConfig `yaml:"groundedness" json:"groundedness"`
+	// FeedbackRerank adjusts rerank scores by the feedback on the chunks
+	FeedbackRerank *types.FeedbackRerankConfig `yaml:"feedback_rerank" json:"feedback_rerank"`
+	// GraphSearch configures the knowledge graph traversal of the entity search stage
+	GraphSearch *types.GraphSearchConfig `yaml:"graph_search" json:"graph_search"`
+	// Pipelines declares chat pipelines, overriding built-in pipelines of the same name
+	Pipelines []*types.PipelineDefinition `yaml:"pipelines"        json:"pipelines"`
+	// DefaultPipeline is the pipeline used by knowledge QA when neither session nor tenant selects one
+	DefaultPipeline string `yaml:"default_pipeline" json:"default_pipeline"`
 }
 
 // SummaryConfig 摘要配置
@@ -108,6 +129,20 @@ type TenantConfig struct {
 	EnableCrossTenantAccess bool `yaml:"enable_cross_tenant_access" json:"enable_cross_tenant_access"`
 }
 
+// UsageConfig 用量统计配置
+type UsageConfig struct {
+	Currency string       `yaml:"currency" json:"currency"` // 计价货币
+	Prices   []ModelPrice `yaml:"prices"   json:"prices"`   // 模型价格
+}
+
+// ModelPrice 模型价格，按模型名称匹配
+type ModelPrice struct {
+	ModelName       string  `yaml:"model_name"       json:"model_name"`
+	PromptPrice     float64 `yaml:"prompt_price"     json:"prompt_price"`     // 每千输入 token 价格
+	CompletionPrice float64 `yaml:"completion_price" json:"completion_price"` // 每千输出 token 价格
+	CallPrice       float64 `yaml:"call_price"       json:"call_price"`       // 每次调用价格，用于向量化和重排模型
+}
+
 // ModelConfig 模型配置
 type ModelConfig struct {
 	Type       string                 `yaml:"type"       json:"type"`
@@ -137,6 +172,10 @@ type ExtractManagerConfig struct {
 	ExtractGraph  *types.PromptTemplateStructured `yaml:"extract_graph"  json:"extract_graph"`
 	ExtractEntity *types.PromptTemplateStructured `yaml:"extract_entity" json:"extract_entity"`
 	FabriText     *FebriText                      `yaml:"fabri_text"     json:"fabri_text"`
+	// EntityResolution merges graph entities naming the same thing, e.g. "Tencent" and "腾讯"
+	EntityResolution *types.EntityResolutionConfig `yaml:"entity_resolution" json:"entity_resolution"`
+	// Communities clusters graph entities into summarized communities for the global search
+	Communities *types.GraphCommunityConfig `yaml:"communities" json:"communities"`
 }
 
 type FebriText struct {
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Knowledge graph storage drivers
+const (
+	GraphDriverNeo4j    = "neo4j"
+	GraphDriverPostgres = "postgres"
+)
+
+// GraphDriver returns the knowledge graph storage selected by GRAPH_DRIVER. Without GRAPH_DRIVER,
+// NEO4J_ENABLE=true selects neo4j for compatibility. An empty driver means the knowledge graph is disabled.
+func GraphDriver() string {
+	switch driver := strings.ToLower(strings.TrimSpace(os.Getenv("GRAPH_DRIVER"))); driver {
+	case GraphDriverNeo4j, GraphDriverPostgres:
+		return driver
+	case "":
+		if strings.ToLower(os.Getenv("NEO4J_ENABLE")) == "true" {
+			return GraphDriverNeo4j
+		}
+	}
+	return ""
+}
+
+// IsGraphEnabled reports whether a knowledge graph storage is configured
+func IsGraphEnabled() bool {
+	return GraphDriver() != ""
+}
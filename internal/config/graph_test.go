@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestGraphDriver(t *testing.T) {
+	cases := []struct {
+		driver string
+		neo4j  string
+		want   string
+	}{
+		{driver: "", neo4j: "", want: ""},
+		{driver: "", neo4j: "true", want: GraphDriverNeo4j},
+		{driver: "postgres", neo4j: "true", want: GraphDriverPostgres},
+		{driver: " Neo4j ", neo4j: "", want: GraphDriverNeo4j},
+		{driver: "age", neo4j: "true", want: ""},
+	}
+	for _, c := range cases {
+		t.Setenv("GRAPH_DRIVER", c.driver)
+		t.Setenv("NEO4J_ENABLE", c.neo4j)
+		if got := GraphDriver(); got != c.want {
+			t.Errorf("GraphDriver() with GRAPH_DRIVER=%q NEO4J_ENABLE=%q = %q, want %q", c.driver, c.neo4j, got, c.want)
+		}
+		if got := IsGraphEnabled(); got != (c.want != "") {
+			t.Errorf("IsGraphEnabled() with GRAPH_DRIVER=%q = %v", c.driver, got)
+		}
+	}
+}
@@ -24,9 +24,12 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Tencent/WeKnora/docreader/client"
+	"github.com/Tencent/WeKnora/internal/answercache"
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	elasticsearchRepoV7 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v7"
 	elasticsearchRepoV8 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v8"
+	embeddedRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/embedded"
+	infinityRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/infinity"
 	neo4jRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/neo4j"
 	postgresRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/postgres"
 	qdrantRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/qdrant"
@@ -83,6 +86,7 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(initOllamaService))
 	must(container.Provide(initNeo4jClient))
 	must(container.Provide(stream.NewStreamManager))
+	must(container.Provide(answercache.NewAnswerCache))
 
 	// Data repositories layer
 	must(container.Provide(repository.NewTenantRepository))
@@ -95,8 +99,14 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(repository.NewModelRepository))
 	must(container.Provide(repository.NewUserRepository))
 	must(container.Provide(repository.NewAuthTokenRepository))
-	must(container.Provide(neo4jRepo.NewNeo4jRepository))
+	must(container.Provide(initGraphRepository))
 	must(container.Provide(repository.NewMCPServiceRepository))
+	must(container.Provide(repository.NewUsageRepository))
+	must(container.Provide(repository.NewAgentRunRepository))
+	must(container.Provide(repository.NewFeedbackRepository))
+	must(container.Provide(repository.NewFAQPromotionRepository))
+	must(container.Provide(repository.NewGraphEntityAliasRepository))
+	must(container.Provide(repository.NewGraphTombstoneRepository))
 
 	// MCP manager for managing MCP client connections
 	must(container.Provide(mcp.NewMCPManager))
@@ -108,12 +118,19 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(service.NewChunkService))
 	must(container.Provide(service.NewKnowledgeTagService))
 	must(container.Provide(embedding.NewBatchEmbedder))
+	must(container.Provide(service.NewUsageService))
+	must(container.Provide(service.NewAgentRunService))
 	must(container.Provide(service.NewModelService))
 	must(container.Provide(service.NewDatasetService))
 	must(container.Provide(service.NewEvaluationService))
 	must(container.Provide(service.NewUserService))
 	must(container.Provide(service.NewChunkExtractService))
 	must(container.Provide(service.NewMessageService))
+	must(container.Provide(service.NewFeedbackService))
+	must(container.Provide(service.NewFAQPromotionService))
+	must(container.Provide(service.NewEntityResolutionService))
+	must(container.Provide(service.NewKnowledgeGraphService))
+	must(container.Provide(service.NewGraphCommunityService))
 	must(container.Provide(service.NewMCPServiceService))
 
 	// Web search service (needed by AgentService)
@@ -137,15 +154,23 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Invoke(chatpipline.NewPluginSearch))
 	must(container.Invoke(chatpipline.NewPluginRerank))
 	must(container.Invoke(chatpipline.NewPluginMerge))
+	must(container.Invoke(chatpipline.NewPluginExpand))
 	must(container.Invoke(chatpipline.NewPluginIntoChatMessage))
 	must(container.Invoke(chatpipline.NewPluginChatCompletion))
 	must(container.Invoke(chatpipline.NewPluginChatCompletionStream))
+	must(container.Invoke(chatpipline.NewPluginGroundedness))
 	must(container.Invoke(chatpipline.NewPluginStreamFilter))
 	must(container.Invoke(chatpipline.NewPluginFilterTopK))
 	must(container.Invoke(chatpipline.NewPluginRewrite))
+	must(container.Invoke(chatpipline.NewPluginAnswerCache))
+	must(container.Invoke(chatpipline.NewPluginHyDE))
+	must(container.Invoke(chatpipline.NewPluginMultiQuery))
 	must(container.Invoke(chatpipline.NewPluginExtractEntity))
 	must(container.Invoke(chatpipline.NewPluginSearchEntity))
 	must(container.Invoke(chatpipline.NewPluginSearchParallel))
+	must(container.Invoke(chatpipline.NewPluginGlobalSearch))
+	must(container.Provide(chatpipline.NewPipelineRegistry))
+	must(container.Invoke(chatpipline.ValidatePipelines))
 
 	// HTTP handlers layer
 	must(container.Provide(handler.NewTenantHandler))
@@ -163,6 +188,9 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewSystemHandler))
 	must(container.Provide(handler.NewMCPServiceHandler))
 	must(container.Provide(handler.NewWebSearchHandler))
+	must(container.Provide(handler.NewUsageHandler))
+	must(container.Provide(handler.NewFeedbackHandler))
+	must(container.Provide(handler.NewGraphHandler))
 
 	// Router configuration
 	must(container.Provide(router.NewRouter))
@@ -471,6 +499,50 @@ func initRetrieveEngineRegistry(db *gorm.DB, cfg *config.Config) (interfaces.Ret
 			}
 		}
 	}
+
+	if slices.Contains(retrieveDriver, "infinity") {
+		infinityAddr := os.Getenv("INFINITY_ADDR")
+		if infinityAddr == "" {
+			infinityAddr = "http://localhost:23820"
+		}
+
+		log.Infof("Connecting to Infinity at %s", infinityAddr)
+
+		infinityRepository := infinityRepo.NewInfinityRetrieveEngineRepository(
+			infinityRepo.NewClient(infinityAddr, nil),
+		)
+		if err := registry.Register(
+			retriever.NewKVHybridRetrieveEngine(
+				infinityRepository, types.InfinityRetrieverEngineType,
+			),
+		); err != nil {
+			log.Errorf("Register infinity retrieve engine failed: %v", err)
+		} else {
+			log.Infof("Register infinity retrieve engine success")
+		}
+	}
+
+	if slices.Contains(retrieveDriver, "embedded") {
+		dataDir := os.Getenv("EMBEDDED_RETRIEVER_DATA_DIR")
+		if dataDir == "" {
+			dataDir = "./data/retriever"
+		}
+
+		embeddedRepository, err := embeddedRepo.NewEmbeddedRetrieveEngineRepository(dataDir)
+		if err != nil {
+			log.Errorf("Create embedded retrieve engine failed: %v", err)
+		} else {
+			if err := registry.Register(
+				retriever.NewKVHybridRetrieveEngine(
+					embeddedRepository, types.EmbeddedRetrieverEngineType,
+				),
+			); err != nil {
+				log.Errorf("Register embedded retrieve engine failed: %v", err)
+			} else {
+				log.Infof("Register embedded retrieve engine success")
+			}
+		}
+	}
 	return registry, nil
 }
 
@@ -539,7 +611,7 @@ func initOllamaService() (*ollama.OllamaService, error) {
 }
 
 func initNeo4jClient() (neo4j.Driver, error) {
-	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) != "true" {
+	if config.GraphDriver() != config.GraphDriverNeo4j {
 		logger.Debugf(context.Background(), "NOT SUPPORT RETRIEVE GRAPH")
 		return nil, nil
 	}
@@ -557,3 +629,17 @@ func initNeo4jClient() (neo4j.Driver, error) {
 	}
 	return driver, nil
 }
+
+// initGraphRepository initializes the knowledge graph repository selected by GRAPH_DRIVER
+// Parameters:
+//   - driver: Neo4j driver, nil unless the neo4j driver is selected
+//   - db: Database connection used by the postgres driver
+//
+// Returns:
+//   - Knowledge graph repository, the Neo4j repository ignores all calls if the graph is disabled
+func initGraphRepository(driver neo4j.Driver, db *gorm.DB) interfaces.RetrieveGraphRepository {
+	if config.GraphDriver() == config.GraphDriverPostgres {
+		return postgresRepo.NewPostgresGraphRepository(db)
+	}
+	return neo4jRepo.NewNeo4jRepository(driver)
+}
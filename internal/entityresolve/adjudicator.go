@@ -0,0 +1,78 @@
+package entityresolve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// adjudicatePrompt asks the chat model whether pairs of entity names refer to the same entity
+const adjudicatePrompt = `你是知识图谱的实体消歧专家。给定编号的实体名称对，判断每一对是否指代同一个现实中的实体，` +
+	`例如同一机构的中英文名称、简称与全称。仅名称相似但指代不同实体（如母公司与子公司、同名的不同人物、` +
+	`产品与其公司）时判断为不是同一实体。无法确定时判断为不是同一实体。`
+
+// decisionSchema is the JSON schema of the adjudication output
+var decisionSchema = &types.ResponseSchema{Name: "entity_decisions", Schema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"decisions": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"pair": {"type": "integer"},
+					"same": {"type": "boolean"}
+				},
+				"required": ["pair", "same"]
+			}
+		}
+	},
+	"required": ["decisions"]
+}`)}
+
+// LLMAdjudicator asks the chat model whether pairs of names are the same entity
+type LLMAdjudicator struct {
+	model chat.Chat
+}
+
+// NewLLMAdjudicator creates an adjudicator asking the chat model
+func NewLLMAdjudicator(model chat.Chat) *LLMAdjudicator {
+	return &LLMAdjudicator{model: model}
+}
+
+// SameEntities decides all pairs in a single structured request, pairs without decision are distinct
+func (a *LLMAdjudicator) SameEntities(ctx context.Context, pairs [][2]string) ([]bool, error) {
+	var content strings.Builder
+	for i, pair := range pairs {
+		fmt.Fprintf(&content, "%d. %s | %s\n", i+1, pair[0], pair[1])
+	}
+	messages := []chat.Message{
+		{Role: "system", Content: adjudicatePrompt},
+		{Role: "user", Content: content.String()},
+	}
+	_, output, err := chat.GenerateStructured(ctx, a.model, messages, &chat.ChatOptions{Temperature: 0},
+		decisionSchema)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Decisions []struct {
+			Pair int  `json:"pair"`
+			Same bool `json:"same"`
+		} `json:"decisions"`
+	}
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, err
+	}
+	decisions := make([]bool, len(pairs))
+	for _, decision := range parsed.Decisions {
+		if decision.Pair >= 1 && decision.Pair <= len(pairs) {
+			decisions[decision.Pair-1] = decision.Same
+		}
+	}
+	return decisions, nil
+}
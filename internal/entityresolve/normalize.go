@@ -0,0 +1,75 @@
+// Package entityresolve finds the names of a knowledge graph that refer to the same entity, so their
+// nodes and relations can be merged under one canonical name
+package entityresolve
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultSuffixes are the legal form suffixes dropped from names when none are configured
+var DefaultSuffixes = []string{
+	"inc", "incorporated", "corp", "corporation", "co", "company", "ltd", "limited", "llc", "plc", "gmbh",
+	"holding", "holdings", "group",
+	"股份有限公司", "有限责任公司", "有限公司", "控股", "集团", "公司",
+}
+
+// Normalize returns the comparison key of a name: full-width characters are folded, letters are lower
+// cased, punctuation and spaces are removed and trailing suffixes are dropped. Suffixes are whole words
+// for latin names and may be glued to the name for CJK names, "Tencent Holdings Ltd." and "腾讯控股有限公司"
+// become "tencent" and "腾讯".
+func Normalize(name string, suffixes []string) string {
+	tokens := tokenize(name)
+	if len(tokens) == 0 {
+		return ""
+	}
+	suffixKeys := make([]string, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		if key := strings.Join(tokenize(suffix), ""); key != "" {
+			suffixKeys = append(suffixKeys, key)
+		}
+	}
+	// Longer suffixes first so "股份有限公司" is not left as "股份" by "有限公司"
+	slices.SortFunc(suffixKeys, func(a, b string) int { return len(b) - len(a) })
+
+	for stripped := true; stripped; {
+		stripped = false
+		last := tokens[len(tokens)-1]
+		for _, suffix := range suffixKeys {
+			if len(tokens) > 1 && last == suffix {
+				tokens, stripped = tokens[:len(tokens)-1], true
+				break
+			}
+			if !isLatin(suffix) && len(last) > len(suffix) && strings.HasSuffix(last, suffix) {
+				tokens[len(tokens)-1], stripped = strings.TrimSuffix(last, suffix), true
+				break
+			}
+		}
+	}
+	return strings.Join(tokens, "")
+}
+
+// tokenize folds and lower cases the name and splits it on everything but letters and digits
+func tokenize(name string) []string {
+	folded := strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			// Full-width forms of the ASCII characters
+			return unicode.ToLower(r - 0xfee0)
+		}
+		return unicode.ToLower(r)
+	}, name)
+	return strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// isLatin reports whether the suffix only has single byte characters, such suffixes are only dropped as
+// whole words
+func isLatin(suffix string) bool {
+	return utf8.RuneCountInString(suffix) == len(suffix)
+}
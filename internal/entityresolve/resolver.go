@@ -0,0 +1,300 @@
+package entityresolve
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// maxAdjudicatedPairs bounds the pairs of names sent to the adjudicator by one resolution
+	maxAdjudicatedPairs = 200
+	// adjudicationBatchSize is the number of pairs sent to the adjudicator in one request
+	adjudicationBatchSize = 20
+)
+
+// Entity is a name of the knowledge graph considered by the resolution
+type Entity struct {
+	Name string
+	// Weight orders the names of a cluster, the heaviest name becomes the canonical entity
+	Weight int
+	// Pending names are compared with all names, the others only with pending names. A name that is not
+	// pending is always preferred as canonical entity so the existing graph keeps its names.
+	Pending bool
+}
+
+// Merge maps a name to the canonical entity of its cluster
+type Merge struct {
+	Alias     string
+	Canonical string
+	Source    types.EntityAliasSource
+	Score     float64
+}
+
+// Embedder returns the embeddings of the names, in the order of the names
+type Embedder func(ctx context.Context, names []string) ([][]float32, error)
+
+// Adjudicator decides whether pairs of names refer to the same entity
+type Adjudicator interface {
+	// SameEntities returns one decision per pair, in the order of the pairs
+	SameEntities(ctx context.Context, pairs [][2]string) ([]bool, error)
+}
+
+// Resolver clusters names by normalization, embedding similarity and, for names that are only somewhat
+// similar, the decision of an adjudicator
+type Resolver struct {
+	suffixes     []string
+	threshold    float64
+	llmThreshold float64
+	embed        Embedder
+	adjudicator  Adjudicator
+}
+
+// New creates a resolver. Embeddings are skipped without embedder and adjudication without adjudicator.
+func New(config *types.EntityResolutionConfig, embed Embedder, adjudicator Adjudicator) *Resolver {
+	if config == nil {
+		config = &types.EntityResolutionConfig{}
+	}
+	threshold, llmThreshold := config.Thresholds()
+	suffixes := config.Suffixes
+	if len(suffixes) == 0 {
+		suffixes = DefaultSuffixes
+	}
+	if adjudicator == nil {
+		llmThreshold = 0
+	}
+	return &Resolver{
+		suffixes:     suffixes,
+		threshold:    threshold,
+		llmThreshold: llmThreshold,
+		embed:        embed,
+		adjudicator:  adjudicator,
+	}
+}
+
+// link is the evidence that two names are the same entity
+type link struct {
+	a, b   int
+	source types.EntityAliasSource
+	score  float64
+}
+
+// Resolve returns the merges of the names into the canonical entities of their clusters, sorted by alias.
+// Names equal after normalization are always merged, embeddings are only compared for pairs with a pending
+// name.
+func (r *Resolver) Resolve(ctx context.Context, entities []Entity) ([]Merge, error) {
+	entities = dedupeEntities(entities)
+	clusters := newUnionFind(len(entities))
+	var links []link
+
+	byKey := make(map[string]int, len(entities))
+	for i, entity := range entities {
+		key := Normalize(entity.Name, r.suffixes)
+		if key == "" {
+			continue
+		}
+		if j, ok := byKey[key]; ok {
+			clusters.union(i, j)
+			links = append(links, link{a: i, b: j, source: types.EntityAliasSourceNormalized, score: 1})
+			continue
+		}
+		byKey[key] = i
+	}
+
+	embeddingLinks, err := r.embeddingLinks(ctx, entities, clusters)
+	if err != nil {
+		return nil, err
+	}
+	links = append(links, embeddingLinks...)
+	return r.merges(entities, clusters, links), nil
+}
+
+// embeddingLinks links the names whose embeddings reach the threshold and the names between the
+// adjudication threshold and the threshold that the adjudicator finds to be the same entity
+func (r *Resolver) embeddingLinks(ctx context.Context, entities []Entity, clusters *unionFind) ([]link, error) {
+	if r.embed == nil || r.threshold <= 0 || len(entities) < 2 {
+		return nil, nil
+	}
+	names := make([]string, len(entities))
+	for i, entity := range entities {
+		names[i] = entity.Name
+	}
+	embeddings, err := r.embed(ctx, names)
+	if err != nil {
+		return nil, fmt.Errorf("embed entity names: %w", err)
+	}
+	if len(embeddings) != len(names) {
+		return nil, fmt.Errorf("embed entity names: got %d embeddings for %d names", len(embeddings), len(names))
+	}
+
+	var links, candidates []link
+	for i := range entities {
+		for j := i + 1; j < len(entities); j++ {
+			if !entities[i].Pending && !entities[j].Pending {
+				continue
+			}
+			score := cosineSimilarity(embeddings[i], embeddings[j])
+			switch {
+			case score >= r.threshold:
+				links = append(links, link{a: i, b: j, source: types.EntityAliasSourceEmbedding, score: score})
+			case r.llmThreshold > 0 && score >= r.llmThreshold:
+				candidates = append(candidates, link{a: i, b: j, source: types.EntityAliasSourceLLM, score: score})
+			}
+		}
+	}
+	for _, l := range links {
+		clusters.union(l.a, l.b)
+	}
+
+	// The most similar pairs are asked first, pairs already merged by a previous link are skipped
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	pending := make([]link, 0, len(candidates))
+	for _, candidate := range candidates {
+		if len(pending) < maxAdjudicatedPairs && clusters.find(candidate.a) != clusters.find(candidate.b) {
+			pending = append(pending, candidate)
+		}
+	}
+	for start := 0; start < len(pending); start += adjudicationBatchSize {
+		batch := pending[start:min(start+adjudicationBatchSize, len(pending))]
+		pairs := make([][2]string, len(batch))
+		for i, candidate := range batch {
+			pairs[i] = [2]string{names[candidate.a], names[candidate.b]}
+		}
+		decisions, err := r.adjudicator.SameEntities(ctx, pairs)
+		if err != nil {
+			return nil, fmt.Errorf("adjudicate entity names: %w", err)
+		}
+		for i, same := range decisions {
+			if same && i < len(batch) {
+				clusters.union(batch[i].a, batch[i].b)
+				links = append(links, batch[i])
+			}
+		}
+	}
+	return links, nil
+}
+
+// merges maps every name of a cluster but its canonical entity to the canonical entity, with the first
+// evidence linking the name
+func (r *Resolver) merges(entities []Entity, clusters *unionFind, links []link) []Merge {
+	members := make(map[int][]int)
+	for i := range entities {
+		root := clusters.find(i)
+		members[root] = append(members[root], i)
+	}
+	evidence := make(map[int]link, len(links))
+	for _, l := range links {
+		for _, i := range []int{l.a, l.b} {
+			if _, ok := evidence[i]; !ok {
+				evidence[i] = l
+			}
+		}
+	}
+
+	var merges []Merge
+	for _, cluster := range members {
+		if len(cluster) < 2 {
+			continue
+		}
+		canonical := cluster[0]
+		for _, i := range cluster[1:] {
+			if preferred(entities[i], entities[canonical]) {
+				canonical = i
+			}
+		}
+		for _, i := range cluster {
+			if i == canonical {
+				continue
+			}
+			merges = append(merges, Merge{
+				Alias:     entities[i].Name,
+				Canonical: entities[canonical].Name,
+				Source:    evidence[i].source,
+				Score:     evidence[i].score,
+			})
+		}
+	}
+	sort.Slice(merges, func(i, j int) bool { return merges[i].Alias < merges[j].Alias })
+	return merges
+}
+
+// preferred reports whether a is a better canonical entity than b: existing names first, then the heaviest,
+// then the shortest name
+func preferred(a, b Entity) bool {
+	if a.Pending != b.Pending {
+		return !a.Pending
+	}
+	if a.Weight != b.Weight {
+		return a.Weight > b.Weight
+	}
+	if len(a.Name) != len(b.Name) {
+		return len(a.Name) < len(b.Name)
+	}
+	return a.Name < b.Name
+}
+
+// dedupeEntities merges entities of the same name, adding their weights
+func dedupeEntities(entities []Entity) []Entity {
+	index := make(map[string]int, len(entities))
+	result := make([]Entity, 0, len(entities))
+	for _, entity := range entities {
+		if entity.Name == "" {
+			continue
+		}
+		if i, ok := index[entity.Name]; ok {
+			result[i].Weight += entity.Weight
+			result[i].Pending = result[i].Pending && entity.Pending
+			continue
+		}
+		index[entity.Name] = len(result)
+		result = append(result, entity)
+	}
+	return result
+}
+
+// unionFind is a disjoint set of entity indexes
+type unionFind struct {
+	parent []int
+}
+
+func newUnionFind(n int) *unionFind {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	return &unionFind{parent: parent}
+}
+
+func (u *unionFind) find(i int) int {
+	for u.parent[i] != i {
+		u.parent[i] = u.parent[u.parent[i]]
+		i = u.parent[i]
+	}
+	return i
+}
+
+func (u *unionFind) union(a, b int) {
+	if ra, rb := u.find(a), u.find(b); ra != rb {
+		u.parent[ra] = rb
+	}
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 if their dimensions differ
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,110 @@
+package entityresolve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"Tencent Holdings Ltd.": "tencent",
+		"TENCENT":               "tencent",
+		"Ｔｅｎｃｅｎｔ":               "tencent",
+		"腾讯控股有限公司":              "腾讯",
+		"腾讯 公司":                 "腾讯",
+		"Group":                 "group",
+		"集团":                    "集团",
+		"Alpha Co":              "alpha",
+		"Costco":                "costco",
+		"  ":                    "",
+	}
+	for name, want := range cases {
+		if got := Normalize(name, DefaultSuffixes); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// fakeAdjudicator confirms the pairs it knows, in either order
+type fakeAdjudicator struct {
+	same  map[[2]string]bool
+	asked int
+}
+
+func (f *fakeAdjudicator) SameEntities(_ context.Context, pairs [][2]string) ([]bool, error) {
+	decisions := make([]bool, len(pairs))
+	for i, pair := range pairs {
+		f.asked++
+		decisions[i] = f.same[pair] || f.same[[2]string{pair[1], pair[0]}]
+	}
+	return decisions, nil
+}
+
+func TestResolve(t *testing.T) {
+	vectors := map[string][]float32{
+		"Tencent":          {1, 0, 0},
+		"Tencent Holdings": {1, 0, 0},
+		"腾讯":               {0.99, 0.1, 0},
+		"微信":               {0, 1, 0},
+		"WeChat":           {0, 0.85, 0.5},
+		"Alibaba":          {0, 0, 1},
+	}
+	embed := func(_ context.Context, names []string) ([][]float32, error) {
+		result := make([][]float32, len(names))
+		for i, name := range names {
+			result[i] = vectors[name]
+		}
+		return result, nil
+	}
+	adjudicator := &fakeAdjudicator{same: map[[2]string]bool{{"微信", "WeChat"}: true}}
+	resolver := New(&types.EntityResolutionConfig{Enabled: true, LLMAdjudication: true}, embed, adjudicator)
+
+	merges, err := resolver.Resolve(context.Background(), []Entity{
+		{Name: "Tencent Holdings", Weight: 5},
+		{Name: "Tencent", Weight: 2, Pending: true},
+		{Name: "腾讯", Weight: 9, Pending: true},
+		{Name: "微信", Weight: 3},
+		{Name: "WeChat", Weight: 1, Pending: true},
+		{Name: "Alibaba", Weight: 1, Pending: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Merge{
+		"Tencent": {Alias: "Tencent", Canonical: "Tencent Holdings", Source: types.EntityAliasSourceNormalized},
+		"腾讯":      {Alias: "腾讯", Canonical: "Tencent Holdings", Source: types.EntityAliasSourceEmbedding},
+		"WeChat":  {Alias: "WeChat", Canonical: "微信", Source: types.EntityAliasSourceLLM},
+	}
+	if len(merges) != len(want) {
+		t.Fatalf("got %d merges %+v, want %d", len(merges), merges, len(want))
+	}
+	for _, merge := range merges {
+		expected, ok := want[merge.Alias]
+		if !ok || merge.Canonical != expected.Canonical || merge.Source != expected.Source {
+			t.Errorf("unexpected merge %+v, want %+v", merge, expected)
+		}
+	}
+	if adjudicator.asked != 1 {
+		t.Errorf("adjudicator asked %d pairs, want 1", adjudicator.asked)
+	}
+}
+
+func TestResolveWithoutPendingNames(t *testing.T) {
+	embed := func(_ context.Context, names []string) ([][]float32, error) {
+		result := make([][]float32, len(names))
+		for i := range names {
+			result[i] = []float32{1, 0}
+		}
+		return result, nil
+	}
+	resolver := New(&types.EntityResolutionConfig{Enabled: true}, embed, nil)
+	merges, err := resolver.Resolve(context.Background(), []Entity{{Name: "Alpha"}, {Name: "Beta"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merges) != 0 {
+		t.Errorf("names that are not pending were merged: %+v", merges)
+	}
+}
@@ -29,12 +29,16 @@ const (
 	ErrTenantInactive      ErrorCode = 2002
 	ErrTenantNameRequired  ErrorCode = 2003
 	ErrTenantInvalidStatus ErrorCode = 2004
+	ErrTenantTokenQuota    ErrorCode = 2005
 
 	// Agent related error codes (2100-2199)
 	ErrAgentMissingThinkingModel ErrorCode = 2100
 	ErrAgentMissingAllowedTools  ErrorCode = 2101
 	ErrAgentInvalidMaxIterations ErrorCode = 2102
 	ErrAgentInvalidTemperature   ErrorCode = 2103
+	ErrAgentInvalidParallelTools ErrorCode = 2104
+	ErrAgentInvalidToolTimeout   ErrorCode = 2105
+	ErrAgentInvalidToolPolicy    ErrorCode = 2106
 
 	// Add more error codes here
 )
@@ -151,6 +155,15 @@ func NewTenantInactiveError() *AppError {
 	}
 }
 
+// NewTenantTokenQuotaExceededError creates a monthly token quota exceeded error
+func NewTenantTokenQuotaExceededError() *AppError {
+	return &AppError{
+		Code:     ErrTenantTokenQuota,
+		Message:  "本月 token 用量已超出配额",
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
 // Agent related errors
 func NewAgentMissingThinkingModelError() *AppError {
 	return &AppError{
@@ -184,6 +197,30 @@ func NewAgentInvalidTemperatureError() *AppError {
 	}
 }
 
+func NewAgentInvalidParallelToolsError() *AppError {
+	return &AppError{
+		Code:     ErrAgentInvalidParallelTools,
+		Message:  "工具并发数必须在0-16之间",
+		HTTPCode: http.StatusBadRequest,
+	}
+}
+
+func NewAgentInvalidToolTimeoutError() *AppError {
+	return &AppError{
+		Code:     ErrAgentInvalidToolTimeout,
+		Message:  "工具超时时间必须在0-600秒之间",
+		HTTPCode: http.StatusBadRequest,
+	}
+}
+
+func NewAgentInvalidToolPolicyError() *AppError {
+	return &AppError{
+		Code:     ErrAgentInvalidToolPolicy,
+		Message:  "工具策略配置不合法",
+		HTTPCode: http.StatusBadRequest,
+	}
+}
+
 // IsAppError checks if the error is an AppError type
 func IsAppError(err error) (*AppError, bool) {
 	appErr, ok := err.(*AppError)
@@ -18,6 +18,7 @@ const (
 	EventQueryPreprocess EventType = "query.preprocess" // 查询预处理
 	EventQueryRewrite    EventType = "query.rewrite"    // 查询改写
 	EventQueryRewritten  EventType = "query.rewritten"  // 查询改写完成
+	EventQueryExpanded   EventType = "query.expanded"   // 检索查询扩展完成（HyDE、多查询）
 
 	// Retrieval events
 	EventRetrievalStart    EventType = "retrieval.start"    // 检索开始
@@ -54,6 +55,12 @@ const (
 	EventAgentReferences  EventType = "references"   // 知识引用
 	EventAgentFinalAnswer EventType = "final_answer" // 最终答案
 
+	// Agent tool approval events
+	EventAgentToolApproval EventType = "tool_approval" // 工具调用等待用户审批
+
+	// Sub-agent events
+	EventAgentSubAgent EventType = "sub_agent" // 子 Agent 执行委派任务的进度
+
 	// Error events
 	EventError EventType = "error" // 错误事件
 
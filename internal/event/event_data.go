@@ -1,5 +1,7 @@
 package event
 
+import "encoding/json"
+
 // EventData contains common event data structures for different stages
 
 // QueryData represents query-related event data
@@ -11,6 +13,15 @@ type QueryData struct {
 	Extra          map[string]interface{} `json:"extra,omitempty"`
 }
 
+// QueryExpansionData represents the queries generated for retrieval by a query expansion stage
+type QueryExpansionData struct {
+	Strategy      string   `json:"strategy"` // hyde, multi_query
+	OriginalQuery string   `json:"original_query"`
+	Queries       []string `json:"queries"`
+	SessionID     string   `json:"session_id"`
+	Duration      int64    `json:"duration_ms,omitempty"`
+}
+
 // RetrievalData represents retrieval event data
 type RetrievalData struct {
 	Query           string                 `json:"query"`
@@ -143,6 +154,12 @@ type AgentCompleteData struct {
 	MessageID       string                 `json:"message_id,omitempty"` // Assistant message ID
 	RequestID       string                 `json:"request_id,omitempty"`
 	Extra           map[string]interface{} `json:"extra,omitempty"`
+	// Final answer as an object conforming to the response schema (only when one was requested)
+	StructuredOutput json.RawMessage `json:"structured_output,omitempty"`
+	// Citation markers of the final answer mapped to the knowledge references (*types.CitationMap)
+	CitationMap interface{} `json:"citation_map,omitempty"`
+	// Groundedness of the final answer (*types.GroundednessResult), only when the check is enabled
+	Groundedness interface{} `json:"groundedness,omitempty"`
 }
 
 // === Streaming Event Data Structures ===
@@ -185,6 +202,14 @@ type AgentReferencesData struct {
 type AgentFinalAnswerData struct {
 	Content string `json:"content"`
 	Done    bool   `json:"done"`
+	// Answer object conforming to the response schema, set on the last chunk when one was requested
+	StructuredOutput json.RawMessage `json:"structured_output,omitempty"`
+	Citations        interface{}     `json:"citations,omitempty"` // []types.Citation of the structured answer
+
+	// Citation markers of the answer mapped to the references (*types.CitationMap), set on the last chunk
+	CitationMap interface{} `json:"citation_map,omitempty"`
+	// Groundedness of the answer (*types.GroundednessResult), set on the last chunk when the check is enabled
+	Groundedness interface{} `json:"groundedness,omitempty"`
 }
 
 // AgentReflectionData represents agent reflection data
@@ -195,6 +220,25 @@ type AgentReflectionData struct {
 	Done       bool   `json:"done"` // Whether streaming is complete
 }
 
+// AgentToolApprovalData represents a tool call waiting for user approval
+type AgentToolApprovalData struct {
+	ToolCallID string         `json:"tool_call_id"` // Tool call ID to approve or reject
+	ToolName   string         `json:"tool_name"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+}
+
+// AgentSubAgentData represents an event of a sub-agent running a delegated task
+type AgentSubAgentData struct {
+	ParentToolCallID string `json:"parent_tool_call_id"` // delegate_task call the sub-agent runs for
+	Event            string `json:"event"`               // thought, tool_call, tool_result, final_answer or error
+	Content          string `json:"content,omitempty"`
+	ToolCallID       string `json:"tool_call_id,omitempty"`
+	ToolName         string `json:"tool_name,omitempty"`
+	Success          bool   `json:"success,omitempty"`
+	Iteration        int    `json:"iteration"`
+	Done             bool   `json:"done"`
+}
+
 // SessionTitleData represents session title update data
 type SessionTitleData struct {
 	SessionID string `json:"session_id"`
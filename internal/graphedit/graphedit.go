@@ -0,0 +1,105 @@
+// Package graphedit holds the in-memory views of a knowledge graph used to browse and edit it: ranking
+// entities, picking the relations among entities and dropping the elements deleted through the API
+package graphedit
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Rank sets the degree of the nodes from the relations and returns the nodes whose name contains the keyword,
+// ignoring case, most connected first and then by name
+func Rank(nodes []*types.GraphNode, relations []*types.GraphRelation, keyword string) []*types.GraphNode {
+	degree := make(map[string]int, len(nodes))
+	for _, rel := range relations {
+		degree[rel.Node1]++
+		degree[rel.Node2]++
+	}
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	ranked := make([]*types.GraphNode, 0, len(nodes))
+	for _, node := range nodes {
+		if keyword != "" && !strings.Contains(strings.ToLower(node.Name), keyword) {
+			continue
+		}
+		node.Degree = degree[node.Name]
+		ranked = append(ranked, node)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Degree != ranked[j].Degree {
+			return ranked[i].Degree > ranked[j].Degree
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked
+}
+
+// Among returns the relations whose both ends are among the nodes
+func Among(relations []*types.GraphRelation, nodes []*types.GraphNode) []*types.GraphRelation {
+	names := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		names[node.Name] = true
+	}
+	result := make([]*types.GraphRelation, 0)
+	for _, rel := range relations {
+		if names[rel.Node1] && names[rel.Node2] {
+			result = append(result, rel)
+		}
+	}
+	return result
+}
+
+// EntityTombstone returns the tombstone of an entity
+func EntityTombstone(name string) *types.GraphTombstone {
+	return &types.GraphTombstone{Kind: types.GraphTombstoneEntity, Name: name}
+}
+
+// RelationTombstone returns the tombstone of a relation
+func RelationTombstone(rel *types.GraphRelation) *types.GraphTombstone {
+	return &types.GraphTombstone{
+		Kind:   types.GraphTombstoneRelation,
+		Source: rel.Node1,
+		Target: rel.Node2,
+		Type:   rel.Type,
+	}
+}
+
+// Filter drops the entities and relations of the graph that have a tombstone, with the relations of the
+// dropped entities. It returns the number of dropped entities and relations.
+func Filter(graph *types.GraphData, tombstones []*types.GraphTombstone) int {
+	if len(tombstones) == 0 {
+		return 0
+	}
+	entities := make(map[string]bool)
+	relations := make(map[types.GraphRelation]bool)
+	for _, tombstone := range tombstones {
+		switch tombstone.Kind {
+		case types.GraphTombstoneEntity:
+			entities[tombstone.Name] = true
+		case types.GraphTombstoneRelation:
+			relations[types.GraphRelation{Node1: tombstone.Source, Node2: tombstone.Target, Type: tombstone.Type}] = true
+		}
+	}
+
+	dropped := 0
+	nodes := graph.Node[:0]
+	for _, node := range graph.Node {
+		if entities[node.Name] {
+			dropped++
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	graph.Node = nodes
+	rels := graph.Relation[:0]
+	for _, rel := range graph.Relation {
+		if entities[rel.Node1] || entities[rel.Node2] || relations[*rel] {
+			dropped++
+			continue
+		}
+		rels = append(rels, rel)
+	}
+	graph.Relation = rels
+	return dropped
+}
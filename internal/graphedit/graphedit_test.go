@@ -0,0 +1,55 @@
+package graphedit
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestRankAndAmong(t *testing.T) {
+	nodes := []*types.GraphNode{{Name: "Beta"}, {Name: "Alpha"}, {Name: "Gamma"}, {Name: "alphabet"}}
+	relations := []*types.GraphRelation{
+		{Node1: "Gamma", Node2: "Alpha", Type: "owns"},
+		{Node1: "Gamma", Node2: "Beta", Type: "owns"},
+	}
+	ranked := Rank(nodes, relations, "")
+	want := []string{"Gamma", "Alpha", "Beta", "alphabet"}
+	for i, name := range want {
+		if ranked[i].Name != name {
+			t.Fatalf("rank %d is %s, want %s", i, ranked[i].Name, name)
+		}
+	}
+	if ranked[0].Degree != 2 {
+		t.Errorf("degree of Gamma is %d, want 2", ranked[0].Degree)
+	}
+
+	if among := Among(relations, ranked[:2]); len(among) != 1 || among[0].Node2 != "Alpha" {
+		t.Errorf("unexpected relations among Gamma and Alpha %+v", among)
+	}
+
+	matched := Rank(nodes, relations, " ALPHA")
+	if len(matched) != 2 || matched[0].Name != "Alpha" || matched[1].Name != "alphabet" {
+		t.Errorf("unexpected keyword matches %+v", matched)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	graph := &types.GraphData{
+		Node: []*types.GraphNode{{Name: "Alpha"}, {Name: "Beta"}, {Name: "Gamma"}},
+		Relation: []*types.GraphRelation{
+			{Node1: "Alpha", Node2: "Beta", Type: "owns"},
+			{Node1: "Alpha", Node2: "Beta", Type: "funds"},
+			{Node1: "Beta", Node2: "Gamma", Type: "owns"},
+		},
+	}
+	dropped := Filter(graph, []*types.GraphTombstone{
+		EntityTombstone("Gamma"),
+		RelationTombstone(&types.GraphRelation{Node1: "Alpha", Node2: "Beta", Type: "owns"}),
+	})
+	if dropped != 3 {
+		t.Errorf("dropped %d elements, want 3", dropped)
+	}
+	if len(graph.Node) != 2 || len(graph.Relation) != 1 || graph.Relation[0].Type != "funds" {
+		t.Errorf("unexpected filtered graph %+v %+v", graph.Node, graph.Relation)
+	}
+}
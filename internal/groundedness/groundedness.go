@@ -0,0 +1,72 @@
+package groundedness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/searchutil"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// MaxClaims bounds the number of claims of an answer sent to the judge
+const MaxClaims = 30
+
+// regeneratePrompt asks the model for a new answer restricted to the retrieved chunks
+const regeneratePrompt = `上面的回答中有以下内容无法从检索到的信息中得到支持：
+%s
+请严格依据检索到的信息重新回答：只陈述检索到的信息明确支持的内容，并在每句话后用 [n] 标注来源编号；` +
+	`检索到的信息不足以回答的部分请直接说明无法确定，不要推测或补充其他知识。只输出新的回答。`
+
+// Judge scores the claims of an answer against the retrieved chunks
+type Judge interface {
+	// Name returns the judge type, see types.GroundednessJudgeLLM and types.GroundednessJudgeRerank
+	Name() string
+	// JudgeClaims returns one verdict per claim, in the order of the claims
+	JudgeClaims(ctx context.Context, claims []string, references []*types.SearchResult) ([]types.ClaimVerdict, error)
+}
+
+// Check scores an answer by the share of its claims the judge finds supported by the references
+func Check(ctx context.Context, judge Judge, answer string, references []*types.SearchResult,
+	threshold float64,
+) (*types.GroundednessResult, error) {
+	result := &types.GroundednessResult{Score: 1, Judge: judge.Name(), Claims: []types.ClaimVerdict{}}
+	claims := searchutil.AnswerClaims(answer)
+	if len(claims) > MaxClaims {
+		claims = claims[:MaxClaims]
+	}
+	if len(claims) > 0 {
+		verdicts, err := judge.JudgeClaims(ctx, claims, references)
+		if err != nil {
+			return nil, fmt.Errorf("judge claims: %w", err)
+		}
+		supported := 0
+		for _, verdict := range verdicts {
+			if verdict.Supported {
+				supported++
+			}
+		}
+		result.Claims = verdicts
+		result.Score = float64(supported) / float64(len(claims))
+	}
+	result.Grounded = result.Score >= threshold
+	return result, nil
+}
+
+// RegenerationMessages returns the messages that produced the answer followed by the answer
+// and a stricter instruction listing its unsupported claims
+func RegenerationMessages(messages []chat.Message, answer string, result *types.GroundednessResult) []chat.Message {
+	var unsupported strings.Builder
+	for _, claim := range result.Claims {
+		if !claim.Supported {
+			fmt.Fprintf(&unsupported, "- %s\n", claim.Claim)
+		}
+	}
+	regenerated := make([]chat.Message, 0, len(messages)+2)
+	regenerated = append(regenerated, messages...)
+	return append(regenerated,
+		chat.Message{Role: "assistant", Content: answer},
+		chat.Message{Role: "user", Content: fmt.Sprintf(regeneratePrompt, unsupported.String())},
+	)
+}
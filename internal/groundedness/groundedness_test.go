@@ -0,0 +1,96 @@
+package groundedness
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedChat answers every request with the same content
+type fixedChat struct {
+	answer   string
+	requests [][]chat.Message
+}
+
+func (c *fixedChat) Chat(_ context.Context, messages []chat.Message, _ *chat.ChatOptions) (*types.ChatResponse, error) {
+	c.requests = append(c.requests, messages)
+	return &types.ChatResponse{Content: c.answer}, nil
+}
+
+func (c *fixedChat) ChatStream(context.Context, []chat.Message, *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, nil
+}
+
+func (c *fixedChat) GetModelName() string { return "fixed" }
+func (c *fixedChat) GetModelID() string   { return "fixed" }
+
+// overlapReranker scores a document 0.9 if it contains the first word of the query
+type overlapReranker struct{}
+
+func (overlapReranker) Rerank(_ context.Context, query string, documents []string) ([]rerank.RankResult, error) {
+	results := make([]rerank.RankResult, len(documents))
+	for i, document := range documents {
+		results[i] = rerank.RankResult{Index: i, Document: rerank.DocumentInfo{Text: document}}
+		if strings.Contains(document, strings.Fields(query)[0]) {
+			results[i].RelevanceScore = 0.9
+		}
+	}
+	return results, nil
+}
+
+func (overlapReranker) GetModelName() string { return "overlap" }
+func (overlapReranker) GetModelID() string   { return "overlap" }
+
+func TestCheck(t *testing.T) {
+	references := []*types.SearchResult{
+		{ID: "c1", Content: "Comets are made of ice and dust and grow a tail near the sun."},
+		{ID: "c2", Content: "Halley's comet returns every 76 years."},
+	}
+	answer := "## Comets\nComets are made of ice and dust. [1] Halley's comet returns every 76 years. [2]\n" +
+		"Jupiter is the largest planet of the solar system."
+
+	model := &fixedChat{answer: `{"verdicts": [
+		{"claim": 1, "verdict": "entailment", "sources": [1]},
+		{"claim": 2, "verdict": "entailment", "sources": [2, 7]},
+		{"claim": 3, "verdict": "neutral"}
+	]}`}
+	judge, err := NewJudge(types.GroundednessJudgeLLM, model, nil)
+	require.NoError(t, err)
+	result, err := Check(context.Background(), judge, answer, references, 0.7)
+	require.NoError(t, err)
+	require.Len(t, result.Claims, 3, "the heading is not a claim")
+	assert.Equal(t, "Comets are made of ice and dust.", result.Claims[0].Claim)
+	assert.Equal(t, []string{"c2"}, result.Claims[1].ChunkIDs)
+	assert.False(t, result.Claims[2].Supported)
+	assert.InDelta(t, 2.0/3.0, result.Score, 1e-9)
+	assert.False(t, result.Grounded)
+	assert.Contains(t, model.requests[0][1].Content, "[2] Halley's comet")
+
+	judge, err = NewJudge(types.GroundednessJudgeRerank, nil, overlapReranker{})
+	require.NoError(t, err)
+	result, err = Check(context.Background(), judge, answer, references, 0.6)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, false},
+		[]bool{result.Claims[0].Supported, result.Claims[1].Supported, result.Claims[2].Supported})
+	assert.True(t, result.Grounded)
+
+	messages := RegenerationMessages([]chat.Message{{Role: "user", Content: "question"}}, answer, result)
+	require.Len(t, messages, 3)
+	assert.Contains(t, messages[2].Content, "- Jupiter is the largest planet of the solar system.")
+	assert.NotContains(t, messages[2].Content, "Halley")
+
+	// Answers without claims are grounded without asking the judge
+	result, err = Check(context.Background(), judge, "Yes.", references, 0.7)
+	require.NoError(t, err)
+	assert.True(t, result.Grounded)
+	assert.Equal(t, 1.0, result.Score)
+
+	_, err = NewJudge(types.GroundednessJudgeRerank, model, nil)
+	assert.Error(t, err)
+}
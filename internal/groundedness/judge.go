@@ -0,0 +1,176 @@
+package groundedness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// RerankSupportScore is the relevance score a chunk needs for the rerank judge to support a claim
+	RerankSupportScore = 0.5
+	// maxReferenceRunes bounds the length of each chunk shown to the LLM judge
+	maxReferenceRunes = 1500
+)
+
+// verdictEntailment is the NLI verdict of the LLM judge for supported claims
+const verdictEntailment = "entailment"
+
+// judgePrompt asks the LLM judge for an NLI verdict per claim
+const judgePrompt = `你是一个严谨的事实核查员。给定编号的参考资料和编号的陈述，判断每条陈述能否由参考资料推出：
+- entailment：参考资料明确支持该陈述
+- contradiction：参考资料与该陈述矛盾
+- neutral：参考资料中没有足够信息判断
+只能依据参考资料判断，不要使用其他知识。对每条陈述给出判断，并在 sources 中列出支持它的参考资料编号。`
+
+// verdictSchema is the JSON schema of the LLM judge output
+var verdictSchema = &types.ResponseSchema{Name: "claim_verdicts", Schema: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"verdicts": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"claim": {"type": "integer"},
+					"verdict": {"type": "string", "enum": ["entailment", "neutral", "contradiction"]},
+					"sources": {"type": "array", "items": {"type": "integer"}}
+				},
+				"required": ["claim", "verdict"]
+			}
+		}
+	},
+	"required": ["verdicts"]
+}`)}
+
+// NewJudge creates the judge of the given type, the chat model is used by the llm judge
+// and the rerank model by the rerank judge
+func NewJudge(name string, chatModel chat.Chat, rerankModel rerank.Reranker) (Judge, error) {
+	switch name {
+	case "", types.GroundednessJudgeLLM:
+		if chatModel == nil {
+			return nil, fmt.Errorf("llm groundedness judge requires a chat model")
+		}
+		return &LLMJudge{model: chatModel}, nil
+	case types.GroundednessJudgeRerank:
+		if rerankModel == nil {
+			return nil, fmt.Errorf("rerank groundedness judge requires a rerank model")
+		}
+		return &RerankJudge{model: rerankModel}, nil
+	default:
+		return nil, fmt.Errorf("unknown groundedness judge: %s", name)
+	}
+}
+
+// LLMJudge asks the chat model whether the references entail each claim
+type LLMJudge struct {
+	model chat.Chat
+}
+
+// Name returns the judge type
+func (j *LLMJudge) Name() string {
+	return types.GroundednessJudgeLLM
+}
+
+// JudgeClaims judges all claims in a single structured request, claims without verdict are unsupported
+func (j *LLMJudge) JudgeClaims(ctx context.Context, claims []string,
+	references []*types.SearchResult,
+) ([]types.ClaimVerdict, error) {
+	var content strings.Builder
+	content.WriteString("## 参考资料\n")
+	for i, ref := range references {
+		text := []rune(ref.Content)
+		if len(text) > maxReferenceRunes {
+			text = text[:maxReferenceRunes]
+		}
+		fmt.Fprintf(&content, "[%d] %s\n", i+1, string(text))
+	}
+	content.WriteString("\n## 陈述\n")
+	for i, claim := range claims {
+		fmt.Fprintf(&content, "%d. %s\n", i+1, claim)
+	}
+
+	messages := []chat.Message{
+		{Role: "system", Content: judgePrompt},
+		{Role: "user", Content: content.String()},
+	}
+	_, output, err := chat.GenerateStructured(ctx, j.model, messages, &chat.ChatOptions{Temperature: 0},
+		verdictSchema)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Verdicts []struct {
+			Claim   int    `json:"claim"`
+			Verdict string `json:"verdict"`
+			Sources []int  `json:"sources"`
+		} `json:"verdicts"`
+	}
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, err
+	}
+
+	verdicts := make([]types.ClaimVerdict, len(claims))
+	for i, claim := range claims {
+		verdicts[i] = types.ClaimVerdict{Claim: claim}
+	}
+	for _, v := range parsed.Verdicts {
+		if v.Claim < 1 || v.Claim > len(claims) || v.Verdict != verdictEntailment {
+			continue
+		}
+		verdict := &verdicts[v.Claim-1]
+		verdict.Supported, verdict.Score = true, 1
+		for _, source := range v.Sources {
+			if source >= 1 && source <= len(references) {
+				verdict.ChunkIDs = append(verdict.ChunkIDs, references[source-1].ID)
+			}
+		}
+	}
+	return verdicts, nil
+}
+
+// RerankJudge scores the relevance of the references to each claim with the rerank model,
+// a claim is supported if a reference reaches RerankSupportScore
+type RerankJudge struct {
+	model rerank.Reranker
+}
+
+// Name returns the judge type
+func (j *RerankJudge) Name() string {
+	return types.GroundednessJudgeRerank
+}
+
+// JudgeClaims reranks the references once per claim
+func (j *RerankJudge) JudgeClaims(ctx context.Context, claims []string,
+	references []*types.SearchResult,
+) ([]types.ClaimVerdict, error) {
+	documents := make([]string, len(references))
+	for i, ref := range references {
+		documents[i] = ref.Content
+	}
+	verdicts := make([]types.ClaimVerdict, 0, len(claims))
+	for _, claim := range claims {
+		results, err := j.model.Rerank(ctx, claim, documents)
+		if err != nil {
+			return nil, err
+		}
+		verdict := types.ClaimVerdict{Claim: claim}
+		for _, result := range results {
+			if result.Index < 0 || result.Index >= len(references) {
+				continue
+			}
+			verdict.Score = max(verdict.Score, min(result.RelevanceScore, 1))
+			if result.RelevanceScore >= RerankSupportScore {
+				verdict.ChunkIDs = append(verdict.ChunkIDs, references[result.Index].ID)
+			}
+		}
+		verdict.Supported = verdict.Score >= RerankSupportScore
+		verdicts = append(verdicts, verdict)
+	}
+	return verdicts, nil
+}
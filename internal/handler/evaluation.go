@@ -23,10 +23,12 @@ func NewEvaluationHandler(evaluationService interfaces.EvaluationService) *Evalu
 
 // EvaluationRequest contains parameters for evaluation request
 type EvaluationRequest struct {
-	DatasetID       string `json:"dataset_id"`        // ID of dataset to evaluate
-	KnowledgeBaseID string `json:"knowledge_base_id"` // ID of knowledge base to use
-	ChatModelID     string `json:"chat_id"`           // ID of chat model to use
-	RerankModelID   string `json:"rerank_id"`         // ID of rerank model to use
+	DatasetID        string `json:"dataset_id"`         // ID of dataset to evaluate
+	KnowledgeBaseID  string `json:"knowledge_base_id"`  // ID of knowledge base to use
+	ChatModelID      string `json:"chat_id"`            // ID of chat model to use
+	RerankModelID    string `json:"rerank_id"`          // ID of rerank model to use
+	EnableHyDE       bool   `json:"enable_hyde"`        // Whether to retrieve with HyDE
+	EnableMultiQuery bool   `json:"enable_multi_query"` // Whether to retrieve with query paraphrases
 }
 
 // Evaluation handles evaluation request
@@ -62,6 +64,8 @@ func (e *EvaluationHandler) Evaluation(c *gin.Context) {
 		secutils.SanitizeForLog(request.KnowledgeBaseID),
 		secutils.SanitizeForLog(request.ChatModelID),
 		secutils.SanitizeForLog(request.RerankModelID),
+		request.EnableHyDE,
+		request.EnableMultiQuery,
 	)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
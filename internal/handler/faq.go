@@ -15,11 +15,14 @@ import (
 // FAQHandler handles FAQ knowledge base operations.
 type FAQHandler struct {
 	knowledgeService interfaces.KnowledgeService
+	promotionService interfaces.FAQPromotionService
 }
 
 // NewFAQHandler creates a new FAQ handler
-func NewFAQHandler(knowledgeService interfaces.KnowledgeService) *FAQHandler {
-	return &FAQHandler{knowledgeService: knowledgeService}
+func NewFAQHandler(knowledgeService interfaces.KnowledgeService,
+	promotionService interfaces.FAQPromotionService,
+) *FAQHandler {
+	return &FAQHandler{knowledgeService: knowledgeService, promotionService: promotionService}
 }
 
 // ListEntries lists FAQ entries under a knowledge base.
@@ -224,3 +227,51 @@ func (h *FAQHandler) SearchFAQ(c *gin.Context) {
 		"data":    entries,
 	})
 }
+
+// PromoteExchange promotes the exchange of an assistant message into the FAQ knowledge base.
+func (h *FAQHandler) PromoteExchange(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req types.FAQPromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind FAQ promotion payload", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	req.SessionID = secutils.SanitizeForLog(req.SessionID)
+	req.MessageID = secutils.SanitizeForLog(req.MessageID)
+
+	result, err := h.promotionService.Promote(ctx, secutils.SanitizeForLog(c.Param("id")), &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ListPromotions lists the exchanges promoted into the FAQ knowledge base.
+func (h *FAQHandler) ListPromotions(c *gin.Context) {
+	ctx := c.Request.Context()
+	var page types.Pagination
+	if err := c.ShouldBindQuery(&page); err != nil {
+		logger.Error(ctx, "Failed to bind pagination query", err)
+		c.Error(errors.NewBadRequestError("分页参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	result, err := h.promotionService.ListPromotions(ctx, secutils.SanitizeForLog(c.Param("id")), &page)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
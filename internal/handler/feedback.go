@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// FeedbackHandler handles feedback on assistant messages and its aggregation per knowledge base
+type FeedbackHandler struct {
+	feedbackService interfaces.FeedbackService
+}
+
+// NewFeedbackHandler creates a new FeedbackHandler
+func NewFeedbackHandler(feedbackService interfaces.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{feedbackService: feedbackService}
+}
+
+type feedbackRequest struct {
+	Rating         types.FeedbackRating `json:"rating"           binding:"required"`
+	Correction     string               `json:"correction"`
+	UsefulChunkIDs []string             `json:"useful_chunk_ids"`
+}
+
+type feedbackDashboardRequest struct {
+	Limit int `form:"limit"`
+}
+
+// SubmitFeedback rates an assistant message, optionally with a corrected answer and the references that
+// were useful, replacing earlier feedback on the message
+func (h *FeedbackHandler) SubmitFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req feedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse feedback request", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	usefulChunkIDs := make([]string, 0, len(req.UsefulChunkIDs))
+	for _, chunkID := range req.UsefulChunkIDs {
+		usefulChunkIDs = append(usefulChunkIDs, secutils.SanitizeForLog(chunkID))
+	}
+	logger.Infof(ctx, "Submitting feedback on message %s of session %s", messageID, sessionID)
+
+	feedback, err := h.feedbackService.SubmitFeedback(ctx, &types.MessageFeedback{
+		SessionID:      sessionID,
+		MessageID:      messageID,
+		Rating:         req.Rating,
+		Correction:     req.Correction,
+		UsefulChunkIDs: usefulChunkIDs,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feedback,
+	})
+}
+
+// GetFeedback gets the feedback on a message
+func (h *FeedbackHandler) GetFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	feedback, err := h.feedbackService.GetFeedback(ctx, sessionID, messageID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feedback,
+	})
+}
+
+// DeleteFeedback deletes the feedback on a message
+func (h *FeedbackHandler) DeleteFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	if err := h.feedbackService.DeleteFeedback(ctx, sessionID, messageID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Feedback deleted successfully",
+	})
+}
+
+// GetDashboard aggregates the feedback on answers referencing a knowledge base, listing the knowledge
+// and chunks with the worst feedback first
+func (h *FeedbackHandler) GetDashboard(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req feedbackDashboardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errors.NewBadRequestError("查询参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	dashboard, err := h.feedbackService.GetDashboard(ctx, kbID, req.Limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dashboard,
+	})
+}
+
+// ExportDataset saves the corrected and upvoted answers referencing a knowledge base as an evaluation
+// dataset, its ID can be passed as dataset_id to the evaluation
+func (h *FeedbackHandler) ExportDataset(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	logger.Infof(ctx, "Exporting feedback of knowledge base %s as evaluation dataset", kbID)
+
+	export, err := h.feedbackService.ExportDataset(ctx, kbID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    export,
+	})
+}
+
+// handleError responds with application errors as they are and with an internal error otherwise
+func (h *FeedbackHandler) handleError(c *gin.Context, err error) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	c.Error(errors.NewInternalServerError(err.Error()))
+}
@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// GraphHandler handles the knowledge graph of a knowledge base
+type GraphHandler struct {
+	resolutionService interfaces.EntityResolutionService
+	graphService      interfaces.KnowledgeGraphService
+	communityService  interfaces.GraphCommunityService
+}
+
+// NewGraphHandler creates a new GraphHandler
+func NewGraphHandler(resolutionService interfaces.EntityResolutionService,
+	graphService interfaces.KnowledgeGraphService,
+	communityService interfaces.GraphCommunityService,
+) *GraphHandler {
+	return &GraphHandler{
+		resolutionService: resolutionService,
+		graphService:      graphService,
+		communityService:  communityService,
+	}
+}
+
+type resolveEntitiesRequest struct {
+	KnowledgeID string `json:"knowledge_id"`
+}
+
+// ResolveEntities queues the resolution of the entities of a knowledge, or the rebuild of the resolution of
+// the whole knowledge base without knowledge ID
+func (h *GraphHandler) ResolveEntities(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req resolveEntitiesRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+			return
+		}
+	}
+	knowledgeID := secutils.SanitizeForLog(req.KnowledgeID)
+	logger.Infof(ctx, "Queueing entity resolution of knowledge base %s, knowledge %s", kbID, knowledgeID)
+
+	if err := h.resolutionService.EnqueueResolution(ctx, kbID, knowledgeID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Entity resolution queued",
+	})
+}
+
+// ListAliases lists the entity aliases of the knowledge graph
+func (h *GraphHandler) ListAliases(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	aliases, err := h.resolutionService.ListAliases(ctx, kbID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    aliases,
+	})
+}
+
+// AddAlias adds a manual alias and merges the nodes of the alias into its canonical entity
+func (h *GraphHandler) AddAlias(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphEntityAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	alias, err := h.resolutionService.AddAlias(ctx, kbID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    alias,
+	})
+}
+
+// DeleteAlias deletes an entity alias, the merged nodes are not split again
+func (h *GraphHandler) DeleteAlias(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	aliasID := secutils.SanitizeForLog(c.Param("alias_id"))
+
+	if err := h.resolutionService.DeleteAlias(ctx, kbID, aliasID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Alias deleted successfully",
+	})
+}
+
+// BrowseGraph returns a page of entities, most connected first, with the relations among them
+func (h *GraphHandler) BrowseGraph(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphBrowseRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	result, err := h.graphService.BrowseGraph(ctx, kbID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ListEntities returns a page of entities, most connected first, filtered by keyword
+func (h *GraphHandler) ListEntities(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphBrowseRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	result, err := h.graphService.ListEntities(ctx, kbID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetEntity returns an entity with its relations and source chunks, the entity is named by the name query
+// parameter since names may contain slashes
+func (h *GraphHandler) GetEntity(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	name := c.Query("name")
+	if name == "" {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails("name is required"))
+		return
+	}
+	detail, err := h.graphService.GetEntity(ctx, kbID, name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    detail,
+	})
+}
+
+// CreateEntity creates an entity
+func (h *GraphHandler) CreateEntity(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphEntityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	logger.Infof(ctx, "Creating entity %s of knowledge base %s", secutils.SanitizeForLog(req.Name), kbID)
+
+	entity, err := h.graphService.CreateEntity(ctx, kbID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entity,
+	})
+}
+
+// UpdateEntity replaces the attributes of an entity or renames it
+func (h *GraphHandler) UpdateEntity(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphEntityUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	logger.Infof(ctx, "Updating entity %s of knowledge base %s", secutils.SanitizeForLog(req.Name), kbID)
+
+	entity, err := h.graphService.UpdateEntity(ctx, kbID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entity,
+	})
+}
+
+// MergeEntities merges entities into one
+func (h *GraphHandler) MergeEntities(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphEntityMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	logger.Infof(ctx, "Merging %d entities of knowledge base %s into %s",
+		len(req.Names), kbID, secutils.SanitizeForLog(req.Into))
+
+	entity, err := h.graphService.MergeEntities(ctx, kbID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entity,
+	})
+}
+
+// DeleteEntity deletes the entity of the name query parameter and its relations
+func (h *GraphHandler) DeleteEntity(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	name := c.Query("name")
+	if name == "" {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails("name is required"))
+		return
+	}
+	logger.Infof(ctx, "Deleting entity %s of knowledge base %s", secutils.SanitizeForLog(name), kbID)
+
+	if err := h.graphService.DeleteEntity(ctx, kbID, name); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Entity deleted successfully",
+	})
+}
+
+// CreateRelation creates a relation between two entities
+func (h *GraphHandler) CreateRelation(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	relation, err := h.graphService.CreateRelation(ctx, kbID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    relation,
+	})
+}
+
+// DeleteRelation deletes the relation of the source, target and type query parameters
+func (h *GraphHandler) DeleteRelation(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphRelationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	if err := h.graphService.DeleteRelation(ctx, kbID, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Relation deleted successfully",
+	})
+}
+
+// DetectCommunities queues the detection of the communities of the knowledge graph and their reports
+func (h *GraphHandler) DetectCommunities(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	logger.Infof(ctx, "Queueing community detection of knowledge base %s", kbID)
+
+	if err := h.communityService.EnqueueDetection(ctx, kbID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Community detection queued",
+	})
+}
+
+// ListCommunities lists the communities of the knowledge graph with their reports, of a single level if the
+// level query parameter is set
+func (h *GraphHandler) ListCommunities(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GraphCommunityListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	communities, err := h.communityService.ListCommunities(ctx, kbID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    communities,
+	})
+}
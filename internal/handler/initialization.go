@@ -510,9 +510,9 @@ func validateNodeExtractConfig(ctx context.Context, req *InitializationRequest)
 	if !req.NodeExtract.Enabled {
 		return nil
 	}
-	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) != "true" {
+	if !config.IsGraphEnabled() {
 		logger.Error(ctx, "Node Extractor configuration incomplete")
-		return errors.NewBadRequestError("请正确配置环境变量NEO4J_ENABLE")
+		return errors.NewBadRequestError("请正确配置环境变量GRAPH_DRIVER或NEO4J_ENABLE")
 	}
 	if req.NodeExtract.Text == "" || len(req.NodeExtract.Tags) == 0 {
 		logger.Error(ctx, "Node Extractor configuration incomplete")
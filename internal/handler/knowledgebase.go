@@ -48,6 +48,16 @@ func (h *KnowledgeBaseHandler) HybridSearch(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Fusion.Validate(); err != nil {
+		logger.Error(ctx, "Invalid fusion config", err)
+		c.Error(errors.NewBadRequestError("Invalid fusion config").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid metadata filter", err)
+		c.Error(errors.NewBadRequestError("Invalid metadata filter").WithDetails(err.Error()))
+		return
+	}
 
 	logger.Infof(ctx, "Executing hybrid search, knowledge base ID: %s, query: %s",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.QueryText))
@@ -86,6 +96,21 @@ func (h *KnowledgeBaseHandler) CreateKnowledgeBase(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	if err := req.ChunkingConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid chunking config", err)
+		c.Error(errors.NewBadRequestError("Invalid chunking config").WithDetails(err.Error()))
+		return
+	}
+	if err := req.FusionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid fusion config", err)
+		c.Error(errors.NewBadRequestError("Invalid fusion config").WithDetails(err.Error()))
+		return
+	}
+	if err := req.ContextExpansionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid context expansion config", err)
+		c.Error(errors.NewBadRequestError("Invalid context expansion config").WithDetails(err.Error()))
+		return
+	}
 
 	logger.Infof(ctx, "Creating knowledge base, name: %s", secutils.SanitizeForLog(req.Name))
 	// Create knowledge base using the service
@@ -189,7 +214,7 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 	logger.Info(ctx, "Start updating knowledge base")
 
 	// Validate and get the knowledge base
-	_, id, err := h.validateAndGetKnowledgeBase(c)
+	oldKB, id, err := h.validateAndGetKnowledgeBase(c)
 	if err != nil {
 		c.Error(err)
 		return
@@ -202,6 +227,21 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Config.ChunkingConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid chunking config", err)
+		c.Error(errors.NewBadRequestError("Invalid chunking config").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.FusionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid fusion config", err)
+		c.Error(errors.NewBadRequestError("Invalid fusion config").WithDetails(err.Error()))
+		return
+	}
+	if err := req.Config.ContextExpansionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid context expansion config", err)
+		c.Error(errors.NewBadRequestError("Invalid context expansion config").WithDetails(err.Error()))
+		return
+	}
 
 	logger.Infof(ctx, "Updating knowledge base, ID: %s, name: %s",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.Name))
@@ -214,6 +254,13 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 		return
 	}
 
+	// Existing knowledge has to be split again when hierarchical chunking is switched on or off or resized
+	if needsRechunk(oldKB.ChunkingConfig, kb.ChunkingConfig) {
+		if err := h.knowledgeService.RechunkKnowledgeBase(ctx, id); err != nil {
+			logger.Errorf(ctx, "Failed to rechunk knowledge base %s: %v", secutils.SanitizeForLog(id), err)
+		}
+	}
+
 	logger.Infof(ctx, "Knowledge base updated successfully, ID: %s",
 		secutils.SanitizeForLog(id))
 	c.JSON(http.StatusOK, gin.H{
@@ -222,6 +269,16 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 	})
 }
 
+// needsRechunk reports whether a chunking config change affects the hierarchical chunks of existing knowledge
+func needsRechunk(oldConfig, newConfig types.ChunkingConfig) bool {
+	if oldConfig.IsHierarchical() != newConfig.IsHierarchical() {
+		return true
+	}
+	return newConfig.IsHierarchical() &&
+		(oldConfig.GetChildChunkSize() != newConfig.GetChildChunkSize() ||
+			oldConfig.GetParentChunkSize() != newConfig.GetParentChunkSize())
+}
+
 // DeleteKnowledgeBase handles requests to delete a knowledge base
 func (h *KnowledgeBaseHandler) DeleteKnowledgeBase(c *gin.Context) {
 	ctx := c.Request.Context()
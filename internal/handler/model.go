@@ -48,8 +48,10 @@ func hideSensitiveInfo(model *types.Model) *types.Model {
 			BaseURL: "",
 			APIKey:  "",
 			// Keep other parameters like embedding dimensions
-			EmbeddingParameters: model.Parameters.EmbeddingParameters,
-			ParameterSize:       model.Parameters.ParameterSize,
+			EmbeddingParameters:      model.Parameters.EmbeddingParameters,
+			ParameterSize:            model.Parameters.ParameterSize,
+			SupportsStructuredOutput: model.Parameters.SupportsStructuredOutput,
+			SupportsStreamUsage:      model.Parameters.SupportsStreamUsage,
 		},
 		IsBuiltin: model.IsBuiltin,
 		Status:    model.Status,
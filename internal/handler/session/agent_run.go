@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ListAgentRuns lists the unfinished agent runs of the tenant
+func (h *Handler) ListAgentRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	runs, err := h.agentRunService.ListInFlightRuns(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runs,
+	})
+}
+
+// GetAgentRun gets an agent run including its last checkpoint
+func (h *Handler) GetAgentRun(c *gin.Context) {
+	ctx := c.Request.Context()
+	runID := secutils.SanitizeForLog(c.Param("id"))
+
+	run, err := h.agentRunService.GetRun(ctx, runID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if run == nil {
+		c.Error(errors.NewNotFoundError("Agent run not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// ResumeAgentRun resumes an interrupted agent run from its last checkpoint and streams the rest of it
+func (h *Handler) ResumeAgentRun(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+	runID := secutils.SanitizeForLog(c.Param("id"))
+	logger.Infof(ctx, "Start resuming agent run: %s", runID)
+
+	run, err := h.agentRunService.GetRun(ctx, runID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if run == nil {
+		c.Error(errors.NewNotFoundError("Agent run not found"))
+		return
+	}
+	if !run.Resumable {
+		logger.Warnf(ctx, "Agent run %s with status %s can not be resumed", runID, run.Status)
+		c.Error(errors.NewConflictError(fmt.Sprintf("Agent run with status %s can not be resumed", run.Status)))
+		return
+	}
+	if err := h.checkTokenQuota(ctx); err != nil {
+		c.Error(err)
+		return
+	}
+
+	sessionID := run.SessionID
+	session, err := h.sessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get session, session ID: %s, error: %v", sessionID, err)
+		c.Error(errors.NewNotFoundError("Session not found"))
+		return
+	}
+	assistantMessage, err := h.messageService.GetMessage(ctx, sessionID, run.MessageID)
+	if err != nil || assistantMessage == nil {
+		logger.Errorf(ctx, "Failed to get message %s of agent run %s: %v", run.MessageID, runID, err)
+		c.Error(errors.NewNotFoundError("Message of the agent run not found"))
+		return
+	}
+
+	// Events of the interrupted execution, including its stop event, are not streamed again
+	_, offset, err := h.streamManager.GetEvents(ctx, sessionID, assistantMessage.ID, 0)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(fmt.Sprintf("Failed to get stream data: %s", err.Error())))
+		return
+	}
+
+	// Claim the run before touching its message, a concurrent resume of the same run fails here
+	if err := h.agentRunService.ResumeRun(ctx, run); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The message is answered again by the resumed run
+	assistantMessage.IsCompleted = false
+	assistantMessage.Content = ""
+	assistantMessage.UpdatedAt = time.Now()
+	if err := h.messageService.UpdateMessage(ctx, assistantMessage); err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		h.agentRunService.FinishRun(ctx, run, err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	setSSEHeaders(c)
+	requestID := secutils.SanitizeForLog(getRequestID(c))
+
+	eventBus := event.NewEventBus()
+	asyncCtx, cancel := context.WithCancel(logger.CloneContext(ctx))
+	h.setupStreamHandler(asyncCtx, sessionID, assistantMessage.ID, requestID, assistantMessage, eventBus)
+	h.setupStopEventHandler(eventBus, sessionID, assistantMessage, cancel)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				buf := make([]byte, 1024)
+				runtime.Stack(buf, true)
+				logger.ErrorWithFields(asyncCtx,
+					errors.NewInternalServerError(fmt.Sprintf("Agent run resume panicked: %v\n%s", r, string(buf))),
+					map[string]interface{}{
+						"session_id": sessionID,
+						"run_id":     runID,
+					})
+			}
+			h.completeAssistantMessage(asyncCtx, assistantMessage)
+			logger.Infof(asyncCtx, "Resumed agent run %s completed for session: %s", runID, sessionID)
+		}()
+		if err := h.sessionService.ResumeAgentRun(asyncCtx, session, run, eventBus); err != nil {
+			logger.ErrorWithFields(asyncCtx, err, nil)
+			eventBus.Emit(asyncCtx, event.Event{
+				Type:      event.EventError,
+				SessionID: sessionID,
+				Data: event.ErrorData{
+					Error:     err.Error(),
+					Stage:     "agent_execution",
+					SessionID: sessionID,
+				},
+			})
+		}
+	}()
+
+	h.streamAgentEventsFrom(ctx, c, sessionID, assistantMessage.ID, requestID, eventBus, offset)
+}
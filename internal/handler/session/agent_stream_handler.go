@@ -66,6 +66,9 @@ func (h *AgentStreamHandler) Subscribe() {
 	h.eventBus.On(event.EventError, h.handleError)
 	h.eventBus.On(event.EventSessionTitle, h.handleSessionTitle)
 	h.eventBus.On(event.EventAgentComplete, h.handleComplete)
+	h.eventBus.On(event.EventQueryExpanded, h.handleQueryExpansion)
+	h.eventBus.On(event.EventAgentToolApproval, h.handleToolApproval)
+	h.eventBus.On(event.EventAgentSubAgent, h.handleSubAgent)
 }
 
 // handleThought handles agent thought events
@@ -302,6 +305,16 @@ func (h *AgentStreamHandler) handleFinalAnswer(ctx context.Context, evt event.Ev
 			"completed_at": time.Now().Unix(),
 		}
 		delete(h.eventStartTimes, evt.ID)
+		if len(data.StructuredOutput) > 0 {
+			metadata["structured_output"] = data.StructuredOutput
+			metadata["citations"] = data.Citations
+		}
+		if data.CitationMap != nil {
+			metadata["citation_map"] = data.CitationMap
+		}
+		if data.Groundedness != nil {
+			metadata["groundedness"] = data.Groundedness
+		}
 	} else {
 		metadata = map[string]interface{}{
 			"event_id": evt.ID,
@@ -345,6 +358,87 @@ func (h *AgentStreamHandler) handleReflection(ctx context.Context, evt event.Eve
 	return nil
 }
 
+// handleQueryExpansion handles the queries generated for retrieval by query expansion stages
+func (h *AgentStreamHandler) handleQueryExpansion(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.QueryExpansionData)
+	if !ok {
+		return nil
+	}
+
+	// Append query expansion event to stream
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeQueryExpansion,
+		Done:      true,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"strategy":       data.Strategy,
+			"original_query": data.OriginalQuery,
+			"queries":        data.Queries,
+			"duration_ms":    data.Duration,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append query expansion event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
+// handleToolApproval handles tool calls waiting for the user's approval
+func (h *AgentStreamHandler) handleToolApproval(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentToolApprovalData)
+	if !ok {
+		return nil
+	}
+
+	// Append approval request to stream, the client answers through the tool approval endpoint
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeToolApproval,
+		Content:   fmt.Sprintf("Waiting for approval: %s", data.ToolName),
+		Done:      false,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"tool_call_id": data.ToolCallID,
+			"tool_name":    data.ToolName,
+			"arguments":    data.Arguments,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append tool approval event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
+// handleSubAgent handles progress events of sub-agents running delegated tasks
+func (h *AgentStreamHandler) handleSubAgent(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentSubAgentData)
+	if !ok {
+		return nil
+	}
+
+	// Sub-agent events are nested under the delegate_task call, they are not part of the answer
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeSubAgent,
+		Content:   data.Content,
+		Done:      data.Done,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"parent_tool_call_id": data.ParentToolCallID,
+			"event":               data.Event,
+			"tool_call_id":        data.ToolCallID,
+			"tool_name":           data.ToolName,
+			"success":             data.Success,
+			"iteration":           data.Iteration,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append sub-agent event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
 // handleError handles error events
 func (h *AgentStreamHandler) handleError(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.ErrorData)
@@ -430,6 +524,29 @@ func (h *AgentStreamHandler) handleComplete(ctx context.Context, evt event.Event
 				h.assistantMessage.AgentSteps = steps
 			}
 		}
+		h.assistantMessage.StructuredOutput = types.StructuredOutput(data.StructuredOutput)
+		if citationMap, ok := data.CitationMap.(*types.CitationMap); ok && citationMap != nil {
+			h.assistantMessage.CitationMap = citationMap
+		}
+		if result, ok := data.Groundedness.(*types.GroundednessResult); ok && result != nil {
+			h.assistantMessage.Groundedness = result
+		}
+	}
+
+	completeData := map[string]interface{}{
+		"total_steps":       data.TotalSteps,
+		"total_duration_ms": data.TotalDurationMs,
+	}
+	// Structured answers are returned with the chunks they cite
+	if len(data.StructuredOutput) > 0 {
+		completeData["structured_output"] = data.StructuredOutput
+		completeData["citations"] = types.CitationsFromReferences(h.assistantMessage.KnowledgeReferences)
+	}
+	if citationMap, ok := data.CitationMap.(*types.CitationMap); ok && citationMap != nil {
+		completeData["citation_map"] = citationMap
+	}
+	if result, ok := data.Groundedness.(*types.GroundednessResult); ok && result != nil {
+		completeData["groundedness"] = result
 	}
 
 	// Send completion event to stream manager so SSE can detect completion
@@ -439,10 +556,7 @@ func (h *AgentStreamHandler) handleComplete(ctx context.Context, evt event.Event
 		Content:   "",
 		Done:      true,
 		Timestamp: time.Now(),
-		Data: map[string]interface{}{
-			"total_steps":       data.TotalSteps,
-			"total_duration_ms": data.TotalDurationMs,
-		},
+		Data:      completeData,
 	}); err != nil {
 		logger.GetLogger(h.ctx).Errorf("Append complete event to stream failed: %v", err)
 	}
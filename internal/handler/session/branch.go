@@ -0,0 +1,145 @@
+package session
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// branchPoint is where the messages of a regenerate or edit request branch off the conversation
+type branchPoint struct {
+	// Message the new branch follows, empty for the start of the session
+	parentID string
+	// Whether the answer of the user message parentID is regenerated, no new user message is created then
+	regenerate bool
+}
+
+// resolveBranch validates the message to regenerate or edit of a QA request and returns where its
+// messages branch off, nil if they simply continue the active branch. A regenerated answer is
+// generated for the query of the original question, which is filled into the request.
+func (h *Handler) resolveBranch(ctx context.Context,
+	sessionID string, request *CreateKnowledgeQARequest,
+) (*branchPoint, error) {
+	if request.RegenerateMessageID == "" && request.EditMessageID == "" {
+		return nil, nil
+	}
+	if request.RegenerateMessageID != "" && request.EditMessageID != "" {
+		return nil, errors.NewBadRequestError("regenerate_message_id and edit_message_id are mutually exclusive")
+	}
+
+	if request.RegenerateMessageID != "" {
+		answer, err := h.messageService.GetMessage(ctx, sessionID, secutils.SanitizeForLog(request.RegenerateMessageID))
+		if err != nil {
+			logger.Errorf(ctx, "Failed to get message to regenerate: %v", err)
+			return nil, errors.NewNotFoundError("Message to regenerate not found")
+		}
+		if answer.Role != "assistant" || answer.ParentID == "" {
+			return nil, errors.NewBadRequestError("Only answers to a question can be regenerated")
+		}
+		question, err := h.messageService.GetMessage(ctx, sessionID, answer.ParentID)
+		if err != nil || question.Role != "user" {
+			logger.Errorf(ctx, "Failed to get question of message %s: %v", answer.ID, err)
+			return nil, errors.NewBadRequestError("Only answers to a question can be regenerated")
+		}
+		request.Query = question.Content
+		logger.Infof(ctx, "Regenerating answer %s of question %s", answer.ID, question.ID)
+		return &branchPoint{parentID: question.ID, regenerate: true}, nil
+	}
+
+	question, err := h.messageService.GetMessage(ctx, sessionID, secutils.SanitizeForLog(request.EditMessageID))
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get message to edit: %v", err)
+		return nil, errors.NewNotFoundError("Message to edit not found")
+	}
+	if question.Role != "user" {
+		return nil, errors.NewBadRequestError("Only questions can be edited")
+	}
+	logger.Infof(ctx, "Editing question %s", question.ID)
+	return &branchPoint{parentID: question.ParentID}, nil
+}
+
+// startBranch moves the end of the active branch to the branch point, so the messages created next
+// start a new branch there, and rebuilds the LLM context for the conversation up to the branch point
+func (h *Handler) startBranch(ctx context.Context, session *types.Session, branch *branchPoint) error {
+	if branch == nil {
+		return nil
+	}
+	messages, err := h.messageService.BranchFrom(ctx, session.ID, branch.parentID)
+	if err != nil {
+		return err
+	}
+	// The regenerated question is asked again, it is not part of its own history
+	if branch.regenerate && len(messages) > 0 {
+		messages = messages[:len(messages)-1]
+	}
+	if err := h.sessionService.RebuildContext(ctx, session, messages); err != nil {
+		// Not fatal, the answer is generated with the context of the previous branch
+		logger.Errorf(ctx, "Failed to rebuild context for session %s: %v", session.ID, err)
+	}
+	return nil
+}
+
+// GetBranches gets the branch tree of the messages of a session
+func (h *Handler) GetBranches(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("id"))
+
+	tree, err := h.messageService.GetMessageTree(ctx, sessionID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tree,
+	})
+}
+
+// SwitchBranch activates the branch through a message and rebuilds the LLM context for it,
+// the messages of the activated branch are returned
+func (h *Handler) SwitchBranch(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("id"))
+
+	var request SwitchBranchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse request data", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	messageID := secutils.SanitizeForLog(request.MessageID)
+	logger.Infof(ctx, "Switching branch of session %s to message %s", sessionID, messageID)
+
+	session, err := h.sessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get session, session ID: %s, error: %v", sessionID, err)
+		c.Error(errors.NewNotFoundError("Session not found"))
+		return
+	}
+	if _, err := h.messageService.GetMessage(ctx, sessionID, messageID); err != nil {
+		c.Error(errors.NewNotFoundError("Message not found"))
+		return
+	}
+
+	messages, err := h.messageService.SwitchBranch(ctx, sessionID, messageID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if err := h.sessionService.RebuildContext(ctx, session, messages); err != nil {
+		logger.Errorf(ctx, "Failed to rebuild context for session %s: %v", sessionID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    messages,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -20,6 +21,9 @@ type Handler struct {
 	streamManager        interfaces.StreamManager        // Manager for handling streaming responses
 	config               *config.Config                  // Application configuration
 	knowledgebaseService interfaces.KnowledgeBaseService // Service for managing knowledge bases
+	pipelineRegistry     *chatpipline.PipelineRegistry   // Registry resolving chat pipeline definitions
+	usageService         interfaces.UsageService         // Service enforcing the monthly token quota
+	agentRunService      interfaces.AgentRunService      // Service tracking checkpointed agent runs
 }
 
 // NewHandler creates a new instance of Handler with all necessary dependencies
@@ -29,6 +33,9 @@ func NewHandler(
 	streamManager interfaces.StreamManager,
 	config *config.Config,
 	knowledgebaseService interfaces.KnowledgeBaseService,
+	pipelineRegistry *chatpipline.PipelineRegistry,
+	usageService interfaces.UsageService,
+	agentRunService interfaces.AgentRunService,
 ) *Handler {
 	return &Handler{
 		sessionService:       sessionService,
@@ -36,6 +43,9 @@ func NewHandler(
 		streamManager:        streamManager,
 		config:               config,
 		knowledgebaseService: knowledgebaseService,
+		pipelineRegistry:     pipelineRegistry,
+		usageService:         usageService,
+		agentRunService:      agentRunService,
 	}
 }
 
@@ -76,11 +86,17 @@ func (h *Handler) CreateSession(c *gin.Context) {
 		hasAgentKnowledgeBases,
 	)
 
+	if err := h.validatePipeline(ctx, request.Pipeline); err != nil {
+		c.Error(err)
+		return
+	}
+
 	// Create session object with base properties
 	createdSession := &types.Session{
 		TenantID:        tenantID.(uint64),
 		KnowledgeBaseID: request.KnowledgeBaseID,
 		AgentConfig:     request.AgentConfig, // Set agent config if provided
+		Pipeline:        request.Pipeline,
 	}
 
 	// If summary model parameters are empty, set defaults
@@ -284,6 +300,11 @@ func (h *Handler) UpdateSession(c *gin.Context) {
 	session.ID = id
 	session.TenantID = tenantID.(uint64)
 
+	if err := h.validatePipeline(ctx, session.Pipeline); err != nil {
+		c.Error(err)
+		return
+	}
+
 	// Call service to update session
 	if err := h.sessionService.UpdateSession(ctx, &session); err != nil {
 		if err == errors.ErrSessionNotFound {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -269,3 +270,32 @@ func (h *Handler) fillSummaryConfigDefaults(ctx context.Context, config *types.S
 		config.NoMatchPrefix = defaultNoMatchPrefix
 	}
 }
+
+// validatePipeline checks that a session's pipeline can be resolved for the current tenant
+func (h *Handler) validatePipeline(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	var tenantConv *types.ConversationConfig
+	if tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant); ok && tenant != nil {
+		tenantConv = tenant.ConversationConfig
+	}
+	if _, err := h.pipelineRegistry.Resolve(name, tenantConv); err != nil {
+		logger.Warnf(ctx, "Invalid session pipeline %s: %v", name, err)
+		return errors.NewBadRequestError(err.Error())
+	}
+	return nil
+}
+
+// checkTokenQuota rejects chat requests of tenants that used up their monthly token quota
+func (h *Handler) checkTokenQuota(ctx context.Context) error {
+	err := h.usageService.CheckTokenQuota(ctx)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*types.TokenQuotaExceededError); ok {
+		return errors.NewTenantTokenQuotaExceededError()
+	}
+	logger.Errorf(ctx, "Failed to check token quota: %v", err)
+	return errors.NewInternalServerError(err.Error())
+}
@@ -43,6 +43,12 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 		return
 	}
 
+	if err := request.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid metadata filter", err)
+		c.Error(errors.NewBadRequestError("Invalid metadata filter").WithDetails(err.Error()))
+		return
+	}
+
 	logger.Infof(
 		ctx,
 		"Knowledge search request, knowledge base ID: %s, query: %s",
@@ -51,7 +57,7 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 	)
 
 	// Directly call knowledge retrieval service without LLM summarization
-	searchResults, err := h.sessionService.SearchKnowledge(ctx, request.KnowledgeBaseID, request.Query)
+	searchResults, err := h.sessionService.SearchKnowledge(ctx, request.KnowledgeBaseID, request.Query, request.Filter)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
@@ -97,12 +103,32 @@ func (h *Handler) KnowledgeQA(c *gin.Context) {
 		IsCompleted: false,
 	}
 
+	branch, err := h.resolveBranch(ctx, sessionID, &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	// Validate query content
 	if request.Query == "" {
 		logger.Error(ctx, "Query content is empty")
 		c.Error(errors.NewBadRequestError("Query content cannot be empty"))
 		return
 	}
+	if err := request.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid metadata filter", err)
+		c.Error(errors.NewBadRequestError("Invalid metadata filter").WithDetails(err.Error()))
+		return
+	}
+	if err := request.ResponseSchema.Validate(); err != nil {
+		logger.Error(ctx, "Invalid response schema", err)
+		c.Error(errors.NewBadRequestError("Invalid response schema").WithDetails(err.Error()))
+		return
+	}
+	if err := h.checkTokenQuota(ctx); err != nil {
+		c.Error(err)
+		return
+	}
 
 	logger.Infof(
 		ctx,
@@ -133,7 +159,8 @@ func (h *Handler) KnowledgeQA(c *gin.Context) {
 	// Use shared function to handle KnowledgeQA request
 	h.handleKnowledgeQARequest(ctx, c, session, secutils.SanitizeForLog(request.Query),
 		secutils.SanitizeForLogArray(knowledgeBaseIDs),
-		assistantMessage, true, secutils.SanitizeForLog(request.SummaryModelID), request.WebSearchEnabled)
+		assistantMessage, true, secutils.SanitizeForLog(request.SummaryModelID), request.WebSearchEnabled,
+		request.Filter, request.ResponseSchema, branch)
 }
 
 // AgentQA handles agent-based question answering with conversation history and streaming
@@ -161,6 +188,11 @@ func (h *Handler) AgentQA(c *gin.Context) {
 	} else {
 		logger.Warnf(ctx, "failed to marshal for logging: %s", secutils.SanitizeForLog(err.Error()))
 	}
+	branch, err := h.resolveBranch(ctx, sessionID, &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
 	// Validate query content
 	if request.Query == "" {
@@ -168,6 +200,20 @@ func (h *Handler) AgentQA(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Query content cannot be empty"))
 		return
 	}
+	if err := request.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid metadata filter", err)
+		c.Error(errors.NewBadRequestError("Invalid metadata filter").WithDetails(err.Error()))
+		return
+	}
+	if err := request.ResponseSchema.Validate(); err != nil {
+		logger.Error(ctx, "Invalid response schema", err)
+		c.Error(errors.NewBadRequestError("Invalid response schema").WithDetails(err.Error()))
+		return
+	}
+	if err := h.checkTokenQuota(ctx); err != nil {
+		c.Error(err)
+		return
+	}
 
 	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 
@@ -328,6 +374,9 @@ func (h *Handler) AgentQA(c *gin.Context) {
 			false,
 			secutils.SanitizeForLog(request.SummaryModelID),
 			request.WebSearchEnabled,
+			request.Filter,
+			request.ResponseSchema,
+			branch,
 		)
 		return
 	}
@@ -351,12 +400,20 @@ func (h *Handler) AgentQA(c *gin.Context) {
 	// Set headers for SSE immediately
 	setSSEHeaders(c)
 
-	// Create user message
-	if err := h.createUserMessage(ctx, sessionID, secutils.SanitizeForLog(request.Query), requestID); err != nil {
+	// Regenerated and edited messages start a new branch
+	if err := h.startBranch(ctx, session, branch); err != nil {
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
 	}
 
+	// Create user message, a regenerated answer follows the existing question
+	if branch == nil || !branch.regenerate {
+		if err := h.createUserMessage(ctx, sessionID, secutils.SanitizeForLog(request.Query), requestID); err != nil {
+			c.Error(errors.NewInternalServerError(err.Error()))
+			return
+		}
+	}
+
 	// Create assistant message (response)
 	assistantMessagePtr, err := h.createAssistantMessage(ctx, assistantMessage)
 	if err != nil {
@@ -404,6 +461,7 @@ func (h *Handler) AgentQA(c *gin.Context) {
 			session,
 			secutils.SanitizeForLog(request.Query),
 			assistantMessage.ID,
+			request.ResponseSchema,
 			eventBus,
 		)
 		if err != nil {
@@ -438,16 +496,27 @@ func (h *Handler) handleKnowledgeQARequest(
 	generateTitle bool, // Whether to generate title if session has no title
 	summaryModelID string, // Optional summary model ID (overrides session default)
 	webSearchEnabled bool, // Whether web search is enabled
+	filter *types.MetadataFilter, // Optional metadata filter applied to retrieval
+	responseSchema *types.ResponseSchema, // Optional JSON schema the answer must conform to
+	branch *branchPoint, // Where a regenerated answer or edited question branches off, nil to continue
 ) {
 	sessionID := session.ID
 	requestID := getRequestID(c)
 
-	// Create user message
-	if err := h.createUserMessage(ctx, sessionID, query, requestID); err != nil {
+	// Regenerated and edited messages start a new branch
+	if err := h.startBranch(ctx, session, branch); err != nil {
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
 	}
 
+	// Create user message, a regenerated answer follows the existing question
+	if branch == nil || !branch.regenerate {
+		if err := h.createUserMessage(ctx, sessionID, query, requestID); err != nil {
+			c.Error(errors.NewInternalServerError(err.Error()))
+			return
+		}
+	}
+
 	// Create assistant message (response)
 	if _, err := h.createAssistantMessage(ctx, assistantMessage); err != nil {
 		c.Error(errors.NewInternalServerError(err.Error()))
@@ -492,13 +561,23 @@ func (h *Handler) handleKnowledgeQARequest(
 		assistantMessage.Content += data.Content
 		if data.Done {
 			logger.Infof(asyncCtx, "Knowledge QA service completed for session: %s", sessionID)
+			assistantMessage.StructuredOutput = types.StructuredOutput(data.StructuredOutput)
+			if citationMap, ok := data.CitationMap.(*types.CitationMap); ok {
+				assistantMessage.CitationMap = citationMap
+			}
+			if result, ok := data.Groundedness.(*types.GroundednessResult); ok {
+				assistantMessage.Groundedness = result
+			}
 			h.completeAssistantMessage(asyncCtx, assistantMessage)
 			// Emit completion event when stream finishes
 			if err := eventBus.Emit(asyncCtx, event.Event{
 				Type:      event.EventAgentComplete,
 				SessionID: sessionID,
 				Data: event.AgentCompleteData{
-					FinalAnswer: assistantMessage.Content,
+					FinalAnswer:      assistantMessage.Content,
+					StructuredOutput: data.StructuredOutput,
+					CitationMap:      assistantMessage.CitationMap,
+					Groundedness:     data.Groundedness,
 				},
 			}); err != nil {
 				logger.Errorf(asyncCtx, "Failed to emit completion event: %v", err)
@@ -530,6 +609,8 @@ func (h *Handler) handleKnowledgeQARequest(
 			assistantMessage.ID,
 			summaryModelID,
 			webSearchEnabled,
+			filter,
+			responseSchema,
 			eventBus,
 		)
 		if err != nil {
@@ -269,6 +269,83 @@ func (h *Handler) StopSession(c *gin.Context) {
 	})
 }
 
+// ApproveToolCall handles the user's decision on an agent tool call waiting for approval
+func (h *Handler) ApproveToolCall(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+
+	var req ToolApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+		})
+		c.Error(errors.NewBadRequestError("message_id and tool_call_id are required").WithDetails(err.Error()))
+		return
+	}
+	assistantMessageID := secutils.SanitizeForLog(req.MessageID)
+	toolCallID := secutils.SanitizeForLog(req.ToolCallID)
+
+	// Verify the session belongs to the current tenant
+	session, err := h.sessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+		})
+		c.Error(errors.NewNotFoundError("Session not found"))
+		return
+	}
+	if tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64); session.TenantID != tenantID {
+		logger.Warnf(ctx, "Session %s does not belong to tenant %d", sessionID, tenantID)
+		c.Error(errors.NewForbiddenError("Access denied"))
+		return
+	}
+
+	message, err := h.messageService.GetMessage(ctx, sessionID, assistantMessageID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": assistantMessageID,
+		})
+		c.Error(errors.NewNotFoundError("Message not found"))
+		return
+	}
+	if message.IsCompleted {
+		c.Error(errors.NewBadRequestError("Message is already completed"))
+		return
+	}
+
+	// Write the decision to StreamManager, the approver of the running agent reads it from there
+	decisionEvent := interfaces.StreamEvent{
+		ID:        fmt.Sprintf("%s-tool-approval-decision", toolCallID),
+		Type:      types.ResponseTypeToolApprovalDecision,
+		Content:   "",
+		Done:      false,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"tool_call_id": toolCallID,
+			"approved":     req.Approved,
+			"reason":       req.Reason,
+		},
+	}
+	if err := h.streamManager.AppendEvent(ctx, sessionID, assistantMessageID, decisionEvent); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": assistantMessageID,
+		})
+		c.Error(errors.NewInternalServerError("Failed to write tool approval").WithDetails(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Tool call %s of message %s approved: %v", toolCallID, assistantMessageID, req.Approved)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"tool_call_id": toolCallID,
+			"approved":     req.Approved,
+		},
+	})
+}
+
 // handleAgentEventsForSSE handles agent events for SSE streaming using an existing handler
 // The handler is already subscribed to events and AgentQA is already running
 // This function polls StreamManager and pushes events to SSE, allowing graceful handling of disconnections
@@ -277,11 +354,22 @@ func (h *Handler) handleAgentEventsForSSE(
 	c *gin.Context,
 	sessionID, assistantMessageID, requestID string,
 	eventBus *event.EventBus,
+) {
+	h.streamAgentEventsFrom(ctx, c, sessionID, assistantMessageID, requestID, eventBus, 0)
+}
+
+// streamAgentEventsFrom pushes the agent events of the message to SSE starting at the given stream offset,
+// a resumed run starts after the events of its interrupted execution
+func (h *Handler) streamAgentEventsFrom(
+	ctx context.Context,
+	c *gin.Context,
+	sessionID, assistantMessageID, requestID string,
+	eventBus *event.EventBus,
+	lastOffset int,
 ) {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
-	lastOffset := 0
 	log := logger.GetLogger(ctx)
 
 	log.Infof("Starting pull-based SSE streaming for session=%s, message=%s", sessionID, assistantMessageID)
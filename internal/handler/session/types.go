@@ -44,6 +44,8 @@ type CreateSessionRequest struct {
 	SessionStrategy *SessionStrategy `json:"session_strategy"`
 	// Agent configuration (optional, session-level config only: enabled and knowledge_bases)
 	AgentConfig *types.SessionAgentConfig `json:"agent_config"`
+	// Name of the chat pipeline used by knowledge QA (optional, defaults to the tenant or system pipeline)
+	Pipeline string `json:"pipeline"`
 }
 
 // GenerateTitleRequest defines the request structure for generating a session title
@@ -53,20 +55,45 @@ type GenerateTitleRequest struct {
 
 // CreateKnowledgeQARequest defines the request structure for knowledge QA
 type CreateKnowledgeQARequest struct {
-	Query            string   `json:"query"              binding:"required"` // Query text for knowledge base search
-	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`                    // Selected knowledge base ID for this request
-	AgentEnabled     bool     `json:"agent_enabled"`                         // Whether agent mode is enabled for this request
-	WebSearchEnabled bool     `json:"web_search_enabled"`                    // Whether web search is enabled for this request
-	SummaryModelID   string   `json:"summary_model_id"`                      // Optional summary model ID for this request (overrides session default)
+	Query            string   `json:"query"`              // Query text, taken from the question when regenerating
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids"` // Selected knowledge base ID for this request
+	AgentEnabled     bool     `json:"agent_enabled"`      // Whether agent mode is enabled for this request
+	WebSearchEnabled bool     `json:"web_search_enabled"` // Whether web search is enabled for this request
+	SummaryModelID   string   `json:"summary_model_id"`   // Optional summary model ID for this request (overrides session default)
+	// Optional metadata filter restricting retrieval to matching knowledge (knowledge QA only,
+	// agents pass filters through the knowledge_search tool)
+	Filter *types.MetadataFilter `json:"filter,omitempty"`
+	// Optional JSON schema, the answer is additionally returned as an object conforming to it
+	ResponseSchema *types.ResponseSchema `json:"response_schema,omitempty"`
+	// Optional assistant message to regenerate, the new answer becomes a sibling of it on a new branch
+	RegenerateMessageID string `json:"regenerate_message_id,omitempty"`
+	// Optional user message to edit, the query becomes a sibling of it on a new branch
+	EditMessageID string `json:"edit_message_id,omitempty"`
+}
+
+// SwitchBranchRequest defines the request structure for switching the active branch of a session
+type SwitchBranchRequest struct {
+	// Any message of the branch to activate, the branch continues with the latest answers after it
+	MessageID string `json:"message_id" binding:"required"`
 }
 
 // SearchKnowledgeRequest defines the request structure for searching knowledge without LLM summarization
 type SearchKnowledgeRequest struct {
 	Query           string `json:"query"             binding:"required"` // Query text to search for
 	KnowledgeBaseID string `json:"knowledge_base_id" binding:"required"` // ID of the knowledge base to search
+	// Optional metadata filter restricting the search to matching knowledge
+	Filter *types.MetadataFilter `json:"filter,omitempty"`
 }
 
 // StopSessionRequest represents the stop session request
 type StopSessionRequest struct {
 	MessageID string `json:"message_id" binding:"required"`
 }
+
+// ToolApprovalRequest represents the user's decision on an agent tool call waiting for approval
+type ToolApprovalRequest struct {
+	MessageID  string `json:"message_id"   binding:"required"`
+	ToolCallID string `json:"tool_call_id" binding:"required"`
+	Approved   bool   `json:"approved"`
+	Reason     string `json:"reason"`
+}
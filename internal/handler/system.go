@@ -54,7 +54,7 @@ func (h *SystemHandler) GetSystemInfo(c *gin.Context) {
 	// Get vector store engine from config or RETRIEVE_DRIVER
 	vectorStoreEngine := h.getVectorStoreEngine()
 
-	// Get graph database engine from NEO4J_ENABLE
+	// Get graph database engine from GRAPH_DRIVER or NEO4J_ENABLE
 	graphDatabaseEngine := h.getGraphDatabaseEngine()
 
 	// Get MinIO enabled status
@@ -91,7 +91,8 @@ func (h *SystemHandler) getKeywordIndexEngine() string {
 	keywordEngines := []string{}
 	for _, driver := range drivers {
 		driver = strings.TrimSpace(driver)
-		if driver == "postgres" || driver == "elasticsearch_v7" || driver == "elasticsearch_v8" {
+		if driver == "postgres" || driver == "elasticsearch_v7" || driver == "elasticsearch_v8" ||
+			driver == "infinity" || driver == "embedded" {
 			keywordEngines = append(keywordEngines, driver)
 		}
 	}
@@ -120,7 +121,8 @@ func (h *SystemHandler) getVectorStoreEngine() string {
 	vectorEngines := []string{}
 	for _, driver := range drivers {
 		driver = strings.TrimSpace(driver)
-		if driver == "postgres" || driver == "elasticsearch_v8" {
+		if driver == "postgres" || driver == "elasticsearch_v8" || driver == "infinity" ||
+			driver == "embedded" {
 			vectorEngines = append(vectorEngines, driver)
 		}
 	}
@@ -133,6 +135,9 @@ func (h *SystemHandler) getVectorStoreEngine() string {
 
 // getGraphDatabaseEngine returns the graph database engine name
 func (h *SystemHandler) getGraphDatabaseEngine() string {
+	if config.GraphDriver() == config.GraphDriverPostgres {
+		return "PostgreSQL"
+	}
 	if h.neo4jDriver == nil {
 		return "未启用"
 	}
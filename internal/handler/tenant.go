@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/Tencent/WeKnora/internal/agent"
 	agenttools "github.com/Tencent/WeKnora/internal/agent/tools"
+	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -20,9 +23,10 @@ import (
 // Provides functionality for creating, retrieving, updating, and deleting tenants
 // through the REST API endpoints
 type TenantHandler struct {
-	service     interfaces.TenantService
-	userService interfaces.UserService
-	config      *config.Config
+	service          interfaces.TenantService
+	userService      interfaces.UserService
+	config           *config.Config
+	pipelineRegistry *chatpipline.PipelineRegistry
 }
 
 // NewTenantHandler creates a new tenant handler instance with the provided service
@@ -32,11 +36,14 @@ type TenantHandler struct {
 //   - config: Application configuration
 //
 // Returns a pointer to the newly created TenantHandler
-func NewTenantHandler(service interfaces.TenantService, userService interfaces.UserService, config *config.Config) *TenantHandler {
+func NewTenantHandler(service interfaces.TenantService, userService interfaces.UserService, config *config.Config,
+	pipelineRegistry *chatpipline.PipelineRegistry,
+) *TenantHandler {
 	return &TenantHandler{
-		service:     service,
-		userService: userService,
-		config:      config,
+		service:          service,
+		userService:      userService,
+		config:           config,
+		pipelineRegistry: pipelineRegistry,
 	}
 }
 
@@ -379,6 +386,10 @@ type AgentConfigRequest struct {
 	SystemPromptWebEnabled  string   `json:"system_prompt_web_enabled,omitempty"`
 	SystemPromptWebDisabled string   `json:"system_prompt_web_disabled,omitempty"`
 	UseCustomPrompt         *bool    `json:"use_custom_system_prompt"`
+	MaxParallelTools        *int     `json:"max_parallel_tools"`
+	ToolTimeoutSeconds      *int     `json:"tool_timeout_seconds"`
+	// ToolPolicies replaces the tool policies when set, keys are tool names or prefixes ending with "*"
+	ToolPolicies map[string]*types.ToolPolicy `json:"tool_policies"`
 }
 
 // GetTenantAgentConfig retrieves the agent configuration for a tenant
@@ -424,6 +435,9 @@ func (h *TenantHandler) GetTenantAgentConfig(c *gin.Context) {
 				"system_prompt_web_enabled":  agent.ProgressiveRAGSystemPromptWithWeb,
 				"system_prompt_web_disabled": agent.ProgressiveRAGSystemPromptWithoutWeb,
 				"use_custom_system_prompt":   false,
+				"max_parallel_tools":         agent.DefaultAgentMaxParallelTools,
+				"tool_timeout_seconds":       agent.DefaultAgentToolTimeoutSeconds,
+				"tool_policies":              map[string]*types.ToolPolicy{},
 				"available_tools":            availableTools,
 				"available_placeholders":     availablePlaceholders,
 			},
@@ -442,6 +456,14 @@ func (h *TenantHandler) GetTenantAgentConfig(c *gin.Context) {
 	}
 
 	useCustomPrompt := tenant.AgentConfig.UseCustomSystemPrompt
+	maxParallelTools := tenant.AgentConfig.MaxParallelTools
+	if maxParallelTools <= 0 {
+		maxParallelTools = agent.DefaultAgentMaxParallelTools
+	}
+	toolTimeoutSeconds := tenant.AgentConfig.ToolTimeoutSeconds
+	if toolTimeoutSeconds <= 0 {
+		toolTimeoutSeconds = agent.DefaultAgentToolTimeoutSeconds
+	}
 
 	logger.Infof(ctx, "Retrieved tenant agent config successfully, Tenant ID: %d", tenant.ID)
 	c.JSON(http.StatusOK, gin.H{
@@ -454,6 +476,9 @@ func (h *TenantHandler) GetTenantAgentConfig(c *gin.Context) {
 			"system_prompt_web_enabled":  systemPromptWithWeb,
 			"system_prompt_web_disabled": systemPromptWithoutWeb,
 			"use_custom_system_prompt":   useCustomPrompt,
+			"max_parallel_tools":         maxParallelTools,
+			"tool_timeout_seconds":       toolTimeoutSeconds,
+			"tool_policies":              tenant.AgentConfig.ToolPolicies,
 			"available_tools":            availableTools,
 			"available_placeholders":     availablePlaceholders,
 		},
@@ -481,6 +506,25 @@ func (h *TenantHandler) updateTenantAgentConfigInternal(c *gin.Context) {
 		c.Error(errors.NewAgentInvalidTemperatureError())
 		return
 	}
+	// 0 falls back to the default tool concurrency and timeout
+	if req.MaxParallelTools != nil && (*req.MaxParallelTools < 0 || *req.MaxParallelTools > 16) {
+		c.Error(errors.NewAgentInvalidParallelToolsError())
+		return
+	}
+	if req.ToolTimeoutSeconds != nil && (*req.ToolTimeoutSeconds < 0 || *req.ToolTimeoutSeconds > 600) {
+		c.Error(errors.NewAgentInvalidToolTimeoutError())
+		return
+	}
+	for name, policy := range req.ToolPolicies {
+		if strings.TrimSpace(name) == "" || policy == nil {
+			c.Error(errors.NewAgentInvalidToolPolicyError().WithDetails("tool name and policy are required"))
+			return
+		}
+		if err := policy.Validate(); err != nil {
+			c.Error(errors.NewAgentInvalidToolPolicyError().WithDetails(err.Error()))
+			return
+		}
+	}
 
 	// Get existing tenant
 	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
@@ -497,6 +541,22 @@ func (h *TenantHandler) updateTenantAgentConfigInternal(c *gin.Context) {
 	if req.UseCustomPrompt != nil {
 		useCustomPrompt = *req.UseCustomPrompt
 	}
+	maxParallelTools, toolTimeoutSeconds := 0, 0
+	var toolPolicies map[string]*types.ToolPolicy
+	if tenant.AgentConfig != nil {
+		maxParallelTools = tenant.AgentConfig.MaxParallelTools
+		toolTimeoutSeconds = tenant.AgentConfig.ToolTimeoutSeconds
+		toolPolicies = tenant.AgentConfig.ToolPolicies
+	}
+	if req.MaxParallelTools != nil {
+		maxParallelTools = *req.MaxParallelTools
+	}
+	if req.ToolTimeoutSeconds != nil {
+		toolTimeoutSeconds = *req.ToolTimeoutSeconds
+	}
+	if req.ToolPolicies != nil {
+		toolPolicies = req.ToolPolicies
+	}
 
 	tenant.AgentConfig = &types.AgentConfig{
 		MaxIterations:           req.MaxIterations,
@@ -506,6 +566,9 @@ func (h *TenantHandler) updateTenantAgentConfigInternal(c *gin.Context) {
 		SystemPromptWebEnabled:  req.SystemPromptWebEnabled,
 		SystemPromptWebDisabled: req.SystemPromptWebDisabled,
 		UseCustomSystemPrompt:   useCustomPrompt,
+		MaxParallelTools:        maxParallelTools,
+		ToolTimeoutSeconds:      toolTimeoutSeconds,
+		ToolPolicies:            toolPolicies,
 	}
 
 	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
@@ -655,6 +718,13 @@ func (h *TenantHandler) buildDefaultConversationConfig() *types.ConversationConf
 		RerankThreshold:          h.config.Conversation.RerankThreshold,
 		EnableRewrite:            h.config.Conversation.EnableRewrite,
 		EnableQueryExpansion:     h.config.Conversation.EnableQueryExpansion,
+		EnableHyDE:               h.config.Conversation.EnableHyDE,
+		EnableMultiQuery:         h.config.Conversation.EnableMultiQuery,
+		MultiQueryCount:          h.config.Conversation.MultiQueryCount,
+		EnableAnswerCache:        h.config.Conversation.EnableAnswerCache,
+		AnswerCacheThreshold:     h.config.Conversation.AnswerCacheThreshold,
+		Groundedness:             h.config.Conversation.Groundedness,
+		FeedbackRerank:           h.config.Conversation.FeedbackRerank,
 		FallbackStrategy:         h.config.Conversation.FallbackStrategy,
 		FallbackResponse:         h.config.Conversation.FallbackResponse,
 		FallbackPrompt:           h.config.Conversation.FallbackPrompt,
@@ -693,6 +763,42 @@ func validateConversationConfig(req *types.ConversationConfig) error {
 		req.FallbackStrategy != string(types.FallbackStrategyModel) {
 		return errors.NewBadRequestError("fallback_strategy is invalid")
 	}
+	if req.MultiQueryCount < 0 || req.MultiQueryCount > types.MaxMultiQueryCount {
+		return errors.NewBadRequestError(fmt.Sprintf("multi_query_count must be between 0 and %d", types.MaxMultiQueryCount))
+	}
+	if req.AnswerCacheThreshold < 0 || req.AnswerCacheThreshold > 1 {
+		return errors.NewBadRequestError("answer_cache_threshold must be between 0 and 1")
+	}
+	if err := req.FusionConfig.Validate(); err != nil {
+		return errors.NewBadRequestError(err.Error())
+	}
+	if err := req.Groundedness.Validate(); err != nil {
+		return errors.NewBadRequestError(err.Error())
+	}
+	if err := req.FeedbackRerank.Validate(); err != nil {
+		return errors.NewBadRequestError(err.Error())
+	}
+	return nil
+}
+
+// validatePipelines checks the tenant's pipelines against the registered chat pipeline plugins
+func (h *TenantHandler) validatePipelines(req *types.ConversationConfig) error {
+	names := make(map[string]bool, len(req.Pipelines))
+	for _, pipeline := range req.Pipelines {
+		if pipeline == nil {
+			return errors.NewBadRequestError("pipeline must not be empty")
+		}
+		if names[pipeline.Name] {
+			return errors.NewBadRequestError(fmt.Sprintf("duplicate pipeline: %s", pipeline.Name))
+		}
+		names[pipeline.Name] = true
+		if err := h.pipelineRegistry.Validate(pipeline); err != nil {
+			return errors.NewBadRequestError(err.Error())
+		}
+	}
+	if _, err := h.pipelineRegistry.Resolve(req.DefaultPipeline, req); err != nil {
+		return errors.NewBadRequestError(err.Error())
+	}
 	return nil
 }
 
@@ -764,6 +870,36 @@ func (h *TenantHandler) GetTenantConversationConfig(c *gin.Context) {
 		// Query expansion toggle
 		defaultCfg.EnableQueryExpansion = tc.EnableQueryExpansion
 
+		// HyDE and multi-query retrieval
+		defaultCfg.EnableHyDE = tc.EnableHyDE
+		defaultCfg.EnableMultiQuery = tc.EnableMultiQuery
+		if tc.MultiQueryCount > 0 {
+			defaultCfg.MultiQueryCount = tc.MultiQueryCount
+		}
+
+		// Semantic answer cache
+		defaultCfg.EnableAnswerCache = tc.EnableAnswerCache
+		if tc.AnswerCacheThreshold > 0 {
+			defaultCfg.AnswerCacheThreshold = tc.AnswerCacheThreshold
+		}
+
+		// Score fusion
+		defaultCfg.FusionConfig = tc.FusionConfig
+
+		// Groundedness check
+		if tc.Groundedness != nil {
+			defaultCfg.Groundedness = tc.Groundedness
+		}
+
+		// Feedback rerank
+		if tc.FeedbackRerank != nil {
+			defaultCfg.FeedbackRerank = tc.FeedbackRerank
+		}
+
+		// Chat pipelines
+		defaultCfg.Pipelines = tc.Pipelines
+		defaultCfg.DefaultPipeline = tc.DefaultPipeline
+
 		// Model IDs
 		if tc.SummaryModelID != "" {
 			defaultCfg.SummaryModelID = tc.SummaryModelID
@@ -818,6 +954,10 @@ func (h *TenantHandler) updateTenantConversationInternal(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	if err := h.validatePipelines(&req); err != nil {
+		c.Error(err)
+		return
+	}
 
 	// Get existing tenant
 	tenant := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
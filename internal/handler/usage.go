@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// UsageHandler handles usage ledger queries of the tenant
+type UsageHandler struct {
+	usageService interfaces.UsageService
+}
+
+// NewUsageHandler creates a new UsageHandler
+func NewUsageHandler(usageService interfaces.UsageService) *UsageHandler {
+	return &UsageHandler{usageService: usageService}
+}
+
+type usageQueryRequest struct {
+	StartTime       time.Time `form:"start_time"        time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime         time.Time `form:"end_time"          time_format:"2006-01-02T15:04:05Z07:00"`
+	GroupBy         string    `form:"group_by"`
+	Interval        string    `form:"interval"`
+	SessionID       string    `form:"session_id"`
+	KnowledgeBaseID string    `form:"knowledge_base_id"`
+	ModelID         string    `form:"model_id"`
+	Stage           string    `form:"stage"`
+}
+
+// GetUsage aggregates the token usage, call counts and cost of the tenant over a time range
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req usageQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Error(ctx, "Failed to bind usage query", err)
+		c.Error(errors.NewBadRequestError("查询参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	query := &types.UsageQuery{
+		StartTime:       req.StartTime,
+		EndTime:         req.EndTime,
+		Interval:        types.UsageInterval(req.Interval),
+		SessionID:       secutils.SanitizeForLog(req.SessionID),
+		KnowledgeBaseID: secutils.SanitizeForLog(req.KnowledgeBaseID),
+		ModelID:         secutils.SanitizeForLog(req.ModelID),
+		Stage:           types.UsageStage(secutils.SanitizeForLog(req.Stage)),
+	}
+	for _, dimension := range strings.Split(req.GroupBy, ",") {
+		if dimension = strings.TrimSpace(dimension); dimension != "" {
+			query.GroupBy = append(query.GroupBy, types.UsageDimension(dimension))
+		}
+	}
+	if err := query.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError("查询参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	report, err := h.usageService.GetUsage(ctx, query)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetTokenQuota returns the monthly token quota of the tenant and its usage in the current month
+func (h *UsageHandler) GetTokenQuota(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	status, err := h.usageService.GetTokenQuotaStatus(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
@@ -35,6 +35,8 @@ type ChatOptions struct {
 	Thinking            *bool   `json:"thinking"`              // 是否启用思考
 	Tools               []Tool  `json:"tools,omitempty"`       // 可用工具列表
 	ToolChoice          string  `json:"tool_choice,omitempty"` // "auto", "required", "none", or specific tool
+	// 结构化输出格式，仅在模型支持时设置（见 SupportsStructuredOutput）
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 }
 
 // Message 表示聊天消息
@@ -75,11 +77,13 @@ type Chat interface {
 }
 
 type ChatConfig struct {
-	Source    types.ModelSource
-	BaseURL   string
-	ModelName string
-	APIKey    string
-	ModelID   string
+	Source           types.ModelSource
+	BaseURL          string
+	ModelName        string
+	APIKey           string
+	ModelID          string
+	StructuredOutput bool // 远程 API 是否支持 JSON Schema 结构化输出
+	StreamUsage      bool // 远程 API 是否支持在流式响应中返回 token 用量
 }
 
 // NewChat 创建聊天实例
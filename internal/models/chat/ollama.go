@@ -67,6 +67,9 @@ func (c *OllamaChat) buildChatRequest(messages []Message, opts *ChatOptions, isS
 				Value: *opts.Thinking,
 			}
 		}
+		if opts.ResponseFormat != nil {
+			chatReq.Format = opts.ResponseFormat.Schema
+		}
 	}
 
 	return chatReq
@@ -156,6 +159,11 @@ func (c *OllamaChat) ChatStream(
 				streamChan <- types.StreamResponse{
 					ResponseType: types.ResponseTypeAnswer,
 					Done:         true,
+					Usage: &types.TokenUsage{
+						PromptTokens:     resp.PromptEvalCount,
+						CompletionTokens: resp.EvalCount,
+						TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+					},
 				}
 			}
 
@@ -180,6 +188,11 @@ func (c *OllamaChat) ensureModelAvailable(ctx context.Context) error {
 	return c.ollamaService.EnsureModelAvailable(ctx, c.modelName)
 }
 
+// SupportsStructuredOutput Ollama 通过 format 参数原生支持 JSON Schema 输出
+func (c *OllamaChat) SupportsStructuredOutput() bool {
+	return true
+}
+
 // GetModelName 获取模型名称
 func (c *OllamaChat) GetModelName() string {
 	return c.modelName
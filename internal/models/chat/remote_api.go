@@ -20,6 +20,10 @@ type RemoteAPIChat struct {
 	modelID   string
 	baseURL   string
 	apiKey    string
+	// 是否支持 JSON Schema 结构化输出
+	structuredOutput bool
+	// 是否支持 stream_options 在流式响应中返回 token 用量
+	streamUsage bool
 }
 
 // QwenChatCompletionRequest 用于 qwen 模型的自定义请求结构体
@@ -41,6 +45,9 @@ func NewRemoteAPIChat(chatConfig *ChatConfig) (*RemoteAPIChat, error) {
 		modelID:   chatConfig.ModelID,
 		baseURL:   chatConfig.BaseURL,
 		apiKey:    apiKey,
+
+		structuredOutput: chatConfig.StructuredOutput,
+		streamUsage:      chatConfig.StreamUsage,
 	}, nil
 }
 
@@ -119,6 +126,10 @@ func (c *RemoteAPIChat) buildChatCompletionRequest(messages []Message,
 		Messages: c.convertMessages(messages),
 		Stream:   isStream,
 	}
+	if isStream && c.streamUsage {
+		// 在流的最后一个数据块中返回 token 用量，部分服务不接受 stream_options 字段，需在模型配置中开启
+		req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
 	thinking := false
 
 	// 添加可选参数
@@ -171,6 +182,15 @@ func (c *RemoteAPIChat) buildChatCompletionRequest(messages []Message,
 		// 对于 "auto", "none", "required" 直接使用字符串
 		// 对于特定工具名称，使用 ToolChoice 对象
 		// 注意：某些模型（如 DeepSeek）不支持 tool_choice，需要跳过设置
+		if opts.ResponseFormat != nil {
+			req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+				JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+					Name:   opts.ResponseFormat.Name,
+					Schema: opts.ResponseFormat.Schema,
+				},
+			}
+		}
 		if opts.ToolChoice != "" {
 			// DeepSeek 模型不支持 tool_choice，跳过设置（默认行为会自动使用工具）
 			if c.isDeepSeekModel() {
@@ -388,19 +408,29 @@ func (c *RemoteAPIChat) ChatStream(ctx context.Context,
 			return result
 		}
 
+		var usage *types.TokenUsage
 		for {
 			response, err := stream.Recv()
 			if err != nil {
-				// 发送最后一个响应，包含收集到的 tool calls
+				// 发送最后一个响应，包含收集到的 tool calls 和 token 用量
 				streamChan <- types.StreamResponse{
 					ResponseType: types.ResponseTypeAnswer,
 					Content:      "",
 					Done:         true,
 					ToolCalls:    buildOrderedToolCalls(),
+					Usage:        usage,
 				}
 				return
 			}
 
+			if response.Usage != nil {
+				usage = &types.TokenUsage{
+					PromptTokens:     response.Usage.PromptTokens,
+					CompletionTokens: response.Usage.CompletionTokens,
+					TotalTokens:      response.Usage.TotalTokens,
+				}
+			}
+
 			if len(response.Choices) > 0 {
 				delta := response.Choices[0].Delta
 				isDone := string(response.Choices[0].FinishReason) != ""
@@ -493,6 +523,11 @@ func (c *RemoteAPIChat) ChatStream(ctx context.Context,
 	return streamChan, nil
 }
 
+// SupportsStructuredOutput 模型配置声明支持时使用 response_format 约束输出
+func (c *RemoteAPIChat) SupportsStructuredOutput() bool {
+	return c.structuredOutput
+}
+
 // GetModelName 获取模型名称
 func (c *RemoteAPIChat) GetModelName() string {
 	return c.modelName
@@ -125,3 +125,31 @@ func TestRemoteAPIChat(t *testing.T) {
 		})
 	}
 }
+
+// TestBuildChatCompletionRequestStreamUsage 仅在模型配置开启时请求流式用量
+func TestBuildChatCompletionRequestStreamUsage(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "你好"}}
+	tests := []struct {
+		name        string
+		streamUsage bool
+		isStream    bool
+		wantUsage   bool
+	}{
+		{name: "未开启时不发送 stream_options", isStream: true},
+		{name: "开启后流式请求返回用量", streamUsage: true, isStream: true, wantUsage: true},
+		{name: "非流式请求不发送 stream_options", streamUsage: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewRemoteAPIChat(&ChatConfig{ModelName: "m", StreamUsage: tt.streamUsage})
+			require.NoError(t, err)
+			req := c.buildChatCompletionRequest(messages, nil, tt.isStream)
+			if tt.wantUsage {
+				require.NotNil(t, req.StreamOptions)
+				assert.True(t, req.StreamOptions.IncludeUsage)
+			} else {
+				assert.Nil(t, req.StreamOptions)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ResponseFormat 将模型输出约束为符合 JSON Schema 的对象
+type ResponseFormat struct {
+	Name   string          `json:"name"`   // Schema 名称
+	Schema json.RawMessage `json:"schema"` // JSON Schema
+}
+
+// StructuredOutputChat 由原生支持 JSON Schema 结构化输出的模型实现
+type StructuredOutputChat interface {
+	// SupportsStructuredOutput 模型是否支持 ChatOptions.ResponseFormat
+	SupportsStructuredOutput() bool
+}
+
+// SupportsStructuredOutput 判断模型是否原生支持结构化输出
+func SupportsStructuredOutput(c Chat) bool {
+	s, ok := c.(StructuredOutputChat)
+	return ok && s.SupportsStructuredOutput()
+}
+
+// structuredOutputInstruction 不支持原生结构化输出时追加到系统提示词的要求
+const structuredOutputInstruction = `## 输出格式
+只输出一个符合以下 JSON Schema 的 JSON 对象，不要输出 Markdown 代码块或任何其他文字：
+%s`
+
+// structuredOutputRetryPrompt 输出不符合 Schema 时要求模型重新输出的提示词
+const structuredOutputRetryPrompt = `上面的输出无效：%v。请只输出一个符合 JSON Schema 的 JSON 对象。`
+
+// GenerateStructured 生成符合 JSON Schema 的回答
+// 模型原生支持时通过 ResponseFormat 约束输出，否则在提示词中要求 JSON 输出；
+// 两种方式都会校验输出，不符合 Schema 时携带错误原因重试
+func GenerateStructured(ctx context.Context, c Chat, messages []Message, opts *ChatOptions,
+	schema *types.ResponseSchema,
+) (*types.ChatResponse, types.StructuredOutput, error) {
+	options := ChatOptions{}
+	if opts != nil {
+		options = *opts
+	}
+	// 思考内容会混入输出，结构化输出时关闭
+	thinking := false
+	options.Thinking = &thinking
+
+	native := SupportsStructuredOutput(c)
+	if native {
+		options.ResponseFormat = &ResponseFormat{Name: schema.SchemaName(), Schema: schema.Schema}
+		messages = slices.Clone(messages)
+	} else {
+		messages = withStructuredOutputInstruction(messages, schema)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= types.StructuredOutputMaxRetries; attempt++ {
+		resp, err := c.Chat(ctx, messages, &options)
+		if err != nil {
+			return nil, nil, err
+		}
+		output, err := schema.Parse(resp.Content)
+		if err == nil {
+			return resp, output, nil
+		}
+		lastErr = err
+		logger.Warnf(ctx, "Structured output attempt %d (native: %v) is invalid: %v", attempt+1, native, err)
+		messages = append(messages,
+			Message{Role: "assistant", Content: resp.Content},
+			Message{Role: "user", Content: fmt.Sprintf(structuredOutputRetryPrompt, err)},
+		)
+	}
+	return nil, nil, fmt.Errorf("answer does not match the response schema after %d attempts: %w",
+		types.StructuredOutputMaxRetries+1, lastErr)
+}
+
+// withStructuredOutputInstruction 在系统提示词中追加 JSON 输出要求
+func withStructuredOutputInstruction(messages []Message, schema *types.ResponseSchema) []Message {
+	instruction := fmt.Sprintf(structuredOutputInstruction, string(schema.Schema))
+	result := make([]Message, 0, len(messages)+1)
+	if len(messages) == 0 || messages[0].Role != "system" {
+		result = append(result, Message{Role: "system", Content: instruction})
+		return append(result, messages...)
+	}
+	result = append(result, messages...)
+	result[0].Content += "\n\n" + instruction
+	return result
+}
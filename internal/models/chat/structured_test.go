@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedChat 按顺序返回预设回答并记录请求
+type scriptedChat struct {
+	answers  []string
+	native   bool
+	requests [][]Message
+	options  []*ChatOptions
+}
+
+func (c *scriptedChat) Chat(_ context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	c.requests = append(c.requests, messages)
+	c.options = append(c.options, opts)
+	answer := c.answers[0]
+	c.answers = c.answers[1:]
+	return &types.ChatResponse{Content: answer}, nil
+}
+
+func (c *scriptedChat) ChatStream(context.Context, []Message, *ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, nil
+}
+
+func (c *scriptedChat) GetModelName() string           { return "scripted" }
+func (c *scriptedChat) GetModelID() string             { return "scripted" }
+func (c *scriptedChat) SupportsStructuredOutput() bool { return c.native }
+
+func TestGenerateStructured(t *testing.T) {
+	schema := &types.ResponseSchema{Schema: []byte(`{
+		"type": "object",
+		"properties": {
+			"parts": {"type": "array", "items": {"type": "string"}},
+			"kind": {"type": "string", "enum": ["comet", "asteroid"]}
+		},
+		"required": ["parts", "kind"]
+	}`)}
+	require.NoError(t, schema.Validate())
+	messages := []Message{{Role: "system", Content: "system"}, {Role: "user", Content: "question"}}
+
+	// 不支持原生结构化输出：提示词要求 JSON，不符合 Schema 时重试
+	model := &scriptedChat{answers: []string{
+		`{"parts": ["nucleus"], "kind": "planet"}`,
+		"```json\n{\"parts\": [\"nucleus\", \"tail\"], \"kind\": \"comet\"}\n```",
+	}}
+	_, output, err := GenerateStructured(context.Background(), model, messages, nil, schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"parts": ["nucleus", "tail"], "kind": "comet"}`, string(output))
+	require.Len(t, model.requests, 2)
+	assert.Contains(t, model.requests[0][0].Content, "JSON Schema")
+	assert.Nil(t, model.options[0].ResponseFormat)
+	assert.Len(t, model.requests[1], 4)
+	assert.Equal(t, "system", messages[0].Content, "caller messages must not change")
+
+	// 原生结构化输出：通过 ResponseFormat 约束，重试次数用尽后返回错误
+	model = &scriptedChat{native: true, answers: []string{"no json", "{}", `{"parts": []}`}}
+	_, _, err = GenerateStructured(context.Background(), model, messages, nil, schema)
+	require.Error(t, err)
+	assert.Len(t, model.requests, types.StructuredOutputMaxRetries+1)
+	assert.Equal(t, types.DefaultResponseSchemaName, model.options[0].ResponseFormat.Name)
+	assert.Equal(t, "system", model.requests[0][0].Content)
+}
+
+func TestResponseSchemaValidate(t *testing.T) {
+	for _, schema := range []string{
+		`{"type": "array", "items": {"type": "string"}}`,
+		`{"type": "object", "properties": {"tags": {"type": "array"}}}`,
+		`{"type": "object", "properties": {"a": {"type": "date"}}}`,
+		`not json`,
+	} {
+		err := (&types.ResponseSchema{Schema: []byte(schema)}).Validate()
+		assert.Error(t, err, schema)
+	}
+	err := (&types.ResponseSchema{Name: "has space", Schema: []byte(`{"type": "object"}`)}).Validate()
+	assert.True(t, err != nil && strings.Contains(err.Error(), "name"))
+}
@@ -43,6 +43,9 @@ type RouterParams struct {
 	WebSearchHandler      *handler.WebSearchHandler
 	FAQHandler            *handler.FAQHandler
 	TagHandler            *handler.TagHandler
+	UsageHandler          *handler.UsageHandler
+	FeedbackHandler       *handler.FeedbackHandler
+	GraphHandler          *handler.GraphHandler
 }
 
 // NewRouter 创建新的路由
@@ -86,6 +89,7 @@ func NewRouter(params RouterParams) *gin.Engine {
 		RegisterChunkRoutes(v1, params.ChunkHandler)
 		RegisterSessionRoutes(v1, params.SessionHandler)
 		RegisterChatRoutes(v1, params.SessionHandler)
+		RegisterAgentRunRoutes(v1, params.SessionHandler)
 		RegisterMessageRoutes(v1, params.MessageHandler)
 		RegisterModelRoutes(v1, params.ModelHandler)
 		RegisterEvaluationRoutes(v1, params.EvaluationHandler)
@@ -93,6 +97,9 @@ func NewRouter(params RouterParams) *gin.Engine {
 		RegisterSystemRoutes(v1, params.SystemHandler)
 		RegisterMCPServiceRoutes(v1, params.MCPServiceHandler)
 		RegisterWebSearchRoutes(v1, params.WebSearchHandler)
+		RegisterUsageRoutes(v1, params.UsageHandler)
+		RegisterFeedbackRoutes(v1, params.FeedbackHandler)
+		RegisterGraphRoutes(v1, params.GraphHandler)
 	}
 
 	return r
@@ -170,6 +177,10 @@ func RegisterFAQRoutes(r *gin.RouterGroup, handler *handler.FAQHandler) {
 		faq.PUT("/entries/tags", handler.UpdateEntryTagBatch)
 		faq.DELETE("/entries", handler.DeleteEntries)
 		faq.POST("/search", handler.SearchFAQ)
+		// 将对话中的问答提升为 FAQ 条目
+		faq.POST("/promote", handler.PromoteExchange)
+		// 获取问答提升记录
+		faq.GET("/promotions", handler.ListPromotions)
 	}
 }
 
@@ -232,11 +243,30 @@ func RegisterSessionRoutes(r *gin.RouterGroup, handler *session.Handler) {
 		sessions.DELETE("/:id", handler.DeleteSession)
 		sessions.POST("/:session_id/generate_title", handler.GenerateTitle)
 		sessions.POST("/:session_id/stop", handler.StopSession)
+		// 审批或拒绝等待审批的 Agent 工具调用
+		sessions.POST("/:session_id/tool-approval", handler.ApproveToolCall)
+		// 获取会话消息的分支树
+		sessions.GET("/:id/branches", handler.GetBranches)
+		// 切换会话的当前分支
+		sessions.PUT("/:id/branches/active", handler.SwitchBranch)
 		// 继续接收活跃流
 		sessions.GET("/continue-stream/:session_id", handler.ContinueStream)
 	}
 }
 
+// RegisterAgentRunRoutes 注册 Agent 运行记录相关的路由
+func RegisterAgentRunRoutes(r *gin.RouterGroup, handler *session.Handler) {
+	agentRuns := r.Group("/agent-runs")
+	{
+		// 获取租户下未完成的 Agent 运行
+		agentRuns.GET("", handler.ListAgentRuns)
+		// 获取 Agent 运行详情及最近的检查点
+		agentRuns.GET("/:id", handler.GetAgentRun)
+		// 从最近的检查点恢复中断的 Agent 运行（SSE）
+		agentRuns.POST("/:id/resume", handler.ResumeAgentRun)
+	}
+}
+
 // RegisterChatRoutes 注册路由
 func RegisterChatRoutes(r *gin.RouterGroup, handler *session.Handler) {
 	knowledgeChat := r.Group("/knowledge-chat")
@@ -381,3 +411,71 @@ func RegisterWebSearchRoutes(r *gin.RouterGroup, webSearchHandler *handler.WebSe
 		webSearch.GET("/providers", webSearchHandler.GetProviders)
 	}
 }
+
+// RegisterUsageRoutes 注册用量统计相关的路由
+func RegisterUsageRoutes(r *gin.RouterGroup, handler *handler.UsageHandler) {
+	usage := r.Group("/usage")
+	{
+		// 按时间范围查询用量，可按会话、知识库、模型、阶段分组
+		usage.GET("", handler.GetUsage)
+		// 获取本月 token 配额及用量
+		usage.GET("/quota", handler.GetTokenQuota)
+	}
+}
+
+// RegisterFeedbackRoutes 注册消息反馈相关的路由
+func RegisterFeedbackRoutes(r *gin.RouterGroup, handler *handler.FeedbackHandler) {
+	messages := r.Group("/messages")
+	{
+		// 提交（覆盖）回答的评价、纠正和有用的引用
+		messages.PUT("/:session_id/:id/feedback", handler.SubmitFeedback)
+		// 获取回答的反馈
+		messages.GET("/:session_id/:id/feedback", handler.GetFeedback)
+		// 删除回答的反馈
+		messages.DELETE("/:session_id/:id/feedback", handler.DeleteFeedback)
+	}
+	kb := r.Group("/knowledge-bases/:id/feedback")
+	{
+		// 知识库反馈看板，按反馈得分从低到高列出知识和分块
+		kb.GET("", handler.GetDashboard)
+		// 将知识库的反馈导出为评估数据集
+		kb.POST("/export", handler.ExportDataset)
+	}
+}
+
+// RegisterGraphRoutes 注册知识图谱相关的路由
+func RegisterGraphRoutes(r *gin.RouterGroup, handler *handler.GraphHandler) {
+	graph := r.Group("/knowledge-bases/:id/graph")
+	{
+		// 消解知识图谱中指代同一实体的节点，指定 knowledge_id 时只消解该知识的实体
+		graph.POST("/resolve", handler.ResolveEntities)
+		// 获取实体别名
+		graph.GET("/aliases", handler.ListAliases)
+		// 添加实体别名并合并节点
+		graph.POST("/aliases", handler.AddAlias)
+		// 删除实体别名
+		graph.DELETE("/aliases/:alias_id", handler.DeleteAlias)
+		// 分页浏览知识图谱，返回当前页实体及其之间的关系
+		graph.GET("", handler.BrowseGraph)
+		// 分页获取和搜索实体
+		graph.GET("/entities", handler.ListEntities)
+		// 获取实体详情，包括关系和来源分块
+		graph.GET("/entity", handler.GetEntity)
+		// 创建实体
+		graph.POST("/entities", handler.CreateEntity)
+		// 修改实体属性或重命名实体
+		graph.PUT("/entities", handler.UpdateEntity)
+		// 合并实体
+		graph.POST("/entities/merge", handler.MergeEntities)
+		// 删除实体及其关系
+		graph.DELETE("/entities", handler.DeleteEntity)
+		// 创建关系
+		graph.POST("/relations", handler.CreateRelation)
+		// 删除关系
+		graph.DELETE("/relations", handler.DeleteRelation)
+		// 重建知识图谱社区及社区报告
+		graph.POST("/communities", handler.DetectCommunities)
+		// 获取社区及社区报告，可按层级过滤
+		graph.GET("/communities", handler.ListCommunities)
+	}
+}
@@ -14,9 +14,11 @@ import (
 type AsynqTaskParams struct {
 	dig.In
 
-	Server           *asynq.Server
-	Extracter        interfaces.Extracter
-	KnowledgeService interfaces.KnowledgeService
+	Server                  *asynq.Server
+	Extracter               interfaces.Extracter
+	KnowledgeService        interfaces.KnowledgeService
+	EntityResolutionService interfaces.EntityResolutionService
+	GraphCommunityService   interfaces.GraphCommunityService
 }
 
 func getAsynqRedisClientOpt() *asynq.RedisClientOpt {
@@ -73,6 +75,15 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Register summary generation handler
 	mux.HandleFunc(types.TypeSummaryGeneration, params.KnowledgeService.ProcessSummaryGeneration)
 
+	// Register knowledge rechunk handler
+	mux.HandleFunc(types.TypeKnowledgeRechunk, params.KnowledgeService.ProcessKnowledgeRechunk)
+
+	// Register knowledge graph entity resolution handler
+	mux.HandleFunc(types.TypeEntityResolution, params.EntityResolutionService.ResolveEntities)
+
+	// Register knowledge graph community detection handler
+	mux.HandleFunc(types.TypeCommunityDetection, params.GraphCommunityService.DetectCommunities)
+
 	go func() {
 		// Start the server
 		if err := params.Server.Run(mux); err != nil {
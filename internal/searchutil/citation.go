@@ -0,0 +1,285 @@
+package searchutil
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// MinCitationSupport is the share of its terms a sentence must share with the cited chunks to be supported
+	MinCitationSupport = 0.4
+	// minClaimTerms is the number of terms below which a sentence is not verified, e.g. short transitions
+	minClaimTerms = 4
+)
+
+var (
+	// citationMarkerPattern matches "[n]" and the <kb .../> and <web .../> citation tags
+	citationMarkerPattern = regexp.MustCompile(`\[(\d{1,3})\]|<(?:kb|web)\b[^<>]*>`)
+	// citationSourcePattern extracts the cited chunk from a citation tag, web results use their URL as ID
+	citationSourcePattern = regexp.MustCompile(`\b(?:chunk_id|url)\s*=\s*"([^"]*)"`)
+)
+
+// BuildCitationMap maps the citation markers of an answer to the references they cite and verifies
+// every sentence against the chunks cited for it. "[n]" cites the n-th reference, citation tags cite
+// the reference with the given chunk ID or URL. A sentence without own marker is verified against the
+// markers of the next cited sentence of its paragraph. Returns nil if there is nothing to cite.
+func BuildCitationMap(answer string, references []*types.SearchResult) *types.CitationMap {
+	if strings.TrimSpace(answer) == "" || len(references) == 0 {
+		return nil
+	}
+	refByID := make(map[string]*types.SearchResult, len(references))
+	for _, ref := range references {
+		if ref != nil {
+			if _, ok := refByID[ref.ID]; !ok {
+				refByID[ref.ID] = ref
+			}
+		}
+	}
+
+	runes := []rune(answer)
+	citationMap := &types.CitationMap{
+		Citations: []types.Citation{},
+		Markers:   findCitationMarkers(answer, references, refByID),
+		Sentences: []types.CitedSentence{},
+	}
+	cited := make(map[string]bool)
+	for _, marker := range citationMap.Markers {
+		if marker.ChunkID != "" && !cited[marker.ChunkID] {
+			cited[marker.ChunkID] = true
+			citationMap.Citations = append(citationMap.Citations, types.CitationFromReference(refByID[marker.ChunkID]))
+		}
+	}
+
+	sentences := splitCitedSentences(runes, citationMap.Markers)
+	chunkTerms := make(map[string]map[string]struct{})
+	for i, sentence := range sentences {
+		terms := claimTerms(sentence.text)
+		if len(terms) < minClaimTerms || strings.HasPrefix(strings.TrimSpace(sentence.text), "#") {
+			continue
+		}
+		markers := sentence.markers
+		for j := i + 1; len(markers) == 0 && j < len(sentences) && sentences[j].paragraph == sentence.paragraph; j++ {
+			markers = sentences[j].markers
+		}
+		cs := verifySentence(terms, markers, refByID, chunkTerms)
+		cs.Text = string(runes[sentence.start:sentence.end])
+		cs.Start, cs.End = sentence.start, sentence.end
+		if !cs.Supported {
+			citationMap.UnsupportedCount++
+		}
+		citationMap.Sentences = append(citationMap.Sentences, cs)
+	}
+	return citationMap
+}
+
+// AnswerClaims splits an answer into the sentences worth verifying, without their citation markers.
+// Headings and short sentences such as transitions are skipped.
+func AnswerClaims(answer string) []string {
+	runes := []rune(answer)
+	var claims []string
+	for _, sentence := range splitCitedSentences(runes, findCitationMarkers(answer, nil, nil)) {
+		text := strings.TrimSpace(sentence.text)
+		if strings.HasPrefix(text, "#") || len(claimTerms(text)) < minClaimTerms {
+			continue
+		}
+		claims = append(claims, text)
+	}
+	return claims
+}
+
+// findCitationMarkers returns the citation markers of the answer with rune offsets
+func findCitationMarkers(answer string, references []*types.SearchResult,
+	refByID map[string]*types.SearchResult,
+) []types.CitationMarker {
+	markers := []types.CitationMarker{}
+	runeOffset, byteOffset := 0, 0
+	for _, loc := range citationMarkerPattern.FindAllStringSubmatchIndex(answer, -1) {
+		// "[n](...)" is a markdown link rather than a citation
+		if loc[2] >= 0 && strings.HasPrefix(answer[loc[1]:], "(") {
+			continue
+		}
+		runeOffset += utf8.RuneCountInString(answer[byteOffset:loc[0]])
+		byteOffset = loc[0]
+		text := answer[loc[0]:loc[1]]
+		marker := types.CitationMarker{
+			Text:  text,
+			Start: runeOffset,
+			End:   runeOffset + utf8.RuneCountInString(text),
+		}
+		if loc[2] >= 0 {
+			if n, err := strconv.Atoi(answer[loc[2]:loc[3]]); err == nil && n >= 1 && n <= len(references) &&
+				references[n-1] != nil {
+				marker.ChunkID = references[n-1].ID
+			}
+		} else if match := citationSourcePattern.FindStringSubmatch(text); match != nil && refByID[match[1]] != nil {
+			marker.ChunkID = match[1]
+		}
+		markers = append(markers, marker)
+	}
+	return markers
+}
+
+// citedSentence is a sentence of the answer with the markers citing it
+type citedSentence struct {
+	start, end int
+	paragraph  int
+	text       string // Sentence without its markers
+	markers    []types.CitationMarker
+}
+
+// splitCitedSentences splits the answer into sentences, markers following the end of a sentence belong to it
+func splitCitedSentences(runes []rune, markers []types.CitationMarker) []citedSentence {
+	var sentences []citedSentence
+	paragraph, start, next := 0, 0, 0
+	flush := func(end int, endsParagraph bool) {
+		sentence := citedSentence{start: start, end: end, paragraph: paragraph}
+		for sentence.start < end && unicode.IsSpace(runes[sentence.start]) {
+			sentence.start++
+		}
+		for sentence.end > sentence.start && unicode.IsSpace(runes[sentence.end-1]) {
+			sentence.end--
+		}
+		var text strings.Builder
+		pos := start
+		for _, marker := range markers {
+			if marker.Start >= start && marker.End <= end {
+				text.WriteString(string(runes[pos:marker.Start]))
+				pos = marker.End
+				sentence.markers = append(sentence.markers, marker)
+			}
+		}
+		text.WriteString(string(runes[pos:end]))
+		sentence.text = text.String()
+		if strings.TrimSpace(sentence.text) != "" || len(sentence.markers) > 0 {
+			sentences = append(sentences, sentence)
+		}
+		if endsParagraph {
+			paragraph++
+		}
+		start = end
+	}
+
+	for i := 0; i < len(runes); i++ {
+		if next < len(markers) && i == markers[next].Start {
+			i = markers[next].End - 1
+			next++
+			continue
+		}
+		if runes[i] == '\n' {
+			flush(i+1, true)
+			continue
+		}
+		if !isSentenceEnd(runes, i) {
+			continue
+		}
+		// Closing quotes and the markers right after the end of the sentence belong to it
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(`"'”’)）`, runes[end]) {
+			end++
+		}
+		for {
+			j := end
+			for j < len(runes) && (runes[j] == ' ' || runes[j] == '\t') {
+				j++
+			}
+			if next >= len(markers) || markers[next].Start != j {
+				break
+			}
+			end = markers[next].End
+			next++
+		}
+		i = end - 1
+		flush(end, false)
+	}
+	if start < len(runes) {
+		flush(len(runes), true)
+	}
+	return sentences
+}
+
+// isSentenceEnd reports whether the rune at i ends a sentence
+func isSentenceEnd(runes []rune, i int) bool {
+	switch runes[i] {
+	case '。', '！', '？', '；', '!', '?', ';':
+		return true
+	case '.':
+		return i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+	}
+	return false
+}
+
+// verifySentence checks that the sentence terms are found in the chunks cited by the markers
+func verifySentence(terms map[string]struct{}, markers []types.CitationMarker,
+	refByID map[string]*types.SearchResult, chunkTerms map[string]map[string]struct{},
+) types.CitedSentence {
+	sentence := types.CitedSentence{}
+	if len(markers) == 0 {
+		sentence.Reason = types.UnsupportedUncited
+		return sentence
+	}
+	for _, marker := range markers {
+		if marker.ChunkID != "" && !slices.Contains(sentence.ChunkIDs, marker.ChunkID) {
+			sentence.ChunkIDs = append(sentence.ChunkIDs, marker.ChunkID)
+		}
+	}
+	if len(sentence.ChunkIDs) == 0 {
+		sentence.Reason = types.UnsupportedUnknownSource
+		return sentence
+	}
+
+	found := make(map[string]struct{}, len(terms))
+	for _, chunkID := range sentence.ChunkIDs {
+		if _, ok := chunkTerms[chunkID]; !ok {
+			chunkTerms[chunkID] = claimTerms(refByID[chunkID].Content)
+		}
+		for term := range terms {
+			if _, ok := chunkTerms[chunkID][term]; ok {
+				found[term] = struct{}{}
+			}
+		}
+	}
+	sentence.Support = float64(len(found)) / float64(len(terms))
+	sentence.Supported = sentence.Support >= MinCitationSupport
+	if !sentence.Supported {
+		sentence.Reason = types.UnsupportedLowOverlap
+	}
+	return sentence
+}
+
+// claimTerms returns the terms of a text, lowercase words of other scripts and bigrams of Han characters,
+// so Chinese text is compared without word segmentation
+func claimTerms(text string) map[string]struct{} {
+	terms := make(map[string]struct{})
+	var word []rune
+	var prevHan rune
+	flushWord := func() {
+		if len(word) > 1 {
+			terms[strings.ToLower(string(word))] = struct{}{}
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			if prevHan != 0 {
+				terms[string([]rune{prevHan, r})] = struct{}{}
+			}
+			prevHan = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flushWord()
+		}
+		prevHan = 0
+	}
+	flushWord()
+	return terms
+}
@@ -0,0 +1,72 @@
+package searchutil
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestBuildCitationMap(t *testing.T) {
+	references := []*types.SearchResult{
+		{ID: "c1", KnowledgeID: "k1", Content: "彗星由冰和尘埃组成，接近太阳时形成彗尾。", StartAt: 10, EndAt: 30},
+		{ID: "c2", KnowledgeID: "k2", Content: "Halley's comet returns every 76 years.", StartAt: 0, EndAt: 38},
+	}
+	answer := "## 结论\n彗星由冰和尘埃组成。[1] 接近太阳时会形成彗尾[1][3]。\n" +
+		"Halley's comet returns every 76 years. <kb doc=\"b.md\" chunk_id=\"c2\" />\n" +
+		"木星是太阳系最大的行星。\n见 [1](https://example.com)。"
+
+	citationMap := BuildCitationMap(answer, references)
+	if citationMap == nil {
+		t.Fatal("expected a citation map")
+	}
+	if len(citationMap.Markers) != 4 {
+		t.Fatalf("expected 4 markers without the markdown link, got %+v", citationMap.Markers)
+	}
+	first := citationMap.Markers[0]
+	if first.Text != "[1]" || first.ChunkID != "c1" || string([]rune(answer)[first.Start:first.End]) != "[1]" {
+		t.Errorf("unexpected marker %+v", first)
+	}
+	if citationMap.Markers[2].ChunkID != "" {
+		t.Errorf("expected [3] to cite no reference, got %+v", citationMap.Markers[2])
+	}
+	citations := citationMap.Citations
+	if len(citations) != 2 || citations[0].StartAt != 10 || citations[1].ChunkID != "c2" {
+		t.Errorf("unexpected citations %+v", citations)
+	}
+
+	expected := []struct {
+		text      string
+		supported bool
+		reason    string
+	}{
+		{"彗星由冰和尘埃组成。[1]", true, ""},
+		{"接近太阳时会形成彗尾[1][3]。", true, ""},
+		{"Halley's comet returns every 76 years. <kb doc=\"b.md\" chunk_id=\"c2\" />", true, ""},
+		{"木星是太阳系最大的行星。", false, types.UnsupportedUncited},
+	}
+	if len(citationMap.Sentences) != len(expected) {
+		t.Fatalf("expected %d verified sentences, got %+v", len(expected), citationMap.Sentences)
+	}
+	for i, e := range expected {
+		sentence := citationMap.Sentences[i]
+		if text := string([]rune(answer)[sentence.Start:sentence.End]); text != e.text {
+			t.Errorf("sentence %d: expected %q, got %q", i, e.text, text)
+		}
+		if sentence.Supported != e.supported || sentence.Reason != e.reason {
+			t.Errorf("sentence %d: unexpected verification %+v", i, sentence)
+		}
+	}
+	if citationMap.UnsupportedCount != 1 {
+		t.Errorf("expected 1 unsupported sentence, got %d", citationMap.UnsupportedCount)
+	}
+
+	// Sentences without own marker are verified against the next citation of the paragraph
+	citationMap = BuildCitationMap("木星是太阳系最大的行星。彗星由冰和尘埃组成。[1]", references)
+	if first := citationMap.Sentences[0]; first.Supported || first.Reason != types.UnsupportedLowOverlap ||
+		len(first.ChunkIDs) != 1 {
+		t.Errorf("expected the first sentence to be unsupported by c1, got %+v", first)
+	}
+	if BuildCitationMap("answer", nil) != nil {
+		t.Error("expected no citation map without references")
+	}
+}
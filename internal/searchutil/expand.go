@@ -0,0 +1,237 @@
+package searchutil
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// contextExpansion holds the chunks a search result may be expanded with.
+type contextExpansion struct {
+	config *types.ContextExpansionConfig
+	base   *types.Chunk
+	parent *types.Chunk
+	// prev and next hold the neighbouring chunks, nearest first
+	prev []*types.Chunk
+	next []*types.Chunk
+}
+
+// ExpandContext expands ranked search results to their surrounding context (small-to-big retrieval).
+// configs holds the context expansion configuration by knowledge base ID, results of knowledge bases
+// without expansion are kept unchanged. Results must be in rank order: a result whose chunk is covered
+// by a higher ranked result is dropped, and a result is only expanded while the estimated tokens of all
+// results stay within the budget of its knowledge base. The results are left untouched on error.
+func ExpandContext(
+	ctx context.Context,
+	chunkRepo interfaces.ChunkRepository,
+	tenantID uint64,
+	results []*types.SearchResult,
+	configs map[string]*types.ContextExpansionConfig,
+) ([]*types.SearchResult, error) {
+	enabled := false
+	for _, config := range configs {
+		enabled = enabled || config.Enabled()
+	}
+	if !enabled || len(results) == 0 {
+		return results, nil
+	}
+
+	expansions, err := loadContextExpansions(ctx, chunkRepo, tenantID, results, configs)
+	if err != nil {
+		return results, err
+	}
+
+	tokens := 0
+	for _, result := range results {
+		tokens += EstimateTokens(result.Content)
+	}
+
+	covered := make(map[string]bool)
+	expanded := make([]*types.SearchResult, 0, len(results))
+	for _, result := range results {
+		expansion := expansions[result.ID]
+		if covered[result.ID] || (expansion != nil && expansion.parent != nil && covered[expansion.parent.ID]) {
+			tokens -= EstimateTokens(result.Content)
+			continue
+		}
+		own := map[string]bool{result.ID: true}
+		for _, id := range result.SubChunkID {
+			own[id] = true
+		}
+		if expansion != nil {
+			tokens = expansion.apply(result, own, covered, tokens)
+		}
+		covered[result.ID] = true
+		for _, id := range result.SubChunkID {
+			covered[id] = true
+		}
+		expanded = append(expanded, result)
+	}
+	return expanded, nil
+}
+
+// loadContextExpansions fetches the parent and neighbouring chunks of the text results
+// of knowledge bases with context expansion enabled, keyed by result ID
+func loadContextExpansions(
+	ctx context.Context,
+	chunkRepo interfaces.ChunkRepository,
+	tenantID uint64,
+	results []*types.SearchResult,
+	configs map[string]*types.ContextExpansionConfig,
+) (map[string]*contextExpansion, error) {
+	chunks := make(map[string]*types.Chunk)
+	fetch := func(ids []string) error {
+		missing := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if _, ok := chunks[id]; !ok && id != "" {
+				missing = append(missing, id)
+				chunks[id] = nil
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		fetched, err := chunkRepo.ListChunksByID(ctx, tenantID, missing)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range fetched {
+			chunks[chunk.ID] = chunk
+		}
+		return nil
+	}
+
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.ChunkType == string(types.ChunkTypeText) {
+			ids = append(ids, result.ID)
+		}
+	}
+	if err := fetch(ids); err != nil {
+		return nil, err
+	}
+
+	expansions := make(map[string]*contextExpansion)
+	var parentIDs []string
+	for _, id := range ids {
+		base := chunks[id]
+		if base == nil || !configs[base.KnowledgeBaseID].Enabled() {
+			continue
+		}
+		config := configs[base.KnowledgeBaseID]
+		expansions[id] = &contextExpansion{config: config, base: base}
+		if config.Mode == types.ContextExpansionParent && base.ParentChunkID != "" {
+			parentIDs = append(parentIDs, base.ParentChunkID)
+		}
+	}
+	if err := fetch(parentIDs); err != nil {
+		return nil, err
+	}
+
+	// Chunks without a parent are expanded to their neighbours, walking one step per round
+	prevCursors := make(map[string]string)
+	nextCursors := make(map[string]string)
+	for id, expansion := range expansions {
+		if parent := chunks[expansion.base.ParentChunkID]; expansion.config.Mode == types.ContextExpansionParent &&
+			parent != nil && parent.KnowledgeID == expansion.base.KnowledgeID {
+			expansion.parent = parent
+			continue
+		}
+		prevCursors[id] = expansion.base.PreChunkID
+		nextCursors[id] = expansion.base.NextChunkID
+	}
+	for step := 0; step < types.MaxContextExpansionWindow; step++ {
+		var cursorIDs []string
+		for id, expansion := range expansions {
+			if step < expansion.config.GetWindow() {
+				cursorIDs = append(cursorIDs, prevCursors[id], nextCursors[id])
+			}
+		}
+		if err := fetch(cursorIDs); err != nil {
+			return nil, err
+		}
+
+		for id, expansion := range expansions {
+			if step >= expansion.config.GetWindow() {
+				continue
+			}
+			if prev := chunks[prevCursors[id]]; isNeighbor(expansion.base, prev) {
+				expansion.prev = append(expansion.prev, prev)
+				prevCursors[id] = prev.PreChunkID
+			} else {
+				prevCursors[id] = ""
+			}
+			if next := chunks[nextCursors[id]]; isNeighbor(expansion.base, next) {
+				expansion.next = append(expansion.next, next)
+				nextCursors[id] = next.NextChunkID
+			} else {
+				nextCursors[id] = ""
+			}
+		}
+	}
+	return expansions, nil
+}
+
+// isNeighbor reports whether a chunk is a text chunk of the same knowledge as base
+func isNeighbor(base, chunk *types.Chunk) bool {
+	return chunk != nil && chunk.KnowledgeID == base.KnowledgeID && chunk.ChunkType == types.ChunkTypeText
+}
+
+// apply expands the result within the token budget, skipping chunks the result already contains
+// and stopping at chunks covered by other results, and returns the updated token count
+func (e *contextExpansion) apply(result *types.SearchResult, own, covered map[string]bool, tokens int) int {
+	budget := e.config.GetMaxTokens()
+	if e.parent != nil {
+		delta := EstimateTokens(e.parent.Content) - EstimateTokens(result.Content)
+		if tokens+delta > budget {
+			return tokens
+		}
+		result.Content = e.parent.Content
+		result.StartAt = e.parent.StartAt
+		result.EndAt = e.parent.EndAt
+		result.SubChunkID = append(result.SubChunkID, e.parent.ID)
+		covered[e.parent.ID] = true
+		return tokens + delta
+	}
+
+	// Grow alternately on both sides so the hit stays in the middle of its context
+	extend := func(chunks []*types.Chunk, before bool) ([]*types.Chunk, bool) {
+		for len(chunks) > 0 {
+			chunk := chunks[0]
+			chunks = chunks[1:]
+			if own[chunk.ID] {
+				continue
+			}
+			if covered[chunk.ID] {
+				return nil, false
+			}
+			content := ConcatNoOverlap(result.Content, chunk.Content)
+			if before {
+				content = ConcatNoOverlap(chunk.Content, result.Content)
+			}
+			delta := EstimateTokens(content) - EstimateTokens(result.Content)
+			if tokens+delta > budget {
+				return nil, false
+			}
+			tokens += delta
+			result.Content = content
+			result.StartAt = min(result.StartAt, chunk.StartAt)
+			result.EndAt = max(result.EndAt, chunk.EndAt)
+			result.SubChunkID = append(result.SubChunkID, chunk.ID)
+			covered[chunk.ID] = true
+			return chunks, true
+		}
+		return nil, false
+	}
+	prev, next := e.prev, e.next
+	for prevOpen, nextOpen := true, true; prevOpen || nextOpen; {
+		if prevOpen {
+			prev, prevOpen = extend(prev, true)
+		}
+		if nextOpen {
+			next, nextOpen = extend(next, false)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,125 @@
+package searchutil
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type fakeChunkRepo struct {
+	interfaces.ChunkRepository
+	chunks map[string]*types.Chunk
+}
+
+func (r *fakeChunkRepo) ListChunksByID(ctx context.Context, tenantID uint64, ids []string) ([]*types.Chunk, error) {
+	var chunks []*types.Chunk
+	for _, id := range ids {
+		if chunk, ok := r.chunks[id]; ok {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+// newChunkChain creates n consecutive text chunks c0..c{n-1} of one knowledge, each 7 bytes long
+func newChunkChain(n int) *fakeChunkRepo {
+	repo := &fakeChunkRepo{chunks: make(map[string]*types.Chunk)}
+	for i := 0; i < n; i++ {
+		chunk := &types.Chunk{
+			ID:              fmt.Sprintf("c%d", i),
+			KnowledgeID:     "k1",
+			KnowledgeBaseID: "kb1",
+			Content:         fmt.Sprintf("[part%d]", i),
+			ChunkType:       types.ChunkTypeText,
+			StartAt:         i * 7,
+			EndAt:           (i + 1) * 7,
+		}
+		if i > 0 {
+			chunk.PreChunkID = fmt.Sprintf("c%d", i-1)
+		}
+		if i < n-1 {
+			chunk.NextChunkID = fmt.Sprintf("c%d", i+1)
+		}
+		repo.chunks[chunk.ID] = chunk
+	}
+	return repo
+}
+
+func searchResult(repo *fakeChunkRepo, id string) *types.SearchResult {
+	chunk := repo.chunks[id]
+	return &types.SearchResult{
+		ID:          chunk.ID,
+		Content:     chunk.Content,
+		KnowledgeID: chunk.KnowledgeID,
+		StartAt:     chunk.StartAt,
+		EndAt:       chunk.EndAt,
+		ChunkType:   string(chunk.ChunkType),
+	}
+}
+
+func TestExpandContextNeighbors(t *testing.T) {
+	repo := newChunkChain(6)
+	results := []*types.SearchResult{searchResult(repo, "c3"), searchResult(repo, "c4"), searchResult(repo, "c0")}
+	configs := map[string]*types.ContextExpansionConfig{"kb1": {Mode: types.ContextExpansionNeighbor}}
+
+	expanded, err := ExpandContext(context.Background(), repo, 1, results, configs)
+	if err != nil {
+		t.Fatalf("ExpandContext failed: %v", err)
+	}
+	if len(expanded) != 2 || expanded[0].ID != "c3" || expanded[1].ID != "c0" {
+		t.Fatalf("expected c4 to be dropped as covered by c3, got %v", expanded)
+	}
+	if got := expanded[0].Content; got != "[part2][part3][part4]" {
+		t.Errorf("unexpected expanded content %q", got)
+	}
+	if expanded[0].StartAt != 14 || expanded[0].EndAt != 35 {
+		t.Errorf("unexpected range [%d, %d)", expanded[0].StartAt, expanded[0].EndAt)
+	}
+	// c0 can only grow to c1, c2 is already part of the context of c3
+	if got := expanded[1].Content; got != "[part0][part1]" {
+		t.Errorf("unexpected expanded content %q", got)
+	}
+	if !slices.Equal(expanded[1].SubChunkID, []string{"c1"}) {
+		t.Errorf("unexpected sub chunks %v", expanded[1].SubChunkID)
+	}
+}
+
+func TestExpandContextBudget(t *testing.T) {
+	repo := newChunkChain(6)
+	results := []*types.SearchResult{searchResult(repo, "c3")}
+	// The hit takes 2 estimated tokens and the context grows by 2, 2, 1 and 2 tokens
+	configs := map[string]*types.ContextExpansionConfig{"kb1": {Mode: types.ContextExpansionNeighbor, Window: 2, MaxTokens: 8}}
+
+	expanded, err := ExpandContext(context.Background(), repo, 1, results, configs)
+	if err != nil {
+		t.Fatalf("ExpandContext failed: %v", err)
+	}
+	if got := expanded[0].Content; got != "[part1][part2][part3][part4]" {
+		t.Errorf("unexpected expanded content %q", got)
+	}
+}
+
+func TestExpandContextParent(t *testing.T) {
+	repo := newChunkChain(3)
+	repo.chunks["p"] = &types.Chunk{ID: "p", KnowledgeID: "k1", Content: "[section]", ChunkType: types.ChunkTypeText}
+	repo.chunks["c1"].ParentChunkID = "p"
+	repo.chunks["c2"].ParentChunkID = "p"
+	results := []*types.SearchResult{searchResult(repo, "c1"), searchResult(repo, "c2"), searchResult(repo, "c0")}
+	configs := map[string]*types.ContextExpansionConfig{"kb1": {Mode: types.ContextExpansionParent}}
+
+	expanded, err := ExpandContext(context.Background(), repo, 1, results, configs)
+	if err != nil {
+		t.Fatalf("ExpandContext failed: %v", err)
+	}
+	if len(expanded) != 2 || expanded[0].Content != "[section]" {
+		t.Fatalf("expected c1 to be replaced by its parent and c2 dropped, got %v", expanded)
+	}
+	// c0 has no parent and falls back to its neighbours, c1 is covered
+	if got := expanded[1].Content; got != "[part0]" {
+		t.Errorf("unexpected expanded content %q", got)
+	}
+}
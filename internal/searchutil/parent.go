@@ -0,0 +1,79 @@
+package searchutil
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// ResolveParentChunks replaces the child text chunks of hierarchical chunking by their parent chunks,
+// so retrieval matches the small child chunks but returns the text of the large parent sections.
+// Results sharing a parent are collapsed into one, keeping the rank and the best score of the first,
+// and the child chunk IDs are recorded as sub chunks. Children whose parent no longer exists are dropped.
+// Resolved results are updated in place.
+func ResolveParentChunks(
+	ctx context.Context,
+	chunkRepo interfaces.ChunkRepository,
+	tenantID uint64,
+	results []*types.SearchResult,
+) ([]*types.SearchResult, error) {
+	var parentIDs []string
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if result.ChunkType == string(types.ChunkTypeChildText) && !seen[result.ParentChunkID] {
+			seen[result.ParentChunkID] = true
+			parentIDs = append(parentIDs, result.ParentChunkID)
+		}
+	}
+	if len(parentIDs) == 0 {
+		return results, nil
+	}
+
+	parents, err := chunkRepo.ListChunksByID(ctx, tenantID, parentIDs)
+	if err != nil {
+		return results, err
+	}
+	parentMap := make(map[string]*types.Chunk, len(parents))
+	for _, parent := range parents {
+		parentMap[parent.ID] = parent
+	}
+
+	resolved := make([]*types.SearchResult, 0, len(results))
+	byID := make(map[string]*types.SearchResult, len(results))
+	for _, result := range results {
+		if result.ChunkType != string(types.ChunkTypeChildText) {
+			if existing, ok := byID[result.ID]; ok {
+				existing.Score = max(existing.Score, result.Score)
+				continue
+			}
+			byID[result.ID] = result
+			resolved = append(resolved, result)
+			continue
+		}
+
+		parent := parentMap[result.ParentChunkID]
+		if parent == nil {
+			continue
+		}
+		if existing, ok := byID[parent.ID]; ok {
+			existing.Score = max(existing.Score, result.Score)
+			existing.SubChunkID = append(existing.SubChunkID, result.ID)
+			continue
+		}
+		result.SubChunkID = append(result.SubChunkID, result.ID)
+		result.ID = parent.ID
+		result.Content = parent.Content
+		result.ChunkIndex = parent.ChunkIndex
+		result.Seq = parent.ChunkIndex
+		result.StartAt = parent.StartAt
+		result.EndAt = parent.EndAt
+		result.ChunkType = string(parent.ChunkType)
+		result.ParentChunkID = parent.ParentChunkID
+		result.ImageInfo = parent.ImageInfo
+		result.MatchType = types.MatchTypeParentChunk
+		byID[parent.ID] = result
+		resolved = append(resolved, result)
+	}
+	return resolved, nil
+}
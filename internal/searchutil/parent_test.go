@@ -0,0 +1,47 @@
+package searchutil
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestResolveParentChunks(t *testing.T) {
+	repo := newChunkChain(2)
+	child := func(id, parentID string, score float64) *types.SearchResult {
+		return &types.SearchResult{
+			ID:            id,
+			KnowledgeID:   "k1",
+			Content:       "child " + id,
+			ChunkType:     string(types.ChunkTypeChildText),
+			ParentChunkID: parentID,
+			Score:         score,
+		}
+	}
+	results := []*types.SearchResult{
+		child("a", "c1", 0.9),
+		searchResult(repo, "c0"),
+		child("b", "c1", 0.95),
+		child("orphan", "missing", 0.8),
+	}
+
+	resolved, err := ResolveParentChunks(context.Background(), repo, 1, results)
+	if err != nil {
+		t.Fatalf("ResolveParentChunks failed: %v", err)
+	}
+	if len(resolved) != 2 || resolved[0].ID != "c1" || resolved[1].ID != "c0" {
+		t.Fatalf("expected the children to collapse into c1 ahead of c0, got %v", resolved)
+	}
+	parent := resolved[0]
+	if parent.Content != "[part1]" || parent.ChunkType != string(types.ChunkTypeText) || parent.StartAt != 7 {
+		t.Errorf("result not replaced by its parent: %+v", parent)
+	}
+	if parent.Score != 0.95 || parent.MatchType != types.MatchTypeParentChunk {
+		t.Errorf("unexpected score %v or match type %v", parent.Score, parent.MatchType)
+	}
+	if !slices.Equal(parent.SubChunkID, []string{"a", "b"}) {
+		t.Errorf("unexpected sub chunks %v", parent.SubChunkID)
+	}
+}
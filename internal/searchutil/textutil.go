@@ -68,3 +68,27 @@ func ClampFloat(v, minV, maxV float64) float64 {
 	}
 	return v
 }
+
+// ConcatNoOverlap concatenates two strings, removing the overlap between the suffix of a and the prefix of b.
+func ConcatNoOverlap(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+
+	ar := []rune(a)
+	br := []rune(b)
+	for k := min(len(ar), len(br)); k > 0; k-- {
+		if string(ar[len(ar)-k:]) == string(br[:k]) {
+			return string(ar) + string(br[k:])
+		}
+	}
+	return string(ar) + string(br)
+}
+
+// EstimateTokens estimates the token count of a text (rough approximation: 4 characters ≈ 1 token).
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
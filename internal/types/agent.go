@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -20,6 +21,37 @@ type AgentConfig struct {
 	UseCustomSystemPrompt   bool     `json:"use_custom_system_prompt"`             // Whether to use custom system prompt instead of default
 	WebSearchEnabled        bool     `json:"web_search_enabled"`                   // Whether web search tool is enabled
 	WebSearchMaxResults     int      `json:"web_search_max_results"`               // Maximum number of web search results (default: 5)
+	MaxParallelTools        int      `json:"max_parallel_tools,omitempty"`         // Maximum tool calls of a round running at once (0 uses default)
+	ToolTimeoutSeconds      int      `json:"tool_timeout_seconds,omitempty"`       // Timeout of a single tool call in seconds (0 uses default)
+	// ToolPolicies restricts tools by name, keys ending with "*" match name prefixes (e.g. "mcp.*")
+	ToolPolicies map[string]*ToolPolicy `json:"tool_policies,omitempty"`
+	// ResponseSchema requests the final answer as a JSON object conforming to the schema, set per request
+	ResponseSchema *ResponseSchema `json:"response_schema,omitempty"`
+	// Groundedness checks the final answer against the knowledge references, taken from the conversation config
+	Groundedness *GroundednessConfig `json:"groundedness,omitempty"`
+}
+
+// ToolPolicy restricts how the agent runs a tool, zero values mean no restriction
+type ToolPolicy struct {
+	TimeoutSeconds     int  `json:"timeout_seconds,omitempty"`       // Overrides the agent tool timeout
+	MaxCallsPerSession int  `json:"max_calls_per_session,omitempty"` // Maximum calls of the tool within a session
+	MaxOutputChars     int  `json:"max_output_chars,omitempty"`      // Longer outputs are truncated before reaching the LLM
+	RequireApproval    bool `json:"require_approval,omitempty"`      // Pause until the user approves each call
+}
+
+// Validate checks the policy values
+func (p *ToolPolicy) Validate() error {
+	if p.TimeoutSeconds < 0 || p.MaxCallsPerSession < 0 || p.MaxOutputChars < 0 {
+		return errors.New("tool policy values must not be negative")
+	}
+	return nil
+}
+
+// ToolApprovalDecision is the user's answer to a tool call waiting for approval
+type ToolApprovalDecision struct {
+	ToolCallID string `json:"tool_call_id"`
+	Approved   bool   `json:"approved"`
+	Reason     string `json:"reason,omitempty"`
 }
 
 // SessionAgentConfig represents session-level agent configuration
@@ -146,6 +178,12 @@ type AgentState struct {
 	IsComplete    bool            `json:"is_complete"`    // Whether agent has finished
 	FinalAnswer   string          `json:"final_answer"`   // The final answer to the query
 	KnowledgeRefs []*SearchResult `json:"knowledge_refs"` // Collected knowledge references
+	// Final answer as an object conforming to the response schema (only when one was requested)
+	StructuredOutput StructuredOutput `json:"structured_output,omitempty"`
+	// Citation markers of the final answer mapped to the knowledge references
+	CitationMap *CitationMap `json:"citation_map,omitempty"`
+	// Groundedness of the final answer, only when the check is enabled
+	Groundedness *GroundednessResult `json:"groundedness,omitempty"`
 }
 
 // FunctionDefinition represents a function definition for LLM function calling
@@ -0,0 +1,99 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// AgentRunStatus is the status of a persisted agent run
+type AgentRunStatus string
+
+const (
+	// AgentRunStatusRunning means the run is executing, or its process died without finishing it
+	AgentRunStatusRunning AgentRunStatus = "running"
+	// AgentRunStatusCompleted means the run produced its final answer
+	AgentRunStatusCompleted AgentRunStatus = "completed"
+	// AgentRunStatusStopped means the run was stopped by the user
+	AgentRunStatusStopped AgentRunStatus = "stopped"
+	// AgentRunStatusFailed means the run ended with an error
+	AgentRunStatusFailed AgentRunStatus = "failed"
+)
+
+// AgentRunStaleAfter is how long a running agent run may go without a checkpoint
+// before it is considered interrupted and may be resumed
+const AgentRunStaleAfter = 15 * time.Minute
+
+// AgentRun is an agent execution whose state is checkpointed after every round,
+// so an interrupted run can be resumed from its last checkpoint
+type AgentRun struct {
+	// Unique identifier of the run
+	ID string `json:"id" gorm:"type:varchar(36);primaryKey"`
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id" gorm:"index"`
+	// Session the run answers in
+	SessionID string `json:"session_id" gorm:"type:varchar(36)"`
+	// Assistant message the run writes to
+	MessageID string `json:"message_id" gorm:"type:varchar(36)"`
+	// User query of the run
+	Query string `json:"query"`
+	// Status of the run
+	Status AgentRunStatus `json:"status" gorm:"type:varchar(32)"`
+	// Number of completed rounds
+	CurrentRound int `json:"current_round"`
+	// Checkpoint of the last completed round
+	Checkpoint *AgentCheckpoint `json:"checkpoint,omitempty" gorm:"type:jsonb"`
+	// Error of a failed run
+	Error string `json:"error,omitempty"`
+	// Whether the run can be resumed now, computed when listing
+	Resumable bool `json:"resumable" gorm:"-"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+	// Time of the last checkpoint or status change
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsResumable reports whether the run ended early or its process stopped checkpointing it
+func (r *AgentRun) IsResumable(now time.Time) bool {
+	if r.Checkpoint == nil {
+		return false
+	}
+	switch r.Status {
+	case AgentRunStatusStopped, AgentRunStatusFailed:
+		return true
+	case AgentRunStatusRunning:
+		return now.Sub(r.UpdatedAt) > AgentRunStaleAfter
+	default:
+		return false
+	}
+}
+
+// AgentCheckpoint is the agent state saved after every round
+type AgentCheckpoint struct {
+	// Agent configuration the run executes with
+	Config *AgentConfig `json:"config"`
+	// Steps, references and round counter of the run
+	State *AgentState `json:"state"`
+	// LLM messages for the next round, a JSON encoded []chat.Message
+	Messages json.RawMessage `json:"messages"`
+}
+
+// Value implements the driver.Valuer interface, used to convert AgentCheckpoint to database value
+func (c *AgentCheckpoint) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to AgentCheckpoint
+func (c *AgentCheckpoint) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
@@ -0,0 +1,81 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// DefaultAnswerCacheThreshold is the cosine similarity a query needs to reuse a cached answer
+const DefaultAnswerCacheThreshold = 0.95
+
+// CachedAnswer is an answer to a knowledge base question kept in the semantic answer cache
+type CachedAnswer struct {
+	// Query is the rewritten query the answer was generated for
+	Query string `json:"query"`
+	// Embedding is the embedding of the rewritten query
+	Embedding []float32 `json:"embedding"`
+	// Answer is the generated answer
+	Answer string `json:"answer"`
+	// References are the retrieved chunks the answer is based on
+	References []*SearchResult `json:"references"`
+	// CreatedAt is the time the answer was cached
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AnswerCacheScope identifies the cached answers that may be reused for a question: answers are only
+// shared between questions over the same knowledge bases with the same model and retrieval configuration
+type AnswerCacheScope struct {
+	// KnowledgeBaseIDs are the searched knowledge bases, cached answers are dropped when their knowledge changes
+	KnowledgeBaseIDs []string
+	// Fingerprint is a digest of the model and retrieval configuration
+	Fingerprint string
+	// Key is resolved by the cache on lookup, so that an answer stored after its knowledge
+	// bases were invalidated is not reused
+	Key string
+}
+
+// NewAnswerCacheScope creates the answer cache scope of a chat
+func NewAnswerCacheScope(chatManage *ChatManage, embeddingModelID string) *AnswerCacheScope {
+	knowledgeBaseIDs := slices.Clone(chatManage.KnowledgeBaseIDs)
+	if len(knowledgeBaseIDs) == 0 && chatManage.KnowledgeBaseID != "" {
+		knowledgeBaseIDs = []string{chatManage.KnowledgeBaseID}
+	}
+	slices.Sort(knowledgeBaseIDs)
+	knowledgeBaseIDs = slices.Compact(knowledgeBaseIDs)
+
+	config, _ := json.Marshal(struct {
+		KnowledgeBaseIDs []string        `json:"knowledge_base_ids"`
+		EmbeddingModelID string          `json:"embedding_model_id"`
+		ChatModelID      string          `json:"chat_model_id"`
+		RerankModelID    string          `json:"rerank_model_id"`
+		RerankTopK       int             `json:"rerank_top_k"`
+		RerankThreshold  float64         `json:"rerank_threshold"`
+		EmbeddingTopK    int             `json:"embedding_top_k"`
+		VectorThreshold  float64         `json:"vector_threshold"`
+		KeywordThreshold float64         `json:"keyword_threshold"`
+		FusionConfig     *FusionConfig   `json:"fusion_config"`
+		Filter           *MetadataFilter `json:"filter"`
+		SummaryConfig    SummaryConfig   `json:"summary_config"`
+	}{
+		KnowledgeBaseIDs: knowledgeBaseIDs,
+		EmbeddingModelID: embeddingModelID,
+		ChatModelID:      chatManage.ChatModelID,
+		RerankModelID:    chatManage.RerankModelID,
+		RerankTopK:       chatManage.RerankTopK,
+		RerankThreshold:  chatManage.RerankThreshold,
+		EmbeddingTopK:    chatManage.EmbeddingTopK,
+		VectorThreshold:  chatManage.VectorThreshold,
+		KeywordThreshold: chatManage.KeywordThreshold,
+		FusionConfig:     chatManage.FusionConfig,
+		Filter:           chatManage.Filter,
+		SummaryConfig:    chatManage.SummaryConfig,
+	})
+	sum := sha256.Sum256(config)
+	return &AnswerCacheScope{
+		KnowledgeBaseIDs: knowledgeBaseIDs,
+		Fingerprint:      hex.EncodeToString(sum[:]),
+	}
+}
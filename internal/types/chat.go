@@ -18,6 +18,13 @@ type FunctionCall struct {
 	Arguments string `json:"arguments"` // JSON string
 }
 
+// TokenUsage is the token usage reported by a model
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
 // ChatResponse chat response
 type ChatResponse struct {
 	Content string `json:"content"`
@@ -60,6 +67,14 @@ const (
 	ResponseTypeAgentQuery ResponseType = "agent_query"
 	// Complete response type (agent complete)
 	ResponseTypeComplete ResponseType = "complete"
+	// Query expansion response type (queries generated for retrieval by HyDE or multi-query)
+	ResponseTypeQueryExpansion ResponseType = "query_expansion"
+	// Tool approval response type (agent tool call waiting for user approval)
+	ResponseTypeToolApproval ResponseType = "tool_approval"
+	// Tool approval decision response type (user approved or rejected a tool call)
+	ResponseTypeToolApprovalDecision ResponseType = "tool_approval_decision"
+	// Sub-agent response type (progress of a delegated task, nested under its delegate_task call)
+	ResponseTypeSubAgent ResponseType = "sub_agent"
 )
 
 // StreamResponse stream response
@@ -82,6 +97,8 @@ type StreamResponse struct {
 	ToolCalls []LLMToolCall `json:"tool_calls,omitempty"`
 	// Additional metadata for enhanced display
 	Data map[string]interface{} `json:"data,omitempty"`
+	// Token usage of the model call, set on the last response if the model reports it
+	Usage *TokenUsage `json:"usage,omitempty"`
 }
 
 // References references
@@ -1,5 +1,8 @@
 package types
 
+// MaxMultiQueryCount bounds the number of paraphrases generated by the multi-query stage
+const MaxMultiQueryCount = 8
+
 // ChatManage represents the configuration and state for a chat session
 // including query processing, search parameters, and model configurations
 type ChatManage struct {
@@ -19,6 +22,9 @@ type ChatManage struct {
 	RerankTopK      int     `json:"rerank_top_k"`     // Number of top results after reranking
 	RerankThreshold float64 `json:"rerank_threshold"` // Minimum score threshold for reranked results
 
+	FusionConfig *FusionConfig   `json:"fusion_config,omitempty"` // Score fusion for hybrid search, overrides the knowledge base's
+	Filter       *MetadataFilter `json:"filter,omitempty"`        // Metadata filter restricting retrieval to matching knowledge
+
 	MaxRounds int `json:"max_rounds"` // Maximum history rounds used for rewrite/context
 
 	ChatModelID      string           `json:"chat_model_id"`     // ID of the chat model to use
@@ -32,6 +38,20 @@ type ChatManage struct {
 	RewritePromptSystem  string `json:"rewrite_prompt_system"`  // Custom system prompt for rewrite stage
 	RewritePromptUser    string `json:"rewrite_prompt_user"`    // Custom user prompt for rewrite stage
 
+	EnableHyDE       bool `json:"enable_hyde"`        // Whether to search with an LLM-generated hypothetical answer (HyDE)
+	EnableMultiQuery bool `json:"enable_multi_query"` // Whether to search with LLM-generated query paraphrases
+	MultiQueryCount  int  `json:"multi_query_count"`  // Number of paraphrases generated by the multi-query stage
+
+	EnableAnswerCache    bool    `json:"enable_answer_cache"`    // Whether to replay cached answers of similar questions
+	AnswerCacheThreshold float64 `json:"answer_cache_threshold"` // Minimum query similarity to replay a cached answer
+
+	Groundedness *GroundednessConfig `json:"groundedness,omitempty"` // Check of the answer against MergeResult
+
+	FeedbackRerank *FeedbackRerankConfig `json:"feedback_rerank,omitempty"` // Adjusts rerank scores by chunk feedback
+
+	ResponseSchema   *ResponseSchema  `json:"response_schema,omitempty"` // Requests the answer as a JSON object
+	StructuredOutput StructuredOutput `json:"-"`                         // Answer validated against ResponseSchema
+
 	// Internal fields for pipeline data processing
 	SearchResult []*SearchResult `json:"-"` // Results from search phase
 	RerankResult []*SearchResult `json:"-"` // Results after reranking
@@ -40,6 +60,12 @@ type ChatManage struct {
 	GraphResult  *GraphData      `json:"-"` // Graph data from search phase
 	UserContent  string          `json:"-"` // Processed user content
 	ChatResponse *ChatResponse   `json:"-"` // Final response from chat model
+	HyDEDocument string          `json:"-"` // Hypothetical answer embedded for vector search
+	MultiQueries []string        `json:"-"` // Paraphrases of the query searched alongside it
+	AnswerCached bool            `json:"-"` // Whether ChatResponse and MergeResult were replayed from the answer cache
+	CitationMap  *CitationMap    `json:"-"` // Citation markers of ChatResponse mapped to MergeResult
+
+	GroundednessResult *GroundednessResult `json:"-"` // Groundedness of ChatResponse, set by GROUNDEDNESS_CHECK
 
 	// Event system for streaming responses
 	EventBus  EventBusInterface `json:"-"` // EventBus for emitting streaming events
@@ -48,6 +74,9 @@ type ChatManage struct {
 	// Web search configuration (internal use)
 	TenantID         uint64 `json:"-"` // Tenant ID for retrieving web search config
 	WebSearchEnabled bool   `json:"-"` // Whether web search is enabled for this request
+
+	// Types of the searched knowledge bases, used by conditional pipeline stages
+	KnowledgeBaseTypes []string `json:"-"`
 }
 
 // Clone creates a deep copy of the ChatManage object
@@ -70,6 +99,8 @@ func (c *ChatManage) Clone() *ChatManage {
 		RerankModelID:    c.RerankModelID,
 		RerankTopK:       c.RerankTopK,
 		RerankThreshold:  c.RerankThreshold,
+		FusionConfig:     c.FusionConfig,
+		Filter:           c.Filter,
 		ChatModelID:      c.ChatModelID,
 		SummaryConfig: SummaryConfig{
 			MaxTokens:           c.SummaryConfig.MaxTokens,
@@ -92,6 +123,14 @@ func (c *ChatManage) Clone() *ChatManage {
 		RewritePromptUser:    c.RewritePromptUser,
 		EnableRewrite:        c.EnableRewrite,
 		EnableQueryExpansion: c.EnableQueryExpansion,
+		EnableHyDE:           c.EnableHyDE,
+		EnableMultiQuery:     c.EnableMultiQuery,
+		MultiQueryCount:      c.MultiQueryCount,
+		EnableAnswerCache:    c.EnableAnswerCache,
+		AnswerCacheThreshold: c.AnswerCacheThreshold,
+		Groundedness:         c.Groundedness,
+		FeedbackRerank:       c.FeedbackRerank,
+		ResponseSchema:       c.ResponseSchema,
 	}
 }
 
@@ -100,12 +139,18 @@ type EventType string
 
 const (
 	REWRITE_QUERY          EventType = "rewrite_query"          // Query rewriting for better retrieval
+	ANSWER_CACHE           EventType = "answer_cache"           // Replay the cached answer of a similar question
+	HYDE_QUERY             EventType = "hyde_query"             // Generate a hypothetical answer for vector search
+	MULTI_QUERY            EventType = "multi_query"            // Generate query paraphrases searched in parallel
 	CHUNK_SEARCH           EventType = "chunk_search"           // Search for relevant chunks
 	CHUNK_SEARCH_PARALLEL  EventType = "chunk_search_parallel"  // Parallel search: chunks + entities
 	ENTITY_SEARCH          EventType = "entity_search"          // Search for relevant entities
+	GLOBAL_SEARCH          EventType = "global_search"          // Map community reports to key points of the query
 	CHUNK_RERANK           EventType = "chunk_rerank"           // Rerank search results
 	CHUNK_MERGE            EventType = "chunk_merge"            // Merge similar chunks
+	CHUNK_EXPAND           EventType = "chunk_expand"           // Expand chunks to their surrounding context
 	INTO_CHAT_MESSAGE      EventType = "into_chat_message"      // Convert chunks into chat messages
+	GROUNDEDNESS_CHECK     EventType = "groundedness_check"     // Check the answer against the merged chunks
 	CHAT_COMPLETION        EventType = "chat_completion"        // Generate chat completion
 	CHAT_COMPLETION_STREAM EventType = "chat_completion_stream" // Stream chat completion
 	STREAM_FILTER          EventType = "stream_filter"          // Filter streaming output
@@ -122,18 +167,39 @@ var Pipline = map[string][]EventType{
 		STREAM_FILTER,
 	},
 	"rag": { // Retrieval Augmented Generation
+		HYDE_QUERY,
+		MULTI_QUERY,
 		CHUNK_SEARCH,
 		CHUNK_RERANK,
 		CHUNK_MERGE,
+		CHUNK_EXPAND,
 		INTO_CHAT_MESSAGE,
 		CHAT_COMPLETION,
+		GROUNDEDNESS_CHECK,
 	},
 	"rag_stream": { // Streaming Retrieval Augmented Generation
 		REWRITE_QUERY,
+		ANSWER_CACHE,
+		HYDE_QUERY,
+		MULTI_QUERY,
 		CHUNK_SEARCH_PARALLEL, // Parallel: CHUNK_SEARCH + ENTITY_SEARCH
 		CHUNK_RERANK,
 		CHUNK_MERGE,
 		FILTER_TOP_K,
+		CHUNK_EXPAND,
+		INTO_CHAT_MESSAGE,
+		GROUNDEDNESS_CHECK, // Intercepts the streamed answer, so it precedes the completion
+		CHAT_COMPLETION_STREAM,
+		STREAM_FILTER,
+	},
+	"global_search": { // Answer corpus wide questions from the knowledge graph community reports
+		GLOBAL_SEARCH,
+		INTO_CHAT_MESSAGE,
+		CHAT_COMPLETION,
+	},
+	"global_search_stream": { // Streaming answer from the knowledge graph community reports
+		REWRITE_QUERY,
+		GLOBAL_SEARCH,
 		INTO_CHAT_MESSAGE,
 		CHAT_COMPLETION_STREAM,
 		STREAM_FILTER,
@@ -28,6 +28,10 @@ const (
 	ChunkTypeFAQ ChunkType = "faq"
 	// ChunkTypeWebSearch 表示 Web 搜索结果的 Chunk
 	ChunkTypeWebSearch ChunkType = "web_search"
+	// ChunkTypeChildText 表示层级切分中用于检索的子 Chunk，通过 ParentChunkID 关联其父文本 Chunk
+	ChunkTypeChildText ChunkType = "child_text"
+	// ChunkTypeCommunityReport 表示知识图谱社区报告的 Chunk，属于知识库而非某个知识，用于全局检索
+	ChunkTypeCommunityReport ChunkType = "community_report"
 )
 
 // ChunkStatus 定义了不同状态的 Chunk
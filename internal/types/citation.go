@@ -0,0 +1,63 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// Reasons a sentence of an answer is not supported by the chunks it cites
+const (
+	// UnsupportedUncited marks a sentence without citation marker
+	UnsupportedUncited = "uncited"
+	// UnsupportedUnknownSource marks a sentence whose markers cite no retrieved chunk
+	UnsupportedUnknownSource = "unknown_source"
+	// UnsupportedLowOverlap marks a sentence sharing too little text with the chunks it cites
+	UnsupportedLowOverlap = "low_overlap"
+)
+
+// CitationMap links the inline citation markers of an answer to the chunks they cite.
+// Offsets into the answer are character (rune) offsets, for agent messages the answer is the final answer.
+type CitationMap struct {
+	// Chunks cited by the answer, ordered by their first citation
+	Citations []Citation `json:"citations"`
+	// Citation markers in the order they appear in the answer
+	Markers []CitationMarker `json:"markers"`
+	// Sentences of the answer with the result of the grounding verification
+	Sentences []CitedSentence `json:"sentences"`
+	// Number of sentences flagged as unsupported
+	UnsupportedCount int `json:"unsupported_count"`
+}
+
+// CitationMarker is a citation marker in an answer, "[n]" for the n-th reference
+// or <kb chunk_id="..." /> and <web url="..." /> naming the cited chunk
+type CitationMarker struct {
+	Text    string `json:"text"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	ChunkID string `json:"chunk_id,omitempty"` // Empty if the marker cites no retrieved chunk
+}
+
+// CitedSentence is a sentence of an answer and the chunks cited for it
+type CitedSentence struct {
+	Text      string   `json:"text"`
+	Start     int      `json:"start"`
+	End       int      `json:"end"`
+	ChunkIDs  []string `json:"chunk_ids,omitempty"`
+	Support   float64  `json:"support"` // Share of the sentence terms found in the cited chunks
+	Supported bool     `json:"supported"`
+	Reason    string   `json:"reason,omitempty"` // Why the sentence is unsupported
+}
+
+// Value implements the driver.Valuer interface, used to convert CitationMap to database value
+func (m CitationMap) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to CitationMap
+func (m *CitationMap) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, m)
+}
@@ -12,6 +12,10 @@ const (
 	RequestIDContextKey ContextKey = "RequestID"
 	// LoggerContextKey is the context key for logger
 	LoggerContextKey ContextKey = "Logger"
+	// UsageScopeContextKey is the context key for the usage scope of model calls
+	UsageScopeContextKey ContextKey = "UsageScope"
+	// ToolCallIDContextKey is the context key for the ID of the agent tool call being executed
+	ToolCallIDContextKey ContextKey = "ToolCallID"
 )
 
 // String returns the string representation of the context key
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ContextExpansionMode represents how retrieved chunks are expanded before being sent to the model
+type ContextExpansionMode string
+
+// ContextExpansionMode constants
+const (
+	// ContextExpansionNone disables context expansion
+	ContextExpansionNone ContextExpansionMode = "none"
+	// ContextExpansionNeighbor expands a chunk to a window of neighbouring chunks of the same knowledge
+	ContextExpansionNeighbor ContextExpansionMode = "neighbor"
+	// ContextExpansionParent replaces a chunk with its parent chunk,
+	// chunks without a parent are expanded like ContextExpansionNeighbor
+	ContextExpansionParent ContextExpansionMode = "parent"
+)
+
+const (
+	// DefaultContextExpansionWindow is the default number of neighbours added on each side of a chunk
+	DefaultContextExpansionWindow = 1
+	// MaxContextExpansionWindow bounds the number of neighbours added on each side of a chunk
+	MaxContextExpansionWindow = 5
+	// DefaultContextExpansionMaxTokens is the default token budget of the expanded context
+	DefaultContextExpansionMaxTokens = 4000
+)
+
+// ContextExpansionConfig represents the small-to-big retrieval configuration of a knowledge base.
+// Chunks are retrieved and reranked as is, then expanded to their surrounding context.
+type ContextExpansionConfig struct {
+	// Mode is the expansion mode, empty or none disables expansion
+	Mode ContextExpansionMode `yaml:"mode"       json:"mode"`
+	// Window is the number of neighbours added on each side of a chunk, defaults to 1
+	Window int `yaml:"window"     json:"window,omitempty"`
+	// MaxTokens is the estimated token budget of all retrieved context, defaults to 4000
+	MaxTokens int `yaml:"max_tokens" json:"max_tokens,omitempty"`
+}
+
+// Validate checks that the context expansion configuration is well formed
+func (c *ContextExpansionConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Mode {
+	case "", ContextExpansionNone, ContextExpansionNeighbor, ContextExpansionParent:
+	default:
+		return fmt.Errorf("invalid context expansion mode: %s", c.Mode)
+	}
+	if c.Window < 0 || c.Window > MaxContextExpansionWindow {
+		return fmt.Errorf("window must be between 0 and %d", MaxContextExpansionWindow)
+	}
+	if c.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens must not be negative")
+	}
+	return nil
+}
+
+// Enabled reports whether retrieved chunks should be expanded
+func (c *ContextExpansionConfig) Enabled() bool {
+	return c != nil && (c.Mode == ContextExpansionNeighbor || c.Mode == ContextExpansionParent)
+}
+
+// GetWindow returns the number of neighbours added on each side, defaulting to DefaultContextExpansionWindow
+func (c *ContextExpansionConfig) GetWindow() int {
+	if c == nil || c.Window <= 0 {
+		return DefaultContextExpansionWindow
+	}
+	return min(c.Window, MaxContextExpansionWindow)
+}
+
+// GetMaxTokens returns the token budget, defaulting to DefaultContextExpansionMaxTokens
+func (c *ContextExpansionConfig) GetMaxTokens() int {
+	if c == nil || c.MaxTokens <= 0 {
+		return DefaultContextExpansionMaxTokens
+	}
+	return c.MaxTokens
+}
+
+// Value implements the driver.Valuer interface, used to convert ContextExpansionConfig to database value
+func (c ContextExpansionConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to ContextExpansionConfig
+func (c *ContextExpansionConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
@@ -0,0 +1,99 @@
+package types
+
+import "time"
+
+// EntityAliasSource is the step of the entity resolution that mapped an alias to its canonical entity
+type EntityAliasSource string
+
+const (
+	// EntityAliasSourceNormalized is recorded for names equal after normalization
+	EntityAliasSourceNormalized EntityAliasSource = "normalized"
+	// EntityAliasSourceEmbedding is recorded for names whose embedding similarity reaches the threshold
+	EntityAliasSourceEmbedding EntityAliasSource = "embedding"
+	// EntityAliasSourceLLM is recorded for names the chat model judged to be the same entity
+	EntityAliasSourceLLM EntityAliasSource = "llm"
+	// EntityAliasSourceManual is recorded for aliases added through the API, never overwritten by the resolution
+	EntityAliasSourceManual EntityAliasSource = "manual"
+)
+
+const (
+	// DefaultEntitySimilarityThreshold is the embedding similarity from which two names are the same entity
+	DefaultEntitySimilarityThreshold = 0.92
+	// DefaultEntityLLMThreshold is the embedding similarity from which the chat model is asked when
+	// adjudication is enabled
+	DefaultEntityLLMThreshold = 0.8
+)
+
+// EntityResolutionConfig configures the merging of knowledge graph entities that name the same thing
+type EntityResolutionConfig struct {
+	Enabled bool `yaml:"enabled"              json:"enabled"`
+	// SimilarityThreshold merges names whose embeddings are at least as similar, 0 disables embeddings,
+	// defaults to DefaultEntitySimilarityThreshold when unset
+	SimilarityThreshold *float64 `yaml:"similarity_threshold" json:"similarity_threshold,omitempty"`
+	// LLMAdjudication asks the chat model about names between LLMThreshold and SimilarityThreshold
+	LLMAdjudication bool `yaml:"llm_adjudication"     json:"llm_adjudication"`
+	// LLMThreshold defaults to DefaultEntityLLMThreshold
+	LLMThreshold float64 `yaml:"llm_threshold"        json:"llm_threshold,omitempty"`
+	// Suffixes are dropped from names before comparing them, e.g. "Inc." or "有限公司"
+	Suffixes []string `yaml:"suffixes"             json:"suffixes,omitempty"`
+}
+
+// IsEnabled reports whether entities are resolved
+func (c *EntityResolutionConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// Thresholds returns the embedding similarity from which names are merged and from which the chat model
+// is asked, the latter is 0 when adjudication is disabled
+func (c *EntityResolutionConfig) Thresholds() (float64, float64) {
+	similarity := DefaultEntitySimilarityThreshold
+	if c.SimilarityThreshold != nil {
+		similarity = *c.SimilarityThreshold
+	}
+	if !c.LLMAdjudication || similarity <= 0 {
+		return similarity, 0
+	}
+	llm := c.LLMThreshold
+	if llm <= 0 {
+		llm = DefaultEntityLLMThreshold
+	}
+	return similarity, min(llm, similarity)
+}
+
+// GraphEntityAlias maps a name of the knowledge graph of a knowledge base to its canonical entity. Names
+// extracted later are replaced by their canonical entity before they are added to the graph.
+type GraphEntityAlias struct {
+	// ID
+	ID string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id"         gorm:"index"`
+	// Knowledge base of the knowledge graph
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	// Name replaced by the canonical entity
+	Alias string `json:"alias"             gorm:"type:varchar(512)"`
+	// Canonical entity name
+	Canonical string `json:"canonical"         gorm:"type:varchar(512)"`
+	// Resolution step that found the alias
+	Source EntityAliasSource `json:"source"            gorm:"type:varchar(16)"`
+	// Embedding similarity of the alias and the canonical entity, 1 for normalized and manual aliases
+	Score float64 `json:"score"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+	// Last update time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GraphEntityAliasRequest adds a manual alias
+type GraphEntityAliasRequest struct {
+	Alias     string `json:"alias"     binding:"required"`
+	Canonical string `json:"canonical" binding:"required"`
+}
+
+// EntityResolutionPayload represents the entity resolution task payload
+type EntityResolutionPayload struct {
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	// KnowledgeID resolves the entities of the knowledge against the knowledge base, empty rebuilds the
+	// resolution of the whole knowledge base
+	KnowledgeID string `json:"knowledge_id,omitempty"`
+}
@@ -54,6 +54,8 @@ type MetricInput struct {
 
 	GeneratedTexts string // Generated text for evaluation
 	GeneratedGT    string // Ground truth text for comparison
+
+	Groundedness *GroundednessResult // Groundedness of the generated text against the retrieved chunks
 }
 
 // MetricResult contains evaluation metrics
@@ -82,6 +84,8 @@ type GenerationMetrics struct {
 	ROUGE1 float64 `json:"rouge1"` // ROUGE-1 score
 	ROUGE2 float64 `json:"rouge2"` // ROUGE-2 score
 	ROUGEL float64 `json:"rougel"` // ROUGE-L score
+
+	Groundedness float64 `json:"groundedness"` // Share of answer claims supported by the retrieved chunks
 }
 
 // EvalState represents different stages of evaluation process
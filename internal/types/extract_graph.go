@@ -6,6 +6,9 @@ const (
 	TypeFAQImport           = "faq:import"           // FAQ导入任务
 	TypeQuestionGeneration  = "question:generation"  // 问题生成任务
 	TypeSummaryGeneration   = "summary:generation"   // 摘要生成任务
+	TypeKnowledgeRechunk    = "knowledge:rechunk"    // 按知识库切分配置重新切分已有知识
+	TypeEntityResolution    = "graph:resolve"        // 合并知识图谱中指代同一实体的节点
+	TypeCommunityDetection  = "graph:community"      // 知识图谱社区发现与社区报告生成
 )
 
 // ExtractChunkPayload represents the extract chunk task payload
@@ -56,6 +59,13 @@ type SummaryGenerationPayload struct {
 	KnowledgeID     string `json:"knowledge_id"`
 }
 
+// KnowledgeRechunkPayload represents the knowledge rechunk task payload
+type KnowledgeRechunkPayload struct {
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+}
+
 // ChunkContext represents chunk content with surrounding context
 type ChunkContext struct {
 	ChunkID      string `json:"chunk_id"`
@@ -75,6 +85,8 @@ type GraphNode struct {
 	Name       string   `json:"name,omitempty"`
 	Chunks     []string `json:"chunks,omitempty"`
 	Attributes []string `json:"attributes,omitempty"`
+	// Degree is the number of relations of the node, only set by traversal queries
+	Degree int `json:"degree,omitempty"`
 }
 
 // GraphRelation represents the relation of the graph
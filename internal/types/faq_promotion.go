@@ -0,0 +1,117 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// FAQPromotionMode selects how a QA exchange is promoted into a FAQ knowledge base
+type FAQPromotionMode string
+
+const (
+	// FAQPromotionModeAuto adds the question to the most similar entry if it reaches the threshold,
+	// otherwise a new entry is created
+	FAQPromotionModeAuto FAQPromotionMode = "auto"
+	// FAQPromotionModeNew creates a new entry
+	FAQPromotionModeNew FAQPromotionMode = "new"
+	// FAQPromotionModeSimilar adds the question as a similar question of an existing entry
+	FAQPromotionModeSimilar FAQPromotionMode = "similar"
+)
+
+// FAQPromotionAction is the change a promotion made to the FAQ knowledge base
+type FAQPromotionAction string
+
+const (
+	// FAQPromotionActionCreated is recorded for promotions that created a new entry
+	FAQPromotionActionCreated FAQPromotionAction = "created"
+	// FAQPromotionActionMerged is recorded for promotions that added a similar question to an entry
+	FAQPromotionActionMerged FAQPromotionAction = "merged"
+)
+
+// DefaultFAQPromotionThreshold is the similarity from which an existing entry is a duplicate of the question
+const DefaultFAQPromotionThreshold = 0.9
+
+// FAQPromotionRequest promotes the exchange of an assistant message and its question into a FAQ knowledge base
+type FAQPromotionRequest struct {
+	SessionID string `json:"session_id" binding:"required"`
+	// Assistant message whose answer is promoted
+	MessageID string `json:"message_id" binding:"required"`
+	// Edited question, defaults to the question of the answer
+	Question string `json:"question"`
+	// Edited answer, defaults to the correction of the message feedback and then to the answer
+	Answer string `json:"answer"`
+	// Mode defaults to auto
+	Mode FAQPromotionMode `json:"mode"`
+	// Entry the question is added to in similar mode, defaults to the most similar entry
+	EntryID string `json:"entry_id"`
+	// Similarity from which an entry is a duplicate, defaults to DefaultFAQPromotionThreshold
+	Threshold float64 `json:"threshold"`
+	// Creates a new entry even if a duplicate exists
+	Force bool `json:"force"`
+	// Tag of a new entry
+	TagID string `json:"tag_id"`
+	// Only plans the promotion, nothing is changed or recorded
+	DryRun bool `json:"dry_run"`
+}
+
+// Validate checks the mode and threshold and applies their defaults
+func (r *FAQPromotionRequest) Validate() error {
+	switch r.Mode {
+	case "":
+		r.Mode = FAQPromotionModeAuto
+	case FAQPromotionModeAuto, FAQPromotionModeNew, FAQPromotionModeSimilar:
+	default:
+		return fmt.Errorf("invalid promotion mode: %s", r.Mode)
+	}
+	if r.Threshold < 0 || r.Threshold > 1 {
+		return fmt.Errorf("promotion threshold must be between 0 and 1")
+	}
+	if r.Threshold == 0 {
+		r.Threshold = DefaultFAQPromotionThreshold
+	}
+	return nil
+}
+
+// FAQPromotion is the audit record of a QA exchange promoted into a FAQ knowledge base
+type FAQPromotion struct {
+	// ID
+	ID string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id"         gorm:"index"`
+	// FAQ knowledge base the exchange was promoted into
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	// Session of the promoted message
+	SessionID string `json:"session_id"        gorm:"type:varchar(36)"`
+	// Promoted assistant message
+	MessageID string `json:"message_id"        gorm:"type:varchar(36)"`
+	// Created entry or entry the question was added to
+	EntryID string `json:"entry_id"          gorm:"type:varchar(36)"`
+	// Change made to the knowledge base
+	Action FAQPromotionAction `json:"action"            gorm:"type:varchar(16)"`
+	// Promoted question
+	Question string `json:"question"          gorm:"type:text"`
+	// Promoted answer, the answer added to the entry if any
+	Answer string `json:"answer"            gorm:"type:text"`
+	// Similarity of the most similar existing entry, 0 if there was none
+	Similarity float64 `json:"similarity"`
+	// User who promoted the exchange, empty for API key requests
+	ReviewerID string `json:"reviewer_id"       gorm:"type:varchar(36)"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FAQPromotionResult is the outcome of a promotion, or the plan of a dry run
+type FAQPromotionResult struct {
+	// Action taken, or planned in a dry run
+	Action FAQPromotionAction `json:"action"`
+	DryRun bool               `json:"dry_run"`
+	// Question and answer as promoted
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	// Created or merged entry, the target entry in a dry run of a merge
+	Entry *FAQEntry `json:"entry,omitempty"`
+	// Similar existing entries, most similar first
+	Candidates []*FAQEntry `json:"candidates"`
+	// Audit record, nil in a dry run
+	Promotion *FAQPromotion `json:"promotion,omitempty"`
+}
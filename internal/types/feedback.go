@@ -0,0 +1,177 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// FeedbackRating is the rating of an assistant message
+type FeedbackRating string
+
+const (
+	FeedbackRatingUp   FeedbackRating = "up"
+	FeedbackRatingDown FeedbackRating = "down"
+)
+
+// Validate checks that the rating is known
+func (r FeedbackRating) Validate() error {
+	switch r {
+	case FeedbackRatingUp, FeedbackRatingDown:
+		return nil
+	default:
+		return fmt.Errorf("invalid feedback rating: %s", r)
+	}
+}
+
+// FeedbackScorePrior is the number of neutral votes the feedback score of a chunk is smoothed with,
+// so a single vote does not move a chunk as far as a consistent history of votes
+const FeedbackScorePrior = 2
+
+// DefaultFeedbackRerankWeight is the share of its score a chunk gains with only positive feedback
+const DefaultFeedbackRerankWeight = 0.1
+
+// MessageFeedback is the feedback of a user on an assistant message, one per message
+type MessageFeedback struct {
+	// ID
+	ID string `json:"id"               gorm:"type:varchar(36);primaryKey"`
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id"        gorm:"index"`
+	// Session of the message
+	SessionID string `json:"session_id"       gorm:"type:varchar(36)"`
+	// Rated assistant message
+	MessageID string `json:"message_id"       gorm:"type:varchar(36);uniqueIndex"`
+	// Rating of the answer
+	Rating FeedbackRating `json:"rating"           gorm:"type:varchar(16)"`
+	// Corrected answer written by the user, empty if none
+	Correction string `json:"correction"       gorm:"type:text"`
+	// References the user marked as useful, empty if none were marked
+	UsefulChunkIDs StringArray `json:"useful_chunk_ids" gorm:"type:jsonb"`
+	// Question of the answer at the time of the feedback
+	Query string `json:"query"            gorm:"type:text"`
+	// Rated answer
+	Answer string `json:"answer"           gorm:"type:text"`
+	// Votes on the referenced chunks
+	Chunks []*FeedbackChunk `json:"chunks"           gorm:"foreignKey:FeedbackID"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+	// Last updated time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FeedbackChunk is the vote a message feedback casts on a chunk referenced by the message
+type FeedbackChunk struct {
+	// ID
+	ID uint64 `json:"-"                 gorm:"primaryKey;autoIncrement"`
+	// Feedback the vote belongs to
+	FeedbackID string `json:"-"                 gorm:"type:varchar(36);index"`
+	// Tenant ID
+	TenantID uint64 `json:"-"`
+	// Knowledge base of the chunk
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	// Knowledge of the chunk
+	KnowledgeID string `json:"knowledge_id"      gorm:"type:varchar(36)"`
+	// Referenced chunk
+	ChunkID string `json:"chunk_id"          gorm:"type:varchar(36)"`
+	// Vote on the chunk, see FeedbackVote
+	Vote int `json:"vote"`
+	// Creation time
+	CreatedAt time.Time `json:"-"`
+}
+
+// FeedbackVote returns the vote a feedback casts on a referenced chunk: +1 for a useful chunk, -1 for a
+// chunk of a bad answer that was not marked useful and 0 for the other chunks of a good answer once the
+// user marked some references as useful. Without marked references all chunks share the rating.
+func FeedbackVote(rating FeedbackRating, marked bool, useful bool) int {
+	switch {
+	case useful:
+		return 1
+	case rating == FeedbackRatingDown:
+		return -1
+	case marked:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// FeedbackScore returns the quality signal of positive and negative votes, between -1 and 1
+func FeedbackScore(positive int64, negative int64) float64 {
+	return float64(positive-negative) / float64(positive+negative+FeedbackScorePrior)
+}
+
+// ChunkFeedbackStats is the feedback aggregated over a chunk
+type ChunkFeedbackStats struct {
+	ChunkID     string `json:"chunk_id"`
+	KnowledgeID string `json:"knowledge_id"`
+	Positive    int64  `json:"positive"`
+	Negative    int64  `json:"negative"`
+	// Score is the FeedbackScore of the votes
+	Score float64 `json:"score"`
+}
+
+// KnowledgeFeedbackStats is the feedback aggregated over the chunks of a knowledge
+type KnowledgeFeedbackStats struct {
+	KnowledgeID    string  `json:"knowledge_id"`
+	KnowledgeTitle string  `json:"knowledge_title"`
+	Positive       int64   `json:"positive"`
+	Negative       int64   `json:"negative"`
+	Score          float64 `json:"score"`
+}
+
+// FeedbackSummary counts the feedback on answers referencing a knowledge base
+type FeedbackSummary struct {
+	Total       int64 `json:"total"`
+	Up          int64 `json:"up"`
+	Down        int64 `json:"down"`
+	Corrections int64 `json:"corrections"`
+}
+
+// FeedbackDashboard is the feedback of a knowledge base, knowledge and chunks are listed worst first
+type FeedbackDashboard struct {
+	KnowledgeBaseID string                    `json:"knowledge_base_id"`
+	Summary         FeedbackSummary           `json:"summary"`
+	Knowledge       []*KnowledgeFeedbackStats `json:"knowledge"`
+	Chunks          []*ChunkFeedbackStats     `json:"chunks"`
+}
+
+// FeedbackDatasetExport describes an evaluation dataset exported from feedback
+type FeedbackDatasetExport struct {
+	// DatasetID is accepted as dataset_id by the evaluation
+	DatasetID     string `json:"dataset_id"`
+	QuestionCount int    `json:"question_count"`
+	PassageCount  int    `json:"passage_count"`
+	// Skipped counts feedback without a reference answer or a useful chunk
+	Skipped int `json:"skipped"`
+}
+
+// FeedbackRerankConfig adjusts rerank scores by the feedback on the chunks
+type FeedbackRerankConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Weight is the share of its score a chunk gains with only positive feedback, or loses with only
+	// negative feedback, defaults to DefaultFeedbackRerankWeight
+	Weight float64 `yaml:"weight"  json:"weight,omitempty"`
+}
+
+// IsEnabled reports whether rerank scores are adjusted
+func (c *FeedbackRerankConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// Validate checks that the feedback rerank configuration is well formed
+func (c *FeedbackRerankConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Weight < 0 || c.Weight > 1 {
+		return fmt.Errorf("feedback rerank weight must be between 0 and 1")
+	}
+	return nil
+}
+
+// GetWeight returns the weight, defaulting to DefaultFeedbackRerankWeight
+func (c *FeedbackRerankConfig) GetWeight() float64 {
+	if c == nil || c.Weight == 0 {
+		return DefaultFeedbackRerankWeight
+	}
+	return c.Weight
+}
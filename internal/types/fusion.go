@@ -0,0 +1,103 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// FusionMethod represents the method used to combine results of multiple retrievers
+type FusionMethod string
+
+// FusionMethod constants
+const (
+	// FusionMethodRRF ranks by reciprocal rank fusion, ignoring raw scores
+	FusionMethodRRF FusionMethod = "rrf"
+	// FusionMethodWeighted sums min-max normalized scores with per-retriever weights
+	FusionMethodWeighted FusionMethod = "weighted"
+	// FusionMethodDBSF sums scores normalized by their distribution (mean ± 3 std) with per-retriever weights
+	FusionMethodDBSF FusionMethod = "dbsf"
+)
+
+// DefaultRRFK is the default rank constant for reciprocal rank fusion
+const DefaultRRFK = 60
+
+// FusionConfig represents the score fusion configuration for hybrid search
+type FusionConfig struct {
+	// Method is the fusion method, defaults to rrf
+	Method FusionMethod `yaml:"method"  json:"method"`
+	// RRFK is the rank constant used by rrf, defaults to 60
+	RRFK int `yaml:"rrf_k"   json:"rrf_k,omitempty"`
+	// Weights holds per-retriever weights keyed by retriever type, missing retrievers weigh 1
+	Weights map[RetrieverType]float64 `yaml:"weights" json:"weights,omitempty"`
+}
+
+// DefaultFusionConfig returns the fusion configuration used when none is configured
+func DefaultFusionConfig() *FusionConfig {
+	return &FusionConfig{Method: FusionMethodRRF, RRFK: DefaultRRFK}
+}
+
+// Validate checks that the fusion configuration is well formed
+func (c *FusionConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Method {
+	case "", FusionMethodRRF, FusionMethodWeighted, FusionMethodDBSF:
+	default:
+		return fmt.Errorf("invalid fusion method: %s", c.Method)
+	}
+	if c.RRFK < 0 {
+		return fmt.Errorf("rrf_k must not be negative")
+	}
+	for retrieverType, weight := range c.Weights {
+		if weight < 0 {
+			return fmt.Errorf("weight of retriever %s must not be negative", retrieverType)
+		}
+	}
+	return nil
+}
+
+// GetMethod returns the fusion method, defaulting to rrf
+func (c *FusionConfig) GetMethod() FusionMethod {
+	if c == nil || c.Method == "" {
+		return FusionMethodRRF
+	}
+	return c.Method
+}
+
+// GetRRFK returns the rrf rank constant, defaulting to DefaultRRFK
+func (c *FusionConfig) GetRRFK() int {
+	if c == nil || c.RRFK <= 0 {
+		return DefaultRRFK
+	}
+	return c.RRFK
+}
+
+// GetWeight returns the weight of a retriever, defaulting to 1
+func (c *FusionConfig) GetWeight(retrieverType RetrieverType) float64 {
+	if c == nil {
+		return 1
+	}
+	if weight, ok := c.Weights[retrieverType]; ok {
+		return weight
+	}
+	return 1
+}
+
+// Value implements the driver.Valuer interface, used to convert FusionConfig to database value
+func (c FusionConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to FusionConfig
+func (c *FusionConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
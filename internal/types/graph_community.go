@@ -0,0 +1,147 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// DefaultCommunityMinSize is the number of entities from which a community is summarized
+	DefaultCommunityMinSize = 3
+	// DefaultCommunityReportEntities is the number of entities of a community given to the chat model
+	DefaultCommunityReportEntities = 30
+	// DefaultCommunityConcurrency is the number of chat requests sent at once
+	DefaultCommunityConcurrency = 4
+	// DefaultGlobalSearchBatchSize is the number of community reports per map request of the global search
+	DefaultGlobalSearchBatchSize = 5
+	// DefaultGlobalSearchMaxPoints is the number of key points the global search answers from
+	DefaultGlobalSearchMaxPoints = 20
+)
+
+// GraphCommunityConfig configures the community detection of knowledge graphs and the global search over
+// the community reports
+type GraphCommunityConfig struct {
+	Enabled bool `yaml:"enabled"         json:"enabled"`
+	// Resolution of the modularity, higher values find smaller communities, defaults to 1
+	Resolution float64 `yaml:"resolution"      json:"resolution,omitempty"`
+	// MaxLevels caps the levels of the community hierarchy, defaults to 3
+	MaxLevels int `yaml:"max_levels"      json:"max_levels,omitempty"`
+	// MinSize is the number of entities from which a community is summarized
+	MinSize int `yaml:"min_size"        json:"min_size,omitempty"`
+	// ReportEntities caps the entities of a community given to the chat model, most connected first
+	ReportEntities int `yaml:"report_entities" json:"report_entities,omitempty"`
+	// Concurrency caps the chat requests sent at once by the detection and the global search
+	Concurrency int `yaml:"concurrency"     json:"concurrency,omitempty"`
+	// SearchLevel is the level whose reports the global search reads, 0 is the coarsest level and levels
+	// deeper than the hierarchy read its finest level
+	SearchLevel int `yaml:"search_level"    json:"search_level"`
+	// BatchSize is the number of reports per map request of the global search
+	BatchSize int `yaml:"batch_size"      json:"batch_size,omitempty"`
+	// MaxPoints is the number of key points the global search answers from
+	MaxPoints int `yaml:"max_points"      json:"max_points,omitempty"`
+}
+
+// IsEnabled reports whether communities are detected
+func (c *GraphCommunityConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// WithDefaults returns a copy of the configuration with the defaults of the unset values
+func (c *GraphCommunityConfig) WithDefaults() GraphCommunityConfig {
+	var result GraphCommunityConfig
+	if c != nil {
+		result = *c
+	}
+	if result.MinSize <= 0 {
+		result.MinSize = DefaultCommunityMinSize
+	}
+	if result.ReportEntities <= 0 {
+		result.ReportEntities = DefaultCommunityReportEntities
+	}
+	if result.Concurrency <= 0 {
+		result.Concurrency = DefaultCommunityConcurrency
+	}
+	if result.BatchSize <= 0 {
+		result.BatchSize = DefaultGlobalSearchBatchSize
+	}
+	if result.MaxPoints <= 0 {
+		result.MaxPoints = DefaultGlobalSearchMaxPoints
+	}
+	return result
+}
+
+// CommunityDetectionPayload represents the community detection task payload
+type CommunityDetectionPayload struct {
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+}
+
+// CommunityReportMetadata is the Chunk.Metadata of a community report chunk
+type CommunityReportMetadata struct {
+	// CommunityID is unique among the communities of the knowledge base, e.g. "1-4"
+	CommunityID string `json:"community_id"`
+	// Level in the hierarchy, 0 is the coarsest
+	Level int `json:"level"`
+	// Parent is the community ID of the enclosing community, empty at level 0
+	Parent string `json:"parent,omitempty"`
+	// Title of the report
+	Title string `json:"title"`
+	// Rating is the importance of the community from 0 to 10 given by the chat model
+	Rating float64 `json:"rating"`
+	// Size is the number of entities of the community
+	Size int `json:"size"`
+	// Entities are the most connected entities of the community
+	Entities []string `json:"entities"`
+	// Summary and Findings of the report, kept so that the report can be reused
+	Summary  string   `json:"summary,omitempty"`
+	Findings []string `json:"findings,omitempty"`
+	// MembersHash identifies the entities of the community, a later detection finding a community of the same
+	// entities reuses the report instead of summarizing it again
+	MembersHash string `json:"members_hash,omitempty"`
+}
+
+// CommunityReportMetadata parses the community report metadata of the chunk
+func (c *Chunk) CommunityReportMetadata() (*CommunityReportMetadata, error) {
+	if c == nil || len(c.Metadata) == 0 {
+		return nil, nil
+	}
+	var meta CommunityReportMetadata
+	if err := json.Unmarshal(c.Metadata, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// SetCommunityReportMetadata sets the community report metadata of the chunk
+func (c *Chunk) SetCommunityReportMetadata(meta *CommunityReportMetadata) error {
+	if c == nil {
+		return nil
+	}
+	if meta == nil {
+		c.Metadata = nil
+		return nil
+	}
+	bytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	c.Metadata = JSON(bytes)
+	return nil
+}
+
+// GraphCommunity is a community of the knowledge graph with its report
+type GraphCommunity struct {
+	// ID of the report chunk
+	ID string `json:"id"`
+	CommunityReportMetadata
+	// Report written by the chat model, in markdown
+	Report string `json:"report"`
+	// CreatedAt is the time of the detection
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GraphCommunityListRequest filters the communities of a knowledge graph
+type GraphCommunityListRequest struct {
+	// Level keeps the communities of the level, all levels if omitted
+	Level *int `form:"level" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,88 @@
+package types
+
+import "time"
+
+// ManualGraphKnowledgeID is the knowledge of the namespace holding the entities, attributes and relations
+// edited through the API. Re-extracting or deleting documents never deletes it, so edits are preserved.
+const ManualGraphKnowledgeID = "manual"
+
+// GraphTombstoneKind is the kind of knowledge graph element a tombstone removes
+type GraphTombstoneKind string
+
+const (
+	// GraphTombstoneEntity removes an entity and its relations
+	GraphTombstoneEntity GraphTombstoneKind = "entity"
+	// GraphTombstoneRelation removes a relation
+	GraphTombstoneRelation GraphTombstoneKind = "relation"
+)
+
+// GraphTombstone records an entity or relation deleted through the API, so it is dropped when documents are
+// extracted again
+type GraphTombstone struct {
+	// ID
+	ID string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id"         gorm:"index"`
+	// Knowledge base of the knowledge graph
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	// Kind of the deleted element
+	Kind GraphTombstoneKind `json:"kind"              gorm:"type:varchar(16)"`
+	// Name of the deleted entity, empty for relations
+	Name string `json:"name"              gorm:"type:varchar(512)"`
+	// Source, target and type of the deleted relation, empty for entities
+	Source string `json:"source"            gorm:"type:varchar(512)"`
+	Target string `json:"target"            gorm:"type:varchar(512)"`
+	Type   string `json:"type"              gorm:"type:varchar(255)"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GraphBrowseRequest pages the entities of a knowledge graph, most connected first
+type GraphBrowseRequest struct {
+	Pagination
+	// Keyword keeps the entities whose name contains it, ignoring case
+	Keyword string `form:"keyword"`
+}
+
+// GraphEntityRequest creates an entity
+type GraphEntityRequest struct {
+	Name       string   `json:"name"       binding:"required"`
+	Attributes []string `json:"attributes"`
+}
+
+// GraphEntityUpdateRequest renames an entity or replaces its attributes
+type GraphEntityUpdateRequest struct {
+	Name string `json:"name"       binding:"required"`
+	// NewName renames the entity, it is merged into the entity of the new name if there is one
+	NewName string `json:"new_name"`
+	// Attributes replace the attributes of the entity, omitted attributes are kept and empty attributes restore
+	// the extracted ones
+	Attributes []string `json:"attributes"`
+}
+
+// GraphEntityMergeRequest merges entities into one
+type GraphEntityMergeRequest struct {
+	Names []string `json:"names" binding:"required,min=1"`
+	Into  string   `json:"into"  binding:"required"`
+}
+
+// GraphRelationRequest identifies a relation to create or delete
+type GraphRelationRequest struct {
+	Source string `json:"source" form:"source" binding:"required"`
+	Target string `json:"target" form:"target" binding:"required"`
+	Type   string `json:"type"   form:"type"   binding:"required"`
+}
+
+// GraphEntityChunk is a chunk an entity was extracted from
+type GraphEntityChunk struct {
+	ID          string `json:"id"`
+	KnowledgeID string `json:"knowledge_id"`
+	Content     string `json:"content"`
+}
+
+// GraphEntityDetail is an entity with its relations and the chunks it was extracted from
+type GraphEntityDetail struct {
+	Entity    *GraphNode          `json:"entity"`
+	Relations []*GraphRelation    `json:"relations"`
+	Chunks    []*GraphEntityChunk `json:"chunks"`
+}
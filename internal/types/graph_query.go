@@ -0,0 +1,136 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultGraphHops is the default distance of a neighbourhood expansion
+	DefaultGraphHops = 1
+	// DefaultGraphPathHops is the default maximum length of a path
+	DefaultGraphPathHops = 4
+	// MaxGraphHops caps the distance of expansions and the length of paths
+	MaxGraphHops = 6
+	// DefaultGraphNodeLimit is the default number of nodes returned by an expansion
+	DefaultGraphNodeLimit = 50
+	// MaxGraphNodeLimit caps the number of nodes returned by an expansion
+	MaxGraphNodeLimit = 500
+)
+
+// GraphQuery expands the neighbourhood of entities in a namespace
+type GraphQuery struct {
+	// Nodes are the start entities, matched on name containment like SearchNode
+	Nodes []string
+	// Hops is the maximum distance from a start entity
+	Hops int
+	// RelationTypes restricts the traversed relations, all relations if empty
+	RelationTypes []string
+	// Limit caps the number of returned nodes, start entities first and then by degree
+	Limit int
+}
+
+// Normalize applies the defaults and caps of the query
+func (q *GraphQuery) Normalize() {
+	q.Hops = clampGraphValue(q.Hops, DefaultGraphHops, MaxGraphHops)
+	q.Limit = clampGraphValue(q.Limit, DefaultGraphNodeLimit, MaxGraphNodeLimit)
+}
+
+// GraphPathQuery finds the shortest path between two entities in a namespace
+type GraphPathQuery struct {
+	// Source and Target are matched on name containment like SearchNode
+	Source string
+	Target string
+	// MaxHops is the maximum length of the path
+	MaxHops int
+	// RelationTypes restricts the traversed relations, all relations if empty
+	RelationTypes []string
+}
+
+// Normalize applies the defaults and caps of the query
+func (q *GraphPathQuery) Normalize() {
+	q.MaxHops = clampGraphValue(q.MaxHops, DefaultGraphPathHops, MaxGraphHops)
+}
+
+// GraphSearchConfig configures the graph traversal of the entity search stage
+type GraphSearchConfig struct {
+	// Hops is the distance the extracted entities are expanded to, 1 returns direct neighbours
+	Hops int `yaml:"hops"       json:"hops"`
+	// MaxNodes caps the nodes whose chunks are added to the search results, ranked by degree
+	MaxNodes int `yaml:"max_nodes"  json:"max_nodes"`
+	// FindPaths adds the shortest paths between the extracted entities so their documents are bridged
+	FindPaths bool `yaml:"find_paths" json:"find_paths"`
+}
+
+// GraphQuery returns the expansion query of the entities
+func (c *GraphSearchConfig) GraphQuery(entities []string) *GraphQuery {
+	query := &GraphQuery{Nodes: entities}
+	if c != nil {
+		query.Hops = c.Hops
+		query.Limit = c.MaxNodes
+	}
+	query.Normalize()
+	return query
+}
+
+// PathsEnabled reports whether paths between the entities are searched
+func (c *GraphSearchConfig) PathsEnabled() bool {
+	return c != nil && c.FindPaths
+}
+
+// RankGraphNodes orders nodes with start entities first, then by decreasing degree, and keeps at most limit
+// nodes. Relations whose endpoints were dropped are removed.
+func RankGraphNodes(graph *GraphData, isStart func(name string) bool, limit int) {
+	if graph == nil {
+		return
+	}
+	nodes := append([]*GraphNode(nil), graph.Node...)
+	rank := func(node *GraphNode) int {
+		if isStart(node.Name) {
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if rank(nodes[i]) != rank(nodes[j]) {
+			return rank(nodes[i]) > rank(nodes[j])
+		}
+		return nodes[i].Degree > nodes[j].Degree
+	})
+	if limit > 0 && len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+	kept := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		kept[node.Name] = true
+	}
+	relations := make([]*GraphRelation, 0, len(graph.Relation))
+	for _, rel := range graph.Relation {
+		if kept[rel.Node1] && kept[rel.Node2] {
+			relations = append(relations, rel)
+		}
+	}
+	graph.Node = nodes
+	graph.Relation = relations
+}
+
+// clampGraphValue returns def for non-positive values and caps the others at limit
+func clampGraphValue(value int, def int, limit int) int {
+	if value <= 0 {
+		return def
+	}
+	if value > limit {
+		return limit
+	}
+	return value
+}
+
+// GraphNameMatches reports whether the entity name contains one of the terms, like SearchNode matches names
+func GraphNameMatches(name string, terms []string) bool {
+	for _, term := range terms {
+		if term != "" && strings.Contains(name, term) {
+			return true
+		}
+	}
+	return false
+}
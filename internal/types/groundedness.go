@@ -0,0 +1,123 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Judges scoring the claims of an answer against the retrieved chunks
+const (
+	// GroundednessJudgeLLM asks the chat model whether the chunks entail each claim
+	GroundednessJudgeLLM = "llm"
+	// GroundednessJudgeRerank scores the relevance of the chunks to each claim with the rerank model
+	GroundednessJudgeRerank = "rerank"
+)
+
+// Actions taken when an answer is not grounded
+const (
+	// GroundednessActionAnnotate only attaches the groundedness result to the answer
+	GroundednessActionAnnotate = "annotate"
+	// GroundednessActionRegenerate regenerates the answer once with a stricter prompt
+	GroundednessActionRegenerate = "regenerate"
+	// GroundednessActionFallback replaces the answer with the fallback response
+	GroundednessActionFallback = "fallback"
+)
+
+// DefaultGroundednessThreshold is the share of supported claims an answer needs to be grounded
+const DefaultGroundednessThreshold = 0.7
+
+// GroundednessConfig configures the check of answers against the retrieved chunks
+type GroundednessConfig struct {
+	Enabled bool `yaml:"enabled"   json:"enabled"`
+	// Judge is llm (default) or rerank
+	Judge string `yaml:"judge"     json:"judge,omitempty"`
+	// Threshold is the share of supported claims an answer needs, defaults to DefaultGroundednessThreshold
+	Threshold float64 `yaml:"threshold" json:"threshold,omitempty"`
+	// Action is annotate (default), regenerate or fallback
+	Action string `yaml:"action"    json:"action,omitempty"`
+}
+
+// IsEnabled reports whether answers are checked
+func (c *GroundednessConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// Validate checks that the groundedness configuration is well formed
+func (c *GroundednessConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Judge {
+	case "", GroundednessJudgeLLM, GroundednessJudgeRerank:
+	default:
+		return fmt.Errorf("invalid groundedness judge: %s", c.Judge)
+	}
+	switch c.Action {
+	case "", GroundednessActionAnnotate, GroundednessActionRegenerate, GroundednessActionFallback:
+	default:
+		return fmt.Errorf("invalid groundedness action: %s", c.Action)
+	}
+	if c.Threshold < 0 || c.Threshold > 1 {
+		return fmt.Errorf("groundedness threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// GetJudge returns the judge, defaulting to llm
+func (c *GroundednessConfig) GetJudge() string {
+	if c == nil || c.Judge == "" {
+		return GroundednessJudgeLLM
+	}
+	return c.Judge
+}
+
+// GetAction returns the action, defaulting to annotate
+func (c *GroundednessConfig) GetAction() string {
+	if c == nil || c.Action == "" {
+		return GroundednessActionAnnotate
+	}
+	return c.Action
+}
+
+// GetThreshold returns the threshold, defaulting to DefaultGroundednessThreshold
+func (c *GroundednessConfig) GetThreshold() float64 {
+	if c == nil || c.Threshold <= 0 {
+		return DefaultGroundednessThreshold
+	}
+	return c.Threshold
+}
+
+// GroundednessResult is the result of checking an answer against the retrieved chunks
+type GroundednessResult struct {
+	// Share of claims supported by the retrieved chunks, 1 for answers without claims
+	Score float64 `json:"score"`
+	// Whether the score reaches the threshold
+	Grounded bool   `json:"grounded"`
+	Judge    string `json:"judge"`
+	// Action taken because the checked answer was not grounded, empty if none
+	Action string         `json:"action,omitempty"`
+	Claims []ClaimVerdict `json:"claims"`
+}
+
+// ClaimVerdict is the verdict of the judge on a claim of the answer
+type ClaimVerdict struct {
+	Claim     string   `json:"claim"`
+	Supported bool     `json:"supported"`
+	Score     float64  `json:"score"`               // Confidence of the support, in [0, 1]
+	ChunkIDs  []string `json:"chunk_ids,omitempty"` // Chunks supporting the claim
+}
+
+// Value implements the driver.Valuer interface, used to convert GroundednessResult to database value
+func (r GroundednessResult) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to GroundednessResult
+func (r *GroundednessResult) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, r)
+}
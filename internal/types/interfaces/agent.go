@@ -26,6 +26,23 @@ type AgentEngine interface {
 		sessionID, messageID, query string,
 		llmContext []chat.Message,
 	) (*types.AgentState, error)
+
+	// Resume continues an interrupted execution from its checkpointed state and LLM messages
+	Resume(
+		ctx context.Context,
+		sessionID, messageID, query string,
+		state *types.AgentState,
+		messages []chat.Message,
+	) (*types.AgentState, error)
+
+	// SetCheckpointer sets the checkpointer called after every round
+	SetCheckpointer(checkpointer AgentCheckpointer)
+}
+
+// AgentCheckpointer persists the agent state after every round
+type AgentCheckpointer interface {
+	// SaveCheckpoint saves the state and the LLM messages for the next round
+	SaveCheckpoint(ctx context.Context, state *types.AgentState, messages []chat.Message) error
 }
 
 // AgentService defines the interface for agent-related operations
@@ -39,6 +56,7 @@ type AgentService interface {
 		eventBus *event.EventBus,
 		contextManager ContextManager,
 		sessionID string,
+		messageID string,
 		sessionService SessionService,
 	) (AgentEngine, error)
 
@@ -0,0 +1,40 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// AgentRunService tracks agent runs and checkpoints their state so interrupted runs can be resumed
+type AgentRunService interface {
+	// StartRun records a new running agent run of the assistant message
+	StartRun(ctx context.Context, sessionID, messageID, query string) (*types.AgentRun, error)
+	// ResumeRun claims a resumable run and marks it as running again, it fails with a conflict error if the run
+	// changed since it was read, e.g. because a concurrent resume claimed it
+	ResumeRun(ctx context.Context, run *types.AgentRun) error
+	// FinishRun records the outcome of the run, a cancelled context means it was stopped
+	FinishRun(ctx context.Context, run *types.AgentRun, runErr error)
+	// Checkpointer returns the checkpointer saving the rounds of the run
+	Checkpointer(run *types.AgentRun, config *types.AgentConfig) AgentCheckpointer
+	// GetRun gets a run of the tenant including its checkpoint
+	GetRun(ctx context.Context, id string) (*types.AgentRun, error)
+	// ListInFlightRuns lists the unfinished runs of the tenant
+	ListInFlightRuns(ctx context.Context) ([]*types.AgentRun, error)
+}
+
+// AgentRunRepository stores agent runs
+type AgentRunRepository interface {
+	// CreateRun creates a run
+	CreateRun(ctx context.Context, run *types.AgentRun) error
+	// UpdateRun saves the status, round and checkpoint of a run
+	UpdateRun(ctx context.Context, run *types.AgentRun) error
+	// ClaimRun marks the run as running if it is in one of the statuses and unchanged since it was read,
+	// it reports whether the run was claimed
+	ClaimRun(ctx context.Context, run *types.AgentRun, statuses []types.AgentRunStatus, now time.Time) (bool, error)
+	// GetRun gets a run of the tenant
+	GetRun(ctx context.Context, tenantID uint64, id string) (*types.AgentRun, error)
+	// ListRuns lists the runs of the tenant in the given statuses without their checkpoints, newest first
+	ListRuns(ctx context.Context, tenantID uint64, statuses []types.AgentRunStatus) ([]*types.AgentRun, error)
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// AnswerCache caches knowledge base answers by the embedding of their rewritten query
+type AnswerCache interface {
+	// Lookup returns the cached answer of the scope most similar to the query embedding,
+	// or nil if none reaches the similarity threshold. It resolves the key of the scope.
+	Lookup(ctx context.Context, scope *types.AnswerCacheScope,
+		embedding []float32, threshold float64) (*types.CachedAnswer, error)
+
+	// Store caches an answer under the scope
+	Store(ctx context.Context, scope *types.AnswerCacheScope, answer *types.CachedAnswer) error
+
+	// Invalidate drops the cached answers of every scope that includes one of the knowledge bases
+	Invalidate(ctx context.Context, knowledgeBaseIDs ...string) error
+}
@@ -47,9 +47,21 @@ type ChunkRepository interface {
 	// ListAllFAQChunksByKnowledgeID lists all FAQ chunks for a knowledge ID
 	// only ID and ContentHash fields for efficiency
 	ListAllFAQChunksByKnowledgeID(ctx context.Context, tenantID uint64, knowledgeID string) ([]*types.Chunk, error)
+	// FindFAQChunkByQuestion returns the ID of the FAQ chunk of a knowledge base whose standard or similar
+	// questions include the question, empty if there is none
+	FindFAQChunkByQuestion(ctx context.Context, tenantID uint64, kbID string, question string) (string, error)
 	// ListAllFAQChunksWithMetadataByKnowledgeBaseID lists all FAQ chunks for a knowledge base ID
 	// returns ID and Metadata fields for duplicate question checking
 	ListAllFAQChunksWithMetadataByKnowledgeBaseID(ctx context.Context, tenantID uint64, kbID string) ([]*types.Chunk, error)
+	// ListChunksByKnowledgeBaseIDAndType lists the chunks of a type in a knowledge base
+	ListChunksByKnowledgeBaseIDAndType(
+		ctx context.Context, tenantID uint64, kbID string, chunkType types.ChunkType,
+	) ([]*types.Chunk, error)
+	// ReplaceChunksByType replaces the chunks of a type in a knowledge base, except those of keepIDs,
+	// with the given chunks in one transaction
+	ReplaceChunksByType(ctx context.Context, tenantID uint64, kbID string,
+		chunkType types.ChunkType, chunks []*types.Chunk, keepIDs []string,
+	) error
 }
 
 // ChunkService defines the interface for chunk service operations
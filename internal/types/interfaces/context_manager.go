@@ -22,6 +22,11 @@ type ContextManager interface {
 	// ClearContext clears all context for a session
 	ClearContext(ctx context.Context, sessionID string) error
 
+	// RebuildContext replaces the context of a session with the given messages,
+	// e.g. the conversation of a branch when the user switches branches
+	// The messages are compressed if they exceed the context window
+	RebuildContext(ctx context.Context, sessionID string, messages []chat.Message) error
+
 	// GetContextStats returns statistics about the context
 	GetContextStats(ctx context.Context, sessionID string) (*ContextStats, error)
 }
@@ -0,0 +1,46 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// EntityResolutionService merges the knowledge graph entities of a knowledge base that refer to the same thing
+type EntityResolutionService interface {
+	// ResolveEntities handles the entity resolution task
+	ResolveEntities(ctx context.Context, t *asynq.Task) error
+	// EnqueueResolution queues the resolution of the entities of the knowledge against the knowledge base, or
+	// of the whole knowledge base if knowledgeID is empty
+	EnqueueResolution(ctx context.Context, kbID string, knowledgeID string) error
+	// ListAliases lists the aliases of the knowledge graph of a knowledge base
+	ListAliases(ctx context.Context, kbID string) ([]*types.GraphEntityAlias, error)
+	// AddAlias adds a manual alias and merges the alias into its canonical entity
+	AddAlias(ctx context.Context, kbID string, req *types.GraphEntityAliasRequest) (*types.GraphEntityAlias, error)
+	// DeleteAlias deletes an alias, the merged nodes are not split again
+	DeleteAlias(ctx context.Context, kbID string, aliasID string) error
+}
+
+// GraphEntityAliasRepository stores the aliases of knowledge graph entities
+type GraphEntityAliasRepository interface {
+	// SaveAliases creates or updates the aliases, manual aliases are only overwritten by manual aliases.
+	// Aliases whose canonical entity became an alias are moved to the new canonical entity.
+	SaveAliases(ctx context.Context, aliases []*types.GraphEntityAlias) error
+	// ListAliases lists the aliases of a knowledge base ordered by canonical entity
+	ListAliases(ctx context.Context, tenantID uint64, kbID string) ([]*types.GraphEntityAlias, error)
+	// GetCanonicalNames returns the canonical entities of the names that are aliases
+	GetCanonicalNames(ctx context.Context, tenantID uint64, kbID string, names []string) (map[string]string, error)
+	// DeleteAlias deletes an alias
+	DeleteAlias(ctx context.Context, tenantID uint64, kbID string, id string) error
+}
+
+// GraphTombstoneRepository stores the knowledge graph entities and relations deleted through the API
+type GraphTombstoneRepository interface {
+	// CreateTombstone records a deleted entity or relation, recording it twice is not an error
+	CreateTombstone(ctx context.Context, tombstone *types.GraphTombstone) error
+	// DeleteTombstones deletes the tombstones of the entities or relations created again
+	DeleteTombstones(ctx context.Context, tenantID uint64, kbID string, tombstones []*types.GraphTombstone) error
+	// ListTombstones lists the tombstones of a knowledge base
+	ListTombstones(ctx context.Context, tenantID uint64, kbID string) ([]*types.GraphTombstone, error)
+}
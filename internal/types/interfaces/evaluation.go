@@ -8,9 +8,9 @@ import (
 
 // EvaluationService defines operations for evaluation tasks
 type EvaluationService interface {
-	// Evaluation starts a new evaluation task
+	// Evaluation starts a new evaluation task, optionally retrieving with HyDE and multi-query
 	Evaluation(ctx context.Context, datasetID string, knowledgeBaseID string,
-		chatModelID string, rerankModelID string,
+		chatModelID string, rerankModelID string, enableHyDE bool, enableMultiQuery bool,
 	) (*types.EvaluationDetail, error)
 	// EvaluationResult retrieves evaluation result by task ID
 	EvaluationResult(ctx context.Context, taskID string) (*types.EvaluationDetail, error)
@@ -32,4 +32,6 @@ type EvalHook interface {
 type DatasetService interface {
 	// GetDatasetByID retrieves QA pairs from dataset by ID
 	GetDatasetByID(ctx context.Context, datasetID string) ([]*types.QAPair, error)
+	// SaveDataset stores QA pairs as a dataset that can be retrieved by its ID
+	SaveDataset(ctx context.Context, datasetID string, pairs []*types.QAPair) error
 }
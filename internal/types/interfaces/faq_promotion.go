@@ -0,0 +1,33 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// FAQPromotionService promotes QA exchanges of chat sessions into FAQ knowledge bases
+type FAQPromotionService interface {
+	// Promote adds the exchange of an assistant message to a FAQ knowledge base as a new entry or as a
+	// similar question of an existing entry and records the promotion
+	Promote(ctx context.Context, kbID string, req *types.FAQPromotionRequest) (*types.FAQPromotionResult, error)
+	// ListPromotions lists the promotions into a FAQ knowledge base, newest first
+	ListPromotions(ctx context.Context, kbID string, page *types.Pagination) (*types.PageResult, error)
+}
+
+// FAQPromotionRepository stores the audit trail of promotions
+type FAQPromotionRepository interface {
+	// CreatePromotion records a promotion, gorm.ErrDuplicatedKey is returned if the message was already
+	// promoted into the knowledge base
+	CreatePromotion(ctx context.Context, promotion *types.FAQPromotion) error
+	// SetPromotionEntry sets the entry of a recorded promotion
+	SetPromotionEntry(ctx context.Context, tenantID uint64, id string, entryID string) error
+	// DeletePromotion deletes a recorded promotion
+	DeletePromotion(ctx context.Context, tenantID uint64, id string) error
+	// GetPromotionByMessage gets the promotion of a message into a knowledge base
+	GetPromotionByMessage(ctx context.Context,
+		tenantID uint64, kbID string, messageID string) (*types.FAQPromotion, error)
+	// ListPromotions lists the promotions into a knowledge base, newest first
+	ListPromotions(ctx context.Context,
+		tenantID uint64, kbID string, page *types.Pagination) ([]*types.FAQPromotion, int64, error)
+}
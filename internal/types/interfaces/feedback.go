@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// FeedbackService records feedback on assistant messages and aggregates it into quality signals of chunks
+type FeedbackService interface {
+	// SubmitFeedback records the rating, correction and useful references of an assistant message,
+	// replacing earlier feedback on the message
+	SubmitFeedback(ctx context.Context, feedback *types.MessageFeedback) (*types.MessageFeedback, error)
+	// GetFeedback gets the feedback on a message
+	GetFeedback(ctx context.Context, sessionID string, messageID string) (*types.MessageFeedback, error)
+	// DeleteFeedback deletes the feedback on a message
+	DeleteFeedback(ctx context.Context, sessionID string, messageID string) error
+	// GetChunkScores returns the feedback score of the chunks with feedback, see types.FeedbackScore
+	GetChunkScores(ctx context.Context, chunkIDs []string) (map[string]float64, error)
+	// GetDashboard aggregates the feedback on a knowledge base, listing at most limit knowledge and chunks
+	GetDashboard(ctx context.Context, knowledgeBaseID string, limit int) (*types.FeedbackDashboard, error)
+	// ExportDataset saves the corrected and upvoted answers referencing a knowledge base as an evaluation dataset
+	ExportDataset(ctx context.Context, knowledgeBaseID string) (*types.FeedbackDatasetExport, error)
+}
+
+// FeedbackRepository stores feedback on messages and the votes on the referenced chunks
+type FeedbackRepository interface {
+	// SaveFeedback creates or replaces the feedback on its message together with its votes
+	SaveFeedback(ctx context.Context, feedback *types.MessageFeedback) error
+	// GetFeedbackByMessage gets the feedback on a message with its votes
+	GetFeedbackByMessage(ctx context.Context, tenantID uint64, messageID string) (*types.MessageFeedback, error)
+	// DeleteFeedbackByMessage deletes the feedback on a message with its votes
+	DeleteFeedbackByMessage(ctx context.Context, tenantID uint64, messageID string) error
+	// ListFeedbackByKnowledgeBase lists the feedback with votes on chunks of a knowledge base
+	ListFeedbackByKnowledgeBase(ctx context.Context,
+		tenantID uint64, knowledgeBaseID string) ([]*types.MessageFeedback, error)
+	// SummarizeKnowledgeBase counts the feedback with votes on chunks of a knowledge base
+	SummarizeKnowledgeBase(ctx context.Context, tenantID uint64, knowledgeBaseID string) (*types.FeedbackSummary, error)
+	// ChunkStats aggregates the votes on the given chunks
+	ChunkStats(ctx context.Context, tenantID uint64, chunkIDs []string) ([]*types.ChunkFeedbackStats, error)
+	// WorstChunks aggregates the votes on chunks of a knowledge base, lowest score first
+	WorstChunks(ctx context.Context,
+		tenantID uint64, knowledgeBaseID string, limit int) ([]*types.ChunkFeedbackStats, error)
+	// WorstKnowledge aggregates the votes on knowledge of a knowledge base, lowest score first
+	WorstKnowledge(ctx context.Context,
+		tenantID uint64, knowledgeBaseID string, limit int) ([]*types.KnowledgeFeedbackStats, error)
+}
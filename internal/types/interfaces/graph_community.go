@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// GraphCommunityService clusters the knowledge graph of a knowledge base into communities summarized by the
+// chat model, the reports the global search answers from
+type GraphCommunityService interface {
+	// DetectCommunities handles the community detection task
+	DetectCommunities(ctx context.Context, t *asynq.Task) error
+	// EnqueueDetection queues the detection of the communities of a knowledge base
+	EnqueueDetection(ctx context.Context, kbID string) error
+	// ListCommunities lists the communities of a knowledge base with their reports
+	ListCommunities(ctx context.Context,
+		kbID string, req *types.GraphCommunityListRequest,
+	) ([]*types.GraphCommunity, error)
+}
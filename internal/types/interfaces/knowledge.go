@@ -86,6 +86,8 @@ type KnowledgeService interface {
 	UpsertFAQEntries(ctx context.Context, kbID string, payload *types.FAQBatchUpsertPayload) (string, error)
 	// CreateFAQEntry creates a single FAQ entry synchronously.
 	CreateFAQEntry(ctx context.Context, kbID string, payload *types.FAQEntryPayload) (*types.FAQEntry, error)
+	// GetFAQEntry gets a single FAQ entry.
+	GetFAQEntry(ctx context.Context, kbID string, entryID string) (*types.FAQEntry, error)
 	// UpdateFAQEntry updates a single FAQ entry.
 	UpdateFAQEntry(ctx context.Context, kbID string, entryID string, payload *types.FAQEntryPayload) error
 	// UpdateFAQEntryStatusBatch updates enable status for FAQ entries in batch.
@@ -108,6 +110,10 @@ type KnowledgeService interface {
 	ProcessQuestionGeneration(ctx context.Context, t *asynq.Task) error
 	// ProcessSummaryGeneration handles Asynq summary generation tasks
 	ProcessSummaryGeneration(ctx context.Context, t *asynq.Task) error
+	// RechunkKnowledgeBase enqueues rechunk tasks for the processed knowledge of a knowledge base
+	RechunkKnowledgeBase(ctx context.Context, kbID string) error
+	// ProcessKnowledgeRechunk handles Asynq knowledge rechunk tasks
+	ProcessKnowledgeRechunk(ctx context.Context, t *asynq.Task) error
 }
 
 // KnowledgeRepository defines the interface for knowledge repositories.
@@ -145,4 +151,8 @@ type KnowledgeRepository interface {
 	CountKnowledgeByKnowledgeBaseID(ctx context.Context, tenantID uint64, kbID string) (int64, error)
 	// CountKnowledgeByStatus counts the number of knowledge items with the specified parse status.
 	CountKnowledgeByStatus(ctx context.Context, tenantID uint64, kbID string, parseStatuses []string) (int64, error)
+	// ListKnowledgeIDsByFilter returns the IDs of knowledge in the knowledge bases that match the metadata filter.
+	ListKnowledgeIDsByFilter(ctx context.Context,
+		tenantID uint64, kbIDs []string, filter *types.MetadataFilter,
+	) ([]string, error)
 }
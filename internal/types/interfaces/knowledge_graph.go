@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeGraphService browses and edits the knowledge graph of a knowledge base. Edits are kept when the
+// documents of the knowledge base are extracted again.
+type KnowledgeGraphService interface {
+	// BrowseGraph returns a page of entities, most connected first, with the relations among them
+	BrowseGraph(ctx context.Context, kbID string, req *types.GraphBrowseRequest) (*types.PageResult, error)
+	// ListEntities returns a page of entities, most connected first
+	ListEntities(ctx context.Context, kbID string, req *types.GraphBrowseRequest) (*types.PageResult, error)
+	// GetEntity returns an entity with its relations and the chunks it was extracted from
+	GetEntity(ctx context.Context, kbID string, name string) (*types.GraphEntityDetail, error)
+	// CreateEntity creates an entity
+	CreateEntity(ctx context.Context, kbID string, req *types.GraphEntityRequest) (*types.GraphNode, error)
+	// UpdateEntity replaces the attributes of an entity and renames it
+	UpdateEntity(ctx context.Context, kbID string, req *types.GraphEntityUpdateRequest) (*types.GraphNode, error)
+	// MergeEntities merges entities into one, the merged names become its aliases
+	MergeEntities(ctx context.Context, kbID string, req *types.GraphEntityMergeRequest) (*types.GraphNode, error)
+	// DeleteEntity deletes an entity and its relations
+	DeleteEntity(ctx context.Context, kbID string, name string) error
+	// CreateRelation creates a relation between two entities
+	CreateRelation(ctx context.Context, kbID string, req *types.GraphRelationRequest) (*types.GraphRelation, error)
+	// DeleteRelation deletes a relation
+	DeleteRelation(ctx context.Context, kbID string, req *types.GraphRelationRequest) error
+}
@@ -31,11 +31,46 @@ type MessageService interface {
 
 	// DeleteMessage deletes a message
 	DeleteMessage(ctx context.Context, sessionID string, id string) error
+
+	// GetMessageTree gets the branch tree of all messages of a session
+	GetMessageTree(ctx context.Context, sessionID string) (*types.MessageTree, error)
+
+	// BranchFrom makes a message the end of the active branch, so the next message starts a new branch
+	// after it, and returns the messages of the branch. An empty ID branches from the start of the session.
+	BranchFrom(ctx context.Context, sessionID string, messageID string) ([]*types.Message, error)
+
+	// SwitchBranch activates the branch through a message, up to the latest answer following it,
+	// and returns the messages of the branch
+	SwitchBranch(ctx context.Context, sessionID string, messageID string) ([]*types.Message, error)
 }
 
 // MessageRepository defines the message repository interface
+// It stores the messages of all branches, following the branches is up to the service
 type MessageRepository interface {
-	MessageService
+	// CreateMessage creates a message
+	CreateMessage(ctx context.Context, message *types.Message) (*types.Message, error)
+	// GetMessage gets a message
+	GetMessage(ctx context.Context, sessionID string, id string) (*types.Message, error)
+	// GetMessagesBySession gets all messages of a session
+	GetMessagesBySession(ctx context.Context, sessionID string, page int, pageSize int) ([]*types.Message, error)
+	// GetRecentMessagesBySession gets recent messages of a session regardless of their branch
+	GetRecentMessagesBySession(ctx context.Context, sessionID string, limit int) ([]*types.Message, error)
+	// GetMessagesBySessionBeforeTime gets messages before a specific time of a session regardless of their branch
+	GetMessagesBySessionBeforeTime(
+		ctx context.Context, sessionID string, beforeTime time.Time, limit int,
+	) ([]*types.Message, error)
+	// UpdateMessage updates a message
+	UpdateMessage(ctx context.Context, message *types.Message) error
+	// DeleteMessage deletes a message
+	DeleteMessage(ctx context.Context, sessionID string, id string) error
 	// GetFirstMessageOfUser gets the first message of a user
 	GetFirstMessageOfUser(ctx context.Context, sessionID string) (*types.Message, error)
+	// GetMessageLinksBySession gets the ID, parent, role and creation time of all messages of a session
+	GetMessageLinksBySession(ctx context.Context, sessionID string) ([]*types.Message, error)
+	// GetAllMessagesBySession gets all messages of a session across all branches
+	GetAllMessagesBySession(ctx context.Context, sessionID string) ([]*types.Message, error)
+	// GetMessagesByIDs gets the messages of a session with the given IDs
+	GetMessagesByIDs(ctx context.Context, sessionID string, messageIDs []string) ([]*types.Message, error)
+	// ReparentMessages moves the messages following a message to follow another one
+	ReparentMessages(ctx context.Context, sessionID string, parentID string, newParentID string) error
 }
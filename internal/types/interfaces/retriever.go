@@ -19,6 +19,13 @@ type RetrieveEngine interface {
 	Support() []types.RetrieverType
 }
 
+// MetadataFilterRetrieveEngine is implemented by retrieve engines that evaluate RetrieveParams.Filter in their
+// own query, other engines only receive the matching RetrieveParams.KnowledgeIDs
+type MetadataFilterRetrieveEngine interface {
+	// SupportMetadataFilter reports whether the engine evaluates metadata filters
+	SupportMetadataFilter() bool
+}
+
 // RetrieveEngineRepository defines the retrieve engine repository interface
 type RetrieveEngineRepository interface {
 	// Save saves the index info
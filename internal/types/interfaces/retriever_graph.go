@@ -14,4 +14,35 @@ type RetrieveGraphRepository interface {
 	DelGraph(ctx context.Context, namespace []types.NameSpace) error
 	// SearchNode searches for nodes in the repository
 	SearchNode(ctx context.Context, namespace types.NameSpace, nodes []string) (*types.GraphData, error)
+	// ExpandNodes returns the neighbourhood of the query nodes up to the query hops, with node degrees
+	ExpandNodes(ctx context.Context, namespace types.NameSpace, query *types.GraphQuery) (*types.GraphData, error)
+	// FindPath returns the nodes and relations of the shortest path between the query entities in path order,
+	// or an empty graph if they are not connected
+	FindPath(ctx context.Context, namespace types.NameSpace, query *types.GraphPathQuery) (*types.GraphData, error)
+	// ListNodes returns the nodes of the namespace, nodes of the same name in different knowledge are merged
+	ListNodes(ctx context.Context, namespace types.NameSpace) ([]*types.GraphNode, error)
+	// RankNodes returns a page of the nodes of the namespace whose name contains the keyword, ignoring case, most
+	// connected first and then by name, with their degrees and the number of matching nodes
+	RankNodes(ctx context.Context,
+		namespace types.NameSpace, keyword string, page *types.Pagination) ([]*types.GraphNode, int64, error)
+	// GetNode returns the named node of the namespace, nodes of the same name in different knowledge are merged,
+	// nil if there is none
+	GetNode(ctx context.Context, namespace types.NameSpace, name string) (*types.GraphNode, error)
+	// RenameNodes renames the nodes of the namespace from the keys to the values of renames. A renamed node is
+	// merged into the node of the new name of the same knowledge with the union of their chunks and
+	// attributes, its relations are moved and relations that become loops are dropped.
+	RenameNodes(ctx context.Context, namespace types.NameSpace, renames map[string]string) error
+	// ListRelations returns the relations of the namespace, relations of different knowledge are merged
+	ListRelations(ctx context.Context, namespace types.NameSpace) ([]*types.GraphRelation, error)
+	// ListNodeRelations returns the relations of the namespace from or to one of the named nodes
+	ListNodeRelations(ctx context.Context, namespace types.NameSpace, names []string) ([]*types.GraphRelation, error)
+	// ListRelationsAmong returns the relations of the namespace between the named nodes
+	ListRelationsAmong(ctx context.Context,
+		namespace types.NameSpace, names []string) ([]*types.GraphRelation, error)
+	// DeleteNodes deletes the named nodes of the namespace and their relations
+	DeleteNodes(ctx context.Context, namespace types.NameSpace, names []string) error
+	// DeleteRelations deletes the relations of the namespace
+	DeleteRelations(ctx context.Context, namespace types.NameSpace, relations []*types.GraphRelation) error
+	// SetNodeAttributes creates the node of the namespace or replaces its attributes
+	SetNodeAttributes(ctx context.Context, namespace types.NameSpace, name string, attributes []string) error
 }
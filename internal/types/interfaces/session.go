@@ -30,26 +30,44 @@ type SessionService interface {
 	// knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 	// summaryModelID: optional summary model ID override (if empty, uses session/KB default)
 	// webSearchEnabled: whether to enable web search to supplement knowledge base results
+	// filter: optional metadata filter restricting retrieval to matching knowledge
+	// responseSchema: optional JSON schema the answer must conform to
 	// Events are emitted through eventBus (references, answer chunks, completion)
 	KnowledgeQA(ctx context.Context,
 		session *types.Session, query string, knowledgeBaseIDs []string,
-		assistantMessageID string, summaryModelID string, webSearchEnabled bool, eventBus *event.EventBus,
+		assistantMessageID string, summaryModelID string, webSearchEnabled bool, filter *types.MetadataFilter,
+		responseSchema *types.ResponseSchema, eventBus *event.EventBus,
 	) error
 	// KnowledgeQAByEvent performs knowledge-based question answering by event
 	KnowledgeQAByEvent(ctx context.Context, chatManage *types.ChatManage, eventList []types.EventType) error
 	// SearchKnowledge performs knowledge-based search, without summarization
-	SearchKnowledge(ctx context.Context, knowledgeBaseID, query string) ([]*types.SearchResult, error)
+	// filter: optional metadata filter restricting the search to matching knowledge
+	SearchKnowledge(ctx context.Context,
+		knowledgeBaseID, query string, filter *types.MetadataFilter,
+	) ([]*types.SearchResult, error)
 	// AgentQA performs agent-based question answering with conversation history and streaming support
+	// responseSchema: optional JSON schema the final answer is additionally returned in
 	// eventBus is optional - if nil, uses service's default EventBus
 	AgentQA(
 		ctx context.Context,
 		session *types.Session,
 		query string,
 		assistantMessageID string,
+		responseSchema *types.ResponseSchema,
+		eventBus *event.EventBus,
+	) error
+	// ResumeAgentRun continues an interrupted agent run of the session from its last checkpoint,
+	// the run must have been claimed with AgentRunService.ResumeRun
+	ResumeAgentRun(
+		ctx context.Context,
+		session *types.Session,
+		run *types.AgentRun,
 		eventBus *event.EventBus,
 	) error
 	// ClearContext clears the LLM context for a session
 	ClearContext(ctx context.Context, sessionID string) error
+	// RebuildContext replaces the LLM context of a session with the messages of a branch
+	RebuildContext(ctx context.Context, session *types.Session, messages []*types.Message) error
 	// GetWebSearchTempKBState retrieves the temporary KB state for web search from Redis
 	GetWebSearchTempKBState(
 		ctx context.Context,
@@ -80,6 +98,8 @@ type SessionRepository interface {
 	GetPagedByTenantID(ctx context.Context, tenantID uint64, page *types.Pagination) ([]*types.Session, int64, error)
 	// Update updates a session
 	Update(ctx context.Context, session *types.Session) error
+	// UpdateActiveMessage sets the last message of the active branch of a session
+	UpdateActiveMessage(ctx context.Context, tenantID uint64, id string, messageID string) error
 	// Delete deletes a session
 	Delete(ctx context.Context, tenantID uint64, id string) error
 }
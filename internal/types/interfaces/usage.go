@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// UsageService records model calls in the usage ledger and reports usage per tenant
+type UsageService interface {
+	// Record adds a model call to the usage ledger, the tenant and usage scope are taken from the context.
+	// Failures are logged, model calls are never failed by accounting.
+	Record(ctx context.Context, record *types.UsageRecord)
+	// GetUsage aggregates the usage ledger of the tenant
+	GetUsage(ctx context.Context, query *types.UsageQuery) (*types.UsageReport, error)
+	// GetTokenQuotaStatus returns the token usage of the tenant in the current month
+	GetTokenQuotaStatus(ctx context.Context) (*types.TokenQuotaStatus, error)
+	// CheckTokenQuota returns a TokenQuotaExceededError if the tenant used up its monthly token quota
+	CheckTokenQuota(ctx context.Context) error
+}
+
+// UsageRepository stores the usage ledger
+type UsageRepository interface {
+	// CreateRecord adds a record to the usage ledger
+	CreateRecord(ctx context.Context, record *types.UsageRecord) error
+	// Summarize aggregates the usage ledger of the tenant
+	Summarize(ctx context.Context, tenantID uint64, query *types.UsageQuery) ([]*types.UsageSummary, error)
+	// SumTokens returns the total tokens used by the tenant in [start, end)
+	SumTokens(ctx context.Context, tenantID uint64, start, end time.Time) (int64, error)
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -67,6 +68,10 @@ type KnowledgeBase struct {
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"              gorm:"column:faq_config;type:json"`
 	// QuestionGenerationConfig stores question generation configuration for document knowledge bases
 	QuestionGenerationConfig *QuestionGenerationConfig `yaml:"question_generation_config" json:"question_generation_config" gorm:"column:question_generation_config;type:json"`
+	// FusionConfig controls how results of multiple retrievers are fused in hybrid search
+	FusionConfig *FusionConfig `yaml:"fusion_config"           json:"fusion_config"           gorm:"column:fusion_config;type:json"`
+	// ContextExpansionConfig controls how retrieved chunks are expanded to their surrounding context
+	ContextExpansionConfig *ContextExpansionConfig `yaml:"context_expansion_config" json:"context_expansion_config" gorm:"column:context_expansion_config;type:json"`
 	// Creation time of the knowledge base
 	CreatedAt time.Time `yaml:"created_at"              json:"created_at"`
 	// Last updated time of the knowledge base
@@ -91,6 +96,10 @@ type KnowledgeBaseConfig struct {
 	ImageProcessingConfig ImageProcessingConfig `yaml:"image_processing_config" json:"image_processing_config"`
 	// FAQ configuration (only for FAQ type knowledge bases)
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"`
+	// Fusion configuration for hybrid search
+	FusionConfig *FusionConfig `yaml:"fusion_config"           json:"fusion_config"`
+	// Context expansion configuration for retrieved chunks
+	ContextExpansionConfig *ContextExpansionConfig `yaml:"context_expansion_config" json:"context_expansion_config"`
 }
 
 // ChunkingConfig represents the document splitting configuration
@@ -103,6 +112,81 @@ type ChunkingConfig struct {
 	Separators []string `yaml:"separators"    json:"separators"`
 	// EnableMultimodal (deprecated, kept for backward compatibility with old data)
 	EnableMultimodal bool `yaml:"enable_multimodal,omitempty" json:"enable_multimodal,omitempty"`
+	// Mode is the chunking mode, flat by default
+	Mode ChunkingMode `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// ParentChunkSize is the size of parent sections in hierarchical mode,
+	// which are split into child chunks of ChunkSize
+	ParentChunkSize int `yaml:"parent_chunk_size,omitempty" json:"parent_chunk_size,omitempty"`
+}
+
+// ChunkingMode represents how documents are split into chunks
+type ChunkingMode string
+
+// ChunkingMode constants
+const (
+	// ChunkingModeFlat splits documents into chunks that are embedded and returned by retrieval
+	ChunkingModeFlat ChunkingMode = "flat"
+	// ChunkingModeHierarchical splits documents into parent sections and their child chunks,
+	// only children are embedded and retrieval returns the parent text
+	ChunkingModeHierarchical ChunkingMode = "hierarchical"
+)
+
+// DefaultChildChunkSize is the child chunk size used in hierarchical mode when no chunk size is configured
+const DefaultChildChunkSize = 512
+
+// IsHierarchical reports whether documents are split into parent sections and child chunks
+func (c ChunkingConfig) IsHierarchical() bool {
+	return c.Mode == ChunkingModeHierarchical
+}
+
+// GetChildChunkSize returns the size of child chunks in hierarchical mode
+func (c ChunkingConfig) GetChildChunkSize() int {
+	if c.ChunkSize <= 0 {
+		return DefaultChildChunkSize
+	}
+	return c.ChunkSize
+}
+
+// GetParentChunkSize returns the size of parent sections in hierarchical mode, defaulting to 4 child chunks
+func (c ChunkingConfig) GetParentChunkSize() int {
+	if c.ParentChunkSize <= 0 {
+		return 4 * c.GetChildChunkSize()
+	}
+	return c.ParentChunkSize
+}
+
+// ReadChunkSize returns the chunk size requested from the document reader,
+// which splits documents into parent sections in hierarchical mode
+func (c ChunkingConfig) ReadChunkSize() int {
+	if c.IsHierarchical() {
+		return c.GetParentChunkSize()
+	}
+	return c.ChunkSize
+}
+
+// ReadChunkOverlap returns the chunk overlap requested from the document reader,
+// parent sections do not overlap
+func (c ChunkingConfig) ReadChunkOverlap() int {
+	if c.IsHierarchical() {
+		return 0
+	}
+	return c.ChunkOverlap
+}
+
+// Validate checks that the chunking configuration is well formed
+func (c ChunkingConfig) Validate() error {
+	switch c.Mode {
+	case "", ChunkingModeFlat, ChunkingModeHierarchical:
+	default:
+		return fmt.Errorf("invalid chunking mode: %s", c.Mode)
+	}
+	if c.ChunkSize < 0 || c.ChunkOverlap < 0 || c.ParentChunkSize < 0 {
+		return fmt.Errorf("chunk sizes must not be negative")
+	}
+	if c.IsHierarchical() && c.GetParentChunkSize() <= c.GetChildChunkSize() {
+		return fmt.Errorf("parent_chunk_size must be larger than chunk_size")
+	}
+	return nil
 }
 
 // COSConfig represents the COS configuration
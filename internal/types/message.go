@@ -28,6 +28,8 @@ type Message struct {
 	ID string `json:"id"                    gorm:"type:varchar(36);primaryKey"`
 	// ID of the session this message belongs to
 	SessionID string `json:"session_id"`
+	// ID of the message this message follows in its branch, empty for the first message of the session
+	ParentID string `json:"parent_id"             gorm:"type:varchar(36);index"`
 	// Request identifier for tracking API requests
 	RequestID string `json:"request_id"`
 	// Message text content
@@ -40,6 +42,12 @@ type Message struct {
 	// This contains the detailed reasoning process and tool calls made by the agent
 	// Stored for user history display, but NOT included in LLM context to avoid redundancy
 	AgentSteps AgentSteps `json:"agent_steps,omitempty" gorm:"type:jsonb,column:agent_steps"`
+	// Answer object conforming to the response schema of the request (only when one was requested)
+	StructuredOutput StructuredOutput `json:"structured_output,omitempty" gorm:"type:jsonb"`
+	// Inline citation markers of the answer mapped to the cited chunks, with unsupported sentences flagged
+	CitationMap *CitationMap `json:"citation_map,omitempty" gorm:"type:jsonb"`
+	// Groundedness of the answer against the knowledge references, only when the check is enabled
+	Groundedness *GroundednessResult `json:"groundedness,omitempty" gorm:"type:jsonb"`
 	// Whether message generation is complete
 	IsCompleted bool `json:"is_completed"`
 	// Message creation timestamp
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"slices"
+)
+
+// MessageTreeNode is a message of a session with the messages following it, each child starts a branch.
+// Regenerated answers are siblings of the original answer, edited questions siblings of the original question.
+type MessageTreeNode struct {
+	Message *Message `json:"message"`
+	// Whether the message is on the active branch of the session
+	Active   bool               `json:"active"`
+	Children []*MessageTreeNode `json:"children"`
+}
+
+// MessageTree is the branch tree of the messages of a session
+type MessageTree struct {
+	// Last message of the active branch
+	ActiveMessageID string             `json:"active_message_id"`
+	Roots           []*MessageTreeNode `json:"roots"`
+}
+
+// MessagePath returns the messages from the start of the session to the given message by following
+// their parents, empty if the message is not found
+func MessagePath(messages []*Message, messageID string) []*Message {
+	byID := make(map[string]*Message, len(messages))
+	for _, message := range messages {
+		byID[message.ID] = message
+	}
+	var path []*Message
+	visited := make(map[string]bool)
+	for id := messageID; id != "" && !visited[id]; {
+		message, ok := byID[id]
+		if !ok {
+			break
+		}
+		visited[id] = true
+		path = append(path, message)
+		id = message.ParentID
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// BranchLeaf returns the ID of the last message of the branch through the given message, following
+// the most recent child at each fork, i.e. the latest regenerated answer or edited question
+func BranchLeaf(messages []*Message, messageID string) string {
+	latest := make(map[string]*Message)
+	for _, message := range messages {
+		if current, ok := latest[message.ParentID]; !ok || message.CreatedAt.After(current.CreatedAt) {
+			latest[message.ParentID] = message
+		}
+	}
+	visited := make(map[string]bool)
+	for !visited[messageID] {
+		visited[messageID] = true
+		child, ok := latest[messageID]
+		if !ok {
+			break
+		}
+		messageID = child.ID
+	}
+	return messageID
+}
+
+// BuildMessageTree arranges the messages of a session by their parents, children are ordered by creation time.
+// Messages whose parent is missing, e.g. deleted, become roots.
+func BuildMessageTree(messages []*Message, activeMessageID string) *MessageTree {
+	tree := &MessageTree{ActiveMessageID: activeMessageID, Roots: []*MessageTreeNode{}}
+	active := make(map[string]bool)
+	for _, message := range MessagePath(messages, activeMessageID) {
+		active[message.ID] = true
+	}
+	sorted := slices.Clone(messages)
+	slices.SortStableFunc(sorted, func(a, b *Message) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	nodes := make(map[string]*MessageTreeNode, len(sorted))
+	for _, message := range sorted {
+		nodes[message.ID] = &MessageTreeNode{Message: message, Active: active[message.ID], Children: []*MessageTreeNode{}}
+	}
+	for _, message := range sorted {
+		node := nodes[message.ID]
+		if parent, ok := nodes[message.ParentID]; ok && message.ParentID != message.ID {
+			parent.Children = append(parent.Children, node)
+		} else {
+			tree.Roots = append(tree.Roots, node)
+		}
+	}
+	return tree
+}
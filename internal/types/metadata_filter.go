@@ -0,0 +1,201 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MetadataFilterOp represents the operator of a metadata filter expression
+type MetadataFilterOp string
+
+// MetadataFilterOp constants
+const (
+	// MetadataFilterOpEq matches when the field equals Value
+	MetadataFilterOpEq MetadataFilterOp = "eq"
+	// MetadataFilterOpIn matches when the field equals any of Values
+	MetadataFilterOpIn MetadataFilterOp = "in"
+	// MetadataFilterOpRange matches when the field lies within the Gt/Gte/Lt/Lte bounds
+	MetadataFilterOpRange MetadataFilterOp = "range"
+	// MetadataFilterOpAnd matches when all sub filters match
+	MetadataFilterOpAnd MetadataFilterOp = "and"
+	// MetadataFilterOpOr matches when any sub filter matches
+	MetadataFilterOpOr MetadataFilterOp = "or"
+	// MetadataFilterOpNot matches when its single sub filter does not match
+	MetadataFilterOpNot MetadataFilterOp = "not"
+)
+
+// Filterable knowledge fields, custom metadata keys are addressed as "metadata.<key>"
+const (
+	MetadataFilterFieldTagID     = "tag_id"
+	MetadataFilterFieldFileType  = "file_type"
+	MetadataFilterFieldCreatedAt = "created_at"
+	MetadataFilterFieldPrefix    = "metadata."
+)
+
+// maxMetadataFilterDepth limits the nesting of filter expressions
+const maxMetadataFilterDepth = 8
+
+// MetadataFilter is a filter expression over knowledge attributes used to narrow retrieval.
+// Leaf expressions (eq, in, range) compare a field, while and/or/not combine sub filters, e.g.
+//
+//	{"op": "and", "filters": [
+//	    {"op": "eq", "field": "file_type", "value": "pdf"},
+//	    {"op": "range", "field": "created_at", "gte": "2024-01-01"}
+//	]}
+type MetadataFilter struct {
+	// Op is the operator of the expression
+	Op MetadataFilterOp `json:"op"`
+	// Field is the knowledge field compared by eq, in and range
+	Field string `json:"field,omitempty"`
+	// Value is the operand of eq
+	Value interface{} `json:"value,omitempty"`
+	// Values are the operands of in
+	Values []interface{} `json:"values,omitempty"`
+	// Gt, Gte, Lt and Lte are the bounds of range, at least one is required
+	Gt  interface{} `json:"gt,omitempty"`
+	Gte interface{} `json:"gte,omitempty"`
+	Lt  interface{} `json:"lt,omitempty"`
+	Lte interface{} `json:"lte,omitempty"`
+	// Filters are the sub filters of and, or and not (exactly one for not)
+	Filters []*MetadataFilter `json:"filters,omitempty"`
+}
+
+// Validate checks that the filter expression is well formed
+func (f *MetadataFilter) Validate() error {
+	if f == nil {
+		return nil
+	}
+	return f.validate(1)
+}
+
+func (f *MetadataFilter) validate(depth int) error {
+	if f == nil {
+		return fmt.Errorf("filter expression must not be empty")
+	}
+	if depth > maxMetadataFilterDepth {
+		return fmt.Errorf("filter nesting exceeds %d levels", maxMetadataFilterDepth)
+	}
+	switch f.Op {
+	case MetadataFilterOpAnd, MetadataFilterOpOr:
+		if len(f.Filters) == 0 {
+			return fmt.Errorf("%s filter requires at least one sub filter", f.Op)
+		}
+	case MetadataFilterOpNot:
+		if len(f.Filters) != 1 {
+			return fmt.Errorf("not filter requires exactly one sub filter")
+		}
+	case MetadataFilterOpEq:
+		if err := validateMetadataFilterField(f.Field); err != nil {
+			return err
+		}
+		return validateMetadataFilterValue(f.Field, f.Value)
+	case MetadataFilterOpIn:
+		if err := validateMetadataFilterField(f.Field); err != nil {
+			return err
+		}
+		if len(f.Values) == 0 {
+			return fmt.Errorf("in filter on %s requires at least one value", f.Field)
+		}
+		for _, value := range f.Values {
+			if err := validateMetadataFilterValue(f.Field, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case MetadataFilterOpRange:
+		if err := validateMetadataFilterField(f.Field); err != nil {
+			return err
+		}
+		if f.Field == MetadataFilterFieldTagID {
+			return fmt.Errorf("range filter is not supported on %s", f.Field)
+		}
+		bounds := f.RangeBounds()
+		if len(bounds) == 0 {
+			return fmt.Errorf("range filter on %s requires at least one bound", f.Field)
+		}
+		for _, bound := range bounds {
+			if err := validateMetadataFilterValue(f.Field, bound.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid filter op: %s", f.Op)
+	}
+	for _, sub := range f.Filters {
+		if err := sub.validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MetadataFilterBound is a single bound of a range filter
+type MetadataFilterBound struct {
+	// Operator is the SQL style comparison operator: >, >=, < or <=
+	Operator string
+	// Value is the bound value
+	Value interface{}
+}
+
+// RangeBounds returns the bounds set on a range filter
+func (f *MetadataFilter) RangeBounds() []MetadataFilterBound {
+	var bounds []MetadataFilterBound
+	for _, b := range []MetadataFilterBound{
+		{Operator: ">", Value: f.Gt}, {Operator: ">=", Value: f.Gte},
+		{Operator: "<", Value: f.Lt}, {Operator: "<=", Value: f.Lte},
+	} {
+		if b.Value != nil {
+			bounds = append(bounds, b)
+		}
+	}
+	return bounds
+}
+
+// MetadataKey returns the custom metadata key addressed by field, if any
+func MetadataKey(field string) (string, bool) {
+	if !strings.HasPrefix(field, MetadataFilterFieldPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(field, MetadataFilterFieldPrefix), true
+}
+
+// ParseMetadataFilterTime parses a created_at filter value, accepting RFC3339 timestamps or dates
+func ParseMetadataFilterTime(value interface{}) (time.Time, error) {
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("created_at value must be a string, got %T", value)
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid created_at value %q, expected RFC3339 or YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+func validateMetadataFilterField(field string) error {
+	switch field {
+	case MetadataFilterFieldTagID, MetadataFilterFieldFileType, MetadataFilterFieldCreatedAt:
+		return nil
+	}
+	if key, ok := MetadataKey(field); ok && key != "" {
+		return nil
+	}
+	return fmt.Errorf("invalid filter field: %q", field)
+}
+
+func validateMetadataFilterValue(field string, value interface{}) error {
+	if field == MetadataFilterFieldCreatedAt {
+		_, err := ParseMetadataFilterTime(value)
+		return err
+	}
+	switch value.(type) {
+	case string, bool, float64, float32, int, int64:
+		return nil
+	}
+	return fmt.Errorf("invalid value for filter field %s: %v", field, value)
+}
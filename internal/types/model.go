@@ -49,6 +49,10 @@ type ModelParameters struct {
 	InterfaceType       string              `yaml:"interface_type"       json:"interface_type"`
 	EmbeddingParameters EmbeddingParameters `yaml:"embedding_parameters" json:"embedding_parameters"`
 	ParameterSize       string              `yaml:"parameter_size"       json:"parameter_size"` // Ollama model parameter size (e.g., "7B", "13B", "70B")
+	// Whether the remote API accepts JSON schema response formats (response_format json_schema)
+	SupportsStructuredOutput bool `yaml:"supports_structured_output" json:"supports_structured_output"`
+	// Whether the remote API reports token usage of streamed responses (stream_options include_usage)
+	SupportsStreamUsage bool `yaml:"supports_stream_usage" json:"supports_stream_usage"`
 }
 
 // Model represents the AI model
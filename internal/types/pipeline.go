@@ -0,0 +1,186 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// DefaultPipelineName is the pipeline used by knowledge QA when none is selected
+const DefaultPipelineName = "rag_stream"
+
+// PipelineCondition decides whether a stage runs for a request, all set fields must hold
+type PipelineCondition struct {
+	// KnowledgeBaseTypes runs the stage only when any searched knowledge base has one of these types
+	KnowledgeBaseTypes []string `yaml:"knowledge_base_types"      json:"knowledge_base_types,omitempty"`
+	// SkipKnowledgeBaseTypes skips the stage when every searched knowledge base has one of these types
+	SkipKnowledgeBaseTypes []string `yaml:"skip_knowledge_base_types" json:"skip_knowledge_base_types,omitempty"`
+	// WebSearchEnabled runs the stage only when web search is enabled (true) or disabled (false)
+	WebSearchEnabled *bool `yaml:"web_search_enabled"        json:"web_search_enabled,omitempty"`
+}
+
+// Matches reports whether the condition holds for the chat request, a nil condition always holds
+func (c *PipelineCondition) Matches(chatManage *ChatManage) bool {
+	if c == nil {
+		return true
+	}
+	if len(c.KnowledgeBaseTypes) > 0 && !slices.ContainsFunc(chatManage.KnowledgeBaseTypes, func(t string) bool {
+		return slices.Contains(c.KnowledgeBaseTypes, t)
+	}) {
+		return false
+	}
+	if len(c.SkipKnowledgeBaseTypes) > 0 && len(chatManage.KnowledgeBaseTypes) > 0 &&
+		!slices.ContainsFunc(chatManage.KnowledgeBaseTypes, func(t string) bool {
+			return !slices.Contains(c.SkipKnowledgeBaseTypes, t)
+		}) {
+		return false
+	}
+	if c.WebSearchEnabled != nil && *c.WebSearchEnabled != chatManage.WebSearchEnabled {
+		return false
+	}
+	return true
+}
+
+// PipelineStage is a single stage of a chat pipeline
+type PipelineStage struct {
+	// Event is the event triggered on the event manager
+	Event EventType `yaml:"event"    json:"event"`
+	// Optional stages log their failures and let the pipeline continue
+	Optional bool `yaml:"optional" json:"optional,omitempty"`
+	// When makes the stage conditional
+	When *PipelineCondition `yaml:"when"     json:"when,omitempty"`
+	// Params override tuning settings (by their JSON name, e.g. rerank_top_k) while the stage runs
+	Params map[string]interface{} `yaml:"params"   json:"params,omitempty"`
+}
+
+// PipelineDefinition is a named, ordered list of chat pipeline stages
+type PipelineDefinition struct {
+	// Name identifies the pipeline, definitions override built-in pipelines of the same name
+	Name string `yaml:"name"        json:"name"`
+	// Description of the pipeline
+	Description string `yaml:"description" json:"description,omitempty"`
+	// Stages run in order
+	Stages []PipelineStage `yaml:"stages"      json:"stages"`
+}
+
+// Validate checks that the pipeline definition is well formed.
+// Whether each stage has a registered plugin is checked by the event manager.
+func (p *PipelineDefinition) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("pipeline name is required")
+	}
+	if len(p.Stages) == 0 {
+		return fmt.Errorf("pipeline %s has no stages", p.Name)
+	}
+	for i, stage := range p.Stages {
+		if stage.Event == "" {
+			return fmt.Errorf("stage %d of pipeline %s has no event", i, p.Name)
+		}
+		if _, err := (&ChatManage{}).ApplyParams(stage.Params); err != nil {
+			return fmt.Errorf("invalid params of stage %s in pipeline %s: %w", stage.Event, p.Name, err)
+		}
+	}
+	return nil
+}
+
+// NeedsKnowledgeBaseTypes reports whether any stage condition depends on knowledge base types
+func (p *PipelineDefinition) NeedsKnowledgeBaseTypes() bool {
+	for _, stage := range p.Stages {
+		if stage.When != nil && (len(stage.When.KnowledgeBaseTypes) > 0 || len(stage.When.SkipKnowledgeBaseTypes) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPipelineDefinition builds an unconditional pipeline from a list of events
+func NewPipelineDefinition(name string, events []EventType) *PipelineDefinition {
+	stages := make([]PipelineStage, len(events))
+	for i, event := range events {
+		stages[i] = PipelineStage{Event: event}
+	}
+	return &PipelineDefinition{Name: name, Stages: stages}
+}
+
+// BuiltinPipeline returns the built-in pipeline of the given name from Pipline
+func BuiltinPipeline(name string) (*PipelineDefinition, bool) {
+	events, ok := Pipline[name]
+	if !ok {
+		return nil, false
+	}
+	return NewPipelineDefinition(name, events), true
+}
+
+// ApplyParams overrides chat settings with params keyed by their JSON names, only pipelineStageParams
+// are accepted. The returned function restores the overridden settings.
+func (c *ChatManage) ApplyParams(params map[string]interface{}) (func(), error) {
+	if len(params) == 0 {
+		return func() {}, nil
+	}
+	value := reflect.ValueOf(c).Elem()
+	saved := make(map[int]reflect.Value, len(params))
+	restore := func() {
+		for i, v := range saved {
+			value.Field(i).Set(v)
+		}
+	}
+	for key, param := range params {
+		index, ok := chatManageParams[key]
+		if !ok {
+			restore()
+			return nil, fmt.Errorf("param %q can not be set by a stage", key)
+		}
+		field := value.Field(index)
+		if _, ok := saved[index]; !ok {
+			original := reflect.New(field.Type()).Elem()
+			original.Set(field)
+			saved[index] = original
+		}
+		// Decode into a copy so nested structs are merged but shared pointers, maps and slices are never mutated
+		target := reflect.New(field.Type())
+		if kind := field.Kind(); kind != reflect.Pointer && kind != reflect.Map && kind != reflect.Slice {
+			target.Elem().Set(field)
+		}
+		data, err := json.Marshal(param)
+		if err == nil {
+			err = json.Unmarshal(data, target.Interface())
+		}
+		if err != nil {
+			restore()
+			return nil, fmt.Errorf("invalid value of param %q: %w", key, err)
+		}
+		field.Set(target.Elem())
+	}
+	return restore, nil
+}
+
+// pipelineStageParams are the chat settings a stage may override, tuning knobs only. Request scope (knowledge
+// bases, session, query, history, filter), models and the outputs written by stages cannot be overridden,
+// the latter would be reverted when the stage ends.
+var pipelineStageParams = []string{
+	"vector_threshold", "keyword_threshold", "embedding_top_k",
+	"rerank_top_k", "rerank_threshold",
+	"fusion_config", "feedback_rerank", "max_rounds",
+	"summary_config", "fallback_strategy", "fallback_response", "fallback_prompt",
+	"enable_rewrite", "enable_query_expansion", "rewrite_prompt_system", "rewrite_prompt_user",
+	"enable_hyde", "enable_multi_query", "multi_query_count",
+	"enable_answer_cache", "answer_cache_threshold",
+	"groundedness",
+}
+
+// chatManageParams maps the JSON names of pipelineStageParams to their ChatManage field index
+var chatManageParams = chatManageParamFields()
+
+func chatManageParamFields() map[string]int {
+	fields := make(map[string]int, len(pipelineStageParams))
+	t := reflect.TypeOf(ChatManage{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if slices.Contains(pipelineStageParams, name) {
+			fields[name] = i
+		}
+	}
+	return fields
+}
@@ -10,6 +10,7 @@ const (
 	InfinityRetrieverEngineType      RetrieverEngineType = "infinity"
 	ElasticFaissRetrieverEngineType  RetrieverEngineType = "elasticfaiss"
 	QdrantRetrieverEngineType        RetrieverEngineType = "qdrant"
+	EmbeddedRetrieverEngineType      RetrieverEngineType = "embedded"
 )
 
 // RetrieverType represents the type of retriever
@@ -34,6 +35,11 @@ type RetrieveParams struct {
 	ExcludeKnowledgeIDs []string
 	// Excluded chunk IDs
 	ExcludeChunkIDs []string
+	// Metadata filter over knowledge attributes, evaluated by engines that support metadata filters
+	Filter *MetadataFilter
+	// Knowledge IDs the results are restricted to, the metadata filter is resolved to batches of them
+	// for engines that do not store knowledge attributes; ignored when empty
+	KnowledgeIDs []string
 	// Number of results to return
 	TopK int
 	// Similarity threshold
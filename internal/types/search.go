@@ -59,6 +59,13 @@ type SearchParams struct {
 	MatchCount           int     `json:"match_count"`
 	DisableKeywordsMatch bool    `json:"disable_keywords_match"`
 	DisableVectorMatch   bool    `json:"disable_vector_match"`
+	// Fusion overrides the knowledge base's fusion config when set
+	Fusion *FusionConfig `json:"fusion,omitempty"`
+	// Filter restricts the search to knowledge matching the metadata filter
+	Filter *MetadataFilter `json:"filter,omitempty"`
+	// VectorQueryText is embedded for vector retrieval in place of QueryText when set,
+	// e.g. a hypothetical answer generated by HyDE
+	VectorQueryText string `json:"vector_query_text,omitempty"`
 }
 
 // Value implements the driver.Valuer interface, used to convert SearchResult to database value
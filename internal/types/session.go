@@ -96,6 +96,10 @@ type Session struct {
 	SummaryParameters *SummaryConfig      `json:"summary_parameters" gorm:"type:json"`  // 总结模型参数
 	AgentConfig       *SessionAgentConfig `json:"agent_config"       gorm:"type:jsonb"` // Agent 配置（会话级别，仅存储enabled和knowledge_bases）
 	ContextConfig     *ContextConfig      `json:"context_config"     gorm:"type:jsonb"` // 上下文管理配置（可选）
+	Pipeline          string              `json:"pipeline"`                             // 问答流水线名称（可选，默认使用租户或系统配置）
+
+	// Last message of the active branch, messages are appended after it and loaded along its parents
+	ActiveMessageID string `json:"active_message_id" gorm:"type:varchar(36)"`
 
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -0,0 +1,215 @@
+package types
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	// DefaultResponseSchemaName is the schema name sent to models when the request does not name it
+	DefaultResponseSchemaName = "answer"
+	// MaxResponseSchemaBytes bounds the size of a response schema
+	MaxResponseSchemaBytes = 32 * 1024
+	// StructuredOutputMaxRetries is how often an answer not matching the schema is requested again
+	StructuredOutputMaxRetries = 2
+)
+
+var responseSchemaNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ResponseSchema requests answers as JSON objects conforming to a JSON schema
+type ResponseSchema struct {
+	// Name of the schema, letters, digits, "_" and "-" (optional)
+	Name string `json:"name,omitempty"`
+	// JSON schema the answer must conform to, its root must be an object
+	Schema json.RawMessage `json:"schema"`
+}
+
+// Validate checks that the schema is an object schema the answer can be validated against
+func (s *ResponseSchema) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.Name != "" && !responseSchemaNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("schema name %q must consist of at most 64 letters, digits, '_' or '-'", s.Name)
+	}
+	if len(s.Schema) == 0 {
+		return errors.New("schema is required")
+	}
+	if len(s.Schema) > MaxResponseSchemaBytes {
+		return fmt.Errorf("schema exceeds %d bytes", MaxResponseSchemaBytes)
+	}
+	definition, err := s.definition()
+	if err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	if definition.Type != jsonschema.Object {
+		return errors.New("schema root must be of type object")
+	}
+	return validateSchemaDefinition(definition, "#")
+}
+
+// SchemaName returns the name of the schema sent to models
+func (s *ResponseSchema) SchemaName() string {
+	if s.Name == "" {
+		return DefaultResponseSchemaName
+	}
+	return s.Name
+}
+
+// Parse extracts the JSON object from a model answer and validates it against the schema,
+// answers wrapped in markdown code fences or surrounded by text are accepted
+func (s *ResponseSchema) Parse(answer string) (StructuredOutput, error) {
+	definition, err := s.definition()
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	content := extractJSONObject(answer)
+	if content == "" {
+		return nil, errors.New("answer contains no JSON object")
+	}
+	var data interface{}
+	if err := json.Unmarshal([]byte(content), &data); err != nil {
+		return nil, fmt.Errorf("answer is not valid JSON: %w", err)
+	}
+	if !jsonschema.Validate(*definition, data, jsonschema.WithDefs(jsonschema.CollectDefs(*definition))) {
+		return nil, errors.New("answer does not match the schema")
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(content)); err != nil {
+		return nil, err
+	}
+	return StructuredOutput(compacted.Bytes()), nil
+}
+
+// definition decodes the schema
+func (s *ResponseSchema) definition() (*jsonschema.Definition, error) {
+	var definition jsonschema.Definition
+	if err := json.Unmarshal(s.Schema, &definition); err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// validateSchemaDefinition rejects schema parts the answer can not be validated against
+func validateSchemaDefinition(definition *jsonschema.Definition, path string) error {
+	switch definition.Type {
+	case jsonschema.Object:
+		for name, property := range definition.Properties {
+			if err := validateSchemaDefinition(&property, path+"/properties/"+name); err != nil {
+				return err
+			}
+		}
+	case jsonschema.Array:
+		if definition.Items == nil {
+			return fmt.Errorf("array schema %s must define items", path)
+		}
+		return validateSchemaDefinition(definition.Items, path+"/items")
+	case jsonschema.String, jsonschema.Number, jsonschema.Integer, jsonschema.Boolean, jsonschema.Null:
+	case "":
+		if definition.Ref == "" {
+			return fmt.Errorf("schema %s must define a type", path)
+		}
+	default:
+		return fmt.Errorf("schema %s has unsupported type %q", path, definition.Type)
+	}
+	for name, def := range definition.Defs {
+		if err := validateSchemaDefinition(&def, path+"/$defs/"+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractJSONObject returns the outermost JSON object of the answer
+func extractJSONObject(answer string) string {
+	start := strings.Index(answer, "{")
+	end := strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return answer[start : end+1]
+}
+
+// StructuredOutput is an answer object conforming to the response schema of the request
+type StructuredOutput json.RawMessage
+
+// MarshalJSON returns the object itself
+func (o StructuredOutput) MarshalJSON() ([]byte, error) {
+	if len(o) == 0 {
+		return []byte("null"), nil
+	}
+	return o, nil
+}
+
+// UnmarshalJSON stores a copy of the object
+func (o *StructuredOutput) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = nil
+		return nil
+	}
+	*o = append((*o)[:0], data...)
+	return nil
+}
+
+// Value implements the driver.Valuer interface, used to convert StructuredOutput to database value
+func (o StructuredOutput) Value() (driver.Value, error) {
+	if len(o) == 0 {
+		return nil, nil
+	}
+	return []byte(o), nil
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to StructuredOutput
+func (o *StructuredOutput) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*o = append(StructuredOutput(nil), v...)
+	case string:
+		*o = StructuredOutput(v)
+	default:
+		*o = nil
+	}
+	return nil
+}
+
+// Citation is a knowledge chunk an answer is based on
+type Citation struct {
+	ChunkID        string `json:"chunk_id"`
+	KnowledgeID    string `json:"knowledge_id"`
+	KnowledgeTitle string `json:"knowledge_title"`
+	// Character offsets of the chunk in the knowledge (Chunk.StartAt/EndAt)
+	StartAt int `json:"start_at"`
+	EndAt   int `json:"end_at"`
+}
+
+// CitationsFromReferences returns the citations of the references, one per chunk
+func CitationsFromReferences(references []*SearchResult) []Citation {
+	citations := make([]Citation, 0, len(references))
+	seen := make(map[string]bool, len(references))
+	for _, ref := range references {
+		if ref == nil || seen[ref.ID] {
+			continue
+		}
+		seen[ref.ID] = true
+		citations = append(citations, CitationFromReference(ref))
+	}
+	return citations
+}
+
+// CitationFromReference returns the citation of a referenced chunk
+func CitationFromReference(ref *SearchResult) Citation {
+	return Citation{
+		ChunkID:        ref.ID,
+		KnowledgeID:    ref.KnowledgeID,
+		KnowledgeTitle: ref.KnowledgeTitle,
+		StartAt:        ref.StartAt,
+		EndAt:          ref.EndAt,
+	}
+}
@@ -28,6 +28,8 @@ type Tenant struct {
 	StorageQuota int64 `yaml:"storage_quota"       json:"storage_quota"       gorm:"default:10737418240"`
 	// Storage used (Bytes)
 	StorageUsed int64 `yaml:"storage_used"        json:"storage_used"        gorm:"default:0"`
+	// Monthly token quota, 0 means unlimited
+	TokenQuota int64 `yaml:"token_quota"         json:"token_quota"         gorm:"default:0"`
 	// Global Agent configuration for this tenant (default for all sessions)
 	AgentConfig *AgentConfig `yaml:"agent_config"        json:"agent_config"        gorm:"type:jsonb"`
 	// Global Context configuration for this tenant (default for all sessions)
@@ -97,6 +99,24 @@ type ConversationConfig struct {
 	EnableRewrite        bool    `json:"enable_rewrite"`
 	EnableQueryExpansion bool    `json:"enable_query_expansion"`
 
+	// HyDE and multi-query retrieval
+	EnableHyDE       bool `json:"enable_hyde"`
+	EnableMultiQuery bool `json:"enable_multi_query"`
+	MultiQueryCount  int  `json:"multi_query_count,omitempty"`
+
+	// Semantic answer cache
+	EnableAnswerCache    bool    `json:"enable_answer_cache"`
+	AnswerCacheThreshold float64 `json:"answer_cache_threshold,omitempty"`
+
+	// FusionConfig controls score fusion in hybrid search, overriding knowledge base settings
+	FusionConfig *FusionConfig `json:"fusion_config,omitempty"`
+
+	// Groundedness checks answers against the retrieved chunks
+	Groundedness *GroundednessConfig `json:"groundedness,omitempty"`
+
+	// FeedbackRerank adjusts rerank scores by the feedback on the chunks
+	FeedbackRerank *FeedbackRerankConfig `json:"feedback_rerank,omitempty"`
+
 	// Model configuration
 	SummaryModelID string `json:"summary_model_id"`
 	RerankModelID  string `json:"rerank_model_id"`
@@ -109,6 +129,11 @@ type ConversationConfig struct {
 	// Rewrite prompts
 	RewritePromptSystem string `json:"rewrite_prompt_system"`
 	RewritePromptUser   string `json:"rewrite_prompt_user"`
+
+	// Pipelines declares tenant chat pipelines, overriding configured pipelines of the same name
+	Pipelines []*PipelineDefinition `json:"pipelines,omitempty"`
+	// DefaultPipeline is the pipeline used by sessions that do not select one
+	DefaultPipeline string `json:"default_pipeline,omitempty"`
 }
 
 // Value implements the driver.Valuer interface, used to convert ConversationConfig to database value
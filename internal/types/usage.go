@@ -0,0 +1,195 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// UsageStage identifies the step a model was called in, chat pipeline stages use their event type
+type UsageStage string
+
+const (
+	// UsageStageOther is recorded for model calls outside a known stage
+	UsageStageOther UsageStage = "other"
+	// UsageStageAgent is recorded for model calls of the agent, including its tools
+	UsageStageAgent UsageStage = "agent"
+	// UsageStageSubAgent is recorded for model calls of sub-agents running delegated tasks
+	UsageStageSubAgent UsageStage = "sub_agent"
+	// UsageStageTitleGeneration is recorded for session title generation
+	UsageStageTitleGeneration UsageStage = "title_generation"
+	// UsageStageIndexing is recorded for embedding knowledge chunks
+	UsageStageIndexing UsageStage = "indexing"
+	// UsageStageSummaryGeneration is recorded for knowledge summary generation
+	UsageStageSummaryGeneration UsageStage = "summary_generation"
+	// UsageStageQuestionGeneration is recorded for chunk question generation
+	UsageStageQuestionGeneration UsageStage = "question_generation"
+	// UsageStageSearch is recorded for knowledge search without generation
+	UsageStageSearch UsageStage = "search"
+)
+
+// UsageScope attributes model calls to a session and knowledge base, it is carried by the context
+type UsageScope struct {
+	SessionID       string
+	KnowledgeBaseID string
+	Stage           UsageStage
+}
+
+// WithUsageScope returns a context attributing model calls to the session and knowledge base,
+// the stage of the parent context is kept
+func WithUsageScope(ctx context.Context, sessionID string, knowledgeBaseID string) context.Context {
+	scope := UsageScopeFromContext(ctx)
+	scope.SessionID = sessionID
+	scope.KnowledgeBaseID = knowledgeBaseID
+	return context.WithValue(ctx, UsageScopeContextKey, scope)
+}
+
+// WithUsageStage returns a context attributing model calls to the stage
+func WithUsageStage(ctx context.Context, stage UsageStage) context.Context {
+	scope := UsageScopeFromContext(ctx)
+	scope.Stage = stage
+	return context.WithValue(ctx, UsageScopeContextKey, scope)
+}
+
+// UsageScopeFromContext returns the usage scope of the context
+func UsageScopeFromContext(ctx context.Context) UsageScope {
+	scope, _ := ctx.Value(UsageScopeContextKey).(UsageScope)
+	if scope.Stage == "" {
+		scope.Stage = UsageStageOther
+	}
+	return scope
+}
+
+// UsageRecord is an entry of the usage ledger, one per model call
+type UsageRecord struct {
+	// ID
+	ID uint64 `json:"id"                gorm:"primaryKey;autoIncrement"`
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id"         gorm:"index"`
+	// Session the call was made for, empty for knowledge processing
+	SessionID string `json:"session_id"        gorm:"type:varchar(36)"`
+	// Knowledge base the call was made for
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	// Model ID
+	ModelID string `json:"model_id"          gorm:"type:varchar(64)"`
+	// Model name, prices are configured by model name
+	ModelName string `json:"model_name"        gorm:"type:varchar(255)"`
+	// Model type
+	ModelType ModelType `json:"model_type"        gorm:"type:varchar(32)"`
+	// Stage the call was made in
+	Stage UsageStage `json:"stage"             gorm:"type:varchar(64)"`
+	// Prompt tokens reported by the model
+	PromptTokens int64 `json:"prompt_tokens"`
+	// Completion tokens reported by the model
+	CompletionTokens int64 `json:"completion_tokens"`
+	// Total tokens reported by the model
+	TotalTokens int64 `json:"total_tokens"`
+	// Number of calls, embedding and rerank usage is counted in calls
+	CallCount int64 `json:"call_count"`
+	// Cost according to the configured model prices
+	Cost float64 `json:"cost"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UsageDimension is a dimension usage can be grouped by
+type UsageDimension string
+
+const (
+	UsageDimensionSession       UsageDimension = "session"
+	UsageDimensionKnowledgeBase UsageDimension = "knowledge_base"
+	UsageDimensionModel         UsageDimension = "model"
+	UsageDimensionModelType     UsageDimension = "model_type"
+	UsageDimensionStage         UsageDimension = "stage"
+)
+
+// UsageInterval is the time bucket usage can be grouped by
+type UsageInterval string
+
+const (
+	UsageIntervalHour  UsageInterval = "hour"
+	UsageIntervalDay   UsageInterval = "day"
+	UsageIntervalMonth UsageInterval = "month"
+)
+
+// UsageQuery selects and groups the usage ledger of a tenant
+type UsageQuery struct {
+	// StartTime is inclusive, EndTime is exclusive
+	StartTime time.Time
+	EndTime   time.Time
+	// GroupBy lists the dimensions usage is grouped by, usage is totalled without it
+	GroupBy []UsageDimension
+	// Interval additionally groups usage into time buckets
+	Interval UsageInterval
+	// Optional filters
+	SessionID       string
+	KnowledgeBaseID string
+	ModelID         string
+	Stage           UsageStage
+}
+
+// Validate checks the dimensions and interval of the query
+func (q *UsageQuery) Validate() error {
+	if !q.EndTime.IsZero() && !q.EndTime.After(q.StartTime) {
+		return fmt.Errorf("end_time must be after start_time")
+	}
+	for _, dimension := range q.GroupBy {
+		switch dimension {
+		case UsageDimensionSession, UsageDimensionKnowledgeBase, UsageDimensionModel,
+			UsageDimensionModelType, UsageDimensionStage:
+		default:
+			return fmt.Errorf("unsupported group_by dimension: %s", dimension)
+		}
+	}
+	switch q.Interval {
+	case "", UsageIntervalHour, UsageIntervalDay, UsageIntervalMonth:
+	default:
+		return fmt.Errorf("unsupported interval: %s", q.Interval)
+	}
+	return nil
+}
+
+// UsageSummary is the usage of a group, dimensions the query is not grouped by are empty
+type UsageSummary struct {
+	Period           *time.Time `json:"period,omitempty"`
+	SessionID        string     `json:"session_id,omitempty"`
+	KnowledgeBaseID  string     `json:"knowledge_base_id,omitempty"`
+	ModelID          string     `json:"model_id,omitempty"`
+	ModelName        string     `json:"model_name,omitempty"`
+	ModelType        ModelType  `json:"model_type,omitempty"`
+	Stage            UsageStage `json:"stage,omitempty"`
+	PromptTokens     int64      `json:"prompt_tokens"`
+	CompletionTokens int64      `json:"completion_tokens"`
+	TotalTokens      int64      `json:"total_tokens"`
+	CallCount        int64      `json:"call_count"`
+	Cost             float64    `json:"cost"`
+}
+
+// UsageReport is the result of a usage query
+type UsageReport struct {
+	StartTime time.Time       `json:"start_time"`
+	EndTime   time.Time       `json:"end_time"`
+	Currency  string          `json:"currency"`
+	Items     []*UsageSummary `json:"items"`
+	Total     UsageSummary    `json:"total"`
+}
+
+// TokenQuotaStatus is the token usage of a tenant in the current month
+type TokenQuotaStatus struct {
+	// TokenQuota is the monthly token quota, 0 means unlimited
+	TokenQuota  int64     `json:"token_quota"`
+	TokensUsed  int64     `json:"tokens_used"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// Exceeded reports whether the quota is used up
+func (s *TokenQuotaStatus) Exceeded() bool {
+	return s.TokenQuota > 0 && s.TokensUsed >= s.TokenQuota
+}
+
+// UsagePeriod returns the calendar month containing t, token quotas are reset monthly
+func UsagePeriod(t time.Time) (time.Time, time.Time) {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
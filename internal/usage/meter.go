@@ -0,0 +1,162 @@
+// Package usage meters model calls into the usage ledger
+package usage
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// meteredChat records the token usage of every chat call
+type meteredChat struct {
+	inner     chat.Chat
+	modelType types.ModelType
+	recorder  interfaces.UsageService
+}
+
+// WrapChat returns a chat model recording its token usage with the recorder
+func WrapChat(c chat.Chat, modelType types.ModelType, recorder interfaces.UsageService) chat.Chat {
+	return &meteredChat{inner: c, modelType: modelType, recorder: recorder}
+}
+
+// Chat records the usage reported in the response
+func (c *meteredChat) Chat(ctx context.Context,
+	messages []chat.Message, opts *chat.ChatOptions,
+) (*types.ChatResponse, error) {
+	resp, err := c.inner.Chat(ctx, messages, opts)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	c.record(ctx, &types.TokenUsage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	})
+	return resp, nil
+}
+
+// SupportsStructuredOutput reports whether the wrapped model supports structured output
+func (c *meteredChat) SupportsStructuredOutput() bool {
+	return chat.SupportsStructuredOutput(c.inner)
+}
+
+// ChatStream forwards the stream and records the usage reported by its last response once it is closed
+func (c *meteredChat) ChatStream(ctx context.Context,
+	messages []chat.Message, opts *chat.ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	stream, err := c.inner.ChatStream(ctx, messages, opts)
+	if err != nil || stream == nil {
+		return stream, err
+	}
+	out := make(chan types.StreamResponse)
+	go func() {
+		defer close(out)
+		var usage *types.TokenUsage
+		for resp := range stream {
+			if resp.Usage != nil {
+				usage = resp.Usage
+			}
+			out <- resp
+		}
+		c.record(ctx, usage)
+	}()
+	return out, nil
+}
+
+// GetModelName returns the model name
+func (c *meteredChat) GetModelName() string {
+	return c.inner.GetModelName()
+}
+
+// GetModelID returns the model ID
+func (c *meteredChat) GetModelID() string {
+	return c.inner.GetModelID()
+}
+
+// record adds the call to the ledger, calls of models not reporting usage are counted without tokens
+func (c *meteredChat) record(ctx context.Context, usage *types.TokenUsage) {
+	record := &types.UsageRecord{
+		ModelID:   c.inner.GetModelID(),
+		ModelName: c.inner.GetModelName(),
+		ModelType: c.modelType,
+		CallCount: 1,
+	}
+	if usage != nil {
+		record.PromptTokens = int64(usage.PromptTokens)
+		record.CompletionTokens = int64(usage.CompletionTokens)
+		record.TotalTokens = int64(usage.TotalTokens)
+		if record.TotalTokens == 0 {
+			record.TotalTokens = record.PromptTokens + record.CompletionTokens
+		}
+	}
+	c.recorder.Record(ctx, record)
+}
+
+// meteredEmbedder counts the embedding calls, BatchEmbedWithPool is metered through BatchEmbed
+type meteredEmbedder struct {
+	embedding.Embedder
+	recorder interfaces.UsageService
+}
+
+// WrapEmbedder returns an embedder recording its calls with the recorder
+func WrapEmbedder(e embedding.Embedder, recorder interfaces.UsageService) embedding.Embedder {
+	return &meteredEmbedder{Embedder: e, recorder: recorder}
+}
+
+// Embed records the call
+func (e *meteredEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector, err := e.Embedder.Embed(ctx, text)
+	if err == nil {
+		e.record(ctx)
+	}
+	return vector, err
+}
+
+// BatchEmbed records the call
+func (e *meteredEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := e.Embedder.BatchEmbed(ctx, texts)
+	if err == nil {
+		e.record(ctx)
+	}
+	return vectors, err
+}
+
+func (e *meteredEmbedder) record(ctx context.Context) {
+	e.recorder.Record(ctx, &types.UsageRecord{
+		ModelID:   e.GetModelID(),
+		ModelName: e.GetModelName(),
+		ModelType: types.ModelTypeEmbedding,
+		CallCount: 1,
+	})
+}
+
+// meteredReranker counts the rerank calls
+type meteredReranker struct {
+	rerank.Reranker
+	recorder interfaces.UsageService
+}
+
+// WrapReranker returns a reranker recording its calls with the recorder
+func WrapReranker(r rerank.Reranker, recorder interfaces.UsageService) rerank.Reranker {
+	return &meteredReranker{Reranker: r, recorder: recorder}
+}
+
+// Rerank records the call
+func (r *meteredReranker) Rerank(ctx context.Context,
+	query string, documents []string,
+) ([]rerank.RankResult, error) {
+	results, err := r.Reranker.Rerank(ctx, query, documents)
+	if err == nil {
+		r.recorder.Record(ctx, &types.UsageRecord{
+			ModelID:   r.GetModelID(),
+			ModelName: r.GetModelName(),
+			ModelType: types.ModelTypeRerank,
+			CallCount: 1,
+		})
+	}
+	return results, err
+}
@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+type fakeChat struct{}
+
+func (fakeChat) Chat(context.Context, []chat.Message, *chat.ChatOptions) (*types.ChatResponse, error) {
+	resp := &types.ChatResponse{Content: "answer"}
+	resp.Usage.PromptTokens = 100
+	resp.Usage.CompletionTokens = 20
+	resp.Usage.TotalTokens = 120
+	return resp, nil
+}
+
+func (fakeChat) ChatStream(context.Context, []chat.Message, *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	stream := make(chan types.StreamResponse, 3)
+	stream <- types.StreamResponse{Content: "a"}
+	stream <- types.StreamResponse{Content: "b"}
+	stream <- types.StreamResponse{Done: true, Usage: &types.TokenUsage{PromptTokens: 1000, CompletionTokens: 500}}
+	close(stream)
+	return stream, nil
+}
+
+func (fakeChat) GetModelName() string { return "qwen-plus" }
+func (fakeChat) GetModelID() string   { return "m1" }
+
+type fakeRecorder struct {
+	records []*types.UsageRecord
+}
+
+func (r *fakeRecorder) Record(_ context.Context, record *types.UsageRecord) {
+	r.records = append(r.records, record)
+}
+
+func (r *fakeRecorder) GetUsage(context.Context, *types.UsageQuery) (*types.UsageReport, error) {
+	return nil, nil
+}
+
+func (r *fakeRecorder) GetTokenQuotaStatus(context.Context) (*types.TokenQuotaStatus, error) {
+	return nil, nil
+}
+
+func (r *fakeRecorder) CheckTokenQuota(context.Context) error { return nil }
+
+func TestMeteredChat(t *testing.T) {
+	ctx := context.Background()
+	recorder := &fakeRecorder{}
+	metered := WrapChat(fakeChat{}, types.ModelTypeKnowledgeQA, recorder)
+
+	if _, err := metered.Chat(ctx, nil, nil); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	stream, err := metered.ChatStream(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	content := ""
+	for resp := range stream {
+		content += resp.Content
+	}
+	if content != "ab" {
+		t.Errorf("stream not forwarded, got %q", content)
+	}
+
+	if len(recorder.records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(recorder.records))
+	}
+	if r := recorder.records[0]; r.TotalTokens != 120 || r.ModelID != "m1" || r.CallCount != 1 {
+		t.Errorf("unexpected chat record %+v", r)
+	}
+	streamed := recorder.records[1]
+	if streamed.PromptTokens != 1000 || streamed.CompletionTokens != 500 || streamed.TotalTokens != 1500 {
+		t.Errorf("unexpected stream record %+v", streamed)
+	}
+
+	pricing := NewPricing(&config.UsageConfig{Prices: []config.ModelPrice{
+		{ModelName: "qwen-plus", PromptPrice: 0.002, CompletionPrice: 0.006, CallPrice: 0.001},
+	}})
+	if cost := pricing.Cost(streamed); math.Abs(cost-0.006) > 1e-9 {
+		t.Errorf("expected cost 0.006, got %v", cost)
+	}
+	if pricing.Currency() != DefaultCurrency {
+		t.Errorf("unexpected currency %s", pricing.Currency())
+	}
+}
@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// DefaultCurrency is the currency of costs when none is configured
+const DefaultCurrency = "CNY"
+
+// Pricing prices usage records by the configured model prices
+type Pricing struct {
+	currency string
+	prices   map[string]config.ModelPrice
+}
+
+// NewPricing creates the pricing of the usage configuration, cfg may be nil
+func NewPricing(cfg *config.UsageConfig) *Pricing {
+	p := &Pricing{currency: DefaultCurrency, prices: make(map[string]config.ModelPrice)}
+	if cfg == nil {
+		return p
+	}
+	if cfg.Currency != "" {
+		p.currency = cfg.Currency
+	}
+	for _, price := range cfg.Prices {
+		p.prices[price.ModelName] = price
+	}
+	return p
+}
+
+// Currency returns the currency of the prices
+func (p *Pricing) Currency() string {
+	return p.currency
+}
+
+// Cost returns the cost of the record, models without a configured price are free
+func (p *Pricing) Cost(record *types.UsageRecord) float64 {
+	price, ok := p.prices[record.ModelName]
+	if !ok {
+		return 0
+	}
+	return float64(record.PromptTokens)/1000*price.PromptPrice +
+		float64(record.CompletionTokens)/1000*price.CompletionPrice +
+		float64(record.CallCount)*price.CallPrice
+}
@@ -0,0 +1,86 @@
+package utils
+
+// DefaultSeparators are the separators SplitText prefers to cut after when none are given
+var DefaultSeparators = []string{"\n\n", "\n", "。", "！", "？", "；", ". ", "! ", "? ", "; "}
+
+// TextSpan is a piece of a split text, Start and End are rune offsets into the text
+type TextSpan struct {
+	Content string
+	Start   int
+	End     int
+}
+
+// SplitText splits text into pieces of at most size runes, preferring to cut right after a separator.
+// Consecutive pieces share up to overlap runes. A size of zero or less returns the text as one piece.
+func SplitText(text string, size, overlap int, separators []string) []TextSpan {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return nil
+	}
+	if size <= 0 || len(runes) <= size {
+		return []TextSpan{{Content: text, Start: 0, End: len(runes)}}
+	}
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+	seps := make([][]rune, 0, len(separators))
+	for _, sep := range separators {
+		if sep != "" {
+			seps = append(seps, []rune(sep))
+		}
+	}
+
+	// Cut the text into segments that end at a separator and are no longer than size
+	var segments [][2]int
+	start := 0
+	for i := 0; i < len(runes); {
+		if n := matchSeparator(runes, i, seps); n > 0 && i+n-start <= size {
+			i += n
+			segments = append(segments, [2]int{start, i})
+			start = i
+			continue
+		}
+		i++
+		if i-start == size {
+			segments = append(segments, [2]int{start, i})
+			start = i
+		}
+	}
+	if start < len(runes) {
+		segments = append(segments, [2]int{start, len(runes)})
+	}
+
+	// Pack consecutive segments into pieces, starting each piece with the segments that fit in the overlap
+	var spans []TextSpan
+	for first := 0; first < len(segments); {
+		last := first
+		for last+1 < len(segments) && segments[last+1][1]-segments[first][0] <= size {
+			last++
+		}
+		s, e := segments[first][0], segments[last][1]
+		spans = append(spans, TextSpan{Content: string(runes[s:e]), Start: s, End: e})
+		if last == len(segments)-1 {
+			break
+		}
+		next := last + 1
+		for next-1 > first && e-segments[next-1][0] <= overlap &&
+			segments[last+1][1]-segments[next-1][0] <= size {
+			next--
+		}
+		first = next
+	}
+	return spans
+}
+
+// matchSeparator returns the length of the first separator found at position i, or 0
+func matchSeparator(runes []rune, i int, seps [][]rune) int {
+	for _, sep := range seps {
+		if i+len(sep) > len(runes) {
+			continue
+		}
+		if string(runes[i:i+len(sep)]) == string(sep) {
+			return len(sep)
+		}
+	}
+	return 0
+}
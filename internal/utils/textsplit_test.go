@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		size    int
+		overlap int
+		want    []TextSpan
+	}{
+		{
+			name: "fits",
+			text: "短文本。",
+			size: 10,
+			want: []TextSpan{{Content: "短文本。", Start: 0, End: 4}},
+		},
+		{
+			name:    "separators with overlap",
+			text:    "aaaa。bbbb。cccc。dddd。",
+			size:    10,
+			overlap: 5,
+			want: []TextSpan{
+				{Content: "aaaa。bbbb。", Start: 0, End: 10},
+				{Content: "bbbb。cccc。", Start: 5, End: 15},
+				{Content: "cccc。dddd。", Start: 10, End: 20},
+			},
+		},
+		{
+			name: "hard cut",
+			text: "abcdefghijklmnopqrstuvwxyz",
+			size: 10,
+			want: []TextSpan{
+				{Content: "abcdefghij", Start: 0, End: 10},
+				{Content: "klmnopqrst", Start: 10, End: 20},
+				{Content: "uvwxyz", Start: 20, End: 26},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitText(tt.text, tt.size, tt.overlap, nil); !slices.Equal(got, tt.want) {
+				t.Errorf("SplitText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
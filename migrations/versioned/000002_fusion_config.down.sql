@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE knowledge_bases
+    DROP COLUMN IF EXISTS fusion_config;
+
+COMMIT;
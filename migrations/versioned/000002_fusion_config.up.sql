@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE knowledge_bases
+    ADD COLUMN IF NOT EXISTS fusion_config JSONB NULL;
+
+COMMENT ON COLUMN knowledge_bases.fusion_config IS 'Score fusion configuration for hybrid search';
+
+COMMIT;
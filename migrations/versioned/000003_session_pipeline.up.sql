@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS pipeline VARCHAR(64) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN sessions.pipeline IS 'Chat pipeline used by knowledge QA, empty for the tenant or system default';
+
+COMMIT;
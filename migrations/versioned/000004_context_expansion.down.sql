@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE knowledge_bases
+    DROP COLUMN IF EXISTS context_expansion_config;
+
+COMMIT;
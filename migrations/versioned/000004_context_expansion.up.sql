@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE knowledge_bases
+    ADD COLUMN IF NOT EXISTS context_expansion_config JSONB NULL;
+
+COMMENT ON COLUMN knowledge_bases.context_expansion_config IS 'Expansion of retrieved chunks to neighbouring or parent chunks';
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_chunks_parent_type;
+
+-- Child chunks are only retrievable in hierarchical mode, knowledge bases using it must be rechunked
+UPDATE knowledge_bases
+SET chunking_config = (chunking_config::jsonb - 'mode' - 'parent_chunk_size')
+WHERE chunking_config::jsonb ? 'mode';
+
+COMMIT;
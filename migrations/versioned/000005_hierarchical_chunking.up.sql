@@ -0,0 +1,13 @@
+BEGIN;
+
+-- Existing knowledge bases keep flat chunking, hierarchical chunking is opted in per knowledge base
+-- and rechunks the processed knowledge in the background
+UPDATE knowledge_bases
+SET chunking_config = jsonb_set(chunking_config::jsonb, '{mode}', '"flat"')
+WHERE NOT (chunking_config::jsonb ? 'mode');
+
+CREATE INDEX IF NOT EXISTS idx_chunks_parent_type ON chunks(parent_chunk_id, chunk_type);
+
+COMMENT ON COLUMN knowledge_bases.chunking_config IS 'Document splitting configuration, mode is flat or hierarchical (parent/child chunks)';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS token_quota;
+
+DROP TABLE IF EXISTS usage_records;
+
+COMMIT;
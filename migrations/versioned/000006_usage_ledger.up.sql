@@ -0,0 +1,33 @@
+BEGIN;
+
+-- Usage ledger, one record per model call
+CREATE TABLE IF NOT EXISTS usage_records (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    model_id VARCHAR(64) NOT NULL DEFAULT '',
+    model_name VARCHAR(255) NOT NULL DEFAULT '',
+    model_type VARCHAR(32) NOT NULL DEFAULT '',
+    stage VARCHAR(64) NOT NULL DEFAULT '',
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    total_tokens BIGINT NOT NULL DEFAULT 0,
+    call_count BIGINT NOT NULL DEFAULT 0,
+    cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_created
+    ON usage_records(tenant_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_tenant_session
+    ON usage_records(tenant_id, session_id);
+
+-- Monthly token quota per tenant, 0 means unlimited
+ALTER TABLE tenants
+    ADD COLUMN IF NOT EXISTS token_quota BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN tenants.token_quota IS 'Monthly token quota of the tenant, 0 means unlimited';
+
+COMMIT;
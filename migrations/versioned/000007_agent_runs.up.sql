@@ -0,0 +1,26 @@
+BEGIN;
+
+-- Agent runs, checkpointed after every round so interrupted runs can be resumed
+CREATE TABLE IF NOT EXISTS agent_runs (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    status VARCHAR(32) NOT NULL DEFAULT 'running',
+    current_round INTEGER NOT NULL DEFAULT 0,
+    checkpoint JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_runs_tenant_status
+    ON agent_runs(tenant_id, status);
+
+CREATE INDEX IF NOT EXISTS idx_agent_runs_message
+    ON agent_runs(message_id);
+
+COMMENT ON COLUMN agent_runs.checkpoint IS 'Agent config, state and LLM messages after the last completed round';
+
+COMMIT;
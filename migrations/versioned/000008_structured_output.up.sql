@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS structured_output JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN messages.structured_output IS 'Answer object conforming to the response schema of the request';
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS citation_map JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN messages.citation_map IS 'Inline citation markers of the answer mapped to the cited chunks';
+
+COMMIT;
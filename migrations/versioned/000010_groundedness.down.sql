@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS groundedness;
+
+COMMIT;
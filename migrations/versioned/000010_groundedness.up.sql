@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS groundedness JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN messages.groundedness IS 'Groundedness of the answer against the knowledge references';
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_messages_parent_id;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS parent_id;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS active_message_id;
+
+COMMIT;
@@ -0,0 +1,39 @@
+BEGIN;
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS parent_id VARCHAR(36) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN messages.parent_id IS 'Message this message follows in its branch, empty for the first message of the session';
+
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id ON messages (parent_id);
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS active_message_id VARCHAR(36) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN sessions.active_message_id IS 'Last message of the active branch of the session';
+
+-- Existing conversations are linear, every message follows the previous one of its session
+UPDATE messages m
+SET parent_id = linked.parent_id
+FROM (
+    SELECT id,
+           COALESCE(LAG(id) OVER (
+               PARTITION BY session_id
+               ORDER BY created_at, CASE WHEN role = 'user' THEN 0 ELSE 1 END
+           ), '') AS parent_id
+    FROM messages
+    WHERE deleted_at IS NULL
+) linked
+WHERE m.id = linked.id;
+
+UPDATE sessions s
+SET active_message_id = last_message.id
+FROM (
+    SELECT DISTINCT ON (session_id) session_id, id
+    FROM messages
+    WHERE deleted_at IS NULL
+    ORDER BY session_id, created_at DESC, CASE WHEN role = 'user' THEN 1 ELSE 0 END
+) last_message
+WHERE s.id = last_message.session_id;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS feedback_chunks;
+
+DROP TABLE IF EXISTS message_feedbacks;
+
+COMMIT;
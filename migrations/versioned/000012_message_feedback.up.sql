@@ -0,0 +1,47 @@
+BEGIN;
+
+-- Feedback of users on assistant messages, one per message
+CREATE TABLE IF NOT EXISTS message_feedbacks (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL,
+    rating VARCHAR(16) NOT NULL,
+    correction TEXT NOT NULL DEFAULT '',
+    useful_chunk_ids JSONB DEFAULT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    answer TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_message_feedbacks_message_id
+    ON message_feedbacks(message_id);
+
+CREATE INDEX IF NOT EXISTS idx_message_feedbacks_tenant_id
+    ON message_feedbacks(tenant_id);
+
+-- Votes of the feedback on the chunks referenced by the message
+CREATE TABLE IF NOT EXISTS feedback_chunks (
+    id BIGSERIAL PRIMARY KEY,
+    feedback_id VARCHAR(36) NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    chunk_id VARCHAR(36) NOT NULL,
+    vote INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_feedback_chunks_feedback_id
+    ON feedback_chunks(feedback_id);
+
+CREATE INDEX IF NOT EXISTS idx_feedback_chunks_tenant_chunk
+    ON feedback_chunks(tenant_id, chunk_id);
+
+CREATE INDEX IF NOT EXISTS idx_feedback_chunks_tenant_kb
+    ON feedback_chunks(tenant_id, knowledge_base_id);
+
+COMMENT ON COLUMN feedback_chunks.vote IS '1 for a useful chunk, -1 for a chunk of a bad answer, 0 otherwise';
+
+COMMIT;
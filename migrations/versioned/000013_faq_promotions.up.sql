@@ -0,0 +1,28 @@
+BEGIN;
+
+-- Audit trail of QA exchanges promoted into FAQ knowledge bases
+CREATE TABLE IF NOT EXISTS faq_promotions (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL,
+    entry_id VARCHAR(36) NOT NULL DEFAULT '',
+    action VARCHAR(16) NOT NULL,
+    question TEXT NOT NULL DEFAULT '',
+    answer TEXT NOT NULL DEFAULT '',
+    similarity DOUBLE PRECISION NOT NULL DEFAULT 0,
+    reviewer_id VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_faq_promotions_tenant_kb_created
+    ON faq_promotions(tenant_id, knowledge_base_id, created_at);
+
+-- A message is promoted into a knowledge base at most once
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_faq_promotions_tenant_kb_message
+    ON faq_promotions(tenant_id, knowledge_base_id, message_id);
+
+COMMENT ON COLUMN faq_promotions.action IS 'created for a new entry, merged for a similar question added to entry_id';
+
+COMMIT;
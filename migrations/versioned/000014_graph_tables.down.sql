@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS graph_relations;
+DROP TABLE IF EXISTS graph_nodes;
+
+COMMIT;
@@ -0,0 +1,44 @@
+BEGIN;
+
+-- Knowledge graph entities, used when GRAPH_DRIVER=postgres
+CREATE TABLE IF NOT EXISTS graph_nodes (
+    id BIGSERIAL PRIMARY KEY,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    chunks JSONB NOT NULL DEFAULT '[]'::jsonb,
+    attributes JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_nodes_namespace_name
+    ON graph_nodes(knowledge_base_id, knowledge_id, name);
+
+CREATE INDEX IF NOT EXISTS idx_graph_nodes_knowledge_id
+    ON graph_nodes(knowledge_id);
+
+-- Relationships between knowledge graph entities of the same knowledge
+CREATE TABLE IF NOT EXISTS graph_relations (
+    id BIGSERIAL PRIMARY KEY,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    source TEXT NOT NULL,
+    target TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_relations_namespace_edge
+    ON graph_relations(knowledge_base_id, knowledge_id, source, target, type);
+
+CREATE INDEX IF NOT EXISTS idx_graph_relations_knowledge_id
+    ON graph_relations(knowledge_id);
+
+CREATE INDEX IF NOT EXISTS idx_graph_relations_source
+    ON graph_relations(knowledge_base_id, source);
+
+CREATE INDEX IF NOT EXISTS idx_graph_relations_target
+    ON graph_relations(knowledge_base_id, target);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS graph_entity_aliases;
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+-- Aliases of knowledge graph entities, names extracted later are replaced by their canonical entity
+CREATE TABLE IF NOT EXISTS graph_entity_aliases (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    alias VARCHAR(512) NOT NULL,
+    canonical VARCHAR(512) NOT NULL,
+    source VARCHAR(16) NOT NULL,
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_entity_aliases_kb_alias
+    ON graph_entity_aliases(knowledge_base_id, alias);
+
+CREATE INDEX IF NOT EXISTS idx_graph_entity_aliases_kb_canonical
+    ON graph_entity_aliases(knowledge_base_id, canonical);
+
+COMMENT ON COLUMN graph_entity_aliases.source IS 'normalized, embedding, llm or manual, manual aliases are never overwritten by the resolution';
+
+COMMIT;
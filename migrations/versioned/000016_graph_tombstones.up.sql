@@ -0,0 +1,23 @@
+BEGIN;
+
+-- Knowledge graph entities and relations deleted through the API, dropped again when documents are re-extracted
+CREATE TABLE IF NOT EXISTS graph_tombstones (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    name VARCHAR(512) NOT NULL DEFAULT '',
+    source VARCHAR(512) NOT NULL DEFAULT '',
+    target VARCHAR(512) NOT NULL DEFAULT '',
+    type VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_graph_tombstones_tenant_id ON graph_tombstones(tenant_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_graph_tombstones_kb_element
+    ON graph_tombstones(knowledge_base_id, kind, name, source, target, type);
+
+COMMENT ON COLUMN graph_tombstones.kind IS 'entity or relation, entities use name and relations use source, target and type';
+
+COMMIT;